@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListAlerts
+// Takes query (user, vehicle, job, task, type, read, isAlerted, q, sort, limit, cursor, count) as arg, returns Alert list
+func (c *Client) ListAlerts(ctx context.Context, query url.Values) (*List[models.Alert], error) {
+	return getList[models.Alert](ctx, c, "/alerts", query)
+}
+
+// GetAlert
+// Takes alert id as arg, returns Alert
+func (c *Client) GetAlert(ctx context.Context, alertId int64) (*models.Alert, error) {
+	var alert models.Alert
+	err := c.doJSON(ctx, http.MethodGet, "/alerts/"+idStr(alertId), nil, nil, &alert)
+	return &alert, err
+}
+
+// MarkAlertRead
+// Takes alert id as arg, marks alert read, or unread if unread is true
+func (c *Client) MarkAlertRead(ctx context.Context, alertId int64, unread bool) error {
+	return c.doJSON(ctx, http.MethodPatch, "/alerts/"+idStr(alertId)+"/read", flag("unread", unread), nil, nil)
+}
+
+// BulkMarkRead
+// Takes BulkRequest of alert ids and unread as args, returns BulkResult, failed items are reported in it rather than as an error
+func (c *Client) BulkMarkRead(ctx context.Context, bulkReq models.BulkRequest, unread bool) (*models.BulkResult, error) {
+	return c.bulk(ctx, "/alerts/bulk/read", flag("unread", unread), bulkReq)
+}
+
+// CreateAlert
+// Takes NewAlert as arg, returns created Alert
+func (c *Client) CreateAlert(ctx context.Context, newAlert models.NewAlert) (*models.Alert, error) {
+	var alert models.Alert
+	err := c.doJSON(ctx, http.MethodPost, "/alerts/create", nil, newAlert, &alert)
+	return &alert, err
+}
+
+// EditAlert
+// Takes Alert as arg, returns updated Alert
+func (c *Client) EditAlert(ctx context.Context, alert models.Alert) (*models.Alert, error) {
+	var updatedAlert models.Alert
+	err := c.doJSON(ctx, http.MethodPost, "/alerts/edit", nil, alert, &updatedAlert)
+	return &updatedAlert, err
+}
+
+// PatchAlert
+// Takes alert id, JSON merge patch and If-Match ETag (empty to skip) as args, returns updated Alert
+func (c *Client) PatchAlert(ctx context.Context, alertId int64, patch any, ifMatch string) (*models.Alert, error) {
+	var updatedAlert models.Alert
+	err := c.patch(ctx, "/alerts/"+idStr(alertId), patch, ifMatch, &updatedAlert)
+	return &updatedAlert, err
+}
+
+// DeleteAlert
+// Takes alert id as arg, deletes alert
+func (c *Client) DeleteAlert(ctx context.Context, alertId int64) error {
+	return c.doJSON(ctx, http.MethodDelete, "/alerts/"+idStr(alertId), nil, nil, nil)
+}
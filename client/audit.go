@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListActivity
+// Takes query (actor, entity, action, vehicle, job, limit, cursor, count) as arg, admin only, returns AuditEvent list newest first
+func (c *Client) ListActivity(ctx context.Context, query url.Values) (*List[models.AuditEvent], error) {
+	return getList[models.AuditEvent](ctx, c, "/activity", query)
+}
+
+// ListJobActivity
+// Takes job id and query (entity, action, limit, cursor, count) as args, returns AuditEvent list of job and its tasks newest first
+func (c *Client) ListJobActivity(ctx context.Context, jobId int64, query url.Values) (*List[models.AuditEvent], error) {
+	return getList[models.AuditEvent](ctx, c, "/jobs/"+idStr(jobId)+"/activity", query)
+}
+
+// ListVehicleActivity
+// Takes vehicle id and query (entity, action, limit, cursor, count) as args, returns AuditEvent list of vehicle and everything on it newest first
+func (c *Client) ListVehicleActivity(ctx context.Context, vehicleId int64, query url.Values) (*List[models.AuditEvent], error) {
+	return getList[models.AuditEvent](ctx, c, "/vehicles/"+idStr(vehicleId)+"/activity", query)
+}
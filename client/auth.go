@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// Auth
+// Takes Credentials as arg, retrieves JWT and sets it as Client Token
+func (c *Client) Auth(ctx context.Context, creds models.Credentials) (*http.Cookie, error) {
+	var jwtCookie http.Cookie
+	err := c.doJSON(ctx, http.MethodPost, "/auth", nil, creds, &jwtCookie)
+	if err != nil {
+		return nil, err
+	}
+	c.Token = jwtCookie.Value
+	return &jwtCookie, nil
+}
+
+// Logout
+// Clears Client Token
+func (c *Client) Logout(ctx context.Context) error {
+	err := c.doJSON(ctx, http.MethodGet, "/logout", nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	c.Token = ""
+	return nil
+}
+
+// Verify
+// Returns nil if Client Token is valid
+func (c *Client) Verify(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodGet, "/verify", nil, nil, nil)
+}
+
+// Refresh
+// Refreshes JWT if it expires within 24 hours, or if force is true, sets it as Client Token, returns nil if not refreshed
+func (c *Client) Refresh(ctx context.Context, force bool) (*http.Cookie, error) {
+	res, err := c.do(ctx, http.MethodGet, "/refresh", flag("force", force), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	var jwtCookie http.Cookie
+	err = decode(res, &jwtCookie)
+	if err != nil {
+		return nil, err
+	}
+	c.Token = jwtCookie.Value
+	return &jwtCookie, nil
+}
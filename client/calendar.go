@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// CreateCalendarToken
+// Takes username as arg, returns new CalendarToken, replacing any existing one
+func (c *Client) CreateCalendarToken(ctx context.Context, username string) (*models.CalendarToken, error) {
+	var calendarToken models.CalendarToken
+	err := c.doJSON(ctx, http.MethodPost, "/users/"+url.PathEscape(username)+"/calendar", nil, nil, &calendarToken)
+	return &calendarToken, err
+}
+
+// DeleteCalendarToken
+// Takes username as arg, revokes calendar token
+func (c *Client) DeleteCalendarToken(ctx context.Context, username string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/users/"+url.PathEscape(username)+"/calendar", nil, nil, nil)
+}
+
+// GetCalendarFeed
+// Takes calendar token and query (vehicle, label) as args, returns iCalendar feed
+func (c *Client) GetCalendarFeed(ctx context.Context, token string, query url.Values) ([]byte, error) {
+	data, _, err := c.doBytes(ctx, http.MethodGet, "/calendar/"+url.PathEscape(token)+".ics", query)
+	return data, err
+}
@@ -0,0 +1,236 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/response"
+)
+
+// Client
+// Typed client for the WrenchTurn API, see openapi/openapi.json, Token is sent as Bearer token and is set by Auth
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// New
+// Takes API base url as arg, e.g. http://localhost:8080, returns Client
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Error
+// Returned for unexpected response statuses, Problem is decoded from problem+json bodies
+type Error struct {
+	StatusCode int
+	Problem    *response.Problem
+	Body       string
+}
+
+func (e *Error) Error() string {
+	if e.Problem != nil {
+		if len(e.Problem.Detail) > 0 {
+			return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Problem.Code, e.Problem.Detail)
+		}
+		return fmt.Sprintf("%d %s", e.StatusCode, e.Problem.Code)
+	}
+	return fmt.Sprintf("%d %s", e.StatusCode, strings.TrimSpace(e.Body))
+}
+
+// List
+// Page of list results, lists are only paginated when query has limit or cursor, NextCursor is nil on the last page
+type List[T any] struct {
+	Items      []*T
+	NextCursor *string
+	Total      *int64
+}
+
+// do sends request, returns response if its status is 2xx or one of ok, otherwise Error
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, contentType string, body io.Reader, ok ...int) (*http.Response, error) {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return c.send(req, ok...)
+}
+
+// send adds the token to req and sends it, returns response if its status is 2xx or one of ok, otherwise Error
+func (c *Client) send(req *http.Request, ok ...int) (*http.Response, error) {
+	if len(c.Token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	for _, status := range ok {
+		if res.StatusCode == status {
+			return res, nil
+		}
+	}
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+	apiErr := &Error{StatusCode: res.StatusCode, Body: string(data)}
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "application/problem+json") {
+		var problem response.Problem
+		if json.Unmarshal(data, &problem) == nil {
+			apiErr.Problem = &problem
+		}
+	}
+	return nil, apiErr
+}
+
+// doJSON sends in as JSON body if not nil, decodes response into out if not nil
+func (c *Client) doJSON(ctx context.Context, method string, path string, query url.Values, in any, out any) error {
+	var body io.Reader
+	contentType := ""
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+	res, err := c.do(ctx, method, path, query, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return decode(res, out)
+}
+
+// doBytes sends request, returns response body and content type
+func (c *Client) doBytes(ctx context.Context, method string, path string, query url.Values) ([]byte, string, error) {
+	res, err := c.do(ctx, method, path, query, "", nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	return data, res.Header.Get("Content-Type"), err
+}
+
+// patch sends patch as a JSON merge patch with If-Match if set, decodes response into out
+func (c *Client) patch(ctx context.Context, path string, patch any, ifMatch string, out any) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, c.BaseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	if len(ifMatch) > 0 {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	res, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return decode(res, out)
+}
+
+// upload sends body as is, decodes response into out, 422 responses are decoded too as they report invalid rows
+func (c *Client) upload(ctx context.Context, path string, query url.Values, contentType string, body io.Reader, out any) error {
+	res, err := c.do(ctx, http.MethodPost, path, query, contentType, body, http.StatusUnprocessableEntity)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return decode(res, out)
+}
+
+// bulk sends BulkRequest, decodes BulkResult, 422 responses are decoded too as they report the failed items
+func (c *Client) bulk(ctx context.Context, path string, query url.Values, bulkReq models.BulkRequest) (*models.BulkResult, error) {
+	data, err := json.Marshal(bulkReq)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.do(ctx, http.MethodPost, path, query, "application/json", bytes.NewReader(data), http.StatusUnprocessableEntity)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var result models.BulkResult
+	err = decode(res, &result)
+	return &result, err
+}
+
+// getList retrieves a list endpoint, decoding either a plain array or a paginated envelope
+func getList[T any](ctx context.Context, c *Client, path string, query url.Values) (*List[T], error) {
+	var raw json.RawMessage
+	err := c.doJSON(ctx, http.MethodGet, path, query, nil, &raw)
+	if err != nil {
+		return nil, err
+	}
+	list := &List[T]{}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		err = json.Unmarshal(raw, &list.Items)
+		return list, err
+	}
+	var page struct {
+		Items      []*T    `json:"items"`
+		NextCursor *string `json:"nextCursor"`
+		Total      *int64  `json:"total"`
+	}
+	err = json.Unmarshal(raw, &page)
+	if err != nil {
+		return nil, err
+	}
+	list.Items, list.NextCursor, list.Total = page.Items, page.NextCursor, page.Total
+	return list, nil
+}
+
+// decode decodes JSON response body into out
+func decode(res *http.Response, out any) error {
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// flag returns query with name=true set if value is true
+func flag(name string, value bool) url.Values {
+	if !value {
+		return nil
+	}
+	return url.Values{name: {"true"}}
+}
+
+// ETag
+// Takes a resources updatedAt as arg, returns the ETag the API derives from it, for use as If-Match
+func ETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMilli(), 10) + `"`
+}
+
+// idStr formats an id as a path segment
+func idStr(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
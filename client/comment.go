@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// commentsPath returns path of a jobs comments
+func commentsPath(jobId int64) string {
+	return "/jobs/" + idStr(jobId) + "/comments"
+}
+
+// ListComments
+// Takes job id and query (task, sort, pagination) as args, returns Comment list oldest first
+func (c *Client) ListComments(ctx context.Context, jobId int64, query url.Values) (*List[models.Comment], error) {
+	return getList[models.Comment](ctx, c, commentsPath(jobId), query)
+}
+
+// GetComment
+// Takes job and comment ids as args, returns Comment
+func (c *Client) GetComment(ctx context.Context, jobId int64, commentId int64) (*models.Comment, error) {
+	var comment models.Comment
+	err := c.doJSON(ctx, http.MethodGet, commentsPath(jobId)+"/"+idStr(commentId), nil, nil, &comment)
+	return &comment, err
+}
+
+// CreateComment
+// Takes job id and NewComment as args, returns created Comment
+func (c *Client) CreateComment(ctx context.Context, jobId int64, newComment models.NewComment) (*models.Comment, error) {
+	var comment models.Comment
+	err := c.doJSON(ctx, http.MethodPost, commentsPath(jobId)+"/create", nil, newComment, &comment)
+	return &comment, err
+}
+
+// EditComment
+// Takes job id and Comment as args, returns updated Comment
+func (c *Client) EditComment(ctx context.Context, jobId int64, comment models.Comment) (*models.Comment, error) {
+	var updatedComment models.Comment
+	err := c.doJSON(ctx, http.MethodPost, commentsPath(jobId)+"/edit", nil, comment, &updatedComment)
+	return &updatedComment, err
+}
+
+// DeleteComment
+// Takes job and comment ids as args, deletes comment with its history
+func (c *Client) DeleteComment(ctx context.Context, jobId int64, commentId int64) error {
+	return c.doJSON(ctx, http.MethodDelete, commentsPath(jobId)+"/"+idStr(commentId), nil, nil, nil)
+}
+
+// ListCommentHistory
+// Takes job and comment ids as args, returns CommentRevision list of previous bodies newest first
+func (c *Client) ListCommentHistory(ctx context.Context, jobId int64, commentId int64) ([]*models.CommentRevision, error) {
+	var revisions []*models.CommentRevision
+	err := c.doJSON(ctx, http.MethodGet, commentsPath(jobId)+"/"+idStr(commentId)+"/history", nil, nil, &revisions)
+	return revisions, err
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// documentsPath returns path of a vehicles documents
+func documentsPath(vehicleId int64) string {
+	return "/vehicles/" + idStr(vehicleId) + "/documents"
+}
+
+// ListDocuments
+// Takes vehicle id and query (type, expiresBefore, q, sort) as args, returns Document list
+func (c *Client) ListDocuments(ctx context.Context, vehicleId int64, query url.Values) ([]*models.Document, error) {
+	var documents []*models.Document
+	err := c.doJSON(ctx, http.MethodGet, documentsPath(vehicleId), query, nil, &documents)
+	return documents, err
+}
+
+// GetDocument
+// Takes vehicle and document ids as args, returns Document
+func (c *Client) GetDocument(ctx context.Context, vehicleId int64, documentId int64) (*models.Document, error) {
+	var document models.Document
+	err := c.doJSON(ctx, http.MethodGet, documentsPath(vehicleId)+"/"+idStr(documentId), nil, nil, &document)
+	return &document, err
+}
+
+// CreateDocument
+// Takes vehicle id and NewDocument as args, returns created Document
+func (c *Client) CreateDocument(ctx context.Context, vehicleId int64, newDocument models.NewDocument) (*models.Document, error) {
+	var document models.Document
+	err := c.doJSON(ctx, http.MethodPost, documentsPath(vehicleId)+"/create", nil, newDocument, &document)
+	return &document, err
+}
+
+// EditDocument
+// Takes vehicle id and Document as args, returns updated Document
+func (c *Client) EditDocument(ctx context.Context, vehicleId int64, document models.Document) (*models.Document, error) {
+	var updatedDocument models.Document
+	err := c.doJSON(ctx, http.MethodPost, documentsPath(vehicleId)+"/edit", nil, document, &updatedDocument)
+	return &updatedDocument, err
+}
+
+// DeleteDocument
+// Takes vehicle and document ids as args, deletes document
+func (c *Client) DeleteDocument(ctx context.Context, vehicleId int64, documentId int64) error {
+	return c.doJSON(ctx, http.MethodDelete, documentsPath(vehicleId)+"/"+idStr(documentId), nil, nil, nil)
+}
+
+// GetAttachment
+// Takes vehicle and document ids as args, returns attachment and its content type
+func (c *Client) GetAttachment(ctx context.Context, vehicleId int64, documentId int64) ([]byte, string, error) {
+	return c.doBytes(ctx, http.MethodGet, documentsPath(vehicleId)+"/"+idStr(documentId)+"/attachment", nil)
+}
+
+// UploadAttachment
+// Takes vehicle and document ids, file name, content type and file as args, saves it as the documents attachment
+func (c *Client) UploadAttachment(ctx context.Context, vehicleId int64, documentId int64, name string, contentType string, file io.Reader) error {
+	var query url.Values
+	if len(name) > 0 {
+		query = url.Values{"name": {name}}
+	}
+	res, err := c.do(ctx, http.MethodPut, documentsPath(vehicleId)+"/"+idStr(documentId)+"/attachment", query, contentType, file)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// DeleteAttachment
+// Takes vehicle and document ids as args, removes the documents attachment
+func (c *Client) DeleteAttachment(ctx context.Context, vehicleId int64, documentId int64) error {
+	return c.doJSON(ctx, http.MethodDelete, documentsPath(vehicleId)+"/"+idStr(documentId)+"/attachment", nil, nil, nil)
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// StreamEvents
+// Takes id of the last event seen, nil for none, and handler as args, passes each live update Event to handler until ctx is done, handler returns an error or the server closes the stream, reset events are passed with Type reset
+func (c *Client) StreamEvents(ctx context.Context, lastEventId *int64, handle func(event models.Event) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/events", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventId != nil {
+		req.Header.Set("Last-Event-ID", strconv.FormatInt(*lastEventId, 10))
+	}
+	res, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var name, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		// a blank line ends an event, lines starting with a colon are heartbeats
+		switch {
+		case len(line) == 0:
+			if len(data) == 0 {
+				continue
+			}
+			event := models.Event{Type: name}
+			if name != "reset" {
+				if err := json.Unmarshal([]byte(data), &event); err != nil {
+					return err
+				}
+			}
+			if err := handle(event); err != nil {
+				return err
+			}
+			name, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListFuelLogs
+// Takes vehicle id and query (limit, cursor, count) as args, returns FuelLog list newest first
+func (c *Client) ListFuelLogs(ctx context.Context, vehicleId int64, query url.Values) (*List[models.FuelLog], error) {
+	return getList[models.FuelLog](ctx, c, "/vehicles/"+idStr(vehicleId)+"/fuel", query)
+}
+
+// ImportFuelLogs
+// Takes vehicle id, csv and query (preview, map) as args, returns CSVImportResult, rows with errors are reported in it rather than as an error
+func (c *Client) ImportFuelLogs(ctx context.Context, vehicleId int64, csv io.Reader, query url.Values) (*models.CSVImportResult, error) {
+	var result models.CSVImportResult
+	err := c.upload(ctx, "/vehicles/"+idStr(vehicleId)+"/fuel/import", query, "text/csv", csv, &result)
+	return &result, err
+}
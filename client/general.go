@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// GetWelcome
+// Returns welcome message including API version
+func (c *Client) GetWelcome(ctx context.Context) (string, error) {
+	data, _, err := c.doBytes(ctx, http.MethodGet, "/", nil)
+	return string(data), err
+}
+
+// GetEnv
+// Returns public environment variables of the API
+func (c *Client) GetEnv(ctx context.Context) (map[string]string, error) {
+	var env map[string]string
+	err := c.doJSON(ctx, http.MethodGet, "/env", nil, nil, &env)
+	return env, err
+}
+
+// GetOpenAPI
+// Returns OpenAPI document of the API
+func (c *Client) GetOpenAPI(ctx context.Context) ([]byte, error) {
+	data, _, err := c.doBytes(ctx, http.MethodGet, "/openapi.json", nil)
+	return data, err
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListImportFormats
+// Returns names of supported tracker export formats
+func (c *Client) ListImportFormats(ctx context.Context) ([]string, error) {
+	var formats []string
+	err := c.doJSON(ctx, http.MethodGet, "/import/formats", nil, nil, &formats)
+	return formats, err
+}
+
+// ImportTracker
+// Takes format (or auto), export file and query (preview, dayFirst, vehicle) as args, returns TrackerImportResult, rows with errors are reported in it rather than as an error
+func (c *Client) ImportTracker(ctx context.Context, format string, file io.Reader, query url.Values) (*models.TrackerImportResult, error) {
+	var result models.TrackerImportResult
+	err := c.upload(ctx, "/import/"+url.PathEscape(format), query, "text/csv", file, &result)
+	return &result, err
+}
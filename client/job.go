@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListJobs
+// Takes query (user, vehicle, template, complete, status, label, q, sort, limit, cursor, count) as arg, returns Job list
+func (c *Client) ListJobs(ctx context.Context, query url.Values) (*List[models.Job], error) {
+	return getList[models.Job](ctx, c, "/jobs", query)
+}
+
+// GetJob
+// Takes job id as arg, returns Job
+func (c *Client) GetJob(ctx context.Context, jobId int64) (*models.Job, error) {
+	var job models.Job
+	err := c.doJSON(ctx, http.MethodGet, "/jobs/"+idStr(jobId), nil, nil, &job)
+	return &job, err
+}
+
+// AssignJobLabel
+// Takes job and label ids as args, assigns label to job, or unassigns it if unassign is true
+func (c *Client) AssignJobLabel(ctx context.Context, jobId int64, labelId int64, unassign bool) error {
+	return c.doJSON(ctx, http.MethodPost, "/jobs/"+idStr(jobId)+"/assignLabel/"+idStr(labelId), flag("unassign", unassign), nil, nil)
+}
+
+// CreateJob
+// Takes NewJob as arg, returns created Job
+func (c *Client) CreateJob(ctx context.Context, newJob models.NewJob) (*models.Job, error) {
+	var job models.Job
+	err := c.doJSON(ctx, http.MethodPost, "/jobs/create", nil, newJob, &job)
+	return &job, err
+}
+
+// ImportJobs
+// Takes csv and query (preview, map) as args, returns CSVImportResult, rows with errors are reported in it rather than as an error
+func (c *Client) ImportJobs(ctx context.Context, csv io.Reader, query url.Values) (*models.CSVImportResult, error) {
+	var result models.CSVImportResult
+	err := c.upload(ctx, "/jobs/import", query, "text/csv", csv, &result)
+	return &result, err
+}
+
+// EditJob
+// Takes Job as arg, returns updated Job
+func (c *Client) EditJob(ctx context.Context, job models.Job) (*models.Job, error) {
+	var updatedJob models.Job
+	err := c.doJSON(ctx, http.MethodPost, "/jobs/edit", nil, job, &updatedJob)
+	return &updatedJob, err
+}
+
+// PatchJob
+// Takes job id, JSON merge patch and If-Match ETag (empty to skip) as args, returns updated Job
+func (c *Client) PatchJob(ctx context.Context, jobId int64, patch any, ifMatch string) (*models.Job, error) {
+	var updatedJob models.Job
+	err := c.patch(ctx, "/jobs/"+idStr(jobId), patch, ifMatch, &updatedJob)
+	return &updatedJob, err
+}
+
+// DeleteJob
+// Takes job id as arg, deletes job
+func (c *Client) DeleteJob(ctx context.Context, jobId int64) error {
+	return c.doJSON(ctx, http.MethodDelete, "/jobs/"+idStr(jobId), nil, nil, nil)
+}
+
+// BulkDeleteJobs
+// Takes BulkRequest of job ids as arg, returns BulkResult, failed items are reported in it rather than as an error
+func (c *Client) BulkDeleteJobs(ctx context.Context, bulkReq models.BulkRequest) (*models.BulkResult, error) {
+	return c.bulk(ctx, "/jobs/bulk/delete", nil, bulkReq)
+}
+
+// BulkAssignJobLabel
+// Takes label id, BulkRequest of job ids and unassign as args, returns BulkResult, failed items are reported in it rather than as an error
+func (c *Client) BulkAssignJobLabel(ctx context.Context, labelId int64, bulkReq models.BulkRequest, unassign bool) (*models.BulkResult, error) {
+	return c.bulk(ctx, "/jobs/bulk/assignLabel/"+idStr(labelId), flag("unassign", unassign), bulkReq)
+}
+
+// UpdateJobStatus
+// Takes job id and JobStatusChange as args, returns updated Job
+func (c *Client) UpdateJobStatus(ctx context.Context, jobId int64, statusChange models.JobStatusChange) (*models.Job, error) {
+	var updatedJob models.Job
+	err := c.doJSON(ctx, http.MethodPost, "/jobs/"+idStr(jobId)+"/status", nil, statusChange, &updatedJob)
+	return &updatedJob, err
+}
+
+// ListJobStatusHistory
+// Takes job id as arg, returns JobStatusHistory list
+func (c *Client) ListJobStatusHistory(ctx context.Context, jobId int64) ([]*models.JobStatusHistory, error) {
+	var history []*models.JobStatusHistory
+	err := c.doJSON(ctx, http.MethodGet, "/jobs/"+idStr(jobId)+"/status/history", nil, nil, &history)
+	return history, err
+}
+
+// GetJobCompletion
+// Takes job id as arg, returns latest JobCompletion
+func (c *Client) GetJobCompletion(ctx context.Context, jobId int64) (*models.JobCompletion, error) {
+	var completion models.JobCompletion
+	err := c.doJSON(ctx, http.MethodGet, "/jobs/"+idStr(jobId)+"/complete", nil, nil, &completion)
+	return &completion, err
+}
+
+// CompleteJob
+// Takes job id and NewJobCompletion as args, returns JobCompletion
+func (c *Client) CompleteJob(ctx context.Context, jobId int64, newCompletion models.NewJobCompletion) (*models.JobCompletion, error) {
+	var completion models.JobCompletion
+	err := c.doJSON(ctx, http.MethodPost, "/jobs/"+idStr(jobId)+"/complete", nil, newCompletion, &completion)
+	return &completion, err
+}
+
+// UndoJobCompletion
+// Takes job id as arg, reverts its latest completion, returns updated Job
+func (c *Client) UndoJobCompletion(ctx context.Context, jobId int64) (*models.Job, error) {
+	var updatedJob models.Job
+	err := c.doJSON(ctx, http.MethodDelete, "/jobs/"+idStr(jobId)+"/complete", nil, nil, &updatedJob)
+	return &updatedJob, err
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListLabels
+// Takes query (user, job, q, sort, limit, cursor, count) as arg, returns Label list
+func (c *Client) ListLabels(ctx context.Context, query url.Values) (*List[models.Label], error) {
+	return getList[models.Label](ctx, c, "/labels", query)
+}
+
+// GetLabel
+// Takes label id as arg, returns Label
+func (c *Client) GetLabel(ctx context.Context, labelId int64) (*models.Label, error) {
+	var label models.Label
+	err := c.doJSON(ctx, http.MethodGet, "/labels/"+idStr(labelId), nil, nil, &label)
+	return &label, err
+}
+
+// CreateLabel
+// Takes NewLabel as arg, returns created Label
+func (c *Client) CreateLabel(ctx context.Context, newLabel models.NewLabel) (*models.Label, error) {
+	var label models.Label
+	err := c.doJSON(ctx, http.MethodPost, "/labels/create", nil, newLabel, &label)
+	return &label, err
+}
+
+// EditLabel
+// Takes Label as arg, returns updated Label
+func (c *Client) EditLabel(ctx context.Context, label models.Label) (*models.Label, error) {
+	var updatedLabel models.Label
+	err := c.doJSON(ctx, http.MethodPost, "/labels/edit", nil, label, &updatedLabel)
+	return &updatedLabel, err
+}
+
+// PatchLabel
+// Takes label id, JSON merge patch and If-Match ETag (empty to skip) as args, returns updated Label
+func (c *Client) PatchLabel(ctx context.Context, labelId int64, patch any, ifMatch string) (*models.Label, error) {
+	var updatedLabel models.Label
+	err := c.patch(ctx, "/labels/"+idStr(labelId), patch, ifMatch, &updatedLabel)
+	return &updatedLabel, err
+}
+
+// DeleteLabel
+// Takes label id as arg, deletes label
+func (c *Client) DeleteLabel(ctx context.Context, labelId int64) error {
+	return c.doJSON(ctx, http.MethodDelete, "/labels/"+idStr(labelId), nil, nil, nil)
+}
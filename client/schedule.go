@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListSchedules
+// Takes query (user, make, model, year, q, sort) as arg, returns Schedule list
+func (c *Client) ListSchedules(ctx context.Context, query url.Values) ([]*models.Schedule, error) {
+	var schedules []*models.Schedule
+	err := c.doJSON(ctx, http.MethodGet, "/schedules", query, nil, &schedules)
+	return schedules, err
+}
+
+// GetSchedule
+// Takes schedule id as arg, returns Schedule
+func (c *Client) GetSchedule(ctx context.Context, scheduleId int64) (*models.Schedule, error) {
+	var schedule models.Schedule
+	err := c.doJSON(ctx, http.MethodGet, "/schedules/"+idStr(scheduleId), nil, nil, &schedule)
+	return &schedule, err
+}
+
+// ImportSchedule
+// Takes NewSchedule as arg, returns created Schedule
+func (c *Client) ImportSchedule(ctx context.Context, newSchedule models.NewSchedule) (*models.Schedule, error) {
+	var schedule models.Schedule
+	err := c.doJSON(ctx, http.MethodPost, "/schedules/import", nil, newSchedule, &schedule)
+	return &schedule, err
+}
+
+// ApplySchedule
+// Takes vehicle and schedule ids as args, returns jobs created on vehicle, force applies it even if vehicle does not match
+func (c *Client) ApplySchedule(ctx context.Context, vehicleId int64, scheduleId int64, force bool) ([]*models.Job, error) {
+	var jobs []*models.Job
+	err := c.doJSON(ctx, http.MethodPost, "/vehicles/"+idStr(vehicleId)+"/applySchedule/"+idStr(scheduleId), flag("force", force), nil, &jobs)
+	return jobs, err
+}
+
+// DeleteSchedule
+// Takes schedule id as arg, deletes schedule
+func (c *Client) DeleteSchedule(ctx context.Context, scheduleId int64) error {
+	return c.doJSON(ctx, http.MethodDelete, "/schedules/"+idStr(scheduleId), nil, nil, nil)
+}
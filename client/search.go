@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// Search
+// Takes search string and query (type, limit) as args, returns SearchResult list
+func (c *Client) Search(ctx context.Context, q string, query url.Values) ([]*models.SearchResult, error) {
+	params := url.Values{}
+	for key, values := range query {
+		params[key] = values
+	}
+	params.Set("q", q)
+	var results []*models.SearchResult
+	err := c.doJSON(ctx, http.MethodGet, "/search", params, nil, &results)
+	return results, err
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListTasks
+// Takes job id and query (template, q, sort, limit, cursor, count) as args, returns Task list in job order unless sorted
+func (c *Client) ListTasks(ctx context.Context, jobId int64, query url.Values) (*List[models.Task], error) {
+	return getList[models.Task](ctx, c, "/jobs/"+idStr(jobId)+"/tasks", query)
+}
+
+// GetTask
+// Takes job and task ids as args, returns Task
+func (c *Client) GetTask(ctx context.Context, jobId int64, taskId int64) (*models.Task, error) {
+	var task models.Task
+	err := c.doJSON(ctx, http.MethodGet, "/jobs/"+idStr(jobId)+"/tasks/"+idStr(taskId), nil, nil, &task)
+	return &task, err
+}
+
+// MarkTaskComplete
+// Takes job and task ids, incomplete and force as args, marks task complete, or incomplete if incomplete is true, tasks with open prerequisites are only completed if force is true
+func (c *Client) MarkTaskComplete(ctx context.Context, jobId int64, taskId int64, incomplete bool, force bool) error {
+	return c.doJSON(ctx, http.MethodPatch, "/jobs/"+idStr(jobId)+"/tasks/"+idStr(taskId)+"/complete", completeQuery(incomplete, force), nil, nil)
+}
+
+// AssignTaskLabel
+// Takes job, task and label ids as args, assigns label to task, or unassigns it if unassign is true
+func (c *Client) AssignTaskLabel(ctx context.Context, jobId int64, taskId int64, labelId int64, unassign bool) error {
+	return c.doJSON(ctx, http.MethodPost, "/jobs/"+idStr(jobId)+"/tasks/"+idStr(taskId)+"/assignLabel/"+idStr(labelId), flag("unassign", unassign), nil, nil)
+}
+
+// CreateTask
+// Takes job id and NewTask as args, returns created Task
+func (c *Client) CreateTask(ctx context.Context, jobId int64, newTask models.NewTask) (*models.Task, error) {
+	var task models.Task
+	err := c.doJSON(ctx, http.MethodPost, "/jobs/"+idStr(jobId)+"/tasks/create", nil, newTask, &task)
+	return &task, err
+}
+
+// ImportTasks
+// Takes job id, csv and query (preview, map) as args, returns CSVImportResult, rows with errors are reported in it rather than as an error
+func (c *Client) ImportTasks(ctx context.Context, jobId int64, csv io.Reader, query url.Values) (*models.CSVImportResult, error) {
+	var result models.CSVImportResult
+	err := c.upload(ctx, "/jobs/"+idStr(jobId)+"/tasks/import", query, "text/csv", csv, &result)
+	return &result, err
+}
+
+// EditTask
+// Takes job id and Task as args, returns updated Task
+func (c *Client) EditTask(ctx context.Context, jobId int64, task models.Task) (*models.Task, error) {
+	var updatedTask models.Task
+	err := c.doJSON(ctx, http.MethodPost, "/jobs/"+idStr(jobId)+"/tasks/edit", nil, task, &updatedTask)
+	return &updatedTask, err
+}
+
+// PatchTask
+// Takes job and task ids, JSON merge patch and If-Match ETag (empty to skip) as args, returns updated Task
+func (c *Client) PatchTask(ctx context.Context, jobId int64, taskId int64, patch any, ifMatch string) (*models.Task, error) {
+	var updatedTask models.Task
+	err := c.patch(ctx, "/jobs/"+idStr(jobId)+"/tasks/"+idStr(taskId), patch, ifMatch, &updatedTask)
+	return &updatedTask, err
+}
+
+// BulkMarkComplete
+// Takes BulkRequest of task ids, incomplete and force as args, returns BulkResult, failed items are reported in it rather than as an error
+func (c *Client) BulkMarkComplete(ctx context.Context, bulkReq models.BulkRequest, incomplete bool, force bool) (*models.BulkResult, error) {
+	return c.bulk(ctx, "/tasks/bulk/complete", completeQuery(incomplete, force), bulkReq)
+}
+
+// completeQuery returns query of the complete endpoints
+func completeQuery(incomplete bool, force bool) url.Values {
+	query := url.Values{}
+	if incomplete {
+		query.Set("incomplete", "true")
+	}
+	if force {
+		query.Set("force", "true")
+	}
+	return query
+}
+
+// ReorderTasks
+// Takes job id and task ids in their new order as args, listed tasks move to the front of the job in that order, returns Task list in job order
+func (c *Client) ReorderTasks(ctx context.Context, jobId int64, taskIds []int64) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := c.doJSON(ctx, http.MethodPost, "/jobs/"+idStr(jobId)+"/tasks/reorder", nil, models.TaskOrder{Tasks: taskIds}, &tasks)
+	return tasks, err
+}
+
+// DeleteTask
+// Takes job and task ids as args, deletes task
+func (c *Client) DeleteTask(ctx context.Context, jobId int64, taskId int64) error {
+	return c.doJSON(ctx, http.MethodDelete, "/jobs/"+idStr(jobId)+"/tasks/"+idStr(taskId), nil, nil, nil)
+}
+
+// DeleteTasks
+// Takes job id as arg, deletes all of its tasks
+func (c *Client) DeleteTasks(ctx context.Context, jobId int64) error {
+	return c.doJSON(ctx, http.MethodDelete, "/jobs/"+idStr(jobId)+"/tasks", nil, nil, nil)
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// timerPath returns path of the requesting users timer on a task
+func timerPath(jobId int64, taskId int64) string {
+	return "/jobs/" + idStr(jobId) + "/tasks/" + idStr(taskId) + "/timer"
+}
+
+// StartTimer
+// Takes job and task ids as args, stops the requesting users timer on any other task, returns started TimeEntry
+func (c *Client) StartTimer(ctx context.Context, jobId int64, taskId int64) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := c.doJSON(ctx, http.MethodPost, timerPath(jobId, taskId)+"/start", nil, nil, &entry)
+	return &entry, err
+}
+
+// StopTimer
+// Takes job and task ids as args, returns stopped TimeEntry
+func (c *Client) StopTimer(ctx context.Context, jobId int64, taskId int64) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := c.doJSON(ctx, http.MethodPost, timerPath(jobId, taskId)+"/stop", nil, nil, &entry)
+	return &entry, err
+}
+
+// GetRunningTimer
+// Returns the requesting users running TimeEntry
+func (c *Client) GetRunningTimer(ctx context.Context) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := c.doJSON(ctx, http.MethodGet, "/timer", nil, nil, &entry)
+	return &entry, err
+}
+
+// ListTimeEntries
+// Takes job id and query (task, user, pagination) as args, returns TimeEntry list oldest first
+func (c *Client) ListTimeEntries(ctx context.Context, jobId int64, query url.Values) (*List[models.TimeEntry], error) {
+	return getList[models.TimeEntry](ctx, c, "/jobs/"+idStr(jobId)+"/time/entries", query)
+}
+
+// GetJobTime
+// Takes job id as arg, returns JobTime with time tracked per task and user against estimates
+func (c *Client) GetJobTime(ctx context.Context, jobId int64) (*models.JobTime, error) {
+	var jobTime models.JobTime
+	err := c.doJSON(ctx, http.MethodGet, "/jobs/"+idStr(jobId)+"/time", nil, nil, &jobTime)
+	return &jobTime, err
+}
+
+// GetEstimateReport
+// Takes query (user, from, to) as args, returns EstimateReport of estimate accuracy by label
+func (c *Client) GetEstimateReport(ctx context.Context, query url.Values) (*models.EstimateReport, error) {
+	var report models.EstimateReport
+	err := c.doJSON(ctx, http.MethodGet, "/reports/estimates", query, nil, &report)
+	return &report, err
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListTrash
+// Takes query (user) as arg, returns TrashItem list newest first, admins get everyones trash unless filtered
+func (c *Client) ListTrash(ctx context.Context, query url.Values) ([]*models.TrashItem, error) {
+	var items []*models.TrashItem
+	err := c.doJSON(ctx, http.MethodGet, "/trash", query, nil, &items)
+	return items, err
+}
+
+// RestoreTrash
+// Takes type (job, vehicle or user), id and out as args, restores it with the children trashed along with it, decodes restored Job, Vehicle or User into out unless nil
+func (c *Client) RestoreTrash(ctx context.Context, typeStr string, id int64, out any) error {
+	return c.doJSON(ctx, http.MethodPost, "/trash/"+url.PathEscape(typeStr)+"/"+idStr(id)+"/restore", nil, nil, out)
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListUsers
+// Takes query (job, vehicle, admin, q, sort, limit, cursor, count) as arg, returns User list
+func (c *Client) ListUsers(ctx context.Context, query url.Values) (*List[models.User], error) {
+	return getList[models.User](ctx, c, "/users", query)
+}
+
+// GetUser
+// Takes username as arg, returns User
+func (c *Client) GetUser(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	err := c.doJSON(ctx, http.MethodGet, "/users/"+url.PathEscape(username), nil, nil, &user)
+	return &user, err
+}
+
+// DeleteUser
+// Takes username as arg, deletes user
+func (c *Client) DeleteUser(ctx context.Context, username string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/users/"+url.PathEscape(username), nil, nil, nil)
+}
+
+// CreateUser
+// Takes NewUser as arg, returns created User
+func (c *Client) CreateUser(ctx context.Context, newUser models.NewUser) (*models.User, error) {
+	var user models.User
+	err := c.doJSON(ctx, http.MethodPost, "/users/create", nil, newUser, &user)
+	return &user, err
+}
+
+// EditUser
+// Takes User as arg, returns updated User
+func (c *Client) EditUser(ctx context.Context, user models.User) (*models.User, error) {
+	var updatedUser models.User
+	err := c.doJSON(ctx, http.MethodPost, "/users/edit", nil, user, &updatedUser)
+	return &updatedUser, err
+}
+
+// PatchUser
+// Takes username, JSON merge patch and If-Match ETag (empty to skip) as args, returns updated User
+func (c *Client) PatchUser(ctx context.Context, username string, patch any, ifMatch string) (*models.User, error) {
+	var updatedUser models.User
+	err := c.patch(ctx, "/users/"+url.PathEscape(username), patch, ifMatch, &updatedUser)
+	return &updatedUser, err
+}
+
+// UpdatePassword
+// Takes Passwords as arg, changes password
+func (c *Client) UpdatePassword(ctx context.Context, passwords models.Passwords) error {
+	return c.doJSON(ctx, http.MethodPost, "/users/updatePassword", nil, passwords, nil)
+}
+
+// ExportAccount
+// Takes username as arg, returns AccountExport
+func (c *Client) ExportAccount(ctx context.Context, username string) (*models.AccountExport, error) {
+	var export models.AccountExport
+	err := c.doJSON(ctx, http.MethodGet, "/users/"+url.PathEscape(username)+"/export", nil, nil, &export)
+	return &export, err
+}
+
+// ImportAccount
+// Takes username, AccountExport and query (dryRun, labels) as args, returns ImportResult
+func (c *Client) ImportAccount(ctx context.Context, username string, export models.AccountExport, query url.Values) (*models.ImportResult, error) {
+	var result models.ImportResult
+	err := c.doJSON(ctx, http.MethodPost, "/users/"+url.PathEscape(username)+"/import", query, export, &result)
+	return &result, err
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListVehicles
+// Takes query (user, job, q, sort, limit, cursor, count) as arg, returns Vehicle list
+func (c *Client) ListVehicles(ctx context.Context, query url.Values) (*List[models.Vehicle], error) {
+	return getList[models.Vehicle](ctx, c, "/vehicles", query)
+}
+
+// GetVehicle
+// Takes vehicle id as arg, returns Vehicle
+func (c *Client) GetVehicle(ctx context.Context, vehicleId int64) (*models.Vehicle, error) {
+	var vehicle models.Vehicle
+	err := c.doJSON(ctx, http.MethodGet, "/vehicles/"+idStr(vehicleId), nil, nil, &vehicle)
+	return &vehicle, err
+}
+
+// AssignVehicleLabel
+// Takes vehicle and label ids as args, assigns label to vehicle, or unassigns it if unassign is true
+func (c *Client) AssignVehicleLabel(ctx context.Context, vehicleId int64, labelId int64, unassign bool) error {
+	return c.doJSON(ctx, http.MethodPost, "/vehicles/"+idStr(vehicleId)+"/assignLabel/"+idStr(labelId), flag("unassign", unassign), nil, nil)
+}
+
+// CreateVehicle
+// Takes NewVehicle as arg, returns created Vehicle
+func (c *Client) CreateVehicle(ctx context.Context, newVehicle models.NewVehicle) (*models.Vehicle, error) {
+	var vehicle models.Vehicle
+	err := c.doJSON(ctx, http.MethodPost, "/vehicles/create", nil, newVehicle, &vehicle)
+	return &vehicle, err
+}
+
+// ImportVehicles
+// Takes csv and query (preview, map) as args, returns CSVImportResult, rows with errors are reported in it rather than as an error
+func (c *Client) ImportVehicles(ctx context.Context, csv io.Reader, query url.Values) (*models.CSVImportResult, error) {
+	var result models.CSVImportResult
+	err := c.upload(ctx, "/vehicles/import", query, "text/csv", csv, &result)
+	return &result, err
+}
+
+// EditVehicle
+// Takes Vehicle as arg, returns updated Vehicle
+func (c *Client) EditVehicle(ctx context.Context, vehicle models.Vehicle) (*models.Vehicle, error) {
+	var updatedVehicle models.Vehicle
+	err := c.doJSON(ctx, http.MethodPost, "/vehicles/edit", nil, vehicle, &updatedVehicle)
+	return &updatedVehicle, err
+}
+
+// PatchVehicle
+// Takes vehicle id, JSON merge patch and If-Match ETag (empty to skip) as args, returns updated Vehicle
+func (c *Client) PatchVehicle(ctx context.Context, vehicleId int64, patch any, ifMatch string) (*models.Vehicle, error) {
+	var updatedVehicle models.Vehicle
+	err := c.patch(ctx, "/vehicles/"+idStr(vehicleId), patch, ifMatch, &updatedVehicle)
+	return &updatedVehicle, err
+}
+
+// DeleteVehicle
+// Takes vehicle id as arg, deletes vehicle
+func (c *Client) DeleteVehicle(ctx context.Context, vehicleId int64) error {
+	return c.doJSON(ctx, http.MethodDelete, "/vehicles/"+idStr(vehicleId), nil, nil, nil)
+}
+
+// ListOdometerReadings
+// Takes vehicle id as arg, returns OdometerReading list
+func (c *Client) ListOdometerReadings(ctx context.Context, vehicleId int64) ([]*models.OdometerReading, error) {
+	var readings []*models.OdometerReading
+	err := c.doJSON(ctx, http.MethodGet, "/vehicles/"+idStr(vehicleId)+"/odometer", nil, nil, &readings)
+	return readings, err
+}
+
+// CreateOdometerReading
+// Takes vehicle id and NewOdometerReading as args, returns OdometerReading list including it
+func (c *Client) CreateOdometerReading(ctx context.Context, vehicleId int64, newReading models.NewOdometerReading) ([]*models.OdometerReading, error) {
+	var readings []*models.OdometerReading
+	err := c.doJSON(ctx, http.MethodPost, "/vehicles/"+idStr(vehicleId)+"/odometer", nil, newReading, &readings)
+	return readings, err
+}
+
+// GetVehicleReport
+// Takes vehicle id and query (format, from, to, label) as args, returns service history report as HTML or PDF
+func (c *Client) GetVehicleReport(ctx context.Context, vehicleId int64, query url.Values) ([]byte, error) {
+	data, _, err := c.doBytes(ctx, http.MethodGet, "/vehicles/"+idStr(vehicleId)+"/report", query)
+	return data, err
+}
@@ -1,21 +1,23 @@
 package controllers
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
 	"github.com/okdv/wrench-turn/services"
 )
 
 type AlertController struct {
+	svc *services.Service
 }
 
-func NewAlertController() *AlertController {
-	return &AlertController{}
+func NewAlertController(repo repository.Repositories) *AlertController {
+	return &AlertController{svc: services.New(repo)}
 }
 
 // GetAlert
@@ -24,34 +26,23 @@ func (ac *AlertController) GetAlert(w http.ResponseWriter, r *http.Request, c *m
 	// get alert id from url params, parse into int
 	alertId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// call GetAlert service, return Alert
-	alert, err := services.GetAlert(alertId)
+	alert, err := ac.svc.GetAlert(alertId)
 	if err != nil || alert == nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Alert not found: %v", err)
+		response.Error(w, http.StatusNotFound, "Alert not found", err)
 		return
 	}
 	// if retrieved alert user is not requesting user, check if admin
 	if alert.User != c.ID && c.Is_admin == false {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to get alerts for other users")
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(alert)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert alert to JSON response: %v", err)
+		response.Error(w, http.StatusForbidden, "Must be admin to get alerts for other users", nil)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, alert.Updated_at)
+	response.JSON(w, http.StatusOK, alert)
 }
 
 // ListAlerts
@@ -69,34 +60,29 @@ func (ac *AlertController) ListAlerts(w http.ResponseWriter, r *http.Request, c
 	isAlerted := r.URL.Query().Get("isAlerted")
 	searchStr := r.URL.Query().Get("q")
 	sort := r.URL.Query().Get("sort")
+	// get pagination params, nil if not paginating
+	page, err := pageParams(r)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Invalid pagination params", err)
+		return
+	}
 	// set newAlert.user is nil, set to current user
 	if len(userId) == 0 {
 		userId = strconv.FormatInt(c.ID, 10)
 	}
 	// if newAlert user is not requesting user, check if admin
 	if userId != strconv.FormatInt(c.ID, 10) && c.Is_admin == false {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to list alerts for other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to list alerts for other users", nil)
 		return
 	}
 	// call ListAlerts service
-	alerts, err := services.ListAlerts(&userId, &vehicleId, &jobId, &taskId, &typeStr, &isRead, &isAlerted, &searchStr, &sort)
+	alerts, err = ac.svc.ListAlerts(&userId, &vehicleId, &jobId, &taskId, &typeStr, &isRead, &isAlerted, &searchStr, &sort, page)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to retrieve any alerts: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(alerts)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Unable to convert alerts to JSON response")
+		writeListError(w, "alerts", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusOK, listBody(alerts, page))
 }
 
 // CreateAlert
@@ -104,10 +90,7 @@ func (ac *AlertController) ListAlerts(w http.ResponseWriter, r *http.Request, c
 func (ac *AlertController) CreateAlert(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	var newAlert *models.NewAlert
 	// get alert data from request body
-	err := json.NewDecoder(r.Body).Decode(&newAlert)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &newAlert) {
 		return
 	}
 	// set newAlert.user is nil, set to current user
@@ -116,34 +99,17 @@ func (ac *AlertController) CreateAlert(w http.ResponseWriter, r *http.Request, c
 	}
 	// if newAlert user is not requesting user, check if admin
 	if newAlert.User != &c.ID && c.Is_admin == false {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to create alerts for other users")
-		return
-	}
-	// if type is invalid throw error
-	if newAlert.Type != "notification" && newAlert.Type != "reminder" {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "Type must be notification or reminder")
+		response.Error(w, http.StatusForbidden, "Must be admin to create alerts for other users", nil)
 		return
 	}
 	// send to newAlert service, return Alert
-	alert, err := services.CreateAlert(*newAlert)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to create alert: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(alert)
+	alert, err := ac.svc.WithActor(c.ID).CreateAlert(*newAlert)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert alert to JSON response: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to create alert", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusCreated)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusCreated, alert)
 }
 
 // EditAlert
@@ -151,42 +117,71 @@ func (ac *AlertController) CreateAlert(w http.ResponseWriter, r *http.Request, c
 func (ac *AlertController) EditAlert(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	var alert models.Alert
 	// get user data from request body
-	err := json.NewDecoder(r.Body).Decode(&alert)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &alert) {
 		return
 	}
 	// if requesting users id doesnt match user id in request body, and they are not an admin, throw error
 	if (c.ID != alert.User) && (c.Is_admin != true) {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to edit alerts of other users")
-		return
-	}
-	// if type is invalid throw error
-	if alert.Type != "notification" && alert.Type != "reminder" {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, "Type must be notification or reminder")
+		response.Error(w, http.StatusForbidden, "Must be admin to edit alerts of other users", nil)
 		return
 	}
 	// call EditAlert service, return updated Alert
-	updatedAlert, err := services.EditAlert(alert)
+	updatedAlert, err := ac.svc.WithActor(c.ID).EditAlert(alert, nil)
 	if err != nil || updatedAlert == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to edit alert: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to edit alert", err)
 		return
 	}
-	// convert to JSON response
-	jsonData, err := json.Marshal(updatedAlert)
+	// respond with json
+	response.JSON(w, http.StatusOK, updatedAlert)
+}
+
+// PatchAlert
+// Takes JSON merge patch as request body, applies it to Alert, calls EditAlert service, return Alert
+func (ac *AlertController) PatchAlert(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get alert id from url params, parse into int
+	alertId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert alert to JSON response: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get existing alert data
+	currentAlert, err := ac.svc.GetAlert(alertId)
+	if err != nil || currentAlert == nil {
+		response.Error(w, http.StatusNotFound, "Alert not found", err)
+		return
+	}
+	// if requesting users id doesnt match user of alert, and they are not an admin, throw error
+	if (c.ID != currentAlert.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to edit alerts of other users", nil)
+		return
+	}
+	// apply patch to existing alert
+	var alert models.Alert
+	if !decodeMergePatch(w, r, currentAlert, &alert) {
+		return
+	}
+	alert.ID = alertId
+	// patch may hand alert to another user
+	if (c.ID != alert.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to edit alerts of other users", nil)
+		return
+	}
+	revision, ok := checkIfMatch(w, r, currentAlert.Updated_at)
+	if !ok {
+		return
+	}
+	// call EditAlert service, return updated Alert
+	updatedAlert, err := ac.svc.WithActor(c.ID).EditAlert(alert, revision)
+	if revisionChanged(w, err) {
+		return
+	}
+	if err != nil || updatedAlert == nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to edit alert", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, updatedAlert.Updated_at)
+	response.JSON(w, http.StatusOK, updatedAlert)
 }
 
 // DeleteAlert
@@ -195,8 +190,7 @@ func (ac *AlertController) DeleteAlert(w http.ResponseWriter, r *http.Request, c
 	// get alert id from url params, parse into int
 	alertId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// if admin, call DeleteAlert service, otherwise call DeleteUsersAlert to only allow alert deletion for requesting users alerts
@@ -204,10 +198,9 @@ func (ac *AlertController) DeleteAlert(w http.ResponseWriter, r *http.Request, c
 	if c.Is_admin != true {
 		userId = &c.ID
 	}
-	err = services.DeleteAlert(alertId, userId)
+	err = ac.svc.WithActor(c.ID).DeleteAlert(alertId, userId)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to delete alert: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to delete alert", err)
 		return
 	}
 	// respond with text
@@ -215,6 +208,32 @@ func (ac *AlertController) DeleteAlert(w http.ResponseWriter, r *http.Request, c
 	fmt.Fprintf(w, "Alert ID %v has been deleted", alertId)
 }
 
+// BulkMarkRead
+// Takes BulkRequest of alert ids as request body, marks each read, or unread, in a single transaction, returns BulkResult
+func (ac *AlertController) BulkMarkRead(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get URL query params, convert to int
+	unread := r.URL.Query().Get("unread")
+	status := 1
+	if unread == "true" {
+		status = 0
+	}
+	alertUsers := map[int64]int64{}
+	runBulk(w, r, func(alertId int64) (int, string) {
+		alert, err := ac.svc.GetAlert(alertId)
+		if alert == nil || err != nil {
+			return http.StatusNotFound, "Alert not found"
+		}
+		// if requesting users id doesnt match user from alert, and they are not an admin, reject item
+		if (c.ID != alert.User) && !c.Is_admin {
+			return http.StatusForbidden, "Must be admin to mark alerts of other users"
+		}
+		alertUsers[alertId] = alert.User
+		return http.StatusOK, ""
+	}, func(alertIds []int64, atomic bool) ([]error, bool, error) {
+		return ac.svc.WithActor(c.ID).BulkMarkRead(alertIds, alertUsers, status, atomic)
+	})
+}
+
 // MarkRead
 // Marks task read, or unread
 func (ac *AlertController) MarkRead(w http.ResponseWriter, r *http.Request, c *models.Claims) {
@@ -227,27 +246,23 @@ func (ac *AlertController) MarkRead(w http.ResponseWriter, r *http.Request, c *m
 	// get task id from url params, parse into int
 	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// get Alert Data
-	alert, err := services.GetAlert(id)
+	alert, err := ac.svc.GetAlert(id)
 	if alert == nil || err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Alert ID %d not found: %v", id, err)
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Alert ID %d not found", id), err)
 		return
 	}
 	// if alert user is not requesting user, check if admin
 	if alert.User != c.ID && c.Is_admin == false {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to create alerts for other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to create alerts for other users", nil)
 		return
 	}
-	err = services.MarkRead(id, alert.User, status)
+	err = ac.svc.WithActor(c.ID).MarkRead(id, alert.User, status)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to mark task read: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to mark task read", err)
 		return
 	}
 	// respond with text
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+type AuditController struct {
+	svc *services.Service
+}
+
+func NewAuditController(repo repository.Repositories) *AuditController {
+	return &AuditController{svc: services.New(repo)}
+}
+
+// ListActivity
+// Retrieves any URL query params, admin only, calls ListAuditEvents service, returns AuditEvent list newest first
+func (ac *AuditController) ListActivity(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	if !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to view all activity", nil)
+		return
+	}
+	// get URL query params
+	actor := r.URL.Query().Get("actor")
+	entity := r.URL.Query().Get("entity")
+	action := r.URL.Query().Get("action")
+	vehicleId := r.URL.Query().Get("vehicle")
+	jobId := r.URL.Query().Get("job")
+	writeActivity(w, r, ac.svc, &actor, &entity, &action, &vehicleId, &jobId)
+}
+
+// writeActivity
+// Retrieves entity, action and pagination params, calls ListAuditEvents service with them and the given scope, responds with AuditEvent list
+func writeActivity(w http.ResponseWriter, r *http.Request, svc *services.Service, actor *string, entity *string, action *string, vehicleId *string, jobId *string) {
+	// get pagination params, nil if not paginating
+	page, err := pageParams(r)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Invalid pagination params", err)
+		return
+	}
+	events, err := svc.ListAuditEvents(actor, entity, action, vehicleId, jobId, page)
+	if err != nil {
+		writeListError(w, "activity", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, listBody(events, page))
+}
@@ -1,21 +1,23 @@
 package controllers
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
 	"github.com/okdv/wrench-turn/services"
 )
 
 type AuthController struct {
+	svc *services.Service
 }
 
-func NewAuthController() *AuthController {
-	return &AuthController{}
+func NewAuthController(repo repository.Repositories) *AuthController {
+	return &AuthController{svc: services.New(repo)}
 }
 
 var jwtCookieName = "wrenchturn-jwt"
@@ -25,32 +27,25 @@ var jwtCookieName = "wrenchturn-jwt"
 func (ac *AuthController) Auth(w http.ResponseWriter, r *http.Request) {
 	var creds *models.Credentials
 	// get credentials from request body
-	err := json.NewDecoder(r.Body).Decode(&creds)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Unable to parse request body: %v", err)
+	if !decodeBody(w, r, &creds) {
 		return
 	}
 	// retrieve user auth info
-	userId, username, isAdmin, _, isValid, err, statusCode := services.RetrieveAuthInfo(creds)
+	userId, username, isAdmin, _, isValid, err, statusCode := ac.svc.RetrieveAuthInfo(creds)
 	if err != nil || !isValid {
-		w.WriteHeader(statusCode)
-		fmt.Fprintf(w, "Unable to retrieve user auth info: %v", err)
+		response.Error(w, statusCode, "Unable to retrieve user auth info", err)
 		return
 	}
 	// generate new jwt
 	jwtCookie, err := services.CreateJWT(*userId, *username, *isAdmin, jwtCookieName)
 	if err != nil || jwtCookie == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to generate JWT: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to generate JWT", err)
 		return
 	}
 	// set jwt as cookie
 	http.SetCookie(w, jwtCookie)
 	// return 200ok auth jwt and session
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(jwtCookie)
+	response.JSON(w, http.StatusOK, jwtCookie)
 }
 
 // Verify
@@ -61,8 +56,7 @@ func (ac *AuthController) Verify(endpointHandler func(w http.ResponseWriter, r *
 		jwt := r.Header.Get("Authorization")
 		// if no auth header, return err
 		if len(jwt) == 0 {
-			w.WriteHeader(http.StatusUnauthorized)
-			fmt.Fprint(w, "No JWT provided as Bearer token in Authorization header")
+			response.Error(w, http.StatusUnauthorized, "No JWT provided as Bearer token in Authorization header", nil)
 			return
 		}
 		// trim "Bearer " prefix from auth header if present
@@ -70,8 +64,7 @@ func (ac *AuthController) Verify(endpointHandler func(w http.ResponseWriter, r *
 		// retrieve JWT claims from VerifyJWT service
 		claims, err := services.VerifyJWT(jwt)
 		if err != nil || claims == nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			fmt.Fprintf(w, "Unable to verify JWT: %v", err)
+			response.Error(w, http.StatusUnauthorized, "Unable to verify JWT", err)
 			return
 		}
 		// return controller provided as arg with JWT claims attached
@@ -97,16 +90,13 @@ func (ac *AuthController) Refresh(w http.ResponseWriter, r *http.Request, c *mod
 	}
 	jwtCookie, err := services.CreateJWT(c.ID, c.Username, c.Is_admin, jwtCookieName)
 	if err != nil || jwtCookie == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to generate JWT: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to generate JWT", err)
 		return
 	}
 	// set jwt as cookie
 	http.SetCookie(w, jwtCookie)
 	// return 200ok and jwt as cookie
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(jwtCookie)
+	response.JSON(w, http.StatusOK, jwtCookie)
 }
 
 // Logout
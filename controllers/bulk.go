@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/response"
+)
+
+// bulkCheck
+// Authorizes a single item of a bulk operation, returns 200 if it may be changed, otherwise the status and reason
+type bulkCheck func(id int64) (int, string)
+
+// bulkApply
+// Changes the allowed items in a single transaction, returns an error or nil per id and whether it was committed
+type bulkApply func(ids []int64, atomic bool) ([]error, bool, error)
+
+// runBulk
+// Decodes BulkRequest, checks each item, applies the allowed ones, responds with BulkResult, 422 if nothing was committed
+func runBulk(w http.ResponseWriter, r *http.Request, check bulkCheck, apply bulkApply) {
+	var bulkReq models.BulkRequest
+	if !decodeBody(w, r, &bulkReq) {
+		return
+	}
+	result := models.BulkResult{Mode: "atomic", Committed: true}
+	if bulkReq.Mode != nil {
+		result.Mode = *bulkReq.Mode
+	}
+	atomic := result.Mode == "atomic"
+	// check each distinct id, keeping request order
+	seen := map[int64]bool{}
+	var allowed []int64
+	allowedIdx := map[int64]int{}
+	rejected := false
+	for _, id := range bulkReq.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		item := models.BulkItemResult{ID: id, Status: http.StatusOK}
+		status, reason := check(id)
+		if status != http.StatusOK {
+			item.Status = status
+			item.Error = &reason
+			rejected = true
+		} else {
+			allowedIdx[id] = len(result.Results)
+			allowed = append(allowed, id)
+		}
+		result.Results = append(result.Results, item)
+	}
+	// in atomic mode a rejected item means nothing is attempted
+	if atomic && rejected {
+		result.Committed = false
+	} else if len(allowed) > 0 {
+		errs, committed, err := apply(allowed, atomic)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "Unable to run bulk operation", err)
+			return
+		}
+		result.Committed = committed
+		for i, id := range allowed {
+			if errs[i] != nil {
+				log.Printf("Bulk operation failed for ID %d: %v", id, errs[i])
+				reason := "Unable to apply change"
+				result.Results[allowedIdx[id]].Status = http.StatusInternalServerError
+				result.Results[allowedIdx[id]].Error = &reason
+			}
+		}
+	}
+	// items that passed but were rolled back with the rest
+	for i := range result.Results {
+		item := &result.Results[i]
+		if item.Status == http.StatusOK && !result.Committed {
+			reason := "Not applied, another item failed"
+			item.Status = http.StatusFailedDependency
+			item.Error = &reason
+		}
+		if item.Status == http.StatusOK {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	status := http.StatusOK
+	if !result.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+	response.JSON(w, status, result)
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+type CalendarController struct {
+	svc *services.Service
+}
+
+func NewCalendarController(repo repository.Repositories) *CalendarController {
+	return &CalendarController{svc: services.New(repo)}
+}
+
+// GetFeed
+// Retrieves token param, calls BuildCalendar service, returns iCalendar feed, filtered by ?vehicle= and ?label= params
+func (cc *CalendarController) GetFeed(w http.ResponseWriter, r *http.Request) {
+	// the token is the only credential, calendar clients cannot send auth headers
+	calendarToken, err := cc.svc.GetCalendarToken(chi.URLParam(r, "token"))
+	if err != nil || calendarToken == nil {
+		response.Error(w, http.StatusNotFound, "Calendar not found", nil)
+		return
+	}
+	// get URL query params, filters must be ids
+	vehicleId := r.URL.Query().Get("vehicle")
+	labelId := r.URL.Query().Get("label")
+	for _, id := range []string{vehicleId, labelId} {
+		if _, err := strconv.ParseInt(id, 10, 64); len(id) > 0 && err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Vehicle and label filters must be integers", err)
+			return
+		}
+	}
+	// call BuildCalendar service
+	feed, err := cc.svc.BuildCalendar(calendarToken.User, &vehicleId, &labelId)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to build calendar", err)
+		return
+	}
+	// respond with calendar
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write(feed)
+}
+
+// CreateToken
+// Retrieves username param, validates request, calls CreateCalendarToken service, returns CalendarToken, replaces any existing token
+func (cc *CalendarController) CreateToken(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	user := cc.getUser(w, r, c)
+	if user == nil {
+		return
+	}
+	// call CreateCalendarToken service
+	calendarToken, err := cc.svc.CreateCalendarToken(user.ID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to create calendar token", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusCreated, calendarToken)
+}
+
+// DeleteToken
+// Retrieves username param, validates request, calls DeleteCalendarToken service
+func (cc *CalendarController) DeleteToken(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	user := cc.getUser(w, r, c)
+	if user == nil {
+		return
+	}
+	// call DeleteCalendarToken service
+	err := cc.svc.DeleteCalendarToken(user.ID)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "Unable to delete calendar token", err)
+		return
+	}
+	// respond with text
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Calendar token for %v has been deleted", user.Username)
+}
+
+// getUser
+// Retrieves username param, confirms requesting user is that user or admin, writes error response and returns nil otherwise
+func (cc *CalendarController) getUser(w http.ResponseWriter, r *http.Request, c *models.Claims) *models.User {
+	// get username from url params
+	username := chi.URLParam(r, "username")
+	// if requesting users username doesnt match username param, and they are not an admin, throw error
+	if (c.Username != username) && (c.Is_admin != true) {
+		response.Error(w, http.StatusForbidden, "Calendar tokens can only be managed by admins and themselves", nil)
+		return nil
+	}
+	user, err := cc.svc.GetUserByUsername(username)
+	if err != nil || user == nil {
+		response.Error(w, http.StatusNotFound, "User not found", err)
+		return nil
+	}
+	return user
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+type CommentController struct {
+	svc *services.Service
+}
+
+func NewCommentController(repo repository.Repositories) *CommentController {
+	return &CommentController{svc: services.New(repo)}
+}
+
+// getAccessibleJob
+// Retrieves jobId param, gets Job under the same rules as JobController.GetJob, writes error response and returns nil if not found
+func (cc *CommentController) getAccessibleJob(w http.ResponseWriter, r *http.Request) *models.Job {
+	// get job from url
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Job ID must be an integer", err)
+		return nil
+	}
+	// get Job data
+	job, err := cc.svc.GetJob(jobId)
+	if job == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
+		return nil
+	}
+	// anyone who can read the job can read and add to its discussion
+	return job
+}
+
+// getComment
+// Retrieves commentId param, gets Comment of job, writes error response and returns nil if not found
+func (cc *CommentController) getComment(w http.ResponseWriter, r *http.Request, job *models.Job) *models.Comment {
+	// get comment id from url params, parse into int
+	commentId, err := strconv.ParseInt(chi.URLParam(r, "commentId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Comment ID must be an integer", err)
+		return nil
+	}
+	comment, err := cc.svc.GetComment(job.ID, commentId)
+	if err != nil || comment == nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Comment ID %d not found", commentId), err)
+		return nil
+	}
+	return comment
+}
+
+// GetComment
+// Retrieves ids params, calls GetComment service, returns Comment
+func (cc *CommentController) GetComment(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	job := cc.getAccessibleJob(w, r)
+	if job == nil {
+		return
+	}
+	comment := cc.getComment(w, r, job)
+	if comment == nil {
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, comment)
+}
+
+// ListComments
+// Retrieves any URL query params, calls ListComments service, returns Comment list oldest first
+func (cc *CommentController) ListComments(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	job := cc.getAccessibleJob(w, r)
+	if job == nil {
+		return
+	}
+	// get URL query params
+	taskId := r.URL.Query().Get("task")
+	sort := r.URL.Query().Get("sort")
+	// get pagination params, nil if not paginating
+	page, err := pageParams(r)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Invalid pagination params", err)
+		return
+	}
+	// call ListComments service
+	comments, err := cc.svc.ListComments(job.ID, &taskId, &sort, page)
+	if err != nil {
+		writeListError(w, "comments", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, listBody(comments, page))
+}
+
+// CreateComment
+// Takes NewComment as request body, validates it, calls CreateComment service, returns Comment
+func (cc *CommentController) CreateComment(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var newComment *models.NewComment
+	job := cc.getAccessibleJob(w, r)
+	if job == nil {
+		return
+	}
+	// get comment data from request body
+	if !decodeBody(w, r, &newComment) {
+		return
+	}
+	// send to CreateComment service, return Comment
+	comment, err := cc.svc.WithActor(c.ID).CreateComment(*newComment, job, c.ID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to create comment", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusCreated, comment)
+}
+
+// EditComment
+// Takes Comment as request body, author only, calls EditComment service, returns Comment
+func (cc *CommentController) EditComment(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var comment models.Comment
+	job := cc.getAccessibleJob(w, r)
+	if job == nil {
+		return
+	}
+	// get comment data from request body
+	if !decodeBody(w, r, &comment) {
+		return
+	}
+	comment.Job = job.ID
+	currentComment, err := cc.svc.GetComment(job.ID, comment.ID)
+	if err != nil || currentComment == nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Comment ID %d not found", comment.ID), err)
+		return
+	}
+	// only the author may change what they said
+	if currentComment.User != c.ID {
+		response.Error(w, http.StatusForbidden, "Must be author to edit a comment", nil)
+		return
+	}
+	// call EditComment service, return updated Comment
+	updatedComment, err := cc.svc.WithActor(c.ID).EditComment(comment, job, c.ID)
+	if err != nil || updatedComment == nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to edit comment", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, updatedComment)
+}
+
+// DeleteComment
+// Retrieves ids params, author only, calls DeleteComment service
+func (cc *CommentController) DeleteComment(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	job := cc.getAccessibleJob(w, r)
+	if job == nil {
+		return
+	}
+	comment := cc.getComment(w, r, job)
+	if comment == nil {
+		return
+	}
+	// only the author may take back what they said
+	if comment.User != c.ID {
+		response.Error(w, http.StatusForbidden, "Must be author to delete a comment", nil)
+		return
+	}
+	err := cc.svc.WithActor(c.ID).DeleteComment(job, comment.ID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to delete comment", err)
+		return
+	}
+	// respond with text
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Comment ID %v has been deleted", comment.ID)
+}
+
+// ListCommentHistory
+// Retrieves ids params, calls ListCommentRevisions service, returns CommentRevision list newest first
+func (cc *CommentController) ListCommentHistory(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	job := cc.getAccessibleJob(w, r)
+	if job == nil {
+		return
+	}
+	comment := cc.getComment(w, r, job)
+	if comment == nil {
+		return
+	}
+	revisions, err := cc.svc.ListCommentRevisions(comment.ID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to retrieve comment history", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, revisions)
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/response"
+)
+
+// max size of a csv import upload, 10MB
+const maxCSVSize = 10 << 20
+
+// wantsCSV
+// Returns whether request asked for csv, via ?format=csv or Accept: text/csv
+func wantsCSV(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// writeCSV
+// Renders csv with given writer func, responds with it as a downloadable file
+func writeCSV(w http.ResponseWriter, filename string, write func(io.Writer) error) {
+	// render first so errors can still be reported
+	var data bytes.Buffer
+	err := write(&data)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to convert to CSV response", err)
+		return
+	}
+	// respond with csv
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data.Bytes())
+}
+
+// csvMapping
+// Retrieves repeated ?map=<csv header>:<field> params, returns mapping of csv header to field
+func csvMapping(r *http.Request) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, m := range r.URL.Query()["map"] {
+		header, field, ok := strings.Cut(m, ":")
+		if !ok || len(header) == 0 || len(field) == 0 {
+			return nil, fmt.Errorf("Column mapping %q must look like header:field", m)
+		}
+		mapping[header] = field
+	}
+	return mapping, nil
+}
+
+// writeCSVImportResult
+// Responds with CSVImportResult, 200 for previews, 422 if rows were invalid, 201 once created
+func writeCSVImportResult(w http.ResponseWriter, result *models.CSVImportResult) {
+	// respond with json
+	status := http.StatusCreated
+	if result.Preview {
+		status = http.StatusOK
+	} else if len(result.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	response.JSON(w, status, result)
+}
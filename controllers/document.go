@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+// max size of a document attachment upload, 10MB
+const maxAttachmentSize = 10 << 20
+
+type DocumentController struct {
+	svc *services.Service
+}
+
+func NewDocumentController(repo repository.Repositories) *DocumentController {
+	return &DocumentController{svc: services.New(repo)}
+}
+
+// getOwnedVehicle
+// Retrieves vehicleId param, gets Vehicle and confirms requesting user owns it or is admin, writes error response and returns nil otherwise
+func (dc *DocumentController) getOwnedVehicle(w http.ResponseWriter, r *http.Request, c *models.Claims) *models.Vehicle {
+	// get vehicle from url
+	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "vehicleId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Vehicle ID must be an integer", err)
+		return nil
+	}
+	// get Vehicle Data
+	vehicle, err := dc.svc.GetVehicle(vehicleId)
+	if vehicle == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Vehicle ID %d not found", vehicleId), err)
+		return nil
+	}
+	// if requesting users id doesnt match user from vehicle, and they are not an admin, throw error
+	if (c.ID != vehicle.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to access documents of other users vehicles", nil)
+		return nil
+	}
+	return vehicle
+}
+
+// GetDocument
+// Retrieves ids params, calls GetDocument services, returns Document
+func (dc *DocumentController) GetDocument(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	vehicle := dc.getOwnedVehicle(w, r, c)
+	if vehicle == nil {
+		return
+	}
+	// get document id from url params, parse into int
+	documentId, err := strconv.ParseInt(chi.URLParam(r, "documentId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Document ID must be an integer", err)
+		return
+	}
+	// call GetDocument service, return Document
+	document, err := dc.svc.GetDocument(vehicle.ID, documentId)
+	if err != nil || document == nil {
+		response.Error(w, http.StatusNotFound, "Document not found", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, document)
+}
+
+// ListDocuments
+// Retrieves any URL query params, calls ListDocuments service, returns Document list
+func (dc *DocumentController) ListDocuments(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var documents []*models.Document
+	vehicle := dc.getOwnedVehicle(w, r, c)
+	if vehicle == nil {
+		return
+	}
+	// get URL query params
+	typeStr := r.URL.Query().Get("type")
+	expiresBefore := r.URL.Query().Get("expiresBefore")
+	searchStr := r.URL.Query().Get("q")
+	sort := r.URL.Query().Get("sort")
+	// call ListDocuments service
+	documents, err := dc.svc.ListDocuments(vehicle.ID, &typeStr, &expiresBefore, &searchStr, &sort)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to retrieve any documents", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, documents)
+}
+
+// CreateDocument
+// Takes NewDocument as request body, validates it, calls CreateDocument service, return Document
+func (dc *DocumentController) CreateDocument(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var newDocument *models.NewDocument
+	vehicle := dc.getOwnedVehicle(w, r, c)
+	if vehicle == nil {
+		return
+	}
+	// get document data from request body
+	if !decodeBody(w, r, &newDocument) {
+		return
+	}
+	// send to CreateDocument service, return Document
+	document, err := dc.svc.WithActor(c.ID).CreateDocument(*newDocument, *vehicle)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to create document", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusCreated, document)
+}
+
+// EditDocument
+// Takes Document as request body, calls EditDocument service, return Document
+func (dc *DocumentController) EditDocument(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var document models.Document
+	vehicle := dc.getOwnedVehicle(w, r, c)
+	if vehicle == nil {
+		return
+	}
+	// get document data from request body
+	if !decodeBody(w, r, &document) {
+		return
+	}
+	// call EditDocument service, return updated Document
+	updatedDocument, err := dc.svc.WithActor(c.ID).EditDocument(document, vehicle.ID)
+	if err != nil || updatedDocument == nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to edit document", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, updatedDocument)
+}
+
+// DeleteDocument
+// Retrieves ids params, validates request, calls DeleteDocument service
+func (dc *DocumentController) DeleteDocument(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	vehicle := dc.getOwnedVehicle(w, r, c)
+	if vehicle == nil {
+		return
+	}
+	// get document id from url params, parse into int
+	documentId, err := strconv.ParseInt(chi.URLParam(r, "documentId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Document ID must be an integer", err)
+		return
+	}
+	err = dc.svc.WithActor(c.ID).DeleteDocument(vehicle.ID, documentId)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to delete document", err)
+		return
+	}
+	// respond with text
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Document ID %v has been deleted", documentId)
+}
+
+// GetAttachment
+// Retrieves ids params, responds with the documents attachment file
+func (dc *DocumentController) GetAttachment(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	vehicle := dc.getOwnedVehicle(w, r, c)
+	if vehicle == nil {
+		return
+	}
+	// get document id from url params, parse into int
+	documentId, err := strconv.ParseInt(chi.URLParam(r, "documentId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Document ID must be an integer", err)
+		return
+	}
+	// call GetDocumentAttachment service
+	name, contentType, data, err := dc.svc.GetDocumentAttachment(vehicle.ID, documentId)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "Attachment not found", err)
+		return
+	}
+	// respond with file
+	if contentType != nil {
+		w.Header().Set("Content-Type", *contentType)
+	}
+	if name != nil {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", *name))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// UploadAttachment
+// Takes file as raw request body (name via ?name= param), stores it as the documents attachment, replacing any existing one
+func (dc *DocumentController) UploadAttachment(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	vehicle := dc.getOwnedVehicle(w, r, c)
+	if vehicle == nil {
+		return
+	}
+	// get document id from url params, parse into int
+	documentId, err := strconv.ParseInt(chi.URLParam(r, "documentId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Document ID must be an integer", err)
+		return
+	}
+	// read file from request body, reject oversized files
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAttachmentSize))
+	if err != nil {
+		response.Error(w, http.StatusRequestEntityTooLarge, "Unable to read attachment", err)
+		return
+	}
+	if len(data) == 0 {
+		response.Error(w, http.StatusBadRequest, "Attachment must not be empty", nil)
+		return
+	}
+	// use provided name and content type, detect content type if not provided
+	name := r.URL.Query().Get("name")
+	if len(name) == 0 {
+		name = "document-" + strconv.FormatInt(documentId, 10)
+	}
+	contentType := r.Header.Get("Content-Type")
+	if len(contentType) == 0 {
+		contentType = http.DetectContentType(data)
+	}
+	err = dc.svc.WithActor(c.ID).UpdateDocumentAttachment(vehicle.ID, documentId, &name, &contentType, data)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to save attachment", err)
+		return
+	}
+	// respond with text
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Attachment saved to document ID %v", documentId)
+}
+
+// DeleteAttachment
+// Retrieves ids params, removes the documents attachment
+func (dc *DocumentController) DeleteAttachment(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	vehicle := dc.getOwnedVehicle(w, r, c)
+	if vehicle == nil {
+		return
+	}
+	// get document id from url params, parse into int
+	documentId, err := strconv.ParseInt(chi.URLParam(r, "documentId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Document ID must be an integer", err)
+		return
+	}
+	err = dc.svc.WithActor(c.ID).UpdateDocumentAttachment(vehicle.ID, documentId, nil, nil, nil)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to delete attachment", err)
+		return
+	}
+	// respond with text
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Attachment removed from document ID %v", documentId)
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+// interval of comments sent to keep idle streams open through proxies
+const eventHeartbeat = 30 * time.Second
+
+type EventController struct {
+	svc *services.Service
+}
+
+func NewEventController(repo repository.Repositories) *EventController {
+	return &EventController{svc: services.New(repo)}
+}
+
+// StreamEvents
+// Streams create, update and delete events of the jobs, tasks, alerts and vehicles the requesting user can access as Server-Sent Events, resumes after the Last-Event-ID header if given, sends a reset event if it can not
+func (ec *EventController) StreamEvents(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var lastEventId *int64
+	// browsers send the id of the last event received when reconnecting
+	if lastStr := r.Header.Get("Last-Event-ID"); len(lastStr) > 0 {
+		id, err := strconv.ParseInt(lastStr, 10, 64)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Last-Event-ID must be an integer", err)
+			return
+		}
+		lastEventId = &id
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.Error(w, http.StatusInternalServerError, "Streaming is not supported", nil)
+		return
+	}
+	sub, missed, resumed := ec.svc.SubscribeEvents(c.ID, c.Is_admin, lastEventId)
+	defer sub.Close()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// stop nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	// events were missed that can not be replayed, clients should reload what they show
+	if !resumed {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range missed {
+		writeEvent(w, event)
+	}
+	flusher.Flush()
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			// dropped for falling behind, client reconnects with Last-Event-ID
+			if !ok {
+				return
+			}
+			writeEvent(w, event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent
+// Takes writer and Event as args, writes it as a Server-Sent Event with its id
+func writeEvent(w http.ResponseWriter, event models.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\ndata: %s\n\n", event.ID, data)
+}
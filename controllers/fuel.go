@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+type FuelController struct {
+	svc *services.Service
+}
+
+func NewFuelController(repo repository.Repositories) *FuelController {
+	return &FuelController{svc: services.New(repo)}
+}
+
+// getOwnedVehicle
+// Retrieves vehicleId param, gets Vehicle and confirms requesting user owns it or is admin, writes error response and returns nil otherwise
+func (fc *FuelController) getOwnedVehicle(w http.ResponseWriter, r *http.Request, c *models.Claims) *models.Vehicle {
+	// get vehicle from url
+	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "vehicleId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Vehicle ID must be an integer", err)
+		return nil
+	}
+	// get Vehicle Data
+	vehicle, err := fc.svc.GetVehicle(vehicleId)
+	if vehicle == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Vehicle ID %d not found", vehicleId), err)
+		return nil
+	}
+	// if requesting users id doesnt match user from vehicle, and they are not an admin, throw error
+	if (c.ID != vehicle.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to access fuel logs of other users vehicles", nil)
+		return nil
+	}
+	return vehicle
+}
+
+// ListFuelLogs
+// Retrieves vehicleId param, calls ListFuelLogs service, returns FuelLog list newest first, as csv with Accept: text/csv or ?format=csv
+func (fc *FuelController) ListFuelLogs(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	vehicle := fc.getOwnedVehicle(w, r, c)
+	if vehicle == nil {
+		return
+	}
+	// get pagination params, nil if not paginating
+	page, err := pageParams(r)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Invalid pagination params", err)
+		return
+	}
+	// call ListFuelLogs service
+	fuelLogs, err := fc.svc.ListFuelLogs(vehicle.ID, page)
+	if err != nil {
+		writeListError(w, "fuel logs", err)
+		return
+	}
+	// respond with csv if requested
+	if wantsCSV(r) {
+		writeCSV(w, "fuel.csv", func(out io.Writer) error {
+			return services.WriteFuelLogsCSV(out, fuelLogs)
+		})
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, listBody(fuelLogs, page))
+}
+
+// ImportFuelLogs
+// Takes csv file as request body, calls ImportFuelLogsCSV service, returns CSVImportResult, ?map=<header>:<field> maps columns and ?preview=true validates without creating
+func (fc *FuelController) ImportFuelLogs(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	vehicle := fc.getOwnedVehicle(w, r, c)
+	if vehicle == nil {
+		return
+	}
+	preview := r.URL.Query().Get("preview") == "true"
+	mapping, err := csvMapping(r)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, err.Error(), nil)
+		return
+	}
+	// call ImportFuelLogsCSV service
+	result, err := fc.svc.WithActor(c.ID).ImportFuelLogsCSV(http.MaxBytesReader(w, r.Body, maxCSVSize), mapping, *vehicle, c.ID, preview)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Unable to import fuel logs", err)
+		return
+	}
+	writeCSVImportResult(w, result)
+}
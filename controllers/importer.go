@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/okdv/wrench-turn/importers"
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+type ImporterController struct {
+	svc *services.Service
+}
+
+func NewImporterController(repo repository.Repositories) *ImporterController {
+	return &ImporterController{svc: services.New(repo)}
+}
+
+// ListFormats
+// Returns names of supported tracker export formats
+func (ic *ImporterController) ListFormats(w http.ResponseWriter, r *http.Request) {
+	// respond with json
+	response.JSON(w, http.StatusOK, importers.Formats())
+}
+
+// Import
+// Takes another trackers csv export as request body, format param (or auto), calls ImportTracker service, returns TrackerImportResult, ?vehicle= picks the vehicle for per vehicle exports, ?preview=true validates without creating, ?dayFirst=true reads dates as DD/MM
+func (ic *ImporterController) Import(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var vehicle *models.Vehicle
+	format := chi.URLParam(r, "format")
+	preview := r.URL.Query().Get("preview") == "true"
+	opts := importers.Options{Day_first: r.URL.Query().Get("dayFirst") == "true"}
+	// get target vehicle, if any
+	if vehicleStr := r.URL.Query().Get("vehicle"); len(vehicleStr) > 0 {
+		vehicleId, err := strconv.ParseInt(vehicleStr, 10, 64)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Vehicle must be an integer", err)
+			return
+		}
+		vehicle, err = ic.svc.GetVehicle(vehicleId)
+		if vehicle == nil || err != nil {
+			response.Error(w, http.StatusNotFound, fmt.Sprintf("Vehicle ID %d not found", vehicleId), err)
+			return
+		}
+		// if requesting users id doesnt match user from vehicle, throw error
+		if c.ID != vehicle.User {
+			response.Error(w, http.StatusForbidden, "Can only import into your own vehicles", nil)
+			return
+		}
+	}
+	// call ImportTracker service, data is owned by requesting user
+	result, err := ic.svc.WithActor(c.ID).ImportTracker(http.MaxBytesReader(w, r.Body, maxCSVSize), format, opts, c.ID, vehicle, preview)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Unable to import", err)
+		return
+	}
+	// respond with json, 200 for previews, 422 if rows were invalid, 201 once created
+	status := http.StatusCreated
+	if preview {
+		status = http.StatusOK
+	} else if len(result.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	response.JSON(w, status, result)
+}
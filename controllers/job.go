@@ -1,21 +1,24 @@
 package controllers
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
 	"github.com/okdv/wrench-turn/services"
 )
 
 type JobController struct {
+	svc *services.Service
 }
 
-func NewJobController() *JobController {
-	return &JobController{}
+func NewJobController(repo repository.Repositories) *JobController {
+	return &JobController{svc: services.New(repo)}
 }
 
 // GetJob
@@ -24,28 +27,18 @@ func (jc *JobController) GetJob(w http.ResponseWriter, r *http.Request) {
 	// get job id from url params, parse into int
 	jobId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// call GetJob service, return Job
-	job, err := services.GetJob(jobId)
+	job, err := jc.svc.GetJob(jobId)
 	if err != nil || job == nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Job not found: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(job)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert job to JSON response: %v", err)
+		response.Error(w, http.StatusNotFound, "Job not found", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, job.Updated_at)
+	response.JSON(w, http.StatusOK, job)
 }
 
 // ListJobs
@@ -57,27 +50,31 @@ func (jc *JobController) ListJobs(w http.ResponseWriter, r *http.Request) {
 	vehicleId := r.URL.Query().Get("vehicle")
 	isTemplate := r.URL.Query().Get("template")
 	isComplete := r.URL.Query().Get("complete")
+	status := r.URL.Query().Get("status")
 	labelId := r.URL.Query().Get("label")
 	searchStr := r.URL.Query().Get("q")
 	sort := r.URL.Query().Get("sort")
-	// call ListJobs service
-	jobs, err := services.ListJobs(&userId, &vehicleId, &isTemplate, &isComplete, &labelId, &searchStr, &sort)
+	// get pagination params, nil if not paginating
+	page, err := pageParams(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to retrieve any jobs: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Invalid pagination params", err)
 		return
 	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(jobs)
+	// call ListJobs service
+	jobs, err = jc.svc.ListJobs(&userId, &vehicleId, &isTemplate, &isComplete, &status, &labelId, &searchStr, &sort, page)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Unable to convert jobs to JSON response")
+		writeListError(w, "jobs", err)
+		return
+	}
+	// respond with csv if requested
+	if wantsCSV(r) {
+		writeCSV(w, "jobs.csv", func(out io.Writer) error {
+			return services.WriteJobsCSV(out, jobs)
+		})
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusOK, listBody(jobs, page))
 }
 
 // CreateJob
@@ -85,10 +82,7 @@ func (jc *JobController) ListJobs(w http.ResponseWriter, r *http.Request) {
 func (jc *JobController) CreateJob(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	var newJob *models.NewJob
 	// get job data from request body
-	err := json.NewDecoder(r.Body).Decode(&newJob)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &newJob) {
 		return
 	}
 	// set newJob.user is nil, set to current user
@@ -97,28 +91,17 @@ func (jc *JobController) CreateJob(w http.ResponseWriter, r *http.Request, c *mo
 	}
 	// if newJob user is not requesting user, check if admin
 	if newJob.User != &c.ID && !c.Is_admin {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to create jobs for other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to create jobs for other users", nil)
 		return
 	}
 	// send to newJob service, return Job
-	job, err := services.CreateJob(*newJob)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to create job: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(job)
+	job, err := jc.svc.WithActor(c.ID).CreateJob(*newJob)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert job to JSON response: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to create job", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusCreated)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusCreated, job)
 }
 
 // EditJob
@@ -126,36 +109,71 @@ func (jc *JobController) CreateJob(w http.ResponseWriter, r *http.Request, c *mo
 func (jc *JobController) EditJob(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	var job models.Job
 	// get user data from request body
-	err := json.NewDecoder(r.Body).Decode(&job)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &job) {
 		return
 	}
 	// if requesting users id doesnt match user id in request body, and they are not an admin, throw error
 	if (c.ID != job.User) && !c.Is_admin {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to edit jobs of other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to edit jobs of other users", nil)
 		return
 	}
 	// call EditJob service, return updated Job
-	updatedJob, err := services.EditJob(job)
+	updatedJob, err := jc.svc.WithActor(c.ID).EditJob(job, c.ID, nil)
 	if err != nil || updatedJob == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to edit job: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to edit job", err)
 		return
 	}
-	// convert to JSON response
-	jsonData, err := json.Marshal(updatedJob)
+	// respond with json
+	response.JSON(w, http.StatusOK, updatedJob)
+}
+
+// PatchJob
+// Takes JSON merge patch as request body, applies it to Job, calls EditJob service, return Job
+func (jc *JobController) PatchJob(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get job id from url params, parse into int
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert job to JSON response: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get existing job data
+	currentJob, err := jc.svc.GetJob(jobId)
+	if err != nil || currentJob == nil {
+		response.Error(w, http.StatusNotFound, "Job not found", err)
+		return
+	}
+	// if requesting users id doesnt match user of job, and they are not an admin, throw error
+	if (c.ID != currentJob.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to edit jobs of other users", nil)
+		return
+	}
+	// apply patch to existing job
+	var job models.Job
+	if !decodeMergePatch(w, r, currentJob, &job) {
+		return
+	}
+	job.ID = jobId
+	// patch may hand job to another user
+	if (c.ID != job.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to edit jobs of other users", nil)
+		return
+	}
+	revision, ok := checkIfMatch(w, r, currentJob.Updated_at)
+	if !ok {
+		return
+	}
+	// call EditJob service, return updated Job
+	updatedJob, err := jc.svc.WithActor(c.ID).EditJob(job, c.ID, revision)
+	if revisionChanged(w, err) {
+		return
+	}
+	if err != nil || updatedJob == nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to edit job", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, updatedJob.Updated_at)
+	response.JSON(w, http.StatusOK, updatedJob)
 }
 
 // DeleteJob
@@ -164,8 +182,7 @@ func (jc *JobController) DeleteJob(w http.ResponseWriter, r *http.Request, c *mo
 	// get job id from url params, parse into int
 	jobId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// if admin, call DeleteJob service, otherwise call DeleteUsersJob to only allow job deletion for requesting users jobs
@@ -173,10 +190,9 @@ func (jc *JobController) DeleteJob(w http.ResponseWriter, r *http.Request, c *mo
 	if !c.Is_admin {
 		userId = &c.ID
 	}
-	err = services.DeleteJob(jobId, userId)
+	err = jc.svc.WithActor(c.ID).DeleteJob(jobId, userId)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to delete job: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to delete job", err)
 		return
 	}
 	// respond with text
@@ -196,50 +212,292 @@ func (jc *JobController) AssignJobLabel(w http.ResponseWriter, r *http.Request,
 	// get job from url
 	jobId, err := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Job ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Job ID must be an integer", err)
 		return
 	}
 	// get label id from url params, parse into int
 	labelId, err := strconv.ParseInt(chi.URLParam(r, "labelId"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// get Job Data
-	job, err := services.GetJob(jobId)
+	job, err := jc.svc.GetJob(jobId)
 	if job == nil || err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Job ID %d not found: %v", jobId, err)
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
 		return
 	}
 	// if requesting users id doesnt match user from job, and they are not an admin, throw error
 	if (c.ID != job.User) && !c.Is_admin {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to assign labels to other users jobs")
+		response.Error(w, http.StatusForbidden, "Must be admin to assign labels to other users jobs", nil)
 		return
 	}
 	// get Label Data
-	label, err := services.GetLabel(labelId)
+	label, err := jc.svc.GetLabel(labelId)
 	if label == nil || err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Label ID %d not found: %v", labelId, err)
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Label ID %d not found", labelId), err)
 		return
 	}
 	// if requesting users id doesnt match user from label, and its not an unowned label, and they are not an admin, throw error
 	if (label.User != nil) && (c.ID != *label.User) && !c.Is_admin {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to assign other users labels to jobs")
+		response.Error(w, http.StatusForbidden, "Must be admin to assign other users labels to jobs", nil)
 		return
 	}
-	_, err = services.AssignJobLabel(jobId, labelId, assign)
+	_, err = jc.svc.WithActor(c.ID).AssignJobLabel(jobId, labelId, assign)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to assign label to job: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to assign label to job", err)
 		return
 	}
 	// respond with text
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "Label ID %v has been assigned to Job ID %v", labelId, jobId)
 }
+
+// BulkDeleteJobs
+// Takes BulkRequest of job ids as request body, deletes each in a single transaction, returns BulkResult
+func (jc *JobController) BulkDeleteJobs(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	runBulk(w, r, func(jobId int64) (int, string) {
+		return jc.checkJobOwner(jobId, c, "Must be admin to delete jobs of other users")
+	}, jc.svc.WithActor(c.ID).BulkDeleteJobs)
+}
+
+// BulkAssignJobLabel
+// Takes BulkRequest of job ids as request body, assigns label to each, or unassigns it, in a single transaction, returns BulkResult
+func (jc *JobController) BulkAssignJobLabel(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get URL query params, convert to int
+	unassign := r.URL.Query().Get("unassign")
+	assign := 1
+	if unassign == "true" {
+		assign = 0
+	}
+	// get label id from url params, parse into int
+	labelId, err := strconv.ParseInt(chi.URLParam(r, "labelId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get Label Data
+	label, err := jc.svc.GetLabel(labelId)
+	if label == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Label ID %d not found", labelId), err)
+		return
+	}
+	// if requesting users id doesnt match user from label, and its not an unowned label, and they are not an admin, throw error
+	if (label.User != nil) && (c.ID != *label.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to assign other users labels to jobs", nil)
+		return
+	}
+	runBulk(w, r, func(jobId int64) (int, string) {
+		return jc.checkJobOwner(jobId, c, "Must be admin to assign labels to other users jobs")
+	}, func(jobIds []int64, atomic bool) ([]error, bool, error) {
+		return jc.svc.WithActor(c.ID).BulkAssignJobLabel(jobIds, labelId, assign, atomic)
+	})
+}
+
+// checkJobOwner
+// Checks that the job exists and belongs to the requesting user, unless they are an admin, returns http status and message
+func (jc *JobController) checkJobOwner(jobId int64, c *models.Claims, forbidden string) (int, string) {
+	job, err := jc.svc.GetJob(jobId)
+	if job == nil || err != nil {
+		return http.StatusNotFound, "Job not found"
+	}
+	if (c.ID != job.User) && !c.Is_admin {
+		return http.StatusForbidden, forbidden
+	}
+	return http.StatusOK, ""
+}
+
+// UpdateJobStatus
+// Takes JobStatusChange as request body, moves job to new status if allowed, returns Job
+func (jc *JobController) UpdateJobStatus(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var statusChange models.JobStatusChange
+	// get job id from url params, parse into int
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get status change from request body
+	if !decodeBody(w, r, &statusChange) {
+		return
+	}
+	// get Job Data
+	job, err := jc.svc.GetJob(jobId)
+	if job == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
+		return
+	}
+	// if requesting users id doesnt match user from job, and they are not an admin, throw error
+	if (c.ID != job.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to change status of other users jobs", nil)
+		return
+	}
+	// if state machine does not allow change, throw error
+	if !services.CanTransitionJobStatus(job.Status, statusChange.Status) {
+		response.Error(w, http.StatusConflict, fmt.Sprintf("Job status cannot change from %v to %v", job.Status, statusChange.Status), nil)
+		return
+	}
+	// call UpdateJobStatus service, return updated Job
+	updatedJob, err := jc.svc.WithActor(c.ID).UpdateJobStatus(jobId, statusChange.Status, c.ID, statusChange.Note)
+	if err != nil || updatedJob == nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to update job status", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, updatedJob)
+}
+
+// ListJobStatusHistory
+// Retrieves id param, calls ListJobStatusHistory service, returns status history
+func (jc *JobController) ListJobStatusHistory(w http.ResponseWriter, r *http.Request) {
+	// get job id from url params, parse into int
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// call ListJobStatusHistory service
+	history, err := jc.svc.ListJobStatusHistory(jobId)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to retrieve job status history", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, history)
+}
+
+// ListJobActivity
+// Retrieves id param, owner or admin only, returns audit events of job and its tasks newest first
+func (jc *JobController) ListJobActivity(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get job id from url params, parse into int
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	if status, message := jc.checkJobOwner(jobId, c, "Must be admin to view activity of other users jobs"); status != http.StatusOK {
+		response.Error(w, status, message, nil)
+		return
+	}
+	entity := r.URL.Query().Get("entity")
+	action := r.URL.Query().Get("action")
+	jobIdStr := strconv.FormatInt(jobId, 10)
+	writeActivity(w, r, jc.svc, nil, &entity, &action, nil, &jobIdStr)
+}
+
+// CompleteJob
+// Takes NewJobCompletion as request body, marks job done with completion details, returns JobCompletion
+func (jc *JobController) CompleteJob(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var newCompletion models.NewJobCompletion
+	// get job id from url params, parse into int
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get completion data from request body
+	if !decodeBody(w, r, &newCompletion) {
+		return
+	}
+	// if shop did the work, require its name
+	if newCompletion.Performed_by != nil && *newCompletion.Performed_by == "shop" && (newCompletion.Shop == nil || len(*newCompletion.Shop) == 0) {
+		response.Error(w, http.StatusBadRequest, "Shop name is required when performed by a shop", nil)
+		return
+	}
+	// get Job Data
+	job, err := jc.svc.GetJob(jobId)
+	if job == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
+		return
+	}
+	// if requesting users id doesnt match user from job, and they are not an admin, throw error
+	if (c.ID != job.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to complete other users jobs", nil)
+		return
+	}
+	// if state machine does not allow change, throw error
+	if !services.CanTransitionJobStatus(job.Status, "done") {
+		response.Error(w, http.StatusConflict, fmt.Sprintf("Job status cannot change from %v to done", job.Status), nil)
+		return
+	}
+	// call CompleteJob service, return JobCompletion
+	completion, err := jc.svc.WithActor(c.ID).CompleteJob(jobId, newCompletion, c.ID)
+	if err != nil || completion == nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to complete job", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusCreated, completion)
+}
+
+// GetJobCompletion
+// Retrieves id param, calls GetJobCompletion service, returns latest JobCompletion
+func (jc *JobController) GetJobCompletion(w http.ResponseWriter, r *http.Request) {
+	// get job id from url params, parse into int
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// call GetJobCompletion service, return JobCompletion
+	completion, err := jc.svc.GetJobCompletion(jobId)
+	if err != nil || completion == nil {
+		response.Error(w, http.StatusNotFound, "Job completion not found", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, completion)
+}
+
+// UndoJobCompletion
+// Retrieves id param, reverts jobs latest completion, returns Job
+func (jc *JobController) UndoJobCompletion(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get job id from url params, parse into int
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get Job Data
+	job, err := jc.svc.GetJob(jobId)
+	if job == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
+		return
+	}
+	// if requesting users id doesnt match user from job, and they are not an admin, throw error
+	if (c.ID != job.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to undo completion of other users jobs", nil)
+		return
+	}
+	// only completed jobs can be undone
+	if job.Status != "done" {
+		response.Error(w, http.StatusConflict, "Job is not complete", nil)
+		return
+	}
+	// call UndoJobCompletion service, return updated Job
+	updatedJob, err := jc.svc.WithActor(c.ID).UndoJobCompletion(jobId, c.ID)
+	if err != nil || updatedJob == nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to undo job completion", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, updatedJob)
+}
+
+// ImportJobs
+// Takes csv file as request body, calls ImportJobsCSV service, returns CSVImportResult, ?map=<header>:<field> maps columns and ?preview=true validates without creating
+func (jc *JobController) ImportJobs(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	preview := r.URL.Query().Get("preview") == "true"
+	mapping, err := csvMapping(r)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, err.Error(), nil)
+		return
+	}
+	// call ImportJobsCSV service, jobs are owned by requesting user
+	result, err := jc.svc.WithActor(c.ID).ImportJobsCSV(http.MaxBytesReader(w, r.Body, maxCSVSize), mapping, c.ID, c.Is_admin, preview)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Unable to import jobs", err)
+		return
+	}
+	writeCSVImportResult(w, result)
+}
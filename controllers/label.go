@@ -1,21 +1,23 @@
 package controllers
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
 	"github.com/okdv/wrench-turn/services"
 )
 
 type LabelController struct {
+	svc *services.Service
 }
 
-func NewLabelController() *LabelController {
-	return &LabelController{}
+func NewLabelController(repo repository.Repositories) *LabelController {
+	return &LabelController{svc: services.New(repo)}
 }
 
 // GetLabel
@@ -24,28 +26,18 @@ func (jc *LabelController) GetLabel(w http.ResponseWriter, r *http.Request) {
 	// get label id from url params, parse into int
 	labelId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// call GetLabel service, return Label
-	label, err := services.GetLabel(labelId)
+	label, err := jc.svc.GetLabel(labelId)
 	if err != nil || label == nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Label not found: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(label)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert label to JSON response: %v", err)
+		response.Error(w, http.StatusNotFound, "Label not found", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, label.Updated_at)
+	response.JSON(w, http.StatusOK, label)
 }
 
 // ListLabels
@@ -57,24 +49,20 @@ func (jc *LabelController) ListLabels(w http.ResponseWriter, r *http.Request) {
 	jobId := r.URL.Query().Get("job")
 	searchStr := r.URL.Query().Get("q")
 	sort := r.URL.Query().Get("sort")
-	// call ListLabels service
-	labels, err := services.ListLabels(&userId, &jobId, &searchStr, &sort)
+	// get pagination params, nil if not paginating
+	page, err := pageParams(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to retrieve any labels: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Invalid pagination params", err)
 		return
 	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(labels)
+	// call ListLabels service
+	labels, err = jc.svc.ListLabels(&userId, &jobId, &searchStr, &sort, page)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Unable to convert labels to JSON response")
+		writeListError(w, "labels", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusOK, listBody(labels, page))
 }
 
 // CreateLabel
@@ -82,10 +70,7 @@ func (jc *LabelController) ListLabels(w http.ResponseWriter, r *http.Request) {
 func (jc *LabelController) CreateLabel(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	var newLabel *models.NewLabel
 	// get label data from request body
-	err := json.NewDecoder(r.Body).Decode(&newLabel)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &newLabel) {
 		return
 	}
 	// set newLabel.user is nil and user is not an admin, default label user to them, only admins can create unowned labels
@@ -94,28 +79,17 @@ func (jc *LabelController) CreateLabel(w http.ResponseWriter, r *http.Request, c
 	}
 	// if newLabel user is not requesting user, check if admin
 	if newLabel.User != &c.ID && !c.Is_admin {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to create labels for other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to create labels for other users", nil)
 		return
 	}
 	// send to newLabel service, return Label
-	label, err := services.CreateLabel(*newLabel)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to create label: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(label)
+	label, err := jc.svc.WithActor(c.ID).CreateLabel(*newLabel)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert label to JSON response: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to create label", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusCreated)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusCreated, label)
 }
 
 // EditLabel
@@ -123,37 +97,72 @@ func (jc *LabelController) CreateLabel(w http.ResponseWriter, r *http.Request, c
 func (jc *LabelController) EditLabel(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	var label models.Label
 	// get user data from request body
-	err := json.NewDecoder(r.Body).Decode(&label)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &label) {
 		return
 	}
 
 	// if requesting users id doesnt match user id in request body, and they are not an admin, throw error
 	if ((label.User == nil) || (c.ID != *label.User)) && !c.Is_admin {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to edit labels of other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to edit labels of other users", nil)
 		return
 	}
 	// call EditLabel service, return updated Label
-	updatedLabel, err := services.EditLabel(label)
+	updatedLabel, err := jc.svc.WithActor(c.ID).EditLabel(label, nil)
 	if err != nil || updatedLabel == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to edit label: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to edit label", err)
 		return
 	}
-	// convert to JSON response
-	jsonData, err := json.Marshal(updatedLabel)
+	// respond with json
+	response.JSON(w, http.StatusOK, updatedLabel)
+}
+
+// PatchLabel
+// Takes JSON merge patch as request body, applies it to Label, calls EditLabel service, return Label
+func (jc *LabelController) PatchLabel(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get label id from url params, parse into int
+	labelId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert label to JSON response: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get existing label data
+	currentLabel, err := jc.svc.GetLabel(labelId)
+	if err != nil || currentLabel == nil {
+		response.Error(w, http.StatusNotFound, "Label not found", err)
+		return
+	}
+	// if requesting users id doesnt match user of label, and they are not an admin, throw error
+	if ((currentLabel.User == nil) || (c.ID != *currentLabel.User)) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to edit labels of other users", nil)
+		return
+	}
+	// apply patch to existing label
+	var label models.Label
+	if !decodeMergePatch(w, r, currentLabel, &label) {
+		return
+	}
+	label.ID = labelId
+	// patch may hand label to another user
+	if ((label.User == nil) || (c.ID != *label.User)) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to edit labels of other users", nil)
+		return
+	}
+	revision, ok := checkIfMatch(w, r, currentLabel.Updated_at)
+	if !ok {
+		return
+	}
+	// call EditLabel service, return updated Label
+	updatedLabel, err := jc.svc.WithActor(c.ID).EditLabel(label, revision)
+	if revisionChanged(w, err) {
+		return
+	}
+	if err != nil || updatedLabel == nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to edit label", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, updatedLabel.Updated_at)
+	response.JSON(w, http.StatusOK, updatedLabel)
 }
 
 // DeleteLabel
@@ -162,8 +171,7 @@ func (jc *LabelController) DeleteLabel(w http.ResponseWriter, r *http.Request, c
 	// get label id from url params, parse into int
 	labelId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// if admin not admin, only allow deleting users own labels
@@ -172,10 +180,9 @@ func (jc *LabelController) DeleteLabel(w http.ResponseWriter, r *http.Request, c
 		userId = &c.ID
 	}
 	// call delete label
-	err = services.DeleteLabel(labelId, userId)
+	err = jc.svc.WithActor(c.ID).DeleteLabel(labelId, userId)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to delete label: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to delete label", err)
 		return
 	}
 	// respond with text
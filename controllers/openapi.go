@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/okdv/wrench-turn/openapi"
+	"github.com/okdv/wrench-turn/response"
+)
+
+type OpenAPIController struct {
+}
+
+func NewOpenAPIController() *OpenAPIController {
+	return &OpenAPIController{}
+}
+
+// GetSpec
+// Returns OpenAPI document of the API
+func (oc *OpenAPIController) GetSpec(w http.ResponseWriter, r *http.Request) {
+	spec, err := openapi.Spec()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to load OpenAPI document", err)
+		return
+	}
+	// respond with json
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(spec)
+}
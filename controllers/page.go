@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+// pageParams
+// Retrieves ?limit=, ?cursor= and ?count=true params, returns Page, nil if not paginating
+func pageParams(r *http.Request) (*models.Page, error) {
+	return services.NewPage(r.URL.Query().Get("limit"), r.URL.Query().Get("cursor"), r.URL.Query().Get("count") == "true")
+}
+
+// listBody
+// Takes list items and Page as args, returns items as is, or wrapped in a PageResult envelope if paginating
+func listBody(items any, page *models.Page) any {
+	if page == nil {
+		return items
+	}
+	return services.NewPageResult(items, *page)
+}
+
+// writeListError
+// Responds to a failed list service call, 400 for cursors from a differently sorted list, 500 otherwise
+func writeListError(w http.ResponseWriter, name string, err error) {
+	if errors.Is(err, services.ErrCursorSort) {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeCursorSort, "Unable to retrieve any "+name, err)
+		return
+	}
+	response.Error(w, http.StatusInternalServerError, "Unable to retrieve any "+name, err)
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+)
+
+// media type of RFC 7396 merge patch bodies, plain JSON is accepted too
+const mergePatchType = "application/merge-patch+json"
+
+// etag
+// Formats a strong ETag from a rows updated_at
+func etag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMilli(), 10) + `"`
+}
+
+// setETag
+// Sets the ETag header, must be called before the body is written
+func setETag(w http.ResponseWriter, updatedAt time.Time) {
+	w.Header().Set("ETag", etag(updatedAt))
+}
+
+// checkIfMatch
+// Enforces an If-Match header against the current updated_at, responds with 412 and returns false if it has changed, otherwise returns the revision the edit must still match, nil if there was no header
+func checkIfMatch(w http.ResponseWriter, r *http.Request, updatedAt time.Time) (*time.Time, bool) {
+	header := r.Header.Get("If-Match")
+	// no precondition, last write wins
+	if len(header) == 0 {
+		return nil, true
+	}
+	current := etag(updatedAt)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			// edit compares updated_at in its own update, so concurrent patches with the same ETag cannot both win
+			return &updatedAt, true
+		}
+	}
+	response.Error(w, http.StatusPreconditionFailed, "Resource has changed, current ETag is "+current, nil)
+	return nil, false
+}
+
+// revisionChanged
+// Responds with 412 and returns true if an edit failed because the row changed after checkIfMatch
+func revisionChanged(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, repository.ErrRevisionChanged) {
+		return false
+	}
+	response.Error(w, http.StatusPreconditionFailed, "Resource has changed, fetch it again", nil)
+	return true
+}
+
+// decodeMergePatch
+// Applies the RFC 7396 merge patch in the request body to current, then decodes and validates the result into v like decodeBody
+func decodeMergePatch(w http.ResponseWriter, r *http.Request, current any, v any) bool {
+	if contentType := r.Header.Get("Content-Type"); len(contentType) > 0 {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != mergePatchType && mediaType != "application/json" {
+			response.Error(w, http.StatusUnsupportedMediaType, "Content-Type must be "+mergePatchType, nil)
+			return false
+		}
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.ErrorCode(w, http.StatusRequestEntityTooLarge, response.CodeBodyTooLarge, "Request body is too large", nil)
+		} else {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "Unable to read request body", err)
+		}
+		return false
+	}
+	var patch any
+	err = json.Unmarshal(body, &patch)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "Invalid request body", err)
+		return false
+	}
+	// a patch replacing the whole resource with a non-object cannot be decoded into it
+	if _, ok := patch.(map[string]any); !ok {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "Merge patch must be a JSON object", nil)
+		return false
+	}
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to apply merge patch", err)
+		return false
+	}
+	var target any
+	err = json.Unmarshal(currentJSON, &target)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to apply merge patch", err)
+		return false
+	}
+	merged, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to apply merge patch", err)
+		return false
+	}
+	// reuse decodeBody for unknown field, type and validation errors
+	r.Body = io.NopCloser(bytes.NewReader(merged))
+	return decodeBody(w, r, v)
+}
+
+// mergePatch
+// RFC 7396 MergePatch, objects are merged recursively, null removes a member, anything else replaces the target
+func mergePatch(target any, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = mergePatch(targetObj[key], value)
+		}
+	}
+	return targetObj
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/validate"
+)
+
+// max size of a JSON request body, 1MB
+const maxBodySize = 1 << 20
+
+// decodeBody
+// Decodes JSON request body into v and validates it, rejecting unknown fields and bodies over maxBodySize, responds with an error and returns false if invalid
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	return decodeBodyLimit(w, r, v, maxBodySize)
+}
+
+// decodeBodyLimit
+// Same as decodeBody, for endpoints taking bodies larger than maxBodySize
+func decodeBodyLimit(w http.ResponseWriter, r *http.Request, v any, limit int64) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err == nil && decoder.More() {
+		err = errors.New("Request body must contain a single JSON value")
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.ErrorCode(w, http.StatusRequestEntityTooLarge, response.CodeBodyTooLarge, "Request body is too large", nil)
+		} else if errors.Is(err, io.EOF) {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "Request body is required", nil)
+		} else {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "Invalid request body", err)
+		}
+		return false
+	}
+	// a null body leaves pointers nil
+	if value := reflect.ValueOf(v); value.Elem().Kind() == reflect.Pointer && value.Elem().IsNil() {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "Request body is required", nil)
+		return false
+	}
+	err = validate.Struct(v)
+	if err != nil {
+		var errs validate.Errors
+		if errors.As(err, &errs) {
+			response.Validation(w, errs)
+		} else {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "Invalid request body", err)
+		}
+		return false
+	}
+	return true
+}
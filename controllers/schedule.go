@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+	"github.com/okdv/wrench-turn/validate"
+)
+
+type ScheduleController struct {
+	svc *services.Service
+}
+
+func NewScheduleController(repo repository.Repositories) *ScheduleController {
+	return &ScheduleController{svc: services.New(repo)}
+}
+
+// GetSchedule
+// Retrieves id param, calls GetSchedule services, returns Schedule
+func (sc *ScheduleController) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	// get schedule id from url params, parse into int
+	scheduleId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// call GetSchedule service, return Schedule
+	schedule, err := sc.svc.GetSchedule(scheduleId)
+	if err != nil || schedule == nil {
+		response.Error(w, http.StatusNotFound, "Schedule not found", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, schedule)
+}
+
+// ListSchedules
+// Retrieves any URL query params, calls ListSchedules service, returns Schedule list
+func (sc *ScheduleController) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	var schedules []*models.Schedule
+	// get URL query params
+	userId := r.URL.Query().Get("user")
+	makeStr := r.URL.Query().Get("make")
+	modelStr := r.URL.Query().Get("model")
+	yearStr := r.URL.Query().Get("year")
+	searchStr := r.URL.Query().Get("q")
+	sort := r.URL.Query().Get("sort")
+	// call ListSchedules service
+	schedules, err := sc.svc.ListSchedules(&userId, &makeStr, &modelStr, &yearStr, &searchStr, &sort)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to retrieve any schedules", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, schedules)
+}
+
+// ImportSchedule
+// Takes maintenance schedule file as request body, validates it, calls ImportSchedule service, returns Schedule
+func (sc *ScheduleController) ImportSchedule(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// parse and validate schedule file from request body
+	newSchedule, err := services.ParseSchedule(http.MaxBytesReader(w, r.Body, maxBodySize))
+	var errs validate.Errors
+	if errors.As(err, &errs) {
+		response.Validation(w, errs)
+		return
+	}
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "Invalid schedule", err)
+		return
+	}
+	// set newSchedule.user is nil, set to current user, template jobs are owned by the same user
+	if newSchedule.User == nil {
+		newSchedule.User = &c.ID
+	}
+	// if newSchedule user is not requesting user, check if admin
+	if *newSchedule.User != c.ID && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to import schedules for other users", nil)
+		return
+	}
+	// send to ImportSchedule service, return Schedule
+	schedule, err := sc.svc.WithActor(c.ID).ImportSchedule(*newSchedule)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to import schedule", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusCreated, schedule)
+}
+
+// ApplySchedule
+// Applies a schedule to a vehicle, creating recurring jobs from its templates, ?force=true skips make/model/year matching
+func (sc *ScheduleController) ApplySchedule(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	force := r.URL.Query().Get("force") == "true"
+	// get vehicle id from url params, parse into int
+	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "vehicleId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Vehicle ID must be an integer", err)
+		return
+	}
+	// get schedule id from url params, parse into int
+	scheduleId, err := strconv.ParseInt(chi.URLParam(r, "scheduleId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Schedule ID must be an integer", err)
+		return
+	}
+	// get Vehicle Data
+	vehicle, err := sc.svc.GetVehicle(vehicleId)
+	if vehicle == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Vehicle ID %d not found", vehicleId), err)
+		return
+	}
+	// if requesting users id doesnt match user from vehicle, and they are not an admin, throw error
+	if (c.ID != vehicle.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to apply schedules to other users vehicles", nil)
+		return
+	}
+	// get Schedule Data
+	schedule, err := sc.svc.GetSchedule(scheduleId)
+	if schedule == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Schedule ID %d not found", scheduleId), err)
+		return
+	}
+	// if requesting users id doesnt match user from schedule, and its not an unowned schedule, and they are not an admin, throw error
+	if (schedule.User != nil) && (c.ID != *schedule.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to apply other users schedules", nil)
+		return
+	}
+	// if schedule is not meant for this vehicle, throw error unless forced
+	if !force && !services.ScheduleMatchesVehicle(*schedule, *vehicle) {
+		response.Error(w, http.StatusConflict, "Schedule does not match vehicle make, model or year, use ?force=true to apply anyway", nil)
+		return
+	}
+	// call ApplySchedule service, return created Jobs
+	jobs, err := sc.svc.WithActor(c.ID).ApplySchedule(scheduleId, *vehicle, force)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to apply schedule", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusCreated, jobs)
+}
+
+// DeleteSchedule
+// Retrieves id param, validates request, calls DeleteSchedule service
+func (sc *ScheduleController) DeleteSchedule(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get schedule id from url params, parse into int
+	scheduleId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// if not admin, only allow deleting users own schedules
+	var userId *int64 = nil
+	if !c.Is_admin {
+		userId = &c.ID
+	}
+	// call DeleteSchedule service
+	err = sc.svc.WithActor(c.ID).DeleteSchedule(scheduleId, userId)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to delete schedule", err)
+		return
+	}
+	// respond with text
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Schedule ID %v has been deleted", scheduleId)
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+// default and max number of search results
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchController struct {
+	svc *services.Service
+}
+
+func NewSearchController(repo repository.Repositories) *SearchController {
+	return &SearchController{svc: services.New(repo)}
+}
+
+// Search
+// Retrieves ?q= param, calls Search service, returns SearchResult list of the callers records (all records for admins), ?type= limits to comma separated types, ?limit= caps results
+func (sc *SearchController) Search(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get URL query params
+	q := r.URL.Query().Get("q")
+	if len(strings.TrimSpace(q)) == 0 {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Search query q is required", nil)
+		return
+	}
+	var types []string
+	if typeStr := r.URL.Query().Get("type"); len(typeStr) > 0 {
+		for _, t := range strings.Split(typeStr, ",") {
+			if !services.ValidSearchType(t) {
+				response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, fmt.Sprintf("Type must be one of %v", strings.Join(services.SearchTypes, ", ")), nil)
+				return
+			}
+			types = append(types, t)
+		}
+	}
+	limit := defaultSearchLimit
+	if limitStr := r.URL.Query().Get("limit"); len(limitStr) > 0 {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, fmt.Sprintf("Limit must be an integer between 1 and %d", maxSearchLimit), nil)
+			return
+		}
+	}
+	// admins can see everything, everyone else only their own records
+	var userId *int64
+	if !c.Is_admin {
+		userId = &c.ID
+	}
+	// call Search service
+	results, err := sc.svc.Search(q, userId, types, limit)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to search", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, results)
+}
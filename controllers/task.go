@@ -1,21 +1,25 @@
 package controllers
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
 	"github.com/okdv/wrench-turn/services"
 )
 
 type TaskController struct {
+	svc *services.Service
 }
 
-func NewTaskController() *TaskController {
-	return &TaskController{}
+func NewTaskController(repo repository.Repositories) *TaskController {
+	return &TaskController{svc: services.New(repo)}
 }
 
 // GetTask
@@ -25,28 +29,18 @@ func (tc *TaskController) GetTask(w http.ResponseWriter, r *http.Request) {
 	jobId, jobErr := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
 	taskId, taskErr := strconv.ParseInt(chi.URLParam(r, "taskId"), 10, 64)
 	if jobErr != nil || taskErr != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Ids must be an integer: %v %v", jobErr, taskErr)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, fmt.Sprintf("Ids must be an integer: %v %v", jobErr, taskErr), nil)
 		return
 	}
 	// call GetTask service, return Task
-	task, err := services.GetTask(jobId, taskId)
+	task, err := tc.svc.GetTask(jobId, taskId)
 	if err != nil || task == nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Task not found: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(task)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert task to JSON response: %v", err)
+		response.Error(w, http.StatusNotFound, "Task not found", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, task.Updated_at)
+	response.JSON(w, http.StatusOK, task)
 }
 
 // ListTasks
@@ -59,24 +53,27 @@ func (tc *TaskController) ListTasks(w http.ResponseWriter, r *http.Request) {
 	isComplete := r.URL.Query().Get("template")
 	searchStr := r.URL.Query().Get("q")
 	sort := r.URL.Query().Get("sort")
-	// call ListTasks service
-	tasks, err = services.ListTasks(jobId, &isComplete, &searchStr, &sort)
+	// get pagination params, nil if not paginating
+	page, err := pageParams(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to retrieve any tasks: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Invalid pagination params", err)
 		return
 	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(tasks)
+	// call ListTasks service
+	tasks, err = tc.svc.ListTasks(jobId, &isComplete, &searchStr, &sort, page)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Unable to convert tasks to JSON response")
+		writeListError(w, "tasks", err)
+		return
+	}
+	// respond with csv if requested
+	if wantsCSV(r) {
+		writeCSV(w, "tasks.csv", func(out io.Writer) error {
+			return services.WriteTasksCSV(out, tasks)
+		})
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusOK, listBody(tasks, page))
 }
 
 // CreateTask
@@ -86,48 +83,32 @@ func (tc *TaskController) CreateTask(w http.ResponseWriter, r *http.Request, c *
 	// get job from url
 	jobId, err := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Job ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Job ID must be an integer", err)
 		return
 	}
 	// get Job Data
-	job, err := services.GetJob(jobId)
+	job, err := tc.svc.GetJob(jobId)
 	if job == nil || err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Job ID %d not found: %v", jobId, err)
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
 		return
 	}
 	// get task data from request body
-	err = json.NewDecoder(r.Body).Decode(&newTask)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &newTask) {
 		return
 	}
 	// if newTask user is not requesting user, check if admin
 	if job.User != c.ID && c.Is_admin == false {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to create tasks for other users jobs")
+		response.Error(w, http.StatusForbidden, "Must be admin to create tasks for other users jobs", nil)
 		return
 	}
 	// send to newTask service, return Task
-	task, err := services.CreateTask(*newTask, jobId)
+	task, err := tc.svc.WithActor(c.ID).CreateTask(*newTask, jobId)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to create task: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(task)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert task to JSON response: %v", err)
+		writeTaskError(w, "Unable to create task", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusCreated)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusCreated, task)
 }
 
 // EditTask
@@ -137,62 +118,97 @@ func (tc *TaskController) EditTask(w http.ResponseWriter, r *http.Request, c *mo
 	// get job from url
 	jobId, err := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Job ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Job ID must be an integer", err)
 		return
 	}
 	// get Job Data
-	job, err := services.GetJob(jobId)
+	job, err := tc.svc.GetJob(jobId)
 	if job == nil || err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Job ID %d not found: %v", jobId, err)
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
 		return
 	}
 	// get task data from request body
-	err = json.NewDecoder(r.Body).Decode(&task)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &task) {
 		return
 	}
 	// get existing task Data
-	currentTask, err := services.GetTask(jobId, task.ID)
+	currentTask, err := tc.svc.GetTask(jobId, task.ID)
 	if currentTask == nil || err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Current task ID %d not found: %v", task.ID, err)
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Current task ID %d not found", task.ID), err)
 		return
 	}
 	// if requesting users id doesnt match user from job, and they are not an admin, throw error
 	if (c.ID != job.User) && (c.Is_admin != true) {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to edit tasks of other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to edit tasks of other users", nil)
 		return
 	}
 	// call EditTask service, return updated Task
-	updatedTask, err := services.EditTask(task, jobId)
+	updatedTask, err := tc.svc.WithActor(c.ID).EditTask(task, jobId, nil)
 	if err != nil || updatedTask == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to edit task: %v", err)
+		writeTaskError(w, "Unable to edit task", err)
 		return
 	}
-	// convert to JSON response
-	jsonData, err := json.Marshal(updatedTask)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert task to JSON response: %v", err)
+	// respond with json
+	response.JSON(w, http.StatusOK, updatedTask)
+}
+
+// PatchTask
+// Takes JSON merge patch as request body, applies it to Task, calls EditTask service, return Task
+func (tc *TaskController) PatchTask(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get job and task id from url params, parse into int
+	jobId, jobErr := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
+	taskId, taskErr := strconv.ParseInt(chi.URLParam(r, "taskId"), 10, 64)
+	if jobErr != nil || taskErr != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, fmt.Sprintf("Ids must be an integer: %v %v", jobErr, taskErr), nil)
+		return
+	}
+	// get Job Data
+	job, err := tc.svc.GetJob(jobId)
+	if job == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
+		return
+	}
+	// if requesting users id doesnt match user from job, and they are not an admin, throw error
+	if (c.ID != job.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to edit tasks of other users", nil)
+		return
+	}
+	// get existing task Data
+	currentTask, err := tc.svc.GetTask(jobId, taskId)
+	if currentTask == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Task ID %d not found", taskId), err)
+		return
+	}
+	// apply patch to existing task
+	var task models.Task
+	if !decodeMergePatch(w, r, currentTask, &task) {
+		return
+	}
+	task.ID = taskId
+	revision, ok := checkIfMatch(w, r, currentTask.Updated_at)
+	if !ok {
+		return
+	}
+	// call EditTask service, return updated Task
+	updatedTask, err := tc.svc.WithActor(c.ID).EditTask(task, jobId, revision)
+	if revisionChanged(w, err) {
+		return
+	}
+	if err != nil || updatedTask == nil {
+		writeTaskError(w, "Unable to edit task", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, updatedTask.Updated_at)
+	response.JSON(w, http.StatusOK, updatedTask)
 }
 
 // MarkComplete
-// Marks task complete, or incomplete
+// Marks task complete, or incomplete, tasks with open prerequisites are only completed with ?force=true
 func (tc *TaskController) MarkComplete(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	// get URL query params, convert to int
 	incomplete := r.URL.Query().Get("incomplete")
+	force := r.URL.Query().Get("force") == "true"
 	status := 1
 	if incomplete == "true" {
 		status = 0
@@ -200,34 +216,33 @@ func (tc *TaskController) MarkComplete(w http.ResponseWriter, r *http.Request, c
 	// get job from url
 	jobId, err := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Job ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Job ID must be an integer", err)
 		return
 	}
 	// get task id from url params, parse into int
 	taskId, err := strconv.ParseInt(chi.URLParam(r, "taskId"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// get Job Data
-	job, err := services.GetJob(jobId)
+	job, err := tc.svc.GetJob(jobId)
 	if job == nil || err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Job ID %d not found: %v", jobId, err)
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
 		return
 	}
 	// if requesting users id doesnt match user from job, and they are not an admin, throw error
 	if (c.ID != job.User) && (c.Is_admin != true) {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to edit tasks of other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to edit tasks of other users", nil)
+		return
+	}
+	err = tc.svc.WithActor(c.ID).MarkComplete(jobId, taskId, status, force)
+	if errors.Is(err, services.ErrTaskBlocked) {
+		response.Error(w, http.StatusConflict, fmt.Sprintf("Task ID %d has open prerequisites, use ?force=true to complete anyway", taskId), err)
 		return
 	}
-	err = services.MarkComplete(jobId, taskId, status)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to mark task complete: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to mark task complete", err)
 		return
 	}
 	// respond with text
@@ -235,14 +250,156 @@ func (tc *TaskController) MarkComplete(w http.ResponseWriter, r *http.Request, c
 	fmt.Fprintf(w, "Task ID %v has been marked as complete", taskId)
 }
 
+// AssignTaskLabel
+// Assign label to a task
+func (tc *TaskController) AssignTaskLabel(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get URL query params, convert to int
+	unassign := r.URL.Query().Get("unassign")
+	assign := 1
+	if unassign == "true" {
+		assign = 0
+	}
+	// get job from url
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Job ID must be an integer", err)
+		return
+	}
+	// get task id from url params, parse into int
+	taskId, err := strconv.ParseInt(chi.URLParam(r, "taskId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get label id from url params, parse into int
+	labelId, err := strconv.ParseInt(chi.URLParam(r, "labelId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get Job Data
+	job, err := tc.svc.GetJob(jobId)
+	if job == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
+		return
+	}
+	// if requesting users id doesnt match user from job, and they are not an admin, throw error
+	if (c.ID != job.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to assign labels to tasks of other users", nil)
+		return
+	}
+	// get Task Data
+	task, err := tc.svc.GetTask(jobId, taskId)
+	if task == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Task ID %d not found", taskId), err)
+		return
+	}
+	// get Label Data
+	label, err := tc.svc.GetLabel(labelId)
+	if label == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Label ID %d not found", labelId), err)
+		return
+	}
+	// if requesting users id doesnt match user from label, and its not an unowned label, and they are not an admin, throw error
+	if (label.User != nil) && (c.ID != *label.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to assign other users labels to tasks", nil)
+		return
+	}
+	_, err = tc.svc.WithActor(c.ID).AssignTaskLabel(jobId, taskId, labelId, assign)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to assign label to task", err)
+		return
+	}
+	// respond with text
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Label ID %v has been assigned to Task ID %v", labelId, taskId)
+}
+
+// BulkMarkComplete
+// Takes BulkRequest of task ids as request body, marks each complete, or incomplete, in a single transaction, returns BulkResult, tasks with open prerequisites are only completed with ?force=true
+func (tc *TaskController) BulkMarkComplete(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get URL query params, convert to int
+	incomplete := r.URL.Query().Get("incomplete")
+	force := r.URL.Query().Get("force") == "true"
+	status := 1
+	if incomplete == "true" {
+		status = 0
+	}
+	runBulk(w, r, func(taskId int64) (int, string) {
+		task, err := tc.svc.GetTaskById(taskId)
+		if task == nil || err != nil || task.Job == nil {
+			return http.StatusNotFound, "Task not found"
+		}
+		job, err := tc.svc.GetJob(*task.Job)
+		if job == nil || err != nil {
+			return http.StatusNotFound, "Job not found"
+		}
+		// if requesting users id doesnt match user from job, and they are not an admin, reject item
+		if (c.ID != job.User) && !c.Is_admin {
+			return http.StatusForbidden, "Must be admin to edit tasks of other users"
+		}
+		// reject item if its prerequisites are still open, unless forced
+		if status == 1 && !force && len(tc.svc.OpenPrerequisites(task)) > 0 {
+			return http.StatusConflict, "Task has open prerequisites"
+		}
+		return http.StatusOK, ""
+	}, func(taskIds []int64, atomic bool) ([]error, bool, error) {
+		return tc.svc.WithActor(c.ID).BulkMarkComplete(taskIds, status, atomic)
+	})
+}
+
+// ReorderTasks
+// Takes TaskOrder as request body, moves listed tasks to the front of the job in that order, returns Task list in job order
+func (tc *TaskController) ReorderTasks(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var order *models.TaskOrder
+	// get job from url
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Job ID must be an integer", err)
+		return
+	}
+	// get Job Data
+	job, err := tc.svc.GetJob(jobId)
+	if job == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
+		return
+	}
+	// if requesting users id doesnt match user from job, and they are not an admin, throw error
+	if (c.ID != job.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to edit tasks of other users", nil)
+		return
+	}
+	// get order from request body
+	if !decodeBody(w, r, &order) {
+		return
+	}
+	// call ReorderTasks service, return reordered Task list
+	tasks, err := tc.svc.WithActor(c.ID).ReorderTasks(jobId, order.Tasks)
+	if err != nil {
+		writeTaskError(w, "Unable to reorder tasks", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, tasks)
+}
+
+// writeTaskError
+// Responds to a failed task service call, 400 for parents, prerequisites or reordered tasks that are not other tasks of the job, 500 otherwise
+func writeTaskError(w http.ResponseWriter, detail string, err error) {
+	if errors.Is(err, services.ErrTaskRef) {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, detail, err)
+		return
+	}
+	response.Error(w, http.StatusInternalServerError, detail, err)
+}
+
 // DeleteTask
 // Retrieves username param, validates request, calls DeleteTask service
 func (tc *TaskController) DeleteTask(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	// get job from url
 	jobId, err := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Job ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Job ID must be an integer", err)
 		return
 	}
 	// get task id from url params, parse into int
@@ -254,33 +411,64 @@ func (tc *TaskController) DeleteTask(w http.ResponseWriter, r *http.Request, c *
 		// convert param string to int64
 		taskIdInt, err := strconv.ParseInt(taskIdParam, 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, "Task ID must be an integer: %v", err)
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Task ID must be an integer", err)
 			return
 		}
 		// assign to taskid so its not nil
 		taskId = &taskIdInt
 	}
 	// get Job Data
-	job, err := services.GetJob(jobId)
+	job, err := tc.svc.GetJob(jobId)
 	if job == nil || err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Job ID %d not found: %v", jobId, err)
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
 		return
 	}
 	// if requesting users id doesnt match user from job, and they are not an admin, throw error
 	if (c.ID != job.User) && (c.Is_admin != true) {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to edit tasks of other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to edit tasks of other users", nil)
 		return
 	}
-	err = services.DeleteTask(jobId, taskId)
+	err = tc.svc.WithActor(c.ID).DeleteTask(jobId, taskId)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to delete task: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to delete task", err)
 		return
 	}
 	// respond with text
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "Task ID %v has been deleted", taskId)
 }
+
+// ImportTasks
+// Takes csv file as request body, calls ImportTasksCSV service, returns CSVImportResult, ?map=<header>:<field> maps columns and ?preview=true validates without creating
+func (tc *TaskController) ImportTasks(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	preview := r.URL.Query().Get("preview") == "true"
+	// get job from url
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Job ID must be an integer", err)
+		return
+	}
+	mapping, err := csvMapping(r)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, err.Error(), nil)
+		return
+	}
+	// get Job Data
+	job, err := tc.svc.GetJob(jobId)
+	if job == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
+		return
+	}
+	// if job user is not requesting user, check if admin
+	if job.User != c.ID && c.Is_admin == false {
+		response.Error(w, http.StatusForbidden, "Must be admin to import tasks for other users jobs", nil)
+		return
+	}
+	// call ImportTasksCSV service
+	result, err := tc.svc.WithActor(c.ID).ImportTasksCSV(http.MaxBytesReader(w, r.Body, maxCSVSize), mapping, jobId, preview)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Unable to import tasks", err)
+		return
+	}
+	writeCSVImportResult(w, result)
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+type TimeController struct {
+	svc *services.Service
+}
+
+func NewTimeController(repo repository.Repositories) *TimeController {
+	return &TimeController{svc: services.New(repo)}
+}
+
+// getAccessibleJob
+// Retrieves jobId param, gets Job and confirms requesting user owns it or is admin, writes error response and returns nil otherwise
+func (tc *TimeController) getAccessibleJob(w http.ResponseWriter, r *http.Request, c *models.Claims) *models.Job {
+	// get job from url
+	jobId, err := strconv.ParseInt(chi.URLParam(r, "jobId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Job ID must be an integer", err)
+		return nil
+	}
+	// get Job data
+	job, err := tc.svc.GetJob(jobId)
+	if job == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Job ID %d not found", jobId), err)
+		return nil
+	}
+	// if requesting users id doesnt match user from job, and they are not an admin, throw error
+	if (c.ID != job.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to track time on other users jobs", nil)
+		return nil
+	}
+	return job
+}
+
+// StartTimer
+// Retrieves ids params, calls StartTimer service, stops the requesting users timer on any other task, returns TimeEntry
+func (tc *TimeController) StartTimer(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	job := tc.getAccessibleJob(w, r, c)
+	if job == nil {
+		return
+	}
+	// get task id from url params, parse into int
+	taskId, err := strconv.ParseInt(chi.URLParam(r, "taskId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Task ID must be an integer", err)
+		return
+	}
+	entry, err := tc.svc.WithActor(c.ID).StartTimer(job, taskId, c.ID)
+	if errors.Is(err, services.ErrTimerRunning) {
+		response.Error(w, http.StatusConflict, fmt.Sprintf("Timer is already running on task ID %d", taskId), err)
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to start timer", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusCreated, entry)
+}
+
+// StopTimer
+// Retrieves ids params, calls StopTimer service, returns stopped TimeEntry
+func (tc *TimeController) StopTimer(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	job := tc.getAccessibleJob(w, r, c)
+	if job == nil {
+		return
+	}
+	// get task id from url params, parse into int
+	taskId, err := strconv.ParseInt(chi.URLParam(r, "taskId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Task ID must be an integer", err)
+		return
+	}
+	entry, err := tc.svc.WithActor(c.ID).StopTimer(job, taskId, c.ID)
+	if errors.Is(err, services.ErrTimerStopped) {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("No timer running on task ID %d", taskId), err)
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to stop timer", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, entry)
+}
+
+// GetRunningTimer
+// Calls GetRunningTimer service, returns the requesting users running TimeEntry
+func (tc *TimeController) GetRunningTimer(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	entry, err := tc.svc.GetRunningTimer(c.ID)
+	if entry == nil || err != nil {
+		response.Error(w, http.StatusNotFound, "No timer running", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, entry)
+}
+
+// ListTimeEntries
+// Retrieves any URL query params, calls ListTimeEntries service, returns TimeEntry list oldest first
+func (tc *TimeController) ListTimeEntries(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	job := tc.getAccessibleJob(w, r, c)
+	if job == nil {
+		return
+	}
+	// get URL query params
+	taskId := r.URL.Query().Get("task")
+	userId := r.URL.Query().Get("user")
+	// get pagination params, nil if not paginating
+	page, err := pageParams(r)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Invalid pagination params", err)
+		return
+	}
+	// call ListTimeEntries service
+	entries, err := tc.svc.ListTimeEntries(job.ID, &taskId, &userId, page)
+	if err != nil {
+		writeListError(w, "time entries", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, listBody(entries, page))
+}
+
+// GetJobTime
+// Retrieves jobId param, calls GetJobTime service, returns JobTime with time tracked per task and user against estimates
+func (tc *TimeController) GetJobTime(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	job := tc.getAccessibleJob(w, r, c)
+	if job == nil {
+		return
+	}
+	jobTime, err := tc.svc.GetJobTime(job.ID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to retrieve time tracked", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, jobTime)
+}
+
+// GetEstimateReport
+// Retrieves optional user and from, to (YYYY-MM-DD) filters, reports on requesting users jobs unless admin, returns EstimateReport
+func (tc *TimeController) GetEstimateReport(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var from, to *time.Time
+	var userId *int64
+	// if not admin, only allow reporting on own jobs, admins report on everyones jobs unless filtered
+	if userStr := r.URL.Query().Get("user"); len(userStr) > 0 {
+		id, err := strconv.ParseInt(userStr, 10, 64)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "User must be an integer", err)
+			return
+		}
+		userId = &id
+	}
+	if !c.Is_admin {
+		if userId != nil && *userId != c.ID {
+			response.Error(w, http.StatusForbidden, "Must be admin to report on jobs of other users", nil)
+			return
+		}
+		userId = &c.ID
+	}
+	if fromStr := r.URL.Query().Get("from"); len(fromStr) > 0 {
+		fromDate, err := time.Parse(time.DateOnly, fromStr)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "From must be a date (YYYY-MM-DD)", err)
+			return
+		}
+		from = &fromDate
+	}
+	if toStr := r.URL.Query().Get("to"); len(toStr) > 0 {
+		toDate, err := time.Parse(time.DateOnly, toStr)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "To must be a date (YYYY-MM-DD)", err)
+			return
+		}
+		to = &toDate
+	}
+	// call BuildEstimateReport service
+	report, err := tc.svc.BuildEstimateReport(userId, from, to)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to build report", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+)
+
+type TrashController struct {
+	svc *services.Service
+}
+
+func NewTrashController(repo repository.Repositories) *TrashController {
+	return &TrashController{svc: services.New(repo)}
+}
+
+// ListTrash
+// Retrieves user URL query param, lists requesting users trash unless admin, calls ListTrash service, returns TrashItem list newest first
+func (tc *TrashController) ListTrash(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get URL query params
+	userId := r.URL.Query().Get("user")
+	// if not admin, only allow listing own trash, admins see everyones trash unless filtered
+	if !c.Is_admin {
+		if len(userId) > 0 && userId != strconv.FormatInt(c.ID, 10) {
+			response.Error(w, http.StatusForbidden, "Must be admin to list trash of other users", nil)
+			return
+		}
+		userId = strconv.FormatInt(c.ID, 10)
+	}
+	items, err := tc.svc.ListTrash(&userId)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to list trash", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, items)
+}
+
+// RestoreTrash
+// Restores a trashed job, vehicle or user along with the children trashed with it, returns restored Job, Vehicle or User
+func (tc *TrashController) RestoreTrash(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get type and id from url params, parse id into int
+	typeStr := chi.URLParam(r, "type")
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// if admin, restore anything, otherwise only the requesting users own jobs and vehicles
+	var userId *int64 = nil
+	if !c.Is_admin {
+		userId = &c.ID
+	}
+	var restored any
+	switch typeStr {
+	case "job":
+		restored, err = tc.svc.WithActor(c.ID).RestoreJob(id, userId)
+	case "vehicle":
+		restored, err = tc.svc.WithActor(c.ID).RestoreVehicle(id, userId)
+	case "user":
+		if !c.Is_admin {
+			response.Error(w, http.StatusForbidden, "Must be admin to restore users", nil)
+			return
+		}
+		restored, err = tc.svc.WithActor(c.ID).RestoreUser(id)
+	default:
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Type must be job, vehicle or user", nil)
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("%v ID %d not found in trash", typeStr, id), err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, restored)
+}
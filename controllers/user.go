@@ -1,20 +1,25 @@
 package controllers
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
 	"github.com/okdv/wrench-turn/services"
 )
 
+// max size of an account export request body, 10MB
+const maxExportSize = 10 << 20
+
 type UserController struct {
+	svc *services.Service
 }
 
-func NewUserController() *UserController {
-	return &UserController{}
+func NewUserController(repo repository.Repositories) *UserController {
+	return &UserController{svc: services.New(repo)}
 }
 
 // ListUsers
@@ -27,24 +32,20 @@ func (uc *UserController) ListUsers(w http.ResponseWriter, r *http.Request) {
 	isAdmin := r.URL.Query().Get("admin")
 	searchStr := r.URL.Query().Get("q")
 	sort := r.URL.Query().Get("sort")
-	// call ListUsers service
-	users, err := services.ListUsers(&jobId, &vehicleId, &isAdmin, &searchStr, &sort)
+	// get pagination params, nil if not paginating
+	page, err := pageParams(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to retrieve any users: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Invalid pagination params", err)
 		return
 	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(users)
+	// call ListUsers service
+	users, err = uc.svc.ListUsers(&jobId, &vehicleId, &isAdmin, &searchStr, &sort, page)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert vehicle to JSON response: %v", err)
+		writeListError(w, "users", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusOK, listBody(users, page))
 }
 
 // CreateUser
@@ -53,18 +54,14 @@ func (uc *UserController) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var newUser *models.NewUser
 	isAdmin := 0
 	// get user data from request body
-	err := json.NewDecoder(r.Body).Decode(&newUser)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &newUser) {
 		return
 	}
 	// get list of admin users, upgrade newUser to be admin if none exist
 	adminStr := "1"
-	adminUsers, err := services.ListUsers(nil, nil, &adminStr, nil, nil)
+	adminUsers, err := uc.svc.ListUsers(nil, nil, &adminStr, nil, nil, nil)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable check if existing admin users: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable check if existing admin users", err)
 		return
 	}
 	if len(adminUsers) == 0 {
@@ -72,23 +69,13 @@ func (uc *UserController) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	newUser.Is_admin = &isAdmin
 	// insert into db and return created user via corresponding service
-	user, err := services.CreateUser(*newUser)
+	user, err := uc.svc.CreateUser(*newUser)
 	if err != nil || user == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to create user: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(user)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert user to JSON response: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to create user", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusCreated)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusCreated, user)
 }
 
 // EditUser
@@ -96,36 +83,62 @@ func (uc *UserController) CreateUser(w http.ResponseWriter, r *http.Request) {
 func (uc *UserController) EditUser(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	var user models.User
 	// get user data from request body
-	err := json.NewDecoder(r.Body).Decode(&user)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &user) {
 		return
 	}
 	// if requesting users id doesnt match id in request body, and they are not an admin, throw error
 	if (c.ID != user.ID) && (c.Is_admin != true) {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Users can only be edited by admins and themselves")
+		response.Error(w, http.StatusForbidden, "Users can only be edited by admins and themselves", nil)
 		return
 	}
 	// call EditUser service, return updated User
-	updatedUser, err := services.EditUser(user)
+	updatedUser, err := uc.svc.WithActor(c.ID).EditUser(user, nil)
 	if err != nil || updatedUser == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to edit user: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to edit user", err)
 		return
 	}
-	// convert to JSON response
-	jsonData, err := json.Marshal(updatedUser)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert user to JSON response: %v", err)
+	// respond with json
+	response.JSON(w, http.StatusOK, updatedUser)
+}
+
+// PatchUser
+// Takes JSON merge patch as request body, applies it to User, calls EditUser service, returns User
+func (uc *UserController) PatchUser(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get username from url params
+	username := chi.URLParam(r, "username")
+	// if requesting users username doesnt match username param, and they are not an admin, throw error
+	if (c.Username != username) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Users can only be edited by admins and themselves", nil)
+		return
+	}
+	// get existing user data
+	currentUser, err := uc.svc.GetUserByUsername(username)
+	if err != nil || currentUser == nil {
+		response.Error(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	// apply patch to existing user
+	var user models.User
+	if !decodeMergePatch(w, r, currentUser, &user) {
+		return
+	}
+	user.ID = currentUser.ID
+	revision, ok := checkIfMatch(w, r, currentUser.Updated_at)
+	if !ok {
+		return
+	}
+	// call EditUser service, return updated User
+	updatedUser, err := uc.svc.WithActor(c.ID).EditUser(user, revision)
+	if revisionChanged(w, err) {
+		return
+	}
+	if err != nil || updatedUser == nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to edit user", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, updatedUser.Updated_at)
+	response.JSON(w, http.StatusOK, updatedUser)
 }
 
 // GetUserByUsername
@@ -134,23 +147,14 @@ func (uc *UserController) GetUserByUsername(w http.ResponseWriter, r *http.Reque
 	// get username from url params
 	username := chi.URLParam(r, "username")
 	// pass to service for user retrieval
-	user, err := services.GetUserByUsername(username)
+	user, err := uc.svc.GetUserByUsername(username)
 	if err != nil || user == nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "User not found: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(user)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert user to JSON response: %v", err)
+		response.Error(w, http.StatusNotFound, "User not found", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, user.Updated_at)
+	response.JSON(w, http.StatusOK, user)
 }
 
 // DeleteUser
@@ -160,15 +164,13 @@ func (uc *UserController) DeleteUser(w http.ResponseWriter, r *http.Request, c *
 	username := chi.URLParam(r, "username")
 	// if requesting users username doesnt match username param, and they are not an admin, throw error
 	if (c.Username != username) && (c.Is_admin != true) {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Users can only be deleted by admins and themselves")
+		response.Error(w, http.StatusForbidden, "Users can only be deleted by admins and themselves", nil)
 		return
 	}
 	// call DeleteUser service
-	err := services.DeleteUser(username)
+	err := uc.svc.WithActor(c.ID).DeleteUser(username)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to delete user: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to delete user", err)
 		return
 	}
 	// respond with text
@@ -181,47 +183,39 @@ func (uc *UserController) DeleteUser(w http.ResponseWriter, r *http.Request, c *
 func (uc *UserController) UpdatePassword(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	var passwords *models.Passwords
 	// get Passwords data from request body
-	err := json.NewDecoder(r.Body).Decode(&passwords)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &passwords) {
 		return
 	}
 	// if request is not admin, run additional validations
 	if c.Is_admin == false {
 		// err if no current password
 		if passwords.CurrentPassword == nil {
-			w.WriteHeader(http.StatusForbidden)
-			fmt.Fprint(w, "Unless admin, current password must be provided")
+			response.Error(w, http.StatusForbidden, "Unless admin, current password must be provided", nil)
 			return
 		}
 		// err if requester user is not requested user
 		if passwords.Username != c.Username {
-			w.WriteHeader(http.StatusForbidden)
-			fmt.Fprint(w, "User passwords can only be updated by admins and the user themselves")
+			response.Error(w, http.StatusForbidden, "User passwords can only be updated by admins and the user themselves", nil)
 			return
 		}
 		// retrieve auth info (including bool for if passwords match)
-		_, _, _, _, valid, err, _ := services.RetrieveAuthInfo(&models.Credentials{
+		_, _, _, _, valid, err, _ := uc.svc.RetrieveAuthInfo(&models.Credentials{
 			Username: passwords.Username,
 			Password: *passwords.CurrentPassword,
 		})
 		if !valid {
-			w.WriteHeader(http.StatusUnauthorized)
-			fmt.Fprint(w, "Incorrect password")
+			response.Error(w, http.StatusUnauthorized, "Incorrect password", nil)
 			return
 		}
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "Unable to validate current password: %v", err)
+			response.Error(w, http.StatusInternalServerError, "Unable to validate current password", err)
 			return
 		}
 	}
 	// call UpdatePassword service
-	err = services.UpdatePassword(passwords.Username, passwords.NewPassword)
+	err := uc.svc.WithActor(c.ID).UpdatePassword(passwords.Username, passwords.NewPassword)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to update password: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to update password", err)
 		return
 	}
 	// respond with text
@@ -229,3 +223,78 @@ func (uc *UserController) UpdatePassword(w http.ResponseWriter, r *http.Request,
 	fmt.Fprint(w, "Password updated")
 	return
 }
+
+// ExportAccount
+// Retrieves username param, validates request, calls ExportAccount service, returns AccountExport as a downloadable JSON file
+func (uc *UserController) ExportAccount(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get username from url params
+	username := chi.URLParam(r, "username")
+	// if requesting users username doesnt match username param, and they are not an admin, throw error
+	if (c.Username != username) && (c.Is_admin != true) {
+		response.Error(w, http.StatusForbidden, "Accounts can only be exported by admins and themselves", nil)
+		return
+	}
+	user, err := uc.svc.GetUserByUsername(username)
+	if err != nil || user == nil {
+		response.Error(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	// call ExportAccount service
+	export, err := uc.svc.ExportAccount(*user)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to export account", err)
+		return
+	}
+	// respond with json file
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "wrench-turn-"+username+".json"))
+	response.JSON(w, http.StatusOK, export)
+}
+
+// ImportAccount
+// Takes AccountExport as request body, validates request, calls ImportAccount service, returns ImportResult, ?dryRun=true reports without changing anything, ?labels=rename keeps conflicting labels separate instead of merging
+func (uc *UserController) ImportAccount(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var export models.AccountExport
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	labelConflicts := r.URL.Query().Get("labels")
+	if len(labelConflicts) == 0 {
+		labelConflicts = "merge"
+	}
+	if labelConflicts != "merge" && labelConflicts != "rename" {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Labels must be merge or rename", nil)
+		return
+	}
+	// get username from url params
+	username := chi.URLParam(r, "username")
+	// if requesting users username doesnt match username param, and they are not an admin, throw error
+	if (c.Username != username) && (c.Is_admin != true) {
+		response.Error(w, http.StatusForbidden, "Accounts can only be imported into by admins and themselves", nil)
+		return
+	}
+	user, err := uc.svc.GetUserByUsername(username)
+	if err != nil || user == nil {
+		response.Error(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	// get export from request body, validating every record in it
+	if !decodeBodyLimit(w, r, &export, maxExportSize) {
+		return
+	}
+	// reject broken archives before anything is created
+	err = services.ValidateAccountExport(export)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid export", err)
+		return
+	}
+	// call ImportAccount service
+	result, err := uc.svc.WithActor(c.ID).ImportAccount(export, *user, labelConflicts, dryRun)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to import account", err)
+		return
+	}
+	// respond with json
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	response.JSON(w, status, result)
+}
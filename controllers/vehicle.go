@@ -1,21 +1,26 @@
 package controllers
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
 	"github.com/okdv/wrench-turn/services"
 )
 
 type VehicleController struct {
+	svc *services.Service
 }
 
-func NewVehicleController() *VehicleController {
-	return &VehicleController{}
+func NewVehicleController(repo repository.Repositories) *VehicleController {
+	return &VehicleController{svc: services.New(repo)}
 }
 
 // GetVehicle
@@ -24,28 +29,18 @@ func (vc *VehicleController) GetVehicle(w http.ResponseWriter, r *http.Request)
 	// get vehicle id from url params, parse into int
 	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// call GetVehicle service, return Vehicle
-	vehicle, err := services.GetVehicle(vehicleId)
+	vehicle, err := vc.svc.GetVehicle(vehicleId)
 	if err != nil || vehicle == nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprintf(w, "Vehicle not found: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(vehicle)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert vehicle to JSON response: %v", err)
+		response.Error(w, http.StatusNotFound, "Vehicle not found", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, vehicle.Updated_at)
+	response.JSON(w, http.StatusOK, vehicle)
 }
 
 // ListVehicles
@@ -57,24 +52,27 @@ func (vc *VehicleController) ListVehicles(w http.ResponseWriter, r *http.Request
 	jobId := r.URL.Query().Get("job")
 	searchStr := r.URL.Query().Get("q")
 	sort := r.URL.Query().Get("sort")
-	// call ListVehicles service
-	vehicles, err := services.ListVehicles(&userId, &jobId, &searchStr, &sort)
+	// get pagination params, nil if not paginating
+	page, err := pageParams(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to retrieve any vehicles: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Invalid pagination params", err)
 		return
 	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(vehicles)
+	// call ListVehicles service
+	vehicles, err = vc.svc.ListVehicles(&userId, &jobId, &searchStr, &sort, page)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, "Unable to convert vehicles to JSON response")
+		writeListError(w, "vehicles", err)
+		return
+	}
+	// respond with csv if requested
+	if wantsCSV(r) {
+		writeCSV(w, "vehicles.csv", func(out io.Writer) error {
+			return services.WriteVehiclesCSV(out, vehicles)
+		})
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusOK, listBody(vehicles, page))
 }
 
 // CreateVehicle
@@ -82,10 +80,7 @@ func (vc *VehicleController) ListVehicles(w http.ResponseWriter, r *http.Request
 func (vc *VehicleController) CreateVehicle(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	var newVehicle *models.NewVehicle
 	// get vehicle data from request body
-	err := json.NewDecoder(r.Body).Decode(&newVehicle)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &newVehicle) {
 		return
 	}
 	// set newVehicle.user is nil, set to current user
@@ -94,28 +89,17 @@ func (vc *VehicleController) CreateVehicle(w http.ResponseWriter, r *http.Reques
 	}
 	// if newVehicle user is not requesting user, check if admin
 	if newVehicle.User != &c.ID && c.Is_admin == false {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to create vehicles for other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to create vehicles for other users", nil)
 		return
 	}
 	// send to NewVehicle service, return Vehicle
-	vehicle, err := services.CreateVehicle(*newVehicle)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to create vehicle: %v", err)
-		return
-	}
-	// covnert to JSON response
-	jsonData, err := json.Marshal(vehicle)
+	vehicle, err := vc.svc.WithActor(c.ID).CreateVehicle(*newVehicle)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert vehicle to JSON response: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to create vehicle", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusCreated)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	response.JSON(w, http.StatusCreated, vehicle)
 }
 
 // EditVehicle
@@ -123,36 +107,71 @@ func (vc *VehicleController) CreateVehicle(w http.ResponseWriter, r *http.Reques
 func (vc *VehicleController) EditVehicle(w http.ResponseWriter, r *http.Request, c *models.Claims) {
 	var vehicle models.Vehicle
 	// get user data from request body
-	err := json.NewDecoder(r.Body).Decode(&vehicle)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Invalid request body: %v", err)
+	if !decodeBody(w, r, &vehicle) {
 		return
 	}
 	// if requesting users id doesnt match user id in request body, and they are not an admin, throw error
 	if (c.ID != vehicle.User) && (c.Is_admin != true) {
-		w.WriteHeader(http.StatusForbidden)
-		fmt.Fprint(w, "Must be admin to edit vehicles of other users")
+		response.Error(w, http.StatusForbidden, "Must be admin to edit vehicles of other users", nil)
 		return
 	}
 	// call EditVehicle service, return updated Vehicle
-	updatedVehicle, err := services.EditVehicle(vehicle)
+	updatedVehicle, err := vc.svc.WithActor(c.ID).EditVehicle(vehicle, nil)
 	if err != nil || updatedVehicle == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to edit vehicle: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to edit vehicle", err)
 		return
 	}
-	// convert to JSON response
-	jsonData, err := json.Marshal(updatedVehicle)
+	// respond with json
+	response.JSON(w, http.StatusOK, updatedVehicle)
+}
+
+// PatchVehicle
+// Takes JSON merge patch as request body, applies it to Vehicle, calls EditVehicle service, return Vehicle
+func (vc *VehicleController) PatchVehicle(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get vehicle id from url params, parse into int
+	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to convert vehicle to JSON response: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get existing vehicle data
+	currentVehicle, err := vc.svc.GetVehicle(vehicleId)
+	if err != nil || currentVehicle == nil {
+		response.Error(w, http.StatusNotFound, "Vehicle not found", err)
+		return
+	}
+	// if requesting users id doesnt match user of vehicle, and they are not an admin, throw error
+	if (c.ID != currentVehicle.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to edit vehicles of other users", nil)
+		return
+	}
+	// apply patch to existing vehicle
+	var vehicle models.Vehicle
+	if !decodeMergePatch(w, r, currentVehicle, &vehicle) {
+		return
+	}
+	vehicle.ID = vehicleId
+	// patch may hand vehicle to another user
+	if (c.ID != vehicle.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to edit vehicles of other users", nil)
+		return
+	}
+	revision, ok := checkIfMatch(w, r, currentVehicle.Updated_at)
+	if !ok {
+		return
+	}
+	// call EditVehicle service, return updated Vehicle
+	updatedVehicle, err := vc.svc.WithActor(c.ID).EditVehicle(vehicle, revision)
+	if revisionChanged(w, err) {
+		return
+	}
+	if err != nil || updatedVehicle == nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to edit vehicle", err)
 		return
 	}
 	// respond with json
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	setETag(w, updatedVehicle.Updated_at)
+	response.JSON(w, http.StatusOK, updatedVehicle)
 }
 
 // DeleteVehicle
@@ -161,8 +180,7 @@ func (vc *VehicleController) DeleteVehicle(w http.ResponseWriter, r *http.Reques
 	// get vehicle id from url params, parse into int
 	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "ID must be an integer: %v", err)
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
 		return
 	}
 	// if admin, call DeleteVehicle service, otherwise call DeleteVehicle to only allow vehicle deletion for requesting users vehicles
@@ -170,13 +188,244 @@ func (vc *VehicleController) DeleteVehicle(w http.ResponseWriter, r *http.Reques
 	if c.Is_admin != true {
 		userId = &c.ID
 	}
-	err = services.DeleteVehicle(vehicleId, userId)
+	err = vc.svc.WithActor(c.ID).DeleteVehicle(vehicleId, userId)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Unable to delete vehicle: %v", err)
+		response.Error(w, http.StatusInternalServerError, "Unable to delete vehicle", err)
 		return
 	}
 	// respond with text
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "Vehicle ID %v has been deleted", vehicleId)
 }
+
+// AssignVehicleLabel
+// Assign label to a vehicle
+func (vc *VehicleController) AssignVehicleLabel(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get URL query params, convert to int
+	unassign := r.URL.Query().Get("unassign")
+	assign := 1
+	if unassign == "true" {
+		assign = 0
+	}
+	// get vehicle id from url params, parse into int
+	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "vehicleId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Vehicle ID must be an integer", err)
+		return
+	}
+	// get label id from url params, parse into int
+	labelId, err := strconv.ParseInt(chi.URLParam(r, "labelId"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get Vehicle Data
+	vehicle, err := vc.svc.GetVehicle(vehicleId)
+	if vehicle == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Vehicle ID %d not found", vehicleId), err)
+		return
+	}
+	// if requesting users id doesnt match user from vehicle, and they are not an admin, throw error
+	if (c.ID != vehicle.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to assign labels to other users vehicles", nil)
+		return
+	}
+	// get Label Data
+	label, err := vc.svc.GetLabel(labelId)
+	if label == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Label ID %d not found", labelId), err)
+		return
+	}
+	// if requesting users id doesnt match user from label, and its not an unowned label, and they are not an admin, throw error
+	if (label.User != nil) && (c.ID != *label.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to assign other users labels to vehicles", nil)
+		return
+	}
+	_, err = vc.svc.WithActor(c.ID).AssignVehicleLabel(vehicleId, labelId, assign)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to assign label to vehicle", err)
+		return
+	}
+	// respond with text
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Label ID %v has been assigned to Vehicle ID %v", labelId, vehicleId)
+}
+
+// ListOdometerReadings
+// Retrieves id param, calls ListOdometerReadings service, returns vehicles odometer history
+func (vc *VehicleController) ListOdometerReadings(w http.ResponseWriter, r *http.Request) {
+	// get vehicle id from url params, parse into int
+	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// call ListOdometerReadings service
+	readings, err := vc.svc.ListOdometerReadings(vehicleId)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to retrieve odometer readings", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusOK, readings)
+}
+
+// ListVehicleActivity
+// Retrieves id param, owner or admin only, returns audit events of vehicle and everything on it newest first
+func (vc *VehicleController) ListVehicleActivity(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	// get vehicle id from url params, parse into int
+	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get Vehicle Data
+	vehicle, err := vc.svc.GetVehicle(vehicleId)
+	if vehicle == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Vehicle ID %d not found", vehicleId), err)
+		return
+	}
+	// if requesting users id doesnt match user from vehicle, and they are not an admin, throw error
+	if (c.ID != vehicle.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to view activity of other users vehicles", nil)
+		return
+	}
+	entity := r.URL.Query().Get("entity")
+	action := r.URL.Query().Get("action")
+	vehicleIdStr := strconv.FormatInt(vehicleId, 10)
+	writeActivity(w, r, vc.svc, nil, &entity, &action, &vehicleIdStr, nil)
+}
+
+// CreateOdometerReading
+// Takes NewOdometerReading as request body, records it on vehicle, returns vehicles odometer history
+func (vc *VehicleController) CreateOdometerReading(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	var newReading models.NewOdometerReading
+	// get vehicle id from url params, parse into int
+	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get reading data from request body
+	if !decodeBody(w, r, &newReading) {
+		return
+	}
+	// get Vehicle Data
+	vehicle, err := vc.svc.GetVehicle(vehicleId)
+	if vehicle == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Vehicle ID %d not found", vehicleId), err)
+		return
+	}
+	// if requesting users id doesnt match user from vehicle, and they are not an admin, throw error
+	if (c.ID != vehicle.User) && !c.Is_admin {
+		response.Error(w, http.StatusForbidden, "Must be admin to record odometer of other users vehicles", nil)
+		return
+	}
+	// call CreateOdometerReading service, return odometer history
+	readings, err := vc.svc.WithActor(c.ID).CreateOdometerReading(newReading, *vehicle, c.ID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to record odometer reading", err)
+		return
+	}
+	// respond with json
+	response.JSON(w, http.StatusCreated, readings)
+}
+
+// GetReport
+// Retrieves id param and optional from, to (YYYY-MM-DD) and label filters, responds with service history report as HTML or, with ?format=pdf, PDF
+func (vc *VehicleController) GetReport(w http.ResponseWriter, r *http.Request) {
+	var from, to *time.Time
+	var label *models.Label
+	// get vehicle id from url params, parse into int
+	vehicleId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "ID must be an integer", err)
+		return
+	}
+	// get URL query params
+	format := r.URL.Query().Get("format")
+	if len(format) == 0 {
+		format = "html"
+	}
+	if format != "html" && format != "pdf" {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Format must be html or pdf", nil)
+		return
+	}
+	if fromStr := r.URL.Query().Get("from"); len(fromStr) > 0 {
+		fromDate, err := time.Parse(time.DateOnly, fromStr)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "From must be a date (YYYY-MM-DD)", err)
+			return
+		}
+		from = &fromDate
+	}
+	if toStr := r.URL.Query().Get("to"); len(toStr) > 0 {
+		toDate, err := time.Parse(time.DateOnly, toStr)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "To must be a date (YYYY-MM-DD)", err)
+			return
+		}
+		to = &toDate
+	}
+	if labelStr := r.URL.Query().Get("label"); len(labelStr) > 0 {
+		labelId, err := strconv.ParseInt(labelStr, 10, 64)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, "Label must be an integer", err)
+			return
+		}
+		label, err = vc.svc.GetLabel(labelId)
+		if label == nil || err != nil {
+			response.Error(w, http.StatusNotFound, fmt.Sprintf("Label ID %d not found", labelId), err)
+			return
+		}
+	}
+	// get Vehicle Data
+	vehicle, err := vc.svc.GetVehicle(vehicleId)
+	if vehicle == nil || err != nil {
+		response.Error(w, http.StatusNotFound, fmt.Sprintf("Vehicle ID %d not found", vehicleId), err)
+		return
+	}
+	// call BuildVehicleReport service
+	report, err := vc.svc.BuildVehicleReport(*vehicle, from, to, label)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to build report", err)
+		return
+	}
+	// respond with pdf file
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "service-history-"+strconv.FormatInt(vehicleId, 10)+".pdf"))
+		w.WriteHeader(http.StatusOK)
+		w.Write(services.RenderReportPDF(*report))
+		return
+	}
+	// render html first so template errors can still be reported
+	var html bytes.Buffer
+	err = services.RenderReportHTML(*report, &html)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Unable to render report", err)
+		return
+	}
+	// respond with html
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(html.Bytes())
+}
+
+// ImportVehicles
+// Takes csv file as request body, calls ImportVehiclesCSV service, returns CSVImportResult, ?map=<header>:<field> maps columns and ?preview=true validates without creating
+func (vc *VehicleController) ImportVehicles(w http.ResponseWriter, r *http.Request, c *models.Claims) {
+	preview := r.URL.Query().Get("preview") == "true"
+	mapping, err := csvMapping(r)
+	if err != nil {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParam, err.Error(), nil)
+		return
+	}
+	// call ImportVehiclesCSV service, vehicles are owned by requesting user
+	result, err := vc.svc.WithActor(c.ID).ImportVehiclesCSV(http.MaxBytesReader(w, r.Body, maxCSVSize), mapping, c.ID, preview)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Unable to import vehicles", err)
+		return
+	}
+	writeCSVImportResult(w, result)
+}
@@ -3,17 +3,63 @@ package db
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
 	"strconv"
 	"strings"
-	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
 )
 
 var DB *sql.DB
 
+// whether full text search index is available, requires sqlite built with FTS5 (-tags sqlite_fts5)
+var searchIndex bool
+
+// type searchTable
+// Describes a table in the full text search index, first column is the title, owner is the column restricting who can see a row, trashable tables hide rows in the trash
+type searchTable struct {
+	Type      string
+	Table     string
+	Columns   []string
+	Joins     []string
+	Owner     string
+	Parent    string
+	Trashable bool
+}
+
+// searchTables
+// Tables in the full text search index, in order results are returned when ranked equally
+var searchTables = []searchTable{
+	{Type: "job", Table: "job", Columns: []string{"name", "description", "instructions"}, Owner: "job.user", Parent: "NULL", Trashable: true},
+	{Type: "task", Table: "task", Columns: []string{"name", "description", "part_name"}, Joins: []string{"JOIN job ON job.id = task.job"}, Owner: "job.user", Parent: "task.job", Trashable: true},
+	{Type: "vehicle", Table: "vehicle", Columns: []string{"name", "description", "vin", "make", "model", "trim"}, Owner: "vehicle.user", Parent: "NULL", Trashable: true},
+	{Type: "label", Table: "label", Columns: []string{"name"}, Owner: "label.user", Parent: "NULL", Trashable: true},
+	{Type: "alert", Table: "alert", Columns: []string{"name", "description"}, Owner: "alert.user", Parent: "NULL", Trashable: true},
+}
+
+// scanned columns
+// Column lists in the order rows are scanned into models, selected by name since migrated databases have newer columns appended after older ones
+var userColumns = []string{"id", "username", "email", "description", "hashed_pw", "is_admin", "created_at", "updated_at", "deleted_at"}
+var jobColumns = []string{"id", "name", "description", "instructions", "is_template", "is_complete", "vehicle", "user", "origin_job", "repeats", "odo_interval", "time_interval", "time_interval_unit", "due_date", "completed_at", "created_at", "updated_at", "status", "due_odometer", "deleted_at"}
+var taskColumns = []string{"id", "name", "description", "is_complete", "job", "parent", "position", "part_name", "part_link", "due_date", "estimated_minutes", "completed_at", "created_at", "updated_at", "deleted_at"}
+var vehicleColumns = []string{"id", "name", "description", "type", "is_metric", "vin", "year", "make", "model", "trim", "odometer", "user", "created_at", "updated_at", "deleted_at"}
+var alertColumns = []string{"id", "name", "description", "type", "user", "vehicle", "job", "task", "is_read", "read_at", "alert_at", "created_at", "updated_at", "deleted_at"}
+
+// selectColumns
+// Returns columns prefixed by table name or alias, ready to follow SELECT
+func selectColumns(table string, columns []string) string {
+	return table + "." + strings.Join(columns, ", "+table+".")
+}
+
+// type execer
+// Satisfied by both *sql.DB and *sql.Tx, lets inserts run inside or outside of a transaction
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
 // type Like
 // Used by QueryBuilder
 type Like struct {
@@ -36,31 +82,86 @@ func CreateDatabase(filename string, sqlString string) error {
 	if err != nil {
 		return err
 	}
+	// schema.sql is current, no migrations to run on it
+	err = setSchemaVersion(db, false, len(migrations))
+	if err != nil {
+		return err
+	}
 	log.Print("Databse created successfully")
 	return nil
 }
 
 // ConnectDatabase
-// Use sqlite pkg to establish a connection
+// Use sqlite pkg to establish a connection, migrating the database if it was created by an older schema
 func ConnectDatabase(filename string) (*sql.DB, error) {
 	db, err := sql.Open("sqlite3", "./"+filename)
 	if err != nil {
 		return nil, err
 	}
+	// bring databases created by older versions up to date
+	err = MigrateDatabase(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
 	DB = db
+	CreateSearchIndex()
 	return db, nil
 }
 
+// CreateSearchIndex
+// Creates FTS5 tables kept in sync by triggers and indexes existing rows, falls back to LIKE search if sqlite was built without FTS5
+func CreateSearchIndex() {
+	var stmts []string
+	for _, t := range searchTables {
+		cols := strings.Join(t.Columns, ", ")
+		newCols := "new." + strings.Join(t.Columns, ", new.")
+		oldCols := "old." + strings.Join(t.Columns, ", old.")
+		// external content tables, the index stores no copy of the text
+		stmts = append(stmts,
+			fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %[1]s_fts USING fts5(%[2]s, content='%[1]s', content_rowid='id', tokenize='unicode61 remove_diacritics 2')", t.Table, cols),
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %[1]s_fts_insert AFTER INSERT ON %[1]s BEGIN INSERT INTO %[1]s_fts(rowid, %[2]s) VALUES (new.id, %[3]s); END", t.Table, cols, newCols),
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %[1]s_fts_delete AFTER DELETE ON %[1]s BEGIN INSERT INTO %[1]s_fts(%[1]s_fts, rowid, %[2]s) VALUES ('delete', old.id, %[3]s); END", t.Table, cols, oldCols),
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %[1]s_fts_update AFTER UPDATE ON %[1]s BEGIN INSERT INTO %[1]s_fts(%[1]s_fts, rowid, %[2]s) VALUES ('delete', old.id, %[3]s); INSERT INTO %[1]s_fts(rowid, %[2]s) VALUES (new.id, %[4]s); END", t.Table, cols, oldCols, newCols),
+			// index rows written before the index existed, or while running without FTS5
+			fmt.Sprintf("INSERT INTO %[1]s_fts(%[1]s_fts) VALUES ('rebuild')", t.Table),
+		)
+	}
+	for _, stmt := range stmts {
+		_, err := DB.Exec(stmt)
+		if err != nil {
+			log.Printf("Full text search unavailable, using LIKE search instead: %s", err)
+			// triggers left by an FTS5 build would fail every write without the module
+			for _, t := range searchTables {
+				for _, op := range []string{"insert", "delete", "update"} {
+					DB.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %s_fts_%s", t.Table, op))
+				}
+			}
+			searchIndex = false
+			return
+		}
+	}
+	searchIndex = true
+}
+
+// SearchIndexEnabled
+// Returns whether full text search index is available
+func SearchIndexEnabled() bool {
+	return searchIndex
+}
+
 // QueryBuilder
 // Take basic parts of SQL query, construct into usable query
 // May need ORM in the future if it gets too complex, but should work fine for basic queries used thus far
-func QueryBuilder(query string, joins *[]string, wheres *[]string, likes *[]Like, groupBy *string, sort *string) string {
+// If page is provided, rows start after its cursor and are limited to one page, its args must follow the querys own, see pageArgs
+func QueryBuilder(query string, joins *[]string, wheres *[]string, likes *[]Like, groupBy *string, sort *string, page *models.Page) string {
 	// start with main query (e.g. SELECT ... FROM ... AS ...)
 	var q string = query
 	// start where str to be appended to query after joins, wheres, likes
 	var whereStr string = ""
 	var sortStr string = ""
 	var groupStr string = ""
+	var limitStr string = ""
 	// loop through joins (e.g. JOIN ... AS ... ON), append to query
 	if joins != nil {
 		for _, join := range *joins {
@@ -103,79 +204,217 @@ func QueryBuilder(query string, joins *[]string, wheres *[]string, likes *[]Like
 						whereStr = whereStr + " AND"
 					}
 				}
-				// add field (e.g. table.col), then append LIKE followed by match str surrounded be wildcards (e.g. '%matchStr%')
-				whereStr = whereStr + " " + field + " LIKE " + "\"%" + like.Match + "%\""
+				// add field (e.g. table.col), then append LIKE followed by match str surrounded be wildcards (e.g. '%matchStr%'), quotes in it escaped by doubling
+				whereStr = whereStr + " " + field + " LIKE " + "'%" + strings.ReplaceAll(like.Match, "'", "''") + "%'"
 			}
 			// add closing paranthesis after all fields
 			whereStr = whereStr + ")"
 		}
 	}
+	// if paginating, sort by id after the sort column so every row has a unique position, start after cursor row
+	if page != nil && sort != nil {
+		col, desc := pageSort(*sort)
+		idCol := pageIdColumn(col)
+		dir := " ASC"
+		if desc {
+			dir = " DESC"
+		}
+		if page.Cursor != nil {
+			if len(whereStr) == 0 {
+				whereStr = " WHERE"
+			} else {
+				whereStr = whereStr + " AND"
+			}
+			// sqlite puts NULLs first ascending and last descending, rows with a NULL sort value are ordered by id alone
+			// cursor values are cast where nothing else gives postgres their type
+			if desc {
+				whereStr = whereStr + " ((" + col + " IS NULL AND (CAST(? AS TEXT) IS NOT NULL OR " + idCol + " < ?)) OR " + col + " < ? OR (" + col + " = ? AND " + idCol + " < ?))"
+			} else {
+				whereStr = whereStr + " ((" + col + " IS NULL AND CAST(? AS TEXT) IS NULL AND " + idCol + " > ?) OR (" + col + " IS NOT NULL AND (CAST(? AS TEXT) IS NULL OR " + col + " > ? OR (" + col + " = ? AND " + idCol + " > ?))))"
+			}
+		}
+		sortStr = " ORDER BY " + col + dir + ", " + idCol + dir
+		// fetch one extra row to tell whether there is another page
+		limitStr = " LIMIT " + strconv.Itoa(page.Limit+1)
+	}
 	// if groupby is set, generate that part of query
 	if groupBy != nil {
 		groupStr = " GROUP BY " + *groupBy
 	}
 	// if sort is set, generate that part of query
-	if sort != nil {
+	if sort != nil && len(sortStr) == 0 {
 		sortStr = " ORDER BY " + *sort
 	}
 	// append statements strings to query
-	q = q + whereStr + groupStr + sortStr
+	q = q + whereStr + groupStr + sortStr + limitStr
 	// log and return query
 	log.Printf("QueryBuilder: %v", q)
 	return q
 }
 
-// LabelProcessor
-// takes label cols and convert them into slice of objects
-// ideally this is done as service layer but doing in db layer prevents relooping through results
-func LabelProcessor(ids *string, names *string, colors *string, createdTimes *string, updatedTimes *string) ([]models.Label, error) {
-	var labels []models.Label
-	// if any are null, return empty labels slice
-	if ids == nil || names == nil || colors == nil || createdTimes == nil || updatedTimes == nil {
-		return labels, nil
+// pageSort
+// Takes sort (e.g. job.name DESC) as arg, returns column and whether it is descending
+func pageSort(sort string) (string, bool) {
+	fields := strings.Fields(sort)
+	return fields[0], len(fields) > 1 && strings.EqualFold(fields[1], "DESC")
+}
+
+// pageIdColumn
+// Takes sort column as arg, returns id column of same table (e.g. t.name -> t.id)
+func pageIdColumn(col string) string {
+	if i := strings.LastIndex(col, "."); i >= 0 {
+		return col[:i] + ".id"
 	}
-	// parse comma delimited values into slices by comma
-	parsedIds := strings.Split(*ids, ",")
-	parsedNames := strings.Split(*names, ",")
-	parsedColors := strings.Split(*colors, ",")
-	parsedCreatedTimes := strings.Split(*createdTimes, ",")
-	parsedUpdatedTimes := strings.Split(*updatedTimes, ",")
-	// throw error if slices are not the same length
-	parsedIdsLen := len(parsedIds)
-	if parsedIdsLen != len(parsedNames) || parsedIdsLen != len(parsedColors) || parsedIdsLen != len(parsedCreatedTimes) || parsedIdsLen != len(parsedUpdatedTimes) {
-		return labels, errors.New("Label columns from db do not have same length, returning empty labels, please try again")
-	}
-	// for loop to minlength and generate a label object for each
-	for i := 0; i < len(parsedIds); i++ {
-		// convert id string into int64
-		id, err := strconv.ParseInt(parsedIds[i], 10, 64)
-		// if error skip iteration
-		if err != nil {
-			log.Printf("Error converting label id string to int64, skipping: %v", err)
-			continue
-		}
-		// parse create and update times
-		layout := "2006-01-02 15:04:05"
-		createdTime, err := time.Parse(layout, parsedCreatedTimes[i])
+	return "id"
+}
+
+// pageArgs
+// Takes Page as arg, returns args for the cursor placeholders QueryBuilder adds, in order
+func pageArgs(page *models.Page) []any {
+	if page == nil || page.Cursor == nil {
+		return nil
+	}
+	v, id := page.Cursor.Value, page.Cursor.ID
+	if _, desc := pageSort(page.Sort); desc {
+		return []any{v, id, v, v, id}
+	}
+	return []any{v, id, v, v, v, id}
+}
+
+// pageStart
+// Takes Page, sort, count query (QueryBuilder output without sort or page) and its args, checks cursor belongs to sort, counts rows if requested
+func pageStart(page *models.Page, sort string, countQuery string, args []any) error {
+	page.Sort = sort
+	page.Next = nil
+	if page.Cursor != nil && page.Cursor.Sort != sort {
+		return repository.ErrCursorSort
+	}
+	if page.Count {
+		var total int64
+		err := DB.QueryRow("SELECT COUNT(*) FROM ("+countQuery+") AS counted", args...).Scan(&total)
 		if err != nil {
-			log.Printf("Error converting created time string to time value, skipping: %v", err)
-			continue
+			log.Printf("DB Query Error: %s", err)
+			return err
 		}
-		updatedTime, err := time.Parse(layout, parsedUpdatedTimes[i])
+		page.Total = &total
+	}
+	return nil
+}
+
+// pageNext
+// Takes Page, table and id of last row on the page as args, sets cursor to resume after it
+func pageNext(page *models.Page, table string, lastId int64) error {
+	col, _ := pageSort(page.Sort)
+	// sort value as stored, so the cursor still works if the row is edited or deleted
+	col = col[strings.LastIndex(col, ".")+1:]
+	var value *string
+	err := DB.QueryRow("SELECT CAST("+col+" AS TEXT) FROM "+table+" WHERE id=?", lastId).Scan(&value)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	page.Next = &models.PageCursor{
+		Sort:  page.Sort,
+		Value: value,
+		ID:    lastId,
+	}
+	return nil
+}
+
+// labelLinks
+// Link tables assigning labels to rows of a table, keyed by table, add a table here to assign and load its labels with the LinkedLabel queries
+// Only names registered here are put into queries, any other table is rejected
+var labelLinks = map[string]struct {
+	Table  string
+	Column string
+}{
+	"job":     {Table: "job_label", Column: "job"},
+	"task":    {Table: "task_label", Column: "task"},
+	"vehicle": {Table: "vehicle_label", Column: "vehicle"},
+}
+
+// AssignLinkedLabel
+// Takes table, id of its row and label id, creates entry in its link table, returns id
+func AssignLinkedLabel(table string, id int64, labelId int64) (*int64, error) {
+	return assignLinkedLabel(DB, table, id, labelId)
+}
+
+// assignLinkedLabel
+// Runs AssignLinkedLabel against db or transaction
+func assignLinkedLabel(ex execer, table string, id int64, labelId int64) (*int64, error) {
+	link, ok := labelLinks[table]
+	if !ok {
+		return nil, fmt.Errorf("Table %v has no labels", table)
+	}
+	// insert into db, return any errors
+	res, err := ex.Exec(fmt.Sprintf("INSERT INTO %s(%s, label) VALUES (?,?)", link.Table, link.Column), id, labelId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// get inserted entries id
+	linkId, err := res.LastInsertId()
+	return &linkId, err
+}
+
+// UnassignLinkedLabel
+// Takes table, id of its row and label id, deletes entry from its link table if it exists
+func UnassignLinkedLabel(table string, id int64, labelId int64) error {
+	link, ok := labelLinks[table]
+	if !ok {
+		return fmt.Errorf("Table %v has no labels", table)
+	}
+	res, err := DB.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s=? AND label=?", link.Table, link.Column), id, labelId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	// throw error if no rows affected
+	if rows == 0 {
+		log.Printf("No rows deleted")
+		return errors.New("No rows deleted")
+	}
+	return nil
+}
+
+// ListLinkedLabels
+// Takes table and ids of its rows, returns labels of each row keyed by id in assignment order, one query per call so lists do not query per row
+func ListLinkedLabels(table string, ids []int64) (map[int64][]models.Label, error) {
+	labels := make(map[int64][]models.Label)
+	link, ok := labelLinks[table]
+	if !ok {
+		return nil, fmt.Errorf("Table %v has no labels", table)
+	}
+	if len(ids) == 0 {
+		return labels, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	// labels are read as columns, never split out of concatenated text, so any name or color round trips
+	q := fmt.Sprintf("SELECT %[1]s.%[2]s, label.id, label.name, label.color, label.user, label.created_at, label.updated_at FROM %[1]s JOIN label ON %[1]s.label = label.id WHERE %[1]s.%[2]s IN (?%[3]s) AND label.deleted_at IS NULL ORDER BY %[1]s.id", link.Table, link.Column, strings.Repeat(",?", len(ids)-1))
+	rows, err := DB.Query(q, args...)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var label models.Label
+		err := rows.Scan(&id, &label.ID, &label.Name, &label.Color, &label.User, &label.Created_at, &label.Updated_at)
 		if err != nil {
-			log.Printf("Error converting created time string to time value, skipping: %v", err)
-			continue
-		}
-		// create Label
-		label := &models.Label{
-			ID:         id,
-			Name:       parsedNames[i],
-			Color:      &parsedColors[i],
-			Created_at: createdTime,
-			Updated_at: updatedTime,
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
 		}
-		// append to labels
-		labels = append(labels, *label)
+		labels[id] = append(labels[id], label)
 	}
-	return labels, nil
+	return labels, rows.Err()
 }
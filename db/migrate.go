@@ -0,0 +1,363 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// type migration
+// Step bringing a database created from an older schema.sql up to date, columns are only added when missing and statements must be safe to rerun
+// Stmts run on both drivers, rewritten for postgres like every query, SQLite and Postgres hold statements only one of them understands
+type migration struct {
+	Columns  []column
+	Stmts    []string
+	SQLite   []string
+	Postgres []string
+}
+
+// type column
+// Column added to an existing table, Backfill runs only when the column was added
+type column struct {
+	Table      string
+	Name       string
+	Definition string
+	Backfill   string
+}
+
+// migrations
+// Applied in order to databases whose user_version is behind, append only, a database created from schema.sql starts at len(migrations)
+var migrations = []migration{
+	// job status workflow
+	{
+		Columns: []column{
+			{Table: "job", Name: "status", Definition: "TEXT DEFAULT 'planned' NOT NULL", Backfill: "UPDATE job SET status='done' WHERE is_complete=1"},
+		},
+		Stmts: []string{
+			`CREATE TABLE IF NOT EXISTS job_status_history (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  job INTEGER NOT NULL,
+  from_status TEXT,
+  to_status TEXT NOT NULL,
+  user INTEGER,
+  note TEXT,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+			"CREATE INDEX IF NOT EXISTS job_status_history_job_idx ON job_status_history (job)",
+			"CREATE INDEX IF NOT EXISTS job_status_idx ON job (status)",
+		},
+	},
+	// job completion records
+	{
+		Columns: []column{
+			{Table: "job", Name: "due_odometer", Definition: "INTEGER"},
+		},
+		Stmts: []string{
+			`CREATE TABLE IF NOT EXISTS job_completion (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  job INTEGER NOT NULL,
+  user INTEGER,
+  odometer INTEGER,
+  performed_by TEXT NOT NULL DEFAULT 'self',
+  shop TEXT,
+  notes TEXT,
+  cost REAL,
+  completed_at DATETIME NOT NULL,
+  prior_status TEXT NOT NULL,
+  prior_odometer INTEGER,
+  odometer_reading INTEGER,
+  next_job INTEGER,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+			`CREATE TABLE IF NOT EXISTS odometer_reading (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  vehicle INTEGER NOT NULL,
+  odometer INTEGER NOT NULL,
+  source TEXT NOT NULL DEFAULT 'manual',
+  job INTEGER,
+  user INTEGER,
+  recorded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+			"CREATE INDEX IF NOT EXISTS job_completion_job_idx ON job_completion (job)",
+			"CREATE INDEX IF NOT EXISTS odometer_reading_vehicle_idx ON odometer_reading (vehicle, recorded_at)",
+		},
+	},
+	// maintenance schedule catalogs
+	{
+		Stmts: []string{
+			`CREATE TABLE IF NOT EXISTS schedule (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL,
+  description TEXT,
+  make TEXT,
+  model TEXT,
+  year_min INTEGER,
+  year_max INTEGER,
+  user INTEGER,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+			"CREATE TABLE IF NOT EXISTS schedule_job ( id INTEGER PRIMARY KEY AUTOINCREMENT, schedule INTEGER NOT NULL, job INTEGER NOT NULL )",
+			"CREATE INDEX IF NOT EXISTS schedule_job_schedule_idx ON schedule_job (schedule)",
+			"CREATE INDEX IF NOT EXISTS schedule_user_idx ON schedule (user)",
+		},
+	},
+	// vehicle documents
+	{
+		Stmts: []string{
+			`CREATE TABLE IF NOT EXISTS vehicle_document (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  type TEXT NOT NULL DEFAULT 'other',
+  number TEXT,
+  issuer TEXT,
+  description TEXT,
+  vehicle INTEGER NOT NULL,
+  user INTEGER NOT NULL,
+  issued_at DATETIME,
+  expires_at DATETIME,
+  remind_days INTEGER NOT NULL DEFAULT 30,
+  alert INTEGER,
+  attachment_name TEXT,
+  attachment_type TEXT,
+  attachment BLOB,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME
+)`,
+			"CREATE INDEX IF NOT EXISTS vehicle_document_vehicle_idx ON vehicle_document (vehicle)",
+		},
+	},
+	// calendar feed tokens
+	{
+		Stmts: []string{
+			`CREATE TABLE IF NOT EXISTS calendar_token (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user INTEGER UNIQUE NOT NULL,
+  token TEXT UNIQUE NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+		},
+	},
+	// audit log
+	{
+		Stmts: []string{
+			`CREATE TABLE IF NOT EXISTS audit_event (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  actor INTEGER,
+  action TEXT NOT NULL,
+  entity TEXT NOT NULL,
+  entity_id INTEGER NOT NULL,
+  vehicle INTEGER,
+  job INTEGER,
+  diff TEXT,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+			"CREATE INDEX IF NOT EXISTS audit_event_created_idx ON audit_event (created_at)",
+			"CREATE INDEX IF NOT EXISTS audit_event_job_idx ON audit_event (job)",
+			"CREATE INDEX IF NOT EXISTS audit_event_vehicle_idx ON audit_event (vehicle)",
+		},
+		SQLite: []string{
+			"CREATE TRIGGER IF NOT EXISTS audit_event_append_only BEFORE UPDATE ON audit_event BEGIN SELECT RAISE(ABORT, 'audit_event is append only'); END",
+		},
+		Postgres: []string{
+			"CREATE OR REPLACE FUNCTION audit_event_append_only() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'audit_event is append only'; END $$ LANGUAGE plpgsql",
+			"DROP TRIGGER IF EXISTS audit_event_append_only ON audit_event",
+			"CREATE TRIGGER audit_event_append_only BEFORE UPDATE ON audit_event FOR EACH ROW EXECUTE FUNCTION audit_event_append_only()",
+		},
+	},
+	// soft delete
+	{
+		Columns: []column{
+			{Table: "user", Name: "deleted_at", Definition: "DATETIME"},
+			{Table: "vehicle", Name: "deleted_at", Definition: "DATETIME"},
+			{Table: "job", Name: "deleted_at", Definition: "DATETIME"},
+			{Table: "task", Name: "deleted_at", Definition: "DATETIME"},
+			{Table: "alert", Name: "deleted_at", Definition: "DATETIME"},
+			{Table: "label", Name: "deleted_at", Definition: "DATETIME"},
+		},
+	},
+	// comments
+	{
+		Stmts: []string{
+			`CREATE TABLE IF NOT EXISTS comment (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  job INTEGER NOT NULL,
+  task INTEGER,
+  user INTEGER NOT NULL,
+  body TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+			`CREATE TABLE IF NOT EXISTS comment_revision (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  comment INTEGER NOT NULL,
+  body TEXT NOT NULL,
+  user INTEGER,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+			"CREATE INDEX IF NOT EXISTS comment_job_idx ON comment (job, created_at)",
+			"CREATE INDEX IF NOT EXISTS comment_revision_comment_idx ON comment_revision (comment)",
+		},
+	},
+	// task ordering, sub-tasks and dependencies
+	{
+		Columns: []column{
+			{Table: "task", Name: "parent", Definition: "INTEGER"},
+			// existing tasks keep their creation order within the job
+			{Table: "task", Name: "position", Definition: "INTEGER NOT NULL DEFAULT 0", Backfill: "UPDATE task SET position=(SELECT COUNT(*) FROM task AS t WHERE t.job=task.job AND t.id<task.id)"},
+		},
+		Stmts: []string{
+			`CREATE TABLE IF NOT EXISTS task_dependency (
+  task INTEGER NOT NULL,
+  depends_on INTEGER NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task, depends_on)
+)`,
+			"CREATE INDEX IF NOT EXISTS task_dependency_depends_on_idx ON task_dependency (depends_on)",
+			"CREATE INDEX IF NOT EXISTS task_parent_idx ON task (parent)",
+			// tasks are listed in position order within their job
+			"DROP INDEX IF EXISTS task_job_idx",
+			"CREATE INDEX task_job_idx ON task (job, position)",
+		},
+	},
+	// task time tracking
+	{
+		Columns: []column{
+			{Table: "task", Name: "estimated_minutes", Definition: "INTEGER"},
+		},
+		Stmts: []string{
+			`CREATE TABLE IF NOT EXISTS time_entry (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  job INTEGER NOT NULL,
+  task INTEGER NOT NULL,
+  user INTEGER NOT NULL,
+  started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  stopped_at DATETIME
+)`,
+			"CREATE INDEX IF NOT EXISTS time_entry_job_idx ON time_entry (job, started_at)",
+			"CREATE INDEX IF NOT EXISTS time_entry_user_idx ON time_entry (user, stopped_at)",
+		},
+	},
+	// labels on tasks and vehicles
+	{
+		Stmts: []string{
+			"CREATE TABLE IF NOT EXISTS task_label ( id INTEGER PRIMARY KEY AUTOINCREMENT, task INTEGER NOT NULL, label INTEGER NOT NULL )",
+			"CREATE TABLE IF NOT EXISTS vehicle_label ( id INTEGER PRIMARY KEY AUTOINCREMENT, vehicle INTEGER NOT NULL, label INTEGER NOT NULL )",
+			"CREATE INDEX IF NOT EXISTS task_label_task_idx ON task_label (task)",
+			"CREATE INDEX IF NOT EXISTS vehicle_label_vehicle_idx ON vehicle_label (vehicle)",
+		},
+	},
+	// fuel logs
+	{
+		Stmts: []string{
+			`CREATE TABLE IF NOT EXISTS fuel_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  vehicle INTEGER NOT NULL,
+  user INTEGER,
+  filled_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  odometer INTEGER,
+  volume REAL NOT NULL,
+  cost REAL,
+  is_full INTEGER NOT NULL DEFAULT 1,
+  station TEXT,
+  notes TEXT,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+			"CREATE INDEX IF NOT EXISTS fuel_log_vehicle_idx ON fuel_log (vehicle, filled_at)",
+		},
+	},
+}
+
+// MigrateDatabase
+// Runs the migrations newer than the databases schema version in a single transaction
+func MigrateDatabase(db *sql.DB) error {
+	postgres := isPostgres(db)
+	version, err := schemaVersion(db, postgres)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	if version >= len(migrations) {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	defer tx.Rollback()
+	// postgres lists columns in information_schema, SQLite with a pragma
+	columnExists := "SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name=?"
+	if postgres {
+		columnExists = "SELECT COUNT(*) > 0 FROM information_schema.columns WHERE table_schema=current_schema() AND table_name=? AND column_name=?"
+	}
+	for i, m := range migrations[version:] {
+		for _, c := range m.Columns {
+			var exists bool
+			err = tx.QueryRow(columnExists, c.Table, c.Name).Scan(&exists)
+			if err != nil {
+				log.Printf("DB Execution Error: %s", err)
+				return err
+			}
+			if exists {
+				continue
+			}
+			stmts := []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.Table, c.Name, c.Definition)}
+			if len(c.Backfill) > 0 {
+				stmts = append(stmts, c.Backfill)
+			}
+			for _, stmt := range stmts {
+				if _, err = tx.Exec(stmt); err != nil {
+					log.Printf("DB Execution Error: %s", err)
+					return err
+				}
+			}
+		}
+		stmts := append(m.Stmts, m.SQLite...)
+		if postgres {
+			stmts = append(m.Stmts, m.Postgres...)
+		}
+		for _, stmt := range stmts {
+			if _, err = tx.Exec(stmt); err != nil {
+				log.Printf("DB Execution Error: %s", err)
+				return err
+			}
+		}
+		log.Printf("Migrated database to version %d", version+i+1)
+	}
+	err = setSchemaVersion(tx, postgres, len(migrations))
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	return tx.Commit()
+}
+
+// schemaVersion
+// Returns how many migrations db has had, SQLite keeps it in user_version and postgres in the schema_version table
+func schemaVersion(db *sql.DB, postgres bool) (int, error) {
+	var version int
+	query := "PRAGMA user_version"
+	if postgres {
+		query = "SELECT version FROM schema_version"
+	}
+	err := db.QueryRow(query).Scan(&version)
+	return version, err
+}
+
+// setSchemaVersion
+// Records that a database has had version migrations
+func setSchemaVersion(ex execer, postgres bool, version int) error {
+	if postgres {
+		// the table holds a single row
+		_, err := ex.Exec("DELETE FROM schema_version")
+		if err == nil {
+			_, err = ex.Exec("INSERT INTO schema_version (version) VALUES (?)", version)
+		}
+		return err
+	}
+	// pragmas take no placeholders, version is always an int
+	_, err := ex.Exec(fmt.Sprintf("PRAGMA user_version=%d", version))
+	return err
+}
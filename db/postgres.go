@@ -0,0 +1,368 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ConnectPostgres
+// Use lib/pq to connect to the database at url, creates the schema from sqlString if the database has none, otherwise migrates it
+func ConnectPostgres(url string, sqlString string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(url)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(postgresConnector{connector: connector})
+	// a database without the job table has never been set up
+	var exists bool
+	err = db.QueryRow("SELECT to_regclass('job') IS NOT NULL").Scan(&exists)
+	if err == nil && !exists {
+		err = createPostgres(db, sqlString)
+	} else if err == nil {
+		// bring databases created by older versions up to date
+		err = MigrateDatabase(db)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	DB = db
+	// the full text search index is built on FTS5, postgres searches with ILIKE instead
+	searchIndex = false
+	return db, nil
+}
+
+// createPostgres
+// Runs schema.postgres.sql against an empty database, which is current so no migrations run on it
+func createPostgres(db *sql.DB, sqlString string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(sqlString); err != nil {
+		return err
+	}
+	if err = setSchemaVersion(tx, true, len(migrations)); err != nil {
+		return err
+	}
+	log.Print("Databse created successfully")
+	return tx.Commit()
+}
+
+// Driver
+// Returns the name of the driver the connected database uses, postgres or sqlite3
+func Driver() string {
+	if isPostgres(DB) {
+		return "postgres"
+	}
+	return "sqlite3"
+}
+
+// isPostgres
+// Returns whether db was opened by ConnectPostgres
+func isPostgres(db *sql.DB) bool {
+	if db == nil {
+		return false
+	}
+	_, ok := db.Driver().(postgresDriver)
+	return ok
+}
+
+// type postgresDriver
+// Opens lib/pq connections that take the SQLite flavoured SQL the queries are written in, see postgresQuery
+type postgresDriver struct{}
+
+func (postgresDriver) Open(name string) (driver.Conn, error) {
+	connector, err := pq.NewConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return postgresConnector{connector: connector}.Connect(context.Background())
+}
+
+// type postgresConnector
+// Wraps a lib/pq connector, every connection it opens reads and writes time in UTC like SQLite stores it
+type postgresConnector struct {
+	connector *pq.Connector
+}
+
+func (c postgresConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = conn.(driver.ExecerContext).ExecContext(ctx, "SET TIME ZONE 'UTC'", nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return postgresConn{conn: conn}, nil
+}
+
+func (c postgresConnector) Driver() driver.Driver {
+	return postgresDriver{}
+}
+
+// type postgresConn
+// Wraps a lib/pq connection, rewrites queries with postgresQuery and reports the id of inserted rows as LastInsertId
+type postgresConn struct {
+	conn driver.Conn
+}
+
+func (c postgresConn) Prepare(query string) (driver.Stmt, error) {
+	query, _ = postgresQuery(query)
+	return c.conn.Prepare(query)
+}
+
+func (c postgresConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	query, _ = postgresQuery(query)
+	return c.conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+}
+
+func (c postgresConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c postgresConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c postgresConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c postgresConn) Ping(ctx context.Context) error {
+	return c.conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c postgresConn) ResetSession(ctx context.Context) error {
+	return c.conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c postgresConn) IsValid() bool {
+	return c.conn.(driver.Validator).IsValid()
+}
+
+func (c postgresConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	query, params := postgresQuery(query)
+	return c.conn.(driver.QueryerContext).QueryContext(ctx, query, postgresArgs(args, params))
+}
+
+func (c postgresConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	query, params := postgresQuery(query)
+	args = postgresArgs(args, params)
+	// postgres has no last insert id, inserts return their rows instead
+	if !insertQuery.MatchString(query) || returningClause.MatchString(query) {
+		return c.conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+	}
+	rows, err := c.conn.(driver.QueryerContext).QueryContext(ctx, query+" RETURNING *", args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result postgresResult
+	columns := rows.Columns()
+	values := make([]driver.Value, len(columns))
+	for {
+		err = rows.Next(values)
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.rows++
+		for i, name := range columns {
+			if id, ok := values[i].(int64); ok && name == "id" {
+				result.id = id
+			}
+		}
+	}
+}
+
+// insertQuery and returningClause
+// Match inserts, and queries that already return their rows
+var insertQuery = regexp.MustCompile(`(?i)^\s*INSERT\b`)
+var returningClause = regexp.MustCompile(`(?i)\bRETURNING\b`)
+
+// type postgresResult
+// Result of an insert, id is the id of the last row inserted
+type postgresResult struct {
+	id   int64
+	rows int64
+}
+
+func (r postgresResult) LastInsertId() (int64, error) {
+	return r.id, nil
+}
+
+func (r postgresResult) RowsAffected() (int64, error) {
+	return r.rows, nil
+}
+
+// postgresArgs
+// Takes args and the number of params the rewritten query has, drops args past them like SQLite ignores them, stores booleans as 0 or 1 like SQLite
+func postgresArgs(args []driver.NamedValue, params int) []driver.NamedValue {
+	if len(args) > params {
+		args = args[:params]
+	}
+	for i, arg := range args {
+		if b, ok := arg.Value.(bool); ok {
+			args[i].Value = int64(0)
+			if b {
+				args[i].Value = int64(1)
+			}
+		}
+	}
+	return args
+}
+
+// postgresTimeFunctions
+// SQLite time functions the queries use, and their postgres equivalents
+var postgresTimeFunctions = []struct {
+	SQLite   *regexp.Regexp
+	Postgres string
+}{
+	{SQLite: regexp.MustCompile(`strftime\('%Y-%m-%d %H:%M:%f', ?'now'\)`), Postgres: "CURRENT_TIMESTAMP"},
+	{SQLite: regexp.MustCompile(`strftime\('%Y-%m-%d %H:%M:%f', ?([\w.]+)\)`), Postgres: "to_char($1, 'YYYY-MM-DD HH24:MI:SS.MS')"},
+}
+
+// postgresWords
+// Words outside of quotes that SQLite and postgres spell differently, keyed by upper case SQLite word
+var postgresWords = map[string]string{
+	// user is reserved in postgres
+	"USER": `"user"`,
+	// SQLite LIKE ignores case
+	"LIKE": "ILIKE",
+	// column types
+	"AUTOINCREMENT": "",
+	"BLOB":          "BYTEA",
+	"DATETIME":      "TIMESTAMPTZ(3)",
+	"INTEGER":       "BIGINT",
+	"REAL":          "DOUBLE PRECISION",
+	// SQLite sorts NULLs first ascending and last descending, cursors rely on it
+	"ASC":  "ASC NULLS FIRST",
+	"DESC": "DESC NULLS LAST",
+}
+
+// postgresQuery
+// Takes a query written for SQLite, returns it rewritten for postgres and the number of params it takes
+// ? and ?NNN params become $N, words in postgresWords are replaced, quoted strings, identifiers and comments are left as they are
+func postgresQuery(query string) (string, int) {
+	for _, f := range postgresTimeFunctions {
+		query = f.SQLite.ReplaceAllString(query, f.Postgres)
+	}
+	var b strings.Builder
+	params := 0
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			// quoted string or identifier, a doubled quote is an escaped one
+			end := i + 1
+			for end < len(query) {
+				if query[end] == c {
+					if end+1 < len(query) && query[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end < len(query) {
+				end++
+			}
+			b.WriteString(query[i:end])
+			i = end
+		case strings.HasPrefix(query[i:], "$$"):
+			// function body
+			end := strings.Index(query[i+2:], "$$")
+			if end < 0 {
+				end = len(query)
+			} else {
+				end += i + 4
+			}
+			b.WriteString(query[i:end])
+			i = end
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query)
+			} else {
+				end += i
+			}
+			b.WriteString(query[i:end])
+			i = end
+		case c == '?':
+			end := i + 1
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			n := params + 1
+			if end > i+1 {
+				n, _ = strconv.Atoi(query[i+1 : end])
+			}
+			if n > params {
+				params = n
+			}
+			b.WriteString("$" + strconv.Itoa(n))
+			i = end
+		case c == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			// already a postgres param
+			end := i + 1
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			if n, _ := strconv.Atoi(query[i+1 : end]); n > params {
+				params = n
+			}
+			b.WriteString(query[i:end])
+			i = end
+		case isWordStart(c):
+			end := i + 1
+			for end < len(query) && (isWordStart(query[end]) || query[end] >= '0' && query[end] <= '9') {
+				end++
+			}
+			word := query[i:end]
+			upper := strings.ToUpper(word)
+			replacement, ok := postgresWords[upper]
+			switch {
+			case upper == "INTEGER" && primaryKey.MatchString(query[end:]):
+				// rowid alias, ids are generated
+				b.WriteString("BIGSERIAL")
+			case (upper == "ASC" || upper == "DESC") && nullsOrder.MatchString(query[end:]):
+				b.WriteString(word)
+			case ok:
+				b.WriteString(replacement)
+			default:
+				b.WriteString(word)
+			}
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), params
+}
+
+// primaryKey and nullsOrder
+// Match what follows a column type that is the primary key, and a sort direction that already orders NULLs
+var primaryKey = regexp.MustCompile(`(?i)^\s+PRIMARY\s+KEY\b`)
+var nullsOrder = regexp.MustCompile(`(?i)^\s+NULLS\b`)
+
+// isWordStart
+// Returns whether c can start an unquoted word
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
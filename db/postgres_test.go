@@ -0,0 +1,62 @@
+package db
+
+import (
+	"os"
+	"testing"
+)
+
+// TestPostgresQuery
+// Tests queries written for SQLite are rewritten for postgres, leaving quoted text alone
+func TestPostgresQuery(t *testing.T) {
+	queries := []struct {
+		SQLite   string
+		Postgres string
+		Params   int
+	}{
+		{
+			SQLite:   "SELECT id FROM user WHERE username = ? AND job.user=?",
+			Postgres: `SELECT id FROM "user" WHERE username = $1 AND job."user"=$2`,
+			Params:   2,
+		},
+		{
+			SQLite:   "UPDATE job SET deleted_at=?1 WHERE id=?2 AND user=?3",
+			Postgres: `UPDATE job SET deleted_at=$1 WHERE id=$2 AND "user"=$3`,
+			Params:   3,
+		},
+		{
+			SQLite:   "SELECT 'user?', \"it's\" FROM job WHERE name LIKE '%don''t?%' ORDER BY name DESC, id ASC",
+			Postgres: "SELECT 'user?', \"it's\" FROM job WHERE name ILIKE '%don''t?%' ORDER BY name DESC NULLS LAST, id ASC NULLS FIRST",
+		},
+		{
+			SQLite:   "UPDATE task SET updated_at=strftime('%Y-%m-%d %H:%M:%f','now') WHERE strftime('%Y-%m-%d %H:%M:%f', updated_at)=?",
+			Postgres: "UPDATE task SET updated_at=CURRENT_TIMESTAMP WHERE to_char(updated_at, 'YYYY-MM-DD HH24:MI:SS.MS')=$1",
+			Params:   1,
+		},
+		{
+			SQLite:   "CREATE TABLE IF NOT EXISTS fuel_log ( id INTEGER PRIMARY KEY AUTOINCREMENT, user INTEGER, volume REAL NOT NULL, attachment BLOB, filled_at DATETIME )",
+			Postgres: `CREATE TABLE IF NOT EXISTS fuel_log ( id BIGSERIAL PRIMARY KEY , "user" BIGINT, volume DOUBLE PRECISION NOT NULL, attachment BYTEA, filled_at TIMESTAMPTZ(3) )`,
+		},
+		{
+			SQLite:   "CREATE FUNCTION f() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'user'; END $$ LANGUAGE plpgsql",
+			Postgres: "CREATE FUNCTION f() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'user'; END $$ LANGUAGE plpgsql",
+		},
+	}
+	for _, q := range queries {
+		query, params := postgresQuery(q.SQLite)
+		if query != q.Postgres || params != q.Params {
+			t.Errorf("Expected %q with %d params, got %q with %d", q.Postgres, q.Params, query, params)
+		}
+	}
+}
+
+// TestPostgresSchema
+// Tests schema.postgres.sql is already written for postgres, so rewriting it changes nothing
+func TestPostgresSchema(t *testing.T) {
+	sqlBytes, err := os.ReadFile("../schema.postgres.sql")
+	if err != nil {
+		t.Fatalf("Error reading schema: %v", err)
+	}
+	if query, _ := postgresQuery(string(sqlBytes)); query != string(sqlBytes) {
+		t.Error("Expected schema.postgres.sql to need no rewriting")
+	}
+}
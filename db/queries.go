@@ -1,11 +1,16 @@
 package db
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
 )
 
 // Auth Queries
@@ -16,7 +21,7 @@ func GetAuthInfoByUsername(username string) (*int64, *string, *int, *[]byte, err
 	var userId int64
 	var isAdmin int
 	var hashed []byte
-	err := DB.QueryRow("SELECT id, is_admin, hashed_pw FROM user WHERE username = ?", username).Scan(&userId, &isAdmin, &hashed)
+	err := DB.QueryRow("SELECT id, is_admin, hashed_pw FROM user WHERE username = ? AND deleted_at IS NULL", username).Scan(&userId, &isAdmin, &hashed)
 	if err != nil {
 		log.Printf("DB Query Error: %s", err)
 		return nil, nil, nil, nil, err
@@ -31,7 +36,7 @@ func GetAuthInfoByUsername(username string) (*int64, *string, *int, *[]byte, err
 func GetUserById(userId int64) (*models.User, error) {
 	var user models.User
 	// query db, return any errors
-	err := DB.QueryRow("SELECT * FROM user WHERE id=?", userId).Scan(
+	err := DB.QueryRow("SELECT "+selectColumns("user", userColumns)+" FROM user WHERE id=? AND deleted_at IS NULL", userId).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
@@ -40,6 +45,7 @@ func GetUserById(userId int64) (*models.User, error) {
 		&user.Is_admin,
 		&user.Created_at,
 		&user.Updated_at,
+		&user.Deleted_at,
 	)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
@@ -53,7 +59,7 @@ func GetUserById(userId int64) (*models.User, error) {
 func GetUserByUsername(username string) (*models.User, error) {
 	var user models.User
 	// query db, return any errors
-	err := DB.QueryRow("SELECT * FROM user WHERE username=?", username).Scan(
+	err := DB.QueryRow("SELECT "+selectColumns("user", userColumns)+" FROM user WHERE username=? AND deleted_at IS NULL", username).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
@@ -62,6 +68,7 @@ func GetUserByUsername(username string) (*models.User, error) {
 		&user.Is_admin,
 		&user.Created_at,
 		&user.Updated_at,
+		&user.Deleted_at,
 	)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
@@ -71,15 +78,18 @@ func GetUserByUsername(username string) (*models.User, error) {
 }
 
 // ListUsers
-// Take filters as args, return User list
-func ListUsers(jobId *string, vehicleId *string, isAdmin *string, searchStr *string, sort *string) ([]*models.User, error) {
+// Take filters and optional Page as args, return User list, only one page of it if Page provided
+func ListUsers(jobId *string, vehicleId *string, isAdmin *string, searchStr *string, sort *string, page *models.Page) ([]*models.User, error) {
 	var joins []string
 	var wheres []string
 	var likes []Like
+	var args []interface{}
 	// establish default sort if not provided
 	var orderBy = "u.updated_at DESC"
 	// establish basic query
-	q := "SELECT * FROM user AS u"
+	q := "SELECT " + selectColumns("u", userColumns) + " FROM user AS u"
+	// leave out trashed rows
+	wheres = append(wheres, "u.deleted_at IS NULL")
 	// if isAdmin provided, add where to query
 	if isAdmin != nil && len(*isAdmin) > 0 {
 		wheres = append(wheres, "u.is_admin="+*isAdmin)
@@ -122,10 +132,17 @@ func ListUsers(jobId *string, vehicleId *string, isAdmin *string, searchStr *str
 			orderBy = "u.updated_at DESC"
 		}
 	}
+	// check cursor and count all matching rows if paginating
+	if page != nil {
+		err := pageStart(page, orderBy, QueryBuilder(q, &joins, &wheres, &likes, nil, nil, nil), args)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// generate query with QueryBuilder
-	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy)
+	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy, page)
 	// retrieve all matching rows
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(query, append(args, pageArgs(page)...)...)
 	if err != nil {
 		log.Printf("DB Query Error: %s", err)
 		return nil, err
@@ -146,6 +163,7 @@ func ListUsers(jobId *string, vehicleId *string, isAdmin *string, searchStr *str
 			&user.Is_admin,
 			&user.Created_at,
 			&user.Updated_at,
+			&user.Deleted_at,
 		)
 		if err != nil {
 			log.Printf("Error scanning rows retrieved from DB: %s", err)
@@ -154,6 +172,14 @@ func ListUsers(jobId *string, vehicleId *string, isAdmin *string, searchStr *str
 		// append User to list of User
 		users = append(users, &user)
 	}
+	// drop extra row fetched to detect another page, resume after last row
+	if page != nil && len(users) > page.Limit {
+		users = users[:page.Limit]
+		err = pageNext(page, "user", users[len(users)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return users, nil
 }
 
@@ -178,42 +204,22 @@ func CreateUser(newUser models.NewUser, password *[]byte) (*int64, error) {
 
 // EditUser
 // Take User as arg, update it in db
-func EditUser(editedUser models.User) error {
-	_, err := DB.Exec("UPDATE user SET username=?, email=?, description=?, updated_at=CURRENT_TIMESTAMP WHERE id=?",
-		editedUser.Username,
-		editedUser.Email,
-		editedUser.Description,
-		editedUser.ID,
-	)
-	// throw SQL errors
-	if err != nil {
-		log.Printf("DB Query Error: %s", err)
-		return err
-	}
-	return err
-}
-
-// DeleteUser
-// Take username as arg, delete User from user table where username present
-func DeleteUser(username string) error {
-	res, err := DB.Exec("DELETE FROM user WHERE username = ?", username)
+func EditUser(editedUser models.User, revision *time.Time) error {
+	wheres := []string{"id=?"}
+	args := revisionWhere(&wheres, []any{editedUser.Username, editedUser.Email, editedUser.Description, editedUser.ID}, revision)
+	query := QueryBuilder("UPDATE user SET username=?, email=?, description=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now')", nil, &wheres, nil, nil, nil, nil)
+	res, err := DB.Exec(query, args...)
 	// throw SQL errors
 	if err != nil {
 		log.Printf("DB Query Error: %s", err)
 		return err
 	}
-	// retrieve rows affected count
-	rows, err := res.RowsAffected()
-	if err != nil {
-		log.Printf("DB Query Error: %s", err)
-		return err
-	}
-	// throw error if no rows affected
-	if rows == 0 {
-		log.Printf("No rows deleted")
-		return errors.New("No rows deleted")
+	// only an edit expecting a revision fails when nothing was updated
+	rowCount, err := res.RowsAffected()
+	if err != nil || (rowCount == 0 && revision != nil) {
+		log.Printf("No rows updated: %v", err)
+		return noRowsUpdated(revision)
 	}
-
 	return nil
 }
 
@@ -239,25 +245,16 @@ func UpdatePassword(username string, password *[]byte) error {
 // GetJob
 // Takes job id, queries it in db, returns Job
 func GetJob(jobId int64) (*models.Job, error) {
-	// init querybuilder data and job and label data
-	var labelIds *string
-	var labelNames *string
-	var labelColors *string
-	var labelCreatedTimes *string
-	var labelUpdatedTimes *string
-	var joins []string
+	// init querybuilder data and job
 	var wheres []string
-	groupBy := "job.id"
 	var job models.Job
 	// init query
-	q := "SELECT job.*, GROUP_CONCAT(label.id) AS label_ids, GROUP_CONCAT(label.name) AS label_names, GROUP_CONCAT(label.color) AS label_colors, GROUP_CONCAT(label.created_at) as label_created_times, GROUP_CONCAT(label.updated_at) as label_updated_times FROM job"
-	// join job_label and label to concat
-	joins = append(joins, "LEFT JOIN job_label ON job.id = job_label.job")
-	joins = append(joins, "LEFT JOIN label ON job_label.label = label.id")
-	// add wheres for matching id
+	q := "SELECT " + selectColumns("job", jobColumns) + " FROM job"
+	// add wheres for matching id, leaving out trashed jobs
 	wheres = append(wheres, "job.id=?")
+	wheres = append(wheres, "job.deleted_at IS NULL")
 	// generate query with QueryBuilder
-	query := QueryBuilder(q, &joins, &wheres, nil, &groupBy, nil)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	// query db, return any errors
 	err := DB.QueryRow(query, jobId).Scan(
 		&job.ID,
@@ -277,35 +274,39 @@ func GetJob(jobId int64) (*models.Job, error) {
 		&job.Completed_at,
 		&job.Created_at,
 		&job.Updated_at,
-		&labelIds,
-		&labelNames,
-		&labelColors,
-		&labelCreatedTimes,
-		&labelUpdatedTimes,
+		&job.Status,
+		&job.Due_odometer,
+		&job.Deleted_at,
 	)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return nil, err
 	}
-	// call label processor with cols, return any errors
-	labels, err := LabelProcessor(labelIds, labelNames, labelColors, labelCreatedTimes, labelUpdatedTimes)
+	// add labels to job
+	labels, err := ListLinkedLabels("job", []int64{job.ID})
 	if err != nil {
-		log.Printf("Error converting labels from cols to slice of objects: %s", err)
+		return nil, err
 	}
-	// add labels to job
-	job.Labels = labels
+	job.Labels = labels[job.ID]
 	return &job, nil
 }
 
 // CreateJob
 // Takes newJob, creates in db, returns id
 func CreateJob(newJob models.NewJob) (*int64, error) {
+	return createJob(DB, newJob)
+}
+
+// createJob
+// Runs CreateJob against db or transaction
+func createJob(ex execer, newJob models.NewJob) (*int64, error) {
 	// insert into db, return any errors
-	res, err := DB.Exec("INSERT INTO job(Name, Description, Instructions, Is_template, Vehicle, User, Origin_job, Repeats, Odo_interval, Time_interval, Time_interval_unit, Due_date) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)",
+	res, err := ex.Exec("INSERT INTO job(Name, Description, Instructions, Is_template, Status, Vehicle, User, Origin_job, Repeats, Odo_interval, Time_interval, Time_interval_unit, Due_date, Due_odometer) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
 		newJob.Name,
 		newJob.Description,
 		newJob.Instructions,
 		newJob.Is_template,
+		newJob.Status,
 		newJob.Vehicle,
 		newJob.User,
 		newJob.Origin_job,
@@ -314,6 +315,7 @@ func CreateJob(newJob models.NewJob) (*int64, error) {
 		newJob.Time_interval,
 		newJob.Time_interval_unit,
 		newJob.Due_date,
+		newJob.Due_odometer,
 	)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
@@ -326,17 +328,20 @@ func CreateJob(newJob models.NewJob) (*int64, error) {
 
 // EditJob
 // Take Job as arg, build update query with QueryBuilder, update it in db via generated query
-func EditJob(editedJob models.Job) error {
+func EditJob(editedJob models.Job, revision *time.Time) error {
 	var wheres []string
 	// setup query
-	q := "UPDATE job SET name=?, description=?, instructions=?, is_template=?, is_complete=?, vehicle=?, repeats=?, odo_interval=?, time_interval=?, time_interval_unit=?, due_date=?, updated_at=CURRENT_TIMESTAMP"
+	q := "UPDATE job SET name=?, description=?, instructions=?, is_template=?, is_complete=?, status=?, vehicle=?, repeats=?, odo_interval=?, time_interval=?, time_interval_unit=?, due_date=?, due_odometer=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now')"
+	// set completed_at when job becomes complete, keep it if already complete, clear it otherwise
+	q += ", completed_at=CASE WHEN ?=1 THEN COALESCE(completed_at, CURRENT_TIMESTAMP) ELSE NULL END"
 	// add required wheres (ensures the job id and user id in the db match that of request body)
 	wheres = append(wheres, "user=?")
 	wheres = append(wheres, "id=?")
+	args := revisionWhere(&wheres, []any{editedJob.Name, editedJob.Description, editedJob.Instructions, editedJob.Is_template, editedJob.Is_complete, editedJob.Status, editedJob.Vehicle, editedJob.Repeats, editedJob.Odo_interval, editedJob.Time_interval, editedJob.Time_interval_unit, editedJob.Due_date, editedJob.Due_odometer, editedJob.Is_complete, editedJob.User, editedJob.ID}, revision)
 	// get generated query
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	// exec query
-	res, err := DB.Exec(query, editedJob.Name, editedJob.Description, editedJob.Instructions, editedJob.Is_template, editedJob.Is_complete, editedJob.Vehicle, editedJob.Repeats, editedJob.Odo_interval, editedJob.Time_interval, editedJob.Time_interval_unit, editedJob.Due_date, editedJob.User, editedJob.ID)
+	res, err := DB.Exec(query, args...)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return err
@@ -345,56 +350,24 @@ func EditJob(editedJob models.Job) error {
 	rowCount, err := res.RowsAffected()
 	if rowCount == 0 || err != nil {
 		log.Printf("No rows updated: %v", err)
-		return errors.New("No rows updated")
-	}
-	return nil
-}
-
-// DeleteJob
-// Take job id as arg, delete Job from job table where id present
-func DeleteJob(jobId int64, userId *int64) error {
-	var wheres []string
-	q := "DELETE FROM job"
-	wheres = append(wheres, "id="+strconv.FormatInt(jobId, 10))
-	if userId != nil {
-		wheres = append(wheres, "user="+strconv.FormatInt(*userId, 10))
-	}
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
-	res, err := DB.Exec(query)
-	// throw SQL errors
-	if err != nil {
-		log.Printf("DB Query Error: %s", err)
-		return err
-	}
-	// retrieve rows affected count
-	rows, err := res.RowsAffected()
-	if err != nil {
-		log.Printf("DB Query Error: %s", err)
-		return err
-	}
-	// throw error if no rows affected
-	if rows == 0 {
-		log.Printf("No rows deleted")
-		return errors.New("No rows deleted")
+		return noRowsUpdated(revision)
 	}
-
 	return nil
 }
 
 // ListJobs
-// Take filters as args, return Job list
-func ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete *string, labelId *string, searchStr *string, sort *string) ([]*models.Job, error) {
+// Take filters and optional Page as args, return Job list, only one page of it if Page provided
+func ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete *string, status *string, labelId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Job, error) {
 	var joins []string
 	var wheres []string
 	var likes []Like
-	groupBy := "job.id"
+	var args []interface{}
 	// establish default sort if not provided
 	var orderBy = "job.updated_at DESC"
-	// establish basic query, concat labels into 3 cols of coma separated values
-	q := "SELECT job.*, GROUP_CONCAT(label.id) AS label_ids, GROUP_CONCAT(label.name) AS label_names, GROUP_CONCAT(label.color) AS label_colors,  GROUP_CONCAT(label.created_at) as label_created_times, GROUP_CONCAT(label.updated_at) as label_updated_times FROM job"
-	// join job_label and label to concat
-	joins = append(joins, "LEFT JOIN job_label ON job.id = job_label.job")
-	joins = append(joins, "LEFT JOIN label ON job_label.label = label.id")
+	// establish basic query, labels are loaded afterwards for the whole page at once
+	q := "SELECT " + selectColumns("job", jobColumns) + " FROM job"
+	// leave out trashed rows
+	wheres = append(wheres, "job.deleted_at IS NULL")
 	// if userId provided, add where to query
 	if userId != nil && len(*userId) > 0 {
 		wheres = append(wheres, "job.user="+*userId)
@@ -411,9 +384,18 @@ func ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete
 	if isComplete != nil && len(*isComplete) > 0 {
 		wheres = append(wheres, "job.is_complete="+*isComplete)
 	}
+	// if status provided, add where to query, multiple statuses can be comma separated
+	if status != nil && len(*status) > 0 {
+		statuses := strings.Split(*status, ",")
+		wheres = append(wheres, "job.status IN (?"+strings.Repeat(",?", len(statuses)-1)+")")
+		for _, statusStr := range statuses {
+			args = append(args, strings.TrimSpace(statusStr))
+		}
+	}
 	// if label ID provided join by labelId where jobId is present
 	if labelId != nil && len(*labelId) > 0 {
-		wheres = append(wheres, "job_label.label="+*labelId)
+		wheres = append(wheres, "job.id IN (SELECT job FROM job_label WHERE label=?)")
+		args = append(args, *labelId)
 	}
 	// if search string provided, construct likes to query username, description cols
 	if searchStr != nil && len(*searchStr) > 0 {
@@ -448,10 +430,17 @@ func ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete
 			orderBy = "job.updated_at DESC"
 		}
 	}
+	// check cursor and count all matching rows if paginating
+	if page != nil {
+		err := pageStart(page, orderBy, QueryBuilder(q, &joins, &wheres, &likes, nil, nil, nil), args)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// generate query with QueryBuilder
-	query := QueryBuilder(q, &joins, &wheres, &likes, &groupBy, &orderBy)
+	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy, page)
 	// retrieve all matching rows
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(query, append(args, pageArgs(page)...)...)
 	if err != nil {
 		log.Printf("DB Query Error: %s", err)
 		return nil, err
@@ -463,12 +452,6 @@ func ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete
 	for rows.Next() {
 		// attribute to Job
 		job := models.Job{}
-		// initiate label data for converting concated cols into Label objects slice
-		var labelIds *string
-		var labelNames *string
-		var labelColors *string
-		var labelCreatedTimes *string
-		var labelUpdatedTimes *string
 		err := rows.Scan(
 			&job.ID,
 			&job.Name,
@@ -487,26 +470,37 @@ func ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete
 			&job.Completed_at,
 			&job.Created_at,
 			&job.Updated_at,
-			&labelIds,
-			&labelNames,
-			&labelColors,
-			&labelCreatedTimes,
-			&labelUpdatedTimes,
+			&job.Status,
+			&job.Due_odometer,
+			&job.Deleted_at,
 		)
 		if err != nil {
 			log.Printf("Error scanning rows retrieved from DB: %s", err)
 			return nil, err
 		}
-		// call label processor with cols, return any errors
-		labels, err := LabelProcessor(labelIds, labelNames, labelColors, labelCreatedTimes, labelUpdatedTimes)
-		if err != nil {
-			log.Printf("Error converting labels from cols to slice of objects: %s", err)
-		}
-		// add labels to job
-		job.Labels = labels
 		// append Job to list of Job
 		jobs = append(jobs, &job)
 	}
+	// drop extra row fetched to detect another page, resume after last row
+	if page != nil && len(jobs) > page.Limit {
+		jobs = jobs[:page.Limit]
+		err = pageNext(page, "job", jobs[len(jobs)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// add labels to every job on the page with one query
+	ids := make([]int64, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+	labels, err := ListLinkedLabels("job", ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		job.Labels = labels[job.ID]
+	}
 	return jobs, nil
 }
 
@@ -515,41 +509,86 @@ func ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete
 // GetTask
 // Takes job id, queries it in db, returns Task
 func GetTask(jobId int64, taskId int64) (*models.Task, error) {
+	return getTask("id=? AND job=?", taskId, jobId)
+}
+
+// GetTaskById
+// Takes task id, returns Task regardless of its job
+func GetTaskById(taskId int64) (*models.Task, error) {
+	return getTask("id=?", taskId)
+}
+
+// getTask
+// Returns the Task matching where, trashed tasks are left out
+func getTask(where string, args ...any) (*models.Task, error) {
 	var task models.Task
 	// query db, return any errors
-	err := DB.QueryRow("SELECT * FROM task WHERE id=? AND job=?", taskId, jobId).Scan(
+	err := DB.QueryRow("SELECT "+selectColumns("task", taskColumns)+" FROM task WHERE deleted_at IS NULL AND "+where, args...).Scan(
 		&task.ID,
 		&task.Name,
 		&task.Description,
 		&task.Is_complete,
 		&task.Job,
+		&task.Parent,
+		&task.Position,
 		&task.Part_name,
 		&task.Part_link,
 		&task.Due_date,
+		&task.Estimated_minutes,
 		&task.Completed_at,
 		&task.Created_at,
 		&task.Updated_at,
+		&task.Deleted_at,
 	)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return nil, err
 	}
+	dependencies, err := ListTaskDependencies([]int64{task.ID})
+	if err != nil {
+		return nil, err
+	}
+	task.Depends_on = dependencies[task.ID]
+	// add labels to task
+	labels, err := ListLinkedLabels("task", []int64{task.ID})
+	if err != nil {
+		return nil, err
+	}
+	task.Labels = labels[task.ID]
 	return &task, nil
 }
 
 // CreateTask
-// Takes newTask, creates in db, returns id
+// Takes newTask, creates in db with its dependencies in a single transaction, returns id
 func CreateTask(newTask models.NewTask, jobId int64) (*int64, error) {
-	q := "INSERT INTO task(Name, Description, Job, Part_name, Part_link, Due_date) VALUES (?,?,?,?,?,?)"
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	taskId, err := createTask(tx, newTask, jobId)
+	if err != nil {
+		return nil, err
+	}
+	return taskId, tx.Commit()
+}
+
+// createTask
+// Runs CreateTask against db or transaction, new tasks go after the jobs other tasks
+func createTask(ex execer, newTask models.NewTask, jobId int64) (*int64, error) {
+	q := "INSERT INTO task(Name, Description, Job, Parent, Position, Part_name, Part_link, Due_date, Estimated_minutes) VALUES (?1,?2,?3,?4,(SELECT COALESCE(MAX(position)+1, 0) FROM task WHERE job=?3),?5,?6,?7,?8)"
 	log.Printf(q)
 	// insert into db, return any errors
-	res, err := DB.Exec("INSERT INTO task(Name, Description, Job, Part_name, Part_link, Due_date) VALUES (?,?,?,?,?,?)",
+	res, err := ex.Exec(q,
 		newTask.Name,
 		newTask.Description,
 		jobId,
+		newTask.Parent,
 		newTask.Part_name,
 		newTask.Part_link,
 		newTask.Due_date,
+		newTask.Estimated_minutes,
 	)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
@@ -557,22 +596,33 @@ func CreateTask(newTask models.NewTask, jobId int64) (*int64, error) {
 	}
 	// get inserted tasks id
 	taskId, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	err = setTaskDependencies(ex, taskId, newTask.Depends_on)
 	return &taskId, err
 }
 
 // EditTask
-// Take Task as arg, build update query with QueryBuilder, update it in db via generated query
-func EditTask(editedTask models.Task, jobId int64) error {
+// Take Task as arg, build update query with QueryBuilder, update it in db via generated query, replaces its dependencies unless nil
+func EditTask(editedTask models.Task, jobId int64, revision *time.Time) error {
 	var wheres []string
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	defer tx.Rollback()
 	// setup query
-	q := "UPDATE task SET name=?, description=?, part_name=?, part_link=?, due_date=?, updated_at=CURRENT_TIMESTAMP"
+	q := "UPDATE task SET name=?, description=?, parent=?, part_name=?, part_link=?, due_date=?, estimated_minutes=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now')"
 	// add required wheres (ensures the task id and user id in the db match that of request body)
 	wheres = append(wheres, "job=?")
 	wheres = append(wheres, "id=?")
+	args := revisionWhere(&wheres, []any{editedTask.Name, editedTask.Description, editedTask.Parent, editedTask.Part_name, editedTask.Part_link, editedTask.Due_date, editedTask.Estimated_minutes, jobId, editedTask.ID}, revision)
 	// get generated query
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	// exec query
-	res, err := DB.Exec(query, editedTask.Name, editedTask.Description, editedTask.Part_name, editedTask.Part_link, editedTask.Due_date, jobId, editedTask.ID)
+	res, err := tx.Exec(query, args...)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return err
@@ -581,28 +631,124 @@ func EditTask(editedTask models.Task, jobId int64) error {
 	rowCount, err := res.RowsAffected()
 	if rowCount == 0 || err != nil {
 		log.Printf("No rows updated: %v", err)
-		return errors.New("No rows updated")
+		return noRowsUpdated(revision)
+	}
+	if editedTask.Depends_on != nil {
+		_, err = tx.Exec("DELETE FROM task_dependency WHERE task=?", editedTask.ID)
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return err
+		}
+		err = setTaskDependencies(tx, editedTask.ID, editedTask.Depends_on)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReorderTasks
+// Takes job id and task ids in their new order, moves them to the front in that order in a single transaction, the jobs other tasks keep their order after them
+func ReorderTasks(jobId int64, taskIds []int64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	defer tx.Rollback()
+	// make room in front of the jobs tasks
+	_, err = tx.Exec("UPDATE task SET position=position+? WHERE job=?", len(taskIds), jobId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	for i, taskId := range taskIds {
+		res, err := tx.Exec("UPDATE task SET position=? WHERE job=? AND id=? AND deleted_at IS NULL", i, jobId, taskId)
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return err
+		}
+		// every task must belong to the job
+		rowCount, err := res.RowsAffected()
+		if rowCount == 0 || err != nil {
+			log.Printf("No rows updated: %v", err)
+			return fmt.Errorf("Task ID %d not found on job ID %d", taskId, jobId)
+		}
+	}
+	return tx.Commit()
+}
+
+// setTaskDependencies
+// Takes task id and ids of the tasks it depends on, adds them against db or transaction
+func setTaskDependencies(ex execer, taskId int64, dependsOn []int64) error {
+	for _, dependencyId := range dependsOn {
+		_, err := ex.Exec("INSERT INTO task_dependency(Task, Depends_on) VALUES (?,?) ON CONFLICT DO NOTHING", taskId, dependencyId)
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return err
+		}
 	}
 	return nil
 }
 
+// ListTaskDependencies
+// Takes task ids, returns ids of the tasks each depends on keyed by task id, one query per call so lists do not query per row
+func ListTaskDependencies(taskIds []int64) (map[int64][]int64, error) {
+	dependencies := make(map[int64][]int64)
+	if len(taskIds) == 0 {
+		return dependencies, nil
+	}
+	args := make([]any, len(taskIds))
+	for i, id := range taskIds {
+		args[i] = id
+	}
+	// trashed prerequisites are left out
+	rows, err := DB.Query("SELECT d.task, d.depends_on FROM task_dependency AS d JOIN task AS t ON t.id = d.depends_on WHERE t.deleted_at IS NULL AND d.task IN (?"+strings.Repeat(",?", len(taskIds)-1)+") ORDER BY d.task, d.depends_on", args...)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var taskId, dependencyId int64
+		if err := rows.Scan(&taskId, &dependencyId); err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		dependencies[taskId] = append(dependencies[taskId], dependencyId)
+	}
+	return dependencies, rows.Err()
+}
+
 // UpdateTaskStatus
 // Take job id, task id, status as args, build update query with QueryBuilder, update it in db via generated query
 func UpdateTaskStatus(jobId int64, taskId int64, status int) error {
+	return updateTaskStatus(DB, &jobId, taskId, status)
+}
+
+// updateTaskStatus
+// Runs UpdateTaskStatus against db or transaction, job is not checked if jobId is nil
+func updateTaskStatus(ex execer, jobId *int64, taskId int64, status int) error {
 	var wheres []string
+	var args []any
 	// setup query
-	q := "UPDATE task SET is_complete=?, updated_at=CURRENT_TIMESTAMP"
+	q := "UPDATE task SET is_complete=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now')"
 	// if status is complete, updated completed_at also
 	if status == 1 {
 		q += ", completed_at=CURRENT_TIMESTAMP"
 	}
+	args = append(args, status)
 	// add required wheres (ensures the task id and user id in the db match that of request body)
-	wheres = append(wheres, "job=?")
+	if jobId != nil {
+		wheres = append(wheres, "job=?")
+		args = append(args, *jobId)
+	}
 	wheres = append(wheres, "id=?")
+	args = append(args, taskId)
 	// get generated query
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	// exec query
-	res, err := DB.Exec(query, status, jobId, taskId)
+	res, err := ex.Exec(query, args...)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return err
@@ -617,7 +763,7 @@ func UpdateTaskStatus(jobId int64, taskId int64, status int) error {
 }
 
 // DeleteTask
-// Take task id as arg, delete Task from task table where id present
+// Take task id as arg, delete Task from task table where id present, its sub-tasks move up to its parent and dependencies on it are removed
 func DeleteTask(jobId int64, taskId *int64) error {
 	var wheres []string
 	q := "DELETE FROM task"
@@ -625,9 +771,26 @@ func DeleteTask(jobId int64, taskId *int64) error {
 	if taskId != nil {
 		wheres = append(wheres, "id="+strconv.FormatInt(*taskId, 10))
 	}
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
-
-	res, err := DB.Exec(query)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
+	deleted := QueryBuilder("SELECT id FROM task", nil, &wheres, nil, nil, nil, nil)
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{
+		"UPDATE task SET parent=(SELECT p.parent FROM task AS p WHERE p.id=task.parent) WHERE parent IN (" + deleted + ")",
+		"DELETE FROM task_label WHERE task IN (" + deleted + ")",
+		"DELETE FROM task_dependency WHERE task IN (" + deleted + ") OR depends_on IN (" + deleted + ")",
+	} {
+		_, err = tx.Exec(stmt)
+		if err != nil {
+			log.Printf("DB Query Error: %s", err)
+			return err
+		}
+	}
+	res, err := tx.Exec(query)
 	// throw SQL errors
 	if err != nil {
 		log.Printf("DB Query Error: %s", err)
@@ -644,20 +807,22 @@ func DeleteTask(jobId int64, taskId *int64) error {
 		log.Printf("No rows deleted")
 		return errors.New("No rows deleted")
 	}
-
-	return nil
+	return tx.Commit()
 }
 
 // ListTasks
-// Take filters as args, return Task list
-func ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string) ([]*models.Task, error) {
+// Take filters and optional Page as args, return Task list in job order unless sorted, only one page of it if Page provided
+func ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string, page *models.Page) ([]*models.Task, error) {
 	var joins []string
 	var wheres []string
 	var likes []Like
+	var args []interface{}
 	// establish default sort if not provided
-	var orderBy = "t.updated_at DESC"
+	var orderBy = "t.position ASC"
 	// establish basic query
-	q := "SELECT * FROM task AS t"
+	q := "SELECT " + selectColumns("t", taskColumns) + " FROM task AS t"
+	// leave out trashed rows
+	wheres = append(wheres, "t.deleted_at IS NULL")
 	// if isTemplate provided, add where to query
 	if isComplete != nil && len(*isComplete) > 0 {
 		wheres = append(wheres, "t.is_complete="+*isComplete)
@@ -690,15 +855,23 @@ func ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string)
 			orderBy = "t.created_at DESC"
 		case "last_updated":
 			orderBy = "t.updated_at DESC"
+		case "position":
+			orderBy = "t.position ASC"
 		default:
-			orderBy = "t.updated_at DESC"
+			orderBy = "t.position ASC"
+		}
+	}
+	// check cursor and count all matching rows if paginating
+	if page != nil {
+		err := pageStart(page, orderBy, QueryBuilder(q, &joins, &wheres, &likes, nil, nil, nil), args)
+		if err != nil {
+			return nil, err
 		}
 	}
 	// generate query with QueryBuilder
-	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy)
-	log.Printf(query)
+	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy, page)
 	// retrieve all matching rows
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(query, append(args, pageArgs(page)...)...)
 	if err != nil {
 		log.Printf("DB Query Error: %s", err)
 		return nil, err
@@ -716,12 +889,16 @@ func ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string)
 			&task.Description,
 			&task.Is_complete,
 			&task.Job,
+			&task.Parent,
+			&task.Position,
 			&task.Part_name,
 			&task.Part_link,
 			&task.Due_date,
+			&task.Estimated_minutes,
 			&task.Completed_at,
 			&task.Created_at,
 			&task.Updated_at,
+			&task.Deleted_at,
 		)
 		if err != nil {
 			log.Printf("Error scanning rows retrieved from DB: %s", err)
@@ -730,6 +907,31 @@ func ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string)
 		// append Task to list of Task
 		tasks = append(tasks, &task)
 	}
+	// drop extra row fetched to detect another page, resume after last row
+	if page != nil && len(tasks) > page.Limit {
+		tasks = tasks[:page.Limit]
+		err = pageNext(page, "task", tasks[len(tasks)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// load dependencies and labels of all tasks in one query each
+	ids := make([]int64, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	dependencies, err := ListTaskDependencies(ids)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := ListLinkedLabels("task", ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		task.Depends_on = dependencies[task.ID]
+		task.Labels = labels[task.ID]
+	}
 	return tasks, nil
 }
 
@@ -740,7 +942,7 @@ func ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string)
 func GetVehicle(vehicleId int64) (*models.Vehicle, error) {
 	var vehicle models.Vehicle
 	// query db, return any errors
-	err := DB.QueryRow("SELECT * FROM vehicle WHERE id=?", vehicleId).Scan(
+	err := DB.QueryRow("SELECT "+selectColumns("vehicle", vehicleColumns)+" FROM vehicle WHERE id=? AND deleted_at IS NULL", vehicleId).Scan(
 		&vehicle.ID,
 		&vehicle.Name,
 		&vehicle.Description,
@@ -755,24 +957,34 @@ func GetVehicle(vehicleId int64) (*models.Vehicle, error) {
 		&vehicle.User,
 		&vehicle.Created_at,
 		&vehicle.Updated_at,
+		&vehicle.Deleted_at,
 	)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return nil, err
 	}
+	// add labels to vehicle
+	labels, err := ListLinkedLabels("vehicle", []int64{vehicle.ID})
+	if err != nil {
+		return nil, err
+	}
+	vehicle.Labels = labels[vehicle.ID]
 	return &vehicle, nil
 }
 
 // ListVehicles
-// Take filters as args, return Vehicle list
-func ListVehicles(userId *string, jobId *string, searchStr *string, sort *string) ([]*models.Vehicle, error) {
+// Take filters and optional Page as args, return Vehicle list, only one page of it if Page provided
+func ListVehicles(userId *string, jobId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Vehicle, error) {
 	var joins []string
 	var wheres []string
 	var likes []Like
+	var args []interface{}
 	// establish default sort if not provided
 	var orderBy = "v.updated_at DESC"
 	// establish basic query
-	q := "SELECT * FROM vehicle AS v"
+	q := "SELECT " + selectColumns("v", vehicleColumns) + " FROM vehicle AS v"
+	// leave out trashed rows
+	wheres = append(wheres, "v.deleted_at IS NULL")
 	// if userId provided, add where to query
 	if userId != nil && len(*userId) > 0 {
 		wheres = append(wheres, "v.user="+*userId)
@@ -815,10 +1027,17 @@ func ListVehicles(userId *string, jobId *string, searchStr *string, sort *string
 			orderBy = "v.updated_at DESC"
 		}
 	}
+	// check cursor and count all matching rows if paginating
+	if page != nil {
+		err := pageStart(page, orderBy, QueryBuilder(q, &joins, &wheres, &likes, nil, nil, nil), args)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// generate query with QueryBuilder
-	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy)
+	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy, page)
 	// retrieve all matching rows
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(query, append(args, pageArgs(page)...)...)
 	if err != nil {
 		log.Printf("DB Query Error: %s", err)
 		return nil, err
@@ -845,6 +1064,7 @@ func ListVehicles(userId *string, jobId *string, searchStr *string, sort *string
 			&vehicle.User,
 			&vehicle.Created_at,
 			&vehicle.Updated_at,
+			&vehicle.Deleted_at,
 		)
 		if err != nil {
 			log.Printf("Error scanning rows retrieved from DB: %s", err)
@@ -853,14 +1073,40 @@ func ListVehicles(userId *string, jobId *string, searchStr *string, sort *string
 		// append Job to list of Job
 		vehicles = append(vehicles, &vehicle)
 	}
+	// drop extra row fetched to detect another page, resume after last row
+	if page != nil && len(vehicles) > page.Limit {
+		vehicles = vehicles[:page.Limit]
+		err = pageNext(page, "vehicle", vehicles[len(vehicles)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// add labels to every vehicle on the page with one query
+	ids := make([]int64, len(vehicles))
+	for i, vehicle := range vehicles {
+		ids[i] = vehicle.ID
+	}
+	labels, err := ListLinkedLabels("vehicle", ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, vehicle := range vehicles {
+		vehicle.Labels = labels[vehicle.ID]
+	}
 	return vehicles, nil
 }
 
 // CreateVehicle
 // Takes newVehicle, creates in db, returns id
 func CreateVehicle(newVehicle models.NewVehicle) (*int64, error) {
+	return createVehicle(DB, newVehicle)
+}
+
+// createVehicle
+// Runs CreateVehicle against db or transaction
+func createVehicle(ex execer, newVehicle models.NewVehicle) (*int64, error) {
 	// insert into db, return any errors
-	res, err := DB.Exec("INSERT INTO vehicle(Name, Description, Type, Is_metric, Vin, Year, Make, Model, Trim, Odometer, User) VALUES (?,?,?,?,?,?,?,?,?,?,?)",
+	res, err := ex.Exec("INSERT INTO vehicle(Name, Description, Type, Is_metric, Vin, Year, Make, Model, Trim, Odometer, User) VALUES (?,?,?,?,?,?,?,?,?,?,?)",
 		newVehicle.Name,
 		newVehicle.Description,
 		newVehicle.Type,
@@ -884,17 +1130,18 @@ func CreateVehicle(newVehicle models.NewVehicle) (*int64, error) {
 
 // EditVehicle
 // Take Vehicle as arg, build update query with QueryBuilder, update it in db via generated query
-func EditVehicle(editedVehicle models.Vehicle) error {
+func EditVehicle(editedVehicle models.Vehicle, revision *time.Time) error {
 	var wheres []string
 	// setup query
-	q := "UPDATE vehicle SET name=?, description=?, type=?, is_metric=?, vin=?, year=?, make=?, model=?, trim=?, odometer=?, user=?, updated_at=CURRENT_TIMESTAMP"
+	q := "UPDATE vehicle SET name=?, description=?, type=?, is_metric=?, vin=?, year=?, make=?, model=?, trim=?, odometer=?, user=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now')"
 	// add required wheres (ensures the vehicle id and user id in the db match that of request body)
 	wheres = append(wheres, "user=?")
 	wheres = append(wheres, "id=?")
+	args := revisionWhere(&wheres, []any{editedVehicle.Name, editedVehicle.Description, editedVehicle.Type, editedVehicle.Is_metric, editedVehicle.Vin, editedVehicle.Year, editedVehicle.Make, editedVehicle.Model, editedVehicle.Trim, editedVehicle.Odometer, editedVehicle.User, editedVehicle.User, editedVehicle.ID}, revision)
 	// get generated query
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	// exec query
-	res, err := DB.Exec(query, editedVehicle.Name, editedVehicle.Description, editedVehicle.Type, editedVehicle.Is_metric, editedVehicle.Vin, editedVehicle.Year, editedVehicle.Make, editedVehicle.Model, editedVehicle.Trim, editedVehicle.Odometer, editedVehicle.User, editedVehicle.User, editedVehicle.ID)
+	res, err := DB.Exec(query, args...)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return err
@@ -903,39 +1150,8 @@ func EditVehicle(editedVehicle models.Vehicle) error {
 	rowCount, err := res.RowsAffected()
 	if rowCount == 0 || err != nil {
 		log.Printf("No rows updated: %v", err)
-		return errors.New("No rows updated")
-	}
-	return nil
-}
-
-// DeleteVehicle
-// Take vehicle id as arg, delete Vehicle from vehicle table where id present
-func DeleteVehicle(vehicleId int64, userId *int64) error {
-	var wheres []string
-	q := "DELETE FROM vehicle"
-	wheres = append(wheres, "id="+strconv.FormatInt(vehicleId, 10))
-	if userId != nil {
-		wheres = append(wheres, "user="+strconv.FormatInt(*userId, 10))
-	}
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
-	res, err := DB.Exec(query)
-	// throw SQL errors
-	if err != nil {
-		log.Printf("DB Query Error: %s", err)
-		return err
-	}
-	// retrieve rows affected count
-	rows, err := res.RowsAffected()
-	if err != nil {
-		log.Printf("DB Query Error: %s", err)
-		return err
-	}
-	// throw error if no rows affected
-	if rows == 0 {
-		log.Printf("No rows deleted")
-		return errors.New("No rows deleted")
+		return noRowsUpdated(revision)
 	}
-
 	return nil
 }
 
@@ -946,7 +1162,7 @@ func DeleteVehicle(vehicleId int64, userId *int64) error {
 func GetAlert(alertId int64) (*models.Alert, error) {
 	var alert models.Alert
 	// query db, return any errors
-	err := DB.QueryRow("SELECT * FROM alert WHERE id=?", alertId).Scan(
+	err := DB.QueryRow("SELECT "+selectColumns("alert", alertColumns)+" FROM alert WHERE id=? AND deleted_at IS NULL", alertId).Scan(
 		&alert.ID,
 		&alert.Name,
 		&alert.Description,
@@ -960,6 +1176,7 @@ func GetAlert(alertId int64) (*models.Alert, error) {
 		&alert.Alert_at,
 		&alert.Created_at,
 		&alert.Updated_at,
+		&alert.Deleted_at,
 	)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
@@ -971,10 +1188,17 @@ func GetAlert(alertId int64) (*models.Alert, error) {
 // CreateAlert
 // Takes newAlert, creates in db, returns id
 func CreateAlert(newAlert models.NewAlert) (*int64, error) {
+	return createAlert(DB, newAlert)
+}
+
+// createAlert
+// Runs CreateAlert against db or transaction
+func createAlert(ex execer, newAlert models.NewAlert) (*int64, error) {
 	// insert into db, return any errors
-	res, err := DB.Exec("INSERT INTO alert(Name, Description, User, Vehicle, Job, Task, Alert_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+	res, err := ex.Exec("INSERT INTO alert(Name, Description, Type, User, Vehicle, Job, Task, Alert_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
 		newAlert.Name,
 		newAlert.Description,
+		newAlert.Type,
 		newAlert.User,
 		newAlert.Vehicle,
 		newAlert.Job,
@@ -992,17 +1216,18 @@ func CreateAlert(newAlert models.NewAlert) (*int64, error) {
 
 // EditAlert
 // Take Alert as arg, build update query with QueryBuilder, update it in db via generated query
-func EditAlert(editedAlert models.Alert) error {
+func EditAlert(editedAlert models.Alert, revision *time.Time) error {
 	var wheres []string
 	// setup query
-	q := "UPDATE alert SET name=?, description=?, type=?, user=?, vehicle=?, job=?, task=?, is_read=?, alert_at=?, updated_at=CURRENT_TIMESTAMP"
+	q := "UPDATE alert SET name=?, description=?, type=?, user=?, vehicle=?, job=?, task=?, is_read=?, alert_at=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now')"
 	// add required wheres (ensures the alert id and user id in the db match that of request body)
 	wheres = append(wheres, "user=?")
 	wheres = append(wheres, "id=?")
+	args := revisionWhere(&wheres, []any{editedAlert.Name, editedAlert.Description, editedAlert.Type, editedAlert.User, editedAlert.Vehicle, editedAlert.Job, editedAlert.Task, editedAlert.Is_read, editedAlert.Alert_at, editedAlert.User, editedAlert.ID}, revision)
 	// get generated query
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	// exec query
-	res, err := DB.Exec(query, editedAlert.Name, editedAlert.Description, editedAlert.Type, editedAlert.User, editedAlert.Vehicle, editedAlert.Job, editedAlert.Task, editedAlert.Is_read, editedAlert.Alert_at, editedAlert.User, editedAlert.ID)
+	res, err := DB.Exec(query, args...)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return err
@@ -1011,7 +1236,7 @@ func EditAlert(editedAlert models.Alert) error {
 	rowCount, err := res.RowsAffected()
 	if rowCount == 0 || err != nil {
 		log.Printf("No rows updated: %v", err)
-		return errors.New("No rows updated")
+		return noRowsUpdated(revision)
 	}
 	return nil
 }
@@ -1025,7 +1250,7 @@ func DeleteAlert(alertId int64, userId *int64) error {
 	if userId != nil {
 		wheres = append(wheres, "user="+strconv.FormatInt(*userId, 10))
 	}
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	res, err := DB.Exec(query)
 	// throw SQL errors
 	if err != nil {
@@ -1048,15 +1273,18 @@ func DeleteAlert(alertId int64, userId *int64) error {
 }
 
 // ListAlerts
-// Take filters as args, return Alert list
-func ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string, typeStr *string, isRead *string, alertDate *string, searchStr *string, sort *string) ([]*models.Alert, error) {
+// Take filters and optional Page as args, return Alert list, only one page of it if Page provided
+func ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string, typeStr *string, isRead *string, alertDate *string, searchStr *string, sort *string, page *models.Page) ([]*models.Alert, error) {
 	var joins []string
 	var wheres []string
 	var likes []Like
+	var args []interface{}
 	// establish default sort if not provided
 	var orderBy = "a.updated_at DESC"
 	// establish basic query
-	q := "SELECT * FROM alert AS a"
+	q := "SELECT " + selectColumns("a", alertColumns) + " FROM alert AS a"
+	// leave out trashed rows
+	wheres = append(wheres, "a.deleted_at IS NULL")
 	// if userId provided, add where to query
 	if userId != nil && len(*userId) > 0 {
 		wheres = append(wheres, "a.user="+*userId)
@@ -1115,10 +1343,17 @@ func ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string
 			orderBy = "a.updated_at DESC"
 		}
 	}
+	// check cursor and count all matching rows if paginating
+	if page != nil {
+		err := pageStart(page, orderBy, QueryBuilder(q, &joins, &wheres, &likes, nil, nil, nil), args)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// generate query with QueryBuilder
-	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy)
+	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy, page)
 	// retrieve all matching rows
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(query, append(args, pageArgs(page)...)...)
 	if err != nil {
 		log.Printf("DB Query Error: %s", err)
 		return nil, err
@@ -1144,6 +1379,7 @@ func ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string
 			&alert.Alert_at,
 			&alert.Created_at,
 			&alert.Updated_at,
+			&alert.Deleted_at,
 		)
 		if err != nil {
 			log.Printf("Error scanning rows retrieved from DB: %s", err)
@@ -1152,15 +1388,29 @@ func ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string
 		// append Alert to list of Alert
 		alerts = append(alerts, &alert)
 	}
+	// drop extra row fetched to detect another page, resume after last row
+	if page != nil && len(alerts) > page.Limit {
+		alerts = alerts[:page.Limit]
+		err = pageNext(page, "alert", alerts[len(alerts)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return alerts, nil
 }
 
-// UpdatedAlertStatus
+// UpdateAlertStatus
 // Take alert id, user id, status as args, build update query with QueryBuilder, update it in db via generated query
-func UpdatedAlertStatus(alertId int64, userId int64, status int) error {
+func UpdateAlertStatus(alertId int64, userId int64, status int) error {
+	return updateAlertStatus(DB, alertId, userId, status)
+}
+
+// updateAlertStatus
+// Runs UpdateAlertStatus against db or transaction
+func updateAlertStatus(ex execer, alertId int64, userId int64, status int) error {
 	var wheres []string
 	// setup query
-	q := "UPDATE alert SET is_read=?, updated_at=CURRENT_TIMESTAMP"
+	q := "UPDATE alert SET is_read=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now')"
 	// if status is complete, updated completed_at also
 	if status == 1 {
 		q += ", read_at=CURRENT_TIMESTAMP"
@@ -1169,9 +1419,9 @@ func UpdatedAlertStatus(alertId int64, userId int64, status int) error {
 	wheres = append(wheres, "user=?")
 	wheres = append(wheres, "id=?")
 	// get generated query
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	// exec query
-	res, err := DB.Exec(query, status, userId, alertId)
+	res, err := ex.Exec(query, status, userId, alertId)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return err
@@ -1192,7 +1442,7 @@ func UpdatedAlertStatus(alertId int64, userId int64, status int) error {
 func GetLabel(labelId int64) (*models.Label, error) {
 	var label models.Label
 	// query db, return any errors
-	err := DB.QueryRow("SELECT * FROM label WHERE id=?", labelId).Scan(
+	err := DB.QueryRow("SELECT id, name, color, user, created_at, updated_at FROM label WHERE id=? AND deleted_at IS NULL", labelId).Scan(
 		&label.ID,
 		&label.Name,
 		&label.Color,
@@ -1210,8 +1460,14 @@ func GetLabel(labelId int64) (*models.Label, error) {
 // CreateLabel
 // Takes newLabel, creates in db, returns id
 func CreateLabel(newLabel models.NewLabel) (*int64, error) {
+	return createLabel(DB, newLabel)
+}
+
+// createLabel
+// Runs CreateLabel against db or transaction
+func createLabel(ex execer, newLabel models.NewLabel) (*int64, error) {
 	// insert into db, return any errors
-	res, err := DB.Exec("INSERT INTO label(Name, Color, User) VALUES (?,?,?)",
+	res, err := ex.Exec("INSERT INTO label(Name, Color, User) VALUES (?,?,?)",
 		newLabel.Name,
 		newLabel.Color,
 		newLabel.User,
@@ -1227,17 +1483,18 @@ func CreateLabel(newLabel models.NewLabel) (*int64, error) {
 
 // EditLabel
 // Take Label as arg, build update query with QueryBuilder, update it in db via generated query
-func EditLabel(editedLabel models.Label) error {
+func EditLabel(editedLabel models.Label, revision *time.Time) error {
 	var wheres []string
 	// setup query
-	q := "UPDATE label SET name=?, color=?, updated_at=CURRENT_TIMESTAMP"
+	q := "UPDATE label SET name=?, color=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now')"
 	// add required wheres (ensures the label id and user id in the db match that of request body)
 	wheres = append(wheres, "user=?")
 	wheres = append(wheres, "id=?")
+	args := revisionWhere(&wheres, []any{editedLabel.Name, editedLabel.Color, editedLabel.User, editedLabel.ID}, revision)
 	// get generated query
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	// exec query
-	res, err := DB.Exec(query, editedLabel.Name, editedLabel.Color, editedLabel.User, editedLabel.ID)
+	res, err := DB.Exec(query, args...)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return err
@@ -1246,7 +1503,7 @@ func EditLabel(editedLabel models.Label) error {
 	rowCount, err := res.RowsAffected()
 	if rowCount == 0 || err != nil {
 		log.Printf("No rows updated: %v", err)
-		return errors.New("No rows updated")
+		return noRowsUpdated(revision)
 	}
 	return nil
 }
@@ -1260,7 +1517,7 @@ func DeleteLabel(labelId int64, userId *int64) error {
 	if userId != nil {
 		wheres = append(wheres, "user="+strconv.FormatInt(*userId, 10))
 	}
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	res, err := DB.Exec(query)
 	// throw SQL errors
 	if err != nil {
@@ -1283,15 +1540,18 @@ func DeleteLabel(labelId int64, userId *int64) error {
 }
 
 // ListLabels
-// Take filters as args, return Label list
-func ListLabels(userId *string, jobId *string, searchStr *string, sort *string) ([]*models.Label, error) {
+// Take filters and optional Page as args, return Label list, only one page of it if Page provided
+func ListLabels(userId *string, jobId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Label, error) {
 	var joins []string
 	var wheres []string
 	var likes []Like
+	var args []interface{}
 	// establish default sort if not provided
 	var orderBy = "l.updated_at DESC"
 	// establish basic query
 	q := "SELECT l.id, l.name, l.color, l.user, l.created_at, l.updated_at FROM label AS l"
+	// leave out trashed rows
+	wheres = append(wheres, "l.deleted_at IS NULL")
 	// if userId provided, add where to query
 	if userId != nil && len(*userId) > 0 {
 		wheres = append(wheres, "l.user="+*userId)
@@ -1328,10 +1588,17 @@ func ListLabels(userId *string, jobId *string, searchStr *string, sort *string)
 			orderBy = "l.updated_at DESC"
 		}
 	}
+	// check cursor and count all matching rows if paginating
+	if page != nil {
+		err := pageStart(page, orderBy, QueryBuilder(q, &joins, &wheres, &likes, nil, nil, nil), args)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// generate query with QueryBuilder
-	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy)
+	query := QueryBuilder(q, &joins, &wheres, &likes, nil, &orderBy, page)
 	// retrieve all matching rows
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(query, append(args, pageArgs(page)...)...)
 	if err != nil {
 		log.Printf("DB Query Error: %s", err)
 		return nil, err
@@ -1359,36 +1626,396 @@ func ListLabels(userId *string, jobId *string, searchStr *string, sort *string)
 		// append Label to list of Label
 		labels = append(labels, &label)
 	}
+	// drop extra row fetched to detect another page, resume after last row
+	if page != nil && len(labels) > page.Limit {
+		labels = labels[:page.Limit]
+		err = pageNext(page, "label", labels[len(labels)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return labels, nil
 }
 
 // AssignJobLabel
-// Takes job id and task id, creates job_label in db, returns id
+// Takes job id and label id, creates job_label in db, returns id
 func AssignJobLabel(jobId int64, labelId int64) (*int64, error) {
-	q := "INSERT INTO job_label(Job, Label) VALUES (?,?)"
-	log.Printf(q)
-	// insert into db, return any errors
-	res, err := DB.Exec(q,
-		jobId,
-		labelId,
+	return assignLinkedLabel(DB, "job", jobId, labelId)
+}
+
+// assignJobLabel
+// Runs AssignJobLabel against db or transaction
+func assignJobLabel(ex execer, jobId int64, labelId int64) (*int64, error) {
+	return assignLinkedLabel(ex, "job", jobId, labelId)
+}
+
+// UnassignJobLabel
+// Takes job id and label id, deletes job_label entry in db if its exists
+func UnassignJobLabel(jobId int64, labelId int64) error {
+	return UnassignLinkedLabel("job", jobId, labelId)
+}
+
+// Schedule Queries
+
+// GetSchedule
+// Takes schedule id, queries it in db, returns Schedule
+func GetSchedule(scheduleId int64) (*models.Schedule, error) {
+	var schedule models.Schedule
+	// query db, return any errors
+	err := DB.QueryRow("SELECT * FROM schedule WHERE id=?", scheduleId).Scan(
+		&schedule.ID,
+		&schedule.Name,
+		&schedule.Description,
+		&schedule.Make,
+		&schedule.Model,
+		&schedule.Year_min,
+		&schedule.Year_max,
+		&schedule.User,
+		&schedule.Created_at,
+		&schedule.Updated_at,
 	)
 	if err != nil {
 		log.Printf("DB Execution Error: %s", err)
 		return nil, err
 	}
-	// get inserted tasks id
-	taskId, err := res.LastInsertId()
-	return &taskId, err
+	return &schedule, nil
 }
 
-// UnassignJobLabel
-// Takes job id and task id, deletes job_label entry in db if its exists
-func UnassignJobLabel(jobId int64, labelId int64) error {
+// CreateSchedule
+// Takes newSchedule, creates in db, returns id
+func CreateSchedule(newSchedule models.NewSchedule) (*int64, error) {
+	// insert into db, return any errors
+	res, err := DB.Exec("INSERT INTO schedule(Name, Description, Make, Model, Year_min, Year_max, User) VALUES (?,?,?,?,?,?,?)",
+		newSchedule.Name,
+		newSchedule.Description,
+		newSchedule.Make,
+		newSchedule.Model,
+		newSchedule.Year_min,
+		newSchedule.Year_max,
+		newSchedule.User,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// get inserted schedules id
+	scheduleId, err := res.LastInsertId()
+	return &scheduleId, err
+}
+
+// DeleteSchedule
+// Take schedule id as arg, delete Schedule from schedule table where id present
+func DeleteSchedule(scheduleId int64, userId *int64) error {
 	var wheres []string
-	q := "DELETE FROM job_label"
-	wheres = append(wheres, "job="+strconv.FormatInt(jobId, 10))
-	wheres = append(wheres, "label="+strconv.FormatInt(labelId, 10))
-	query := QueryBuilder(q, nil, &wheres, nil, nil, nil)
+	q := "DELETE FROM schedule"
+	wheres = append(wheres, "id="+strconv.FormatInt(scheduleId, 10))
+	if userId != nil {
+		wheres = append(wheres, "user="+strconv.FormatInt(*userId, 10))
+	}
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
+	res, err := DB.Exec(query)
+	// throw SQL errors
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	// throw error if no rows affected
+	if rows == 0 {
+		log.Printf("No rows deleted")
+		return errors.New("No rows deleted")
+	}
+
+	return nil
+}
+
+// ListSchedules
+// Take filters as args, return Schedule list
+func ListSchedules(userId *string, makeStr *string, modelStr *string, yearStr *string, searchStr *string, sort *string) ([]*models.Schedule, error) {
+	var wheres []string
+	var likes []Like
+	var args []interface{}
+	// establish default sort if not provided
+	var orderBy = "s.updated_at DESC"
+	// establish basic query
+	q := "SELECT * FROM schedule AS s"
+	// if userId provided, add where to query, unowned schedules are available to everyone
+	if userId != nil && len(*userId) > 0 {
+		wheres = append(wheres, "(s.user IS NULL OR s.user=?)")
+		args = append(args, *userId)
+	}
+	// if make provided, add case insensitive where to query, schedules without a make match any
+	if makeStr != nil && len(*makeStr) > 0 {
+		wheres = append(wheres, "(s.make IS NULL OR LOWER(s.make)=LOWER(?))")
+		args = append(args, *makeStr)
+	}
+	// if model provided, add case insensitive where to query, schedules without a model match any
+	if modelStr != nil && len(*modelStr) > 0 {
+		wheres = append(wheres, "(s.model IS NULL OR LOWER(s.model)=LOWER(?))")
+		args = append(args, *modelStr)
+	}
+	// if year provided, add where to query for schedules whose year range contains it
+	if yearStr != nil && len(*yearStr) > 0 {
+		wheres = append(wheres, "(s.year_min IS NULL OR s.year_min<=?)")
+		wheres = append(wheres, "(s.year_max IS NULL OR s.year_max>=?)")
+		args = append(args, *yearStr, *yearStr)
+	}
+	// if search string provided, construct likes to query name, description cols
+	if searchStr != nil && len(*searchStr) > 0 {
+		var fields []string
+		fields = append(fields, "s.name")
+		fields = append(fields, "s.description")
+		likes = append(likes, Like{
+			Fields: fields,
+			Match:  *searchStr,
+			Or:     true,
+		})
+	}
+	// if sort provided, append appropriate sort based on query param
+	if sort != nil && len(*sort) > 0 {
+		switch *sort {
+		case "az":
+			orderBy = "s.name ASC"
+		case "za":
+			orderBy = "s.name DESC"
+		case "oldest":
+			orderBy = "s.created_at ASC"
+		case "newest":
+			orderBy = "s.created_at DESC"
+		case "last_updated":
+			orderBy = "s.updated_at DESC"
+		default:
+			orderBy = "s.updated_at DESC"
+		}
+	}
+	// generate query with QueryBuilder
+	query := QueryBuilder(q, nil, &wheres, &likes, nil, &orderBy, nil)
+	// retrieve all matching rows
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// create list of Schedule
+	schedules := make([]*models.Schedule, 0)
+	// loop through returned rows
+	for rows.Next() {
+		// attribute to Schedule
+		schedule := models.Schedule{}
+		err := rows.Scan(
+			&schedule.ID,
+			&schedule.Name,
+			&schedule.Description,
+			&schedule.Make,
+			&schedule.Model,
+			&schedule.Year_min,
+			&schedule.Year_max,
+			&schedule.User,
+			&schedule.Created_at,
+			&schedule.Updated_at,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		// append Schedule to list of Schedule
+		schedules = append(schedules, &schedule)
+	}
+	return schedules, nil
+}
+
+// AssignScheduleJob
+// Takes schedule id and job id, creates schedule_job in db, returns id
+func AssignScheduleJob(scheduleId int64, jobId int64) (*int64, error) {
+	// insert into db, return any errors
+	res, err := DB.Exec("INSERT INTO schedule_job(Schedule, Job) VALUES (?,?)",
+		scheduleId,
+		jobId,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// get inserted relationships id
+	relationshipId, err := res.LastInsertId()
+	return &relationshipId, err
+}
+
+// ListScheduleJobIds
+// Takes schedule id, returns ids of the template jobs belonging to it
+func ListScheduleJobIds(scheduleId int64) ([]int64, error) {
+	rows, err := DB.Query("SELECT job FROM schedule_job WHERE schedule=? ORDER BY id ASC", scheduleId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// create list of job ids
+	jobIds := make([]int64, 0)
+	// loop through returned rows
+	for rows.Next() {
+		var jobId int64
+		if err := rows.Scan(&jobId); err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		jobIds = append(jobIds, jobId)
+	}
+	return jobIds, nil
+}
+
+// UnassignScheduleJobs
+// Takes schedule id, deletes all of its schedule_job entries
+func UnassignScheduleJobs(scheduleId int64) error {
+	_, err := DB.Exec("DELETE FROM schedule_job WHERE schedule=?", scheduleId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// Document Queries
+
+// documentCols
+// Columns selected for Document, leaves out attachment data so it is only loaded when requested
+const documentCols = "d.id, d.type, d.number, d.issuer, d.description, d.vehicle, d.user, d.issued_at, d.expires_at, d.remind_days, d.alert, d.attachment_name, d.attachment_type, d.created_at, d.updated_at"
+
+// GetDocument
+// Takes vehicle id and document id, queries it in db, returns Document
+func GetDocument(vehicleId int64, documentId int64) (*models.Document, error) {
+	var document models.Document
+	// query db, return any errors
+	err := DB.QueryRow("SELECT "+documentCols+" FROM vehicle_document AS d WHERE d.id=? AND d.vehicle=? AND d.deleted_at IS NULL", documentId, vehicleId).Scan(
+		&document.ID,
+		&document.Type,
+		&document.Number,
+		&document.Issuer,
+		&document.Description,
+		&document.Vehicle,
+		&document.User,
+		&document.Issued_at,
+		&document.Expires_at,
+		&document.Remind_days,
+		&document.Alert,
+		&document.Attachment_name,
+		&document.Attachment_type,
+		&document.Created_at,
+		&document.Updated_at,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	return &document, nil
+}
+
+// CreateDocument
+// Takes newDocument, vehicle id and user id, creates in db, returns id
+func CreateDocument(newDocument models.NewDocument, vehicleId int64, userId int64) (*int64, error) {
+	// insert into db, return any errors
+	res, err := DB.Exec("INSERT INTO vehicle_document(Type, Number, Issuer, Description, Vehicle, User, Issued_at, Expires_at, Remind_days) VALUES (?,?,?,?,?,?,?,?,?)",
+		newDocument.Type,
+		newDocument.Number,
+		newDocument.Issuer,
+		newDocument.Description,
+		vehicleId,
+		userId,
+		newDocument.Issued_at,
+		newDocument.Expires_at,
+		newDocument.Remind_days,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// get inserted documents id
+	documentId, err := res.LastInsertId()
+	return &documentId, err
+}
+
+// EditDocument
+// Take Document as arg, build update query with QueryBuilder, update it in db via generated query
+func EditDocument(editedDocument models.Document, vehicleId int64) error {
+	var wheres []string
+	// setup query
+	q := "UPDATE vehicle_document SET type=?, number=?, issuer=?, description=?, issued_at=?, expires_at=?, remind_days=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now')"
+	// add required wheres (ensures the document id and vehicle id in the db match that of request)
+	wheres = append(wheres, "vehicle=?")
+	wheres = append(wheres, "id=?")
+	// get generated query
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
+	// exec query
+	res, err := DB.Exec(query, editedDocument.Type, editedDocument.Number, editedDocument.Issuer, editedDocument.Description, editedDocument.Issued_at, editedDocument.Expires_at, editedDocument.Remind_days, vehicleId, editedDocument.ID)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count, error if 0
+	rowCount, err := res.RowsAffected()
+	if rowCount == 0 || err != nil {
+		log.Printf("No rows updated: %v", err)
+		return errors.New("No rows updated")
+	}
+	return nil
+}
+
+// UpdateDocumentAlert
+// Takes document id and reminder alert id (nil to clear), updates it in db
+func UpdateDocumentAlert(documentId int64, alertId *int64) error {
+	_, err := DB.Exec("UPDATE vehicle_document SET alert=? WHERE id=?", alertId, documentId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// GetDocumentAttachment
+// Takes vehicle id and document id, returns attachment name, content type and data
+func GetDocumentAttachment(vehicleId int64, documentId int64) (*string, *string, []byte, error) {
+	var name *string
+	var contentType *string
+	var data []byte
+	err := DB.QueryRow("SELECT attachment_name, attachment_type, attachment FROM vehicle_document WHERE id=? AND vehicle=? AND deleted_at IS NULL", documentId, vehicleId).Scan(&name, &contentType, &data)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, nil, nil, err
+	}
+	return name, contentType, data, nil
+}
+
+// UpdateDocumentAttachment
+// Takes vehicle id, document id, attachment name, content type and data (nil to remove), updates it in db
+func UpdateDocumentAttachment(vehicleId int64, documentId int64, name *string, contentType *string, data []byte) error {
+	res, err := DB.Exec("UPDATE vehicle_document SET attachment_name=?, attachment_type=?, attachment=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now') WHERE id=? AND vehicle=?", name, contentType, data, documentId, vehicleId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count, error if 0
+	rowCount, err := res.RowsAffected()
+	if rowCount == 0 || err != nil {
+		log.Printf("No rows updated: %v", err)
+		return errors.New("No rows updated")
+	}
+	return nil
+}
+
+// DeleteDocument
+// Take vehicle id and document id as args, delete Document from vehicle_document table where ids present
+func DeleteDocument(vehicleId int64, documentId int64) error {
+	var wheres []string
+	q := "DELETE FROM vehicle_document"
+	wheres = append(wheres, "vehicle="+strconv.FormatInt(vehicleId, 10))
+	wheres = append(wheres, "id="+strconv.FormatInt(documentId, 10))
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
 	res, err := DB.Exec(query)
 	// throw SQL errors
 	if err != nil {
@@ -1409,3 +2036,2143 @@ func UnassignJobLabel(jobId int64, labelId int64) error {
 
 	return nil
 }
+
+// ListDocuments
+// Take filters as args, return Document list
+func ListDocuments(vehicleId int64, typeStr *string, expiresBefore *string, searchStr *string, sort *string) ([]*models.Document, error) {
+	var wheres []string
+	var likes []Like
+	var args []interface{}
+	// establish default sort if not provided
+	var orderBy = "d.expires_at ASC"
+	// establish basic query
+	q := "SELECT " + documentCols + " FROM vehicle_document AS d"
+	// leave out trashed rows
+	wheres = append(wheres, "d.deleted_at IS NULL")
+	// add wheres for vehicle id
+	wheres = append(wheres, "d.vehicle="+strconv.FormatInt(vehicleId, 10))
+	// if typeStr provided, add where to query
+	if typeStr != nil && len(*typeStr) > 0 {
+		wheres = append(wheres, "d.type=?")
+		args = append(args, *typeStr)
+	}
+	// if expiresBefore provided, add where to query
+	if expiresBefore != nil && len(*expiresBefore) > 0 {
+		wheres = append(wheres, "d.expires_at<=?")
+		args = append(args, *expiresBefore)
+	}
+	// if search string provided, construct likes to query number, issuer, description cols
+	if searchStr != nil && len(*searchStr) > 0 {
+		var fields []string
+		fields = append(fields, "d.number")
+		fields = append(fields, "d.issuer")
+		fields = append(fields, "d.description")
+		likes = append(likes, Like{
+			Fields: fields,
+			Match:  *searchStr,
+			Or:     true,
+		})
+	}
+	// if sort provided, append appropriate sort based on query param
+	if sort != nil && len(*sort) > 0 {
+		switch *sort {
+		case "expires":
+			orderBy = "d.expires_at ASC"
+		case "issued":
+			orderBy = "d.issued_at DESC"
+		case "oldest":
+			orderBy = "d.created_at ASC"
+		case "newest":
+			orderBy = "d.created_at DESC"
+		case "last_updated":
+			orderBy = "d.updated_at DESC"
+		default:
+			orderBy = "d.expires_at ASC"
+		}
+	}
+	// generate query with QueryBuilder
+	query := QueryBuilder(q, nil, &wheres, &likes, nil, &orderBy, nil)
+	// retrieve all matching rows
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// create list of Document
+	documents := make([]*models.Document, 0)
+	// loop through returned rows
+	for rows.Next() {
+		// attribute to Document
+		document := models.Document{}
+		err := rows.Scan(
+			&document.ID,
+			&document.Type,
+			&document.Number,
+			&document.Issuer,
+			&document.Description,
+			&document.Vehicle,
+			&document.User,
+			&document.Issued_at,
+			&document.Expires_at,
+			&document.Remind_days,
+			&document.Alert,
+			&document.Attachment_name,
+			&document.Attachment_type,
+			&document.Created_at,
+			&document.Updated_at,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		// append Document to list of Document
+		documents = append(documents, &document)
+	}
+	return documents, nil
+}
+
+// UpdateJobStatus
+// Take job id, status and complete flag as args, update status, is_complete and completed_at in db
+func UpdateJobStatus(jobId int64, status string, isComplete int) error {
+	return updateJobStatus(DB, jobId, status, isComplete)
+}
+
+// updateJobStatus
+// Runs UpdateJobStatus against db or transaction
+func updateJobStatus(ex execer, jobId int64, status string, isComplete int) error {
+	var wheres []string
+	// setup query
+	q := "UPDATE job SET status=?, is_complete=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now')"
+	// if status is complete, updated completed_at also, otherwise clear it
+	if isComplete == 1 {
+		q += ", completed_at=CURRENT_TIMESTAMP"
+	} else {
+		q += ", completed_at=NULL"
+	}
+	// add required wheres
+	wheres = append(wheres, "id=?")
+	// get generated query
+	query := QueryBuilder(q, nil, &wheres, nil, nil, nil, nil)
+	// exec query
+	res, err := ex.Exec(query, status, isComplete, jobId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count, error if 0
+	rowCount, err := res.RowsAffected()
+	if rowCount == 0 || err != nil {
+		log.Printf("No rows updated: %v", err)
+		return errors.New("No rows updated")
+	}
+	return nil
+}
+
+// CreateJobStatusHistory
+// Takes job id, previous and new status, acting user and note, records the change in db, returns id
+func CreateJobStatusHistory(jobId int64, fromStatus *string, toStatus string, userId *int64, note *string) (*int64, error) {
+	return createJobStatusHistory(DB, jobId, fromStatus, toStatus, userId, note)
+}
+
+// createJobStatusHistory
+// Runs CreateJobStatusHistory against db or transaction
+func createJobStatusHistory(ex execer, jobId int64, fromStatus *string, toStatus string, userId *int64, note *string) (*int64, error) {
+	// insert into db, return any errors
+	res, err := ex.Exec("INSERT INTO job_status_history(Job, From_status, To_status, User, Note) VALUES (?,?,?,?,?)",
+		jobId,
+		fromStatus,
+		toStatus,
+		userId,
+		note,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// get inserted history id
+	historyId, err := res.LastInsertId()
+	return &historyId, err
+}
+
+// ListJobStatusHistory
+// Takes job id, returns its status changes oldest first
+func ListJobStatusHistory(jobId int64) ([]*models.JobStatusHistory, error) {
+	rows, err := DB.Query("SELECT id, job, from_status, to_status, user, note, created_at FROM job_status_history WHERE job=? ORDER BY created_at ASC, id ASC", jobId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// create list of JobStatusHistory
+	history := make([]*models.JobStatusHistory, 0)
+	// loop through returned rows
+	for rows.Next() {
+		// attribute to JobStatusHistory
+		entry := models.JobStatusHistory{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Job,
+			&entry.From_status,
+			&entry.To_status,
+			&entry.User,
+			&entry.Note,
+			&entry.Created_at,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		// append entry to history
+		history = append(history, &entry)
+	}
+	return history, nil
+}
+
+// DeleteJobStatusHistory
+// Takes job id, deletes its status history
+func DeleteJobStatusHistory(jobId int64) error {
+	_, err := DB.Exec("DELETE FROM job_status_history WHERE job=?", jobId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// CompleteJob
+// Take job id and completion time as args, mark job done and complete at the given time
+func CompleteJob(jobId int64, completedAt time.Time) error {
+	return completeJob(DB, jobId, completedAt)
+}
+
+// completeJob
+// Runs CompleteJob against db or transaction
+func completeJob(ex execer, jobId int64, completedAt time.Time) error {
+	res, err := ex.Exec("UPDATE job SET status='done', is_complete=1, completed_at=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now') WHERE id=?", completedAt, jobId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count, error if 0
+	rowCount, err := res.RowsAffected()
+	if rowCount == 0 || err != nil {
+		log.Printf("No rows updated: %v", err)
+		return errors.New("No rows updated")
+	}
+	return nil
+}
+
+// Job Completion Queries
+
+// GetLatestJobCompletion
+// Takes job id, returns its most recent completion record
+func GetLatestJobCompletion(jobId int64) (*models.JobCompletion, error) {
+	var completion models.JobCompletion
+	// query db, return any errors
+	err := DB.QueryRow("SELECT * FROM job_completion WHERE job=? ORDER BY id DESC LIMIT 1", jobId).Scan(
+		&completion.ID,
+		&completion.Job,
+		&completion.User,
+		&completion.Odometer,
+		&completion.Performed_by,
+		&completion.Shop,
+		&completion.Notes,
+		&completion.Cost,
+		&completion.Completed_at,
+		&completion.Prior_status,
+		&completion.Prior_odometer,
+		&completion.Odometer_reading,
+		&completion.Next_job,
+		&completion.Created_at,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	return &completion, nil
+}
+
+// CreateJobCompletion
+// Takes JobCompletion, creates in db, returns id
+func CreateJobCompletion(completion models.JobCompletion) (*int64, error) {
+	return createJobCompletion(DB, completion)
+}
+
+// createJobCompletion
+// Runs CreateJobCompletion against db or transaction
+func createJobCompletion(ex execer, completion models.JobCompletion) (*int64, error) {
+	// insert into db, return any errors
+	res, err := ex.Exec("INSERT INTO job_completion(Job, User, Odometer, Performed_by, Shop, Notes, Cost, Completed_at, Prior_status, Prior_odometer, Odometer_reading, Next_job) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)",
+		completion.Job,
+		completion.User,
+		completion.Odometer,
+		completion.Performed_by,
+		completion.Shop,
+		completion.Notes,
+		completion.Cost,
+		completion.Completed_at,
+		completion.Prior_status,
+		completion.Prior_odometer,
+		completion.Odometer_reading,
+		completion.Next_job,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// get inserted completions id
+	completionId, err := res.LastInsertId()
+	return &completionId, err
+}
+
+// DeleteJobCompletion
+// Takes completion id, deletes it from job_completion table
+func DeleteJobCompletion(completionId int64) error {
+	return deleteJobCompletion(DB, completionId)
+}
+
+// deleteJobCompletion
+// Runs DeleteJobCompletion against db or transaction
+func deleteJobCompletion(ex execer, completionId int64) error {
+	res, err := ex.Exec("DELETE FROM job_completion WHERE id=?", completionId)
+	// throw SQL errors
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	// throw error if no rows affected
+	if rows == 0 {
+		log.Printf("No rows deleted")
+		return errors.New("No rows deleted")
+	}
+	return nil
+}
+
+// DeleteJobCompletions
+// Takes job id, deletes all of its completion records
+func DeleteJobCompletions(jobId int64) error {
+	_, err := DB.Exec("DELETE FROM job_completion WHERE job=?", jobId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// RecordJobCompletion
+// Takes JobCompletion, vehicle the odometer was read on, whether to move the vehicle odometer forward and optional next occurrence of the job as args, marks the job done and records the reading, status change, next occurrence and completion in a single transaction, nothing is written if any fail, returns completion id
+func RecordJobCompletion(completion models.JobCompletion, vehicleId *int64, moveOdometer bool, nextJob *models.NextJob) (*int64, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	// record odometer at service on the vehicle
+	if vehicleId != nil && completion.Odometer != nil {
+		completion.Odometer_reading, err = createOdometerReading(tx, *vehicleId, models.NewOdometerReading{
+			Odometer:    *completion.Odometer,
+			Recorded_at: &completion.Completed_at,
+		}, "completion", &completion.Job, completion.User)
+		if err != nil {
+			return nil, err
+		}
+		if moveOdometer {
+			err = updateVehicleOdometer(tx, *vehicleId, completion.Odometer)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	err = completeJob(tx, completion.Job, completion.Completed_at)
+	if err != nil {
+		return nil, err
+	}
+	_, err = createJobStatusHistory(tx, completion.Job, &completion.Prior_status, "done", completion.User, completion.Notes)
+	if err != nil {
+		return nil, err
+	}
+	if nextJob != nil {
+		completion.Next_job, err = createNextJob(tx, *nextJob)
+		if err != nil {
+			return nil, err
+		}
+	}
+	completionId, err := createJobCompletion(tx, completion)
+	if err != nil {
+		return nil, err
+	}
+	return completionId, tx.Commit()
+}
+
+// createNextJob
+// Takes transaction and NextJob, creates the job with its initial status history, copies of its tasks relinked to each other and its labels, returns job id
+func createNextJob(ex execer, nextJob models.NextJob) (*int64, error) {
+	jobId, err := createJob(ex, nextJob.Job)
+	if err != nil {
+		return nil, err
+	}
+	_, err = createJobStatusHistory(ex, *jobId, nil, *nextJob.Job.Status, nextJob.Job.User, nil)
+	if err != nil {
+		return nil, err
+	}
+	// copy tasks unlinked first, parents and prerequisites may come later in the job
+	taskIds := map[int64]int64{}
+	for _, task := range nextJob.Tasks {
+		taskId, err := createTask(ex, models.NewTask{
+			Name:              task.Name,
+			Description:       task.Description,
+			Part_name:         task.Part_name,
+			Part_link:         task.Part_link,
+			Estimated_minutes: task.Estimated_minutes,
+		}, *jobId)
+		if err != nil {
+			return nil, err
+		}
+		taskIds[task.ID] = *taskId
+	}
+	// links to tasks that were not copied are dropped
+	for _, task := range nextJob.Tasks {
+		if task.Parent != nil {
+			if parentId, ok := taskIds[*task.Parent]; ok {
+				_, err = ex.Exec("UPDATE task SET parent=? WHERE id=?", parentId, taskIds[task.ID])
+				if err != nil {
+					log.Printf("DB Execution Error: %s", err)
+					return nil, err
+				}
+			}
+		}
+		var dependsOn []int64
+		for _, dependencyId := range task.Depends_on {
+			if copiedId, ok := taskIds[dependencyId]; ok {
+				dependsOn = append(dependsOn, copiedId)
+			}
+		}
+		err = setTaskDependencies(ex, taskIds[task.ID], dependsOn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, labelId := range nextJob.Labels {
+		_, err = assignJobLabel(ex, *jobId, labelId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return jobId, nil
+}
+
+// UndoJobCompletion
+// Takes JobCompletion, vehicle the odometer was read on, whether to restore the vehicle odometer, whether to trash the next occurrence and acting user id as args, restores the prior status and removes the reading and completion in a single transaction, nothing is written if any fail
+func UndoJobCompletion(completion models.JobCompletion, vehicleId *int64, restoreOdometer bool, trashNextJob bool, userId *int64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	defer tx.Rollback()
+	// restore prior status, clears completed_at
+	err = updateJobStatus(tx, completion.Job, completion.Prior_status, 0)
+	if err != nil {
+		return err
+	}
+	doneStatus := "done"
+	_, err = createJobStatusHistory(tx, completion.Job, &doneStatus, completion.Prior_status, userId, nil)
+	if err != nil {
+		return err
+	}
+	if completion.Odometer_reading != nil {
+		err = deleteOdometerReading(tx, *completion.Odometer_reading)
+		if err != nil {
+			return err
+		}
+	}
+	if vehicleId != nil && restoreOdometer {
+		err = updateVehicleOdometer(tx, *vehicleId, completion.Prior_odometer)
+		if err != nil {
+			return err
+		}
+	}
+	if completion.Next_job != nil && trashNextJob {
+		err = trashJob(tx, *completion.Next_job, nil, trashTime())
+		if err != nil {
+			return err
+		}
+	}
+	err = deleteJobCompletion(tx, completion.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Odometer Queries
+
+// CreateOdometerReading
+// Takes vehicle id, reading, source, job id and user id, creates in db, returns id
+func CreateOdometerReading(vehicleId int64, newReading models.NewOdometerReading, source string, jobId *int64, userId *int64) (*int64, error) {
+	return createOdometerReading(DB, vehicleId, newReading, source, jobId, userId)
+}
+
+// createOdometerReading
+// Runs CreateOdometerReading against db or transaction
+func createOdometerReading(ex execer, vehicleId int64, newReading models.NewOdometerReading, source string, jobId *int64, userId *int64) (*int64, error) {
+	// default recorded time to now
+	recordedAt := time.Now().UTC()
+	if newReading.Recorded_at != nil {
+		recordedAt = *newReading.Recorded_at
+	}
+	// insert into db, return any errors
+	res, err := ex.Exec("INSERT INTO odometer_reading(Vehicle, Odometer, Source, Job, User, Recorded_at) VALUES (?,?,?,?,?,?)",
+		vehicleId,
+		newReading.Odometer,
+		source,
+		jobId,
+		userId,
+		recordedAt,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// get inserted readings id
+	readingId, err := res.LastInsertId()
+	return &readingId, err
+}
+
+// ListOdometerReadings
+// Takes vehicle id, returns its odometer readings newest first
+func ListOdometerReadings(vehicleId int64) ([]*models.OdometerReading, error) {
+	rows, err := DB.Query("SELECT id, vehicle, odometer, source, job, user, recorded_at, created_at FROM odometer_reading WHERE vehicle=? ORDER BY recorded_at DESC, id DESC", vehicleId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// create list of OdometerReading
+	readings := make([]*models.OdometerReading, 0)
+	// loop through returned rows
+	for rows.Next() {
+		// attribute to OdometerReading
+		reading := models.OdometerReading{}
+		err := rows.Scan(
+			&reading.ID,
+			&reading.Vehicle,
+			&reading.Odometer,
+			&reading.Source,
+			&reading.Job,
+			&reading.User,
+			&reading.Recorded_at,
+			&reading.Created_at,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		// append reading to list of OdometerReading
+		readings = append(readings, &reading)
+	}
+	return readings, nil
+}
+
+// DeleteOdometerReading
+// Takes reading id, deletes it from odometer_reading table
+func DeleteOdometerReading(readingId int64) error {
+	return deleteOdometerReading(DB, readingId)
+}
+
+// deleteOdometerReading
+// Runs DeleteOdometerReading against db or transaction
+func deleteOdometerReading(ex execer, readingId int64) error {
+	_, err := ex.Exec("DELETE FROM odometer_reading WHERE id=?", readingId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteOdometerReadings
+// Takes vehicle id, deletes all of its odometer readings
+func DeleteOdometerReadings(vehicleId int64) error {
+	_, err := DB.Exec("DELETE FROM odometer_reading WHERE vehicle=?", vehicleId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// UpdateVehicleOdometer
+// Takes vehicle id and odometer, updates current odometer of vehicle in db
+func UpdateVehicleOdometer(vehicleId int64, odometer *int64) error {
+	return updateVehicleOdometer(DB, vehicleId, odometer)
+}
+
+// updateVehicleOdometer
+// Runs UpdateVehicleOdometer against db or transaction
+func updateVehicleOdometer(ex execer, vehicleId int64, odometer *int64) error {
+	_, err := ex.Exec("UPDATE vehicle SET odometer=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now') WHERE id=?", odometer, vehicleId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// Fuel Queries
+
+// ListFuelLogs
+// Takes vehicle id and optional Page, returns its fuel logs newest first
+func ListFuelLogs(vehicleId int64, page *models.Page) ([]*models.FuelLog, error) {
+	var wheres []string
+	var args []any
+	orderBy := "fuel_log.filled_at DESC"
+	q := "SELECT id, vehicle, user, filled_at, odometer, volume, cost, is_full, station, notes, created_at FROM fuel_log"
+	// add where for vehicle id
+	wheres = append(wheres, "fuel_log.vehicle=?")
+	args = append(args, vehicleId)
+	// check cursor and count all matching rows if paginating
+	if page != nil {
+		err := pageStart(page, orderBy, QueryBuilder(q, nil, &wheres, nil, nil, nil, nil), args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	query := QueryBuilder(q, nil, &wheres, nil, nil, &orderBy, page)
+	rows, err := DB.Query(query, append(args, pageArgs(page)...)...)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// create list of FuelLog
+	fuelLogs := make([]*models.FuelLog, 0)
+	// loop through returned rows
+	for rows.Next() {
+		// attribute to FuelLog
+		fuelLog := models.FuelLog{}
+		err := rows.Scan(
+			&fuelLog.ID,
+			&fuelLog.Vehicle,
+			&fuelLog.User,
+			&fuelLog.Filled_at,
+			&fuelLog.Odometer,
+			&fuelLog.Volume,
+			&fuelLog.Cost,
+			&fuelLog.Is_full,
+			&fuelLog.Station,
+			&fuelLog.Notes,
+			&fuelLog.Created_at,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		// append FuelLog to list of FuelLog
+		fuelLogs = append(fuelLogs, &fuelLog)
+	}
+	// drop extra row fetched to detect another page, resume after last row
+	if page != nil && len(fuelLogs) > page.Limit {
+		fuelLogs = fuelLogs[:page.Limit]
+		err = pageNext(page, "fuel_log", fuelLogs[len(fuelLogs)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fuelLogs, nil
+}
+
+// createFuelLog
+// Takes execer, vehicle id, NewFuelLog and user id, creates fuel log with an odometer reading if it has one, returns id
+func createFuelLog(ex execer, vehicleId int64, newFuelLog models.NewFuelLog, userId *int64) (*int64, error) {
+	// default filled time to now and fill ups to full
+	filledAt := time.Now().UTC()
+	if newFuelLog.Filled_at != nil {
+		filledAt = *newFuelLog.Filled_at
+	}
+	isFull := 1
+	if newFuelLog.Is_full != nil {
+		isFull = *newFuelLog.Is_full
+	}
+	// insert into db, return any errors
+	res, err := ex.Exec("INSERT INTO fuel_log(Vehicle, User, Filled_at, Odometer, Volume, Cost, Is_full, Station, Notes) VALUES (?,?,?,?,?,?,?,?,?)",
+		vehicleId,
+		userId,
+		filledAt,
+		newFuelLog.Odometer,
+		newFuelLog.Volume,
+		newFuelLog.Cost,
+		isFull,
+		newFuelLog.Station,
+		newFuelLog.Notes,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// get inserted fuel logs id
+	fuelLogId, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	// record odometer at fill up on the vehicle
+	if newFuelLog.Odometer != nil {
+		_, err = createOdometerReading(ex, vehicleId, models.NewOdometerReading{Odometer: *newFuelLog.Odometer, Recorded_at: &filledAt}, "fuel", nil, userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &fuelLogId, nil
+}
+
+// ImportFuelLogs
+// Takes vehicle id, list of NewFuelLog, user id and odometer to move vehicle to (nil to leave it), creates fuel logs with their odometer readings in a single transaction, nothing is created if any fail, returns ids
+func ImportFuelLogs(vehicleId int64, newFuelLogs []models.NewFuelLog, userId *int64, odometer *int64) ([]int64, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	ids := make([]int64, 0, len(newFuelLogs))
+	for _, newFuelLog := range newFuelLogs {
+		fuelLogId, err := createFuelLog(tx, vehicleId, newFuelLog, userId)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, *fuelLogId)
+	}
+	if odometer != nil {
+		err = updateVehicleOdometer(tx, vehicleId, odometer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, tx.Commit()
+}
+
+// Import Queries
+
+// ImportVehicles
+// Takes list of NewVehicle, creates them in a single transaction, nothing is created if any fail, returns ids
+func ImportVehicles(newVehicles []models.NewVehicle) ([]int64, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	ids := make([]int64, 0, len(newVehicles))
+	for _, newVehicle := range newVehicles {
+		vehicleId, err := createVehicle(tx, newVehicle)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, *vehicleId)
+	}
+	return ids, tx.Commit()
+}
+
+// ImportJobs
+// Takes list of NewJob, creates them with their initial status history in a single transaction, nothing is created if any fail, returns ids
+func ImportJobs(newJobs []models.NewJob) ([]int64, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	ids := make([]int64, 0, len(newJobs))
+	for _, newJob := range newJobs {
+		jobId, err := createJob(tx, newJob)
+		if err != nil {
+			return nil, err
+		}
+		_, err = createJobStatusHistory(tx, *jobId, nil, *newJob.Status, newJob.User, nil)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, *jobId)
+	}
+	return ids, tx.Commit()
+}
+
+// ImportTasks
+// Takes list of NewTask and job id, creates them in a single transaction, nothing is created if any fail, returns ids
+func ImportTasks(newTasks []models.NewTask, jobId int64) ([]int64, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	ids := make([]int64, 0, len(newTasks))
+	for _, newTask := range newTasks {
+		taskId, err := createTask(tx, newTask, jobId)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, *taskId)
+	}
+	return ids, tx.Commit()
+}
+
+// ImportTracker
+// Takes TrackerImport, creates its vehicles, a completed job per service record and a fuel log per fuel record, then moves vehicle odometers forward to their latest reading in a single transaction, nothing is created if any fail, returns TrackerImportedIds
+func ImportTracker(trackerImport models.TrackerImport) (*models.TrackerImportedIds, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	userId := trackerImport.User
+	ids := models.TrackerImportedIds{
+		Vehicles:  make([]int64, 0, len(trackerImport.Vehicles)),
+		Jobs:      make([]int64, 0),
+		Fuel_logs: make([]int64, 0),
+	}
+	for _, newVehicle := range trackerImport.Vehicles {
+		vehicleId, err := createVehicle(tx, newVehicle)
+		if err != nil {
+			return nil, err
+		}
+		ids.Vehicles = append(ids.Vehicles, *vehicleId)
+	}
+	// highest odometer seen per vehicle
+	odometers := make(map[int64]int64)
+	for _, importRecord := range trackerImport.Records {
+		record := importRecord.Record
+		vehicleId := importRecord.Vehicle
+		if vehicleId == 0 {
+			vehicleId = ids.Vehicles[importRecord.New_vehicle]
+		}
+		if record.Odometer != nil && *record.Odometer > odometers[vehicleId] {
+			odometers[vehicleId] = *record.Odometer
+		}
+		if importRecord.Fuel_log != nil {
+			fuelLogId, err := createFuelLog(tx, vehicleId, *importRecord.Fuel_log, &userId)
+			if err != nil {
+				return nil, err
+			}
+			ids.Fuel_logs = append(ids.Fuel_logs, *fuelLogId)
+			continue
+		}
+		jobId, err := importTrackerService(tx, record, vehicleId, userId)
+		if err != nil {
+			return nil, err
+		}
+		ids.Jobs = append(ids.Jobs, *jobId)
+	}
+	// only move odometers forward, records may be older than the vehicle
+	for vehicleId, odometer := range odometers {
+		_, err = tx.Exec("UPDATE vehicle SET odometer=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now') WHERE id=? AND (odometer IS NULL OR odometer < ?)", odometer, vehicleId, odometer)
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return nil, err
+		}
+	}
+	return &ids, tx.Commit()
+}
+
+// importTrackerService
+// Takes execer, service TrackerRecord, vehicle id and user id, creates a job done on the record date with completed tasks, status history, odometer reading and completion record, returns job id
+func importTrackerService(ex execer, record models.TrackerRecord, vehicleId int64, userId int64) (*int64, error) {
+	plannedStatus := "planned"
+	defaultBool := 0
+	jobId, err := createJob(ex, models.NewJob{
+		Name:        record.Name,
+		Is_template: &defaultBool,
+		Status:      &plannedStatus,
+		Vehicle:     &vehicleId,
+		User:        &userId,
+		Repeats:     &defaultBool,
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = createJobStatusHistory(ex, *jobId, nil, plannedStatus, &userId, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range record.Tasks {
+		taskId, err := createTask(ex, models.NewTask{Name: name}, *jobId)
+		if err != nil {
+			return nil, err
+		}
+		err = updateTaskStatus(ex, jobId, *taskId, 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = completeJob(ex, *jobId, record.Date)
+	if err != nil {
+		return nil, err
+	}
+	note := "Imported"
+	_, err = createJobStatusHistory(ex, *jobId, &plannedStatus, "done", &userId, &note)
+	if err != nil {
+		return nil, err
+	}
+	completion := models.JobCompletion{
+		Job:          *jobId,
+		User:         &userId,
+		Odometer:     record.Odometer,
+		Performed_by: "self",
+		Shop:         record.Shop,
+		Notes:        record.Notes,
+		Cost:         record.Cost,
+		Completed_at: record.Date,
+		Prior_status: plannedStatus,
+	}
+	if record.Shop != nil {
+		completion.Performed_by = "shop"
+	}
+	if record.Odometer != nil {
+		completion.Odometer_reading, err = createOdometerReading(ex, vehicleId, models.NewOdometerReading{Odometer: *record.Odometer, Recorded_at: &record.Date}, "import", jobId, &userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = createJobCompletion(ex, completion)
+	if err != nil {
+		return nil, err
+	}
+	return jobId, nil
+}
+
+// ImportAccount
+// Takes AccountImport, recreates its vehicles, labels, jobs, tasks and alerts under the user with references mapped to the new ids in a single transaction, nothing is created if any fail, returns ImportedIds
+func ImportAccount(accountImport models.AccountImport) (*models.ImportedIds, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	export := accountImport.Export
+	userId := accountImport.User
+	ids := models.ImportedIds{
+		Vehicles: make(map[int64]int64),
+		Labels:   make(map[int64]int64),
+		Jobs:     make(map[int64]int64),
+		Tasks:    make(map[int64]int64),
+		Alerts:   make(map[int64]int64),
+	}
+	for _, label := range export.Labels {
+		labelId, err := createLabel(tx, models.NewLabel{Name: label.Name, Color: label.Color, User: &userId})
+		if err != nil {
+			return nil, err
+		}
+		ids.Labels[label.ID] = *labelId
+	}
+	// labels merged into existing labels are assigned, not created
+	labelIds := make(map[int64]int64)
+	for exportId, labelId := range accountImport.Merged_labels {
+		labelIds[exportId] = labelId
+	}
+	for exportId, labelId := range ids.Labels {
+		labelIds[exportId] = labelId
+	}
+	for _, vehicle := range export.Vehicles {
+		var odometer *int
+		if vehicle.Odometer != nil {
+			o := int(*vehicle.Odometer)
+			odometer = &o
+		}
+		vehicleId, err := createVehicle(tx, models.NewVehicle{
+			Name:        vehicle.Name,
+			Description: vehicle.Description,
+			Type:        vehicle.Type,
+			Is_metric:   vehicle.Is_metric,
+			Vin:         vehicle.Vin,
+			Year:        vehicle.Year,
+			Make:        vehicle.Make,
+			Model:       vehicle.Model,
+			Trim:        vehicle.Trim,
+			Odometer:    odometer,
+			User:        &userId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids.Vehicles[vehicle.ID] = *vehicleId
+		for _, label := range vehicle.Labels {
+			_, err = assignLinkedLabel(tx, "vehicle", *vehicleId, labelIds[label.ID])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	// jobs come in id order, so origin jobs exist before jobs created from them
+	plannedStatus := "planned"
+	for _, job := range export.Jobs {
+		newJob := models.NewJob{
+			Name:               job.Name,
+			Description:        job.Description,
+			Instructions:       job.Instructions,
+			Is_template:        &job.Is_template,
+			Status:             &plannedStatus,
+			User:               &userId,
+			Repeats:            &job.Repeats,
+			Odo_interval:       job.Odo_interval,
+			Time_interval:      job.Time_interval,
+			Time_interval_unit: job.Time_interval_unit,
+			Due_date:           job.Due_date,
+			Due_odometer:       job.Due_odometer,
+		}
+		if job.Vehicle != nil {
+			vehicleId := ids.Vehicles[*job.Vehicle]
+			newJob.Vehicle = &vehicleId
+		}
+		// origin jobs outside of the export are dropped
+		if job.Origin_job != nil {
+			if originId, ok := ids.Jobs[*job.Origin_job]; ok {
+				newJob.Origin_job = &originId
+			}
+		}
+		jobId, err := createJob(tx, newJob)
+		if err != nil {
+			return nil, err
+		}
+		ids.Jobs[job.ID] = *jobId
+		_, err = createJobStatusHistory(tx, *jobId, nil, plannedStatus, &userId, nil)
+		if err != nil {
+			return nil, err
+		}
+		// restore status as exported, bypassing the workflow
+		if job.Status == "done" && job.Completed_at != nil {
+			err = completeJob(tx, *jobId, *job.Completed_at)
+		} else if len(job.Status) > 0 && job.Status != plannedStatus {
+			isComplete := 0
+			if job.Status == "done" {
+				isComplete = 1
+			}
+			err = updateJobStatus(tx, *jobId, job.Status, isComplete)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range job.Labels {
+			_, err = assignJobLabel(tx, *jobId, labelIds[label.ID])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, task := range export.Tasks {
+		jobId := ids.Jobs[*task.Job]
+		taskId, err := createTask(tx, models.NewTask{
+			Name:              task.Name,
+			Description:       task.Description,
+			Part_name:         task.Part_name,
+			Part_link:         task.Part_link,
+			Due_date:          task.Due_date,
+			Estimated_minutes: task.Estimated_minutes,
+		}, jobId)
+		if err != nil {
+			return nil, err
+		}
+		ids.Tasks[task.ID] = *taskId
+		if task.Is_complete == 1 {
+			err = updateTaskStatus(tx, &jobId, *taskId, 1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, label := range task.Labels {
+			_, err = assignLinkedLabel(tx, "task", *taskId, labelIds[label.ID])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	// link sub-tasks and prerequisites once all tasks exist, links to tasks outside of the export are dropped
+	for _, task := range export.Tasks {
+		if task.Parent != nil {
+			if parentId, ok := ids.Tasks[*task.Parent]; ok {
+				_, err = tx.Exec("UPDATE task SET parent=? WHERE id=?", parentId, ids.Tasks[task.ID])
+				if err != nil {
+					log.Printf("DB Execution Error: %s", err)
+					return nil, err
+				}
+			}
+		}
+		var dependsOn []int64
+		for _, dependencyId := range task.Depends_on {
+			if copiedId, ok := ids.Tasks[dependencyId]; ok {
+				dependsOn = append(dependsOn, copiedId)
+			}
+		}
+		err = setTaskDependencies(tx, ids.Tasks[task.ID], dependsOn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, alert := range export.Alerts {
+		newAlert := models.NewAlert{
+			Name:        alert.Name,
+			Description: alert.Description,
+			Type:        alert.Type,
+			User:        &userId,
+			Alert_at:    alert.Alert_at,
+		}
+		if alert.Vehicle != nil {
+			vehicleId := ids.Vehicles[*alert.Vehicle]
+			newAlert.Vehicle = &vehicleId
+		}
+		if alert.Job != nil {
+			jobId := ids.Jobs[*alert.Job]
+			newAlert.Job = &jobId
+		}
+		if alert.Task != nil {
+			taskId := ids.Tasks[*alert.Task]
+			newAlert.Task = &taskId
+		}
+		alertId, err := createAlert(tx, newAlert)
+		if err != nil {
+			return nil, err
+		}
+		ids.Alerts[alert.ID] = *alertId
+		if alert.Is_read != nil && *alert.Is_read == 1 {
+			err = updateAlertStatus(tx, *alertId, userId, 1)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &ids, tx.Commit()
+}
+
+// GetCalendarToken
+// Takes token as arg, returns CalendarToken it belongs to
+func GetCalendarToken(token string) (*models.CalendarToken, error) {
+	var calendarToken models.CalendarToken
+	// query db, return any errors
+	err := DB.QueryRow("SELECT id, user, token, created_at FROM calendar_token WHERE token=?", token).Scan(
+		&calendarToken.ID,
+		&calendarToken.User,
+		&calendarToken.Token,
+		&calendarToken.Created_at,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	return &calendarToken, nil
+}
+
+// SetCalendarToken
+// Takes user id and token as args, creates or replaces users calendar token
+func SetCalendarToken(userId int64, token string) error {
+	_, err := DB.Exec("INSERT INTO calendar_token(User, Token) VALUES (?,?) ON CONFLICT(user) DO UPDATE SET token=excluded.token, created_at=CURRENT_TIMESTAMP", userId, token)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteCalendarToken
+// Takes user id as arg, deletes users calendar token
+func DeleteCalendarToken(userId int64) error {
+	res, err := DB.Exec("DELETE FROM calendar_token WHERE user=?", userId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	if rows == 0 {
+		return errors.New("No calendar token found")
+	}
+	return nil
+}
+
+// Search Queries
+
+// Search
+// Takes FTS5 match expression, optional owner id, types and limit as args, returns ranked results with matches wrapped in \x02 and \x03
+func Search(match string, userId *int64, types []string, limit int) ([]*models.SearchResult, error) {
+	var selects []string
+	var args []any
+	for _, t := range searchTables {
+		if !searchTypeWanted(t.Type, types) {
+			continue
+		}
+		fts := t.Table + "_fts"
+		// title column weighs most, bm25 is negative with best matches lowest
+		weights := "10.0" + strings.Repeat(", 1.0", len(t.Columns)-1)
+		q := fmt.Sprintf("SELECT '%s', %s.id, %s, COALESCE(highlight(%s, 0, char(2), char(3)), ''), COALESCE(snippet(%s, -1, char(2), char(3), '…', 16), ''), -bm25(%s, %s) FROM %s JOIN %s ON %s.id = %s.rowid",
+			t.Type, t.Table, t.Parent, fts, fts, fts, weights, fts, t.Table, t.Table, fts)
+		wheres := []string{fts + " MATCH ?"}
+		args = append(args, match)
+		if t.Trashable {
+			wheres = append(wheres, t.Table+".deleted_at IS NULL")
+		}
+		if userId != nil {
+			wheres = append(wheres, searchOwnerWhere(t))
+			args = append(args, *userId)
+		}
+		selects = append(selects, QueryBuilder(q, &t.Joins, &wheres, nil, nil, nil, nil))
+	}
+	return searchQuery(selects, args, "6 DESC", limit)
+}
+
+// SearchLike
+// Takes search terms, optional owner id, types and limit as args, returns unranked results with every term in one of their columns, used without FTS5
+func SearchLike(terms []string, userId *int64, types []string, limit int) ([]*models.SearchResult, error) {
+	var selects []string
+	var args []any
+	for _, t := range searchTables {
+		if !searchTypeWanted(t.Type, types) {
+			continue
+		}
+		// snippet is the remaining columns, highlighting is left to the caller
+		body := "''"
+		for _, col := range t.Columns[1:] {
+			body += " || COALESCE(" + t.Table + "." + col + " || ' ', '')"
+		}
+		q := fmt.Sprintf("SELECT '%s', %s.id, %s, COALESCE(%s.%s, ''), %s, 0 FROM %s", t.Type, t.Table, t.Parent, t.Table, t.Columns[0], body, t.Table)
+		var wheres []string
+		for _, term := range terms {
+			var likes []string
+			for _, col := range t.Columns {
+				likes = append(likes, t.Table+"."+col+" LIKE ?")
+				args = append(args, "%"+term+"%")
+			}
+			wheres = append(wheres, "("+strings.Join(likes, " OR ")+")")
+		}
+		if t.Trashable {
+			wheres = append(wheres, t.Table+".deleted_at IS NULL")
+		}
+		if userId != nil {
+			wheres = append(wheres, searchOwnerWhere(t))
+			args = append(args, *userId)
+		}
+		selects = append(selects, QueryBuilder(q, &t.Joins, &wheres, nil, nil, nil, nil))
+	}
+	return searchQuery(selects, args, "4", limit)
+}
+
+// searchQuery runs the per table selects as one query, returns results
+func searchQuery(selects []string, args []any, orderBy string, limit int) ([]*models.SearchResult, error) {
+	// create list of SearchResult
+	results := make([]*models.SearchResult, 0)
+	if len(selects) == 0 {
+		return results, nil
+	}
+	query := strings.Join(selects, " UNION ALL ") + " ORDER BY " + orderBy + " LIMIT ?"
+	args = append(args, limit)
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// loop through returned rows
+	for rows.Next() {
+		// attribute to SearchResult
+		result := models.SearchResult{}
+		err := rows.Scan(
+			&result.Type,
+			&result.ID,
+			&result.Job,
+			&result.Title,
+			&result.Snippet,
+			&result.Rank,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		// append result to list of SearchResult
+		results = append(results, &result)
+	}
+	return results, nil
+}
+
+// searchOwnerWhere restricts a search table to rows owned by a user, labels without an owner are shared
+func searchOwnerWhere(t searchTable) string {
+	if t.Type == "label" {
+		return "(" + t.Owner + "=? OR " + t.Owner + " IS NULL)"
+	}
+	return t.Owner + "=?"
+}
+
+// searchTypeWanted returns whether a type is in the requested types, all types are wanted if none are requested
+func searchTypeWanted(typeStr string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == typeStr {
+			return true
+		}
+	}
+	return false
+}
+
+// revisionWhere
+// Takes wheres, args and the updated_at an edit expects, nil for none, adds a compare against the rows updated_at so the edit only applies if nothing else wrote since, returns args
+func revisionWhere(wheres *[]string, args []any, revision *time.Time) []any {
+	if revision == nil {
+		return args
+	}
+	// compare at millisecond precision, rows written with CURRENT_TIMESTAMP have .000
+	*wheres = append(*wheres, "strftime('%Y-%m-%d %H:%M:%f', updated_at)=?")
+	return append(args, revision.UTC().Format("2006-01-02 15:04:05.000"))
+}
+
+// noRowsUpdated
+// Returns the error of an edit that updated nothing, ErrRevisionChanged if it expected a revision
+func noRowsUpdated(revision *time.Time) error {
+	if revision != nil {
+		return repository.ErrRevisionChanged
+	}
+	return errors.New("No rows updated")
+}
+
+// Bulk Queries
+
+// BulkUpdateTaskStatus
+// Takes task ids, complete status and atomic flag, updates them in a single transaction, returns an error or nil per id and whether it was committed
+func BulkUpdateTaskStatus(taskIds []int64, status int, atomic bool) ([]error, bool, error) {
+	return runBulk(taskIds, atomic, func(ex execer, taskId int64) error {
+		return updateTaskStatus(ex, nil, taskId, status)
+	})
+}
+
+// BulkUpdateAlertStatus
+// Takes map of alert id to its user, read status and atomic flag, updates them in a single transaction, returns an error or nil per id and whether it was committed
+func BulkUpdateAlertStatus(alertIds []int64, alertUsers map[int64]int64, status int, atomic bool) ([]error, bool, error) {
+	return runBulk(alertIds, atomic, func(ex execer, alertId int64) error {
+		return updateAlertStatus(ex, alertId, alertUsers[alertId], status)
+	})
+}
+
+// BulkDeleteJobs
+// Takes job ids and atomic flag, moves them to the trash with their tasks and alerts in a single transaction, returns an error or nil per id and whether it was committed
+func BulkDeleteJobs(jobIds []int64, atomic bool) ([]error, bool, error) {
+	deletedAt := trashTime()
+	return runBulk(jobIds, atomic, func(ex execer, jobId int64) error {
+		return trashJob(ex, jobId, nil, deletedAt)
+	})
+}
+
+// BulkAssignJobLabel
+// Takes job ids, label id, assign flag and atomic flag, assigns or unassigns label in a single transaction, already (un)assigned jobs are left as is, returns an error or nil per id and whether it was committed
+func BulkAssignJobLabel(jobIds []int64, labelId int64, assign int, atomic bool) ([]error, bool, error) {
+	return runBulk(jobIds, atomic, func(ex execer, jobId int64) error {
+		q := "DELETE FROM job_label WHERE job=? AND label=?"
+		if assign == 1 {
+			q = "INSERT INTO job_label(Job, Label) SELECT ?1, ?2 WHERE NOT EXISTS (SELECT 1 FROM job_label WHERE job=?1 AND label=?2)"
+		}
+		_, err := ex.Exec(q, jobId, labelId)
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+		}
+		return err
+	})
+}
+
+// runBulk
+// Runs op for each id in a single transaction, each in its own savepoint so a failure only undoes that item, rolls everything back if atomic and any item failed
+func runBulk(ids []int64, atomic bool, op func(ex execer, id int64) error) ([]error, bool, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, false, err
+	}
+	defer tx.Rollback()
+	errs := make([]error, len(ids))
+	failed := false
+	for i, id := range ids {
+		_, err = tx.Exec("SAVEPOINT bulk_item")
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return nil, false, err
+		}
+		errs[i] = op(tx, id)
+		if errs[i] != nil {
+			failed = true
+			_, err = tx.Exec("ROLLBACK TO bulk_item")
+			if err != nil {
+				log.Printf("DB Execution Error: %s", err)
+				return nil, false, err
+			}
+		}
+		_, err = tx.Exec("RELEASE bulk_item")
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return nil, false, err
+		}
+	}
+	if atomic && failed {
+		return errs, false, nil
+	}
+	return errs, true, tx.Commit()
+}
+
+// Audit Queries
+
+// CreateAuditEvent
+// Takes AuditEvent, appends it to the audit log, returns id
+func CreateAuditEvent(event models.AuditEvent) (*int64, error) {
+	var diff *string
+	if len(event.Diff) > 0 {
+		diffBytes, err := json.Marshal(event.Diff)
+		if err != nil {
+			return nil, err
+		}
+		diffStr := string(diffBytes)
+		diff = &diffStr
+	}
+	res, err := DB.Exec("INSERT INTO audit_event(Actor, Action, Entity, Entity_id, Vehicle, Job, Diff) VALUES (?,?,?,?,?,?,?)",
+		event.Actor,
+		event.Action,
+		event.Entity,
+		event.Entity_id,
+		event.Vehicle,
+		event.Job,
+		diff,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	eventId, err := res.LastInsertId()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	return &eventId, nil
+}
+
+// ListAuditEvents
+// Take filters and optional Page as args, return AuditEvent list newest first, only one page of it if Page provided
+func ListAuditEvents(actor *string, entity *string, action *string, vehicleId *string, jobId *string, page *models.Page) ([]*models.AuditEvent, error) {
+	var wheres []string
+	var args []any
+	orderBy := "audit_event.id DESC"
+	q := "SELECT id, actor, action, entity, entity_id, vehicle, job, diff, created_at FROM audit_event"
+	// add a where for each filter provided
+	filters := []struct {
+		col   string
+		value *string
+	}{
+		{"audit_event.actor", actor},
+		{"audit_event.entity", entity},
+		{"audit_event.action", action},
+		{"audit_event.vehicle", vehicleId},
+		{"audit_event.job", jobId},
+	}
+	for _, filter := range filters {
+		if filter.value != nil && len(*filter.value) > 0 {
+			wheres = append(wheres, filter.col+"=?")
+			args = append(args, *filter.value)
+		}
+	}
+	// check cursor and count all matching rows if paginating
+	if page != nil {
+		err := pageStart(page, orderBy, QueryBuilder(q, nil, &wheres, nil, nil, nil, nil), args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	query := QueryBuilder(q, nil, &wheres, nil, nil, &orderBy, page)
+	rows, err := DB.Query(query, append(args, pageArgs(page)...)...)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	events := make([]*models.AuditEvent, 0)
+	for rows.Next() {
+		event := models.AuditEvent{}
+		var diff *string
+		err := rows.Scan(
+			&event.ID,
+			&event.Actor,
+			&event.Action,
+			&event.Entity,
+			&event.Entity_id,
+			&event.Vehicle,
+			&event.Job,
+			&diff,
+			&event.Created_at,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		if diff != nil {
+			err = json.Unmarshal([]byte(*diff), &event.Diff)
+			if err != nil {
+				log.Printf("Error decoding diff of audit event ID %d: %s", event.ID, err)
+			}
+		}
+		events = append(events, &event)
+	}
+	// drop extra row fetched to detect another page, resume after last row
+	if page != nil && len(events) > page.Limit {
+		events = events[:page.Limit]
+		err = pageNext(page, "audit_event", events[len(events)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// DeleteAuditEvents
+// Takes time, deletes audit events created before it, returns number deleted
+func DeleteAuditEvents(before time.Time) (int64, error) {
+	res, err := DB.Exec("DELETE FROM audit_event WHERE created_at < ?", before.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Trash Queries
+
+// type trashStmt
+// Statement of a trash or restore transaction with its args
+type trashStmt struct {
+	Query string
+	Args  []any
+}
+
+// trashTime
+// Returns the current time as stored in deleted_at, a job or vehicle and its children share one so they can be restored together
+func trashTime() string {
+	return time.Now().UTC().Format("2006-01-02 15:04:05.000")
+}
+
+// ownerArgs
+// Appends an owner condition on the next numbered param to query if userId provided, returns query and args
+func ownerArgs(query string, userId *int64, args ...any) (string, []any) {
+	if userId != nil {
+		query += " AND user=?" + strconv.Itoa(len(args)+1)
+		args = append(args, *userId)
+	}
+	return query, args
+}
+
+// execTrash
+// Runs stmts in order in a single transaction, the last one moves the job, vehicle or user itself and errors with noRows if it affects no rows
+func execTrash(noRows string, stmts []trashStmt) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	defer tx.Rollback()
+	for i, stmt := range stmts {
+		res, err := tx.Exec(stmt.Query, stmt.Args...)
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return err
+		}
+		if i < len(stmts)-1 {
+			continue
+		}
+		// retrieve rows affected count, error if 0
+		rows, err := res.RowsAffected()
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return err
+		}
+		if rows == 0 {
+			log.Printf(noRows)
+			return errors.New(noRows)
+		}
+	}
+	return tx.Commit()
+}
+
+// TrashJob
+// Takes job id and optional owner id, moves the job to the trash with its tasks and alerts
+func TrashJob(jobId int64, userId *int64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	defer tx.Rollback()
+	err = trashJob(tx, jobId, userId, trashTime())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// trashJob
+// Runs TrashJob against a transaction with the given deletion time
+func trashJob(ex execer, jobId int64, userId *int64, deletedAt string) error {
+	q, args := ownerArgs("UPDATE job SET deleted_at=?1 WHERE id=?2 AND deleted_at IS NULL", userId, deletedAt, jobId)
+	res, err := ex.Exec(q, args...)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count, error if 0
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	if rows == 0 {
+		log.Printf("No rows deleted")
+		return errors.New("No rows deleted")
+	}
+	for _, q := range []string{
+		"UPDATE task SET deleted_at=?1 WHERE job=?2 AND deleted_at IS NULL",
+		"UPDATE alert SET deleted_at=?1 WHERE (job=?2 OR task IN (SELECT id FROM task WHERE job=?2)) AND deleted_at IS NULL",
+	} {
+		_, err = ex.Exec(q, deletedAt, jobId)
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// TrashVehicle
+// Takes vehicle id and optional owner id, moves the vehicle to the trash with its jobs, their tasks, its alerts and documents
+func TrashVehicle(vehicleId int64, userId *int64) error {
+	deletedAt := trashTime()
+	// children are matched through jobs that are not yet trashed, jobs trashed before keep their own deletion time
+	vehicleJobs := "SELECT id FROM job WHERE vehicle=?2 AND deleted_at IS NULL"
+	q, args := ownerArgs("UPDATE vehicle SET deleted_at=?1 WHERE id=?2 AND deleted_at IS NULL", userId, deletedAt, vehicleId)
+	return execTrash("No rows deleted", []trashStmt{
+		{Query: "UPDATE task SET deleted_at=?1 WHERE job IN (" + vehicleJobs + ") AND deleted_at IS NULL", Args: []any{deletedAt, vehicleId}},
+		{Query: "UPDATE alert SET deleted_at=?1 WHERE (vehicle=?2 OR job IN (" + vehicleJobs + ")) AND deleted_at IS NULL", Args: []any{deletedAt, vehicleId}},
+		{Query: "UPDATE vehicle_document SET deleted_at=?1 WHERE vehicle=?2 AND deleted_at IS NULL", Args: []any{deletedAt, vehicleId}},
+		{Query: "UPDATE job SET deleted_at=?1 WHERE vehicle=?2 AND deleted_at IS NULL", Args: []any{deletedAt, vehicleId}},
+		{Query: q, Args: args},
+	})
+}
+
+// TrashUser
+// Takes username, moves the user to the trash with their vehicles, jobs, tasks, alerts, documents and labels, trashed users can not log in
+func TrashUser(username string) error {
+	deletedAt := trashTime()
+	// children are matched through the user while it is live, rows trashed before keep their own deletion time
+	userId := "(SELECT id FROM user WHERE username=?2 AND deleted_at IS NULL)"
+	userJobs := "SELECT id FROM job WHERE user=" + userId + " AND deleted_at IS NULL"
+	args := []any{deletedAt, username}
+	return execTrash("No rows deleted", []trashStmt{
+		{Query: "UPDATE task SET deleted_at=?1 WHERE job IN (" + userJobs + ") AND deleted_at IS NULL", Args: args},
+		{Query: "UPDATE alert SET deleted_at=?1 WHERE (user=" + userId + " OR job IN (" + userJobs + ")) AND deleted_at IS NULL", Args: args},
+		{Query: "UPDATE vehicle_document SET deleted_at=?1 WHERE user=" + userId + " AND deleted_at IS NULL", Args: args},
+		{Query: "UPDATE job SET deleted_at=?1 WHERE user=" + userId + " AND deleted_at IS NULL", Args: args},
+		{Query: "UPDATE vehicle SET deleted_at=?1 WHERE user=" + userId + " AND deleted_at IS NULL", Args: args},
+		{Query: "UPDATE label SET deleted_at=?1 WHERE user=" + userId + " AND deleted_at IS NULL", Args: args},
+		{Query: "UPDATE user SET deleted_at=?1 WHERE username=?2 AND deleted_at IS NULL", Args: args},
+	})
+}
+
+// RestoreJob
+// Takes job id and optional owner id, restores the job with the tasks and alerts trashed along with it, jobs of a trashed vehicle or user can only be restored with them
+func RestoreJob(jobId int64, userId *int64) error {
+	// children are matched by the jobs deletion time, so go before it is cleared
+	deletedAt := "(SELECT deleted_at FROM job WHERE id=?1)"
+	q, args := ownerArgs("UPDATE job SET deleted_at=NULL WHERE id=?1 AND deleted_at IS NOT NULL AND (vehicle IS NULL OR vehicle NOT IN (SELECT id FROM vehicle WHERE deleted_at IS NOT NULL)) AND user NOT IN (SELECT id FROM user WHERE deleted_at IS NOT NULL)", userId, jobId)
+	return execTrash("No rows updated", []trashStmt{
+		{Query: "UPDATE task SET deleted_at=NULL WHERE job=?1 AND deleted_at=" + deletedAt, Args: []any{jobId}},
+		{Query: "UPDATE alert SET deleted_at=NULL WHERE (job=?1 OR task IN (SELECT id FROM task WHERE job=?1)) AND deleted_at=" + deletedAt, Args: []any{jobId}},
+		{Query: q, Args: args},
+	})
+}
+
+// RestoreVehicle
+// Takes vehicle id and optional owner id, restores the vehicle with the jobs, tasks, alerts and documents trashed along with it, vehicles of a trashed user can only be restored with the user
+func RestoreVehicle(vehicleId int64, userId *int64) error {
+	// children are matched by the vehicles deletion time, so go before it is cleared
+	deletedAt := "(SELECT deleted_at FROM vehicle WHERE id=?1)"
+	vehicleJobs := "SELECT id FROM job WHERE vehicle=?1 AND deleted_at=" + deletedAt
+	q, args := ownerArgs("UPDATE vehicle SET deleted_at=NULL WHERE id=?1 AND deleted_at IS NOT NULL AND user NOT IN (SELECT id FROM user WHERE deleted_at IS NOT NULL)", userId, vehicleId)
+	return execTrash("No rows updated", []trashStmt{
+		{Query: "UPDATE task SET deleted_at=NULL WHERE job IN (" + vehicleJobs + ") AND deleted_at=" + deletedAt, Args: []any{vehicleId}},
+		{Query: "UPDATE alert SET deleted_at=NULL WHERE (vehicle=?1 OR job IN (" + vehicleJobs + ")) AND deleted_at=" + deletedAt, Args: []any{vehicleId}},
+		{Query: "UPDATE vehicle_document SET deleted_at=NULL WHERE vehicle=?1 AND deleted_at=" + deletedAt, Args: []any{vehicleId}},
+		{Query: "UPDATE job SET deleted_at=NULL WHERE vehicle=?1 AND deleted_at=" + deletedAt, Args: []any{vehicleId}},
+		{Query: q, Args: args},
+	})
+}
+
+// RestoreUser
+// Takes user id, restores the user with the vehicles, jobs, tasks, alerts, documents and labels trashed along with it
+func RestoreUser(userId int64) error {
+	// children are matched by the users deletion time, so go before it is cleared
+	deletedAt := "(SELECT deleted_at FROM user WHERE id=?1)"
+	userJobs := "SELECT id FROM job WHERE user=?1 AND deleted_at=" + deletedAt
+	args := []any{userId}
+	return execTrash("No rows updated", []trashStmt{
+		{Query: "UPDATE task SET deleted_at=NULL WHERE job IN (" + userJobs + ") AND deleted_at=" + deletedAt, Args: args},
+		{Query: "UPDATE alert SET deleted_at=NULL WHERE (user=?1 OR job IN (" + userJobs + ")) AND deleted_at=" + deletedAt, Args: args},
+		{Query: "UPDATE vehicle_document SET deleted_at=NULL WHERE user=?1 AND deleted_at=" + deletedAt, Args: args},
+		{Query: "UPDATE job SET deleted_at=NULL WHERE user=?1 AND deleted_at=" + deletedAt, Args: args},
+		{Query: "UPDATE vehicle SET deleted_at=NULL WHERE user=?1 AND deleted_at=" + deletedAt, Args: args},
+		{Query: "UPDATE label SET deleted_at=NULL WHERE user=?1 AND deleted_at=" + deletedAt, Args: args},
+		{Query: "UPDATE user SET deleted_at=NULL WHERE id=?1 AND deleted_at IS NOT NULL", Args: args},
+	})
+}
+
+// ListTrash
+// Takes optional owner id, returns trashed jobs, vehicles and users newest first, jobs and vehicles trashed along with their vehicle or user are left out
+func ListTrash(userId *string) ([]*models.TrashItem, error) {
+	var selects []string
+	var args []any
+	// trashed along with their user
+	withUser := "EXISTS (SELECT 1 FROM user WHERE user.id=%[1]s.user AND user.deleted_at=%[1]s.deleted_at)"
+	// select per type with the column owning it
+	for _, t := range [][2]string{
+		{"SELECT 'job', id, name, user, deleted_at FROM job WHERE deleted_at IS NOT NULL AND NOT EXISTS (SELECT 1 FROM vehicle WHERE vehicle.id=job.vehicle AND vehicle.deleted_at=job.deleted_at) AND NOT " + fmt.Sprintf(withUser, "job"), "user"},
+		{"SELECT 'vehicle', id, COALESCE(name, ''), user, deleted_at FROM vehicle WHERE deleted_at IS NOT NULL AND NOT " + fmt.Sprintf(withUser, "vehicle"), "user"},
+		{"SELECT 'user', id, username, id, deleted_at FROM user WHERE deleted_at IS NOT NULL", "id"},
+	} {
+		q := t[0]
+		// if userId provided, only return the users own trash
+		if userId != nil && len(*userId) > 0 {
+			q += " AND " + t[1] + "=?"
+			args = append(args, *userId)
+		}
+		selects = append(selects, q)
+	}
+	rows, err := DB.Query(strings.Join(selects, " UNION ALL ")+" ORDER BY 5 DESC, 2 DESC", args...)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// create list of TrashItem
+	items := make([]*models.TrashItem, 0)
+	// loop through returned rows
+	for rows.Next() {
+		// attribute to TrashItem
+		item := models.TrashItem{}
+		err := rows.Scan(
+			&item.Type,
+			&item.ID,
+			&item.Name,
+			&item.User,
+			&item.Deleted_at,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		// append TrashItem to list of TrashItem
+		items = append(items, &item)
+	}
+	return items, nil
+}
+
+// PurgeTrash
+// Takes time, permanently deletes everything trashed before it along with the labels, status history, completions, comments, time entries, odometer readings and fuel logs of purged jobs and vehicles and the labels of purged users, returns number of jobs, vehicles and users deleted
+func PurgeTrash(before time.Time) (int64, error) {
+	cutoff := before.UTC().Format("2006-01-02 15:04:05.000")
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+	purgedJobs := "SELECT id FROM job WHERE deleted_at < ?"
+	for _, q := range []string{
+		"DELETE FROM comment_revision WHERE comment IN (SELECT id FROM comment WHERE job IN (" + purgedJobs + "))",
+		"DELETE FROM comment WHERE job IN (" + purgedJobs + ")",
+		"DELETE FROM time_entry WHERE job IN (" + purgedJobs + ")",
+		"DELETE FROM job_label WHERE job IN (" + purgedJobs + ") OR label IN (SELECT id FROM label WHERE deleted_at < ?1)",
+		"DELETE FROM job_status_history WHERE job IN (" + purgedJobs + ")",
+		"DELETE FROM job_completion WHERE job IN (" + purgedJobs + ")",
+		"DELETE FROM odometer_reading WHERE vehicle IN (SELECT id FROM vehicle WHERE deleted_at < ?)",
+		"DELETE FROM fuel_log WHERE vehicle IN (SELECT id FROM vehicle WHERE deleted_at < ?)",
+		"DELETE FROM task_label WHERE task IN (SELECT id FROM task WHERE deleted_at < ?1) OR label IN (SELECT id FROM label WHERE deleted_at < ?1)",
+		"DELETE FROM vehicle_label WHERE vehicle IN (SELECT id FROM vehicle WHERE deleted_at < ?1) OR label IN (SELECT id FROM label WHERE deleted_at < ?1)",
+		"DELETE FROM task_dependency WHERE task IN (SELECT id FROM task WHERE deleted_at < ?1) OR depends_on IN (SELECT id FROM task WHERE deleted_at < ?1)",
+		"DELETE FROM task WHERE deleted_at < ?",
+		"DELETE FROM alert WHERE deleted_at < ?",
+		"DELETE FROM vehicle_document WHERE deleted_at < ?",
+		"DELETE FROM label WHERE deleted_at < ?",
+	} {
+		_, err = tx.Exec(q, cutoff)
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return 0, err
+		}
+	}
+	var purged int64
+	for _, table := range []string{"job", "vehicle", "user"} {
+		res, err := tx.Exec("DELETE FROM "+table+" WHERE deleted_at < ?", cutoff)
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return 0, err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			log.Printf("DB Execution Error: %s", err)
+			return 0, err
+		}
+		purged += rows
+	}
+	return purged, tx.Commit()
+}
+
+// Comment Queries
+
+// GetComment
+// Takes job id and comment id, queries it in db, returns Comment
+func GetComment(jobId int64, commentId int64) (*models.Comment, error) {
+	var comment models.Comment
+	// query db, return any errors
+	err := DB.QueryRow("SELECT id, job, task, user, body, created_at, updated_at FROM comment WHERE id=? AND job=?", commentId, jobId).Scan(
+		&comment.ID,
+		&comment.Job,
+		&comment.Task,
+		&comment.User,
+		&comment.Body,
+		&comment.Created_at,
+		&comment.Updated_at,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListComments
+// Takes job id, optional task id, sort and optional Page as args, returns Comment list oldest first unless sorted newest, only one page of it if Page provided
+func ListComments(jobId int64, taskId *string, sort *string, page *models.Page) ([]*models.Comment, error) {
+	var wheres []string
+	var args []any
+	// threads read oldest first by default
+	orderBy := "comment.created_at ASC"
+	if sort != nil && *sort == "newest" {
+		orderBy = "comment.created_at DESC"
+	}
+	q := "SELECT id, job, task, user, body, created_at, updated_at FROM comment"
+	// add wheres for job id and task id if provided
+	wheres = append(wheres, "comment.job=?")
+	args = append(args, jobId)
+	if taskId != nil && len(*taskId) > 0 {
+		wheres = append(wheres, "comment.task=?")
+		args = append(args, *taskId)
+	}
+	// check cursor and count all matching rows if paginating
+	if page != nil {
+		err := pageStart(page, orderBy, QueryBuilder(q, nil, &wheres, nil, nil, nil, nil), args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	query := QueryBuilder(q, nil, &wheres, nil, nil, &orderBy, page)
+	rows, err := DB.Query(query, append(args, pageArgs(page)...)...)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// create list of Comment
+	comments := make([]*models.Comment, 0)
+	// loop through returned rows
+	for rows.Next() {
+		// attribute to Comment
+		comment := models.Comment{}
+		err := rows.Scan(
+			&comment.ID,
+			&comment.Job,
+			&comment.Task,
+			&comment.User,
+			&comment.Body,
+			&comment.Created_at,
+			&comment.Updated_at,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		// append Comment to list of Comment
+		comments = append(comments, &comment)
+	}
+	// drop extra row fetched to detect another page, resume after last row
+	if page != nil && len(comments) > page.Limit {
+		comments = comments[:page.Limit]
+		err = pageNext(page, "comment", comments[len(comments)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return comments, nil
+}
+
+// CreateComment
+// Takes NewComment, job id and author id, creates in db, returns id
+func CreateComment(newComment models.NewComment, jobId int64, userId int64) (*int64, error) {
+	// insert into db, return any errors
+	res, err := DB.Exec("INSERT INTO comment(Job, Task, User, Body) VALUES (?,?,?,?)",
+		jobId,
+		newComment.Task,
+		userId,
+		newComment.Body,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// get inserted comments id
+	commentId, err := res.LastInsertId()
+	return &commentId, err
+}
+
+// EditComment
+// Takes Comment and id of the user editing it, keeps its current body as a CommentRevision and replaces it in a single transaction
+func EditComment(editedComment models.Comment, userId int64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("INSERT INTO comment_revision(Comment, Body, User) SELECT id, body, ? FROM comment WHERE id=? AND job=?", userId, editedComment.ID, editedComment.Job)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	res, err := tx.Exec("UPDATE comment SET body=?, updated_at=strftime('%Y-%m-%d %H:%M:%f','now') WHERE id=? AND job=?", editedComment.Body, editedComment.ID, editedComment.Job)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count, error if 0
+	rowCount, err := res.RowsAffected()
+	if rowCount == 0 || err != nil {
+		log.Printf("No rows updated: %v", err)
+		return errors.New("No rows updated")
+	}
+	return tx.Commit()
+}
+
+// DeleteComment
+// Takes job id and comment id, deletes the comment with its revisions
+func DeleteComment(jobId int64, commentId int64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("DELETE FROM comment_revision WHERE comment IN (SELECT id FROM comment WHERE id=? AND job=?)", commentId, jobId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	res, err := tx.Exec("DELETE FROM comment WHERE id=? AND job=?", commentId, jobId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count, throw error if no rows affected
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return err
+	}
+	if rows == 0 {
+		log.Printf("No rows deleted")
+		return errors.New("No rows deleted")
+	}
+	return tx.Commit()
+}
+
+// ListCommentRevisions
+// Takes comment id, returns its CommentRevision list newest first
+func ListCommentRevisions(commentId int64) ([]*models.CommentRevision, error) {
+	rows, err := DB.Query("SELECT id, comment, body, user, created_at FROM comment_revision WHERE comment=? ORDER BY id DESC", commentId)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// create list of CommentRevision
+	revisions := make([]*models.CommentRevision, 0)
+	// loop through returned rows
+	for rows.Next() {
+		// attribute to CommentRevision
+		revision := models.CommentRevision{}
+		err := rows.Scan(
+			&revision.ID,
+			&revision.Comment,
+			&revision.Body,
+			&revision.User,
+			&revision.Created_at,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		// append CommentRevision to list of CommentRevision
+		revisions = append(revisions, &revision)
+	}
+	return revisions, nil
+}
+
+// Time Entry Queries
+
+// GetTimeEntry
+// Takes time entry id, queries it in db, returns TimeEntry
+func GetTimeEntry(entryId int64) (*models.TimeEntry, error) {
+	return getTimeEntry("id=?", entryId)
+}
+
+// GetRunningTimer
+// Takes user id, returns their TimeEntry that has not been stopped
+func GetRunningTimer(userId int64) (*models.TimeEntry, error) {
+	return getTimeEntry("user=? AND stopped_at IS NULL ORDER BY started_at DESC LIMIT 1", userId)
+}
+
+// getTimeEntry
+// Returns the TimeEntry matching where
+func getTimeEntry(where string, args ...any) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	// query db, return any errors
+	err := DB.QueryRow("SELECT id, job, task, user, started_at, stopped_at FROM time_entry WHERE "+where, args...).Scan(
+		&entry.ID,
+		&entry.Job,
+		&entry.Task,
+		&entry.User,
+		&entry.Started_at,
+		&entry.Stopped_at,
+	)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListTimeEntries
+// Takes job id, optional task id, user id and Page as args, returns TimeEntry list oldest first, only one page of it if Page provided
+func ListTimeEntries(jobId int64, taskId *string, userId *string, page *models.Page) ([]*models.TimeEntry, error) {
+	var wheres []string
+	var args []any
+	orderBy := "time_entry.started_at ASC"
+	q := "SELECT id, job, task, user, started_at, stopped_at FROM time_entry"
+	// add wheres for job id, task id and user id if provided
+	wheres = append(wheres, "time_entry.job=?")
+	args = append(args, jobId)
+	if taskId != nil && len(*taskId) > 0 {
+		wheres = append(wheres, "time_entry.task=?")
+		args = append(args, *taskId)
+	}
+	if userId != nil && len(*userId) > 0 {
+		wheres = append(wheres, "time_entry.user=?")
+		args = append(args, *userId)
+	}
+	// check cursor and count all matching rows if paginating
+	if page != nil {
+		err := pageStart(page, orderBy, QueryBuilder(q, nil, &wheres, nil, nil, nil, nil), args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	query := QueryBuilder(q, nil, &wheres, nil, nil, &orderBy, page)
+	rows, err := DB.Query(query, append(args, pageArgs(page)...)...)
+	if err != nil {
+		log.Printf("DB Query Error: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	// create list of TimeEntry
+	entries := make([]*models.TimeEntry, 0)
+	// loop through returned rows
+	for rows.Next() {
+		// attribute to TimeEntry
+		entry := models.TimeEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Job,
+			&entry.Task,
+			&entry.User,
+			&entry.Started_at,
+			&entry.Stopped_at,
+		)
+		if err != nil {
+			log.Printf("Error scanning rows retrieved from DB: %s", err)
+			return nil, err
+		}
+		// append TimeEntry to list of TimeEntry
+		entries = append(entries, &entry)
+	}
+	// drop extra row fetched to detect another page, resume after last row
+	if page != nil && len(entries) > page.Limit {
+		entries = entries[:page.Limit]
+		err = pageNext(page, "time_entry", entries[len(entries)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// StartTimer
+// Takes job id, task id and user id, stops the users running timer and starts a new one on the task in a single transaction, returns id
+func StartTimer(jobId int64, taskId int64, userId int64) (*int64, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("UPDATE time_entry SET stopped_at=strftime('%Y-%m-%d %H:%M:%f','now') WHERE user=? AND stopped_at IS NULL", userId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// insert into db, return any errors
+	res, err := tx.Exec("INSERT INTO time_entry(Job, Task, User, Started_at) VALUES (?,?,?,strftime('%Y-%m-%d %H:%M:%f','now'))", jobId, taskId, userId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return nil, err
+	}
+	// get inserted entries id
+	entryId, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &entryId, tx.Commit()
+}
+
+// StopTimer
+// Takes time entry id, stops it if still running
+func StopTimer(entryId int64) error {
+	res, err := DB.Exec("UPDATE time_entry SET stopped_at=strftime('%Y-%m-%d %H:%M:%f','now') WHERE id=? AND stopped_at IS NULL", entryId)
+	if err != nil {
+		log.Printf("DB Execution Error: %s", err)
+		return err
+	}
+	// retrieve rows affected count, error if 0
+	rowCount, err := res.RowsAffected()
+	if rowCount == 0 || err != nil {
+		log.Printf("No rows updated: %v", err)
+		return errors.New("No rows updated")
+	}
+	return nil
+}
@@ -0,0 +1,530 @@
+package db
+
+import (
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+)
+
+// NewRepositories
+// Returns repositories backed by the connected sqlite database
+func NewRepositories() repository.Repositories {
+	return repository.Repositories{
+		Jobs:      JobStore{},
+		Tasks:     TaskStore{},
+		Vehicles:  VehicleStore{},
+		Alerts:    AlertStore{},
+		Labels:    LabelStore{},
+		Users:     UserStore{},
+		Audit:     AuditStore{},
+		Trash:     TrashStore{},
+		Comments:  CommentStore{},
+		Time:      TimeStore{},
+		Fuel:      FuelStore{},
+		Documents: DocumentStore{},
+		Schedules: ScheduleStore{},
+		Calendar:  CalendarStore{},
+		Search:    SearchStore{},
+	}
+}
+
+// JobStore
+// repository.JobRepository backed by the queries in this package
+type JobStore struct{}
+
+func (JobStore) GetJob(jobId int64) (*models.Job, error) {
+	return GetJob(jobId)
+}
+
+func (JobStore) ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete *string, status *string, labelId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Job, error) {
+	return ListJobs(userId, vehicleId, isTemplate, isComplete, status, labelId, searchStr, sort, page)
+}
+
+func (JobStore) CreateJob(newJob models.NewJob) (*int64, error) {
+	return CreateJob(newJob)
+}
+
+func (JobStore) EditJob(editedJob models.Job, revision *time.Time) error {
+	return EditJob(editedJob, revision)
+}
+
+func (JobStore) UpdateJobStatus(jobId int64, status string, isComplete int) error {
+	return UpdateJobStatus(jobId, status, isComplete)
+}
+
+func (JobStore) AssignJobLabel(jobId int64, labelId int64) (*int64, error) {
+	return AssignJobLabel(jobId, labelId)
+}
+
+func (JobStore) UnassignJobLabel(jobId int64, labelId int64) error {
+	return UnassignJobLabel(jobId, labelId)
+}
+
+func (JobStore) ListJobStatusHistory(jobId int64) ([]*models.JobStatusHistory, error) {
+	return ListJobStatusHistory(jobId)
+}
+
+func (JobStore) CreateJobStatusHistory(jobId int64, fromStatus *string, toStatus string, userId *int64, note *string) (*int64, error) {
+	return CreateJobStatusHistory(jobId, fromStatus, toStatus, userId, note)
+}
+
+func (JobStore) DeleteJobStatusHistory(jobId int64) error {
+	return DeleteJobStatusHistory(jobId)
+}
+
+func (JobStore) CompleteJob(jobId int64, completedAt time.Time) error {
+	return CompleteJob(jobId, completedAt)
+}
+
+func (JobStore) RecordJobCompletion(completion models.JobCompletion, vehicleId *int64, moveOdometer bool, nextJob *models.NextJob) (*int64, error) {
+	return RecordJobCompletion(completion, vehicleId, moveOdometer, nextJob)
+}
+
+func (JobStore) UndoJobCompletion(completion models.JobCompletion, vehicleId *int64, restoreOdometer bool, trashNextJob bool, userId *int64) error {
+	return UndoJobCompletion(completion, vehicleId, restoreOdometer, trashNextJob, userId)
+}
+
+func (JobStore) GetLatestJobCompletion(jobId int64) (*models.JobCompletion, error) {
+	return GetLatestJobCompletion(jobId)
+}
+
+func (JobStore) CreateJobCompletion(completion models.JobCompletion) (*int64, error) {
+	return CreateJobCompletion(completion)
+}
+
+func (JobStore) DeleteJobCompletion(completionId int64) error {
+	return DeleteJobCompletion(completionId)
+}
+
+func (JobStore) DeleteJobCompletions(jobId int64) error {
+	return DeleteJobCompletions(jobId)
+}
+
+func (JobStore) ImportJobs(newJobs []models.NewJob) ([]int64, error) {
+	return ImportJobs(newJobs)
+}
+
+func (JobStore) BulkDeleteJobs(jobIds []int64, atomic bool) ([]error, bool, error) {
+	return BulkDeleteJobs(jobIds, atomic)
+}
+
+func (JobStore) BulkAssignJobLabel(jobIds []int64, labelId int64, assign int, atomic bool) ([]error, bool, error) {
+	return BulkAssignJobLabel(jobIds, labelId, assign, atomic)
+}
+
+// TaskStore
+// repository.TaskRepository backed by the queries in this package
+type TaskStore struct{}
+
+func (TaskStore) GetTask(jobId int64, taskId int64) (*models.Task, error) {
+	return GetTask(jobId, taskId)
+}
+
+func (TaskStore) GetTaskById(taskId int64) (*models.Task, error) {
+	return GetTaskById(taskId)
+}
+
+func (TaskStore) ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string, page *models.Page) ([]*models.Task, error) {
+	return ListTasks(jobId, isComplete, searchStr, sort, page)
+}
+
+func (TaskStore) CreateTask(newTask models.NewTask, jobId int64) (*int64, error) {
+	return CreateTask(newTask, jobId)
+}
+
+func (TaskStore) EditTask(editedTask models.Task, jobId int64, revision *time.Time) error {
+	return EditTask(editedTask, jobId, revision)
+}
+
+func (TaskStore) ReorderTasks(jobId int64, taskIds []int64) error {
+	return ReorderTasks(jobId, taskIds)
+}
+
+func (TaskStore) DeleteTask(jobId int64, taskId *int64) error {
+	return DeleteTask(jobId, taskId)
+}
+
+func (TaskStore) UpdateTaskStatus(jobId int64, taskId int64, status int) error {
+	return UpdateTaskStatus(jobId, taskId, status)
+}
+
+func (TaskStore) AssignTaskLabel(taskId int64, labelId int64) (*int64, error) {
+	return AssignLinkedLabel("task", taskId, labelId)
+}
+
+func (TaskStore) UnassignTaskLabel(taskId int64, labelId int64) error {
+	return UnassignLinkedLabel("task", taskId, labelId)
+}
+
+func (TaskStore) ImportTasks(newTasks []models.NewTask, jobId int64) ([]int64, error) {
+	return ImportTasks(newTasks, jobId)
+}
+
+func (TaskStore) BulkUpdateTaskStatus(taskIds []int64, status int, atomic bool) ([]error, bool, error) {
+	return BulkUpdateTaskStatus(taskIds, status, atomic)
+}
+
+// VehicleStore
+// repository.VehicleRepository backed by the queries in this package
+type VehicleStore struct{}
+
+func (VehicleStore) GetVehicle(vehicleId int64) (*models.Vehicle, error) {
+	return GetVehicle(vehicleId)
+}
+
+func (VehicleStore) ListVehicles(userId *string, jobId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Vehicle, error) {
+	return ListVehicles(userId, jobId, searchStr, sort, page)
+}
+
+func (VehicleStore) CreateVehicle(newVehicle models.NewVehicle) (*int64, error) {
+	return CreateVehicle(newVehicle)
+}
+
+func (VehicleStore) EditVehicle(editedVehicle models.Vehicle, revision *time.Time) error {
+	return EditVehicle(editedVehicle, revision)
+}
+
+func (VehicleStore) UpdateVehicleOdometer(vehicleId int64, odometer *int64) error {
+	return UpdateVehicleOdometer(vehicleId, odometer)
+}
+
+func (VehicleStore) AssignVehicleLabel(vehicleId int64, labelId int64) (*int64, error) {
+	return AssignLinkedLabel("vehicle", vehicleId, labelId)
+}
+
+func (VehicleStore) UnassignVehicleLabel(vehicleId int64, labelId int64) error {
+	return UnassignLinkedLabel("vehicle", vehicleId, labelId)
+}
+
+func (VehicleStore) ListOdometerReadings(vehicleId int64) ([]*models.OdometerReading, error) {
+	return ListOdometerReadings(vehicleId)
+}
+
+func (VehicleStore) CreateOdometerReading(vehicleId int64, newReading models.NewOdometerReading, source string, jobId *int64, userId *int64) (*int64, error) {
+	return CreateOdometerReading(vehicleId, newReading, source, jobId, userId)
+}
+
+func (VehicleStore) DeleteOdometerReading(readingId int64) error {
+	return DeleteOdometerReading(readingId)
+}
+
+func (VehicleStore) DeleteOdometerReadings(vehicleId int64) error {
+	return DeleteOdometerReadings(vehicleId)
+}
+
+func (VehicleStore) ImportVehicles(newVehicles []models.NewVehicle) ([]int64, error) {
+	return ImportVehicles(newVehicles)
+}
+
+func (VehicleStore) ImportTracker(trackerImport models.TrackerImport) (*models.TrackerImportedIds, error) {
+	return ImportTracker(trackerImport)
+}
+
+// AlertStore
+// repository.AlertRepository backed by the queries in this package
+type AlertStore struct{}
+
+func (AlertStore) GetAlert(alertId int64) (*models.Alert, error) {
+	return GetAlert(alertId)
+}
+
+func (AlertStore) ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string, typeStr *string, isRead *string, alertDate *string, searchStr *string, sort *string, page *models.Page) ([]*models.Alert, error) {
+	return ListAlerts(userId, vehicleId, jobId, taskId, typeStr, isRead, alertDate, searchStr, sort, page)
+}
+
+func (AlertStore) CreateAlert(newAlert models.NewAlert) (*int64, error) {
+	return CreateAlert(newAlert)
+}
+
+func (AlertStore) EditAlert(editedAlert models.Alert, revision *time.Time) error {
+	return EditAlert(editedAlert, revision)
+}
+
+func (AlertStore) DeleteAlert(alertId int64, userId *int64) error {
+	return DeleteAlert(alertId, userId)
+}
+
+func (AlertStore) UpdateAlertStatus(alertId int64, userId int64, status int) error {
+	return UpdateAlertStatus(alertId, userId, status)
+}
+
+func (AlertStore) BulkUpdateAlertStatus(alertIds []int64, alertUsers map[int64]int64, status int, atomic bool) ([]error, bool, error) {
+	return BulkUpdateAlertStatus(alertIds, alertUsers, status, atomic)
+}
+
+// LabelStore
+// repository.LabelRepository backed by the queries in this package
+type LabelStore struct{}
+
+func (LabelStore) GetLabel(labelId int64) (*models.Label, error) {
+	return GetLabel(labelId)
+}
+
+func (LabelStore) ListLabels(userId *string, jobId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Label, error) {
+	return ListLabels(userId, jobId, searchStr, sort, page)
+}
+
+func (LabelStore) CreateLabel(newLabel models.NewLabel) (*int64, error) {
+	return CreateLabel(newLabel)
+}
+
+func (LabelStore) EditLabel(editedLabel models.Label, revision *time.Time) error {
+	return EditLabel(editedLabel, revision)
+}
+
+func (LabelStore) DeleteLabel(labelId int64, userId *int64) error {
+	return DeleteLabel(labelId, userId)
+}
+
+// UserStore
+// repository.UserRepository backed by the queries in this package
+type UserStore struct{}
+
+func (UserStore) GetAuthInfoByUsername(username string) (*int64, *string, *int, *[]byte, error) {
+	return GetAuthInfoByUsername(username)
+}
+
+func (UserStore) GetUserById(userId int64) (*models.User, error) {
+	return GetUserById(userId)
+}
+
+func (UserStore) GetUserByUsername(username string) (*models.User, error) {
+	return GetUserByUsername(username)
+}
+
+func (UserStore) ListUsers(jobId *string, vehicleId *string, isAdmin *string, searchStr *string, sort *string, page *models.Page) ([]*models.User, error) {
+	return ListUsers(jobId, vehicleId, isAdmin, searchStr, sort, page)
+}
+
+func (UserStore) CreateUser(newUser models.NewUser, password *[]byte) (*int64, error) {
+	return CreateUser(newUser, password)
+}
+
+func (UserStore) EditUser(editedUser models.User, revision *time.Time) error {
+	return EditUser(editedUser, revision)
+}
+
+func (UserStore) UpdatePassword(username string, password *[]byte) error {
+	return UpdatePassword(username, password)
+}
+
+func (UserStore) ImportAccount(accountImport models.AccountImport) (*models.ImportedIds, error) {
+	return ImportAccount(accountImport)
+}
+
+// AuditStore
+// repository.AuditRepository backed by the queries in this package
+type AuditStore struct{}
+
+func (AuditStore) CreateAuditEvent(event models.AuditEvent) (*int64, error) {
+	return CreateAuditEvent(event)
+}
+
+func (AuditStore) ListAuditEvents(actor *string, entity *string, action *string, vehicleId *string, jobId *string, page *models.Page) ([]*models.AuditEvent, error) {
+	return ListAuditEvents(actor, entity, action, vehicleId, jobId, page)
+}
+
+func (AuditStore) DeleteAuditEvents(before time.Time) (int64, error) {
+	return DeleteAuditEvents(before)
+}
+
+// TrashStore
+// repository.TrashRepository backed by the queries in this package
+type TrashStore struct{}
+
+func (TrashStore) TrashJob(jobId int64, userId *int64) error {
+	return TrashJob(jobId, userId)
+}
+
+func (TrashStore) TrashVehicle(vehicleId int64, userId *int64) error {
+	return TrashVehicle(vehicleId, userId)
+}
+
+func (TrashStore) TrashUser(username string) error {
+	return TrashUser(username)
+}
+
+func (TrashStore) RestoreJob(jobId int64, userId *int64) error {
+	return RestoreJob(jobId, userId)
+}
+
+func (TrashStore) RestoreVehicle(vehicleId int64, userId *int64) error {
+	return RestoreVehicle(vehicleId, userId)
+}
+
+func (TrashStore) RestoreUser(userId int64) error {
+	return RestoreUser(userId)
+}
+
+func (TrashStore) ListTrash(userId *string) ([]*models.TrashItem, error) {
+	return ListTrash(userId)
+}
+
+func (TrashStore) PurgeTrash(before time.Time) (int64, error) {
+	return PurgeTrash(before)
+}
+
+// CommentStore
+// repository.CommentRepository backed by the queries in this package
+type CommentStore struct{}
+
+func (CommentStore) GetComment(jobId int64, commentId int64) (*models.Comment, error) {
+	return GetComment(jobId, commentId)
+}
+
+func (CommentStore) ListComments(jobId int64, taskId *string, sort *string, page *models.Page) ([]*models.Comment, error) {
+	return ListComments(jobId, taskId, sort, page)
+}
+
+func (CommentStore) CreateComment(newComment models.NewComment, jobId int64, userId int64) (*int64, error) {
+	return CreateComment(newComment, jobId, userId)
+}
+
+func (CommentStore) EditComment(editedComment models.Comment, userId int64) error {
+	return EditComment(editedComment, userId)
+}
+
+func (CommentStore) DeleteComment(jobId int64, commentId int64) error {
+	return DeleteComment(jobId, commentId)
+}
+
+func (CommentStore) ListCommentRevisions(commentId int64) ([]*models.CommentRevision, error) {
+	return ListCommentRevisions(commentId)
+}
+
+// TimeStore
+// repository.TimeRepository backed by the queries in this package
+type TimeStore struct{}
+
+func (TimeStore) GetTimeEntry(entryId int64) (*models.TimeEntry, error) {
+	return GetTimeEntry(entryId)
+}
+
+func (TimeStore) GetRunningTimer(userId int64) (*models.TimeEntry, error) {
+	return GetRunningTimer(userId)
+}
+
+func (TimeStore) ListTimeEntries(jobId int64, taskId *string, userId *string, page *models.Page) ([]*models.TimeEntry, error) {
+	return ListTimeEntries(jobId, taskId, userId, page)
+}
+
+func (TimeStore) StartTimer(jobId int64, taskId int64, userId int64) (*int64, error) {
+	return StartTimer(jobId, taskId, userId)
+}
+
+func (TimeStore) StopTimer(entryId int64) error {
+	return StopTimer(entryId)
+}
+
+// FuelStore
+// repository.FuelRepository backed by the queries in this package
+type FuelStore struct{}
+
+func (FuelStore) ListFuelLogs(vehicleId int64, page *models.Page) ([]*models.FuelLog, error) {
+	return ListFuelLogs(vehicleId, page)
+}
+
+func (FuelStore) ImportFuelLogs(vehicleId int64, newFuelLogs []models.NewFuelLog, userId *int64, odometer *int64) ([]int64, error) {
+	return ImportFuelLogs(vehicleId, newFuelLogs, userId, odometer)
+}
+
+// DocumentStore
+// repository.DocumentRepository backed by the queries in this package
+type DocumentStore struct{}
+
+func (DocumentStore) GetDocument(vehicleId int64, documentId int64) (*models.Document, error) {
+	return GetDocument(vehicleId, documentId)
+}
+
+func (DocumentStore) ListDocuments(vehicleId int64, typeStr *string, expiresBefore *string, searchStr *string, sort *string) ([]*models.Document, error) {
+	return ListDocuments(vehicleId, typeStr, expiresBefore, searchStr, sort)
+}
+
+func (DocumentStore) CreateDocument(newDocument models.NewDocument, vehicleId int64, userId int64) (*int64, error) {
+	return CreateDocument(newDocument, vehicleId, userId)
+}
+
+func (DocumentStore) EditDocument(editedDocument models.Document, vehicleId int64) error {
+	return EditDocument(editedDocument, vehicleId)
+}
+
+func (DocumentStore) UpdateDocumentAlert(documentId int64, alertId *int64) error {
+	return UpdateDocumentAlert(documentId, alertId)
+}
+
+func (DocumentStore) GetDocumentAttachment(vehicleId int64, documentId int64) (*string, *string, []byte, error) {
+	return GetDocumentAttachment(vehicleId, documentId)
+}
+
+func (DocumentStore) UpdateDocumentAttachment(vehicleId int64, documentId int64, name *string, contentType *string, data []byte) error {
+	return UpdateDocumentAttachment(vehicleId, documentId, name, contentType, data)
+}
+
+func (DocumentStore) DeleteDocument(vehicleId int64, documentId int64) error {
+	return DeleteDocument(vehicleId, documentId)
+}
+
+// ScheduleStore
+// repository.ScheduleRepository backed by the queries in this package
+type ScheduleStore struct{}
+
+func (ScheduleStore) GetSchedule(scheduleId int64) (*models.Schedule, error) {
+	return GetSchedule(scheduleId)
+}
+
+func (ScheduleStore) ListSchedules(userId *string, makeStr *string, modelStr *string, yearStr *string, searchStr *string, sort *string) ([]*models.Schedule, error) {
+	return ListSchedules(userId, makeStr, modelStr, yearStr, searchStr, sort)
+}
+
+func (ScheduleStore) CreateSchedule(newSchedule models.NewSchedule) (*int64, error) {
+	return CreateSchedule(newSchedule)
+}
+
+func (ScheduleStore) DeleteSchedule(scheduleId int64, userId *int64) error {
+	return DeleteSchedule(scheduleId, userId)
+}
+
+func (ScheduleStore) AssignScheduleJob(scheduleId int64, jobId int64) (*int64, error) {
+	return AssignScheduleJob(scheduleId, jobId)
+}
+
+func (ScheduleStore) ListScheduleJobIds(scheduleId int64) ([]int64, error) {
+	return ListScheduleJobIds(scheduleId)
+}
+
+func (ScheduleStore) UnassignScheduleJobs(scheduleId int64) error {
+	return UnassignScheduleJobs(scheduleId)
+}
+
+// CalendarStore
+// repository.CalendarRepository backed by the queries in this package
+type CalendarStore struct{}
+
+func (CalendarStore) GetCalendarToken(token string) (*models.CalendarToken, error) {
+	return GetCalendarToken(token)
+}
+
+func (CalendarStore) SetCalendarToken(userId int64, token string) error {
+	return SetCalendarToken(userId, token)
+}
+
+func (CalendarStore) DeleteCalendarToken(userId int64) error {
+	return DeleteCalendarToken(userId)
+}
+
+// SearchStore
+// repository.SearchRepository backed by the queries in this package
+type SearchStore struct{}
+
+func (SearchStore) SearchIndexEnabled() bool {
+	return SearchIndexEnabled()
+}
+
+func (SearchStore) Search(match string, userId *int64, types []string, limit int) ([]*models.SearchResult, error) {
+	return Search(match, userId, types, limit)
+}
+
+func (SearchStore) SearchLike(terms []string, userId *int64, types []string, limit int) ([]*models.SearchResult, error) {
+	return SearchLike(terms, userId, types, limit)
+}
@@ -164,4 +164,23 @@ export const updateAlertReadStatus = async(alertId: number, unread?: boolean): P
 export const getLabels = async(params?: {[key:string]:string}): Promise<Response> => {
     const paramStr = await paramStrConstruct(params)
     return apiRequest(`/labels${paramStr}`, undefined, 'GET')
-}
\ No newline at end of file
+}
+// errorMessage
+// return message from a failed response, problem+json detail or title, or the body as is
+export const errorMessage = async(res: Response): Promise<string> => {
+    const text = await res.text()
+    if (res.headers.get('Content-Type')?.startsWith('application/problem+json')) {
+        try {
+            const problem = JSON.parse(text)
+            const message = problem.detail ?? problem.title ?? text
+            // list invalid fields of validation failures
+            if (Array.isArray(problem.errors) && problem.errors.length > 0) {
+                return `${message}: ${problem.errors.map((e: {field: string, message: string}) => `${e.field} ${e.message}`).join(', ')}`
+            }
+            return message
+        } catch {
+            return text
+        }
+    }
+    return text
+}
@@ -32,6 +32,7 @@ export type Job = 	{
     instructions: string|null,
     isTemplate: number,
     isComplete: number,
+    status: 'planned' | 'parts_ordered' | 'in_progress' | 'blocked' | 'done' | 'skipped',
     vehicle: number|null,
     user: number,
     originJob: number|null,
@@ -41,6 +42,7 @@ export type Job = 	{
     timeInterval: number,
     timeIntervalUnit: 'month' | 'day' | 'week' | 'hour' | 'year',
     dueDate: number|null,
+    dueOdometer: number|null,
     completedAt: string|null,
     createdAt: string,
     updatedAt: string,
@@ -0,0 +1,58 @@
+package importers
+
+import (
+	"errors"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// fuelly
+// Fuelly fuel-up exports, all vehicles in one file, price is per gallon or litre:
+// car_name,model,mpg,odometer,miles,gallons,price,city_percentage,fuelup_date,date_added,tags,notes,missed_fuelup,partial_fuelup,latitude,longitude,brand
+type fuelly struct{}
+
+func (fuelly) Name() string { return "fuelly" }
+
+func (fuelly) Detect(header map[string]bool) bool {
+	return hasAll(header, "carname", "odometer", "fuelupdate", "price") && (header["gallons"] || header["litres"] || header["liters"])
+}
+
+func (fuelly) Record(row map[string]string, opts Options) (*models.TrackerRecord, error) {
+	date, err := parseDate(row["fuelupdate"], opts)
+	if err != nil {
+		return nil, err
+	}
+	odometer, err := parseOdometer(row["odometer"])
+	if err != nil {
+		return nil, err
+	}
+	volume, err := parseNumber(first(row, "gallons", "litres", "liters"))
+	if err != nil {
+		return nil, err
+	}
+	if volume == nil {
+		return nil, errors.New("Gallons or litres are required")
+	}
+	price, err := parseNumber(row["price"])
+	if err != nil {
+		return nil, err
+	}
+	// total cost is price per unit times volume
+	var cost *float64
+	if price != nil && volume != nil {
+		total := *price * *volume
+		cost = &total
+	}
+	return &models.TrackerRecord{
+		Kind:        "fuel",
+		Vehicle:     row["carname"],
+		Date:        date,
+		Odometer:    odometer,
+		Name:        "Fuel",
+		Tasks:       make([]string, 0),
+		Cost:        cost,
+		Shop:        optional(row["brand"]),
+		Notes:       optional(row["notes"]),
+		Fuel_volume: volume,
+	}, nil
+}
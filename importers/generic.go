@@ -0,0 +1,48 @@
+package importers
+
+import (
+	"errors"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// generic
+// Service record spreadsheets, vehicle column is optional and tasks are separated by semicolons or pipes:
+// Vehicle,Date,Odometer,Service,Tasks,Cost,Shop,Notes
+type generic struct{}
+
+func (generic) Name() string { return "generic" }
+
+func (generic) Detect(header map[string]bool) bool {
+	return header["date"] && (header["service"] || header["work"] || header["name"])
+}
+
+func (generic) Record(row map[string]string, opts Options) (*models.TrackerRecord, error) {
+	date, err := parseDate(row["date"], opts)
+	if err != nil {
+		return nil, err
+	}
+	odometer, err := parseOdometer(first(row, "odometer", "mileage", "miles", "km"))
+	if err != nil {
+		return nil, err
+	}
+	cost, err := parseNumber(first(row, "cost", "price", "amount", "total"))
+	if err != nil {
+		return nil, err
+	}
+	name := first(row, "service", "work", "name")
+	if len(name) == 0 {
+		return nil, errors.New("Service is required")
+	}
+	return &models.TrackerRecord{
+		Kind:     "service",
+		Vehicle:  row["vehicle"],
+		Date:     date,
+		Odometer: odometer,
+		Name:     name,
+		Tasks:    splitList(first(row, "tasks", "parts"), ";|"),
+		Cost:     cost,
+		Shop:     optional(first(row, "shop", "vendor", "location")),
+		Notes:    optional(row["notes"]),
+	}, nil
+}
@@ -0,0 +1,251 @@
+// Package importers reads exports from other maintenance trackers into TrackerRecords, one adapter per format
+package importers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// Adapter
+// Converts rows of one apps csv export to TrackerRecords
+type Adapter interface {
+	// Name is the format name used in urls
+	Name() string
+	// Detect reports whether a normalized csv header looks like this format
+	Detect(header map[string]bool) bool
+	// Record converts a row, keyed by normalized header, to a TrackerRecord
+	Record(row map[string]string, opts Options) (*models.TrackerRecord, error)
+}
+
+// Options
+// Settings shared by all adapters
+type Options struct {
+	// read ambiguous dates like 02/03/2024 as day first, exports follow the locale of the source app
+	Day_first bool
+}
+
+// adapters in detection order, most specific first
+var adapters = []Adapter{
+	lubeLoggerGas{},
+	lubeLoggerService{},
+	fuelly{},
+	simplyAuto{},
+	generic{},
+}
+
+// Formats
+// Returns names of all supported formats
+func Formats() []string {
+	names := make([]string, len(adapters))
+	for i, adapter := range adapters {
+		names[i] = adapter.Name()
+	}
+	return names
+}
+
+// Get
+// Takes format name as arg, returns its Adapter
+func Get(format string) (Adapter, bool) {
+	for _, adapter := range adapters {
+		if adapter.Name() == format {
+			return adapter, true
+		}
+	}
+	return nil, false
+}
+
+// Detect
+// Takes normalized csv header as arg, returns first Adapter that recognises it
+func Detect(header map[string]bool) (Adapter, bool) {
+	for _, adapter := range adapters {
+		if adapter.Detect(header) {
+			return adapter, true
+		}
+	}
+	return nil, false
+}
+
+// Parse
+// Takes csv export, format name (or auto to detect it) and options as args, returns the Adapter used, records and per row errors
+func Parse(r io.Reader, format string, opts Options) (Adapter, []models.TrackerRecord, []models.CSVRowError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	columns := make([]string, len(header))
+	headerSet := make(map[string]bool)
+	for i, h := range header {
+		columns[i] = normalize(h)
+		headerSet[columns[i]] = true
+	}
+	// pick adapter
+	var adapter Adapter
+	var ok bool
+	if format == "auto" {
+		adapter, ok = Detect(headerSet)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("Could not detect format, use one of %v", strings.Join(Formats(), ", "))
+		}
+	} else {
+		adapter, ok = Get(format)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("Unknown format %q, use one of %v", format, strings.Join(Formats(), ", "))
+		}
+		if !adapter.Detect(headerSet) {
+			return nil, nil, nil, fmt.Errorf("CSV header does not look like a %v export", format)
+		}
+	}
+	records := make([]models.TrackerRecord, 0)
+	rowErrors := make([]models.CSVRowError, 0)
+	for line := 2; ; line++ {
+		values, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		row := make(map[string]string)
+		empty := true
+		for i, value := range values {
+			if i < len(columns) {
+				row[columns[i]] = strings.TrimSpace(value)
+				empty = empty && len(row[columns[i]]) == 0
+			}
+		}
+		// skip blank lines, spreadsheets like to leave them at the end
+		if empty {
+			continue
+		}
+		record, err := adapter.Record(row, opts)
+		if err != nil {
+			rowErrors = append(rowErrors, models.CSVRowError{Row: line, Error: err.Error()})
+			continue
+		}
+		records = append(records, *record)
+	}
+	return adapter, records, rowErrors, nil
+}
+
+// normalize
+// Takes csv header as arg, returns it lower case with only letters and digits, so "Fuel Consumed" matches fuelconsumed
+func normalize(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// hasAll
+// Returns whether header has every column
+func hasAll(header map[string]bool, columns ...string) bool {
+	for _, column := range columns {
+		if !header[column] {
+			return false
+		}
+	}
+	return true
+}
+
+// first
+// Returns value of the first column present in row, empty if none are
+func first(row map[string]string, columns ...string) string {
+	for _, column := range columns {
+		if value, ok := row[column]; ok && len(value) > 0 {
+			return value
+		}
+	}
+	return ""
+}
+
+// optional
+// Returns pointer to value, nil if empty
+func optional(value string) *string {
+	if len(value) == 0 {
+		return nil
+	}
+	return &value
+}
+
+// parseDate
+// Takes date as exported by tracker apps as arg, returns it as time
+func parseDate(value string, opts Options) (time.Time, error) {
+	layouts := []string{time.DateOnly, time.DateTime, time.RFC3339, "2006/01/02", "Jan 2, 2006", "2 Jan 2006"}
+	// slash and dash separated dates depend on locale
+	if opts.Day_first {
+		layouts = append(layouts, "2/1/2006", "2-1-2006", "2.1.2006", "2/1/2006 15:04", "2/1/2006 3:04:05 PM")
+	} else {
+		layouts = append(layouts, "1/2/2006", "1-2-2006", "1/2/2006 15:04", "1/2/2006 3:04:05 PM")
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Unrecognised date %q", value)
+}
+
+// parseNumber
+// Takes number as exported by tracker apps as arg, strips currency symbols and thousands separators, returns nil if empty
+func parseNumber(value string) (*float64, error) {
+	// a lone comma not followed by three digits is a decimal comma, like 640,00
+	if i := strings.LastIndex(value, ","); i >= 0 && !strings.Contains(value, ".") && strings.Count(value, ",") == 1 && len(strings.TrimSpace(value[i+1:])) != 3 {
+		value = value[:i] + "." + value[i+1:]
+	}
+	cleaned := strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == '-' {
+			return r
+		}
+		return -1
+	}, value)
+	if len(cleaned) == 0 {
+		if len(strings.TrimSpace(value)) > 0 {
+			return nil, fmt.Errorf("Unrecognised number %q", value)
+		}
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Unrecognised number %q", value)
+	}
+	return &f, nil
+}
+
+// parseOdometer
+// Takes odometer as exported by tracker apps as arg, returns it rounded to a whole number, nil if empty
+func parseOdometer(value string) (*int64, error) {
+	f, err := parseNumber(value)
+	if err != nil || f == nil {
+		return nil, err
+	}
+	odometer := int64(*f + 0.5)
+	return &odometer, nil
+}
+
+// splitList
+// Takes a list cell and separators as args, returns trimmed non empty items
+func splitList(value string, separators string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return strings.ContainsRune(separators, r) }) {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
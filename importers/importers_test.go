@@ -0,0 +1,165 @@
+package importers
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseFixture
+// Opens fixture from testdata and parses it with format
+func parseFixture(t *testing.T, filename string, format string, opts Options) (Adapter, int, []string) {
+	t.Helper()
+	file, err := os.Open("testdata/" + filename)
+	if err != nil {
+		t.Fatalf("Error opening fixture: %v", err)
+	}
+	defer file.Close()
+	adapter, records, rowErrors, err := Parse(file, format, opts)
+	if err != nil {
+		t.Fatalf("Error parsing %v: %v", filename, err)
+	}
+	names := make([]string, len(records))
+	for i, record := range records {
+		names[i] = record.Name
+	}
+	return adapter, len(rowErrors), names
+}
+
+// TestDetect
+// Tests every fixture is detected as its own format
+func TestDetect(t *testing.T) {
+	fixtures := map[string]string{
+		"lubelogger_service.csv": "lubelogger-service",
+		"lubelogger_gas.csv":     "lubelogger-gas",
+		"fuelly.csv":             "fuelly",
+		"simplyauto.csv":         "simplyauto",
+		"generic.csv":            "generic",
+	}
+	for filename, format := range fixtures {
+		adapter, _, _ := parseFixture(t, filename, "auto", Options{})
+		if adapter.Name() != format {
+			t.Errorf("Expected %v to be detected as %v, got %v", filename, format, adapter.Name())
+		}
+	}
+}
+
+// TestLubeLoggerService
+// Tests LubeLogger service records map to service records with odometer, cost and notes
+func TestLubeLoggerService(t *testing.T) {
+	file, _ := os.Open("testdata/lubelogger_service.csv")
+	defer file.Close()
+	_, records, rowErrors, err := Parse(file, "lubelogger-service", Options{})
+	if err != nil || len(rowErrors) != 0 {
+		t.Fatalf("Unexpected errors: %v %v", err, rowErrors)
+	}
+	// blank trailing row is skipped
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	oil := records[0]
+	if oil.Kind != "service" || oil.Name != "Oil Change" || *oil.Odometer != 52310 || *oil.Cost != 64.99 || *oil.Notes != "5W-30 full synthetic" {
+		t.Errorf("Unexpected record: %+v", oil)
+	}
+	if !oil.Date.Equal(time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 2024-01-15, got %v", oil.Date)
+	}
+}
+
+// TestLubeLoggerGas
+// Tests LubeLogger gas records map to fuel records, and are not mistaken for service records
+func TestLubeLoggerGas(t *testing.T) {
+	file, _ := os.Open("testdata/lubelogger_gas.csv")
+	defer file.Close()
+	_, records, rowErrors, err := Parse(file, "lubelogger-gas", Options{})
+	if err != nil || len(rowErrors) != 0 || len(records) != 2 {
+		t.Fatalf("Unexpected result: %v %v %d", err, rowErrors, len(records))
+	}
+	if records[1].Kind != "fuel" || *records[1].Odometer != 52950 || *records[1].Fuel_volume != 10.4 || *records[1].Notes != "Costco" {
+		t.Errorf("Unexpected record: %+v", records[1])
+	}
+	// gas exports must not parse as service exports
+	file.Seek(0, 0)
+	if _, _, _, err := Parse(file, "lubelogger-service", Options{}); err == nil {
+		t.Error("Gas export should not be accepted as lubelogger-service")
+	}
+	// fuel logs need the volume filled
+	_, _, rowErrors, err = Parse(strings.NewReader("Date,Odometer,FuelConsumed,Cost\n1/20/2024,52640,,41.33\n"), "lubelogger-gas", Options{})
+	if err != nil || len(rowErrors) != 1 || rowErrors[0].Row != 2 {
+		t.Errorf("Expected missing volume reported on row 2, got %v %v", err, rowErrors)
+	}
+}
+
+// TestFuelly
+// Tests Fuelly fuel-ups keep vehicle names, total cost from price per gallon, and report bad rows
+func TestFuelly(t *testing.T) {
+	file, _ := os.Open("testdata/fuelly.csv")
+	defer file.Close()
+	_, records, rowErrors, err := Parse(file, "fuelly", Options{})
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	// truck row has an invalid price
+	if len(records) != 2 || len(rowErrors) != 1 || rowErrors[0].Row != 4 {
+		t.Fatalf("Expected 2 records and an error on row 4, got %d %v", len(records), rowErrors)
+	}
+	fuelup := records[0]
+	if fuelup.Vehicle != "Daily Civic" || *fuelup.Odometer != 88012 || *fuelup.Shop != "Shell" {
+		t.Errorf("Unexpected record: %+v", fuelup)
+	}
+	if cost := *fuelup.Cost; cost < 31.77 || cost > 31.79 {
+		t.Errorf("Expected cost of 8.83 gallons at 3.599, got %v", cost)
+	}
+}
+
+// TestSimplyAuto
+// Tests Simply Auto services split service types into tasks
+func TestSimplyAuto(t *testing.T) {
+	_, errCount, names := parseFixture(t, "simplyauto.csv", "simplyauto", Options{})
+	if errCount != 0 || len(names) != 2 || names[0] != "Oil Change, Tire Rotation" {
+		t.Errorf("Unexpected records: %v, %d errors", names, errCount)
+	}
+	file, _ := os.Open("testdata/simplyauto.csv")
+	defer file.Close()
+	// dates read day first when asked
+	_, records, _, _ := Parse(file, "simplyauto", Options{Day_first: true})
+	if len(records[0].Tasks) != 2 || records[0].Tasks[1] != "Tire Rotation" || records[0].Date.Month() != time.April {
+		t.Errorf("Unexpected record: %+v", records[0])
+	}
+	if records[1].Cost != nil || records[1].Shop != nil {
+		t.Errorf("Empty cost and shop should be nil: %+v", records[1])
+	}
+}
+
+// TestGeneric
+// Tests generic service records with aliased columns, task lists and per row errors
+func TestGeneric(t *testing.T) {
+	file, _ := os.Open("testdata/generic.csv")
+	defer file.Close()
+	_, records, rowErrors, err := Parse(file, "generic", Options{})
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	// bad date on row 3, missing service on row 4
+	if len(records) != 1 || len(rowErrors) != 2 || rowErrors[0].Row != 3 || rowErrors[1].Row != 4 {
+		t.Fatalf("Expected 1 record and errors on rows 3 and 4, got %d %v", len(records), rowErrors)
+	}
+	belt := records[0]
+	if belt.Vehicle != "Project Car" || *belt.Odometer != 101500 || len(belt.Tasks) != 3 || *belt.Cost != 640 || *belt.Shop != "Garage Dupont" {
+		t.Errorf("Unexpected record: %+v", belt)
+	}
+}
+
+// TestUnknownFormat
+// Tests unknown formats and unrecognised headers are rejected
+func TestUnknownFormat(t *testing.T) {
+	file, _ := os.Open("testdata/generic.csv")
+	defer file.Close()
+	if _, _, _, err := Parse(file, "carfax", Options{}); err == nil {
+		t.Error("Unknown format should be rejected")
+	}
+	if _, _, _, err := Parse(strings.NewReader(""), "fuelly", Options{}); err == nil {
+		t.Error("Empty file should be rejected")
+	}
+}
@@ -0,0 +1,88 @@
+package importers
+
+import (
+	"errors"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// lubeLoggerService
+// LubeLogger service and repair record exports, one file per vehicle:
+// Date,Odometer,Description,Notes,Cost,Tags
+type lubeLoggerService struct{}
+
+func (lubeLoggerService) Name() string { return "lubelogger-service" }
+
+func (lubeLoggerService) Detect(header map[string]bool) bool {
+	return hasAll(header, "date", "odometer", "description", "notes", "cost") && !header["fuelconsumed"]
+}
+
+func (lubeLoggerService) Record(row map[string]string, opts Options) (*models.TrackerRecord, error) {
+	date, err := parseDate(row["date"], opts)
+	if err != nil {
+		return nil, err
+	}
+	odometer, err := parseOdometer(row["odometer"])
+	if err != nil {
+		return nil, err
+	}
+	cost, err := parseNumber(row["cost"])
+	if err != nil {
+		return nil, err
+	}
+	if len(row["description"]) == 0 {
+		return nil, errors.New("Description is required")
+	}
+	return &models.TrackerRecord{
+		Kind:     "service",
+		Date:     date,
+		Odometer: odometer,
+		Name:     row["description"],
+		Tasks:    make([]string, 0),
+		Cost:     cost,
+		Notes:    optional(row["notes"]),
+	}, nil
+}
+
+// lubeLoggerGas
+// LubeLogger gas record exports, one file per vehicle:
+// Date,Odometer,FuelConsumed,Cost,FuelEconomy,IsFillToFull,MissedFuelUp,Notes,Tags
+type lubeLoggerGas struct{}
+
+func (lubeLoggerGas) Name() string { return "lubelogger-gas" }
+
+func (lubeLoggerGas) Detect(header map[string]bool) bool {
+	return hasAll(header, "date", "odometer", "fuelconsumed", "cost")
+}
+
+func (lubeLoggerGas) Record(row map[string]string, opts Options) (*models.TrackerRecord, error) {
+	date, err := parseDate(row["date"], opts)
+	if err != nil {
+		return nil, err
+	}
+	odometer, err := parseOdometer(row["odometer"])
+	if err != nil {
+		return nil, err
+	}
+	volume, err := parseNumber(row["fuelconsumed"])
+	if err != nil {
+		return nil, err
+	}
+	if volume == nil {
+		return nil, errors.New("FuelConsumed is required")
+	}
+	cost, err := parseNumber(row["cost"])
+	if err != nil {
+		return nil, err
+	}
+	return &models.TrackerRecord{
+		Kind:        "fuel",
+		Date:        date,
+		Odometer:    odometer,
+		Name:        "Fuel",
+		Tasks:       make([]string, 0),
+		Cost:        cost,
+		Notes:       optional(row["notes"]),
+		Fuel_volume: volume,
+	}, nil
+}
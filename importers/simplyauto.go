@@ -0,0 +1,49 @@
+package importers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// simplyAuto
+// Simply Auto service exports, all vehicles in one file, each service type becomes a task:
+// Vehicle,Date,Odometer,Service Type,Cost,Service Center,Notes
+type simplyAuto struct{}
+
+func (simplyAuto) Name() string { return "simplyauto" }
+
+func (simplyAuto) Detect(header map[string]bool) bool {
+	return hasAll(header, "vehicle", "date", "odometer", "servicetype")
+}
+
+func (simplyAuto) Record(row map[string]string, opts Options) (*models.TrackerRecord, error) {
+	date, err := parseDate(row["date"], opts)
+	if err != nil {
+		return nil, err
+	}
+	odometer, err := parseOdometer(row["odometer"])
+	if err != nil {
+		return nil, err
+	}
+	cost, err := parseNumber(row["cost"])
+	if err != nil {
+		return nil, err
+	}
+	tasks := splitList(row["servicetype"], ",;")
+	if len(tasks) == 0 {
+		return nil, errors.New("Service Type is required")
+	}
+	return &models.TrackerRecord{
+		Kind:     "service",
+		Vehicle:  row["vehicle"],
+		Date:     date,
+		Odometer: odometer,
+		Name:     strings.Join(tasks, ", "),
+		Tasks:    tasks,
+		Cost:     cost,
+		Shop:     optional(row["servicecenter"]),
+		Notes:    optional(row["notes"]),
+	}, nil
+}
@@ -1,11 +1,12 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -14,6 +15,8 @@ import (
 
 	"github.com/okdv/wrench-turn/controllers"
 	"github.com/okdv/wrench-turn/db"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
 	"github.com/okdv/wrench-turn/version"
 )
 
@@ -32,43 +35,48 @@ func main() {
 		log.Fatalf("Unable to load .env file: %v", err)
 	}
 
-	// check if db file exists
-	dbFilename := "./data/" + os.Getenv("DB_FILENAME")
-	if _, err := os.Stat(dbFilename); os.IsNotExist(err) {
-		// if not, create it
-		log.Printf("database file %v does not exist, running db setup... ", dbFilename)
-		file, err := os.Create(dbFilename)
-		if err != nil {
-			log.Fatal("failed to create database file")
-		}
-		file.Close()
-		// read sql from schema
-		sqlBytes, err := os.ReadFile("schema.sql")
-		if err != nil {
-			log.Fatalf("failed to read schema file: %v", err)
-		}
-		err = db.CreateDatabase(dbFilename, string(sqlBytes))
-		if err != nil {
-			log.Fatalf("failed to create database: %v", err)
-		}
-		log.Print("Will attempt to connect to db now...")
+	// connect to db, sqlite unless DB_DRIVER picks postgres
+	switch driver := os.Getenv("DB_DRIVER"); driver {
+	case "", "sqlite3":
+		connectSQLite("./data/" + os.Getenv("DB_FILENAME"))
+	case "postgres":
+		connectPostgres(os.Getenv("DATABASE_URL"))
+	default:
+		log.Fatalf("Unsupported DB_DRIVER %v, use sqlite3 or postgres", driver)
 	}
 
-	// connect to db
-	_, err = db.ConnectDatabase(dbFilename)
-	if err != nil {
-		log.Fatal("Unable to connect to SQLite Database")
-		return
+	// repositories injected into controllers
+	repo := db.NewRepositories()
+
+	// prune activity older than retention period, kept forever if unset
+	if days, err := strconv.Atoi(os.Getenv("AUDIT_RETENTION_DAYS")); err == nil && days > 0 {
+		go pruneAuditEvents(services.New(repo), days)
+	}
+	// purge trash older than retention period, kept forever if unset
+	if days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil && days > 0 {
+		go purgeTrash(services.New(repo), days)
 	}
 
 	// initiate controllers
-	authController := controllers.NewAuthController()
-	userController := controllers.NewUserController()
-	jobController := controllers.NewJobController()
-	taskController := controllers.NewTaskController()
-	vehicleController := controllers.NewVehicleController()
-	alertController := controllers.NewAlertController()
-	labelController := controllers.NewLabelController()
+	authController := controllers.NewAuthController(repo)
+	userController := controllers.NewUserController(repo)
+	jobController := controllers.NewJobController(repo)
+	taskController := controllers.NewTaskController(repo)
+	vehicleController := controllers.NewVehicleController(repo)
+	alertController := controllers.NewAlertController(repo)
+	labelController := controllers.NewLabelController(repo)
+	scheduleController := controllers.NewScheduleController(repo)
+	importerController := controllers.NewImporterController(repo)
+	calendarController := controllers.NewCalendarController(repo)
+	searchController := controllers.NewSearchController(repo)
+	documentController := controllers.NewDocumentController(repo)
+	fuelController := controllers.NewFuelController(repo)
+	auditController := controllers.NewAuditController(repo)
+	trashController := controllers.NewTrashController(repo)
+	commentController := controllers.NewCommentController(repo)
+	timeController := controllers.NewTimeController(repo)
+	eventController := controllers.NewEventController(repo)
+	openAPIController := controllers.NewOpenAPIController()
 
 	// initiate router
 	r := chi.NewRouter()
@@ -78,7 +86,8 @@ func main() {
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{os.Getenv("PUBLIC_FRONTEND_URL")},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "If-Match"},
+		ExposedHeaders:   []string{"ETag"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
@@ -98,18 +107,10 @@ func main() {
 			"NODE_ENV":            os.Getenv("NODE_ENV"),
 			"API_VERSION":         version.Version,
 		}
-		// convert into json response
-		jsonData, err := json.Marshal(envVars)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprint(w, "Unable to convert env to JSON response")
-			return
-		}
 		// respond with json
-		w.WriteHeader(http.StatusOK)
-		w.Header().Set("Content-Type", "application/json")
-		w.Write(jsonData)
+		response.JSON(w, http.StatusOK, envVars)
 	})
+	r.Get("/openapi.json", openAPIController.GetSpec)
 	// auth routes
 	r.Post("/auth", authController.Auth)
 	r.Get("/logout", authController.Logout)
@@ -121,43 +122,191 @@ func main() {
 	r.Delete("/users/{username}", authController.Verify(userController.DeleteUser))
 	r.Post("/users/create", userController.CreateUser)
 	r.Post("/users/edit", authController.Verify(userController.EditUser))
+	r.Patch("/users/{username}", authController.Verify(userController.PatchUser))
 	r.Post("/users/updatePassword", authController.Verify(userController.UpdatePassword))
+	r.Get("/users/{username}/export", authController.Verify(userController.ExportAccount))
+	r.Post("/users/{username}/import", authController.Verify(userController.ImportAccount))
 	// job routes
 	r.Get("/jobs", jobController.ListJobs)
 	r.Get("/jobs/{id:[0-9]+}", jobController.GetJob)
 	r.Post("/jobs/{jobId:[0-9]+}/assignLabel/{labelId:[0-9]+}", authController.Verify(jobController.AssignJobLabel))
 	r.Post("/jobs/create", authController.Verify(jobController.CreateJob))
+	r.Post("/jobs/import", authController.Verify(jobController.ImportJobs))
 	r.Post("/jobs/edit", authController.Verify(jobController.EditJob))
+	r.Patch("/jobs/{id:[0-9]+}", authController.Verify(jobController.PatchJob))
 	r.Delete("/jobs/{id:[0-9]+}", authController.Verify(jobController.DeleteJob))
+	r.Post("/jobs/bulk/delete", authController.Verify(jobController.BulkDeleteJobs))
+	r.Post("/jobs/bulk/assignLabel/{labelId:[0-9]+}", authController.Verify(jobController.BulkAssignJobLabel))
+	r.Post("/jobs/{id:[0-9]+}/status", authController.Verify(jobController.UpdateJobStatus))
+	r.Get("/jobs/{id:[0-9]+}/status/history", jobController.ListJobStatusHistory)
+	r.Get("/jobs/{id:[0-9]+}/activity", authController.Verify(jobController.ListJobActivity))
+	r.Get("/jobs/{id:[0-9]+}/complete", jobController.GetJobCompletion)
+	r.Post("/jobs/{id:[0-9]+}/complete", authController.Verify(jobController.CompleteJob))
+	r.Delete("/jobs/{id:[0-9]+}/complete", authController.Verify(jobController.UndoJobCompletion))
 	// task routes
 	r.Get("/jobs/{jobId:[0-9]+}/tasks", taskController.ListTasks)
 	r.Get("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}", taskController.GetTask)
 	r.Patch("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}/complete", authController.Verify(taskController.MarkComplete))
+	r.Post("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}/assignLabel/{labelId:[0-9]+}", authController.Verify(taskController.AssignTaskLabel))
 	r.Post("/jobs/{jobId:[0-9]+}/tasks/create", authController.Verify(taskController.CreateTask))
+	r.Post("/jobs/{jobId:[0-9]+}/tasks/import", authController.Verify(taskController.ImportTasks))
 	r.Post("/jobs/{jobId:[0-9]+}/tasks/edit", authController.Verify(taskController.EditTask))
+	r.Post("/jobs/{jobId:[0-9]+}/tasks/reorder", authController.Verify(taskController.ReorderTasks))
+	r.Patch("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}", authController.Verify(taskController.PatchTask))
 	r.Delete("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}", authController.Verify(taskController.DeleteTask))
 	r.Delete("/jobs/{jobId:[0-9]+}/tasks", authController.Verify(taskController.DeleteTask))
+	r.Post("/tasks/bulk/complete", authController.Verify(taskController.BulkMarkComplete))
+	// comment routes
+	r.Get("/jobs/{jobId:[0-9]+}/comments", authController.Verify(commentController.ListComments))
+	r.Get("/jobs/{jobId:[0-9]+}/comments/{commentId:[0-9]+}", authController.Verify(commentController.GetComment))
+	r.Post("/jobs/{jobId:[0-9]+}/comments/create", authController.Verify(commentController.CreateComment))
+	r.Post("/jobs/{jobId:[0-9]+}/comments/edit", authController.Verify(commentController.EditComment))
+	r.Delete("/jobs/{jobId:[0-9]+}/comments/{commentId:[0-9]+}", authController.Verify(commentController.DeleteComment))
+	r.Get("/jobs/{jobId:[0-9]+}/comments/{commentId:[0-9]+}/history", authController.Verify(commentController.ListCommentHistory))
+	// time tracking routes
+	r.Get("/timer", authController.Verify(timeController.GetRunningTimer))
+	r.Post("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}/timer/start", authController.Verify(timeController.StartTimer))
+	r.Post("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}/timer/stop", authController.Verify(timeController.StopTimer))
+	r.Get("/jobs/{jobId:[0-9]+}/time", authController.Verify(timeController.GetJobTime))
+	r.Get("/jobs/{jobId:[0-9]+}/time/entries", authController.Verify(timeController.ListTimeEntries))
+	r.Get("/reports/estimates", authController.Verify(timeController.GetEstimateReport))
+	// live update routes
+	r.Get("/events", authController.Verify(eventController.StreamEvents))
 	// vehicle routes
 	r.Get("/vehicles", vehicleController.ListVehicles)
 	r.Get("/vehicles/{id:[0-9]+}", vehicleController.GetVehicle)
+	r.Post("/vehicles/{vehicleId:[0-9]+}/assignLabel/{labelId:[0-9]+}", authController.Verify(vehicleController.AssignVehicleLabel))
 	r.Post("/vehicles/create", authController.Verify(vehicleController.CreateVehicle))
+	r.Post("/vehicles/import", authController.Verify(vehicleController.ImportVehicles))
 	r.Post("/vehicles/edit", authController.Verify(vehicleController.EditVehicle))
+	r.Patch("/vehicles/{id:[0-9]+}", authController.Verify(vehicleController.PatchVehicle))
 	r.Delete("/vehicles/{id:[0-9]+}", authController.Verify(vehicleController.DeleteVehicle))
+	r.Get("/vehicles/{id:[0-9]+}/odometer", vehicleController.ListOdometerReadings)
+	r.Get("/vehicles/{id:[0-9]+}/activity", authController.Verify(vehicleController.ListVehicleActivity))
+	r.Post("/vehicles/{id:[0-9]+}/odometer", authController.Verify(vehicleController.CreateOdometerReading))
+	r.Get("/vehicles/{id:[0-9]+}/report", vehicleController.GetReport)
+	// vehicle fuel log routes
+	r.Get("/vehicles/{vehicleId:[0-9]+}/fuel", authController.Verify(fuelController.ListFuelLogs))
+	r.Post("/vehicles/{vehicleId:[0-9]+}/fuel/import", authController.Verify(fuelController.ImportFuelLogs))
+	// vehicle document routes
+	r.Get("/vehicles/{vehicleId:[0-9]+}/documents", authController.Verify(documentController.ListDocuments))
+	r.Get("/vehicles/{vehicleId:[0-9]+}/documents/{documentId:[0-9]+}", authController.Verify(documentController.GetDocument))
+	r.Post("/vehicles/{vehicleId:[0-9]+}/documents/create", authController.Verify(documentController.CreateDocument))
+	r.Post("/vehicles/{vehicleId:[0-9]+}/documents/edit", authController.Verify(documentController.EditDocument))
+	r.Delete("/vehicles/{vehicleId:[0-9]+}/documents/{documentId:[0-9]+}", authController.Verify(documentController.DeleteDocument))
+	r.Get("/vehicles/{vehicleId:[0-9]+}/documents/{documentId:[0-9]+}/attachment", authController.Verify(documentController.GetAttachment))
+	r.Put("/vehicles/{vehicleId:[0-9]+}/documents/{documentId:[0-9]+}/attachment", authController.Verify(documentController.UploadAttachment))
+	r.Delete("/vehicles/{vehicleId:[0-9]+}/documents/{documentId:[0-9]+}/attachment", authController.Verify(documentController.DeleteAttachment))
 	// alert routes
 	r.Get("/alerts", authController.Verify(alertController.ListAlerts))
 	r.Get("/alerts/{id:[0-9]+}", authController.Verify(alertController.GetAlert))
 	r.Patch("/alerts/{id:[0-9]+}/read", authController.Verify(alertController.MarkRead))
+	r.Post("/alerts/bulk/read", authController.Verify(alertController.BulkMarkRead))
 	r.Post("/alerts/create", authController.Verify(alertController.CreateAlert))
 	r.Post("/alerts/edit", authController.Verify(alertController.EditAlert))
+	r.Patch("/alerts/{id:[0-9]+}", authController.Verify(alertController.PatchAlert))
 	r.Delete("/alerts/{id:[0-9]+}", authController.Verify(alertController.DeleteAlert))
 	// label routes
 	r.Get("/labels", labelController.ListLabels)
 	r.Get("/labels/{id:[0-9]+}", labelController.GetLabel)
 	r.Post("/labels/create", authController.Verify(labelController.CreateLabel))
 	r.Post("/labels/edit", authController.Verify(labelController.EditLabel))
+	r.Patch("/labels/{id:[0-9]+}", authController.Verify(labelController.PatchLabel))
 	r.Delete("/labels/{id:[0-9]+}", authController.Verify(labelController.DeleteLabel))
+	// schedule routes
+	r.Get("/schedules", scheduleController.ListSchedules)
+	r.Get("/schedules/{id:[0-9]+}", scheduleController.GetSchedule)
+	r.Post("/schedules/import", authController.Verify(scheduleController.ImportSchedule))
+	r.Post("/vehicles/{vehicleId:[0-9]+}/applySchedule/{scheduleId:[0-9]+}", authController.Verify(scheduleController.ApplySchedule))
+	r.Delete("/schedules/{id:[0-9]+}", authController.Verify(scheduleController.DeleteSchedule))
+	// importer routes
+	r.Get("/import/formats", importerController.ListFormats)
+	r.Post("/import/{format}", authController.Verify(importerController.Import))
+	// calendar routes
+	r.Get("/calendar/{token:[0-9a-f]+}.ics", calendarController.GetFeed)
+	r.Post("/users/{username}/calendar", authController.Verify(calendarController.CreateToken))
+	r.Delete("/users/{username}/calendar", authController.Verify(calendarController.DeleteToken))
+	// search routes
+	r.Get("/search", authController.Verify(searchController.Search))
+	// activity routes
+	r.Get("/activity", authController.Verify(auditController.ListActivity))
+	// trash routes
+	r.Get("/trash", authController.Verify(trashController.ListTrash))
+	r.Post("/trash/{type}/{id:[0-9]+}/restore", authController.Verify(trashController.RestoreTrash))
 	// serve router
 	log.Printf("Starting WrenchTurn server %v", version.Version)
 	log.Printf("WrenchTurn server listening on port %v", os.Getenv("PUBLIC_API_PORT"))
 	log.Fatal(http.ListenAndServe(":"+os.Getenv("PUBLIC_API_PORT"), r))
 }
+
+// connectSQLite
+// Creates the database file from schema.sql if it does not exist, then connects to it
+func connectSQLite(dbFilename string) {
+	// check if db file exists
+	if _, err := os.Stat(dbFilename); os.IsNotExist(err) {
+		// if not, create it
+		log.Printf("database file %v does not exist, running db setup... ", dbFilename)
+		file, err := os.Create(dbFilename)
+		if err != nil {
+			log.Fatal("failed to create database file")
+		}
+		file.Close()
+		// read sql from schema
+		sqlBytes, err := os.ReadFile("schema.sql")
+		if err != nil {
+			log.Fatalf("failed to read schema file: %v", err)
+		}
+		err = db.CreateDatabase(dbFilename, string(sqlBytes))
+		if err != nil {
+			log.Fatalf("failed to create database: %v", err)
+		}
+		log.Print("Will attempt to connect to db now...")
+	}
+
+	// connect to db
+	_, err := db.ConnectDatabase(dbFilename)
+	if err != nil {
+		log.Fatalf("Unable to connect to SQLite Database: %v", err)
+	}
+}
+
+// connectPostgres
+// Connects to the postgres database at url, creating its tables from schema.postgres.sql if it has none
+func connectPostgres(url string) {
+	sqlBytes, err := os.ReadFile("schema.postgres.sql")
+	if err != nil {
+		log.Fatalf("failed to read schema file: %v", err)
+	}
+	_, err = db.ConnectPostgres(url, string(sqlBytes))
+	if err != nil {
+		log.Fatalf("Unable to connect to Postgres Database: %v", err)
+	}
+}
+
+// pruneAuditEvents
+// Deletes audit events older than days on startup and once a day after
+func pruneAuditEvents(svc *services.Service, days int) {
+	for {
+		deleted, err := svc.PruneAuditEvents(days)
+		if err != nil {
+			log.Printf("Unable to prune activity: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Pruned %d activity events older than %d days", deleted, days)
+		}
+		time.Sleep(24 * time.Hour)
+	}
+}
+
+// purgeTrash
+// Permanently deletes everything trashed more than days ago on startup and once a day after
+func purgeTrash(svc *services.Service, days int) {
+	for {
+		purged, err := svc.PurgeTrash(days)
+		if err != nil {
+			log.Printf("Unable to purge trash: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d jobs, vehicles and users trashed over %d days ago", purged, days)
+		}
+		time.Sleep(24 * time.Hour)
+	}
+}
@@ -1,19 +1,34 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/okdv/wrench-turn/client"
 	"github.com/okdv/wrench-turn/controllers"
 	"github.com/okdv/wrench-turn/db"
 	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+	"github.com/okdv/wrench-turn/response"
+	"github.com/okdv/wrench-turn/services"
+	"github.com/okdv/wrench-turn/version"
 )
 
 var r *chi.Mux
@@ -28,47 +43,46 @@ var w *httptest.ResponseRecorder
 var testUsername string
 var testPassword string
 var jwtCookie *http.Cookie
+var svc *services.Service
 
 func TestMain(m *testing.M) {
-	// check if db file exists
-	dbFilename := "test.db"
-	if _, err := os.Stat(dbFilename); os.IsNotExist(err) {
-		// if not, create it
-		log.Printf("database file %v does not exist, running db setup... ", dbFilename)
-		file, err := os.Create(dbFilename)
-		if err != nil {
-			log.Fatal("failed to create database file")
-		}
-		file.Close()
-		// read sql from schema
-		sqlBytes, err := os.ReadFile("schema.sql")
-		if err != nil {
-			log.Fatalf("failed to read schema file: %v", err)
-		}
-		err = db.CreateDatabase(dbFilename, string(sqlBytes))
-		if err != nil {
-			log.Fatalf("failed to create database: %v", err)
-		}
-		log.Print("Will attempt to connect to db now...")
-	}
-	// test db connection
-	DB, err := db.ConnectDatabase(dbFilename)
-	if err != nil {
-		panic("Unable to open database: " + err.Error())
+	// run against postgres if TEST_DATABASE_URL is set, otherwise against a sqlite file
+	var DB *sql.DB
+	var cleanup func() error
+	if url := os.Getenv("TEST_DATABASE_URL"); len(url) > 0 {
+		DB, cleanup = connectTestPostgres(url)
+	} else {
+		DB, cleanup = connectTestSQLite("test.db")
 	}
 	log.Print("Successfully connected to database")
 	// declare router
 	r = chi.NewRouter()
+	// create repositories and service used by tests
+	repo := db.NewRepositories()
+	svc = services.New(repo)
 	// create controllers
-	userController := controllers.NewUserController()
-	authController := controllers.NewAuthController()
-	jobController := controllers.NewJobController()
-	taskController := controllers.NewTaskController()
-	vehicleController := controllers.NewVehicleController()
-	alertController := controllers.NewAlertController()
-	labelController := controllers.NewLabelController()
+	userController := controllers.NewUserController(repo)
+	authController := controllers.NewAuthController(repo)
+	jobController := controllers.NewJobController(repo)
+	taskController := controllers.NewTaskController(repo)
+	vehicleController := controllers.NewVehicleController(repo)
+	alertController := controllers.NewAlertController(repo)
+	labelController := controllers.NewLabelController(repo)
+	scheduleController := controllers.NewScheduleController(repo)
+	importerController := controllers.NewImporterController(repo)
+	calendarController := controllers.NewCalendarController(repo)
+	searchController := controllers.NewSearchController(repo)
+	documentController := controllers.NewDocumentController(repo)
+	fuelController := controllers.NewFuelController(repo)
+	auditController := controllers.NewAuditController(repo)
+	trashController := controllers.NewTrashController(repo)
+	commentController := controllers.NewCommentController(repo)
+	timeController := controllers.NewTimeController(repo)
+	eventController := controllers.NewEventController(repo)
+	openAPIController := controllers.NewOpenAPIController()
 
 	// create routes
+	r.Get("/openapi.json", openAPIController.GetSpec)
 	// auth routes
 	r.Post("/auth", authController.Auth)
 	r.Get("/verify", authController.Verify(authController.TestVerify))
@@ -79,40 +93,116 @@ func TestMain(m *testing.M) {
 	r.Post("/users/create", userController.CreateUser)
 	r.Delete("/users/{username}", authController.Verify(userController.DeleteUser))
 	r.Post("/users/edit", authController.Verify(userController.EditUser))
+	r.Patch("/users/{username}", authController.Verify(userController.PatchUser))
+	r.Get("/users/{username}/export", authController.Verify(userController.ExportAccount))
+	r.Post("/users/{username}/import", authController.Verify(userController.ImportAccount))
 	// job routes
 	r.Get("/jobs", jobController.ListJobs)
 	r.Get("/jobs/{id:[0-9]+}", jobController.GetJob)
 	r.Post("/jobs/{jobId:[0-9]+}/assignLabel/{labelId:[0-9]+}", authController.Verify(jobController.AssignJobLabel))
 	r.Post("/jobs/create", authController.Verify(jobController.CreateJob))
+	r.Post("/jobs/import", authController.Verify(jobController.ImportJobs))
 	r.Post("/jobs/edit", authController.Verify(jobController.EditJob))
+	r.Patch("/jobs/{id:[0-9]+}", authController.Verify(jobController.PatchJob))
 	r.Delete("/jobs/{id:[0-9]+}", authController.Verify(jobController.DeleteJob))
+	r.Post("/jobs/bulk/delete", authController.Verify(jobController.BulkDeleteJobs))
+	r.Post("/jobs/bulk/assignLabel/{labelId:[0-9]+}", authController.Verify(jobController.BulkAssignJobLabel))
+	r.Post("/jobs/{id:[0-9]+}/status", authController.Verify(jobController.UpdateJobStatus))
+	r.Get("/jobs/{id:[0-9]+}/status/history", jobController.ListJobStatusHistory)
+	r.Get("/jobs/{id:[0-9]+}/activity", authController.Verify(jobController.ListJobActivity))
+	r.Get("/jobs/{id:[0-9]+}/complete", jobController.GetJobCompletion)
+	r.Post("/jobs/{id:[0-9]+}/complete", authController.Verify(jobController.CompleteJob))
+	r.Delete("/jobs/{id:[0-9]+}/complete", authController.Verify(jobController.UndoJobCompletion))
 	// task routes
 	r.Get("/jobs/{jobId:[0-9]+}/tasks", taskController.ListTasks)
 	r.Get("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}", taskController.GetTask)
 	r.Patch("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}/complete", authController.Verify(taskController.MarkComplete))
+	r.Post("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}/assignLabel/{labelId:[0-9]+}", authController.Verify(taskController.AssignTaskLabel))
 	r.Post("/jobs/{jobId:[0-9]+}/tasks/create", authController.Verify(taskController.CreateTask))
+	r.Post("/jobs/{jobId:[0-9]+}/tasks/import", authController.Verify(taskController.ImportTasks))
 	r.Post("/jobs/{jobId:[0-9]+}/tasks/edit", authController.Verify(taskController.EditTask))
+	r.Post("/jobs/{jobId:[0-9]+}/tasks/reorder", authController.Verify(taskController.ReorderTasks))
+	r.Patch("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}", authController.Verify(taskController.PatchTask))
 	r.Delete("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}", authController.Verify(taskController.DeleteTask))
 	r.Delete("/jobs/{jobId:[0-9]+}/tasks", authController.Verify(taskController.DeleteTask))
+	r.Post("/tasks/bulk/complete", authController.Verify(taskController.BulkMarkComplete))
+	// comment routes
+	r.Get("/jobs/{jobId:[0-9]+}/comments", authController.Verify(commentController.ListComments))
+	r.Get("/jobs/{jobId:[0-9]+}/comments/{commentId:[0-9]+}", authController.Verify(commentController.GetComment))
+	r.Post("/jobs/{jobId:[0-9]+}/comments/create", authController.Verify(commentController.CreateComment))
+	r.Post("/jobs/{jobId:[0-9]+}/comments/edit", authController.Verify(commentController.EditComment))
+	r.Delete("/jobs/{jobId:[0-9]+}/comments/{commentId:[0-9]+}", authController.Verify(commentController.DeleteComment))
+	r.Get("/jobs/{jobId:[0-9]+}/comments/{commentId:[0-9]+}/history", authController.Verify(commentController.ListCommentHistory))
+	// time tracking routes
+	r.Get("/timer", authController.Verify(timeController.GetRunningTimer))
+	r.Post("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}/timer/start", authController.Verify(timeController.StartTimer))
+	r.Post("/jobs/{jobId:[0-9]+}/tasks/{taskId:[0-9]+}/timer/stop", authController.Verify(timeController.StopTimer))
+	r.Get("/jobs/{jobId:[0-9]+}/time", authController.Verify(timeController.GetJobTime))
+	r.Get("/jobs/{jobId:[0-9]+}/time/entries", authController.Verify(timeController.ListTimeEntries))
+	r.Get("/reports/estimates", authController.Verify(timeController.GetEstimateReport))
+	// live update routes
+	r.Get("/events", authController.Verify(eventController.StreamEvents))
 	// vehicle routes
 	r.Get("/vehicles", vehicleController.ListVehicles)
 	r.Get("/vehicles/{id:[0-9]+}", vehicleController.GetVehicle)
+	r.Post("/vehicles/{vehicleId:[0-9]+}/assignLabel/{labelId:[0-9]+}", authController.Verify(vehicleController.AssignVehicleLabel))
 	r.Post("/vehicles/create", authController.Verify(vehicleController.CreateVehicle))
+	r.Post("/vehicles/import", authController.Verify(vehicleController.ImportVehicles))
 	r.Post("/vehicles/edit", authController.Verify(vehicleController.EditVehicle))
+	r.Patch("/vehicles/{id:[0-9]+}", authController.Verify(vehicleController.PatchVehicle))
 	r.Delete("/vehicles/{id:[0-9]+}", authController.Verify(vehicleController.DeleteVehicle))
+	r.Get("/vehicles/{id:[0-9]+}/odometer", vehicleController.ListOdometerReadings)
+	r.Get("/vehicles/{id:[0-9]+}/activity", authController.Verify(vehicleController.ListVehicleActivity))
+	r.Post("/vehicles/{id:[0-9]+}/odometer", authController.Verify(vehicleController.CreateOdometerReading))
+	r.Get("/vehicles/{id:[0-9]+}/report", vehicleController.GetReport)
+	// vehicle fuel log routes
+	r.Get("/vehicles/{vehicleId:[0-9]+}/fuel", authController.Verify(fuelController.ListFuelLogs))
+	r.Post("/vehicles/{vehicleId:[0-9]+}/fuel/import", authController.Verify(fuelController.ImportFuelLogs))
+	// vehicle document routes
+	r.Get("/vehicles/{vehicleId:[0-9]+}/documents", authController.Verify(documentController.ListDocuments))
+	r.Get("/vehicles/{vehicleId:[0-9]+}/documents/{documentId:[0-9]+}", authController.Verify(documentController.GetDocument))
+	r.Post("/vehicles/{vehicleId:[0-9]+}/documents/create", authController.Verify(documentController.CreateDocument))
+	r.Post("/vehicles/{vehicleId:[0-9]+}/documents/edit", authController.Verify(documentController.EditDocument))
+	r.Delete("/vehicles/{vehicleId:[0-9]+}/documents/{documentId:[0-9]+}", authController.Verify(documentController.DeleteDocument))
+	r.Get("/vehicles/{vehicleId:[0-9]+}/documents/{documentId:[0-9]+}/attachment", authController.Verify(documentController.GetAttachment))
+	r.Put("/vehicles/{vehicleId:[0-9]+}/documents/{documentId:[0-9]+}/attachment", authController.Verify(documentController.UploadAttachment))
+	r.Delete("/vehicles/{vehicleId:[0-9]+}/documents/{documentId:[0-9]+}/attachment", authController.Verify(documentController.DeleteAttachment))
 	// alert routes
 	r.Get("/alerts", authController.Verify(alertController.ListAlerts))
 	r.Get("/alerts/{id:[0-9]+}", authController.Verify(alertController.GetAlert))
 	r.Patch("/alerts/{id:[0-9]+}/read", authController.Verify(alertController.MarkRead))
+	r.Post("/alerts/bulk/read", authController.Verify(alertController.BulkMarkRead))
 	r.Post("/alerts/create", authController.Verify(alertController.CreateAlert))
 	r.Post("/alerts/edit", authController.Verify(alertController.EditAlert))
+	r.Patch("/alerts/{id:[0-9]+}", authController.Verify(alertController.PatchAlert))
 	r.Delete("/alerts/{id:[0-9]+}", authController.Verify(alertController.DeleteAlert))
 	// label routes
 	r.Get("/labels", labelController.ListLabels)
 	r.Get("/labels/{id:[0-9]+}", labelController.GetLabel)
 	r.Post("/labels/create", authController.Verify(labelController.CreateLabel))
 	r.Post("/labels/edit", authController.Verify(labelController.EditLabel))
+	r.Patch("/labels/{id:[0-9]+}", authController.Verify(labelController.PatchLabel))
 	r.Delete("/labels/{id:[0-9]+}", authController.Verify(labelController.DeleteLabel))
+	// schedule routes
+	r.Get("/schedules", scheduleController.ListSchedules)
+	r.Get("/schedules/{id:[0-9]+}", scheduleController.GetSchedule)
+	r.Post("/schedules/import", authController.Verify(scheduleController.ImportSchedule))
+	r.Post("/vehicles/{vehicleId:[0-9]+}/applySchedule/{scheduleId:[0-9]+}", authController.Verify(scheduleController.ApplySchedule))
+	r.Delete("/schedules/{id:[0-9]+}", authController.Verify(scheduleController.DeleteSchedule))
+	// importer routes
+	r.Get("/import/formats", importerController.ListFormats)
+	r.Post("/import/{format}", authController.Verify(importerController.Import))
+	// calendar routes
+	r.Get("/calendar/{token:[0-9a-f]+}.ics", calendarController.GetFeed)
+	r.Post("/users/{username}/calendar", authController.Verify(calendarController.CreateToken))
+	r.Delete("/users/{username}/calendar", authController.Verify(calendarController.DeleteToken))
+	// search routes
+	r.Get("/search", authController.Verify(searchController.Search))
+	// activity routes
+	r.Get("/activity", authController.Verify(auditController.ListActivity))
+	// trash routes
+	r.Get("/trash", authController.Verify(trashController.ListTrash))
+	r.Post("/trash/{type}/{id:[0-9]+}/restore", authController.Verify(trashController.RestoreTrash))
 	// run tests
 	exitCode := m.Run()
 	// Close the database connection explicitly
@@ -122,13 +212,187 @@ func TestMain(m *testing.M) {
 		}
 	}
 	// Cleanup after all tests
-	if err := os.Remove(dbFilename); err != nil {
+	if err := cleanup(); err != nil {
 		panic(err)
 	}
 	os.Exit(exitCode)
 
 }
 
+// connectTestSQLite
+// Creates the sqlite test database from schema.sql and connects to it, returns the connection and a func removing the file
+func connectTestSQLite(dbFilename string) (*sql.DB, func() error) {
+	// check if db file exists
+	if _, err := os.Stat(dbFilename); os.IsNotExist(err) {
+		// if not, create it
+		log.Printf("database file %v does not exist, running db setup... ", dbFilename)
+		file, err := os.Create(dbFilename)
+		if err != nil {
+			log.Fatal("failed to create database file")
+		}
+		file.Close()
+		// read sql from schema
+		sqlBytes, err := os.ReadFile("schema.sql")
+		if err != nil {
+			log.Fatalf("failed to read schema file: %v", err)
+		}
+		err = db.CreateDatabase(dbFilename, string(sqlBytes))
+		if err != nil {
+			log.Fatalf("failed to create database: %v", err)
+		}
+		log.Print("Will attempt to connect to db now...")
+	}
+	// test db connection
+	DB, err := db.ConnectDatabase(dbFilename)
+	if err != nil {
+		panic("Unable to open database: " + err.Error())
+	}
+	return DB, func() error { return os.Remove(dbFilename) }
+}
+
+// connectTestPostgres
+// Creates a schema of its own in the postgres database at url from schema.postgres.sql and connects to it, returns the connection and a func dropping the schema
+func connectTestPostgres(url string) (*sql.DB, func() error) {
+	admin, err := sql.Open("postgres", url)
+	if err != nil {
+		panic("Unable to open database: " + err.Error())
+	}
+	schema := "wrenchturn_test_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if _, err = admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		panic("Unable to create test schema: " + err.Error())
+	}
+	// every connection in the pool uses the test schema
+	if !strings.Contains(url, "://") {
+		url += " search_path=" + schema
+	} else if strings.Contains(url, "?") {
+		url += "&search_path=" + schema
+	} else {
+		url += "?search_path=" + schema
+	}
+	sqlBytes, err := os.ReadFile("schema.postgres.sql")
+	if err != nil {
+		log.Fatalf("failed to read schema file: %v", err)
+	}
+	DB, err := db.ConnectPostgres(url, string(sqlBytes))
+	if err != nil {
+		panic("Unable to open database: " + err.Error())
+	}
+	return DB, func() error {
+		defer admin.Close()
+		_, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		return err
+	}
+}
+
+// TestMigrateDatabase
+// Tests a database created by the first release schema is migrated in place and its rows read like new ones
+func TestMigrateDatabase(t *testing.T) {
+	if db.Driver() != "sqlite3" {
+		t.Skip("example database is sqlite")
+	}
+	// copy the example database, created by the first release schema
+	exampleBytes, err := os.ReadFile("example.db")
+	if err != nil {
+		t.Fatalf("Error reading example database: %v", err)
+	}
+	migrateFilename := t.TempDir() + "/migrate.db"
+	if err = os.WriteFile(migrateFilename, exampleBytes, 0600); err != nil {
+		t.Fatalf("Error copying example database: %v", err)
+	}
+	conn, err := sql.Open("sqlite3", migrateFilename)
+	if err != nil {
+		t.Fatalf("Error opening example database: %v", err)
+	}
+	defer conn.Close()
+	// rows written before the migration
+	_, err = conn.Exec("INSERT INTO user (id, username) VALUES (1, 'old_user'); INSERT INTO job (id, name, user, is_complete) VALUES (1, 'Old job', 1, 1), (2, 'Open job', 1, 0); INSERT INTO task (id, name, job) VALUES (1, 'First', 2), (2, 'Second', 2)")
+	if err != nil {
+		t.Fatalf("Error inserting rows: %v", err)
+	}
+	if err = db.MigrateDatabase(conn); err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	// running again is a no-op
+	if err = db.MigrateDatabase(conn); err != nil {
+		t.Fatalf("Error migrating database twice: %v", err)
+	}
+	// every column the queries select must exist
+	for table, columns := range map[string][]string{
+		"job":              {"status", "due_odometer", "deleted_at"},
+		"job_completion":   {"odometer", "cost"},
+		"odometer_reading": {"odometer", "recorded_at"},
+		"schedule":         {"make", "year_min"},
+		"schedule_job":     {"schedule", "job"},
+		"vehicle_document": {"expires_at", "attachment"},
+		"calendar_token":   {"token"},
+		"audit_event":      {"entity_id", "diff"},
+		"user":             {"deleted_at"},
+		"vehicle":          {"deleted_at"},
+		"task":             {"deleted_at", "parent", "position", "estimated_minutes"},
+		"alert":            {"updated_at", "deleted_at"},
+		"label":            {"updated_at", "deleted_at"},
+		"comment":          {"body", "updated_at"},
+		"comment_revision": {"comment", "body"},
+		"task_dependency":  {"task", "depends_on"},
+		"time_entry":       {"started_at", "stopped_at"},
+		"task_label":       {"task", "label"},
+		"vehicle_label":    {"vehicle", "label"},
+		"fuel_log":         {"filled_at", "volume", "cost"},
+	} {
+		for _, column := range columns {
+			var exists bool
+			if err = conn.QueryRow("SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name=?", table, column).Scan(&exists); err != nil || !exists {
+				t.Errorf("Expected column %s.%s after migration, got %v", table, column, err)
+			}
+		}
+	}
+	// rows written before the migration read through the regular queries, with a status matching is_complete
+	previous := db.DB
+	db.DB = conn
+	defer func() { db.DB = previous }()
+	for id, status := range map[int64]string{1: "done", 2: "planned"} {
+		job, err := db.GetJob(id)
+		if err != nil {
+			t.Fatalf("Error getting migrated job: %v", err)
+		}
+		if job.Status != status || job.Name == "" {
+			t.Errorf("Expected migrated job %d status %s, got %+v", id, status, job)
+		}
+	}
+	if user, err := db.GetUserById(1); err != nil || user.Username != "old_user" {
+		t.Errorf("Expected migrated user, got %+v, %v", user, err)
+	}
+	// existing tasks keep their order
+	for id, position := range map[int64]int{1: 0, 2: 1} {
+		task, err := db.GetTask(2, id)
+		if err != nil {
+			t.Fatalf("Error getting migrated task: %v", err)
+		}
+		if task.Position != position {
+			t.Errorf("Expected migrated task %d at position %d, got %d", id, position, task.Position)
+		}
+	}
+}
+
+// TestRerunMigrations
+// Tests every migration runs again on a database created from the current schema, with either driver, and leaves it at the latest version
+func TestRerunMigrations(t *testing.T) {
+	resetVersion, getVersion := "PRAGMA user_version=0", "PRAGMA user_version"
+	if db.Driver() == "postgres" {
+		resetVersion, getVersion = "UPDATE schema_version SET version=0", "SELECT version FROM schema_version"
+	}
+	if _, err := db.DB.Exec(resetVersion); err != nil {
+		t.Fatalf("Error resetting schema version: %v", err)
+	}
+	if err := db.MigrateDatabase(db.DB); err != nil {
+		t.Fatalf("Error rerunning migrations: %v", err)
+	}
+	var version int
+	if err := db.DB.QueryRow(getVersion).Scan(&version); err != nil || version == 0 {
+		t.Errorf("Expected schema version to be restored, got %d: %v", version, err)
+	}
+}
+
 // TestCreateUser
 // Tests creating a new user using default credentials and user controller
 func TestCreateUser(t *testing.T) {
@@ -411,6 +675,179 @@ func TestGetAndEditVehicle(t *testing.T) {
 	log.Print("Successfully edited vehicle")
 }
 
+// TestImportAndApplySchedule
+// Tests importing a maintenance schedule and applying it to vehicle created by TestCreateVehicle
+func TestImportAndApplySchedule(t *testing.T) {
+	// setup schedule file for a different make than the test vehicle
+	scheduleFile := []byte(`{
+		"name": "wrench-turn go test schedule",
+		"make": "Honda",
+		"yearMin": 2016,
+		"yearMax": 2021,
+		"items": [
+			{"name": "Oil change", "odoInterval": 5000, "timeInterval": 6, "timeIntervalUnit": "month", "tasks": [{"name": "Drain oil"}, {"name": "Replace filter"}]},
+			{"name": "Rotate tires", "odoInterval": 7500}
+		]
+	}`)
+	// import via api
+	req = httptest.NewRequest("POST", "/schedules/import", bytes.NewReader(scheduleFile))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expted status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	// error if unable to decode response
+	var schedule *models.Schedule
+	if err := json.NewDecoder(w.Body).Decode(&schedule); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	// error if each item did not become a template job
+	if len(schedule.Jobs) != 2 || schedule.Jobs[0].Is_template != 1 {
+		t.Errorf("Expected 2 template jobs, got %v", schedule.Jobs)
+	}
+	log.Print("Successfully imported schedule")
+	// apply via api, should conflict since test vehicle has no make
+	applyUrl := "/vehicles/" + strconv.FormatInt(createdVehicle.ID, 10) + "/applySchedule/" + strconv.FormatInt(schedule.ID, 10)
+	req = httptest.NewRequest("POST", applyUrl, nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expted status code %d, got %d", http.StatusConflict, w.Code)
+	}
+	// force apply via api
+	req = httptest.NewRequest("POST", applyUrl+"?force=true", nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expted status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	// error if unable to decode response
+	var jobs []*models.Job
+	if err := json.NewDecoder(w.Body).Decode(&jobs); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	// error if jobs are not recurring jobs on the vehicle
+	if len(jobs) != 2 || jobs[0].Repeats != 1 || jobs[0].Vehicle == nil || *jobs[0].Vehicle != createdVehicle.ID || jobs[0].Due_date == nil {
+		t.Errorf("Applied jobs are not recurring jobs on vehicle: %v", jobs)
+	}
+	log.Print("Successfully applied schedule")
+	// delete via api
+	req = httptest.NewRequest("DELETE", "/schedules/"+strconv.FormatInt(schedule.ID, 10), nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	log.Print("Successfully deleted schedule")
+}
+
+// TestVehicleDocuments
+// Tests creating a vehicle document with an expiry reminder, attaching a file, editing and deleting it
+func TestVehicleDocuments(t *testing.T) {
+	documentsUrl := "/vehicles/" + strconv.FormatInt(createdVehicle.ID, 10) + "/documents"
+	// setup new test document expiring in 60 days
+	number := "ABC-123"
+	expiresAt := time.Now().UTC().AddDate(0, 0, 60).Truncate(time.Second)
+	newDocument := &models.NewDocument{
+		Type:       "registration",
+		Number:     &number,
+		Expires_at: &expiresAt,
+	}
+	// convert to json
+	jsonData, err := json.Marshal(newDocument)
+	if err != nil {
+		t.Errorf("Error encoding request body: %v", err)
+	}
+	// create via api
+	req = httptest.NewRequest("POST", documentsUrl+"/create", bytes.NewReader(jsonData))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expted status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	// error if unable to decode response
+	var document *models.Document
+	if err := json.NewDecoder(w.Body).Decode(&document); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	// error if no reminder was generated
+	if document.Alert == nil {
+		t.Fatal("No reminder alert created for expiring document")
+	}
+	alert, err := svc.GetAlert(*document.Alert)
+	if err != nil || alert.Type != "reminder" || alert.Alert_at == nil || !alert.Alert_at.Equal(expiresAt.AddDate(0, 0, -30)) {
+		t.Errorf("Reminder alert not scheduled 30 days before expiry: %v %v", alert, err)
+	}
+	log.Print("Successfully created document")
+	// upload attachment via api
+	documentUrl := documentsUrl + "/" + strconv.FormatInt(document.ID, 10)
+	req = httptest.NewRequest("PUT", documentUrl+"/attachment?name=registration.txt", bytes.NewReader([]byte("registration card")))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// download attachment via api
+	req = httptest.NewRequest("GET", documentUrl+"/attachment", nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if attachment does not round trip
+	if w.Code != http.StatusOK || w.Body.String() != "registration card" {
+		t.Errorf("Expted attachment to be returned, got %d %v", w.Code, w.Body.String())
+	}
+	log.Print("Successfully attached file to document")
+	// move expiry and reminder window
+	expiresAt = expiresAt.AddDate(1, 0, 0)
+	document.Expires_at = &expiresAt
+	document.Remind_days = 14
+	jsonData, err = json.Marshal(document)
+	if err != nil {
+		t.Errorf("Error encoding request body: %v", err)
+	}
+	// edit via api
+	req = httptest.NewRequest("POST", documentsUrl+"/edit", bytes.NewReader(jsonData))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// error if reminder was not moved
+	alert, err = svc.GetAlert(*document.Alert)
+	if err != nil || !alert.Alert_at.Equal(expiresAt.AddDate(0, 0, -14)) {
+		t.Errorf("Reminder alert not moved with expiry: %v %v", alert, err)
+	}
+	log.Print("Successfully edited document")
+	// delete via api
+	req = httptest.NewRequest("DELETE", documentUrl, nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// error if reminder still exists
+	if _, err := svc.GetAlert(*document.Alert); err == nil {
+		t.Error("Reminder alert was not deleted with document")
+	}
+	log.Print("Successfully deleted document")
+}
+
 // TestCreateJob
 // Tests createing a job with user created by TestCreateUser
 func TestCreateJob(t *testing.T) {
@@ -594,95 +1031,2318 @@ func TestGetAndEditJob(t *testing.T) {
 	log.Print("Successfully edited job")
 }
 
-// TestGetAndEditLabel
-// Tests getting and editing label created by TestCreateLabel
-func TestGetAndEditLabel(t *testing.T) {
-	// get from api
-	req = httptest.NewRequest("GET", "/labels/"+strconv.FormatInt(createdLabel.ID, 10), nil)
+// TestJobStatusWorkflow
+// Tests moving job created by TestCreateJob through status changes, filtering by status and reading its history
+func TestJobStatusWorkflow(t *testing.T) {
+	statusUrl := "/jobs/" + strconv.FormatInt(createdJob.ID, 10) + "/status"
+	var job *models.Job
+	// move job through workflow via api
+	for _, status := range []string{"parts_ordered", "in_progress", "done"} {
+		req = httptest.NewRequest("POST", statusUrl, bytes.NewReader([]byte(`{"status": "`+status+`"}`)))
+		req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		// error if unexpected HTTP status
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expted status code %d moving to %v, got %d", http.StatusOK, status, w.Code)
+		}
+		// error if unable to decode response
+		if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+			t.Fatalf("Error decoding response body: %v", err)
+		}
+	}
+	// error if done job is not complete
+	if job.Status != "done" || job.Is_complete != 1 || job.Completed_at == nil {
+		t.Errorf("Done job should be complete with completed_at set, got %v %v %v", job.Status, job.Is_complete, job.Completed_at)
+	}
+	log.Print("Successfully moved job through status workflow")
+	// done jobs cannot be skipped
+	req = httptest.NewRequest("POST", statusUrl, bytes.NewReader([]byte(`{"status": "skipped"}`)))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, req)
-
 	// error if unexpected HTTP status
-	if w.Code != http.StatusOK {
-		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expted status code %d, got %d", http.StatusConflict, w.Code)
 	}
+	// list done jobs via api
+	req = httptest.NewRequest("GET", "/jobs?status=done,skipped", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
 	// error if unable to decode response
-	var fetchedLabel *models.Label
-	if err := json.NewDecoder(w.Body).Decode(&fetchedLabel); err != nil {
+	var jobs []*models.Job
+	if err := json.NewDecoder(w.Body).Decode(&jobs); err != nil {
 		t.Errorf("Error decoding response body: %v", err)
 	}
-
-	// error if returned label ID is not the same as created label ID
-	if fetchedLabel.ID != createdLabel.ID {
-		t.Errorf("Label ID %d fetched a different label, ID %d, than expected", createdLabel.ID, fetchedLabel.ID)
-	}
-	log.Print("Successfully retrieved test label")
-	// change label description
-	testColor := "0400ff"
-	fetchedLabel.Color = &testColor
-	// convert to json
-	jsonData, err := json.Marshal(fetchedLabel)
-	if err != nil {
-		t.Errorf("Error encoding request body: %v", err)
+	// error if job missing from filtered list
+	if len(jobs) != 1 || jobs[0].ID != createdJob.ID {
+		t.Errorf("Expected only job ID %d with done status, got %v", createdJob.ID, jobs)
 	}
-	// edit via post req
-	req = httptest.NewRequest("POST", "/labels/edit", bytes.NewReader(jsonData))
-	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	// get history via api
+	req = httptest.NewRequest("GET", statusUrl+"/history", nil)
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, req)
-	// error if unexpected HTTP status
-	if w.Code != http.StatusOK {
-		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
-	}
-	// decode body from json or error
-	if err := json.NewDecoder(w.Body).Decode(&fetchedLabel); err != nil {
+	// error if unable to decode response
+	var history []*models.JobStatusHistory
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
 		t.Errorf("Error decoding response body: %v", err)
 	}
-	// compare dscriptions to confirm successful edit
-	if fetchedLabel.Color != &testColor {
-		t.Error("Color was not updated")
+	// error if history does not have creation and each change, made by test user
+	if len(history) != 4 || history[3].To_status != "done" || history[3].User == nil || *history[3].User != createdUser.ID {
+		t.Errorf("Unexpected job status history: %v", history)
 	}
-	log.Print("Successfully edited label")
+	log.Print("Successfully retrieved job status history")
 }
 
-// TestCreateTask
-// Test creating task on job created by TestCreateJob
-func TestCreateTask(t *testing.T) {
-	// setuop new test job
-	newTask := &models.NewTask{
-		Name: "wrench-turn go test task",
+// TestCompleteAndUndoJob
+// Tests completing a recurring job on vehicle created by TestCreateVehicle with odometer and shop details, then undoing it
+func TestCompleteAndUndoJob(t *testing.T) {
+	// create recurring job on test vehicle
+	odoInterval := int64(5000)
+	timeInterval := int64(6)
+	timeIntervalUnit := "month"
+	repeats := 1
+	job, err := svc.CreateJob(models.NewJob{
+		Name:               "wrench-turn go test recurring job",
+		Vehicle:            &createdVehicle.ID,
+		User:               &createdUser.ID,
+		Repeats:            &repeats,
+		Odo_interval:       &odoInterval,
+		Time_interval:      &timeInterval,
+		Time_interval_unit: &timeIntervalUnit,
+	})
+	if err != nil {
+		t.Fatalf("Error creating recurring job: %v", err)
 	}
-	// convert to json
-	jsonData, err := json.Marshal(newTask)
+	drainTask, _ := svc.CreateTask(models.NewTask{Name: "Drain oil"}, job.ID)
+	_, err = svc.CreateTask(models.NewTask{Name: "Replace drain plug washer", Parent: &drainTask.ID, Depends_on: []int64{drainTask.ID}}, job.ID)
 	if err != nil {
-		t.Errorf("Error encoding request body: %v", err)
+		t.Fatalf("Error creating recurring job tasks: %v", err)
 	}
-	log.Println(createdJob.User)
-	log.Println(createdUser.ID)
-	// create via api
-	req = httptest.NewRequest("POST", "/jobs/"+strconv.FormatInt(createdJob.ID, 10)+"/tasks/create", bytes.NewReader(jsonData))
+	completeUrl := "/jobs/" + strconv.FormatInt(job.ID, 10) + "/complete"
+	// shop completions need a shop name
+	req = httptest.NewRequest("POST", completeUrl, bytes.NewReader([]byte(`{"performedBy": "shop"}`)))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expted status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	// complete via api, backdated
+	req = httptest.NewRequest("POST", completeUrl, bytes.NewReader([]byte(`{
+		"odometer": 120000,
+		"completedAt": "2024-01-15T10:00:00Z",
+		"performedBy": "shop",
+		"shop": "Corner Garage",
+		"notes": "Used OEM filter",
+		"cost": 89.5
+	}`)))
 	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	// error if unexpected HTTP status
 	if w.Code != http.StatusCreated {
-		t.Errorf("Expted status code %d, got %d", http.StatusCreated, w.Code)
+		t.Fatalf("Expted status code %d, got %d", http.StatusCreated, w.Code)
 	}
 	// error if unable to decode response
-	if err := json.NewDecoder(w.Body).Decode(&createdTask); err != nil {
+	var completion *models.JobCompletion
+	if err := json.NewDecoder(w.Body).Decode(&completion); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	// error if completion details were not kept
+	if completion.Performed_by != "shop" || completion.Shop == nil || *completion.Shop != "Corner Garage" || completion.Prior_status != "planned" || completion.Next_job == nil {
+		t.Fatalf("Unexpected job completion: %+v", completion)
+	}
+	// error if job was not marked done at backdated time
+	job, _ = svc.GetJob(job.ID)
+	if job.Status != "done" || job.Completed_at == nil || job.Completed_at.Year() != 2024 {
+		t.Errorf("Job should be done with backdated completed_at, got %v %v", job.Status, job.Completed_at)
+	}
+	// error if next occurrence is not due one interval after completion
+	nextJob, err := svc.GetJob(*completion.Next_job)
+	if err != nil {
+		t.Fatalf("Error getting next job: %v", err)
+	}
+	if nextJob.Due_odometer == nil || *nextJob.Due_odometer != 125000 || nextJob.Due_date == nil || nextJob.Due_date.Month() != time.July {
+		t.Errorf("Next job should be due at 125000 and in July, got %v %v", nextJob.Due_odometer, nextJob.Due_date)
+	}
+	// error if tasks were not copied with their links
+	nextTasks, _ := svc.ListTasks(nextJob.ID, nil, nil, nil, nil)
+	if len(nextTasks) != 2 || nextTasks[1].Parent == nil || *nextTasks[1].Parent != nextTasks[0].ID || len(nextTasks[1].Depends_on) != 1 || nextTasks[1].Depends_on[0] != nextTasks[0].ID {
+		t.Errorf("Next job should have copied sub-task linked to copied parent, got %v", nextTasks)
+	}
+	// error if vehicle odometer was not updated or recorded in its history
+	vehicle, _ := svc.GetVehicle(createdVehicle.ID)
+	if vehicle.Odometer == nil || *vehicle.Odometer != 120000 {
+		t.Errorf("Vehicle odometer should be 120000, got %v", vehicle.Odometer)
+	}
+	req = httptest.NewRequest("GET", "/vehicles/"+strconv.FormatInt(createdVehicle.ID, 10)+"/odometer", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var readings []*models.OdometerReading
+	if err := json.NewDecoder(w.Body).Decode(&readings); err != nil {
 		t.Errorf("Error decoding response body: %v", err)
 	}
-	// create second task to test deletion workflow when job is deleted
-	// create via api
-	req = httptest.NewRequest("POST", "/jobs/"+strconv.FormatInt(createdJob.ID, 10)+"/tasks/create", bytes.NewReader(jsonData))
+	if len(readings) != 1 || readings[0].Source != "completion" || readings[0].Odometer != 120000 {
+		t.Errorf("Unexpected odometer readings: %v", readings)
+	}
+	log.Print("Successfully completed job")
+	// error if an undo failing part way through is not rolled back
+	missingJobId := int64(0)
+	failedUndo := *completion
+	failedUndo.Next_job = &missingJobId
+	if err := db.UndoJobCompletion(failedUndo, &createdVehicle.ID, true, true, &createdUser.ID); err == nil {
+		t.Fatal("Undo trashing a missing next job should fail")
+	}
+	job, _ = svc.GetJob(job.ID)
+	vehicle, _ = svc.GetVehicle(createdVehicle.ID)
+	if job.Status != "done" || vehicle.Odometer == nil || *vehicle.Odometer != 120000 {
+		t.Errorf("Failed undo should leave job done at 120000, got %v %v", job.Status, vehicle.Odometer)
+	}
+	if latest, err := svc.GetJobCompletion(job.ID); err != nil || latest.ID != completion.ID {
+		t.Errorf("Failed undo should keep completion ID %d, got %v %v", completion.ID, latest, err)
+	}
+	// undo via api
+	req = httptest.NewRequest("DELETE", completeUrl, nil)
 	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	// error if unexpected HTTP status
-	if w.Code != http.StatusCreated {
-		t.Errorf("Expted status code %d, got %d", http.StatusCreated, w.Code)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
 	}
-	log.Print("Successfully created task")
+	if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+		t.Errorf("Error decoding response body: %v", err)
+	}
+	// error if prior state was not restored
+	if job.Status != "planned" || job.Is_complete != 0 || job.Completed_at != nil {
+		t.Errorf("Job should be planned again, got %v %v %v", job.Status, job.Is_complete, job.Completed_at)
+	}
+	if _, err := svc.GetJob(*completion.Next_job); err == nil {
+		t.Error("Next job should have been deleted")
+	}
+	vehicle, _ = svc.GetVehicle(createdVehicle.ID)
+	if vehicle.Odometer != nil {
+		t.Errorf("Vehicle odometer should be restored, got %v", *vehicle.Odometer)
+	}
+	// undoing twice conflicts
+	req = httptest.NewRequest("DELETE", completeUrl, nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expted status code %d, got %d", http.StatusConflict, w.Code)
+	}
+	log.Print("Successfully undid job completion")
+}
+
+// TestVehicleReport
+// Tests service history report of vehicle created by TestCreateVehicle as HTML and PDF, with date and label filters
+func TestVehicleReport(t *testing.T) {
+	// create and complete job on test vehicle
+	job, err := svc.CreateJob(models.NewJob{
+		Name:    "wrench-turn go test <report> job",
+		Vehicle: &createdVehicle.ID,
+		User:    &createdUser.ID,
+	})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	odometer := int64(42000)
+	cost := 125.0
+	completedAt := time.Date(2023, time.March, 3, 12, 0, 0, 0, time.UTC)
+	_, err = svc.CompleteJob(job.ID, models.NewJobCompletion{Odometer: &odometer, Cost: &cost, Completed_at: &completedAt}, createdUser.ID)
+	if err != nil {
+		t.Fatalf("Error completing job: %v", err)
+	}
+	reportUrl := "/vehicles/" + strconv.FormatInt(createdVehicle.ID, 10) + "/report"
+	// get html from api
+	req = httptest.NewRequest("GET", reportUrl, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// error if job is missing or not escaped
+	body := w.Body.String()
+	if !strings.Contains(body, "&lt;report&gt; job") || !strings.Contains(body, "2023-03-03") || !strings.Contains(body, "125.00") {
+		t.Errorf("HTML report is missing completed job: %v", body)
+	}
+	log.Print("Successfully retrieved HTML report")
+	// get pdf from api
+	req = httptest.NewRequest("GET", reportUrl+"?format=pdf", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if not a pdf containing the job
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("Expted status code %d with pdf, got %d %v", http.StatusOK, w.Code, w.Header().Get("Content-Type"))
+	}
+	body = w.Body.String()
+	if !strings.HasPrefix(body, "%PDF-") || !strings.Contains(body, "<report> job") || !strings.HasSuffix(body, "%%EOF\n") {
+		t.Errorf("PDF report is missing completed job")
+	}
+	log.Print("Successfully retrieved PDF report")
+	// error if filters do not exclude job
+	for _, query := range []string{"?from=2023-03-04", "?to=2023-03-02", "?label=" + strconv.FormatInt(createdLabel.ID, 10)} {
+		req = httptest.NewRequest("GET", reportUrl+query, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "&lt;report&gt; job") {
+			t.Errorf("Report filtered by %v should not include job, got %d", query, w.Code)
+		}
+	}
+	// error if invalid filter accepted
+	req = httptest.NewRequest("GET", reportUrl+"?from=March", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expted status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	log.Print("Successfully filtered report")
+}
+
+// TestExportAndImportAccount
+// Tests exporting account of user created by TestCreateUser and importing it into a second user, with a dry run and label name conflict
+func TestExportAndImportAccount(t *testing.T) {
+	// label job so relationships are exported
+	_, err := svc.AssignJobLabel(createdJob.ID, createdLabel.ID, 1)
+	if err != nil {
+		t.Fatalf("Error assigning label: %v", err)
+	}
+	defer svc.AssignJobLabel(createdJob.ID, createdLabel.ID, 0)
+	// export via api
+	req = httptest.NewRequest("GET", "/users/"+createdUser.Username+"/export", nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	exportData := w.Body.Bytes()
+	// error if unable to decode response
+	var export models.AccountExport
+	if err := json.Unmarshal(exportData, &export); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	// error if export is missing data
+	if export.Version != services.AccountExportVersion || len(export.Vehicles) == 0 || len(export.Jobs) == 0 || len(export.Tasks) == 0 || len(export.Labels) == 0 {
+		t.Fatalf("Export is missing data: %d vehicles, %d jobs, %d tasks, %d labels", len(export.Vehicles), len(export.Jobs), len(export.Tasks), len(export.Labels))
+	}
+	log.Print("Successfully exported account")
+	// setup second user with a label named like the exported one
+	importPassword := "Password123"
+	importUser, err := svc.CreateUser(models.NewUser{Username: "wrench-turn_go_test_import", Password: &importPassword})
+	if err != nil {
+		t.Fatalf("Error creating user: %v", err)
+	}
+	existingLabel, err := svc.CreateLabel(models.NewLabel{Name: createdLabel.Name, Color: createdLabel.Color, User: &importUser.ID})
+	if err != nil {
+		t.Fatalf("Error creating label: %v", err)
+	}
+	importUrl := "/users/" + importUser.Username + "/import"
+	importUserIdStr := strconv.FormatInt(importUser.ID, 10)
+	// dry run via api
+	req = httptest.NewRequest("POST", importUrl+"?dryRun=true", bytes.NewReader(exportData))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var result models.ImportResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	// error if dry run reports wrong changes or made any
+	if !result.Dry_run || result.Created["jobs"] != len(export.Jobs) || result.Created["labels"] != len(export.Labels)-1 {
+		t.Errorf("Unexpected dry run result: %+v", result)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Existing != existingLabel.ID || result.Conflicts[0].Resolution != "merged" {
+		t.Errorf("Expected label conflict to be merged: %+v", result.Conflicts)
+	}
+	if vehicles, _ := svc.ListVehicles(&importUserIdStr, nil, nil, nil, nil); len(vehicles) != 0 {
+		t.Errorf("Dry run should not create vehicles, found %d", len(vehicles))
+	}
+	log.Print("Successfully dry ran import")
+	// error if an import failing part way through leaves anything behind
+	if len(export.Tasks) == 0 {
+		t.Fatal("Export should have tasks")
+	}
+	createTrigger := []string{"CREATE TRIGGER import_test_fail BEFORE INSERT ON task BEGIN SELECT RAISE(ABORT, 'import test'); END"}
+	dropTrigger := "DROP TRIGGER import_test_fail"
+	if db.Driver() == "postgres" {
+		createTrigger = []string{
+			"CREATE FUNCTION import_test_fail() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'import test' USING ERRCODE = 'integrity_constraint_violation'; END $$ LANGUAGE plpgsql",
+			"CREATE TRIGGER import_test_fail BEFORE INSERT ON task FOR EACH ROW EXECUTE FUNCTION import_test_fail()",
+		}
+		dropTrigger = "DROP FUNCTION import_test_fail() CASCADE"
+	}
+	for _, stmt := range createTrigger {
+		if _, err := db.DB.Exec(stmt); err != nil {
+			t.Fatalf("Error creating trigger: %v", err)
+		}
+	}
+	req = httptest.NewRequest("POST", importUrl, bytes.NewReader(exportData))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if _, err := db.DB.Exec(dropTrigger); err != nil {
+		t.Fatalf("Error dropping trigger: %v", err)
+	}
+	// aborted by a trigger, reported as a constraint conflict
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expted status code %d, got %d", http.StatusConflict, w.Code)
+	}
+	vehicles, _ := svc.ListVehicles(&importUserIdStr, nil, nil, nil, nil)
+	jobs, _ := svc.ListJobs(&importUserIdStr, nil, nil, nil, nil, nil, nil, nil, nil)
+	labels, _ := svc.ListLabels(&importUserIdStr, nil, nil, nil, nil)
+	if len(vehicles) != 0 || len(jobs) != 0 || len(labels) != 1 {
+		t.Errorf("Failed import should create nothing, found %d vehicles, %d jobs and %d labels", len(vehicles), len(jobs), len(labels))
+	}
+	// import via api
+	req = httptest.NewRequest("POST", importUrl, bytes.NewReader(exportData))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expted status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	// error if data was not recreated under second user with remapped relationships
+	vehicles, _ = svc.ListVehicles(&importUserIdStr, nil, nil, nil, nil)
+	jobs, _ = svc.ListJobs(&importUserIdStr, nil, nil, nil, nil, nil, nil, nil, nil)
+	if len(vehicles) != len(export.Vehicles) || len(jobs) != len(export.Jobs) {
+		t.Errorf("Expected %d vehicles and %d jobs, got %d and %d", len(export.Vehicles), len(export.Jobs), len(vehicles), len(jobs))
+	}
+	labelled := false
+	for _, job := range jobs {
+		if job.Vehicle != nil && *job.Vehicle == createdVehicle.ID {
+			t.Errorf("Imported job ID %d still points at exported vehicle", job.ID)
+		}
+		for _, label := range job.Labels {
+			labelled = labelled || label.ID == existingLabel.ID
+		}
+	}
+	if !labelled {
+		t.Error("Imported job should use merged label")
+	}
+	log.Print("Successfully imported account")
+	// error if unsupported version accepted
+	req = httptest.NewRequest("POST", importUrl, bytes.NewReader([]byte(`{"version": 99}`)))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expted status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	// error if invalid records within the export are not rejected
+	req = httptest.NewRequest("POST", importUrl, bytes.NewReader([]byte(`{"version": 1, "jobs": [{"id": 1, "name": "", "status": "finished"}]}`)))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expted status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "jobs[0].name") || !strings.Contains(w.Body.String(), "jobs[0].status") {
+		t.Errorf("Expected errors for the invalid job fields, got %v", w.Body.String())
+	}
+	// error if unknown fields are not rejected
+	req = httptest.NewRequest("POST", importUrl, bytes.NewReader([]byte(`{"version": 1, "cars": []}`)))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expted status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestCSVImportAndExport
+// Tests importing vehicles, jobs and tasks from csv with column mapping, preview and row errors, then exporting them as csv
+func TestCSVImportAndExport(t *testing.T) {
+	userIdStr := strconv.FormatInt(createdUser.ID, 10)
+	vehiclesBefore, _ := svc.ListVehicles(&userIdStr, nil, nil, nil, nil)
+	vehicleCSV := "Vehicle Name,Year,Miles,Notes\nwrench-turn csv car,2011,\"120,000\",ignored\nwrench-turn csv truck,twenty,5000,ignored\n"
+	vehicleUrl := "/vehicles/import?map=Vehicle+Name:name&map=Miles:odometer"
+	// preview via api
+	req = httptest.NewRequest("POST", vehicleUrl+"&preview=true", strings.NewReader(vehicleCSV))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var result models.CSVImportResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	// error if bad year was not reported against its row and column
+	if result.Rows != 2 || len(result.Errors) != 1 || result.Errors[0].Row != 3 || result.Errors[0].Column != "year" || result.Columns["Notes"] != "" {
+		t.Errorf("Unexpected preview result: %+v", result)
+	}
+	// commit with invalid row via api
+	req = httptest.NewRequest("POST", vehicleUrl, strings.NewReader(vehicleCSV))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status or anything was created
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expted status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if vehicles, _ := svc.ListVehicles(&userIdStr, nil, nil, nil, nil); len(vehicles) != len(vehiclesBefore) {
+		t.Errorf("No vehicles should be created when a row is invalid")
+	}
+	// commit fixed file via api
+	req = httptest.NewRequest("POST", vehicleUrl, strings.NewReader(strings.Replace(vehicleCSV, "twenty", "2020", 1)))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expted status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	if len(result.Created) != 2 {
+		t.Fatalf("Expected 2 vehicles created, got %v", result.Created)
+	}
+	vehicle, _ := svc.GetVehicle(result.Created[0])
+	if vehicle.Odometer == nil || *vehicle.Odometer != 120000 {
+		t.Errorf("Imported vehicle should have mapped odometer, got %v", vehicle.Odometer)
+	}
+	log.Print("Successfully imported vehicles from csv")
+	// import jobs and tasks onto imported vehicle via api
+	vehicleIdStr := strconv.FormatInt(vehicle.ID, 10)
+	req = httptest.NewRequest("POST", "/jobs/import", strings.NewReader("name,vehicle,due date,time interval,time interval unit\nwrench-turn csv oil change,"+vehicleIdStr+",2024-06-01,6,month\n"))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expted status code %d, got %d: %v", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil || len(result.Created) != 1 {
+		t.Fatalf("Expected 1 job created, got %v %v", result.Created, err)
+	}
+	jobUrl := "/jobs/" + strconv.FormatInt(result.Created[0], 10)
+	req = httptest.NewRequest("POST", jobUrl+"/tasks/import", strings.NewReader("name,partName\nDrain oil,\nReplace filter,PH7317\n"))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expted status code %d, got %d: %v", http.StatusCreated, w.Code, w.Body.String())
+	}
+	log.Print("Successfully imported jobs and tasks from csv")
+	// export via api, using same filters as json lists
+	exports := map[string]string{
+		"/vehicles?format=csv&user=" + userIdStr: "wrench-turn csv truck",
+		"/jobs?vehicle=" + vehicleIdStr:          "wrench-turn csv oil change",
+		jobUrl + "/tasks?format=csv":             "PH7317",
+	}
+	for url, expected := range exports {
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Add("Accept", "text/csv")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		// error if not csv containing imported data
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+			t.Errorf("Expted status code %d with csv from %v, got %d %v", http.StatusOK, url, w.Code, w.Header().Get("Content-Type"))
+		}
+		if body := w.Body.String(); !strings.HasPrefix(body, "id,") || !strings.Contains(body, expected) {
+			t.Errorf("CSV from %v is missing %v: %v", url, expected, body)
+		}
+	}
+	log.Print("Successfully exported csv")
+}
+
+// TestFuelLogs
+// Tests importing fuel logs from csv with preview and row errors, recording odometer readings, then listing and exporting them against vehicle access
+func TestFuelLogs(t *testing.T) {
+	send := func(method string, path string, cookie *http.Cookie, body string, status int, out any) {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Add("Authorization", "Bearer "+cookie.Value)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Fatalf("%v %v: Expted status code %d, got %d: %v", method, path, status, w.Code, w.Body.String())
+		}
+		if out != nil {
+			if err := json.NewDecoder(w.Body).Decode(out); err != nil {
+				t.Fatalf("Error decoding response body: %v", err)
+			}
+		}
+	}
+	odometer := 52000
+	vehicle, err := svc.CreateVehicle(models.NewVehicle{Name: "wrench-turn go test fuel car", User: &createdUser.ID, Odometer: &odometer})
+	if err != nil {
+		t.Fatalf("Error creating vehicle: %v", err)
+	}
+	fuelPath := "/vehicles/" + strconv.FormatInt(vehicle.ID, 10) + "/fuel"
+	fuelCSV := "Date,Odometer,Gallons,Total,Full,Station\n2024-01-20,52640,11.2,41.33,yes,Costco\n2024-02-03,\"52,950\",lots,38.06,no,\n"
+	importPath := fuelPath + "/import?map=Date:filledAt&map=Gallons:volume&map=Total:cost&map=Full:isFull"
+	// preview reports the bad volume against its row and column
+	var result models.CSVImportResult
+	send("POST", importPath+"&preview=true", jwtCookie, fuelCSV, http.StatusOK, &result)
+	if result.Rows != 2 || len(result.Errors) != 1 || result.Errors[0].Row != 3 || result.Errors[0].Column != "volume" {
+		t.Errorf("Unexpected preview result: %+v", result)
+	}
+	// nothing is created while a row is invalid
+	send("POST", importPath, jwtCookie, fuelCSV, http.StatusUnprocessableEntity, nil)
+	var fuelLogs []*models.FuelLog
+	send("GET", fuelPath, jwtCookie, "", http.StatusOK, &fuelLogs)
+	if len(fuelLogs) != 0 {
+		t.Fatalf("No fuel logs should be created when a row is invalid, got %d", len(fuelLogs))
+	}
+	send("POST", importPath, jwtCookie, strings.Replace(fuelCSV, "lots", "10.4", 1), http.StatusCreated, &result)
+	if len(result.Created) != 2 {
+		t.Fatalf("Expected 2 fuel logs created, got %v", result.Created)
+	}
+	log.Print("Successfully imported fuel logs from csv")
+	// newest first, keeping cost and volume
+	send("GET", fuelPath, jwtCookie, "", http.StatusOK, &fuelLogs)
+	if len(fuelLogs) != 2 || fuelLogs[0].Odometer == nil || *fuelLogs[0].Odometer != 52950 || fuelLogs[0].Volume != 10.4 || fuelLogs[0].Is_full != 0 {
+		t.Fatalf("Expected latest fill up at 52950 first, got %+v", fuelLogs)
+	}
+	if cost := fuelLogs[1].Cost; cost == nil || *cost != 41.33 || fuelLogs[1].Station == nil || *fuelLogs[1].Station != "Costco" {
+		t.Errorf("Expected fill up costing 41.33 at Costco, got %+v", fuelLogs[1])
+	}
+	// readings are recorded and the vehicle odometer moves forward
+	readings, err := svc.ListOdometerReadings(vehicle.ID)
+	if err != nil || len(readings) != 2 || readings[0].Source != "fuel" {
+		t.Errorf("Expected 2 fuel odometer readings, got %d %v", len(readings), err)
+	}
+	if vehicle, _ = svc.GetVehicle(vehicle.ID); vehicle.Odometer == nil || *vehicle.Odometer != 52950 {
+		t.Errorf("Expected vehicle odometer moved to 52950, got %v", vehicle.Odometer)
+	}
+	// export as csv
+	req = httptest.NewRequest("GET", fuelPath+"?format=csv", nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if body := w.Body.String(); w.Code != http.StatusOK || !strings.HasPrefix(body, "id,vehicle,") || !strings.Contains(body, "41.33") {
+		t.Errorf("Expted status code %d with fuel csv, got %d: %v", http.StatusOK, w.Code, body)
+	}
+	log.Print("Successfully exported fuel logs")
+	// other users can not see or add to the fuel logs of the vehicle
+	password := "fuel-test-password"
+	other, err := svc.CreateUser(models.NewUser{Username: "wrench-turn_go_test_fuel_other", Password: &password})
+	if err != nil {
+		t.Fatalf("Error creating user: %v", err)
+	}
+	otherCookie, err := services.CreateJWT(other.ID, other.Username, false, "wrenchturn-jwt")
+	if err != nil {
+		t.Fatalf("Error creating jwt: %v", err)
+	}
+	send("GET", fuelPath, otherCookie, "", http.StatusForbidden, nil)
+	send("POST", importPath, otherCookie, fuelCSV, http.StatusForbidden, nil)
+	send("GET", "/vehicles/0/fuel", jwtCookie, "", http.StatusNotFound, nil)
+}
+
+// TestImportFromTracker
+// Tests importing another trackers export, creating its vehicles, completed jobs, fuel logs and odometer history
+func TestImportFromTracker(t *testing.T) {
+	fixture, err := os.ReadFile("importers/testdata/simplyauto.csv")
+	if err != nil {
+		t.Fatalf("Error reading fixture: %v", err)
+	}
+	// preview via api, detecting format
+	req = httptest.NewRequest("POST", "/import/auto?preview=true", bytes.NewReader(fixture))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var result models.TrackerImportResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	if result.Format != "simplyauto" || result.Vehicles_created != 1 || result.Jobs != 2 || result.Tasks != 3 {
+		t.Errorf("Unexpected preview result: %+v", result)
+	}
+	// import via api
+	req = httptest.NewRequest("POST", "/import/simplyauto", bytes.NewReader(fixture))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expted status code %d, got %d: %v", http.StatusCreated, w.Code, w.Body.String())
+	}
+	// error if vehicle, jobs and odometer history were not created
+	userIdStr := strconv.FormatInt(createdUser.ID, 10)
+	searchStr := "Wagon"
+	vehicles, _ := svc.ListVehicles(&userIdStr, nil, &searchStr, nil, nil)
+	if len(vehicles) != 1 || vehicles[0].Odometer == nil || *vehicles[0].Odometer != 66500 {
+		t.Fatalf("Expected imported vehicle with odometer 66500, got %v", vehicles)
+	}
+	vehicleIdStr := strconv.FormatInt(vehicles[0].ID, 10)
+	isComplete := "1"
+	jobs, _ := svc.ListJobs(nil, &vehicleIdStr, nil, &isComplete, nil, nil, nil, nil, nil)
+	readings, _ := svc.ListOdometerReadings(vehicles[0].ID)
+	if len(jobs) != 2 || len(readings) != 2 {
+		t.Errorf("Expected 2 completed jobs and readings, got %d and %d", len(jobs), len(readings))
+	}
+	log.Print("Successfully imported from tracker export")
+	// fuel exports with a bad row create nothing
+	fixture, err = os.ReadFile("importers/testdata/fuelly.csv")
+	if err != nil {
+		t.Fatalf("Error reading fixture: %v", err)
+	}
+	vehiclesBefore, _ := svc.ListVehicles(&userIdStr, nil, nil, nil, nil)
+	req = httptest.NewRequest("POST", "/import/fuelly", bytes.NewReader(fixture))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expted status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if vehicles, _ := svc.ListVehicles(&userIdStr, nil, nil, nil, nil); len(vehicles) != len(vehiclesBefore) {
+		t.Errorf("No vehicles should be created when a row is invalid")
+	}
+	// fuel records become fuel logs keeping cost and volume
+	req = httptest.NewRequest("POST", "/import/fuelly", bytes.NewReader(bytes.Replace(fixture, []byte(",abc,"), []byte(",3.899,"), 1)))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expted status code %d, got %d: %v", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil || result.Fuel_logs != 3 || result.Vehicles_created != 2 {
+		t.Errorf("Expected 3 fuel logs on 2 new vehicles, got %+v %v", result, err)
+	}
+	searchStr = "Daily Civic"
+	vehicles, _ = svc.ListVehicles(&userIdStr, nil, &searchStr, nil, nil)
+	if len(vehicles) != 1 {
+		t.Fatalf("Expected imported vehicle Daily Civic, got %v", vehicles)
+	}
+	fuelLogs, _ := svc.ListFuelLogs(vehicles[0].ID, nil)
+	if len(fuelLogs) != 2 || fuelLogs[1].Volume != 8.83 || fuelLogs[1].Cost == nil || *fuelLogs[1].Cost < 31.77 || *fuelLogs[1].Cost > 31.79 {
+		t.Errorf("Expected 2 fuel logs with volume and cost, got %+v", fuelLogs)
+	}
+	if vehicles[0].Odometer == nil || *vehicles[0].Odometer != 88320 {
+		t.Errorf("Expected vehicle odometer 88320, got %v", vehicles[0].Odometer)
+	}
+	log.Print("Successfully imported fuel logs from tracker export")
+}
+
+// TestCalendarFeed
+// Tests creating a calendar token and retrieving, filtering and revoking its iCalendar feed
+func TestCalendarFeed(t *testing.T) {
+	// create job, task and reminder with due dates
+	dueDate := time.Date(2030, time.May, 1, 9, 0, 0, 0, time.UTC)
+	job, err := svc.CreateJob(models.NewJob{
+		Name:     "wrench-turn go test calendar, job",
+		Vehicle:  &createdVehicle.ID,
+		User:     &createdUser.ID,
+		Due_date: &dueDate,
+	})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	task, err := svc.CreateTask(models.NewTask{Name: "wrench-turn go test calendar task", Due_date: &dueDate}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	reminderName := "wrench-turn go test calendar reminder"
+	alert, err := svc.CreateAlert(models.NewAlert{Name: &reminderName, Type: "reminder", User: &createdUser.ID, Job: &job.ID, Alert_at: &dueDate})
+	if err != nil {
+		t.Fatalf("Error creating reminder: %v", err)
+	}
+	// create token via api
+	req = httptest.NewRequest("POST", "/users/"+createdUser.Username+"/calendar", nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expted status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	var calendarToken models.CalendarToken
+	if err := json.NewDecoder(w.Body).Decode(&calendarToken); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	feedUrl := "/calendar/" + calendarToken.Token + ".ics"
+	if !strings.HasSuffix(calendarToken.Url, feedUrl) {
+		t.Errorf("Expected feed url ending in %v, got %v", feedUrl, calendarToken.Url)
+	}
+	// get feed via api, no auth header
+	req = httptest.NewRequest("GET", feedUrl, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("Expted status code %d with calendar, got %d %v", http.StatusOK, w.Code, w.Header().Get("Content-Type"))
+	}
+	// error if entries missing
+	body := w.Body.String()
+	jobUid := "UID:job-" + strconv.FormatInt(job.ID, 10) + "@wrenchturn\r\n"
+	for _, want := range []string{"BEGIN:VCALENDAR\r\n", jobUid, "UID:task-" + strconv.FormatInt(task.ID, 10) + "@wrenchturn\r\n", "UID:alert-" + strconv.FormatInt(alert.ID, 10) + "@wrenchturn\r\n", "SUMMARY:wrench-turn go test calendar\\, job", "DUE:20300501T090000Z", "END:VCALENDAR\r\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Calendar feed is missing %q: %v", want, body)
+		}
+	}
+	log.Print("Successfully retrieved calendar feed")
+	// skip job, error if not cancelled in feed
+	_, err = svc.UpdateJobStatus(job.ID, "skipped", createdUser.ID, nil)
+	if err != nil {
+		t.Fatalf("Error skipping job: %v", err)
+	}
+	req = httptest.NewRequest("GET", feedUrl, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), jobUid) || !strings.Contains(w.Body.String(), "STATUS:CANCELLED") {
+		t.Errorf("Calendar feed should include cancelled job: %v", w.Body.String())
+	}
+	// error if filters do not exclude entries
+	for _, query := range []string{"?label=" + strconv.FormatInt(createdLabel.ID, 10), "?vehicle=" + strconv.FormatInt(createdVehicle.ID+1000, 10)} {
+		req = httptest.NewRequest("GET", feedUrl+query, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || strings.Contains(w.Body.String(), jobUid) || strings.Contains(w.Body.String(), "calendar reminder") {
+			t.Errorf("Calendar feed filtered by %v should not include entries, got %d", query, w.Code)
+		}
+	}
+	// error if invalid filter accepted
+	req = httptest.NewRequest("GET", feedUrl+"?vehicle=civic", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expted status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	// revoke token via api, error if feed still available
+	req = httptest.NewRequest("DELETE", "/users/"+createdUser.Username+"/calendar", nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	req = httptest.NewRequest("GET", feedUrl, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expted status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+	log.Print("Successfully revoked calendar feed")
+	// clean up
+	svc.DeleteAlert(alert.ID, nil)
+	svc.DeleteJob(job.ID, nil)
+}
+
+// TestSearch
+// Tests searching across jobs and tasks, highlighting matches and limiting results to what the caller can see
+func TestSearch(t *testing.T) {
+	// create job and task with searchable text
+	description := "replace the zirconite sensor"
+	job, err := svc.CreateJob(models.NewJob{
+		Name:        "wrench-turn go test <search> job",
+		Description: &description,
+		Vehicle:     &createdVehicle.ID,
+		User:        &createdUser.ID,
+	})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	partName := "Zirconite O2 sensor"
+	task, err := svc.CreateTask(models.NewTask{Name: "wrench-turn go test search task", Part_name: &partName}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	// search via api, partial word
+	req = httptest.NewRequest("GET", "/search?q=zircon", nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var results []models.SearchResult
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	// error if job and task not found, tagged and highlighted
+	found := map[string]bool{}
+	for _, result := range results {
+		if result.Type == "job" && result.ID == job.ID {
+			found["job"] = strings.Contains(result.Title, "&lt;search&gt;") && strings.Contains(result.Snippet, "<mark>")
+		}
+		if result.Type == "task" && result.ID == task.ID {
+			found["task"] = result.Job != nil && *result.Job == job.ID && strings.Contains(result.Snippet, "<mark>")
+		}
+	}
+	if !found["job"] || !found["task"] {
+		t.Errorf("Expected highlighted job and task in search results, got %+v", results)
+	}
+	log.Print("Successfully searched")
+	// error if type filter not applied
+	req = httptest.NewRequest("GET", "/search?q=zirconite&type=task", nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	results = nil
+	json.NewDecoder(w.Body).Decode(&results)
+	if len(results) != 1 || results[0].Type != "task" {
+		t.Errorf("Expected only task in search results, got %+v", results)
+	}
+	// error if search syntax in query is not treated as text
+	req = httptest.NewRequest("GET", "/search?q="+url.QueryEscape(`zirconite" OR *`), nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// error if invalid type accepted
+	req = httptest.NewRequest("GET", "/search?q=zirconite&type=user", nil)
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expted status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	// error if other users can see results
+	otherUserId := createdUser.ID + 1000
+	otherResults, err := svc.Search("zirconite", &otherUserId, nil, 20)
+	if err != nil || len(otherResults) != 0 {
+		t.Errorf("Expected no search results for other user, got %v %v", otherResults, err)
+	}
+	// error if edits and deletes are not reflected
+	job.Description = nil
+	_, err = svc.EditJob(*job, createdUser.ID, nil)
+	if err != nil {
+		t.Fatalf("Error editing job: %v", err)
+	}
+	svc.DeleteTask(job.ID, &task.ID)
+	results2, _ := svc.Search("zirconite", nil, nil, 20)
+	if len(results2) != 0 {
+		t.Errorf("Expected no search results after edit and delete, got %+v", results2)
+	}
+	// clean up
+	svc.DeleteJob(job.ID, nil)
+}
+
+// TestPagination
+// Tests paging through a sorted job list with cursors, including rows with no sort value and deleted cursor rows
+func TestPagination(t *testing.T) {
+	// create jobs, some sharing or missing due dates
+	dueDate := time.Date(2031, time.January, 1, 0, 0, 0, 0, time.UTC)
+	jobIds := map[int64]bool{}
+	for i := 0; i < 5; i++ {
+		newJob := models.NewJob{
+			Name: "wrench-turn go test page job " + strconv.Itoa(i),
+			User: &createdUser.ID,
+		}
+		if i%2 == 0 {
+			newJob.Due_date = &dueDate
+		}
+		job, err := svc.CreateJob(newJob)
+		if err != nil {
+			t.Fatalf("Error creating job: %v", err)
+		}
+		jobIds[job.ID] = true
+	}
+	listUrl := "/jobs?q=go%20test%20page%20job&limit=2&count=true"
+	for _, sort := range []string{"az", "due_date", "oldest"} {
+		seen := map[int64]bool{}
+		var names []string
+		cursor := ""
+		for pages := 0; pages < 5; pages++ {
+			// get page via api
+			req = httptest.NewRequest("GET", listUrl+"&sort="+sort+"&cursor="+cursor, nil)
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			// error if unexpected HTTP status
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expted status code %d, got %d: %v", http.StatusOK, w.Code, w.Body.String())
+			}
+			var page struct {
+				Items       []models.Job `json:"items"`
+				Next_cursor *string      `json:"nextCursor"`
+				Total       *int64       `json:"total"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+				t.Fatalf("Error decoding response body: %v", err)
+			}
+			if page.Total == nil || *page.Total != 5 || len(page.Items) > 2 {
+				t.Errorf("Expected pages of at most 2 of 5 jobs, got %d of %v", len(page.Items), page.Total)
+			}
+			for _, job := range page.Items {
+				if seen[job.ID] {
+					t.Errorf("Job ID %d listed twice sorted by %v", job.ID, sort)
+				}
+				seen[job.ID] = true
+				names = append(names, job.Name)
+			}
+			if page.Next_cursor == nil {
+				break
+			}
+			cursor = *page.Next_cursor
+		}
+		// error if any job missed
+		if len(seen) != 5 {
+			t.Errorf("Expected all 5 jobs sorted by %v, got %d", sort, len(seen))
+		}
+		if sort == "az" && (names[0] != "wrench-turn go test page job 0" || names[4] != "wrench-turn go test page job 4") {
+			t.Errorf("Expected jobs in name order, got %v", names)
+		}
+	}
+	log.Print("Successfully paged through jobs")
+	// get first page, delete its last job, error if next page does not continue after it
+	req = httptest.NewRequest("GET", listUrl+"&sort=az", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var first models.PageResult
+	json.NewDecoder(w.Body).Decode(&first)
+	items := first.Items.([]interface{})
+	lastId := int64(items[len(items)-1].(map[string]interface{})["id"].(float64))
+	svc.DeleteJob(lastId, nil)
+	delete(jobIds, lastId)
+	req = httptest.NewRequest("GET", listUrl+"&sort=az&cursor="+*first.Next_cursor, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "page job 2") || strings.Contains(w.Body.String(), "page job 0") {
+		t.Errorf("Expected page after deleted cursor row to continue from it, got %d %v", w.Code, w.Body.String())
+	}
+	// error if cursor used with another sort, or invalid params accepted
+	for _, query := range []string{listUrl + "&sort=za&cursor=" + *first.Next_cursor, "/jobs?cursor=nope", "/jobs?limit=0"} {
+		req = httptest.NewRequest("GET", query, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expted status code %d for %v, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+	// clean up
+	for jobId := range jobIds {
+		svc.DeleteJob(jobId, nil)
+	}
+}
+
+// TestErrorResponses
+// Tests errors are returned as problem+json with stable codes, missing rows as 404 and duplicates as 409
+func TestErrorResponses(t *testing.T) {
+	cases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"GET", "/jobs?limit=abc", "", http.StatusBadRequest, response.CodeInvalidParam},
+		{"GET", "/jobs/999999", "", http.StatusNotFound, "not_found"},
+		{"POST", "/jobs/edit", "{", http.StatusBadRequest, response.CodeInvalidBody},
+		{"POST", "/users/create", `{"username":"` + testUsername + `","password":"Password123"}`, http.StatusConflict, response.CodeConstraint},
+		{"GET", "/verify", "", http.StatusUnauthorized, "unauthorized"},
+	}
+	for _, c := range cases {
+		req = httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.method == "POST" {
+			req.Header.Set("Authorization", "Bearer "+jwtCookie.Value)
+		}
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		// error if unexpected HTTP status
+		if w.Code != c.status {
+			t.Errorf("%s %s: Expted status code %d, got %d", c.method, c.path, c.status, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+			t.Errorf("%s %s: Expected problem+json content type, got %q", c.method, c.path, contentType)
+		}
+		// error if unable to decode response
+		var problem response.Problem
+		if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+			t.Errorf("Error decoding response body: %v", err)
+		}
+		if problem.Status != c.status || problem.Code != c.code || len(problem.Title) == 0 {
+			t.Errorf("%s %s: Unexpected problem %+v", c.method, c.path, problem)
+		}
+	}
+	// successful responses set json content type
+	req = httptest.NewRequest("GET", "/jobs/"+strconv.FormatInt(createdJob.ID, 10), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if contentType := w.Header().Get("Content-Type"); w.Code != http.StatusOK || contentType != "application/json" {
+		t.Errorf("Expected 200 with json content type, got %d %q", w.Code, contentType)
+	}
+	log.Print("Successfully checked error responses")
+}
+
+// TestOpenAPI
+// Tests every route registered in main.go is described by the OpenAPI document, and has a method on the client
+func TestOpenAPI(t *testing.T) {
+	req = httptest.NewRequest("GET", "/openapi.json", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// error if unable to decode response
+	var spec struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			OperationId string `json:"operationId"`
+		} `json:"paths"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&spec); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	if spec.Info.Version != version.Version {
+		t.Errorf("Expected spec version %v, got %v", version.Version, spec.Info.Version)
+	}
+	// collect routes from main.go, dropping path param patterns, e.g. {id:[0-9]+} becomes {id}
+	source, err := os.ReadFile("main.go")
+	if err != nil {
+		t.Fatalf("Error reading main.go: %v", err)
+	}
+	routeRe := regexp.MustCompile(`r\.(Get|Post|Put|Patch|Delete)\("([^"]+)"`)
+	paramRe := regexp.MustCompile(`\{(\w+):[^}]*\}`)
+	routes := map[string]bool{}
+	for _, match := range routeRe.FindAllStringSubmatch(string(source), -1) {
+		method := strings.ToLower(match[1])
+		path := paramRe.ReplaceAllString(match[2], "{$1}")
+		routes[method+" "+path] = true
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("Route %v %v is missing from openapi/openapi.json", method, path)
+		}
+	}
+	if len(routes) == 0 {
+		t.Fatal("No routes found in main.go")
+	}
+	clientType := reflect.TypeOf(&client.Client{})
+	for path, operations := range spec.Paths {
+		for method, operation := range operations {
+			// error if spec describes a route that does not exist
+			if !routes[method+" "+path] {
+				t.Errorf("openapi/openapi.json describes %v %v which is not registered in main.go", method, path)
+			}
+			// error if client has no method for operation
+			name := strings.ToUpper(operation.OperationId[:1]) + operation.OperationId[1:]
+			if _, ok := clientType.MethodByName(name); !ok {
+				t.Errorf("Client has no method %v for %v %v", name, method, path)
+			}
+		}
+	}
+	// use client against test server
+	server := httptest.NewServer(r)
+	defer server.Close()
+	ctx := context.Background()
+	c := client.New(server.URL)
+	_, err = c.Auth(ctx, models.Credentials{Username: testUsername, Password: testPassword})
+	if err != nil {
+		t.Fatalf("Unable to auth with client: %v", err)
+	}
+	if err = c.Verify(ctx); err != nil {
+		t.Errorf("Client token was not verified: %v", err)
+	}
+	job, err := c.GetJob(ctx, createdJob.ID)
+	if err != nil || job.ID != createdJob.ID {
+		t.Errorf("Client did not get job %d: %v", createdJob.ID, err)
+	}
+	jobs, err := c.ListJobs(ctx, url.Values{"limit": {"1"}})
+	if err != nil || len(jobs.Items) != 1 || jobs.NextCursor == nil {
+		t.Errorf("Client did not get first page of jobs: %v", err)
+	}
+	// error if client does not surface problem details
+	_, err = c.GetJob(ctx, 999999)
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Problem == nil || apiErr.Problem.Code != "not_found" {
+		t.Errorf("Expected not found client error, got %v", err)
+	}
+	log.Print("Successfully checked OpenAPI document and client")
+}
+
+// TestValidation
+// Tests request bodies are validated before reaching services, with field level errors, unknown fields and oversized bodies rejected
+func TestValidation(t *testing.T) {
+	cases := []struct {
+		path   string
+		body   string
+		status int
+		fields []string
+	}{
+		{"/vehicles/create", `{"name": " ", "year": -5, "vin": "not a vin!", "isMetric": 3, "odometer": -1}`, http.StatusUnprocessableEntity, []string{"name", "isMetric", "vin", "year", "odometer"}},
+		{"/jobs/create", `{"name": "validated job", "timeIntervalUnit": "fortnight", "status": "lost"}`, http.StatusUnprocessableEntity, []string{"status", "timeIntervalUnit"}},
+		{"/labels/create", `{"name": "validated label", "color": "red"}`, http.StatusUnprocessableEntity, []string{"color"}},
+		{"/schedules/import", `{"name": "validated schedule", "items": [{"name": "", "odoInterval": 5000}]}`, http.StatusUnprocessableEntity, []string{"items[0].name"}},
+		{"/labels/create", `{"name": "validated label", "colour": "#ff0000"}`, http.StatusBadRequest, nil},
+		{"/labels/create", `null`, http.StatusBadRequest, nil},
+		{"/labels/create", `{"name": "validated label", "description": "` + strings.Repeat("a", 2<<20) + `"}`, http.StatusRequestEntityTooLarge, nil},
+	}
+	for _, c := range cases {
+		req = httptest.NewRequest("POST", c.path, strings.NewReader(c.body))
+		req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		// error if unexpected HTTP status
+		if w.Code != c.status {
+			t.Errorf("%v: Expted status code %d, got %d", c.path, c.status, w.Code)
+		}
+		// error if unable to decode response
+		var problem response.Problem
+		if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+			t.Errorf("Error decoding response body: %v", err)
+		}
+		// error if field errors do not match
+		var fields []string
+		for _, fieldErr := range problem.Errors {
+			fields = append(fields, fieldErr.Field)
+		}
+		if strings.Join(fields, ",") != strings.Join(c.fields, ",") {
+			t.Errorf("%v: Expected errors for %v, got %v", c.path, c.fields, problem.Errors)
+		}
+	}
+	// error if valid body is rejected
+	req = httptest.NewRequest("POST", "/labels/create", strings.NewReader(`{"name": "validated label", "color": "#0af"}`))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expted status code %d, got %d: %v", http.StatusCreated, w.Code, w.Body.String())
+	}
+	log.Print("Successfully validated request bodies")
+}
+
+// TestMergePatch
+// Tests partially updating vehicle created by TestCreateVehicle with JSON merge patches and If-Match
+func TestMergePatch(t *testing.T) {
+	vehiclePath := "/vehicles/" + strconv.FormatInt(createdVehicle.ID, 10)
+	// get from api for its ETag
+	req = httptest.NewRequest("GET", vehiclePath, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+	if len(etag) == 0 {
+		t.Fatalf("Expected ETag header on GET %v", vehiclePath)
+	}
+	var current models.Vehicle
+	if err := json.NewDecoder(w.Body).Decode(&current); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	patch := func(path string, body string, ifMatch string) {
+		req = httptest.NewRequest("PATCH", path, strings.NewReader(body))
+		req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+		req.Header.Add("Content-Type", "application/merge-patch+json")
+		if len(ifMatch) > 0 {
+			req.Header.Add("If-Match", ifMatch)
+		}
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}
+	// only provided fields change, null clears a field
+	patch(vehiclePath, `{"description": "patched description", "trim": null}`, etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d: %v", http.StatusOK, w.Code, w.Body.String())
+	}
+	newETag := w.Header().Get("ETag")
+	var patched models.Vehicle
+	if err := json.NewDecoder(w.Body).Decode(&patched); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	if patched.Description == nil || *patched.Description != "patched description" || patched.Trim != nil {
+		t.Errorf("Expected description patched and trim cleared, got %v and %v", patched.Description, patched.Trim)
+	}
+	if patched.Name != current.Name || patched.User != current.User || !reflect.DeepEqual(patched.Make, current.Make) {
+		t.Errorf("Expected unpatched fields to be kept, got %+v", patched)
+	}
+	if len(newETag) == 0 || newETag == etag {
+		t.Errorf("Expected new ETag after patch, got %v", newETag)
+	}
+	// stale ETag is rejected
+	patch(vehiclePath, `{"name": "lost update"}`, etag)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expted status code %d, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+	// an edit racing past the If-Match check still compares updated_at in its update
+	lost := patched
+	lost.Name = "lost update"
+	if _, err := svc.EditVehicle(lost, &current.Updated_at); !errors.Is(err, repository.ErrRevisionChanged) {
+		t.Errorf("Expected ErrRevisionChanged editing with stale revision, got %v", err)
+	}
+	if vehicle, err := svc.GetVehicle(createdVehicle.ID); err != nil || vehicle.Name != current.Name {
+		t.Errorf("Expected stale edit to change nothing, got %+v, %v", vehicle, err)
+	}
+	if _, err := svc.EditVehicle(patched, &patched.Updated_at); err != nil {
+		t.Errorf("Error editing with current revision: %v", err)
+	}
+	// patched result is validated
+	patch(vehiclePath, `{"year": 1700, "name": null}`, "*")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expted status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	// unknown fields and non-object patches are rejected
+	patch(vehiclePath, `{"colour": "#ff0000"}`, "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expted status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	patch(vehiclePath, `[]`, "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expted status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	// a patch without If-Match always applies
+	patch("/labels/"+strconv.FormatInt(createdLabel.ID, 10), `{"color": "#123456"}`, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d: %v", http.StatusOK, w.Code, w.Body.String())
+	}
+	var label models.Label
+	if err := json.NewDecoder(w.Body).Decode(&label); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	if label.Color == nil || *label.Color != "#123456" || label.Name != createdLabel.Name {
+		t.Errorf("Expected label color patched, got %+v", label)
+	}
+	log.Print("Successfully patched vehicle and label")
+}
+
+// TestBulkOperations
+// Tests bulk task completion, label assignment and job deletion in atomic and best effort modes
+func TestBulkOperations(t *testing.T) {
+	send := func(method string, path string, body string) {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}
+	decodeResult := func() models.BulkResult {
+		var result models.BulkResult
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatalf("Error decoding response body: %v", err)
+		}
+		return result
+	}
+	// create jobs with a task to work on
+	var jobIds []string
+	var taskId int64
+	for i := 0; i < 2; i++ {
+		send("POST", "/jobs/create", `{"name": "bulk job"}`)
+		var job models.Job
+		if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+			t.Fatalf("Error decoding response body: %v", err)
+		}
+		jobIds = append(jobIds, strconv.FormatInt(job.ID, 10))
+	}
+	send("POST", "/jobs/"+jobIds[0]+"/tasks/create", `{"name": "bulk task"}`)
+	var task models.Task
+	if err := json.NewDecoder(w.Body).Decode(&task); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	taskId = task.ID
+	ids := strings.Join(jobIds, ", ")
+	labelPath := "/jobs/bulk/assignLabel/" + strconv.FormatInt(createdLabel.ID, 10)
+	// atomic mode changes nothing if an item fails
+	send("POST", labelPath, `{"ids": [`+ids+`, 999999]}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expted status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	result := decodeResult()
+	if result.Committed || result.Failed != 3 || result.Results[0].Status != http.StatusFailedDependency || result.Results[2].Status != http.StatusNotFound {
+		t.Errorf("Expected uncommitted result with missing job, got %+v", result)
+	}
+	send("GET", "/jobs/"+jobIds[0], "")
+	var job models.Job
+	if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	if len(job.Labels) != 0 {
+		t.Errorf("Expected no labels after failed atomic batch, got %v", job.Labels)
+	}
+	// best effort mode keeps the items that succeeded
+	send("POST", labelPath, `{"ids": [`+ids+`, 999999], "mode": "best_effort"}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	result = decodeResult()
+	if !result.Committed || result.Succeeded != 2 || result.Failed != 1 {
+		t.Errorf("Expected 2 of 3 items to succeed, got %+v", result)
+	}
+	send("GET", "/jobs/"+jobIds[1], "")
+	job = models.Job{}
+	if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	if len(job.Labels) != 1 || job.Labels[0].ID != createdLabel.ID {
+		t.Errorf("Expected label assigned to job, got %v", job.Labels)
+	}
+	// complete tasks by id alone
+	send("POST", "/tasks/bulk/complete", `{"ids": [`+strconv.FormatInt(taskId, 10)+`]}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d: %v", http.StatusOK, w.Code, w.Body.String())
+	}
+	send("GET", "/jobs/"+jobIds[0]+"/tasks/"+strconv.FormatInt(taskId, 10), "")
+	task = models.Task{}
+	if err := json.NewDecoder(w.Body).Decode(&task); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	if task.Is_complete != 1 {
+		t.Errorf("Expected task to be complete")
+	}
+	// invalid mode is rejected
+	send("POST", "/jobs/bulk/delete", `{"ids": [`+ids+`], "mode": "sometimes"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expted status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	// delete jobs with their tasks
+	send("POST", "/jobs/bulk/delete", `{"ids": [`+ids+`]}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d: %v", http.StatusOK, w.Code, w.Body.String())
+	}
+	result = decodeResult()
+	if result.Succeeded != 2 {
+		t.Errorf("Expected 2 jobs deleted, got %+v", result)
+	}
+	send("GET", "/jobs/"+jobIds[0]+"/tasks/"+strconv.FormatInt(taskId, 10), "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expted status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+	log.Print("Successfully ran bulk operations")
+}
+
+// TestHostileLabelNames
+// Tests labels with commas, quotes, newlines and no color round trip through a job, a filtered job list, a task and a vehicle, and link tables only come from the registry
+func TestHostileLabelNames(t *testing.T) {
+	job, err := svc.CreateJob(models.NewJob{Name: "wrench-turn go test label job", User: &createdUser.ID})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	defer svc.DeleteJob(job.ID, &createdUser.ID)
+	task, err := svc.CreateTask(models.NewTask{Name: "wrench-turn go test label task"}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	vehicle, err := svc.CreateVehicle(models.NewVehicle{Name: "wrench-turn go test label vehicle", User: &createdUser.ID})
+	if err != nil {
+		t.Fatalf("Error creating vehicle: %v", err)
+	}
+	defer svc.DeleteVehicle(vehicle.ID, &createdUser.ID)
+	red := "ff0000"
+	hostile := []models.NewLabel{
+		{Name: "brakes, front", Color: &red, User: &createdUser.ID},
+		{Name: "no color", User: &createdUser.ID},
+		{Name: "\"quoted\",'single',\nnewline", User: &createdUser.ID},
+		{Name: "ölwechsel ,,, 🔧", Color: &red, User: &createdUser.ID},
+	}
+	var labels []*models.Label
+	for _, newLabel := range hostile {
+		label, err := svc.CreateLabel(newLabel)
+		if err != nil {
+			t.Fatalf("Error creating label: %v", err)
+		}
+		defer svc.DeleteLabel(label.ID, &createdUser.ID)
+		if _, err = svc.AssignJobLabel(job.ID, label.ID, 1); err != nil {
+			t.Fatalf("Error assigning label: %v", err)
+		}
+		// tasks and vehicles through their endpoints
+		for _, path := range []string{
+			"/jobs/" + strconv.FormatInt(job.ID, 10) + "/tasks/" + strconv.FormatInt(task.ID, 10) + "/assignLabel/" + strconv.FormatInt(label.ID, 10),
+			"/vehicles/" + strconv.FormatInt(vehicle.ID, 10) + "/assignLabel/" + strconv.FormatInt(label.ID, 10),
+		} {
+			req = httptest.NewRequest("POST", path, nil)
+			req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
+			}
+		}
+		labels = append(labels, label)
+	}
+	// checkLabels compares fetched labels with the created ones, in assignment order
+	checkLabels := func(fetchedLabels []models.Label) {
+		if len(fetchedLabels) != len(labels) {
+			t.Fatalf("Expected %d labels, got %d: %v", len(labels), len(fetchedLabels), fetchedLabels)
+		}
+		for i, label := range fetchedLabels {
+			if label.ID != labels[i].ID || label.Name != labels[i].Name {
+				t.Errorf("Expected label %d %q, got %d %q", labels[i].ID, labels[i].Name, label.ID, label.Name)
+			}
+			if !reflect.DeepEqual(label.Color, labels[i].Color) {
+				t.Errorf("Expected label %q color %v, got %v", label.Name, labels[i].Color, label.Color)
+			}
+			if label.User == nil || *label.User != createdUser.ID {
+				t.Errorf("Expected label %q to belong to user %d, got %v", label.Name, createdUser.ID, label.User)
+			}
+		}
+	}
+	// get job from api
+	req = httptest.NewRequest("GET", "/jobs/"+strconv.FormatInt(job.ID, 10), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var fetchedJob *models.Job
+	if err := json.NewDecoder(w.Body).Decode(&fetchedJob); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	checkLabels(fetchedJob.Labels)
+	// list jobs filtered by the label with no color, job still has all of its labels
+	req = httptest.NewRequest("GET", "/jobs?label="+strconv.FormatInt(labels[1].ID, 10), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var jobs []*models.Job
+	if err := json.NewDecoder(w.Body).Decode(&jobs); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Fatalf("Expected only job %d with label %d, got %d jobs", job.ID, labels[1].ID, len(jobs))
+	}
+	checkLabels(jobs[0].Labels)
+	// get task and vehicle from api
+	req = httptest.NewRequest("GET", "/jobs/"+strconv.FormatInt(job.ID, 10)+"/tasks/"+strconv.FormatInt(task.ID, 10), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var fetchedTask *models.Task
+	if err := json.NewDecoder(w.Body).Decode(&fetchedTask); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	checkLabels(fetchedTask.Labels)
+	req = httptest.NewRequest("GET", "/vehicles?user="+strconv.FormatInt(createdUser.ID, 10), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var vehicles []*models.Vehicle
+	if err := json.NewDecoder(w.Body).Decode(&vehicles); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	found := false
+	for _, listed := range vehicles {
+		if listed.ID == vehicle.ID {
+			found = true
+			checkLabels(listed.Labels)
+		}
+	}
+	if !found {
+		t.Fatalf("Expected vehicle %d in list", vehicle.ID)
+	}
+	// unassigning leaves the other labels in order
+	if _, err = svc.AssignVehicleLabel(vehicle.ID, labels[0].ID, 0); err != nil {
+		t.Fatalf("Error unassigning label: %v", err)
+	}
+	fetchedVehicle, err := svc.GetVehicle(vehicle.ID)
+	if err != nil || len(fetchedVehicle.Labels) != len(labels)-1 || fetchedVehicle.Labels[0].ID != labels[1].ID {
+		t.Errorf("Expected vehicle to keep labels after the unassigned one, got %+v, %v", fetchedVehicle, err)
+	}
+	// table and column names are only taken from the registry, never from the caller
+	for _, table := range []string{"label", "job_label", "job; DROP TABLE label; --", "job_label WHERE 1=1 OR job", "vehicle(label) VALUES (1, 1); --"} {
+		if _, err = db.ListLinkedLabels(table, []int64{job.ID}); err == nil {
+			t.Errorf("Expected listing labels of %q to be rejected", table)
+		}
+		if _, err = db.AssignLinkedLabel(table, job.ID, labels[0].ID); err == nil {
+			t.Errorf("Expected assigning labels to %q to be rejected", table)
+		}
+		if err = db.UnassignLinkedLabel(table, job.ID, labels[0].ID); err == nil {
+			t.Errorf("Expected unassigning labels of %q to be rejected", table)
+		}
+	}
+	if _, err = svc.GetLabel(labels[0].ID); err != nil {
+		t.Errorf("Expected label table intact, got %v", err)
+	}
+	log.Print("Successfully round tripped hostile label names")
+}
+
+// TestActivity
+// Tests activity feeds of the test job and vehicle and the admin only activity log
+func TestActivity(t *testing.T) {
+	listActivity := func(path string, cookie *http.Cookie, status int) []*models.AuditEvent {
+		req = httptest.NewRequest("GET", path, nil)
+		req.Header.Add("Authorization", "Bearer "+cookie.Value)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Fatalf("Expted status code %d, got %d", status, w.Code)
+		}
+		var events []*models.AuditEvent
+		if status == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&events); err != nil {
+				t.Fatalf("Error decoding response body: %v", err)
+			}
+		}
+		return events
+	}
+	// job feed has its creation and description edit made by the test user via api
+	jobIdStr := strconv.FormatInt(createdJob.ID, 10)
+	var created, edited bool
+	for _, event := range listActivity("/jobs/"+jobIdStr+"/activity", jwtCookie, http.StatusOK) {
+		if event.Job == nil || *event.Job != createdJob.ID {
+			t.Errorf("Expected only events of job %d, got job %v", createdJob.ID, event.Job)
+		}
+		if event.Entity != "job" || event.Actor == nil || *event.Actor != createdUser.ID {
+			continue
+		}
+		switch event.Action {
+		case "create":
+			created = true
+		case "edit":
+			if _, ok := event.Diff["description"]; ok {
+				edited = true
+			}
+		}
+	}
+	if !created || !edited {
+		t.Errorf("Expected job create and description edit events, got create %v edit %v", created, edited)
+	}
+	// vehicle feed has the vehicles creation
+	vehicleIdStr := strconv.FormatInt(createdVehicle.ID, 10)
+	created = false
+	for _, event := range listActivity("/vehicles/"+vehicleIdStr+"/activity", jwtCookie, http.StatusOK) {
+		if event.Entity == "vehicle" && event.Entity_id == createdVehicle.ID && event.Action == "create" {
+			created = true
+		}
+	}
+	if !created {
+		t.Errorf("Expected vehicle %d create event", createdVehicle.ID)
+	}
+	// admin log filtered by entity
+	for _, event := range listActivity("/activity?entity=label", jwtCookie, http.StatusOK) {
+		if event.Entity != "label" {
+			t.Errorf("Expected only label events, got %v", event.Entity)
+		}
+	}
+	// non admin user can not read the activity log or other users feeds
+	otherUsername := "wrench-turn_go_test_activity"
+	otherPassword := "activity-test-password"
+	otherUser, err := svc.CreateUser(models.NewUser{Username: otherUsername, Password: &otherPassword})
+	if err != nil {
+		t.Fatalf("Error creating user: %v", err)
+	}
+	otherCookie, err := services.CreateJWT(otherUser.ID, otherUsername, false, "wrenchturn-jwt")
+	if err != nil {
+		t.Fatalf("Error creating jwt: %v", err)
+	}
+	listActivity("/activity", otherCookie, http.StatusForbidden)
+	listActivity("/jobs/"+jobIdStr+"/activity", otherCookie, http.StatusForbidden)
+	listActivity("/vehicles/"+vehicleIdStr+"/activity", otherCookie, http.StatusForbidden)
+	// audit events can not be changed once recorded
+	if _, err := db.DB.Exec("UPDATE audit_event SET action='edit'"); err == nil {
+		t.Error("Expected audit events to be append only")
+	}
+	log.Print("Successfully listed activity")
+}
+
+// TestTrash
+// Tests deleting a vehicle moves it with its job and task to the trash, and restoring it brings them back
+func TestTrash(t *testing.T) {
+	send := func(method string, path string, cookie *http.Cookie, status int, out any) {
+		req = httptest.NewRequest(method, path, nil)
+		req.Header.Add("Authorization", "Bearer "+cookie.Value)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Fatalf("%v %v: Expted status code %d, got %d", method, path, status, w.Code)
+		}
+		if out != nil {
+			if err := json.NewDecoder(w.Body).Decode(out); err != nil {
+				t.Fatalf("Error decoding response body: %v", err)
+			}
+		}
+	}
+	// create vehicle with a job and task
+	vehicle, err := svc.CreateVehicle(models.NewVehicle{Name: "wrench-turn go test trash vehicle", User: &createdUser.ID})
+	if err != nil {
+		t.Fatalf("Error creating vehicle: %v", err)
+	}
+	job, err := svc.CreateJob(models.NewJob{Name: "wrench-turn go test trash job", Vehicle: &vehicle.ID, User: &createdUser.ID})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	task, err := svc.CreateTask(models.NewTask{Name: "wrench-turn go test trash task"}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	vehicleIdStr, jobIdStr := strconv.FormatInt(vehicle.ID, 10), strconv.FormatInt(job.ID, 10)
+	// delete vehicle, it and its job are gone
+	send("DELETE", "/vehicles/"+vehicleIdStr, jwtCookie, http.StatusOK, nil)
+	send("GET", "/jobs/"+jobIdStr, jwtCookie, http.StatusNotFound, nil)
+	if _, err := svc.GetTaskById(task.ID); err == nil {
+		t.Errorf("Expected task %d to be trashed with its vehicle", task.ID)
+	}
+	// only the vehicle is listed, its job is restored with it
+	var items []*models.TrashItem
+	send("GET", "/trash", jwtCookie, http.StatusOK, &items)
+	found := false
+	for _, item := range items {
+		if item.Type == "job" && item.ID == job.ID {
+			t.Errorf("Expected job %d trashed with its vehicle to be left out of trash", job.ID)
+		}
+		if item.Type == "vehicle" && item.ID == vehicle.ID {
+			found = item.Name == vehicle.Name && !item.Deleted_at.IsZero()
+		}
+	}
+	if !found {
+		t.Fatalf("Expected vehicle %d in trash", vehicle.ID)
+	}
+	send("POST", "/trash/job/"+jobIdStr+"/restore", jwtCookie, http.StatusNotFound, nil)
+	send("POST", "/trash/label/"+jobIdStr+"/restore", jwtCookie, http.StatusBadRequest, nil)
+	// non admin users can only restore their own trash
+	otherCookie, err := services.CreateJWT(createdUser.ID+1000, "wrench-turn_go_test_trash", false, "wrenchturn-jwt")
+	if err != nil {
+		t.Fatalf("Error creating jwt: %v", err)
+	}
+	send("POST", "/trash/vehicle/"+vehicleIdStr+"/restore", otherCookie, http.StatusNotFound, nil)
+	send("POST", "/trash/user/"+strconv.FormatInt(createdUser.ID, 10)+"/restore", otherCookie, http.StatusForbidden, nil)
+	send("GET", "/trash?user="+strconv.FormatInt(createdUser.ID, 10), otherCookie, http.StatusForbidden, nil)
+	// restore vehicle, its job and task come back with it
+	var restored *models.Vehicle
+	send("POST", "/trash/vehicle/"+vehicleIdStr+"/restore", jwtCookie, http.StatusOK, &restored)
+	if restored.ID != vehicle.ID || restored.Deleted_at != nil {
+		t.Errorf("Expected vehicle %d to be restored, got %d deleted at %v", vehicle.ID, restored.ID, restored.Deleted_at)
+	}
+	send("GET", "/jobs/"+jobIdStr, jwtCookie, http.StatusOK, nil)
+	if _, err := svc.GetTaskById(task.ID); err != nil {
+		t.Errorf("Expected task %d to be restored: %v", task.ID, err)
+	}
+	// delete vehicle again and purge it, it can not be restored anymore
+	send("DELETE", "/vehicles/"+vehicleIdStr, jwtCookie, http.StatusOK, nil)
+	if purged, err := svc.PurgeTrash(-1); err != nil || purged < 2 {
+		t.Fatalf("Expected vehicle and job purged, got %d: %v", purged, err)
+	}
+	send("POST", "/trash/vehicle/"+vehicleIdStr+"/restore", jwtCookie, http.StatusNotFound, nil)
+	log.Print("Successfully trashed and restored vehicle")
+}
+
+// TestTrashUser
+// Tests deleting a user moves their vehicles, jobs, tasks, alerts, documents and labels to the trash with them, restoring brings them back and purging leaves nothing behind
+func TestTrashUser(t *testing.T) {
+	send := func(method string, path string, status int) {
+		req = httptest.NewRequest(method, path, nil)
+		req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Fatalf("%v %v: Expted status code %d, got %d", method, path, status, w.Code)
+		}
+	}
+	// create user owning a vehicle with a job, task, alert, document and labelled job
+	user, err := svc.CreateUser(models.NewUser{Username: "wrench-turn_go_test_trash_user"})
+	if err != nil {
+		t.Fatalf("Error creating user: %v", err)
+	}
+	vehicle, err := svc.CreateVehicle(models.NewVehicle{Name: "wrench-turn go test trash user vehicle", User: &user.ID})
+	if err != nil {
+		t.Fatalf("Error creating vehicle: %v", err)
+	}
+	job, err := svc.CreateJob(models.NewJob{Name: "wrench-turn go test trash user job", Vehicle: &vehicle.ID, User: &user.ID})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	task, err := svc.CreateTask(models.NewTask{Name: "wrench-turn go test trash user task"}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	alertName := "wrench-turn go test trash user alert"
+	alert, err := svc.CreateAlert(models.NewAlert{Name: &alertName, User: &user.ID})
+	if err != nil {
+		t.Fatalf("Error creating alert: %v", err)
+	}
+	document, err := svc.CreateDocument(models.NewDocument{Type: "other"}, *vehicle)
+	if err != nil {
+		t.Fatalf("Error creating document: %v", err)
+	}
+	label, err := svc.CreateLabel(models.NewLabel{Name: "wrench-turn go test trash user label", User: &user.ID})
+	if err != nil {
+		t.Fatalf("Error creating label: %v", err)
+	}
+	if _, err = svc.AssignJobLabel(job.ID, label.ID, 1); err != nil {
+		t.Fatalf("Error assigning label: %v", err)
+	}
+	// looks up each record of the users data, returns the error of each lookup
+	lookup := func() []error {
+		var errs []error
+		_, err := svc.GetVehicle(vehicle.ID)
+		errs = append(errs, err)
+		_, err = svc.GetJob(job.ID)
+		errs = append(errs, err)
+		_, err = svc.GetTaskById(task.ID)
+		errs = append(errs, err)
+		_, err = svc.GetAlert(alert.ID)
+		errs = append(errs, err)
+		_, err = svc.GetDocument(vehicle.ID, document.ID)
+		errs = append(errs, err)
+		_, err = svc.GetLabel(label.ID)
+		errs = append(errs, err)
+		return errs
+	}
+	userIdStr, vehicleIdStr := strconv.FormatInt(user.ID, 10), strconv.FormatInt(vehicle.ID, 10)
+	// delete user, everything they own goes with them
+	send("DELETE", "/users/"+user.Username, http.StatusOK)
+	for i, err := range lookup() {
+		if err == nil {
+			t.Errorf("Expected lookup %d of trashed users data to fail", i)
+		}
+	}
+	// only the user is listed, their vehicle can only be restored with them
+	items, err := svc.ListTrash(&userIdStr)
+	if err != nil || len(items) != 1 || items[0].Type != "user" || items[0].ID != user.ID {
+		t.Fatalf("Expected only user %d in their trash, got %+v: %v", user.ID, items, err)
+	}
+	send("POST", "/trash/vehicle/"+vehicleIdStr+"/restore", http.StatusNotFound)
+	// restore user, everything comes back with them
+	send("POST", "/trash/user/"+userIdStr+"/restore", http.StatusOK)
+	for i, err := range lookup() {
+		if err != nil {
+			t.Errorf("Expected lookup %d of restored users data to succeed: %v", i, err)
+		}
+	}
+	if restoredJob, err := svc.GetJob(job.ID); err != nil || len(restoredJob.Labels) != 1 || restoredJob.Labels[0].ID != label.ID {
+		t.Errorf("Expected restored job to keep its label, got %+v: %v", restoredJob, err)
+	}
+	// delete and purge user, no rows of theirs are left behind
+	send("DELETE", "/users/"+user.Username, http.StatusOK)
+	if _, err := svc.PurgeTrash(-1); err != nil {
+		t.Fatalf("Error purging trash: %v", err)
+	}
+	for _, q := range []string{
+		"SELECT COUNT(*) FROM user WHERE id=?1",
+		"SELECT COUNT(*) FROM vehicle WHERE user=?1",
+		"SELECT COUNT(*) FROM job WHERE user=?1",
+		"SELECT COUNT(*) FROM task WHERE job IN (SELECT id FROM job WHERE user=?1) OR id=?2",
+		"SELECT COUNT(*) FROM alert WHERE user=?1",
+		"SELECT COUNT(*) FROM vehicle_document WHERE user=?1",
+		"SELECT COUNT(*) FROM label WHERE user=?1",
+		"SELECT COUNT(*) FROM job_label WHERE label=?3 OR job IN (SELECT job FROM task WHERE id=?2) OR job IN (SELECT id FROM job WHERE user=?1)",
+	} {
+		var count int
+		if err := db.DB.QueryRow(q, user.ID, task.ID, label.ID).Scan(&count); err != nil || count != 0 {
+			t.Errorf("Expected no rows left for %q, got %d: %v", q, count, err)
+		}
+	}
+	send("POST", "/trash/user/"+userIdStr+"/restore", http.StatusNotFound)
+	log.Print("Successfully trashed, restored and purged user with their data")
+}
+
+// TestComments
+// Tests commenting on a job, editing with history, alerting participants and authorization against job access
+func TestComments(t *testing.T) {
+	send := func(method string, path string, cookie *http.Cookie, body any, status int, out any) {
+		var reader io.Reader
+		if body != nil {
+			jsonData, err := json.Marshal(body)
+			if err != nil {
+				t.Fatalf("Error encoding request body: %v", err)
+			}
+			reader = bytes.NewReader(jsonData)
+		}
+		req = httptest.NewRequest(method, path, reader)
+		req.Header.Add("Authorization", "Bearer "+cookie.Value)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Fatalf("%v %v: Expted status code %d, got %d", method, path, status, w.Code)
+		}
+		if out != nil {
+			if err := json.NewDecoder(w.Body).Decode(out); err != nil {
+				t.Fatalf("Error decoding response body: %v", err)
+			}
+		}
+	}
+	newUser := func(username string) (*models.User, *http.Cookie) {
+		password := "comment-test-password"
+		user, err := svc.CreateUser(models.NewUser{Username: username, Password: &password})
+		if err != nil {
+			t.Fatalf("Error creating user: %v", err)
+		}
+		cookie, err := services.CreateJWT(user.ID, username, false, "wrenchturn-jwt")
+		if err != nil {
+			t.Fatalf("Error creating jwt: %v", err)
+		}
+		return user, cookie
+	}
+	alertCount := func(userId int64, jobId int64) int {
+		userIdStr, jobIdStr, empty := strconv.FormatInt(userId, 10), strconv.FormatInt(jobId, 10), ""
+		alerts, err := svc.ListAlerts(&userIdStr, &empty, &jobIdStr, &empty, &empty, &empty, &empty, &empty, &empty, nil)
+		if err != nil {
+			t.Fatalf("Error listing alerts: %v", err)
+		}
+		return len(alerts)
+	}
+	// job of a non admin user, commented on by the admin test user
+	owner, ownerCookie := newUser("wrench-turn_go_test_comment_owner")
+	_, otherCookie := newUser("wrench-turn_go_test_comment_other")
+	job, err := svc.CreateJob(models.NewJob{Name: "wrench-turn go test comment job", User: &owner.ID})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	task, err := svc.CreateTask(models.NewTask{Name: "wrench-turn go test comment task"}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	commentsPath := "/jobs/" + strconv.FormatInt(job.ID, 10) + "/comments"
+	notify := 1
+	var comment *models.Comment
+	send("POST", commentsPath+"/create", jwtCookie, models.NewComment{Body: "Check the **torque** specs", Task: &task.ID, Notify: &notify}, http.StatusCreated, &comment)
+	if comment.User != createdUser.ID || comment.Task == nil || *comment.Task != task.ID {
+		t.Errorf("Expected comment by user %d on task %d, got user %d task %v", createdUser.ID, task.ID, comment.User, comment.Task)
+	}
+	// owner is alerted, then replying alerts the admin who commented before
+	if count := alertCount(owner.ID, job.ID); count != 1 {
+		t.Errorf("Expected 1 alert for job owner, got %d", count)
+	}
+	send("POST", commentsPath+"/create", ownerCookie, models.NewComment{Body: "Done", Notify: &notify}, http.StatusCreated, nil)
+	if count := alertCount(createdUser.ID, job.ID); count != 1 {
+		t.Errorf("Expected 1 alert for previous commenter, got %d", count)
+	}
+	// comments on a task of another job are refused
+	otherTask, err := svc.CreateTask(models.NewTask{Name: "wrench-turn go test comment other task"}, createdJob.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	send("POST", commentsPath+"/create", jwtCookie, models.NewComment{Body: "Wrong job", Task: &otherTask.ID}, http.StatusNotFound, nil)
+	if err := svc.DeleteTask(createdJob.ID, &otherTask.ID); err != nil {
+		t.Fatalf("Error deleting task: %v", err)
+	}
+	// oldest first, only the task comment when filtered
+	var comments []*models.Comment
+	send("GET", commentsPath, ownerCookie, nil, http.StatusOK, &comments)
+	if len(comments) != 2 || comments[0].ID != comment.ID {
+		t.Fatalf("Expected 2 comments starting with %d, got %d", comment.ID, len(comments))
+	}
+	send("GET", commentsPath+"?task="+strconv.FormatInt(task.ID, 10), ownerCookie, nil, http.StatusOK, &comments)
+	if len(comments) != 1 {
+		t.Errorf("Expected 1 comment on task, got %d", len(comments))
+	}
+	// only the author can edit, the previous body is kept in its history
+	commentPath := commentsPath + "/" + strconv.FormatInt(comment.ID, 10)
+	edit := *comment
+	edit.Body = "Check the torque specs twice"
+	send("POST", commentsPath+"/edit", ownerCookie, edit, http.StatusForbidden, nil)
+	send("POST", commentsPath+"/edit", jwtCookie, edit, http.StatusOK, &comment)
+	if comment.Body != edit.Body {
+		t.Errorf("Expected body %q, got %q", edit.Body, comment.Body)
+	}
+	var revisions []*models.CommentRevision
+	send("GET", commentPath+"/history", ownerCookie, nil, http.StatusOK, &revisions)
+	if len(revisions) != 1 || revisions[0].Body != "Check the **torque** specs" || revisions[0].User == nil || *revisions[0].User != createdUser.ID {
+		t.Errorf("Expected previous body edited by user %d in history, got %d revisions", createdUser.ID, len(revisions))
+	}
+	// anyone who can read the job can read and join its discussion, alerting earlier participants
+	send("GET", commentsPath, otherCookie, nil, http.StatusOK, &comments)
+	if len(comments) != 2 {
+		t.Errorf("Expected 2 comments readable by other user, got %d", len(comments))
+	}
+	send("GET", commentPath, otherCookie, nil, http.StatusOK, nil)
+	send("GET", commentPath+"/history", otherCookie, nil, http.StatusOK, nil)
+	var otherComment *models.Comment
+	send("POST", commentsPath+"/create", otherCookie, models.NewComment{Body: "Hi", Notify: &notify}, http.StatusCreated, &otherComment)
+	if count := alertCount(owner.ID, job.ID); count != 2 {
+		t.Errorf("Expected 2 alerts for job owner, got %d", count)
+	}
+	// but not edit or delete comments of others, not even admins
+	otherEdit := *otherComment
+	otherEdit.Body = "Hello"
+	send("POST", commentsPath+"/edit", jwtCookie, otherEdit, http.StatusForbidden, nil)
+	send("DELETE", commentsPath+"/"+strconv.FormatInt(otherComment.ID, 10), jwtCookie, nil, http.StatusForbidden, nil)
+	send("POST", commentsPath+"/edit", otherCookie, edit, http.StatusForbidden, nil)
+	send("DELETE", commentPath, otherCookie, nil, http.StatusForbidden, nil)
+	// only the author can delete
+	send("DELETE", commentPath, ownerCookie, nil, http.StatusForbidden, nil)
+	send("DELETE", commentPath, jwtCookie, nil, http.StatusOK, nil)
+	send("GET", commentPath, jwtCookie, nil, http.StatusNotFound, nil)
+	send("GET", "/jobs/0/comments", otherCookie, nil, http.StatusNotFound, nil)
+	// trash job with the alerts about its comments
+	if err := svc.DeleteJob(job.ID, nil); err != nil {
+		t.Fatalf("Error deleting job: %v", err)
+	}
+	log.Print("Successfully commented on job")
+}
+
+// TestTimeTracking
+// Tests starting and stopping timers on tasks, time rolled up per job and estimate reports against job access
+func TestTimeTracking(t *testing.T) {
+	send := func(method string, path string, cookie *http.Cookie, body any, status int, out any) {
+		var reader io.Reader
+		if body != nil {
+			jsonData, err := json.Marshal(body)
+			if err != nil {
+				t.Fatalf("Error encoding request body: %v", err)
+			}
+			reader = bytes.NewReader(jsonData)
+		}
+		req = httptest.NewRequest(method, path, reader)
+		req.Header.Add("Authorization", "Bearer "+cookie.Value)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Fatalf("%v %v: Expted status code %d, got %d", method, path, status, w.Code)
+		}
+		if out != nil {
+			if err := json.NewDecoder(w.Body).Decode(out); err != nil {
+				t.Fatalf("Error decoding response body: %v", err)
+			}
+		}
+	}
+	newUser := func(username string) (*models.User, *http.Cookie) {
+		password := "time-test-password"
+		user, err := svc.CreateUser(models.NewUser{Username: username, Password: &password})
+		if err != nil {
+			t.Fatalf("Error creating user: %v", err)
+		}
+		cookie, err := services.CreateJWT(user.ID, username, false, "wrenchturn-jwt")
+		if err != nil {
+			t.Fatalf("Error creating jwt: %v", err)
+		}
+		return user, cookie
+	}
+	// job of a non admin user with an estimated task
+	owner, ownerCookie := newUser("wrench-turn_go_test_time_owner")
+	_, otherCookie := newUser("wrench-turn_go_test_time_other")
+	job, err := svc.CreateJob(models.NewJob{Name: "wrench-turn go test time job", User: &owner.ID})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	jobPath := "/jobs/" + strconv.FormatInt(job.ID, 10)
+	negative, estimate := int64(-5), int64(45)
+	send("POST", jobPath+"/tasks/create", ownerCookie, models.NewTask{Name: "wrench-turn go test time task", Estimated_minutes: &negative}, http.StatusUnprocessableEntity, nil)
+	task, err := svc.CreateTask(models.NewTask{Name: "wrench-turn go test time task", Estimated_minutes: &estimate}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	if task.Estimated_minutes == nil || *task.Estimated_minutes != estimate {
+		t.Fatalf("Expected task estimated at %d minutes, got %v", estimate, task.Estimated_minutes)
+	}
+	timerPath := jobPath + "/tasks/" + strconv.FormatInt(task.ID, 10) + "/timer"
+	// one running timer per user and task
+	var entry *models.TimeEntry
+	send("POST", timerPath+"/start", ownerCookie, nil, http.StatusCreated, &entry)
+	if entry.Task != task.ID || entry.User != owner.ID || entry.Stopped_at != nil {
+		t.Errorf("Expected running timer of user %d on task %d, got %+v", owner.ID, task.ID, entry)
+	}
+	send("POST", timerPath+"/start", ownerCookie, nil, http.StatusConflict, nil)
+	send("POST", timerPath+"/start", otherCookie, nil, http.StatusForbidden, nil)
+	send("GET", "/timer", ownerCookie, nil, http.StatusOK, &entry)
+	send("POST", timerPath+"/stop", ownerCookie, nil, http.StatusOK, &entry)
+	if entry.Stopped_at == nil {
+		t.Errorf("Expected timer to be stopped")
+	}
+	send("POST", timerPath+"/stop", ownerCookie, nil, http.StatusNotFound, nil)
+	send("GET", "/timer", ownerCookie, nil, http.StatusNotFound, nil)
+	// time rolled up against estimates
+	var jobTime *models.JobTime
+	send("GET", jobPath+"/time", ownerCookie, nil, http.StatusOK, &jobTime)
+	if jobTime.Estimated_minutes != estimate || jobTime.Running != 0 || len(jobTime.Tasks) != 1 || len(jobTime.Users) != 1 || jobTime.Users[0].User != owner.ID {
+		t.Errorf("Expected %d estimated minutes tracked by user %d, got %+v", estimate, owner.ID, jobTime)
+	}
+	var entries []*models.TimeEntry
+	send("GET", jobPath+"/time/entries?user="+strconv.FormatInt(owner.ID, 10), ownerCookie, nil, http.StatusOK, &entries)
+	if len(entries) != 1 || entries[0].ID != entry.ID {
+		t.Errorf("Expected time entry %d, got %d entries", entry.ID, len(entries))
+	}
+	send("GET", jobPath+"/time", otherCookie, nil, http.StatusForbidden, nil)
+	// estimate reports cover own jobs unless admin
+	var report *models.EstimateReport
+	send("GET", "/reports/estimates", ownerCookie, nil, http.StatusOK, &report)
+	if report.User == nil || *report.User != owner.ID || len(report.Labels) != 0 {
+		t.Errorf("Expected empty report of user %d before tasks are complete, got %+v", owner.ID, report)
+	}
+	if err := svc.MarkComplete(job.ID, task.ID, 1, false); err != nil {
+		t.Fatalf("Error completing task: %v", err)
+	}
+	send("GET", "/reports/estimates?user="+strconv.FormatInt(owner.ID, 10), jwtCookie, nil, http.StatusOK, &report)
+	if len(report.Labels) != 1 || report.Labels[0].Label != nil || report.Labels[0].Estimated_minutes != estimate {
+		t.Errorf("Expected %d estimated minutes of unlabeled jobs, got %+v", estimate, report.Labels)
+	}
+	send("GET", "/reports/estimates?user="+strconv.FormatInt(owner.ID, 10), otherCookie, nil, http.StatusForbidden, nil)
+	if err := svc.DeleteJob(job.ID, nil); err != nil {
+		t.Fatalf("Error deleting job: %v", err)
+	}
+	log.Print("Successfully tracked time on task")
+}
+
+// TestEvents
+// Tests the live update stream pushes changes to users who can access them and resumes after Last-Event-ID
+func TestEvents(t *testing.T) {
+	server := httptest.NewServer(r)
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	newUser := func(username string) (*models.User, *http.Cookie) {
+		password := "events-test-password"
+		user, err := svc.CreateUser(models.NewUser{Username: username, Password: &password})
+		if err != nil {
+			t.Fatalf("Error creating user: %v", err)
+		}
+		cookie, err := services.CreateJWT(user.ID, username, false, "wrenchturn-jwt")
+		if err != nil {
+			t.Fatalf("Error creating jwt: %v", err)
+		}
+		return user, cookie
+	}
+	// stream returns events received by user, the subscription is open once it returns
+	stream := func(cookie *http.Cookie) <-chan models.Event {
+		streamReq, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/events", nil)
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		streamReq.Header.Add("Authorization", "Bearer "+cookie.Value)
+		res, err := http.DefaultClient.Do(streamReq)
+		if err != nil {
+			t.Fatalf("Error opening event stream: %v", err)
+		}
+		if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("Expted status code %d with event stream, got %d and %v", http.StatusOK, res.StatusCode, res.Header.Get("Content-Type"))
+		}
+		events := make(chan models.Event, 16)
+		go func() {
+			defer res.Body.Close()
+			defer close(events)
+			lines := bufio.NewScanner(res.Body)
+			for lines.Scan() {
+				data, ok := strings.CutPrefix(lines.Text(), "data: ")
+				var event models.Event
+				if ok && json.Unmarshal([]byte(data), &event) == nil {
+					events <- event
+				}
+			}
+		}()
+		return events
+	}
+	next := func(events <-chan models.Event) models.Event {
+		select {
+		case event := <-events:
+			return event
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for event")
+		}
+		return models.Event{}
+	}
+	owner, ownerCookie := newUser("wrench-turn_go_test_events_owner")
+	other, otherCookie := newUser("wrench-turn_go_test_events_other")
+	job, err := svc.CreateJob(models.NewJob{Name: "wrench-turn go test events job", User: &owner.ID})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	task, err := svc.CreateTask(models.NewTask{Name: "wrench-turn go test events task"}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	ownerEvents, otherEvents := stream(ownerCookie), stream(otherCookie)
+	// completing a task from another device reaches the job owner
+	req = httptest.NewRequest("PATCH", "/jobs/"+strconv.FormatInt(job.ID, 10)+"/tasks/"+strconv.FormatInt(task.ID, 10)+"/complete", nil)
+	req.Header.Add("Authorization", "Bearer "+ownerCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	completed := next(ownerEvents)
+	if completed.Type != "update" || completed.Action != "complete" || completed.Entity != "task" || completed.Entity_id != task.ID || completed.Job == nil || *completed.Job != job.ID {
+		t.Errorf("Expected update of task %d, got %+v", task.ID, completed)
+	}
+	// other users only get events of their own entities
+	otherJob, err := svc.CreateJob(models.NewJob{Name: "wrench-turn go test events other job", User: &other.ID})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	if created := next(otherEvents); created.Type != "create" || created.Entity != "job" || created.Entity_id != otherJob.ID {
+		t.Errorf("Expected only creation of job %d, got %+v", otherJob.ID, created)
+	}
+	// reconnecting replays missed events, or resets if they are gone
+	c := client.New(server.URL)
+	c.Token = ownerCookie.Value
+	stop := errors.New("stop")
+	var replayed models.Event
+	beforeCompleted := completed.ID - 1
+	err = c.StreamEvents(ctx, &beforeCompleted, func(event models.Event) error {
+		replayed = event
+		return stop
+	})
+	if !errors.Is(err, stop) || replayed.ID != completed.ID {
+		t.Errorf("Expected replay of event %d, got %+v and %v", completed.ID, replayed, err)
+	}
+	future := completed.ID + 1000000
+	err = c.StreamEvents(ctx, &future, func(event models.Event) error {
+		replayed = event
+		return stop
+	})
+	if !errors.Is(err, stop) || replayed.Type != "reset" {
+		t.Errorf("Expected reset event, got %+v and %v", replayed, err)
+	}
+	// Last-Event-ID must be an event id, and the stream requires a token
+	req = httptest.NewRequest("GET", "/events", nil)
+	req.Header.Add("Authorization", "Bearer "+ownerCookie.Value)
+	req.Header.Add("Last-Event-ID", "latest")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expted status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	req = httptest.NewRequest("GET", "/events", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expted status code %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+	for _, jobId := range []int64{job.ID, otherJob.ID} {
+		if err := svc.DeleteJob(jobId, nil); err != nil {
+			t.Fatalf("Error deleting job: %v", err)
+		}
+	}
+	log.Print("Successfully streamed events")
+}
+
+// TestGetAndEditLabel
+// Tests getting and editing label created by TestCreateLabel
+func TestGetAndEditLabel(t *testing.T) {
+	// get from api
+	req = httptest.NewRequest("GET", "/labels/"+strconv.FormatInt(createdLabel.ID, 10), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// error if unable to decode response
+	var fetchedLabel *models.Label
+	if err := json.NewDecoder(w.Body).Decode(&fetchedLabel); err != nil {
+		t.Errorf("Error decoding response body: %v", err)
+	}
+
+	// error if returned label ID is not the same as created label ID
+	if fetchedLabel.ID != createdLabel.ID {
+		t.Errorf("Label ID %d fetched a different label, ID %d, than expected", createdLabel.ID, fetchedLabel.ID)
+	}
+	log.Print("Successfully retrieved test label")
+	// change label description
+	testColor := "0400ff"
+	fetchedLabel.Color = &testColor
+	// convert to json
+	jsonData, err := json.Marshal(fetchedLabel)
+	if err != nil {
+		t.Errorf("Error encoding request body: %v", err)
+	}
+	// edit via post req
+	req = httptest.NewRequest("POST", "/labels/edit", bytes.NewReader(jsonData))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusOK {
+		t.Errorf("Expted status code %d, got %d", http.StatusOK, w.Code)
+	}
+	// decode body from json or error
+	if err := json.NewDecoder(w.Body).Decode(&fetchedLabel); err != nil {
+		t.Errorf("Error decoding response body: %v", err)
+	}
+	// compare dscriptions to confirm successful edit
+	if fetchedLabel.Color != &testColor {
+		t.Error("Color was not updated")
+	}
+	log.Print("Successfully edited label")
+}
+
+// TestCreateTask
+// Test creating task on job created by TestCreateJob
+func TestCreateTask(t *testing.T) {
+	// setuop new test job
+	newTask := &models.NewTask{
+		Name: "wrench-turn go test task",
+	}
+	// convert to json
+	jsonData, err := json.Marshal(newTask)
+	if err != nil {
+		t.Errorf("Error encoding request body: %v", err)
+	}
+	log.Println(createdJob.User)
+	log.Println(createdUser.ID)
+	// create via api
+	req = httptest.NewRequest("POST", "/jobs/"+strconv.FormatInt(createdJob.ID, 10)+"/tasks/create", bytes.NewReader(jsonData))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expted status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	// error if unable to decode response
+	if err := json.NewDecoder(w.Body).Decode(&createdTask); err != nil {
+		t.Errorf("Error decoding response body: %v", err)
+	}
+	// create second task to test deletion workflow when job is deleted
+	// create via api
+	req = httptest.NewRequest("POST", "/jobs/"+strconv.FormatInt(createdJob.ID, 10)+"/tasks/create", bytes.NewReader(jsonData))
+	req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// error if unexpected HTTP status
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expted status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	log.Print("Successfully created task")
+}
+
+// TestTaskOrdering
+// Tests reordering tasks, sub-tasks and completing tasks with open prerequisites
+func TestTaskOrdering(t *testing.T) {
+	send := func(method string, path string, body any, status int, out any) {
+		var reader io.Reader
+		if body != nil {
+			jsonData, err := json.Marshal(body)
+			if err != nil {
+				t.Fatalf("Error encoding request body: %v", err)
+			}
+			reader = bytes.NewReader(jsonData)
+		}
+		req = httptest.NewRequest(method, path, reader)
+		req.Header.Add("Authorization", "Bearer "+jwtCookie.Value)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Fatalf("%v %v: Expted status code %d, got %d", method, path, status, w.Code)
+		}
+		if out != nil {
+			if err := json.NewDecoder(w.Body).Decode(out); err != nil {
+				t.Fatalf("Error decoding response body: %v", err)
+			}
+		}
+	}
+	job, err := svc.CreateJob(models.NewJob{Name: "wrench-turn go test ordering job", User: &createdUser.ID})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	tasksPath := "/jobs/" + strconv.FormatInt(job.ID, 10) + "/tasks"
+	// drain, filter as a sub-task of drain, refill after both
+	var drain, filter, refill *models.Task
+	send("POST", tasksPath+"/create", models.NewTask{Name: "Drain oil"}, http.StatusCreated, &drain)
+	send("POST", tasksPath+"/create", models.NewTask{Name: "Replace filter", Parent: &drain.ID}, http.StatusCreated, &filter)
+	send("POST", tasksPath+"/create", models.NewTask{Name: "Refill", Depends_on: []int64{drain.ID, filter.ID}}, http.StatusCreated, &refill)
+	if filter.Parent == nil || *filter.Parent != drain.ID || len(refill.Depends_on) != 2 {
+		t.Errorf("Expected filter under drain and refill after both, got parent %v prerequisites %v", filter.Parent, refill.Depends_on)
+	}
+	send("POST", tasksPath+"/create", models.NewTask{Name: "Other jobs parent", Parent: &createdTask.ID}, http.StatusBadRequest, nil)
+	// listed tasks move to the front, the default list follows job order
+	var tasks []*models.Task
+	send("POST", tasksPath+"/reorder", models.TaskOrder{Tasks: []int64{refill.ID, drain.ID}}, http.StatusOK, nil)
+	send("GET", tasksPath, nil, http.StatusOK, &tasks)
+	if len(tasks) != 3 || tasks[0].ID != refill.ID || tasks[1].ID != drain.ID || tasks[2].ID != filter.ID {
+		t.Fatalf("Expected refill, drain, filter order, got %d tasks", len(tasks))
+	}
+	send("POST", tasksPath+"/reorder", models.TaskOrder{Tasks: []int64{createdTask.ID}}, http.StatusBadRequest, nil)
+	// refill is refused while drain and filter are open, unless forced
+	refillPath := tasksPath + "/" + strconv.FormatInt(refill.ID, 10) + "/complete"
+	send("PATCH", refillPath, nil, http.StatusConflict, nil)
+	send("PATCH", refillPath+"?force=true", nil, http.StatusOK, nil)
+	// deleting drain moves filter up and drops it from refills prerequisites
+	send("DELETE", tasksPath+"/"+strconv.FormatInt(drain.ID, 10), nil, http.StatusOK, nil)
+	send("GET", tasksPath+"/"+strconv.FormatInt(filter.ID, 10), nil, http.StatusOK, &filter)
+	send("GET", tasksPath+"/"+strconv.FormatInt(refill.ID, 10), nil, http.StatusOK, &refill)
+	if filter.Parent != nil || len(refill.Depends_on) != 1 || refill.Depends_on[0] != filter.ID {
+		t.Errorf("Expected filter without parent and refill after filter, got %v and %v", filter.Parent, refill.Depends_on)
+	}
+	if err := svc.DeleteJob(job.ID, nil); err != nil {
+		t.Fatalf("Error deleting job: %v", err)
+	}
+	log.Print("Successfully ordered tasks")
 }
 
 // TestGetTask
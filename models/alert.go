@@ -5,9 +5,9 @@ import (
 )
 
 type NewAlert struct {
-	Name        *string    `json:"name"`
-	Description *string    `json:"description"`
-	Type        string     `json:"type"` // notification or reminder
+	Name        *string    `json:"name" validate:"max=100"`
+	Description *string    `json:"description" validate:"max=2000"`
+	Type        string     `json:"type" validate:"oneof=notification reminder"` // notification or reminder, defaults to notification
 	User        *int64     `json:"user"`
 	Vehicle     *int64     `json:"vehicle"`
 	Job         *int64     `json:"job"`
@@ -17,16 +17,17 @@ type NewAlert struct {
 
 type Alert struct {
 	ID          int64      `json:"id"`
-	Name        *string    `json:"name"`
-	Description *string    `json:"description"`
-	Type        string     `json:"type"`
+	Name        *string    `json:"name" validate:"max=100"`
+	Description *string    `json:"description" validate:"max=2000"`
+	Type        string     `json:"type" validate:"required,oneof=notification reminder"`
 	User        int64      `json:"user"`
 	Vehicle     *int64     `json:"vehicle"`
 	Job         *int64     `json:"job"`
 	Task        *int64     `json:"task"`
-	Is_read     *int       `json:"isRead"`
+	Is_read     *int       `json:"isRead" validate:"oneof=0 1"`
 	Read_at     *time.Time `json:"readAt"`
 	Alert_at    *time.Time `json:"alertAt"`
 	Created_at  time.Time  `json:"createdAt"`
 	Updated_at  time.Time  `json:"updatedAt"`
+	Deleted_at  *time.Time `json:"deletedAt"`
 }
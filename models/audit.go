@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// used for entries of the append only audit log
+type AuditEvent struct {
+	ID     int64  `json:"id"`
+	Actor  *int64 `json:"actor"`  // user who made the change, nil if made by the server itself
+	Action string `json:"action"` // create, edit, delete, restore, complete, read, assign or unassign
+	// changed entity, e.g. job, task, vehicle
+	Entity    string `json:"entity"`
+	Entity_id int64  `json:"entityId"`
+	// vehicle and job the entity belongs to, used by their activity feeds
+	Vehicle *int64 `json:"vehicle"`
+	Job     *int64 `json:"job"`
+	// changed fields, by json name
+	Diff       map[string]AuditChange `json:"diff"`
+	Created_at time.Time              `json:"createdAt"`
+}
+
+// used for the value of a field before and after a change, nil if it did not exist
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
@@ -4,8 +4,8 @@ import "github.com/golang-jwt/jwt/v5"
 
 // used for auth purposes
 type Credentials struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 // used for storing data in JWT
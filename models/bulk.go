@@ -0,0 +1,24 @@
+package models
+
+// used for bulk operation request bodies
+type BulkRequest struct {
+	IDs []int64 `json:"ids" validate:"required,max=500"`
+	// atomic (default) changes nothing if any item fails, best_effort keeps the items that succeeded
+	Mode *string `json:"mode" validate:"oneof=atomic best_effort"`
+}
+
+// used to report the outcome of a single item of a bulk operation
+type BulkItemResult struct {
+	ID     int64   `json:"id"`
+	Status int     `json:"status"` // http status of the item, 424 if it was rolled back because another item failed
+	Error  *string `json:"error"`
+}
+
+// used to report a bulk operation, nothing is changed unless Committed
+type BulkResult struct {
+	Mode      string           `json:"mode"`
+	Committed bool             `json:"committed"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
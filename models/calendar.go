@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// used for a users calendar feed token
+type CalendarToken struct {
+	ID         int64     `json:"id"`
+	User       int64     `json:"user"`
+	Token      string    `json:"token"`
+	Url        string    `json:"url"`
+	Created_at time.Time `json:"createdAt"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// used for new comment forms
+type NewComment struct {
+	Body   string `json:"body" validate:"required,max=10000"` // markdown
+	Task   *int64 `json:"task"`                               // task of the job the comment is about, nil for the job itself
+	Notify *int   `json:"notify" validate:"oneof=0 1"`        // alert the job owner and everyone else who commented on the job
+}
+
+// used for existing comments on jobs and their tasks
+type Comment struct {
+	ID         int64     `json:"id"`
+	Job        int64     `json:"job"`
+	Task       *int64    `json:"task"`
+	User       int64     `json:"user"` // author
+	Body       string    `json:"body" validate:"required,max=10000"`
+	Created_at time.Time `json:"createdAt"`
+	Updated_at time.Time `json:"updatedAt"`
+}
+
+// used for the previous bodies of an edited comment
+type CommentRevision struct {
+	ID         int64     `json:"id"`
+	Comment    int64     `json:"comment"`
+	Body       string    `json:"body"`      // body before the edit
+	User       *int64    `json:"user"`      // user who made the edit
+	Created_at time.Time `json:"createdAt"` // time of the edit
+}
@@ -0,0 +1,18 @@
+package models
+
+// used to report a problem with a single csv row
+type CSVRowError struct {
+	Row    int    `json:"row"` // line in file, header is row 1
+	Column string `json:"column"`
+	Error  string `json:"error"`
+}
+
+// used to report a csv import, previews include the parsed records but create nothing
+type CSVImportResult struct {
+	Preview bool              `json:"preview"`
+	Rows    int               `json:"rows"`
+	Columns map[string]string `json:"columns"` // csv header to field, unmapped headers are ignored
+	Records interface{}       `json:"records"`
+	Created []int64           `json:"created"`
+	Errors  []CSVRowError     `json:"errors"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// used for new vehicle document forms
+type NewDocument struct {
+	// meta data
+	Type        string  `json:"type" validate:"required,oneof=registration insurance inspection other"` // registration, insurance, inspection or other
+	Number      *string `json:"number" validate:"max=100"`
+	Issuer      *string `json:"issuer" validate:"max=100"`
+	Description *string `json:"description" validate:"max=2000"`
+	// times
+	Issued_at  *time.Time `json:"issuedAt"`
+	Expires_at *time.Time `json:"expiresAt"`
+	// reminders
+	Remind_days *int64 `json:"remindDays" validate:"min=0"`
+}
+
+// used for existing vehicle document data
+type Document struct {
+	// meta data
+	ID          int64   `json:"id"`
+	Type        string  `json:"type" validate:"required,oneof=registration insurance inspection other"`
+	Number      *string `json:"number" validate:"max=100"`
+	Issuer      *string `json:"issuer" validate:"max=100"`
+	Description *string `json:"description" validate:"max=2000"`
+	// ownership
+	Vehicle int64 `json:"vehicle"`
+	User    int64 `json:"user"`
+	// times
+	Issued_at  *time.Time `json:"issuedAt"`
+	Expires_at *time.Time `json:"expiresAt"`
+	// reminders
+	Remind_days int64  `json:"remindDays" validate:"min=0"`
+	Alert       *int64 `json:"alert"`
+	// attachment
+	Attachment_name *string `json:"attachmentName"`
+	Attachment_type *string `json:"attachmentType"`
+	// times
+	Created_at time.Time `json:"createdAt"`
+	Updated_at time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// used for change events pushed to the live update stream
+type Event struct {
+	ID     int64  `json:"id"`     // position in the stream, sent as the SSE id for Last-Event-ID
+	Type   string `json:"type"`   // create, update or delete
+	Action string `json:"action"` // audit action behind the event, e.g. complete
+	// changed entity, job, task, alert or vehicle
+	Entity    string `json:"entity"`
+	Entity_id int64  `json:"entityId"`
+	User      *int64 `json:"user"` // owner of the entity, only they and admins receive the event
+	// vehicle and job the entity belongs to
+	Vehicle *int64 `json:"vehicle"`
+	Job     *int64 `json:"job"`
+	// changed fields, by json name
+	Diff       map[string]AuditChange `json:"diff"`
+	Actor      *int64                 `json:"actor"`
+	Created_at time.Time              `json:"createdAt"`
+}
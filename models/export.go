@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// used for portable account backups, bump version when the format changes
+type AccountExport struct {
+	Version     int       `json:"version" validate:"required"`
+	Exported_at time.Time `json:"exportedAt"`
+	Username    string    `json:"username" validate:"max=50"`
+	// data, relationships refer to ids within the export, each record is validated like its edit form
+	Vehicles []Vehicle `json:"vehicles"`
+	Labels   []Label   `json:"labels"`
+	Jobs     []Job     `json:"jobs"`
+	Tasks    []Task    `json:"tasks"`
+	Alerts   []Alert   `json:"alerts"`
+}
+
+// used to report what an import did, or would do on a dry run
+type ImportResult struct {
+	Dry_run   bool             `json:"dryRun"`
+	Created   map[string]int   `json:"created"`
+	Conflicts []ImportConflict `json:"conflicts"`
+}
+
+// used to report a conflict found during import and how it was resolved
+type ImportConflict struct {
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	Resolution string `json:"resolution"` // merged or renamed
+	Existing   int64  `json:"existing"`
+}
+
+// used to import an AccountExport for a user in a single transaction, label conflicts are resolved beforehand
+type AccountImport struct {
+	User          int64
+	Export        AccountExport   // labels are the ones to create, under their resolved names
+	Merged_labels map[int64]int64 // exported label ids to the existing labels they merge into
+}
+
+// used to map ids within an AccountExport to the ids they were imported with
+type ImportedIds struct {
+	Vehicles map[int64]int64
+	Labels   map[int64]int64
+	Jobs     map[int64]int64
+	Tasks    map[int64]int64
+	Alerts   map[int64]int64
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// used for new fuel log forms and csv imports
+type NewFuelLog struct {
+	Filled_at *time.Time `json:"filledAt"`
+	Odometer  *int64     `json:"odometer" validate:"min=0"`
+	Volume    float64    `json:"volume" validate:"min=0"` // gallons or litres, following the vehicle
+	Cost      *float64   `json:"cost" validate:"min=0"`   // total paid for the fill up
+	Is_full   *int       `json:"isFull" validate:"oneof=0 1"`
+	Station   *string    `json:"station" validate:"max=100"`
+	Notes     *string    `json:"notes" validate:"max=2000"`
+}
+
+// used for existing fuel logs
+type FuelLog struct {
+	ID         int64     `json:"id"`
+	Vehicle    int64     `json:"vehicle"`
+	User       *int64    `json:"user"`
+	Filled_at  time.Time `json:"filledAt"`
+	Odometer   *int64    `json:"odometer"`
+	Volume     float64   `json:"volume"`
+	Cost       *float64  `json:"cost"`
+	Is_full    int       `json:"isFull"`
+	Station    *string   `json:"station"`
+	Notes      *string   `json:"notes"`
+	Created_at time.Time `json:"createdAt"`
+}
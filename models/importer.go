@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// used for a service or fuel entry read from another maintenance tracker
+type TrackerRecord struct {
+	Kind        string    `json:"kind"`    // service or fuel
+	Vehicle     string    `json:"vehicle"` // vehicle name in source app, empty for per vehicle exports
+	Date        time.Time `json:"date"`
+	Odometer    *int64    `json:"odometer"`
+	Name        string    `json:"name"`
+	Tasks       []string  `json:"tasks"`
+	Cost        *float64  `json:"cost"`
+	Shop        *string   `json:"shop"`
+	Notes       *string   `json:"notes"`
+	Fuel_volume *float64  `json:"fuelVolume"`
+}
+
+// used to report an import from another maintenance tracker, previews include the records but create nothing
+type TrackerImportResult struct {
+	Format            string          `json:"format"`
+	Preview           bool            `json:"preview"`
+	Rows              int             `json:"rows"`
+	Vehicles_created  int             `json:"vehiclesCreated"`
+	Jobs              int             `json:"jobs"`
+	Tasks             int             `json:"tasks"`
+	Odometer_readings int             `json:"odometerReadings"`
+	Fuel_logs         int             `json:"fuelLogs"`
+	Fuel_cost         float64         `json:"fuelCost"` // total of all fuel logs
+	Records           []TrackerRecord `json:"records"`
+	Errors            []CSVRowError   `json:"errors"`
+}
+
+// used for a record of a TrackerImport, on an existing vehicle or one created by the import
+type TrackerImportRecord struct {
+	Record      TrackerRecord
+	Fuel_log    *NewFuelLog // fuel log to create for fuel records
+	Vehicle     int64       // existing vehicle id, 0 if created by the import
+	New_vehicle int         // index in TrackerImport.Vehicles if Vehicle is 0
+}
+
+// used to commit an import from another maintenance tracker, service records become completed jobs and fuel records fuel logs
+type TrackerImport struct {
+	User     int64
+	Vehicles []NewVehicle // vehicles to create
+	Records  []TrackerImportRecord
+}
+
+// used to report the ids created by a TrackerImport
+type TrackerImportedIds struct {
+	Vehicles  []int64
+	Jobs      []int64
+	Fuel_logs []int64
+}
@@ -5,45 +5,103 @@ import "time"
 // used for new job forms
 type NewJob struct {
 	// meta data
-	Name         string  `json:"name"`
-	Description  *string `json:"description"`
-	Instructions *string `json:"instructions"`
-	Is_template  *int    `json:"isTemplate"`
+	Name         string  `json:"name" validate:"required,max=100"`
+	Description  *string `json:"description" validate:"max=2000"`
+	Instructions *string `json:"instructions" validate:"max=10000"`
+	Is_template  *int    `json:"isTemplate" validate:"oneof=0 1"`
+	Status       *string `json:"status" validate:"oneof=planned parts_ordered in_progress blocked done skipped"`
 	// ownership
 	Vehicle    *int64 `json:"vehicle"`
 	User       *int64 `json:"user"`
 	Origin_job *int64 `json:"originJob"`
 	// repeats
-	Repeats            *int    `json:"repeats"`
-	Odo_interval       *int64  `json:"odoInterval"`
-	Time_interval      *int64  `json:"timeInterval"`
-	Time_interval_unit *string `json:"timeIntervalUnit"`
+	Repeats            *int    `json:"repeats" validate:"oneof=0 1"`
+	Odo_interval       *int64  `json:"odoInterval" validate:"min=0"`
+	Time_interval      *int64  `json:"timeInterval" validate:"min=0"`
+	Time_interval_unit *string `json:"timeIntervalUnit" validate:"oneof=hour day week month year"`
 	// times
-	Due_date *time.Time `json:"dueDate"`
+	Due_date     *time.Time `json:"dueDate"`
+	Due_odometer *int64     `json:"dueOdometer" validate:"min=0"`
 }
 
 // used for existing job data
 type Job struct {
 	// meta data
 	ID           int64   `json:"id"`
-	Name         string  `json:"name"`
-	Description  *string `json:"description"`
-	Instructions *string `json:"instructions"`
-	Is_template  int     `json:"isTemplate"`
-	Is_complete  int     `json:"isComplete"`
+	Name         string  `json:"name" validate:"required,max=100"`
+	Description  *string `json:"description" validate:"max=2000"`
+	Instructions *string `json:"instructions" validate:"max=10000"`
+	Is_template  int     `json:"isTemplate" validate:"oneof=0 1"`
+	Is_complete  int     `json:"isComplete" validate:"oneof=0 1"`
+	Status       string  `json:"status" validate:"oneof=planned parts_ordered in_progress blocked done skipped"`
 	// ownership
 	Vehicle    *int64  `json:"vehicle"`
 	User       int64   `json:"user"`
 	Origin_job *int64  `json:"originJob"`
 	Labels     []Label `json:"labels"`
 	// repeats
-	Repeats            int     `json:"repeats"`
-	Odo_interval       *int64  `json:"odoInterval"`
-	Time_interval      *int64  `json:"timeInterval"`
-	Time_interval_unit *string `json:"timeIntervalUnit"`
+	Repeats            int     `json:"repeats" validate:"oneof=0 1"`
+	Odo_interval       *int64  `json:"odoInterval" validate:"min=0"`
+	Time_interval      *int64  `json:"timeInterval" validate:"min=0"`
+	Time_interval_unit *string `json:"timeIntervalUnit" validate:"oneof=hour day week month year"`
 	// times
 	Due_date     *time.Time `json:"dueDate"`
+	Due_odometer *int64     `json:"dueOdometer" validate:"min=0"`
 	Completed_at *time.Time `json:"completedAt"`
 	Created_at   time.Time  `json:"createdAt"`
 	Updated_at   time.Time  `json:"updatedAt"`
+	Deleted_at   *time.Time `json:"deletedAt"`
+}
+
+// used for changing a jobs status
+type JobStatusChange struct {
+	Status string  `json:"status" validate:"required,oneof=planned parts_ordered in_progress blocked done skipped"`
+	Note   *string `json:"note" validate:"max=2000"`
+}
+
+// used for job status history entries
+type JobStatusHistory struct {
+	ID          int64     `json:"id"`
+	Job         int64     `json:"job"`
+	From_status *string   `json:"fromStatus"`
+	To_status   string    `json:"toStatus"`
+	User        *int64    `json:"user"`
+	Note        *string   `json:"note"`
+	Created_at  time.Time `json:"createdAt"`
+}
+
+// used for job completion forms
+type NewJobCompletion struct {
+	Odometer     *int64     `json:"odometer" validate:"min=0"`
+	Completed_at *time.Time `json:"completedAt"`
+	Performed_by *string    `json:"performedBy" validate:"oneof=self shop"` // self or shop
+	Shop         *string    `json:"shop" validate:"max=100"`
+	Notes        *string    `json:"notes" validate:"max=2000"`
+	Cost         *float64   `json:"cost" validate:"min=0"`
+}
+
+// used for existing job completion records
+type JobCompletion struct {
+	ID           int64     `json:"id"`
+	Job          int64     `json:"job"`
+	User         *int64    `json:"user"`
+	Odometer     *int64    `json:"odometer"`
+	Performed_by string    `json:"performedBy"`
+	Shop         *string   `json:"shop"`
+	Notes        *string   `json:"notes"`
+	Cost         *float64  `json:"cost"`
+	Completed_at time.Time `json:"completedAt"`
+	// state before completion, used to undo it
+	Prior_status     string    `json:"priorStatus"`
+	Prior_odometer   *int64    `json:"priorOdometer"`
+	Odometer_reading *int64    `json:"odometerReading"`
+	Next_job         *int64    `json:"nextJob"`
+	Created_at       time.Time `json:"createdAt"`
+}
+
+// used for creating the next occurrence of a recurring job along with its completion
+type NextJob struct {
+	Job    NewJob
+	Tasks  []*Task // copied unchecked, parents and prerequisites are relinked to the copies
+	Labels []int64
 }
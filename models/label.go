@@ -5,8 +5,8 @@ import "time"
 // used for new label forms
 type NewLabel struct {
 	// meta data
-	Name  string  `json:"name"`
-	Color *string `json:"color"`
+	Name  string  `json:"name" validate:"required,max=50"`
+	Color *string `json:"color" validate:"hexcolor"`
 	// ownership
 	User *int64 `json:"user"`
 }
@@ -15,8 +15,8 @@ type NewLabel struct {
 type Label struct {
 	// meta data
 	ID    int64   `json:"id"`
-	Name  string  `json:"name"`
-	Color *string `json:"color"`
+	Name  string  `json:"name" validate:"required,max=50"`
+	Color *string `json:"color" validate:"hexcolor"`
 	// ownership
 	User *int64 `json:"user"`
 	// times
@@ -0,0 +1,26 @@
+package models
+
+// used for keyset pagination of list queries
+type Page struct {
+	Limit  int
+	Cursor *PageCursor // last row of the previous page, nil for the first page
+	Count  bool        // whether to count all matching rows
+	// set by list queries
+	Sort  string      // sort the page was ordered by, e.g. job.name ASC
+	Next  *PageCursor // nil on the last page
+	Total *int64
+}
+
+// used to resume a sorted list after a row, by its sort value and id
+type PageCursor struct {
+	Sort  string  `json:"s"`
+	Value *string `json:"v"`
+	ID    int64   `json:"id"`
+}
+
+// used for paginated list responses
+type PageResult struct {
+	Items       any     `json:"items"`
+	Next_cursor *string `json:"nextCursor"`
+	Total       *int64  `json:"total"`
+}
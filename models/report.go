@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// used for completed jobs in a service history report
+type ReportJob struct {
+	Job        Job            `json:"job"`
+	Tasks      []*Task        `json:"tasks"`
+	Completion *JobCompletion `json:"completion"`
+}
+
+// used for vehicle service history reports
+type VehicleReport struct {
+	Vehicle Vehicle `json:"vehicle"`
+	// filters
+	From  *time.Time `json:"from"`
+	To    *time.Time `json:"to"`
+	Label *Label     `json:"label"`
+	// history
+	Jobs         []ReportJob `json:"jobs"`
+	Total_cost   float64     `json:"totalCost"`
+	Generated_at time.Time   `json:"generatedAt"`
+}
+
+// used for how long tasks of jobs with a label took against their estimates
+type EstimateAccuracy struct {
+	Label             *Label   `json:"label"` // nil for jobs without labels
+	Jobs              int      `json:"jobs"`
+	Tasks             int      `json:"tasks"` // completed tasks with an estimate
+	Estimated_minutes int64    `json:"estimatedMinutes"`
+	Actual_minutes    int64    `json:"actualMinutes"`
+	Ratio             *float64 `json:"ratio"` // actual over estimated, above 1 took longer than estimated, nil if nothing was estimated
+}
+
+// used for estimate accuracy reports
+type EstimateReport struct {
+	User *int64 `json:"user"` // owner of the jobs, nil for everyone
+	// filters
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+	// accuracy
+	Labels       []EstimateAccuracy `json:"labels"`
+	Generated_at time.Time          `json:"generatedAt"`
+}
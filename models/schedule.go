@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// used for importing maintenance schedule files
+type NewSchedule struct {
+	// meta data
+	Name        string  `json:"name" validate:"required,max=100"`
+	Description *string `json:"description" validate:"max=2000"`
+	// vehicles the schedule applies to
+	Make     *string `json:"make" validate:"max=100"`
+	Model    *string `json:"model" validate:"max=100"`
+	Year_min *int64  `json:"yearMin" validate:"min=1886,max=2100"`
+	Year_max *int64  `json:"yearMax" validate:"min=1886,max=2100"`
+	// ownership
+	User *int64 `json:"user"`
+	// service items, each becomes a template job
+	Items []ScheduleItem `json:"items" validate:"required"`
+}
+
+// used for each service item within a schedule file
+type ScheduleItem struct {
+	// meta data
+	Name         string  `json:"name" validate:"required,max=100"`
+	Description  *string `json:"description" validate:"max=2000"`
+	Instructions *string `json:"instructions" validate:"max=10000"`
+	// repeats
+	Odo_interval       *int64  `json:"odoInterval" validate:"min=0"`
+	Time_interval      *int64  `json:"timeInterval" validate:"min=0"`
+	Time_interval_unit *string `json:"timeIntervalUnit" validate:"oneof=hour day week month year"`
+	// tasks
+	Tasks []NewTask `json:"tasks"`
+}
+
+// used for existing schedule data
+type Schedule struct {
+	// meta data
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	// vehicles the schedule applies to
+	Make     *string `json:"make"`
+	Model    *string `json:"model"`
+	Year_min *int64  `json:"yearMin"`
+	Year_max *int64  `json:"yearMax"`
+	// ownership
+	User *int64 `json:"user"`
+	Jobs []Job  `json:"jobs"`
+	// times
+	Created_at time.Time `json:"createdAt"`
+	Updated_at time.Time `json:"updatedAt"`
+}
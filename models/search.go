@@ -0,0 +1,11 @@
+package models
+
+// used for search results, matches in title and snippet are wrapped in <mark> tags, the rest is HTML escaped
+type SearchResult struct {
+	Type    string  `json:"type"` // job, task, vehicle, label or alert
+	ID      int64   `json:"id"`
+	Job     *int64  `json:"job"` // parent job of tasks
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"` // higher is more relevant
+}
@@ -5,30 +5,46 @@ import "time"
 // used for new job forms
 type NewTask struct {
 	// meta data
-	Name        string  `json:"name"`
-	Description *string `json:"description"`
+	Name        string  `json:"name" validate:"required,max=100"`
+	Description *string `json:"description" validate:"max=2000"`
+	// ordering
+	Parent     *int64  `json:"parent"`                       // task of the same job this is a sub-task of
+	Depends_on []int64 `json:"dependsOn" validate:"max=100"` // tasks of the same job that must be complete first
 	// part
-	Part_name *string `json:"partName"`
-	Part_link *string `json:"partLink"`
+	Part_name *string `json:"partName" validate:"max=100"`
+	Part_link *string `json:"partLink" validate:"url,max=2000"`
 	// times
-	Due_date *time.Time `json:"dueDate"`
+	Due_date          *time.Time `json:"dueDate"`
+	Estimated_minutes *int64     `json:"estimatedMinutes" validate:"min=0"` // expected labor, compared against tracked time
 }
 
 // used for existing job data
 type Task struct {
 	// meta data
 	ID          int64   `json:"id"`
-	Name        string  `json:"name"`
-	Description *string `json:"description"`
-	Is_complete int     `json:"isComplete"`
+	Name        string  `json:"name" validate:"required,max=100"`
+	Description *string `json:"description" validate:"max=2000"`
+	Is_complete int     `json:"isComplete" validate:"oneof=0 1"`
 	// ownership
-	Job *int64 `json:"job"`
+	Job    *int64  `json:"job"`
+	Labels []Label `json:"labels"`
+	// ordering
+	Parent     *int64  `json:"parent"`                       // task of the same job this is a sub-task of
+	Position   int     `json:"position"`                     // set with the reorder endpoint, new tasks go last
+	Depends_on []int64 `json:"dependsOn" validate:"max=100"` // tasks of the same job that must be complete first, left unchanged on edit if null
 	// part
-	Part_name *string `json:"partName"`
-	Part_link *string `json:"partLink"`
+	Part_name *string `json:"partName" validate:"max=100"`
+	Part_link *string `json:"partLink" validate:"url,max=2000"`
 	// times
-	Due_date     *time.Time `json:"dueDate"`
-	Completed_at *time.Time `json:"completedAt"`
-	Created_at   time.Time  `json:"createdAt"`
-	Updated_at   time.Time  `json:"updatedAt"`
+	Due_date          *time.Time `json:"dueDate"`
+	Estimated_minutes *int64     `json:"estimatedMinutes" validate:"min=0"` // expected labor, compared against tracked time
+	Completed_at      *time.Time `json:"completedAt"`
+	Created_at        time.Time  `json:"createdAt"`
+	Updated_at        time.Time  `json:"updatedAt"`
+	Deleted_at        *time.Time `json:"deletedAt"`
+}
+
+// used for reorder task forms
+type TaskOrder struct {
+	Tasks []int64 `json:"tasks" validate:"required,max=500"` // ids in their new order, tasks left out keep their order after them
 }
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// used for time tracked on tasks, started and stopped with the timer endpoints
+type TimeEntry struct {
+	ID         int64      `json:"id"`
+	Job        int64      `json:"job"`
+	Task       int64      `json:"task"`
+	User       int64      `json:"user"` // user who did the work
+	Started_at time.Time  `json:"startedAt"`
+	Stopped_at *time.Time `json:"stoppedAt"` // nil while the timer is running
+	Minutes    int64      `json:"minutes"`   // running timers count up to now
+}
+
+// used for time tracked on a task against its estimate
+type TaskTime struct {
+	Task              int64  `json:"task"`
+	Name              string `json:"name"`
+	Estimated_minutes *int64 `json:"estimatedMinutes"`
+	Actual_minutes    int64  `json:"actualMinutes"`
+}
+
+// used for time tracked on a job by one user
+type UserTime struct {
+	User           int64 `json:"user"`
+	Actual_minutes int64 `json:"actualMinutes"`
+}
+
+// used for time tracked on a job rolled up against its estimates
+type JobTime struct {
+	Job               int64      `json:"job"`
+	Estimated_minutes int64      `json:"estimatedMinutes"` // sum of task estimates
+	Actual_minutes    int64      `json:"actualMinutes"`    // includes time on tasks that have since been deleted
+	Running           int        `json:"running"`          // number of timers still running
+	Tasks             []TaskTime `json:"tasks"`
+	Users             []UserTime `json:"users"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// used for entries of the trash, children deleted along with a job or vehicle are restored with it and not listed
+type TrashItem struct {
+	Type       string    `json:"type"` // job, vehicle or user
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	User       int64     `json:"user"` // owner, the user themselves for users
+	Deleted_at time.Time `json:"deletedAt"`
+}
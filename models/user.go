@@ -4,27 +4,28 @@ import "time"
 
 // used for changing passwords
 type Passwords struct {
-	Username        string  `json:"username"`
+	Username        string  `json:"username" validate:"required"`
 	CurrentPassword *string `json:"currentPassword"`
-	NewPassword     *string `json:"newPassword"`
+	NewPassword     *string `json:"newPassword" validate:"required,max=72"`
 }
 
 // used for new user forms
 type NewUser struct {
-	Username string  `json:"username"`
-	Password *string `json:"password"`
-	Is_admin *int    `json:"isAdmin"`
-	Email    *string `json:"email"`
+	Username string  `json:"username" validate:"required,max=50"`
+	Password *string `json:"password" validate:"max=72"`
+	Is_admin *int    `json:"isAdmin" validate:"oneof=0 1"`
+	Email    *string `json:"email" validate:"email,max=254"`
 }
 
 // used for existings users
 type User struct {
-	ID          int64     `json:"id"`
-	Username    string    `json:"username"`
-	Email       *string   `json:"email"`
-	Description *string   `json:"description"`
-	Hashed_pw   *[]byte   `json:"hashedPw"`
-	Is_admin    *int      `json:"isAdmin"`
-	Created_at  time.Time `json:"createdAt"`
-	Updated_at  time.Time `json:"updatedAt"`
+	ID          int64      `json:"id"`
+	Username    string     `json:"username" validate:"required,max=50"`
+	Email       *string    `json:"email" validate:"email,max=254"`
+	Description *string    `json:"description" validate:"max=2000"`
+	Hashed_pw   *[]byte    `json:"hashedPw"`
+	Is_admin    *int       `json:"isAdmin" validate:"oneof=0 1"`
+	Created_at  time.Time  `json:"createdAt"`
+	Updated_at  time.Time  `json:"updatedAt"`
+	Deleted_at  *time.Time `json:"deletedAt"`
 }
@@ -4,18 +4,18 @@ import "time"
 
 type NewVehicle struct {
 	// meta data
-	Name        string  `json:"name"`
-	Description *string `json:"description"`
-	Type        *string `json:"type"`
-	Is_metric   *int    `json:"isMetric"`
+	Name        string  `json:"name" validate:"required,max=100"`
+	Description *string `json:"description" validate:"max=2000"`
+	Type        *string `json:"type" validate:"max=50"`
+	Is_metric   *int    `json:"isMetric" validate:"oneof=0 1"`
 	// vehicle data
-	Vin   *string `json:"vin"`
-	Year  *int64  `json:"year"`
-	Make  *string `json:"make"`
-	Model *string `json:"model"`
-	Trim  *string `json:"trim"`
+	Vin   *string `json:"vin" validate:"alphanum,max=17"`
+	Year  *int64  `json:"year" validate:"min=1886,max=2100"`
+	Make  *string `json:"make" validate:"max=100"`
+	Model *string `json:"model" validate:"max=100"`
+	Trim  *string `json:"trim" validate:"max=100"`
 	// life data
-	Odometer *int `json:"odometer"`
+	Odometer *int `json:"odometer" validate:"min=0"`
 	// ownership
 	User *int64 `json:"user"`
 }
@@ -23,21 +23,41 @@ type NewVehicle struct {
 type Vehicle struct {
 	// meta data
 	ID          int64   `json:"id"`
-	Name        string  `json:"name"`
-	Description *string `json:"description"`
-	Type        *string `json:"type"`
-	Is_metric   *int    `json:"isMetric"`
+	Name        string  `json:"name" validate:"required,max=100"`
+	Description *string `json:"description" validate:"max=2000"`
+	Type        *string `json:"type" validate:"max=50"`
+	Is_metric   *int    `json:"isMetric" validate:"oneof=0 1"`
 	// vehicle data
-	Vin   *string `json:"vin"`
-	Year  *int64  `json:"year"`
-	Make  *string `json:"make"`
-	Model *string `json:"model"`
-	Trim  *string `json:"trim"`
+	Vin   *string `json:"vin" validate:"alphanum,max=17"`
+	Year  *int64  `json:"year" validate:"min=1886,max=2100"`
+	Make  *string `json:"make" validate:"max=100"`
+	Model *string `json:"model" validate:"max=100"`
+	Trim  *string `json:"trim" validate:"max=100"`
 	// life data
-	Odometer *int64 `json:"odometer"`
+	Odometer *int64 `json:"odometer" validate:"min=0"`
 	// ownership
-	User int64 `json:"user"`
+	User   int64   `json:"user"`
+	Labels []Label `json:"labels"`
 	// times
-	Created_at time.Time `json:"createdAt"`
-	Updated_at time.Time `json:"updatedAt"`
+	Created_at time.Time  `json:"createdAt"`
+	Updated_at time.Time  `json:"updatedAt"`
+	Deleted_at *time.Time `json:"deletedAt"`
+}
+
+// used for new odometer reading forms
+type NewOdometerReading struct {
+	Odometer    int64      `json:"odometer" validate:"min=0"`
+	Recorded_at *time.Time `json:"recordedAt"`
+}
+
+// used for existing odometer readings
+type OdometerReading struct {
+	ID          int64     `json:"id"`
+	Vehicle     int64     `json:"vehicle"`
+	Odometer    int64     `json:"odometer"`
+	Source      string    `json:"source"` // manual, completion, import or fuel
+	Job         *int64    `json:"job"`
+	User        *int64    `json:"user"`
+	Recorded_at time.Time `json:"recordedAt"`
+	Created_at  time.Time `json:"createdAt"`
 }
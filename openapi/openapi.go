@@ -0,0 +1,27 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"github.com/okdv/wrench-turn/version"
+)
+
+// OpenAPI 3 document describing every route registered in main.go, keep it in sync when adding or changing routes
+//
+//go:embed openapi.json
+var spec []byte
+
+// Spec
+// Returns OpenAPI document with info.version set to the running version
+func Spec() ([]byte, error) {
+	var doc map[string]any
+	err := json.Unmarshal(spec, &doc)
+	if err != nil {
+		return nil, err
+	}
+	if info, ok := doc["info"].(map[string]any); ok {
+		info["version"] = version.Version
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,2264 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// Memory
+// In memory fake of every repository for testing services without a database, lists support the filters services rely on but ignore sort and page, results are in id order
+type Memory struct {
+	mu            sync.Mutex
+	nextId        int64
+	jobs          map[int64]*models.Job
+	jobLabels     map[int64][]int64
+	taskLabels    map[int64][]int64
+	vehicleLabels map[int64][]int64
+	history       map[int64]*models.JobStatusHistory
+	completions   map[int64]*models.JobCompletion
+	tasks         map[int64]*models.Task
+	dependencies  map[int64][]int64
+	vehicles      map[int64]*models.Vehicle
+	readings      map[int64]*models.OdometerReading
+	alerts        map[int64]*models.Alert
+	labels        map[int64]*models.Label
+	users         map[int64]*models.User
+	userPassword  map[int64]*[]byte
+	events        []*models.AuditEvent
+	trash         []*trashed
+	comments      map[int64]*models.Comment
+	revisions     map[int64]*models.CommentRevision
+	timeEntries   map[int64]*models.TimeEntry
+	fuelLogs      map[int64]*models.FuelLog
+	documents     map[int64]*models.Document
+	attachments   map[int64][]byte
+	schedules     map[int64]*models.Schedule
+	scheduleJobs  map[int64][2]int64 // schedule and job of each schedule_job
+	tokens        map[int64]*models.CalendarToken
+}
+
+// NewMemory
+// Returns Repositories all backed by one empty Memory
+func NewMemory() Repositories {
+	m := &Memory{
+		jobs:          map[int64]*models.Job{},
+		jobLabels:     map[int64][]int64{},
+		taskLabels:    map[int64][]int64{},
+		vehicleLabels: map[int64][]int64{},
+		history:       map[int64]*models.JobStatusHistory{},
+		completions:   map[int64]*models.JobCompletion{},
+		tasks:         map[int64]*models.Task{},
+		dependencies:  map[int64][]int64{},
+		vehicles:      map[int64]*models.Vehicle{},
+		readings:      map[int64]*models.OdometerReading{},
+		alerts:        map[int64]*models.Alert{},
+		labels:        map[int64]*models.Label{},
+		users:         map[int64]*models.User{},
+		userPassword:  map[int64]*[]byte{},
+		comments:      map[int64]*models.Comment{},
+		revisions:     map[int64]*models.CommentRevision{},
+		timeEntries:   map[int64]*models.TimeEntry{},
+		fuelLogs:      map[int64]*models.FuelLog{},
+		documents:     map[int64]*models.Document{},
+		attachments:   map[int64][]byte{},
+		schedules:     map[int64]*models.Schedule{},
+		scheduleJobs:  map[int64][2]int64{},
+		tokens:        map[int64]*models.CalendarToken{},
+	}
+	return Repositories{Jobs: m, Tasks: m, Vehicles: m, Alerts: m, Labels: m, Users: m, Audit: m, Trash: m, Comments: m, Time: m, Fuel: m, Documents: m, Schedules: m, Calendar: m, Search: m}
+}
+
+var errNoRowsUpdated = errors.New("No rows updated")
+var errNoRowsDeleted = errors.New("No rows deleted")
+
+// staleRevision
+// Takes a rows updated_at and the one an edit expects, nil for none, returns true if the row has changed since
+func staleRevision(updatedAt time.Time, revision *time.Time) bool {
+	return revision != nil && updatedAt.UnixMilli() != revision.UnixMilli()
+}
+
+// id returns the next id, ids are unique across all records like rowids are within a table
+func (m *Memory) id() int64 {
+	m.nextId++
+	return m.nextId
+}
+
+// sortedIds returns the keys of a map in ascending order
+func sortedIds[T any](records map[int64]T) []int64 {
+	ids := make([]int64, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// matchId reports whether an optional id filter matches value, empty filters match everything
+func matchId(filter *string, value *int64) bool {
+	if filter == nil || len(*filter) == 0 {
+		return true
+	}
+	id, err := strconv.ParseInt(*filter, 10, 64)
+	return err == nil && value != nil && *value == id
+}
+
+// matchSearch reports whether an optional search string is contained in any of fields, ignoring case
+func matchSearch(searchStr *string, fields ...*string) bool {
+	if searchStr == nil || len(*searchStr) == 0 {
+		return true
+	}
+	for _, field := range fields {
+		if field != nil && strings.Contains(strings.ToLower(*field), strings.ToLower(*searchStr)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Jobs
+
+func (m *Memory) GetJob(jobId int64) (*models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.getJob(jobId)
+}
+
+// getJob copies job with its labels, callers hold the lock
+func (m *Memory) getJob(jobId int64) (*models.Job, error) {
+	job, ok := m.jobs[jobId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	result := *job
+	result.Labels = m.linkedLabels(m.jobLabels[jobId])
+	return &result, nil
+}
+
+// linkedLabels returns the labels of ids in assignment order, deleted labels are left out, callers hold the lock
+func (m *Memory) linkedLabels(labelIds []int64) []models.Label {
+	var labels []models.Label
+	for _, labelId := range labelIds {
+		if label, ok := m.labels[labelId]; ok {
+			labels = append(labels, *label)
+		}
+	}
+	return labels
+}
+
+func (m *Memory) ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete *string, status *string, labelId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var jobs []*models.Job
+	for _, id := range sortedIds(m.jobs) {
+		job, _ := m.getJob(id)
+		template, complete := int64(job.Is_template), int64(job.Is_complete)
+		if !matchId(userId, &job.User) || !matchId(vehicleId, job.Vehicle) || !matchId(isTemplate, &template) || !matchId(isComplete, &complete) {
+			continue
+		}
+		if status != nil && len(*status) > 0 && !strings.Contains(","+strings.ReplaceAll(*status, " ", "")+",", ","+job.Status+",") {
+			continue
+		}
+		if labelId != nil && len(*labelId) > 0 {
+			found := false
+			for _, label := range job.Labels {
+				if matchId(labelId, &label.ID) {
+					found = true
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		if !matchSearch(searchStr, &job.Name, job.Description, job.Instructions) {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (m *Memory) CreateJob(newJob models.NewJob) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.createJob(newJob), nil
+}
+
+// createJob applies the column defaults of the job table, callers hold the lock
+func (m *Memory) createJob(newJob models.NewJob) *int64 {
+	now := time.Now().UTC()
+	job := &models.Job{
+		ID:                 m.id(),
+		Name:               newJob.Name,
+		Description:        newJob.Description,
+		Instructions:       newJob.Instructions,
+		Status:             "planned",
+		Vehicle:            newJob.Vehicle,
+		Origin_job:         newJob.Origin_job,
+		Odo_interval:       newJob.Odo_interval,
+		Time_interval:      newJob.Time_interval,
+		Time_interval_unit: newJob.Time_interval_unit,
+		Due_date:           newJob.Due_date,
+		Due_odometer:       newJob.Due_odometer,
+		Created_at:         now,
+		Updated_at:         now,
+	}
+	if newJob.Is_template != nil {
+		job.Is_template = *newJob.Is_template
+	}
+	if newJob.Status != nil {
+		job.Status = *newJob.Status
+	}
+	if newJob.User != nil {
+		job.User = *newJob.User
+	}
+	if newJob.Repeats != nil {
+		job.Repeats = *newJob.Repeats
+	}
+	m.jobs[job.ID] = job
+	return &job.ID
+}
+
+func (m *Memory) EditJob(editedJob models.Job, revision *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[editedJob.ID]
+	if !ok || job.User != editedJob.User {
+		return errNoRowsUpdated
+	}
+	if staleRevision(job.Updated_at, revision) {
+		return ErrRevisionChanged
+	}
+	completedAt := job.Completed_at
+	if editedJob.Is_complete == 1 && completedAt == nil {
+		now := time.Now().UTC()
+		completedAt = &now
+	} else if editedJob.Is_complete != 1 {
+		completedAt = nil
+	}
+	job.Name, job.Description, job.Instructions = editedJob.Name, editedJob.Description, editedJob.Instructions
+	job.Is_template, job.Is_complete, job.Status, job.Vehicle = editedJob.Is_template, editedJob.Is_complete, editedJob.Status, editedJob.Vehicle
+	job.Repeats, job.Odo_interval, job.Time_interval, job.Time_interval_unit = editedJob.Repeats, editedJob.Odo_interval, editedJob.Time_interval, editedJob.Time_interval_unit
+	job.Due_date, job.Due_odometer, job.Completed_at = editedJob.Due_date, editedJob.Due_odometer, completedAt
+	job.Updated_at = time.Now().UTC()
+	return nil
+}
+
+func (m *Memory) UpdateJobStatus(jobId int64, status string, isComplete int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[jobId]
+	if !ok {
+		return errNoRowsUpdated
+	}
+	now := time.Now().UTC()
+	job.Status, job.Is_complete, job.Completed_at, job.Updated_at = status, isComplete, nil, now
+	if isComplete == 1 {
+		job.Completed_at = &now
+	}
+	return nil
+}
+
+func (m *Memory) AssignJobLabel(jobId int64, labelId int64) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobLabels[jobId] = append(m.jobLabels[jobId], labelId)
+	id := m.id()
+	return &id, nil
+}
+
+func (m *Memory) UnassignJobLabel(jobId int64, labelId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !unassignLabel(m.jobLabels, jobId, labelId) {
+		return errNoRowsDeleted
+	}
+	return nil
+}
+
+// unassignLabel removes label from the labels of id in links, returns whether it was assigned, callers hold the lock
+func unassignLabel(links map[int64][]int64, id int64, labelId int64) bool {
+	var kept []int64
+	for _, linkedId := range links[id] {
+		if linkedId != labelId {
+			kept = append(kept, linkedId)
+		}
+	}
+	removed := len(kept) != len(links[id])
+	links[id] = kept
+	return removed
+}
+
+func (m *Memory) ListJobStatusHistory(jobId int64) ([]*models.JobStatusHistory, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var history []*models.JobStatusHistory
+	for _, id := range sortedIds(m.history) {
+		if entry := m.history[id]; entry.Job == jobId {
+			copied := *entry
+			history = append(history, &copied)
+		}
+	}
+	return history, nil
+}
+
+func (m *Memory) CreateJobStatusHistory(jobId int64, fromStatus *string, toStatus string, userId *int64, note *string) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.createHistory(jobId, fromStatus, toStatus, userId, note), nil
+}
+
+// createHistory callers hold the lock
+func (m *Memory) createHistory(jobId int64, fromStatus *string, toStatus string, userId *int64, note *string) *int64 {
+	entry := &models.JobStatusHistory{ID: m.id(), Job: jobId, From_status: fromStatus, To_status: toStatus, User: userId, Note: note, Created_at: time.Now().UTC()}
+	m.history[entry.ID] = entry
+	return &entry.ID
+}
+
+func (m *Memory) DeleteJobStatusHistory(jobId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, entry := range m.history {
+		if entry.Job == jobId {
+			delete(m.history, id)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) CompleteJob(jobId int64, completedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[jobId]
+	if !ok {
+		return errNoRowsUpdated
+	}
+	job.Status, job.Is_complete, job.Completed_at, job.Updated_at = "done", 1, &completedAt, time.Now().UTC()
+	return nil
+}
+
+func (m *Memory) RecordJobCompletion(completion models.JobCompletion, vehicleId *int64, moveOdometer bool, nextJob *models.NextJob) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[completion.Job]
+	if !ok {
+		return nil, errNoRowsUpdated
+	}
+	now := time.Now().UTC()
+	if vehicleId != nil && completion.Odometer != nil {
+		reading := &models.OdometerReading{ID: m.id(), Vehicle: *vehicleId, Odometer: *completion.Odometer, Source: "completion", Job: &completion.Job, User: completion.User, Recorded_at: completion.Completed_at, Created_at: now}
+		m.readings[reading.ID] = reading
+		completion.Odometer_reading = &reading.ID
+		if vehicle, ok := m.vehicles[*vehicleId]; ok && moveOdometer {
+			odometer := *completion.Odometer
+			vehicle.Odometer, vehicle.Updated_at = &odometer, now
+		}
+	}
+	completedAt := completion.Completed_at
+	job.Status, job.Is_complete, job.Completed_at, job.Updated_at = "done", 1, &completedAt, now
+	m.createHistory(completion.Job, &completion.Prior_status, "done", completion.User, completion.Notes)
+	if nextJob != nil {
+		jobId := m.createJob(nextJob.Job)
+		m.createHistory(*jobId, nil, *nextJob.Job.Status, nextJob.Job.User, nil)
+		taskIds := map[int64]int64{}
+		for _, task := range nextJob.Tasks {
+			taskIds[task.ID] = *m.createTask(models.NewTask{Name: task.Name, Description: task.Description, Part_name: task.Part_name, Part_link: task.Part_link, Estimated_minutes: task.Estimated_minutes}, *jobId)
+		}
+		for _, task := range nextJob.Tasks {
+			if task.Parent != nil {
+				if parentId, ok := taskIds[*task.Parent]; ok {
+					m.tasks[taskIds[task.ID]].Parent = &parentId
+				}
+			}
+			for _, dependencyId := range task.Depends_on {
+				if copiedId, ok := taskIds[dependencyId]; ok {
+					m.dependencies[taskIds[task.ID]] = append(m.dependencies[taskIds[task.ID]], copiedId)
+				}
+			}
+		}
+		m.jobLabels[*jobId] = append([]int64(nil), nextJob.Labels...)
+		completion.Next_job = jobId
+	}
+	completion.ID = m.id()
+	completion.Created_at = now
+	m.completions[completion.ID] = &completion
+	return &completion.ID, nil
+}
+
+func (m *Memory) UndoJobCompletion(completion models.JobCompletion, vehicleId *int64, restoreOdometer bool, trashNextJob bool, userId *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[completion.Job]
+	if !ok {
+		return errNoRowsUpdated
+	}
+	if _, ok := m.completions[completion.ID]; !ok {
+		return errNoRowsDeleted
+	}
+	// the next occurrence is trashed along with the undo, so it must still be live
+	if completion.Next_job != nil && trashNextJob {
+		if _, ok := m.jobs[*completion.Next_job]; !ok {
+			return errNoRowsDeleted
+		}
+	}
+	now := time.Now().UTC()
+	job.Status, job.Is_complete, job.Completed_at, job.Updated_at = completion.Prior_status, 0, nil, now
+	doneStatus := "done"
+	m.createHistory(completion.Job, &doneStatus, completion.Prior_status, userId, nil)
+	if completion.Odometer_reading != nil {
+		delete(m.readings, *completion.Odometer_reading)
+	}
+	if vehicleId != nil && restoreOdometer {
+		if vehicle, ok := m.vehicles[*vehicleId]; ok {
+			vehicle.Odometer, vehicle.Updated_at = completion.Prior_odometer, now
+		}
+	}
+	if completion.Next_job != nil && trashNextJob {
+		m.trashJob(*completion.Next_job)
+	}
+	delete(m.completions, completion.ID)
+	return nil
+}
+
+func (m *Memory) GetLatestJobCompletion(jobId int64) (*models.JobCompletion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var latest *models.JobCompletion
+	for _, id := range sortedIds(m.completions) {
+		if completion := m.completions[id]; completion.Job == jobId {
+			copied := *completion
+			latest = &copied
+		}
+	}
+	if latest == nil {
+		return nil, sql.ErrNoRows
+	}
+	return latest, nil
+}
+
+func (m *Memory) CreateJobCompletion(completion models.JobCompletion) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	completion.ID = m.id()
+	completion.Created_at = time.Now().UTC()
+	m.completions[completion.ID] = &completion
+	return &completion.ID, nil
+}
+
+func (m *Memory) DeleteJobCompletion(completionId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.completions[completionId]; !ok {
+		return errNoRowsDeleted
+	}
+	delete(m.completions, completionId)
+	return nil
+}
+
+func (m *Memory) DeleteJobCompletions(jobId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, completion := range m.completions {
+		if completion.Job == jobId {
+			delete(m.completions, id)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) ImportJobs(newJobs []models.NewJob) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]int64, 0, len(newJobs))
+	for _, newJob := range newJobs {
+		jobId := m.createJob(newJob)
+		entry := &models.JobStatusHistory{ID: m.id(), Job: *jobId, To_status: m.jobs[*jobId].Status, User: newJob.User, Created_at: time.Now().UTC()}
+		m.history[entry.ID] = entry
+		ids = append(ids, *jobId)
+	}
+	return ids, nil
+}
+
+func (m *Memory) BulkDeleteJobs(jobIds []int64, atomic bool) ([]error, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bulk(jobIds, atomic, func(jobId int64) error {
+		if _, ok := m.jobs[jobId]; !ok {
+			return errNoRowsDeleted
+		}
+		return nil
+	}, func(jobId int64) {
+		m.trashJob(jobId)
+	})
+}
+
+func (m *Memory) BulkAssignJobLabel(jobIds []int64, labelId int64, assign int, atomic bool) ([]error, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bulk(jobIds, atomic, func(int64) error {
+		return nil
+	}, func(jobId int64) {
+		unassignLabel(m.jobLabels, jobId, labelId)
+		if assign == 1 {
+			m.jobLabels[jobId] = append(m.jobLabels[jobId], labelId)
+		}
+	})
+}
+
+// bulk checks every id first so an atomic batch can apply nothing, then applies the ids that passed, callers hold the lock
+func (m *Memory) bulk(ids []int64, atomic bool, check func(id int64) error, apply func(id int64)) ([]error, bool, error) {
+	errs := make([]error, len(ids))
+	failed := false
+	for i, id := range ids {
+		errs[i] = check(id)
+		failed = failed || errs[i] != nil
+	}
+	if atomic && failed {
+		return errs, false, nil
+	}
+	for i, id := range ids {
+		if errs[i] == nil {
+			apply(id)
+		}
+	}
+	return errs, true, nil
+}
+
+// Tasks
+
+func (m *Memory) GetTask(jobId int64, taskId int64) (*models.Task, error) {
+	task, err := m.GetTaskById(taskId)
+	if err != nil || task.Job == nil || *task.Job != jobId {
+		return nil, sql.ErrNoRows
+	}
+	return task, nil
+}
+
+func (m *Memory) GetTaskById(taskId int64) (*models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	task, ok := m.tasks[taskId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return m.copyTask(task), nil
+}
+
+// copyTask returns a copy of task with its dependencies and labels, callers hold the lock
+func (m *Memory) copyTask(task *models.Task) *models.Task {
+	copied := *task
+	copied.Labels = m.linkedLabels(m.taskLabels[task.ID])
+	copied.Depends_on = nil
+	for _, dependencyId := range m.dependencies[task.ID] {
+		// trashed prerequisites are left out
+		if _, ok := m.tasks[dependencyId]; ok {
+			copied.Depends_on = append(copied.Depends_on, dependencyId)
+		}
+	}
+	return &copied
+}
+
+func (m *Memory) ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string, page *models.Page) ([]*models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var tasks []*models.Task
+	for _, id := range sortedIds(m.tasks) {
+		task := m.copyTask(m.tasks[id])
+		complete := int64(task.Is_complete)
+		if task.Job == nil || *task.Job != jobId || !matchId(isComplete, &complete) || !matchSearch(searchStr, &task.Name, task.Description, task.Part_name) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	sortByPosition(tasks)
+	return tasks, nil
+}
+
+// sortByPosition orders tasks by position, then id
+func sortByPosition(tasks []*models.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Position < tasks[j].Position })
+}
+
+func (m *Memory) CreateTask(newTask models.NewTask, jobId int64) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.createTask(newTask, jobId), nil
+}
+
+// createTask callers hold the lock, new tasks go after the jobs other tasks
+func (m *Memory) createTask(newTask models.NewTask, jobId int64) *int64 {
+	now := time.Now().UTC()
+	position := 0
+	for _, task := range m.tasks {
+		if task.Job != nil && *task.Job == jobId && task.Position >= position {
+			position = task.Position + 1
+		}
+	}
+	task := &models.Task{
+		ID:                m.id(),
+		Name:              newTask.Name,
+		Description:       newTask.Description,
+		Job:               &jobId,
+		Parent:            newTask.Parent,
+		Position:          position,
+		Part_name:         newTask.Part_name,
+		Part_link:         newTask.Part_link,
+		Due_date:          newTask.Due_date,
+		Estimated_minutes: newTask.Estimated_minutes,
+		Created_at:        now,
+		Updated_at:        now,
+	}
+	m.tasks[task.ID] = task
+	m.dependencies[task.ID] = append([]int64(nil), newTask.Depends_on...)
+	return &task.ID
+}
+
+func (m *Memory) EditTask(editedTask models.Task, jobId int64, revision *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	task, ok := m.tasks[editedTask.ID]
+	if !ok || task.Job == nil || *task.Job != jobId {
+		return errNoRowsUpdated
+	}
+	if staleRevision(task.Updated_at, revision) {
+		return ErrRevisionChanged
+	}
+	task.Name, task.Description, task.Parent, task.Part_name, task.Part_link, task.Due_date, task.Estimated_minutes = editedTask.Name, editedTask.Description, editedTask.Parent, editedTask.Part_name, editedTask.Part_link, editedTask.Due_date, editedTask.Estimated_minutes
+	task.Updated_at = time.Now().UTC()
+	if editedTask.Depends_on != nil {
+		m.dependencies[task.ID] = append([]int64(nil), editedTask.Depends_on...)
+	}
+	return nil
+}
+
+func (m *Memory) ReorderTasks(jobId int64, taskIds []int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, taskId := range taskIds {
+		task, ok := m.tasks[taskId]
+		if !ok || task.Job == nil || *task.Job != jobId {
+			return errNoRowsUpdated
+		}
+	}
+	// make room in front of the jobs tasks
+	for _, task := range m.tasks {
+		if task.Job != nil && *task.Job == jobId {
+			task.Position += len(taskIds)
+		}
+	}
+	for i, taskId := range taskIds {
+		m.tasks[taskId].Position = i
+	}
+	return nil
+}
+
+func (m *Memory) DeleteTask(jobId int64, taskId *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deleted := map[int64]*models.Task{}
+	for id, task := range m.tasks {
+		if task.Job != nil && *task.Job == jobId && (taskId == nil || *taskId == id) {
+			deleted[id] = task
+		}
+	}
+	if len(deleted) == 0 {
+		return errNoRowsDeleted
+	}
+	for id := range deleted {
+		delete(m.tasks, id)
+		delete(m.dependencies, id)
+		delete(m.taskLabels, id)
+	}
+	// sub-tasks move up to the deleted tasks parent, dependencies on deleted tasks are removed
+	for id, task := range m.tasks {
+		if task.Parent != nil && deleted[*task.Parent] != nil {
+			task.Parent = deleted[*task.Parent].Parent
+		}
+		var kept []int64
+		for _, dependencyId := range m.dependencies[id] {
+			if deleted[dependencyId] == nil {
+				kept = append(kept, dependencyId)
+			}
+		}
+		m.dependencies[id] = kept
+	}
+	return nil
+}
+
+func (m *Memory) UpdateTaskStatus(jobId int64, taskId int64, status int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	task, ok := m.tasks[taskId]
+	if !ok || task.Job == nil || *task.Job != jobId {
+		return errNoRowsUpdated
+	}
+	m.updateTaskStatus(task, status)
+	return nil
+}
+
+// updateTaskStatus callers hold the lock
+func (m *Memory) updateTaskStatus(task *models.Task, status int) {
+	now := time.Now().UTC()
+	task.Is_complete, task.Updated_at = status, now
+	if status == 1 {
+		task.Completed_at = &now
+	}
+}
+
+func (m *Memory) AssignTaskLabel(taskId int64, labelId int64) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.taskLabels[taskId] = append(m.taskLabels[taskId], labelId)
+	id := m.id()
+	return &id, nil
+}
+
+func (m *Memory) UnassignTaskLabel(taskId int64, labelId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !unassignLabel(m.taskLabels, taskId, labelId) {
+		return errNoRowsDeleted
+	}
+	return nil
+}
+
+func (m *Memory) ImportTasks(newTasks []models.NewTask, jobId int64) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]int64, 0, len(newTasks))
+	for _, newTask := range newTasks {
+		ids = append(ids, *m.createTask(newTask, jobId))
+	}
+	return ids, nil
+}
+
+func (m *Memory) BulkUpdateTaskStatus(taskIds []int64, status int, atomic bool) ([]error, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bulk(taskIds, atomic, func(taskId int64) error {
+		if _, ok := m.tasks[taskId]; !ok {
+			return errNoRowsUpdated
+		}
+		return nil
+	}, func(taskId int64) {
+		m.updateTaskStatus(m.tasks[taskId], status)
+	})
+}
+
+// Vehicles
+
+func (m *Memory) GetVehicle(vehicleId int64) (*models.Vehicle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vehicle, ok := m.vehicles[vehicleId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *vehicle
+	copied.Labels = m.linkedLabels(m.vehicleLabels[vehicleId])
+	return &copied, nil
+}
+
+func (m *Memory) ListVehicles(userId *string, jobId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Vehicle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var vehicles []*models.Vehicle
+	for _, id := range sortedIds(m.vehicles) {
+		vehicle := *m.vehicles[id]
+		vehicle.Labels = m.linkedLabels(m.vehicleLabels[id])
+		if !matchId(userId, &vehicle.User) || !matchSearch(searchStr, &vehicle.Name, vehicle.Description, vehicle.Vin, vehicle.Make, vehicle.Model, vehicle.Trim) {
+			continue
+		}
+		if jobId != nil && len(*jobId) > 0 {
+			found := false
+			for _, job := range m.jobs {
+				if matchId(jobId, &job.ID) && job.Vehicle != nil && *job.Vehicle == vehicle.ID {
+					found = true
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		vehicles = append(vehicles, &vehicle)
+	}
+	return vehicles, nil
+}
+
+func (m *Memory) CreateVehicle(newVehicle models.NewVehicle) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.createVehicle(newVehicle), nil
+}
+
+// createVehicle applies the column defaults of the vehicle table, callers hold the lock
+func (m *Memory) createVehicle(newVehicle models.NewVehicle) *int64 {
+	now := time.Now().UTC()
+	isMetric := 0
+	if newVehicle.Is_metric != nil {
+		isMetric = *newVehicle.Is_metric
+	}
+	vehicle := &models.Vehicle{
+		ID:          m.id(),
+		Name:        newVehicle.Name,
+		Description: newVehicle.Description,
+		Type:        newVehicle.Type,
+		Is_metric:   &isMetric,
+		Vin:         newVehicle.Vin,
+		Year:        newVehicle.Year,
+		Make:        newVehicle.Make,
+		Model:       newVehicle.Model,
+		Trim:        newVehicle.Trim,
+		Created_at:  now,
+		Updated_at:  now,
+	}
+	if newVehicle.Odometer != nil {
+		odometer := int64(*newVehicle.Odometer)
+		vehicle.Odometer = &odometer
+	}
+	if newVehicle.User != nil {
+		vehicle.User = *newVehicle.User
+	}
+	m.vehicles[vehicle.ID] = vehicle
+	return &vehicle.ID
+}
+
+func (m *Memory) EditVehicle(editedVehicle models.Vehicle, revision *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vehicle, ok := m.vehicles[editedVehicle.ID]
+	if !ok || vehicle.User != editedVehicle.User {
+		return errNoRowsUpdated
+	}
+	if staleRevision(vehicle.Updated_at, revision) {
+		return ErrRevisionChanged
+	}
+	editedVehicle.Created_at = vehicle.Created_at
+	editedVehicle.Updated_at = time.Now().UTC()
+	*vehicle = editedVehicle
+	return nil
+}
+
+func (m *Memory) UpdateVehicleOdometer(vehicleId int64, odometer *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if vehicle, ok := m.vehicles[vehicleId]; ok {
+		vehicle.Odometer, vehicle.Updated_at = odometer, time.Now().UTC()
+	}
+	return nil
+}
+
+func (m *Memory) AssignVehicleLabel(vehicleId int64, labelId int64) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vehicleLabels[vehicleId] = append(m.vehicleLabels[vehicleId], labelId)
+	id := m.id()
+	return &id, nil
+}
+
+func (m *Memory) UnassignVehicleLabel(vehicleId int64, labelId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !unassignLabel(m.vehicleLabels, vehicleId, labelId) {
+		return errNoRowsDeleted
+	}
+	return nil
+}
+
+func (m *Memory) ListOdometerReadings(vehicleId int64) ([]*models.OdometerReading, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var readings []*models.OdometerReading
+	for _, id := range sortedIds(m.readings) {
+		if reading := *m.readings[id]; reading.Vehicle == vehicleId {
+			readings = append(readings, &reading)
+		}
+	}
+	// newest first
+	sort.SliceStable(readings, func(i, j int) bool {
+		return readings[i].Recorded_at.After(readings[j].Recorded_at) || (readings[i].Recorded_at.Equal(readings[j].Recorded_at) && readings[i].ID > readings[j].ID)
+	})
+	return readings, nil
+}
+
+func (m *Memory) CreateOdometerReading(vehicleId int64, newReading models.NewOdometerReading, source string, jobId *int64, userId *int64) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	reading := &models.OdometerReading{ID: m.id(), Vehicle: vehicleId, Odometer: newReading.Odometer, Source: source, Job: jobId, User: userId, Recorded_at: now, Created_at: now}
+	if newReading.Recorded_at != nil {
+		reading.Recorded_at = *newReading.Recorded_at
+	}
+	m.readings[reading.ID] = reading
+	return &reading.ID, nil
+}
+
+func (m *Memory) DeleteOdometerReading(readingId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.readings, readingId)
+	return nil
+}
+
+func (m *Memory) DeleteOdometerReadings(vehicleId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, reading := range m.readings {
+		if reading.Vehicle == vehicleId {
+			delete(m.readings, id)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) ImportVehicles(newVehicles []models.NewVehicle) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]int64, 0, len(newVehicles))
+	for _, newVehicle := range newVehicles {
+		ids = append(ids, *m.createVehicle(newVehicle))
+	}
+	return ids, nil
+}
+
+func (m *Memory) ImportTracker(trackerImport models.TrackerImport) (*models.TrackerImportedIds, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	userId := trackerImport.User
+	ids := models.TrackerImportedIds{Vehicles: []int64{}, Jobs: []int64{}, Fuel_logs: []int64{}}
+	for _, newVehicle := range trackerImport.Vehicles {
+		ids.Vehicles = append(ids.Vehicles, *m.createVehicle(newVehicle))
+	}
+	plannedStatus, note := "planned", "Imported"
+	for _, importRecord := range trackerImport.Records {
+		record := importRecord.Record
+		vehicleId := importRecord.Vehicle
+		if vehicleId == 0 {
+			vehicleId = ids.Vehicles[importRecord.New_vehicle]
+		}
+		if vehicle, ok := m.vehicles[vehicleId]; ok && record.Odometer != nil && (vehicle.Odometer == nil || *record.Odometer > *vehicle.Odometer) {
+			odometer := *record.Odometer
+			vehicle.Odometer, vehicle.Updated_at = &odometer, time.Now().UTC()
+		}
+		if importRecord.Fuel_log != nil {
+			ids.Fuel_logs = append(ids.Fuel_logs, m.createFuelLog(vehicleId, *importRecord.Fuel_log, &userId))
+			continue
+		}
+		jobId := *m.createJob(models.NewJob{Name: record.Name, Vehicle: &vehicleId, User: &userId})
+		m.createHistory(jobId, nil, plannedStatus, &userId, nil)
+		for _, name := range record.Tasks {
+			m.updateTaskStatus(m.tasks[*m.createTask(models.NewTask{Name: name}, jobId)], 1)
+		}
+		job := m.jobs[jobId]
+		completedAt := record.Date
+		job.Status, job.Is_complete, job.Completed_at = "done", 1, &completedAt
+		m.createHistory(jobId, &plannedStatus, "done", &userId, &note)
+		completion := &models.JobCompletion{ID: m.id(), Job: jobId, User: &userId, Odometer: record.Odometer, Performed_by: "self", Shop: record.Shop, Notes: record.Notes, Cost: record.Cost, Completed_at: record.Date, Prior_status: plannedStatus, Created_at: time.Now().UTC()}
+		if record.Shop != nil {
+			completion.Performed_by = "shop"
+		}
+		if record.Odometer != nil {
+			reading := &models.OdometerReading{ID: m.id(), Vehicle: vehicleId, Odometer: *record.Odometer, Source: "import", Job: &jobId, User: &userId, Recorded_at: record.Date, Created_at: completion.Created_at}
+			m.readings[reading.ID] = reading
+			completion.Odometer_reading = &reading.ID
+		}
+		m.completions[completion.ID] = completion
+		ids.Jobs = append(ids.Jobs, jobId)
+	}
+	return &ids, nil
+}
+
+// Alerts
+
+func (m *Memory) GetAlert(alertId int64) (*models.Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alert, ok := m.alerts[alertId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *alert
+	return &copied, nil
+}
+
+func (m *Memory) ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string, typeStr *string, isRead *string, alertDate *string, searchStr *string, sort *string, page *models.Page) ([]*models.Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var alerts []*models.Alert
+	for _, id := range sortedIds(m.alerts) {
+		alert := *m.alerts[id]
+		var read int64
+		if alert.Is_read != nil {
+			read = int64(*alert.Is_read)
+		}
+		if !matchId(userId, &alert.User) || !matchId(vehicleId, alert.Vehicle) || !matchId(jobId, alert.Job) || !matchId(taskId, alert.Task) || !matchId(isRead, &read) {
+			continue
+		}
+		if typeStr != nil && len(*typeStr) > 0 && alert.Type != *typeStr {
+			continue
+		}
+		if alertDate != nil && (alert.Alert_at == nil || alert.Alert_at.UTC().Format("2006-01-02 15:04:05") > *alertDate) {
+			continue
+		}
+		if !matchSearch(searchStr, alert.Name, alert.Description) {
+			continue
+		}
+		alerts = append(alerts, &alert)
+	}
+	return alerts, nil
+}
+
+func (m *Memory) CreateAlert(newAlert models.NewAlert) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.createAlert(newAlert), nil
+}
+
+// createAlert callers hold the lock
+func (m *Memory) createAlert(newAlert models.NewAlert) *int64 {
+	now := time.Now().UTC()
+	isRead := 0
+	alert := &models.Alert{
+		ID:          m.id(),
+		Name:        newAlert.Name,
+		Description: newAlert.Description,
+		Type:        newAlert.Type,
+		Vehicle:     newAlert.Vehicle,
+		Job:         newAlert.Job,
+		Task:        newAlert.Task,
+		Is_read:     &isRead,
+		Alert_at:    newAlert.Alert_at,
+		Created_at:  now,
+		Updated_at:  now,
+	}
+	if newAlert.User != nil {
+		alert.User = *newAlert.User
+	}
+	m.alerts[alert.ID] = alert
+	return &alert.ID
+}
+
+func (m *Memory) EditAlert(editedAlert models.Alert, revision *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alert, ok := m.alerts[editedAlert.ID]
+	if !ok || alert.User != editedAlert.User {
+		return errNoRowsUpdated
+	}
+	if staleRevision(alert.Updated_at, revision) {
+		return ErrRevisionChanged
+	}
+	editedAlert.Read_at, editedAlert.Created_at = alert.Read_at, alert.Created_at
+	editedAlert.Updated_at = time.Now().UTC()
+	*alert = editedAlert
+	return nil
+}
+
+func (m *Memory) DeleteAlert(alertId int64, userId *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alert, ok := m.alerts[alertId]
+	if !ok || (userId != nil && alert.User != *userId) {
+		return errNoRowsDeleted
+	}
+	delete(m.alerts, alertId)
+	return nil
+}
+
+func (m *Memory) UpdateAlertStatus(alertId int64, userId int64, status int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alert, ok := m.alerts[alertId]
+	if !ok || alert.User != userId {
+		return errNoRowsUpdated
+	}
+	m.updateAlertStatus(alert, status)
+	return nil
+}
+
+// updateAlertStatus callers hold the lock
+func (m *Memory) updateAlertStatus(alert *models.Alert, status int) {
+	now := time.Now().UTC()
+	alert.Is_read, alert.Updated_at = &status, now
+	if status == 1 {
+		alert.Read_at = &now
+	}
+}
+
+func (m *Memory) BulkUpdateAlertStatus(alertIds []int64, alertUsers map[int64]int64, status int, atomic bool) ([]error, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bulk(alertIds, atomic, func(alertId int64) error {
+		if alert, ok := m.alerts[alertId]; !ok || alert.User != alertUsers[alertId] {
+			return errNoRowsUpdated
+		}
+		return nil
+	}, func(alertId int64) {
+		m.updateAlertStatus(m.alerts[alertId], status)
+	})
+}
+
+// Labels
+
+func (m *Memory) GetLabel(labelId int64) (*models.Label, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	label, ok := m.labels[labelId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *label
+	return &copied, nil
+}
+
+func (m *Memory) ListLabels(userId *string, jobId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Label, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var labels []*models.Label
+	for _, id := range sortedIds(m.labels) {
+		label := *m.labels[id]
+		if !matchId(userId, label.User) || !matchSearch(searchStr, &label.Name) {
+			continue
+		}
+		if jobId != nil && len(*jobId) > 0 {
+			found := false
+			for job, labelIds := range m.jobLabels {
+				for _, labelId := range labelIds {
+					if matchId(jobId, &job) && labelId == label.ID {
+						found = true
+					}
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		labels = append(labels, &label)
+	}
+	return labels, nil
+}
+
+func (m *Memory) CreateLabel(newLabel models.NewLabel) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.createLabel(newLabel), nil
+}
+
+// createLabel callers hold the lock
+func (m *Memory) createLabel(newLabel models.NewLabel) *int64 {
+	now := time.Now().UTC()
+	label := &models.Label{ID: m.id(), Name: newLabel.Name, Color: newLabel.Color, User: newLabel.User, Created_at: now, Updated_at: now}
+	m.labels[label.ID] = label
+	return &label.ID
+}
+
+func (m *Memory) EditLabel(editedLabel models.Label, revision *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	label, ok := m.labels[editedLabel.ID]
+	if !ok || label.User == nil || editedLabel.User == nil || *label.User != *editedLabel.User {
+		return errNoRowsUpdated
+	}
+	if staleRevision(label.Updated_at, revision) {
+		return ErrRevisionChanged
+	}
+	label.Name, label.Color, label.Updated_at = editedLabel.Name, editedLabel.Color, time.Now().UTC()
+	return nil
+}
+
+func (m *Memory) DeleteLabel(labelId int64, userId *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	label, ok := m.labels[labelId]
+	if !ok || (userId != nil && (label.User == nil || *label.User != *userId)) {
+		return errNoRowsDeleted
+	}
+	delete(m.labels, labelId)
+	return nil
+}
+
+// Users
+
+func (m *Memory) GetAuthInfoByUsername(username string) (*int64, *string, *int, *[]byte, error) {
+	user, err := m.GetUserByUsername(username)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	isAdmin := 0
+	if user.Is_admin != nil {
+		isAdmin = *user.Is_admin
+	}
+	return &user.ID, &user.Username, &isAdmin, m.userPassword[user.ID], nil
+}
+
+func (m *Memory) GetUserById(userId int64) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, ok := m.users[userId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (m *Memory) GetUserByUsername(username string) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, user := range m.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *Memory) ListUsers(jobId *string, vehicleId *string, isAdmin *string, searchStr *string, sort *string, page *models.Page) ([]*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var users []*models.User
+	for _, id := range sortedIds(m.users) {
+		user := *m.users[id]
+		var admin int64
+		if user.Is_admin != nil {
+			admin = int64(*user.Is_admin)
+		}
+		if !matchId(isAdmin, &admin) || !matchSearch(searchStr, &user.Username, user.Description) {
+			continue
+		}
+		users = append(users, &user)
+	}
+	return users, nil
+}
+
+func (m *Memory) CreateUser(newUser models.NewUser, password *[]byte) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, user := range m.users {
+		if user.Username == newUser.Username {
+			return nil, errors.New("UNIQUE constraint failed: user.username")
+		}
+	}
+	now := time.Now().UTC()
+	isAdmin := 0
+	if newUser.Is_admin != nil {
+		isAdmin = *newUser.Is_admin
+	}
+	user := &models.User{ID: m.id(), Username: newUser.Username, Email: newUser.Email, Hashed_pw: password, Is_admin: &isAdmin, Created_at: now, Updated_at: now}
+	m.users[user.ID] = user
+	m.userPassword[user.ID] = password
+	return &user.ID, nil
+}
+
+func (m *Memory) EditUser(editedUser models.User, revision *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if user, ok := m.users[editedUser.ID]; ok {
+		if staleRevision(user.Updated_at, revision) {
+			return ErrRevisionChanged
+		}
+		user.Username, user.Email, user.Description, user.Updated_at = editedUser.Username, editedUser.Email, editedUser.Description, time.Now().UTC()
+	}
+	return nil
+}
+
+func (m *Memory) UpdatePassword(username string, password *[]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, user := range m.users {
+		if user.Username == username {
+			user.Hashed_pw = password
+			m.userPassword[id] = password
+			return nil
+		}
+	}
+	return errNoRowsUpdated
+}
+
+func (m *Memory) ImportAccount(accountImport models.AccountImport) (*models.ImportedIds, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	export := accountImport.Export
+	userId := accountImport.User
+	ids := models.ImportedIds{Vehicles: map[int64]int64{}, Labels: map[int64]int64{}, Jobs: map[int64]int64{}, Tasks: map[int64]int64{}, Alerts: map[int64]int64{}}
+	labelIds := map[int64]int64{}
+	for exportId, labelId := range accountImport.Merged_labels {
+		labelIds[exportId] = labelId
+	}
+	for _, label := range export.Labels {
+		ids.Labels[label.ID] = *m.createLabel(models.NewLabel{Name: label.Name, Color: label.Color, User: &userId})
+		labelIds[label.ID] = ids.Labels[label.ID]
+	}
+	for _, vehicle := range export.Vehicles {
+		var odometer *int
+		if vehicle.Odometer != nil {
+			o := int(*vehicle.Odometer)
+			odometer = &o
+		}
+		ids.Vehicles[vehicle.ID] = *m.createVehicle(models.NewVehicle{Name: vehicle.Name, Description: vehicle.Description, Type: vehicle.Type, Is_metric: vehicle.Is_metric, Vin: vehicle.Vin, Year: vehicle.Year, Make: vehicle.Make, Model: vehicle.Model, Trim: vehicle.Trim, Odometer: odometer, User: &userId})
+		for _, label := range vehicle.Labels {
+			m.vehicleLabels[ids.Vehicles[vehicle.ID]] = append(m.vehicleLabels[ids.Vehicles[vehicle.ID]], labelIds[label.ID])
+		}
+	}
+	plannedStatus := "planned"
+	for _, job := range export.Jobs {
+		newJob := models.NewJob{Name: job.Name, Description: job.Description, Instructions: job.Instructions, Is_template: &job.Is_template, Status: &plannedStatus, User: &userId, Repeats: &job.Repeats, Odo_interval: job.Odo_interval, Time_interval: job.Time_interval, Time_interval_unit: job.Time_interval_unit, Due_date: job.Due_date, Due_odometer: job.Due_odometer}
+		if job.Vehicle != nil {
+			vehicleId := ids.Vehicles[*job.Vehicle]
+			newJob.Vehicle = &vehicleId
+		}
+		if job.Origin_job != nil {
+			if originId, ok := ids.Jobs[*job.Origin_job]; ok {
+				newJob.Origin_job = &originId
+			}
+		}
+		jobId := *m.createJob(newJob)
+		ids.Jobs[job.ID] = jobId
+		m.createHistory(jobId, nil, plannedStatus, &userId, nil)
+		created := m.jobs[jobId]
+		if len(job.Status) > 0 && job.Status != plannedStatus {
+			created.Status = job.Status
+			if job.Status == "done" {
+				completedAt := time.Now().UTC()
+				if job.Completed_at != nil {
+					completedAt = *job.Completed_at
+				}
+				created.Is_complete, created.Completed_at = 1, &completedAt
+			}
+		}
+		for _, label := range job.Labels {
+			m.jobLabels[jobId] = append(m.jobLabels[jobId], labelIds[label.ID])
+		}
+	}
+	for _, task := range export.Tasks {
+		taskId := *m.createTask(models.NewTask{Name: task.Name, Description: task.Description, Part_name: task.Part_name, Part_link: task.Part_link, Due_date: task.Due_date, Estimated_minutes: task.Estimated_minutes}, ids.Jobs[*task.Job])
+		ids.Tasks[task.ID] = taskId
+		if task.Is_complete == 1 {
+			m.updateTaskStatus(m.tasks[taskId], 1)
+		}
+		for _, label := range task.Labels {
+			m.taskLabels[taskId] = append(m.taskLabels[taskId], labelIds[label.ID])
+		}
+	}
+	for _, task := range export.Tasks {
+		copied := m.tasks[ids.Tasks[task.ID]]
+		if task.Parent != nil {
+			if parentId, ok := ids.Tasks[*task.Parent]; ok {
+				copied.Parent = &parentId
+			}
+		}
+		for _, dependencyId := range task.Depends_on {
+			if copiedId, ok := ids.Tasks[dependencyId]; ok {
+				m.dependencies[copied.ID] = append(m.dependencies[copied.ID], copiedId)
+			}
+		}
+	}
+	for _, alert := range export.Alerts {
+		newAlert := models.NewAlert{Name: alert.Name, Description: alert.Description, Type: alert.Type, User: &userId, Alert_at: alert.Alert_at}
+		if alert.Vehicle != nil {
+			vehicleId := ids.Vehicles[*alert.Vehicle]
+			newAlert.Vehicle = &vehicleId
+		}
+		if alert.Job != nil {
+			jobId := ids.Jobs[*alert.Job]
+			newAlert.Job = &jobId
+		}
+		if alert.Task != nil {
+			taskId := ids.Tasks[*alert.Task]
+			newAlert.Task = &taskId
+		}
+		alertId := *m.createAlert(newAlert)
+		ids.Alerts[alert.ID] = alertId
+		if alert.Is_read != nil && *alert.Is_read == 1 {
+			m.updateAlertStatus(m.alerts[alertId], 1)
+		}
+	}
+	return &ids, nil
+}
+
+// Audit
+
+func (m *Memory) CreateAuditEvent(event models.AuditEvent) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	event.ID = m.id()
+	event.Created_at = time.Now().UTC()
+	m.events = append(m.events, &event)
+	return &event.ID, nil
+}
+
+func (m *Memory) ListAuditEvents(actor *string, entity *string, action *string, vehicleId *string, jobId *string, page *models.Page) ([]*models.AuditEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var events []*models.AuditEvent
+	// newest first
+	for i := len(m.events) - 1; i >= 0; i-- {
+		event := *m.events[i]
+		if !matchId(actor, event.Actor) || !matchId(vehicleId, event.Vehicle) || !matchId(jobId, event.Job) {
+			continue
+		}
+		if (entity != nil && len(*entity) > 0 && event.Entity != *entity) || (action != nil && len(*action) > 0 && event.Action != *action) {
+			continue
+		}
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+func (m *Memory) DeleteAuditEvents(before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var kept []*models.AuditEvent
+	for _, event := range m.events {
+		if !event.Created_at.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := int64(len(m.events) - len(kept))
+	m.events = kept
+	return deleted, nil
+}
+
+// Trash
+
+// trashed is a job, vehicle or user moved out of the live records with the children trashed along with it
+type trashed struct {
+	item      models.TrashItem
+	jobs      []*models.Job
+	tasks     []*models.Task
+	alerts    []*models.Alert
+	documents []*models.Document
+	labels    []*models.Label
+	vehicles  []*models.Vehicle
+	users     []*models.User
+}
+
+// moveJob moves a job with its tasks and alerts into t, callers hold the lock
+func (m *Memory) moveJob(t *trashed, jobId int64) {
+	t.jobs = append(t.jobs, m.jobs[jobId])
+	delete(m.jobs, jobId)
+	for _, id := range sortedIds(m.tasks) {
+		if task := m.tasks[id]; task.Job != nil && *task.Job == jobId {
+			t.tasks = append(t.tasks, task)
+			delete(m.tasks, id)
+		}
+	}
+	for _, id := range sortedIds(m.alerts) {
+		if alert := m.alerts[id]; alert.Job != nil && *alert.Job == jobId {
+			t.alerts = append(t.alerts, alert)
+			delete(m.alerts, id)
+		}
+	}
+}
+
+// trashJob moves a live job to the trash, callers hold the lock
+func (m *Memory) trashJob(jobId int64) {
+	job := m.jobs[jobId]
+	t := &trashed{item: models.TrashItem{Type: "job", ID: job.ID, Name: job.Name, User: job.User, Deleted_at: time.Now().UTC()}}
+	m.moveJob(t, jobId)
+	m.trash = append(m.trash, t)
+}
+
+func (m *Memory) TrashJob(jobId int64, userId *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[jobId]
+	if !ok || (userId != nil && job.User != *userId) {
+		return errNoRowsDeleted
+	}
+	m.trashJob(jobId)
+	return nil
+}
+
+func (m *Memory) TrashVehicle(vehicleId int64, userId *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vehicle, ok := m.vehicles[vehicleId]
+	if !ok || (userId != nil && vehicle.User != *userId) {
+		return errNoRowsDeleted
+	}
+	t := &trashed{item: models.TrashItem{Type: "vehicle", ID: vehicle.ID, Name: vehicle.Name, User: vehicle.User, Deleted_at: time.Now().UTC()}, vehicles: []*models.Vehicle{vehicle}}
+	delete(m.vehicles, vehicleId)
+	for _, id := range sortedIds(m.jobs) {
+		if job := m.jobs[id]; job.Vehicle != nil && *job.Vehicle == vehicleId {
+			m.moveJob(t, id)
+		}
+	}
+	for _, id := range sortedIds(m.alerts) {
+		if alert := m.alerts[id]; alert.Vehicle != nil && *alert.Vehicle == vehicleId {
+			t.alerts = append(t.alerts, alert)
+			delete(m.alerts, id)
+		}
+	}
+	for _, id := range sortedIds(m.documents) {
+		if document := m.documents[id]; document.Vehicle == vehicleId {
+			t.documents = append(t.documents, document)
+			delete(m.documents, id)
+		}
+	}
+	m.trash = append(m.trash, t)
+	return nil
+}
+
+func (m *Memory) TrashUser(username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for userId, user := range m.users {
+		if user.Username != username {
+			continue
+		}
+		t := &trashed{item: models.TrashItem{Type: "user", ID: userId, Name: username, User: userId, Deleted_at: time.Now().UTC()}, users: []*models.User{user}}
+		delete(m.users, userId)
+		for _, id := range sortedIds(m.jobs) {
+			if m.jobs[id].User == userId {
+				m.moveJob(t, id)
+			}
+		}
+		for _, id := range sortedIds(m.vehicles) {
+			if vehicle := m.vehicles[id]; vehicle.User == userId {
+				t.vehicles = append(t.vehicles, vehicle)
+				delete(m.vehicles, id)
+			}
+		}
+		for _, id := range sortedIds(m.alerts) {
+			if alert := m.alerts[id]; alert.User == userId {
+				t.alerts = append(t.alerts, alert)
+				delete(m.alerts, id)
+			}
+		}
+		for _, id := range sortedIds(m.documents) {
+			if document := m.documents[id]; document.User == userId {
+				t.documents = append(t.documents, document)
+				delete(m.documents, id)
+			}
+		}
+		for _, id := range sortedIds(m.labels) {
+			if label := m.labels[id]; label.User != nil && *label.User == userId {
+				t.labels = append(t.labels, label)
+				delete(m.labels, id)
+			}
+		}
+		m.trash = append(m.trash, t)
+		return nil
+	}
+	return errNoRowsDeleted
+}
+
+// restore moves a trashed job, vehicle or user back with its children, callers hold the lock
+func (m *Memory) restore(typeStr string, id int64, userId *int64) error {
+	for i, t := range m.trash {
+		if t.item.Type != typeStr || t.item.ID != id || (userId != nil && t.item.User != *userId) {
+			continue
+		}
+		// jobs of a trashed vehicle can only be restored with the vehicle
+		if typeStr == "job" && t.jobs[0].Vehicle != nil {
+			if _, ok := m.vehicles[*t.jobs[0].Vehicle]; !ok {
+				return errNoRowsUpdated
+			}
+		}
+		// and jobs and vehicles of a trashed user with the user
+		for _, other := range m.trash {
+			if typeStr != "user" && other.item.Type == "user" && other.item.ID == t.item.User {
+				return errNoRowsUpdated
+			}
+		}
+		for _, job := range t.jobs {
+			m.jobs[job.ID] = job
+		}
+		for _, task := range t.tasks {
+			m.tasks[task.ID] = task
+		}
+		for _, alert := range t.alerts {
+			m.alerts[alert.ID] = alert
+		}
+		for _, document := range t.documents {
+			m.documents[document.ID] = document
+		}
+		for _, label := range t.labels {
+			m.labels[label.ID] = label
+		}
+		for _, vehicle := range t.vehicles {
+			m.vehicles[vehicle.ID] = vehicle
+		}
+		for _, user := range t.users {
+			m.users[user.ID] = user
+		}
+		m.trash = append(m.trash[:i], m.trash[i+1:]...)
+		return nil
+	}
+	return errNoRowsUpdated
+}
+
+func (m *Memory) RestoreJob(jobId int64, userId *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.restore("job", jobId, userId)
+}
+
+func (m *Memory) RestoreVehicle(vehicleId int64, userId *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.restore("vehicle", vehicleId, userId)
+}
+
+func (m *Memory) RestoreUser(userId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.restore("user", userId, nil)
+}
+
+func (m *Memory) ListTrash(userId *string) ([]*models.TrashItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := make([]*models.TrashItem, 0)
+	// newest first
+	for i := len(m.trash) - 1; i >= 0; i-- {
+		item := m.trash[i].item
+		if matchId(userId, &item.User) {
+			items = append(items, &item)
+		}
+	}
+	return items, nil
+}
+
+func (m *Memory) PurgeTrash(before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var kept []*trashed
+	var purged int64
+	for _, t := range m.trash {
+		if !t.item.Deleted_at.Before(before) {
+			kept = append(kept, t)
+			continue
+		}
+		for _, job := range t.jobs {
+			delete(m.jobLabels, job.ID)
+			for id, entry := range m.history {
+				if entry.Job == job.ID {
+					delete(m.history, id)
+				}
+			}
+			for id, completion := range m.completions {
+				if completion.Job == job.ID {
+					delete(m.completions, id)
+				}
+			}
+			for id, comment := range m.comments {
+				if comment.Job == job.ID {
+					m.deleteComment(id)
+				}
+			}
+			for id, entry := range m.timeEntries {
+				if entry.Job == job.ID {
+					delete(m.timeEntries, id)
+				}
+			}
+		}
+		for _, task := range t.tasks {
+			delete(m.dependencies, task.ID)
+			delete(m.taskLabels, task.ID)
+		}
+		for _, document := range t.documents {
+			delete(m.attachments, document.ID)
+		}
+		for _, label := range t.labels {
+			for _, links := range []map[int64][]int64{m.jobLabels, m.taskLabels, m.vehicleLabels} {
+				for id := range links {
+					unassignLabel(links, id, label.ID)
+				}
+			}
+		}
+		for _, vehicle := range t.vehicles {
+			delete(m.vehicleLabels, vehicle.ID)
+			for id, reading := range m.readings {
+				if reading.Vehicle == vehicle.ID {
+					delete(m.readings, id)
+				}
+			}
+			for id, fuelLog := range m.fuelLogs {
+				if fuelLog.Vehicle == vehicle.ID {
+					delete(m.fuelLogs, id)
+				}
+			}
+		}
+		for _, user := range t.users {
+			delete(m.userPassword, user.ID)
+		}
+		purged += int64(len(t.jobs) + len(t.vehicles) + len(t.users))
+	}
+	m.trash = kept
+	return purged, nil
+}
+
+// Comments
+
+func (m *Memory) GetComment(jobId int64, commentId int64) (*models.Comment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	comment, ok := m.comments[commentId]
+	if !ok || comment.Job != jobId {
+		return nil, sql.ErrNoRows
+	}
+	result := *comment
+	return &result, nil
+}
+
+func (m *Memory) ListComments(jobId int64, taskId *string, sort *string, page *models.Page) ([]*models.Comment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	comments := make([]*models.Comment, 0)
+	for _, id := range sortedIds(m.comments) {
+		comment := *m.comments[id]
+		if comment.Job != jobId || !matchId(taskId, comment.Task) {
+			continue
+		}
+		comments = append(comments, &comment)
+	}
+	// oldest first unless sorted newest
+	if sort != nil && *sort == "newest" {
+		for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+			comments[i], comments[j] = comments[j], comments[i]
+		}
+	}
+	return comments, nil
+}
+
+func (m *Memory) CreateComment(newComment models.NewComment, jobId int64, userId int64) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	comment := &models.Comment{ID: m.id(), Job: jobId, Task: newComment.Task, User: userId, Body: newComment.Body, Created_at: now, Updated_at: now}
+	m.comments[comment.ID] = comment
+	return &comment.ID, nil
+}
+
+func (m *Memory) EditComment(editedComment models.Comment, userId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	comment, ok := m.comments[editedComment.ID]
+	if !ok || comment.Job != editedComment.Job {
+		return errNoRowsUpdated
+	}
+	now := time.Now().UTC()
+	revision := &models.CommentRevision{ID: m.id(), Comment: comment.ID, Body: comment.Body, User: &userId, Created_at: now}
+	m.revisions[revision.ID] = revision
+	comment.Body, comment.Updated_at = editedComment.Body, now
+	return nil
+}
+
+func (m *Memory) DeleteComment(jobId int64, commentId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	comment, ok := m.comments[commentId]
+	if !ok || comment.Job != jobId {
+		return errNoRowsDeleted
+	}
+	m.deleteComment(commentId)
+	return nil
+}
+
+// deleteComment removes a comment with its revisions, callers hold the lock
+func (m *Memory) deleteComment(commentId int64) {
+	for id, revision := range m.revisions {
+		if revision.Comment == commentId {
+			delete(m.revisions, id)
+		}
+	}
+	delete(m.comments, commentId)
+}
+
+func (m *Memory) ListCommentRevisions(commentId int64) ([]*models.CommentRevision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	revisions := make([]*models.CommentRevision, 0)
+	ids := sortedIds(m.revisions)
+	// newest first
+	for i := len(ids) - 1; i >= 0; i-- {
+		revision := *m.revisions[ids[i]]
+		if revision.Comment == commentId {
+			revisions = append(revisions, &revision)
+		}
+	}
+	return revisions, nil
+}
+
+// Time entries
+
+func (m *Memory) GetTimeEntry(entryId int64) (*models.TimeEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.timeEntries[entryId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	result := *entry
+	return &result, nil
+}
+
+func (m *Memory) GetRunningTimer(userId int64) (*models.TimeEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range sortedIds(m.timeEntries) {
+		if entry := m.timeEntries[id]; entry.User == userId && entry.Stopped_at == nil {
+			result := *entry
+			return &result, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *Memory) ListTimeEntries(jobId int64, taskId *string, userId *string, page *models.Page) ([]*models.TimeEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := make([]*models.TimeEntry, 0)
+	for _, id := range sortedIds(m.timeEntries) {
+		entry := *m.timeEntries[id]
+		if entry.Job != jobId || !matchId(taskId, &entry.Task) || !matchId(userId, &entry.User) {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+func (m *Memory) StartTimer(jobId int64, taskId int64, userId int64) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	for _, entry := range m.timeEntries {
+		if entry.User == userId && entry.Stopped_at == nil {
+			stoppedAt := now
+			entry.Stopped_at = &stoppedAt
+		}
+	}
+	entry := &models.TimeEntry{ID: m.id(), Job: jobId, Task: taskId, User: userId, Started_at: now}
+	m.timeEntries[entry.ID] = entry
+	return &entry.ID, nil
+}
+
+func (m *Memory) StopTimer(entryId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.timeEntries[entryId]
+	if !ok || entry.Stopped_at != nil {
+		return errNoRowsUpdated
+	}
+	now := time.Now().UTC()
+	entry.Stopped_at = &now
+	return nil
+}
+
+// Fuel logs
+
+func (m *Memory) ListFuelLogs(vehicleId int64, page *models.Page) ([]*models.FuelLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fuelLogs := make([]*models.FuelLog, 0)
+	for _, id := range sortedIds(m.fuelLogs) {
+		if fuelLog := *m.fuelLogs[id]; fuelLog.Vehicle == vehicleId {
+			fuelLogs = append(fuelLogs, &fuelLog)
+		}
+	}
+	// newest first
+	sort.SliceStable(fuelLogs, func(i, j int) bool {
+		return fuelLogs[i].Filled_at.After(fuelLogs[j].Filled_at)
+	})
+	return fuelLogs, nil
+}
+
+func (m *Memory) ImportFuelLogs(vehicleId int64, newFuelLogs []models.NewFuelLog, userId *int64, odometer *int64) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]int64, 0, len(newFuelLogs))
+	for _, newFuelLog := range newFuelLogs {
+		ids = append(ids, m.createFuelLog(vehicleId, newFuelLog, userId))
+	}
+	if vehicle, ok := m.vehicles[vehicleId]; ok && odometer != nil {
+		vehicle.Odometer, vehicle.Updated_at = odometer, time.Now().UTC()
+	}
+	return ids, nil
+}
+
+// createFuelLog applies the column defaults of the fuel_log table and records its odometer reading, callers hold the lock
+func (m *Memory) createFuelLog(vehicleId int64, newFuelLog models.NewFuelLog, userId *int64) int64 {
+	now := time.Now().UTC()
+	fuelLog := &models.FuelLog{
+		ID:         m.id(),
+		Vehicle:    vehicleId,
+		User:       userId,
+		Filled_at:  now,
+		Odometer:   newFuelLog.Odometer,
+		Volume:     newFuelLog.Volume,
+		Cost:       newFuelLog.Cost,
+		Is_full:    1,
+		Station:    newFuelLog.Station,
+		Notes:      newFuelLog.Notes,
+		Created_at: now,
+	}
+	if newFuelLog.Filled_at != nil {
+		fuelLog.Filled_at = *newFuelLog.Filled_at
+	}
+	if newFuelLog.Is_full != nil {
+		fuelLog.Is_full = *newFuelLog.Is_full
+	}
+	m.fuelLogs[fuelLog.ID] = fuelLog
+	if fuelLog.Odometer != nil {
+		reading := &models.OdometerReading{ID: m.id(), Vehicle: vehicleId, Odometer: *fuelLog.Odometer, Source: "fuel", User: userId, Recorded_at: fuelLog.Filled_at, Created_at: now}
+		m.readings[reading.ID] = reading
+	}
+	return fuelLog.ID
+}
+
+// Documents
+
+func (m *Memory) GetDocument(vehicleId int64, documentId int64) (*models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	document, ok := m.documents[documentId]
+	if !ok || document.Vehicle != vehicleId {
+		return nil, sql.ErrNoRows
+	}
+	result := *document
+	return &result, nil
+}
+
+func (m *Memory) ListDocuments(vehicleId int64, typeStr *string, expiresBefore *string, searchStr *string, sort *string) ([]*models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var before *time.Time
+	if expiresBefore != nil && len(*expiresBefore) > 0 {
+		parsed, err := time.Parse(time.RFC3339, *expiresBefore)
+		if err != nil {
+			return nil, err
+		}
+		before = &parsed
+	}
+	documents := make([]*models.Document, 0)
+	for _, id := range sortedIds(m.documents) {
+		document := *m.documents[id]
+		if document.Vehicle != vehicleId || !matchSearch(searchStr, document.Number, document.Issuer, document.Description) {
+			continue
+		}
+		if (typeStr != nil && len(*typeStr) > 0 && document.Type != *typeStr) || (before != nil && (document.Expires_at == nil || document.Expires_at.After(*before))) {
+			continue
+		}
+		documents = append(documents, &document)
+	}
+	return documents, nil
+}
+
+func (m *Memory) CreateDocument(newDocument models.NewDocument, vehicleId int64, userId int64) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	document := &models.Document{
+		ID:          m.id(),
+		Type:        newDocument.Type,
+		Number:      newDocument.Number,
+		Issuer:      newDocument.Issuer,
+		Description: newDocument.Description,
+		Vehicle:     vehicleId,
+		User:        userId,
+		Issued_at:   newDocument.Issued_at,
+		Expires_at:  newDocument.Expires_at,
+		Created_at:  now,
+		Updated_at:  now,
+	}
+	if newDocument.Remind_days != nil {
+		document.Remind_days = *newDocument.Remind_days
+	}
+	m.documents[document.ID] = document
+	return &document.ID, nil
+}
+
+func (m *Memory) EditDocument(editedDocument models.Document, vehicleId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	document, ok := m.documents[editedDocument.ID]
+	if !ok || document.Vehicle != vehicleId {
+		return errNoRowsUpdated
+	}
+	document.Type, document.Number, document.Issuer, document.Description = editedDocument.Type, editedDocument.Number, editedDocument.Issuer, editedDocument.Description
+	document.Issued_at, document.Expires_at, document.Remind_days = editedDocument.Issued_at, editedDocument.Expires_at, editedDocument.Remind_days
+	document.Updated_at = time.Now().UTC()
+	return nil
+}
+
+func (m *Memory) UpdateDocumentAlert(documentId int64, alertId *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if document, ok := m.documents[documentId]; ok {
+		document.Alert = alertId
+	}
+	return nil
+}
+
+func (m *Memory) GetDocumentAttachment(vehicleId int64, documentId int64) (*string, *string, []byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	document, ok := m.documents[documentId]
+	if !ok || document.Vehicle != vehicleId {
+		return nil, nil, nil, sql.ErrNoRows
+	}
+	return document.Attachment_name, document.Attachment_type, m.attachments[documentId], nil
+}
+
+func (m *Memory) UpdateDocumentAttachment(vehicleId int64, documentId int64, name *string, contentType *string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	document, ok := m.documents[documentId]
+	if !ok || document.Vehicle != vehicleId {
+		return errNoRowsUpdated
+	}
+	document.Attachment_name, document.Attachment_type, document.Updated_at = name, contentType, time.Now().UTC()
+	if data == nil {
+		delete(m.attachments, documentId)
+	} else {
+		m.attachments[documentId] = data
+	}
+	return nil
+}
+
+func (m *Memory) DeleteDocument(vehicleId int64, documentId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	document, ok := m.documents[documentId]
+	if !ok || document.Vehicle != vehicleId {
+		return errNoRowsDeleted
+	}
+	delete(m.documents, documentId)
+	delete(m.attachments, documentId)
+	return nil
+}
+
+// Schedules
+
+func (m *Memory) GetSchedule(scheduleId int64) (*models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	schedule, ok := m.schedules[scheduleId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	result := *schedule
+	return &result, nil
+}
+
+func (m *Memory) ListSchedules(userId *string, makeStr *string, modelStr *string, yearStr *string, searchStr *string, sort *string) ([]*models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var year *int64
+	if yearStr != nil && len(*yearStr) > 0 {
+		parsed, err := strconv.ParseInt(*yearStr, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		year = &parsed
+	}
+	// schedules without an owner, make, model or year bound match any
+	matchText := func(filter *string, value *string) bool {
+		return filter == nil || len(*filter) == 0 || value == nil || strings.EqualFold(*filter, *value)
+	}
+	schedules := make([]*models.Schedule, 0)
+	for _, id := range sortedIds(m.schedules) {
+		schedule := *m.schedules[id]
+		if (schedule.User != nil && !matchId(userId, schedule.User)) || !matchText(makeStr, schedule.Make) || !matchText(modelStr, schedule.Model) {
+			continue
+		}
+		if year != nil && ((schedule.Year_min != nil && *schedule.Year_min > *year) || (schedule.Year_max != nil && *schedule.Year_max < *year)) {
+			continue
+		}
+		if !matchSearch(searchStr, &schedule.Name, schedule.Description) {
+			continue
+		}
+		schedules = append(schedules, &schedule)
+	}
+	return schedules, nil
+}
+
+func (m *Memory) CreateSchedule(newSchedule models.NewSchedule) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	schedule := &models.Schedule{
+		ID:          m.id(),
+		Name:        newSchedule.Name,
+		Description: newSchedule.Description,
+		Make:        newSchedule.Make,
+		Model:       newSchedule.Model,
+		Year_min:    newSchedule.Year_min,
+		Year_max:    newSchedule.Year_max,
+		User:        newSchedule.User,
+		Created_at:  now,
+		Updated_at:  now,
+	}
+	m.schedules[schedule.ID] = schedule
+	return &schedule.ID, nil
+}
+
+func (m *Memory) DeleteSchedule(scheduleId int64, userId *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	schedule, ok := m.schedules[scheduleId]
+	if !ok || (userId != nil && (schedule.User == nil || *schedule.User != *userId)) {
+		return errNoRowsDeleted
+	}
+	delete(m.schedules, scheduleId)
+	return nil
+}
+
+func (m *Memory) AssignScheduleJob(scheduleId int64, jobId int64) (*int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.id()
+	m.scheduleJobs[id] = [2]int64{scheduleId, jobId}
+	return &id, nil
+}
+
+func (m *Memory) ListScheduleJobIds(scheduleId int64) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobIds := make([]int64, 0)
+	for _, id := range sortedIds(m.scheduleJobs) {
+		if entry := m.scheduleJobs[id]; entry[0] == scheduleId {
+			jobIds = append(jobIds, entry[1])
+		}
+	}
+	return jobIds, nil
+}
+
+func (m *Memory) UnassignScheduleJobs(scheduleId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, entry := range m.scheduleJobs {
+		if entry[0] == scheduleId {
+			delete(m.scheduleJobs, id)
+		}
+	}
+	return nil
+}
+
+// Calendar tokens
+
+func (m *Memory) GetCalendarToken(token string) (*models.CalendarToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, calendarToken := range m.tokens {
+		if calendarToken.Token == token {
+			result := *calendarToken
+			return &result, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *Memory) SetCalendarToken(userId int64, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	calendarToken, ok := m.tokens[userId]
+	if !ok {
+		calendarToken = &models.CalendarToken{ID: m.id(), User: userId}
+		m.tokens[userId] = calendarToken
+	}
+	calendarToken.Token, calendarToken.Created_at = token, time.Now().UTC()
+	return nil
+}
+
+func (m *Memory) DeleteCalendarToken(userId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tokens[userId]; !ok {
+		return errors.New("No calendar token found")
+	}
+	delete(m.tokens, userId)
+	return nil
+}
+
+// Search
+
+// SearchIndexEnabled is always false, services fall back to SearchLike
+func (m *Memory) SearchIndexEnabled() bool {
+	return false
+}
+
+func (m *Memory) Search(match string, userId *int64, types []string, limit int) ([]*models.SearchResult, error) {
+	return nil, errors.New("Search index unavailable")
+}
+
+func (m *Memory) SearchLike(terms []string, userId *int64, types []string, limit int) ([]*models.SearchResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	results := make([]*models.SearchResult, 0)
+	// add appends a record if every term is in one of its columns, the first column is the title
+	add := func(typeStr string, id int64, owner *int64, shared bool, job *int64, columns ...*string) {
+		if len(types) > 0 && !containsString(types, typeStr) {
+			return
+		}
+		if userId != nil && !(owner != nil && *owner == *userId) && !(shared && owner == nil) {
+			return
+		}
+		for _, term := range terms {
+			if !matchSearch(&term, columns...) {
+				return
+			}
+		}
+		result := &models.SearchResult{Type: typeStr, ID: id, Job: job}
+		if columns[0] != nil {
+			result.Title = *columns[0]
+		}
+		for _, column := range columns[1:] {
+			if column != nil {
+				result.Snippet += *column + " "
+			}
+		}
+		results = append(results, result)
+	}
+	for _, id := range sortedIds(m.jobs) {
+		job := m.jobs[id]
+		add("job", id, &job.User, false, nil, &job.Name, job.Description, job.Instructions)
+	}
+	for _, id := range sortedIds(m.tasks) {
+		task := m.tasks[id]
+		if task.Job == nil || m.jobs[*task.Job] == nil {
+			continue
+		}
+		add("task", id, &m.jobs[*task.Job].User, false, task.Job, &task.Name, task.Description, task.Part_name)
+	}
+	for _, id := range sortedIds(m.vehicles) {
+		vehicle := m.vehicles[id]
+		add("vehicle", id, &vehicle.User, false, nil, &vehicle.Name, vehicle.Description, vehicle.Vin, vehicle.Make, vehicle.Model, vehicle.Trim)
+	}
+	for _, id := range sortedIds(m.labels) {
+		label := m.labels[id]
+		add("label", id, label.User, true, nil, &label.Name)
+	}
+	for _, id := range sortedIds(m.alerts) {
+		alert := m.alerts[id]
+		add("alert", id, &alert.User, false, nil, alert.Name, alert.Description)
+	}
+	// ordered by title like the database
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Title < results[j].Title })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
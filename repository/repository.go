@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ErrRevisionChanged
+// Returned by an edit given the updated_at the caller last saw when the row has changed since, nothing is written
+var ErrRevisionChanged = errors.New("Revision changed")
+
+// ErrCursorSort
+// Returned by list methods given a cursor from a list with a different sort
+var ErrCursorSort = errors.New("Cursor does not match sort, start again from the first page")
+
+// Repositories
+// Bundles the repository of each aggregate, injected into services and controllers
+type Repositories struct {
+	Jobs      JobRepository
+	Tasks     TaskRepository
+	Vehicles  VehicleRepository
+	Alerts    AlertRepository
+	Labels    LabelRepository
+	Users     UserRepository
+	Audit     AuditRepository
+	Trash     TrashRepository
+	Comments  CommentRepository
+	Time      TimeRepository
+	Fuel      FuelRepository
+	Documents DocumentRepository
+	Schedules ScheduleRepository
+	Calendar  CalendarRepository
+	Search    SearchRepository
+}
+
+// JobRepository
+// Jobs with their status history, completions and labels, recording and undoing a completion and bulk methods run in a single transaction, bulk methods return an error or nil per id and whether it was committed
+type JobRepository interface {
+	GetJob(jobId int64) (*models.Job, error)
+	ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete *string, status *string, labelId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Job, error)
+	CreateJob(newJob models.NewJob) (*int64, error)
+	EditJob(editedJob models.Job, revision *time.Time) error
+	UpdateJobStatus(jobId int64, status string, isComplete int) error
+	AssignJobLabel(jobId int64, labelId int64) (*int64, error)
+	UnassignJobLabel(jobId int64, labelId int64) error
+	ListJobStatusHistory(jobId int64) ([]*models.JobStatusHistory, error)
+	CreateJobStatusHistory(jobId int64, fromStatus *string, toStatus string, userId *int64, note *string) (*int64, error)
+	DeleteJobStatusHistory(jobId int64) error
+	CompleteJob(jobId int64, completedAt time.Time) error
+	RecordJobCompletion(completion models.JobCompletion, vehicleId *int64, moveOdometer bool, nextJob *models.NextJob) (*int64, error)
+	UndoJobCompletion(completion models.JobCompletion, vehicleId *int64, restoreOdometer bool, trashNextJob bool, userId *int64) error
+	GetLatestJobCompletion(jobId int64) (*models.JobCompletion, error)
+	CreateJobCompletion(completion models.JobCompletion) (*int64, error)
+	DeleteJobCompletion(completionId int64) error
+	DeleteJobCompletions(jobId int64) error
+	ImportJobs(newJobs []models.NewJob) ([]int64, error)
+	BulkDeleteJobs(jobIds []int64, atomic bool) ([]error, bool, error)
+	BulkAssignJobLabel(jobIds []int64, labelId int64, assign int, atomic bool) ([]error, bool, error)
+}
+
+// TaskRepository
+// Tasks of jobs in job order with their sub-tasks, dependencies and labels, bulk methods run in a single transaction and return an error or nil per id and whether it was committed
+type TaskRepository interface {
+	GetTask(jobId int64, taskId int64) (*models.Task, error)
+	GetTaskById(taskId int64) (*models.Task, error)
+	ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string, page *models.Page) ([]*models.Task, error)
+	CreateTask(newTask models.NewTask, jobId int64) (*int64, error)
+	EditTask(editedTask models.Task, jobId int64, revision *time.Time) error
+	ReorderTasks(jobId int64, taskIds []int64) error
+	DeleteTask(jobId int64, taskId *int64) error
+	UpdateTaskStatus(jobId int64, taskId int64, status int) error
+	AssignTaskLabel(taskId int64, labelId int64) (*int64, error)
+	UnassignTaskLabel(taskId int64, labelId int64) error
+	ImportTasks(newTasks []models.NewTask, jobId int64) ([]int64, error)
+	BulkUpdateTaskStatus(taskIds []int64, status int, atomic bool) ([]error, bool, error)
+}
+
+// VehicleRepository
+// Vehicles with their odometer readings and labels, importing another trackers history runs in a single transaction
+type VehicleRepository interface {
+	GetVehicle(vehicleId int64) (*models.Vehicle, error)
+	ListVehicles(userId *string, jobId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Vehicle, error)
+	CreateVehicle(newVehicle models.NewVehicle) (*int64, error)
+	EditVehicle(editedVehicle models.Vehicle, revision *time.Time) error
+	UpdateVehicleOdometer(vehicleId int64, odometer *int64) error
+	AssignVehicleLabel(vehicleId int64, labelId int64) (*int64, error)
+	UnassignVehicleLabel(vehicleId int64, labelId int64) error
+	ListOdometerReadings(vehicleId int64) ([]*models.OdometerReading, error)
+	CreateOdometerReading(vehicleId int64, newReading models.NewOdometerReading, source string, jobId *int64, userId *int64) (*int64, error)
+	DeleteOdometerReading(readingId int64) error
+	DeleteOdometerReadings(vehicleId int64) error
+	ImportVehicles(newVehicles []models.NewVehicle) ([]int64, error)
+	ImportTracker(trackerImport models.TrackerImport) (*models.TrackerImportedIds, error)
+}
+
+// AlertRepository
+// Alerts of users, bulk methods run in a single transaction and return an error or nil per id and whether it was committed
+type AlertRepository interface {
+	GetAlert(alertId int64) (*models.Alert, error)
+	ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string, typeStr *string, isRead *string, alertDate *string, searchStr *string, sort *string, page *models.Page) ([]*models.Alert, error)
+	CreateAlert(newAlert models.NewAlert) (*int64, error)
+	EditAlert(editedAlert models.Alert, revision *time.Time) error
+	DeleteAlert(alertId int64, userId *int64) error
+	UpdateAlertStatus(alertId int64, userId int64, status int) error
+	BulkUpdateAlertStatus(alertIds []int64, alertUsers map[int64]int64, status int, atomic bool) ([]error, bool, error)
+}
+
+// LabelRepository
+// Labels of users, or shared if they have no user
+type LabelRepository interface {
+	GetLabel(labelId int64) (*models.Label, error)
+	ListLabels(userId *string, jobId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Label, error)
+	CreateLabel(newLabel models.NewLabel) (*int64, error)
+	EditLabel(editedLabel models.Label, revision *time.Time) error
+	DeleteLabel(labelId int64, userId *int64) error
+}
+
+// UserRepository
+// Users with their credentials, importing an account runs in a single transaction
+type UserRepository interface {
+	GetAuthInfoByUsername(username string) (*int64, *string, *int, *[]byte, error)
+	GetUserById(userId int64) (*models.User, error)
+	GetUserByUsername(username string) (*models.User, error)
+	ListUsers(jobId *string, vehicleId *string, isAdmin *string, searchStr *string, sort *string, page *models.Page) ([]*models.User, error)
+	CreateUser(newUser models.NewUser, password *[]byte) (*int64, error)
+	EditUser(editedUser models.User, revision *time.Time) error
+	UpdatePassword(username string, password *[]byte) error
+	ImportAccount(accountImport models.AccountImport) (*models.ImportedIds, error)
+}
+
+// AuditRepository
+// Append only log of changes, events are only deleted once older than the retention period
+type AuditRepository interface {
+	CreateAuditEvent(event models.AuditEvent) (*int64, error)
+	ListAuditEvents(actor *string, entity *string, action *string, vehicleId *string, jobId *string, page *models.Page) ([]*models.AuditEvent, error)
+	DeleteAuditEvents(before time.Time) (int64, error)
+}
+
+// TrashRepository
+// Soft deleted jobs, vehicles and users, trashing one also trashes its children and restoring it restores the children trashed along with it
+type TrashRepository interface {
+	TrashJob(jobId int64, userId *int64) error
+	TrashVehicle(vehicleId int64, userId *int64) error
+	TrashUser(username string) error
+	RestoreJob(jobId int64, userId *int64) error
+	RestoreVehicle(vehicleId int64, userId *int64) error
+	RestoreUser(userId int64) error
+	ListTrash(userId *string) ([]*models.TrashItem, error)
+	PurgeTrash(before time.Time) (int64, error)
+}
+
+// CommentRepository
+// Comments on jobs and their tasks, editing a comment keeps its previous body as a revision
+type CommentRepository interface {
+	GetComment(jobId int64, commentId int64) (*models.Comment, error)
+	ListComments(jobId int64, taskId *string, sort *string, page *models.Page) ([]*models.Comment, error)
+	CreateComment(newComment models.NewComment, jobId int64, userId int64) (*int64, error)
+	EditComment(editedComment models.Comment, userId int64) error
+	DeleteComment(jobId int64, commentId int64) error
+	ListCommentRevisions(commentId int64) ([]*models.CommentRevision, error)
+}
+
+// TimeRepository
+// Time tracked on tasks by users, each user has at most one running timer
+type TimeRepository interface {
+	GetTimeEntry(entryId int64) (*models.TimeEntry, error)
+	GetRunningTimer(userId int64) (*models.TimeEntry, error)
+	ListTimeEntries(jobId int64, taskId *string, userId *string, page *models.Page) ([]*models.TimeEntry, error)
+	StartTimer(jobId int64, taskId int64, userId int64) (*int64, error)
+	StopTimer(entryId int64) error
+}
+
+// FuelRepository
+// Fuel logs of vehicles, importing records an odometer reading per fuel log and moves the vehicle odometer in a single transaction
+type FuelRepository interface {
+	ListFuelLogs(vehicleId int64, page *models.Page) ([]*models.FuelLog, error)
+	ImportFuelLogs(vehicleId int64, newFuelLogs []models.NewFuelLog, userId *int64, odometer *int64) ([]int64, error)
+}
+
+// DocumentRepository
+// Documents of vehicles such as registration and insurance, attachment data is only loaded on its own
+type DocumentRepository interface {
+	GetDocument(vehicleId int64, documentId int64) (*models.Document, error)
+	ListDocuments(vehicleId int64, typeStr *string, expiresBefore *string, searchStr *string, sort *string) ([]*models.Document, error)
+	CreateDocument(newDocument models.NewDocument, vehicleId int64, userId int64) (*int64, error)
+	EditDocument(editedDocument models.Document, vehicleId int64) error
+	UpdateDocumentAlert(documentId int64, alertId *int64) error
+	GetDocumentAttachment(vehicleId int64, documentId int64) (*string, *string, []byte, error)
+	UpdateDocumentAttachment(vehicleId int64, documentId int64, name *string, contentType *string, data []byte) error
+	DeleteDocument(vehicleId int64, documentId int64) error
+}
+
+// ScheduleRepository
+// Maintenance schedules with the template jobs imported from them, schedules without a user are shared
+type ScheduleRepository interface {
+	GetSchedule(scheduleId int64) (*models.Schedule, error)
+	ListSchedules(userId *string, makeStr *string, modelStr *string, yearStr *string, searchStr *string, sort *string) ([]*models.Schedule, error)
+	CreateSchedule(newSchedule models.NewSchedule) (*int64, error)
+	DeleteSchedule(scheduleId int64, userId *int64) error
+	AssignScheduleJob(scheduleId int64, jobId int64) (*int64, error)
+	ListScheduleJobIds(scheduleId int64) ([]int64, error)
+	UnassignScheduleJobs(scheduleId int64) error
+}
+
+// CalendarRepository
+// Secret tokens of calendar feeds, each user has at most one
+type CalendarRepository interface {
+	GetCalendarToken(token string) (*models.CalendarToken, error)
+	SetCalendarToken(userId int64, token string) error
+	DeleteCalendarToken(userId int64) error
+}
+
+// SearchRepository
+// Search across jobs, tasks, vehicles, labels and alerts, Search takes a full text match expression and is only used if SearchIndexEnabled, SearchLike matches every term anywhere
+type SearchRepository interface {
+	SearchIndexEnabled() bool
+	Search(match string, userId *int64, types []string, limit int) ([]*models.SearchResult, error)
+	SearchLike(terms []string, userId *int64, types []string, limit int) ([]*models.SearchResult, error)
+}
@@ -0,0 +1,112 @@
+package response
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/okdv/wrench-turn/validate"
+)
+
+// stable error codes for cases clients are expected to handle, others are derived from the status, e.g. not_found
+const (
+	CodeInvalidBody  = "invalid_body"
+	CodeInvalidParam = "invalid_param"
+	CodeCursorSort   = "cursor_sort_mismatch"
+	CodeConstraint   = "constraint_violation"
+	CodeValidation   = "validation_failed"
+	CodeBodyTooLarge = "body_too_large"
+)
+
+// Problem
+// RFC 7807 problem details, Code is an extension member clients can switch on
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Code   string `json:"code"`
+	Detail string `json:"detail,omitempty"`
+	// field level errors of validation failures
+	Errors validate.Errors `json:"errors,omitempty"`
+}
+
+// Error
+// Responds with problem+json, code derived from status, err is appended to detail outside production
+func Error(w http.ResponseWriter, status int, detail string, err error) {
+	ErrorCode(w, status, "", detail, err)
+}
+
+// ErrorCode
+// Responds with problem+json using given code, a missing row or constraint error the controller could not classify becomes 404 or 409
+func ErrorCode(w http.ResponseWriter, status int, code string, detail string, err error) {
+	if status >= http.StatusInternalServerError {
+		var sqliteErr sqlite3.Error
+		var postgresErr *pq.Error
+		if errors.Is(err, sql.ErrNoRows) {
+			status = http.StatusNotFound
+			code = ""
+		} else if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint || errors.As(err, &postgresErr) && postgresErr.Code.Class() == "23" {
+			status = http.StatusConflict
+			code = CodeConstraint
+		}
+	}
+	if len(code) == 0 {
+		code = strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
+	// underlying errors may contain sql or file paths, keep them out of production responses
+	if err != nil {
+		if os.Getenv("GO_ENV") == "production" {
+			log.Printf("%s: %v", detail, err)
+		} else {
+			detail = detail + ": " + err.Error()
+		}
+	}
+	writeJSON(w, status, "application/problem+json", Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	})
+}
+
+// Validation
+// Responds with 422 problem+json listing each invalid field
+func Validation(w http.ResponseWriter, errs validate.Errors) {
+	writeJSON(w, http.StatusUnprocessableEntity, "application/problem+json", Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusUnprocessableEntity),
+		Status: http.StatusUnprocessableEntity,
+		Code:   CodeValidation,
+		Detail: "Request body has invalid fields",
+		Errors: errs,
+	})
+}
+
+// JSON
+// Responds with v as JSON
+func JSON(w http.ResponseWriter, status int, v any) {
+	writeJSON(w, status, "application/json", v)
+}
+
+// writeJSON marshals v first so a failure can still be reported, headers must be set before WriteHeader
+func writeJSON(w http.ResponseWriter, status int, contentType string, v any) {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Unable to convert response to JSON: %v", err)
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_server_error","detail":"Unable to convert response to JSON"}`))
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(jsonData)
+}
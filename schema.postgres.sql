@@ -0,0 +1,262 @@
+-- PostgreSQL port of schema.sql, keep both in sync, DB_DRIVER=postgres creates databases from it
+-- TABLE
+CREATE TABLE alert ( 
+  id BIGSERIAL PRIMARY KEY, 
+  name TEXT, 
+  description TEXT, 
+  type TEXT NOT NULL DEFAULT 'notification',
+  "user" BIGINT NOT NULL, 
+  vehicle BIGINT,
+  job BIGINT,  
+  task BIGINT, 
+  is_read BIGINT NOT NULL DEFAULT 0, 
+  read_at TIMESTAMPTZ(3),
+  alert_at TIMESTAMPTZ(3),
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMPTZ(3)
+);
+CREATE TABLE audit_event ( 
+  id BIGSERIAL PRIMARY KEY, 
+  actor BIGINT, 
+  action TEXT NOT NULL, 
+  entity TEXT NOT NULL, 
+  entity_id BIGINT NOT NULL, 
+  vehicle BIGINT, 
+  job BIGINT, 
+  diff TEXT, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE calendar_token ( 
+  id BIGSERIAL PRIMARY KEY, 
+  "user" BIGINT UNIQUE NOT NULL, 
+  token TEXT UNIQUE NOT NULL, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE comment ( 
+  id BIGSERIAL PRIMARY KEY, 
+  job BIGINT NOT NULL, 
+  task BIGINT, 
+  "user" BIGINT NOT NULL, 
+  body TEXT NOT NULL, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE comment_revision ( 
+  id BIGSERIAL PRIMARY KEY, 
+  comment BIGINT NOT NULL, 
+  body TEXT NOT NULL, 
+  "user" BIGINT, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE fuel_log ( 
+  id BIGSERIAL PRIMARY KEY, 
+  vehicle BIGINT NOT NULL, 
+  "user" BIGINT, 
+  filled_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP, 
+  odometer BIGINT, 
+  volume DOUBLE PRECISION NOT NULL, 
+  cost DOUBLE PRECISION, 
+  is_full BIGINT NOT NULL DEFAULT 1, 
+  station TEXT, 
+  notes TEXT, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE job(
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  name TEXT NOT NULL,
+  description TEXT,
+  instructions TEXT,
+  is_template BIGINT DEFAULT 0 NOT NULL,
+  is_complete BIGINT DEFAULT 0 NOT NULL,
+  vehicle BIGINT,
+  "user" BIGINT,
+  origin_job BIGINT,
+  repeats BIGINT DEFAULT 0 NOT NULL, 
+  odo_interval BIGINT, 
+  time_interval BIGINT,
+  time_interval_unit VARCHAR(10),
+  due_date TIMESTAMPTZ(3), 
+  completed_at TIMESTAMPTZ(3),
+  created_at TIMESTAMPTZ(3) DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMPTZ(3) DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  status TEXT DEFAULT 'planned' NOT NULL,
+  due_odometer BIGINT,
+  deleted_at TIMESTAMPTZ(3)
+  );
+CREATE TABLE job_completion ( 
+  id BIGSERIAL PRIMARY KEY, 
+  job BIGINT NOT NULL, 
+  "user" BIGINT, 
+  odometer BIGINT, 
+  performed_by TEXT NOT NULL DEFAULT 'self', 
+  shop TEXT, 
+  notes TEXT, 
+  cost DOUBLE PRECISION, 
+  completed_at TIMESTAMPTZ(3) NOT NULL, 
+  prior_status TEXT NOT NULL, 
+  prior_odometer BIGINT, 
+  odometer_reading BIGINT, 
+  next_job BIGINT, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE job_label ( id BIGSERIAL PRIMARY KEY, job BIGINT NOT NULL, label BIGINT NOT NULL );
+CREATE TABLE job_status_history ( 
+  id BIGSERIAL PRIMARY KEY, 
+  job BIGINT NOT NULL, 
+  from_status TEXT, 
+  to_status TEXT NOT NULL, 
+  "user" BIGINT, 
+  note TEXT, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE label ( 
+  id BIGSERIAL PRIMARY KEY, 
+  name TEXT NOT NULL, 
+  color TEXT,
+  "user" BIGINT, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMPTZ(3)
+);
+CREATE TABLE odometer_reading ( 
+  id BIGSERIAL PRIMARY KEY, 
+  vehicle BIGINT NOT NULL, 
+  odometer BIGINT NOT NULL, 
+  source TEXT NOT NULL DEFAULT 'manual', 
+  job BIGINT, 
+  "user" BIGINT, 
+  recorded_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE schedule ( 
+  id BIGSERIAL PRIMARY KEY, 
+  name TEXT NOT NULL, 
+  description TEXT, 
+  make TEXT, 
+  model TEXT, 
+  year_min BIGINT, 
+  year_max BIGINT, 
+  "user" BIGINT, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE schedule_job ( id BIGSERIAL PRIMARY KEY, schedule BIGINT NOT NULL, job BIGINT NOT NULL );
+CREATE TABLE schema_version ( version BIGINT NOT NULL );
+CREATE TABLE task ( 
+  id BIGSERIAL PRIMARY KEY, 
+  name TEXT, 
+  description TEXT, 
+  is_complete BIGINT NOT NULL DEFAULT 0, 
+  job BIGINT NOT NULL, 
+  parent BIGINT,
+  position BIGINT NOT NULL DEFAULT 0,
+  part_name TEXT,
+  part_link TEXT,
+  due_date TIMESTAMPTZ(3),
+  estimated_minutes BIGINT,
+  completed_at TIMESTAMPTZ(3),
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMPTZ(3)
+);
+CREATE TABLE task_dependency (
+  task BIGINT NOT NULL,
+  depends_on BIGINT NOT NULL,
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task, depends_on)
+);
+CREATE TABLE task_label ( id BIGSERIAL PRIMARY KEY, task BIGINT NOT NULL, label BIGINT NOT NULL );
+CREATE TABLE time_entry ( 
+  id BIGSERIAL PRIMARY KEY, 
+  job BIGINT NOT NULL, 
+  task BIGINT NOT NULL, 
+  "user" BIGINT NOT NULL, 
+  started_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  stopped_at TIMESTAMPTZ(3)
+);
+CREATE TABLE "user"(
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  username TEXT UNIQUE NOT NULL,
+  email TEXT UNIQUE,
+  description TEXT,
+  hashed_pw BYTEA,
+  is_admin BIGINT DEFAULT 0,
+  created_at TIMESTAMPTZ(3) DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ(3) DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMPTZ(3)
+  );
+CREATE TABLE vehicle ( 
+  id BIGSERIAL PRIMARY KEY, 
+  name TEXT, 
+  description TEXT, 
+  type TEXT, 
+  is_metric BIGINT NOT NULL DEFAULT 0, 
+  vin TEXT, 
+  year BIGINT, 
+  make TEXT,
+  model TEXT, 
+  trim TEXT,
+  odometer BIGINT, 
+  "user" BIGINT NOT NULL, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMPTZ(3)
+);
+CREATE TABLE vehicle_document ( 
+  id BIGSERIAL PRIMARY KEY, 
+  type TEXT NOT NULL DEFAULT 'other', 
+  number TEXT, 
+  issuer TEXT, 
+  description TEXT, 
+  vehicle BIGINT NOT NULL, 
+  "user" BIGINT NOT NULL, 
+  issued_at TIMESTAMPTZ(3), 
+  expires_at TIMESTAMPTZ(3), 
+  remind_days BIGINT NOT NULL DEFAULT 30, 
+  alert BIGINT, 
+  attachment_name TEXT, 
+  attachment_type TEXT, 
+  attachment BYTEA, 
+  created_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMPTZ(3)
+);
+CREATE TABLE vehicle_label ( id BIGSERIAL PRIMARY KEY, vehicle BIGINT NOT NULL, label BIGINT NOT NULL );
+ 
+-- INDEX
+CREATE INDEX alert_at_user_idx ON alert ("user", alert_at);
+CREATE INDEX alert_user_idx ON alert ("user");
+CREATE INDEX audit_event_created_idx ON audit_event (created_at);
+CREATE INDEX audit_event_job_idx ON audit_event (job);
+CREATE INDEX audit_event_vehicle_idx ON audit_event (vehicle);
+CREATE INDEX comment_job_idx ON comment (job, created_at);
+CREATE INDEX comment_revision_comment_idx ON comment_revision (comment);
+CREATE INDEX fuel_log_vehicle_idx ON fuel_log (vehicle, filled_at);
+CREATE INDEX job_completion_job_idx ON job_completion (job);
+CREATE INDEX job_label_job_idx ON job_label (job);
+CREATE INDEX job_status_history_job_idx ON job_status_history (job);
+CREATE INDEX job_status_idx ON job (status);
+CREATE INDEX job_user_idx ON job ("user");
+CREATE INDEX job_vehicle_idx ON job (vehicle);
+CREATE INDEX label_user_idx ON label ("user");
+CREATE INDEX odometer_reading_vehicle_idx ON odometer_reading (vehicle, recorded_at);
+CREATE INDEX schedule_job_schedule_idx ON schedule_job (schedule);
+CREATE INDEX schedule_user_idx ON schedule ("user");
+CREATE INDEX task_dependency_depends_on_idx ON task_dependency (depends_on);
+CREATE INDEX task_job_idx ON task (job, position);
+CREATE INDEX task_label_task_idx ON task_label (task);
+CREATE INDEX task_parent_idx ON task (parent);
+CREATE INDEX time_entry_job_idx ON time_entry (job, started_at);
+CREATE INDEX time_entry_user_idx ON time_entry ("user", stopped_at);
+CREATE INDEX username_idx ON "user" (username);
+CREATE INDEX vehicle_document_vehicle_idx ON vehicle_document (vehicle);
+CREATE INDEX vehicle_label_vehicle_idx ON vehicle_label (vehicle);
+CREATE INDEX vehicle_user_idx ON vehicle ("user");
+ 
+-- TRIGGER
+CREATE FUNCTION audit_event_append_only() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'audit_event is append only'; END $$ LANGUAGE plpgsql;
+CREATE TRIGGER audit_event_append_only BEFORE UPDATE ON audit_event FOR EACH ROW EXECUTE FUNCTION audit_event_append_only();
+ 
+-- VIEW
+ 
@@ -12,8 +12,55 @@ CREATE TABLE alert (
   read_at DATETIME,
   alert_at DATETIME,
   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME
+);
+CREATE TABLE audit_event ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  actor INTEGER, 
+  action TEXT NOT NULL, 
+  entity TEXT NOT NULL, 
+  entity_id INTEGER NOT NULL, 
+  vehicle INTEGER, 
+  job INTEGER, 
+  diff TEXT, 
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE calendar_token ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  user INTEGER UNIQUE NOT NULL, 
+  token TEXT UNIQUE NOT NULL, 
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE comment ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  job INTEGER NOT NULL, 
+  task INTEGER, 
+  user INTEGER NOT NULL, 
+  body TEXT NOT NULL, 
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
+CREATE TABLE comment_revision ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  comment INTEGER NOT NULL, 
+  body TEXT NOT NULL, 
+  user INTEGER, 
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE fuel_log ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  vehicle INTEGER NOT NULL, 
+  user INTEGER, 
+  filled_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, 
+  odometer INTEGER, 
+  volume REAL NOT NULL, 
+  cost REAL, 
+  is_full INTEGER NOT NULL DEFAULT 1, 
+  station TEXT, 
+  notes TEXT, 
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
 CREATE TABLE job(
   id INTEGER PRIMARY KEY NOT NULL,
   name TEXT NOT NULL,
@@ -31,29 +78,100 @@ CREATE TABLE job(
   due_date DATETIME, 
   completed_at DATETIME,
   created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
-  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  status TEXT DEFAULT 'planned' NOT NULL,
+  due_odometer INTEGER,
+  deleted_at DATETIME
   );
+CREATE TABLE job_completion ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  job INTEGER NOT NULL, 
+  user INTEGER, 
+  odometer INTEGER, 
+  performed_by TEXT NOT NULL DEFAULT 'self', 
+  shop TEXT, 
+  notes TEXT, 
+  cost REAL, 
+  completed_at DATETIME NOT NULL, 
+  prior_status TEXT NOT NULL, 
+  prior_odometer INTEGER, 
+  odometer_reading INTEGER, 
+  next_job INTEGER, 
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
 CREATE TABLE job_label ( id INTEGER PRIMARY KEY AUTOINCREMENT, job INTEGER NOT NULL, label INTEGER NOT NULL );
+CREATE TABLE job_status_history ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  job INTEGER NOT NULL, 
+  from_status TEXT, 
+  to_status TEXT NOT NULL, 
+  user INTEGER, 
+  note TEXT, 
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
 CREATE TABLE label ( 
   id INTEGER PRIMARY KEY AUTOINCREMENT, 
   name TEXT NOT NULL, 
   color TEXT,
   user INTEGER, 
   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME
+);
+CREATE TABLE odometer_reading ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  vehicle INTEGER NOT NULL, 
+  odometer INTEGER NOT NULL, 
+  source TEXT NOT NULL DEFAULT 'manual', 
+  job INTEGER, 
+  user INTEGER, 
+  recorded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, 
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE schedule ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  name TEXT NOT NULL, 
+  description TEXT, 
+  make TEXT, 
+  model TEXT, 
+  year_min INTEGER, 
+  year_max INTEGER, 
+  user INTEGER, 
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
+CREATE TABLE schedule_job ( id INTEGER PRIMARY KEY AUTOINCREMENT, schedule INTEGER NOT NULL, job INTEGER NOT NULL );
 CREATE TABLE task ( 
   id INTEGER PRIMARY KEY AUTOINCREMENT, 
   name TEXT, 
   description TEXT, 
   is_complete INTEGER NOT NULL DEFAULT 0, 
   job INTEGER NOT NULL, 
+  parent INTEGER,
+  position INTEGER NOT NULL DEFAULT 0,
   part_name TEXT,
   part_link TEXT,
   due_date DATETIME,
+  estimated_minutes INTEGER,
   completed_at DATETIME,
   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME
+);
+CREATE TABLE task_dependency (
+  task INTEGER NOT NULL,
+  depends_on INTEGER NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task, depends_on)
+);
+CREATE TABLE task_label ( id INTEGER PRIMARY KEY AUTOINCREMENT, task INTEGER NOT NULL, label INTEGER NOT NULL );
+CREATE TABLE time_entry ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  job INTEGER NOT NULL, 
+  task INTEGER NOT NULL, 
+  user INTEGER NOT NULL, 
+  started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  stopped_at DATETIME
 );
 CREATE TABLE user(
   id INTEGER PRIMARY KEY NOT NULL,
@@ -63,7 +181,8 @@ CREATE TABLE user(
   hashed_pw BLOB,
   is_admin INTEGER DEFAULT 0,
   created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME
   );
 CREATE TABLE vehicle ( 
   id INTEGER PRIMARY KEY AUTOINCREMENT, 
@@ -79,21 +198,62 @@ CREATE TABLE vehicle (
   odometer INTEGER, 
   user INTEGER NOT NULL, 
   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME
+);
+CREATE TABLE vehicle_document ( 
+  id INTEGER PRIMARY KEY AUTOINCREMENT, 
+  type TEXT NOT NULL DEFAULT 'other', 
+  number TEXT, 
+  issuer TEXT, 
+  description TEXT, 
+  vehicle INTEGER NOT NULL, 
+  user INTEGER NOT NULL, 
+  issued_at DATETIME, 
+  expires_at DATETIME, 
+  remind_days INTEGER NOT NULL DEFAULT 30, 
+  alert INTEGER, 
+  attachment_name TEXT, 
+  attachment_type TEXT, 
+  attachment BLOB, 
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME
 );
+CREATE TABLE vehicle_label ( id INTEGER PRIMARY KEY AUTOINCREMENT, vehicle INTEGER NOT NULL, label INTEGER NOT NULL );
  
 -- INDEX
 CREATE INDEX alert_at_user_idx ON alert (user, alert_at);
 CREATE INDEX alert_user_idx ON alert (user);
+CREATE INDEX audit_event_created_idx ON audit_event (created_at);
+CREATE INDEX audit_event_job_idx ON audit_event (job);
+CREATE INDEX audit_event_vehicle_idx ON audit_event (vehicle);
+CREATE INDEX comment_job_idx ON comment (job, created_at);
+CREATE INDEX comment_revision_comment_idx ON comment_revision (comment);
+CREATE INDEX fuel_log_vehicle_idx ON fuel_log (vehicle, filled_at);
+CREATE INDEX job_completion_job_idx ON job_completion (job);
 CREATE INDEX job_label_job_idx ON job_label (job);
+CREATE INDEX job_status_history_job_idx ON job_status_history (job);
+CREATE INDEX job_status_idx ON job (status);
 CREATE INDEX job_user_idx ON job (user);
 CREATE INDEX job_vehicle_idx ON job (vehicle);
 CREATE INDEX label_user_idx ON label (user);
-CREATE INDEX task_job_idx ON task (job);
+CREATE INDEX odometer_reading_vehicle_idx ON odometer_reading (vehicle, recorded_at);
+CREATE INDEX schedule_job_schedule_idx ON schedule_job (schedule);
+CREATE INDEX schedule_user_idx ON schedule (user);
+CREATE INDEX task_dependency_depends_on_idx ON task_dependency (depends_on);
+CREATE INDEX task_job_idx ON task (job, position);
+CREATE INDEX task_label_task_idx ON task_label (task);
+CREATE INDEX task_parent_idx ON task (parent);
+CREATE INDEX time_entry_job_idx ON time_entry (job, started_at);
+CREATE INDEX time_entry_user_idx ON time_entry (user, stopped_at);
 CREATE INDEX username_idx ON user (username);
+CREATE INDEX vehicle_document_vehicle_idx ON vehicle_document (vehicle);
+CREATE INDEX vehicle_label_vehicle_idx ON vehicle_label (vehicle);
 CREATE INDEX vehicle_user_idx ON vehicle (user);
  
 -- TRIGGER
+CREATE TRIGGER audit_event_append_only BEFORE UPDATE ON audit_event BEGIN SELECT RAISE(ABORT, 'audit_event is append only'); END;
  
 -- VIEW
  
@@ -4,45 +4,54 @@ import (
 	"errors"
 	"time"
 
-	"github.com/okdv/wrench-turn/db"
 	"github.com/okdv/wrench-turn/models"
 )
 
 // GetAlert
 // Takes id as arg, passes to db query, returns Alert
-func GetAlert(alertId int64) (*models.Alert, error) {
-	alert, err := db.GetAlert(alertId)
+func (s *Service) GetAlert(alertId int64) (*models.Alert, error) {
+	alert, err := s.repo.Alerts.GetAlert(alertId)
 	return alert, err
 }
 
 // CreateAlert
 // Takes newAlert as arg, passes to db query, calls GetAlert, returns Alert
-func CreateAlert(newAlert models.NewAlert) (*models.Alert, error) {
+func (s *Service) CreateAlert(newAlert models.NewAlert) (*models.Alert, error) {
+	if len(newAlert.Type) == 0 {
+		newAlert.Type = "notification"
+	}
 	// pass to db query, return new Alerts id
-	alertId, err := db.CreateAlert(newAlert)
+	alertId, err := s.repo.Alerts.CreateAlert(newAlert)
 	if err != nil || alertId == nil {
 		err = errors.Join(err, errors.New("No ID of new Alert found"))
 		return nil, err
 	}
 	// pass to GetAlert, return Alert
-	alert, err := GetAlert(*alertId)
+	alert, err := s.GetAlert(*alertId)
+	if err == nil {
+		s.recordAlert("create", alert, nil, alert)
+	}
 	return alert, err
 }
 
 // EditAlert
-// Takes User as arg, passes to EditAlert query, returns updated User
-func EditAlert(editedAlert models.Alert) (*models.Alert, error) {
-	err := db.EditAlert(editedAlert)
+// Takes Alert and the updated_at it was read at, nil to skip the check, passes to EditAlert query, returns updated Alert
+func (s *Service) EditAlert(editedAlert models.Alert, revision *time.Time) (*models.Alert, error) {
+	currentAlert, _ := s.GetAlert(editedAlert.ID)
+	err := s.repo.Alerts.EditAlert(editedAlert, revision)
 	if err != nil {
 		return nil, err
 	}
-	alert, err := GetAlert(editedAlert.ID)
+	alert, err := s.GetAlert(editedAlert.ID)
+	if err == nil {
+		s.recordAlert("edit", alert, currentAlert, alert)
+	}
 	return alert, err
 }
 
 // ListAlerts
-// Takes URL query params as args, passes to ListAlerts query, returns Alert list
-func ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string, typeStr *string, isRead *string, isAlerted *string, searchStr *string, sort *string) ([]*models.Alert, error) {
+// Takes URL query params and optional Page as args, passes to ListAlerts query, returns Alert list
+func (s *Service) ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string, typeStr *string, isRead *string, isAlerted *string, searchStr *string, sort *string, page *models.Page) ([]*models.Alert, error) {
 	var alertDate *string
 	if isAlerted != nil {
 		if *isAlerted == "true" {
@@ -51,20 +60,35 @@ func ListAlerts(userId *string, vehicleId *string, jobId *string, taskId *string
 			alertDate = &currentDatetimeStr
 		}
 	}
-	users, err := db.ListAlerts(userId, vehicleId, jobId, taskId, typeStr, isRead, alertDate, searchStr, sort)
+	users, err := s.repo.Alerts.ListAlerts(userId, vehicleId, jobId, taskId, typeStr, isRead, alertDate, searchStr, sort, page)
 	return users, err
 }
 
 // DeleteAlert
 // Takes alert id as arg, passes to DeleteAlert query
-func DeleteAlert(alertId int64, userId *int64) error {
-	err := db.DeleteAlert(alertId, userId)
+func (s *Service) DeleteAlert(alertId int64, userId *int64) error {
+	alert, _ := s.GetAlert(alertId)
+	err := s.repo.Alerts.DeleteAlert(alertId, userId)
+	if err == nil && alert != nil {
+		s.recordAlert("delete", alert, alert, nil)
+	}
 	return err
 }
 
 // MarkRead
 // Takes job id, task id, complete status as args, passes to MarkRead query
-func MarkRead(alertId int64, userId int64, status int) error {
-	err := db.UpdatedAlertStatus(alertId, userId, status)
+func (s *Service) MarkRead(alertId int64, userId int64, status int) error {
+	currentAlert, _ := s.GetAlert(alertId)
+	err := s.repo.Alerts.UpdateAlertStatus(alertId, userId, status)
+	if err == nil && currentAlert != nil {
+		alert, _ := s.GetAlert(alertId)
+		s.recordAlert("read", currentAlert, currentAlert, alert)
+	}
 	return err
 }
+
+// recordAlert
+// Records a change to an alert, scoped to the vehicle and job it is about
+func (s *Service) recordAlert(action string, alert *models.Alert, before any, after any) {
+	s.record(action, "alert", alert.ID, alert.Vehicle, alert.Job, before, after)
+}
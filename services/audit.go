@@ -0,0 +1,114 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// fields left out of audit diffs, timestamps change on every edit and password hashes are secret
+var auditIgnoredFields = map[string]bool{
+	"createdAt": true,
+	"updatedAt": true,
+	"hashedPw":  true,
+}
+
+// WithActor
+// Takes acting user id as arg, returns copy of Service recording them as the actor of audit events
+func (s *Service) WithActor(userId int64) *Service {
+	scoped := *s
+	scoped.actor = &userId
+	return &scoped
+}
+
+// ListAuditEvents
+// Takes filters and optional Page as args, passes to ListAuditEvents query, returns AuditEvent list newest first
+func (s *Service) ListAuditEvents(actor *string, entity *string, action *string, vehicleId *string, jobId *string, page *models.Page) ([]*models.AuditEvent, error) {
+	events, err := s.repo.Audit.ListAuditEvents(actor, entity, action, vehicleId, jobId, page)
+	return events, err
+}
+
+// PruneAuditEvents
+// Takes retention in days as arg, deletes audit events older than it, returns number deleted
+func (s *Service) PruneAuditEvents(days int) (int64, error) {
+	deleted, err := s.repo.Audit.DeleteAuditEvents(time.Now().AddDate(0, 0, -days))
+	return deleted, err
+}
+
+// record
+// Takes action, entity, its id, vehicle and job it belongs to and its state before and after the change, appends an audit event and publishes it to live update subscribers, failures are only logged so the change itself still succeeds
+func (s *Service) record(action string, entity string, entityId int64, vehicleId *int64, jobId *int64, before any, after any) {
+	diff := auditDiff(before, after)
+	_, err := s.repo.Audit.CreateAuditEvent(models.AuditEvent{
+		Actor:     s.actor,
+		Action:    action,
+		Entity:    entity,
+		Entity_id: entityId,
+		Vehicle:   vehicleId,
+		Job:       jobId,
+		Diff:      diff,
+	})
+	if err != nil {
+		log.Printf("Could not record %v of %v ID %d: %v", action, entity, entityId, err)
+	}
+	s.publish(action, entity, entityId, vehicleId, jobId, before, after, diff)
+}
+
+// recordJob
+// Records a change to a job, scoped to the job and its vehicle
+func (s *Service) recordJob(action string, job *models.Job, before any, after any) {
+	s.record(action, "job", job.ID, job.Vehicle, &job.ID, before, after)
+}
+
+// recordTask
+// Records a change to a task, scoped to its job and the jobs vehicle
+func (s *Service) recordTask(action string, jobId int64, taskId int64, before any, after any) {
+	var vehicleId *int64
+	if job, err := s.repo.Jobs.GetJob(jobId); err == nil {
+		vehicleId = job.Vehicle
+	}
+	s.record(action, "task", taskId, vehicleId, &jobId, before, after)
+}
+
+// auditDiff
+// Takes an entity before and after a change, nil if it did not exist, returns its changed fields by json name
+func auditDiff(before any, after any) map[string]models.AuditChange {
+	beforeFields, afterFields := auditFields(before), auditFields(after)
+	diff := make(map[string]models.AuditChange)
+	for field, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[field]) {
+			diff[field] = models.AuditChange{From: value, To: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok && value != nil {
+			diff[field] = models.AuditChange{From: nil, To: value}
+		}
+	}
+	return diff
+}
+
+// auditFields
+// Takes an entity, returns its fields by json name without ignored fields
+func auditFields(entity any) map[string]any {
+	fields := make(map[string]any)
+	if entity == nil || reflect.ValueOf(entity).Kind() == reflect.Pointer && reflect.ValueOf(entity).IsNil() {
+		return fields
+	}
+	entityBytes, err := json.Marshal(entity)
+	if err != nil {
+		log.Printf("Could not encode entity for audit diff: %v", err)
+		return fields
+	}
+	err = json.Unmarshal(entityBytes, &fields)
+	if err != nil {
+		log.Printf("Could not decode entity for audit diff: %v", err)
+	}
+	for field := range auditIgnoredFields {
+		delete(fields, field)
+	}
+	return fields
+}
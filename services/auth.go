@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/okdv/wrench-turn/db"
 	"github.com/okdv/wrench-turn/models"
 	"github.com/okdv/wrench-turn/utils"
 	"golang.org/x/crypto/bcrypt"
@@ -79,9 +78,9 @@ func VerifyJWT(token string) (*models.Claims, error) {
 
 // RetrieveAuthInfo
 // Take username, retrieve userID, username, admin status, encrypted pw from db for auth purposes
-func RetrieveAuthInfo(creds *models.Credentials) (*int64, *string, *bool, *[]byte, bool, error, int) {
+func (s *Service) RetrieveAuthInfo(creds *models.Credentials) (*int64, *string, *bool, *[]byte, bool, error, int) {
 	// retrieve auth info from db
-	userId, username, isAdminInt, hashed, err := db.GetAuthInfoByUsername(creds.Username)
+	userId, username, isAdminInt, hashed, err := s.repo.Users.GetAuthInfoByUsername(creds.Username)
 	var isAdmin bool
 	if isAdminInt == nil {
 		isAdmin = false
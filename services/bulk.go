@@ -0,0 +1,80 @@
+package services
+
+import "github.com/okdv/wrench-turn/models"
+
+// BulkMarkComplete
+// Takes task ids, complete status and atomic flag, passes to BulkUpdateTaskStatus query, returns an error or nil per id and whether it was committed
+func (s *Service) BulkMarkComplete(taskIds []int64, status int, atomic bool) ([]error, bool, error) {
+	tasks := make([]*models.Task, len(taskIds))
+	for i, taskId := range taskIds {
+		tasks[i], _ = s.GetTaskById(taskId)
+	}
+	errs, committed, err := s.repo.Tasks.BulkUpdateTaskStatus(taskIds, status, atomic)
+	s.recordBulk(errs, committed, err, func(i int) {
+		if tasks[i] != nil && tasks[i].Job != nil {
+			task, _ := s.GetTaskById(taskIds[i])
+			s.recordTask("complete", *tasks[i].Job, taskIds[i], tasks[i], task)
+		}
+	})
+	return errs, committed, err
+}
+
+// BulkMarkRead
+// Takes alert ids, map of alert id to its user, read status and atomic flag, passes to BulkUpdateAlertStatus query, returns an error or nil per id and whether it was committed
+func (s *Service) BulkMarkRead(alertIds []int64, alertUsers map[int64]int64, status int, atomic bool) ([]error, bool, error) {
+	alerts := make([]*models.Alert, len(alertIds))
+	for i, alertId := range alertIds {
+		alerts[i], _ = s.GetAlert(alertId)
+	}
+	errs, committed, err := s.repo.Alerts.BulkUpdateAlertStatus(alertIds, alertUsers, status, atomic)
+	s.recordBulk(errs, committed, err, func(i int) {
+		if alerts[i] != nil {
+			alert, _ := s.GetAlert(alertIds[i])
+			s.recordAlert("read", alerts[i], alerts[i], alert)
+		}
+	})
+	return errs, committed, err
+}
+
+// BulkDeleteJobs
+// Takes job ids and atomic flag, passes to BulkDeleteJobs query moving them to the trash, returns an error or nil per id and whether it was committed
+func (s *Service) BulkDeleteJobs(jobIds []int64, atomic bool) ([]error, bool, error) {
+	jobs := make([]*models.Job, len(jobIds))
+	for i, jobId := range jobIds {
+		jobs[i], _ = s.GetJob(jobId)
+	}
+	errs, committed, err := s.repo.Jobs.BulkDeleteJobs(jobIds, atomic)
+	s.recordBulk(errs, committed, err, func(i int) {
+		if jobs[i] != nil {
+			s.recordJob("delete", jobs[i], jobs[i], nil)
+		}
+	})
+	return errs, committed, err
+}
+
+// BulkAssignJobLabel
+// Takes job ids, label id, assign flag and atomic flag, passes to BulkAssignJobLabel query, returns an error or nil per id and whether it was committed
+func (s *Service) BulkAssignJobLabel(jobIds []int64, labelId int64, assign int, atomic bool) ([]error, bool, error) {
+	errs, committed, err := s.repo.Jobs.BulkAssignJobLabel(jobIds, labelId, assign, atomic)
+	action := "unassign"
+	if assign == 1 {
+		action = "assign"
+	}
+	s.recordBulk(errs, committed, err, func(i int) {
+		s.recordJobLabel(action, jobIds[i], labelId)
+	})
+	return errs, committed, err
+}
+
+// recordBulk
+// Calls record with the index of every item a committed bulk operation changed
+func (s *Service) recordBulk(errs []error, committed bool, err error, record func(i int)) {
+	if err != nil || !committed {
+		return
+	}
+	for i, itemErr := range errs {
+		if itemErr == nil {
+			record(i)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/utils"
+	"github.com/okdv/wrench-turn/version"
+)
+
+// length of a reminders calendar event
+const calendarReminderLength = 30 * time.Minute
+
+// GetCalendarToken
+// Takes feed token as arg, returns CalendarToken it belongs to
+func (s *Service) GetCalendarToken(token string) (*models.CalendarToken, error) {
+	calendarToken, err := s.repo.Calendar.GetCalendarToken(token)
+	if err != nil {
+		return nil, err
+	}
+	calendarToken.Url = calendarUrl(calendarToken.Token)
+	return calendarToken, nil
+}
+
+// CreateCalendarToken
+// Takes user id as arg, generates a new feed token replacing any existing one, returns CalendarToken
+func (s *Service) CreateCalendarToken(userId int64) (*models.CalendarToken, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, errors.Join(err, errors.New("Unable to generate calendar token"))
+	}
+	token := hex.EncodeToString(b)
+	err = s.repo.Calendar.SetCalendarToken(userId, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCalendarToken(token)
+}
+
+// DeleteCalendarToken
+// Takes user id as arg, revokes users feed token
+func (s *Service) DeleteCalendarToken(userId int64) error {
+	err := s.repo.Calendar.DeleteCalendarToken(userId)
+	return err
+}
+
+// BuildCalendar
+// Takes user id and optional vehicle and label ids as args, returns iCalendar feed of due jobs, due tasks and reminders
+func (s *Service) BuildCalendar(userId int64, vehicleId *string, labelId *string) ([]byte, error) {
+	userIdStr := strconv.FormatInt(userId, 10)
+	isTemplate := "0"
+	jobs, err := s.ListJobs(&userIdStr, vehicleId, &isTemplate, nil, nil, labelId, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	vehicles, err := s.ListVehicles(&userIdStr, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	vehicleNames := make(map[int64]string, len(vehicles))
+	for _, vehicle := range vehicles {
+		vehicleNames[vehicle.ID] = vehicle.Name
+	}
+	alerts, err := s.ListAlerts(&userIdStr, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	cal := utils.NewCalendar("-//WrenchTurn//WrenchTurn "+version.Version+"//EN", "WrenchTurn")
+	cal.Property("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	cal.Property("X-PUBLISHED-TTL", "PT1H")
+	// ids of jobs and tasks matching filters, reminders are only included if related to them
+	jobIds := map[int64]bool{}
+	taskIds := map[int64]bool{}
+	for _, job := range jobs {
+		jobIds[job.ID] = true
+		summary := job.Name
+		if job.Vehicle != nil && len(vehicleNames[*job.Vehicle]) > 0 {
+			summary += " (" + vehicleNames[*job.Vehicle] + ")"
+		}
+		if job.Due_date != nil {
+			cal.Begin("VTODO")
+			calendarMeta(cal, "job", job.ID, job.Created_at, job.Updated_at)
+			cal.Text("SUMMARY", summary)
+			if job.Description != nil {
+				cal.Text("DESCRIPTION", *job.Description)
+			}
+			cal.Time("DUE", *job.Due_date)
+			switch job.Status {
+			case "done":
+				calendarCompleted(cal, job.Completed_at)
+			case "skipped":
+				cal.Property("STATUS", "CANCELLED")
+			case "in_progress":
+				cal.Property("STATUS", "IN-PROCESS")
+			default:
+				cal.Property("STATUS", "NEEDS-ACTION")
+			}
+			if len(job.Labels) > 0 {
+				names := make([]string, len(job.Labels))
+				for i, label := range job.Labels {
+					names[i] = label.Name
+				}
+				cal.Categories(names)
+			}
+			cal.End("VTODO")
+		}
+		tasks, err := s.ListTasks(job.ID, nil, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			taskIds[task.ID] = true
+			if task.Due_date == nil {
+				continue
+			}
+			cal.Begin("VTODO")
+			calendarMeta(cal, "task", task.ID, task.Created_at, task.Updated_at)
+			cal.Text("SUMMARY", task.Name+" - "+summary)
+			if task.Description != nil {
+				cal.Text("DESCRIPTION", *task.Description)
+			}
+			cal.Time("DUE", *task.Due_date)
+			cal.Property("RELATED-TO", calendarUid("job", job.ID))
+			// tasks of skipped jobs will never be done
+			if job.Status == "skipped" {
+				cal.Property("STATUS", "CANCELLED")
+			} else if task.Is_complete == 1 {
+				calendarCompleted(cal, task.Completed_at)
+			} else {
+				cal.Property("STATUS", "NEEDS-ACTION")
+			}
+			cal.End("VTODO")
+		}
+	}
+	for _, alert := range alerts {
+		if alert.Type != "reminder" || alert.Alert_at == nil {
+			continue
+		}
+		related := (alert.Job != nil && jobIds[*alert.Job]) || (alert.Task != nil && taskIds[*alert.Task])
+		if labelId != nil && len(*labelId) > 0 && !related {
+			continue
+		}
+		if vehicleId != nil && len(*vehicleId) > 0 && !related && (alert.Vehicle == nil || strconv.FormatInt(*alert.Vehicle, 10) != *vehicleId) {
+			continue
+		}
+		summary := "Reminder"
+		if alert.Name != nil && len(*alert.Name) > 0 {
+			summary = *alert.Name
+		}
+		cal.Begin("VEVENT")
+		calendarMeta(cal, "alert", alert.ID, alert.Created_at, alert.Updated_at)
+		cal.Text("SUMMARY", summary)
+		if alert.Description != nil {
+			cal.Text("DESCRIPTION", *alert.Description)
+		}
+		cal.Time("DTSTART", *alert.Alert_at)
+		cal.Time("DTEND", alert.Alert_at.Add(calendarReminderLength))
+		cal.Property("STATUS", "CONFIRMED")
+		cal.Property("TRANSP", "TRANSPARENT")
+		if alert.Job != nil {
+			cal.Property("RELATED-TO", calendarUid("job", *alert.Job))
+		}
+		cal.Begin("VALARM")
+		cal.Property("ACTION", "DISPLAY")
+		cal.Text("DESCRIPTION", summary)
+		cal.Property("TRIGGER", "PT0M")
+		cal.End("VALARM")
+		cal.End("VEVENT")
+	}
+	return cal.Bytes(), nil
+}
+
+// calendarUid builds a UID that stays the same for the life of the record, so clients update entries rather than duplicate them
+func calendarUid(kind string, id int64) string {
+	return kind + "-" + strconv.FormatInt(id, 10) + "@wrenchturn"
+}
+
+// calendarMeta writes the UID and timestamps shared by every entry, clients use LAST-MODIFIED to pick up edits on refresh
+func calendarMeta(cal *utils.Calendar, kind string, id int64, createdAt time.Time, updatedAt time.Time) {
+	cal.Property("UID", calendarUid(kind, id))
+	cal.Time("DTSTAMP", updatedAt)
+	cal.Time("CREATED", createdAt)
+	cal.Time("LAST-MODIFIED", updatedAt)
+}
+
+// calendarCompleted marks a VTODO as completed
+func calendarCompleted(cal *utils.Calendar, completedAt *time.Time) {
+	cal.Property("STATUS", "COMPLETED")
+	cal.Property("PERCENT-COMPLETE", "100")
+	if completedAt != nil {
+		cal.Time("COMPLETED", *completedAt)
+	}
+}
+
+// calendarUrl builds the feed url for a token
+func calendarUrl(token string) string {
+	return os.Getenv("PUBLIC_API_URL") + "/calendar/" + token + ".ics"
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// GetComment
+// Takes job id and comment id as args, passes to GetComment query, returns Comment
+func (s *Service) GetComment(jobId int64, commentId int64) (*models.Comment, error) {
+	comment, err := s.repo.Comments.GetComment(jobId, commentId)
+	return comment, err
+}
+
+// ListComments
+// Takes job id, optional task id, sort and optional Page as args, passes to ListComments query, returns Comment list
+func (s *Service) ListComments(jobId int64, taskId *string, sort *string, page *models.Page) ([]*models.Comment, error) {
+	comments, err := s.repo.Comments.ListComments(jobId, taskId, sort, page)
+	return comments, err
+}
+
+// CreateComment
+// Takes NewComment, Job and author id as args, checks task belongs to job, creates comment, alerts participants if requested, returns Comment
+func (s *Service) CreateComment(newComment models.NewComment, job *models.Job, userId int64) (*models.Comment, error) {
+	// comments on a task must be on a task of this job
+	if newComment.Task != nil {
+		_, err := s.GetTask(job.ID, *newComment.Task)
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("Task ID %d not found on job ID %d", *newComment.Task, job.ID))
+		}
+	}
+	// pass to db query, return new Comments id
+	commentId, err := s.repo.Comments.CreateComment(newComment, job.ID, userId)
+	if err != nil || commentId == nil {
+		err = errors.Join(err, errors.New("No ID of new Comment found"))
+		return nil, err
+	}
+	comment, err := s.GetComment(job.ID, *commentId)
+	if err != nil {
+		return nil, err
+	}
+	s.record("create", "comment", comment.ID, job.Vehicle, &job.ID, nil, comment)
+	if newComment.Notify != nil && *newComment.Notify == 1 {
+		s.notifyComment(job, comment)
+	}
+	return comment, nil
+}
+
+// EditComment
+// Takes Comment and id of the editing user as args, keeps previous body as a revision, returns updated Comment
+func (s *Service) EditComment(editedComment models.Comment, job *models.Job, userId int64) (*models.Comment, error) {
+	currentComment, _ := s.GetComment(job.ID, editedComment.ID)
+	err := s.repo.Comments.EditComment(editedComment, userId)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := s.GetComment(job.ID, editedComment.ID)
+	if err == nil {
+		s.record("edit", "comment", comment.ID, job.Vehicle, &job.ID, currentComment, comment)
+	}
+	return comment, err
+}
+
+// DeleteComment
+// Takes Job and comment id as args, passes to DeleteComment query
+func (s *Service) DeleteComment(job *models.Job, commentId int64) error {
+	comment, _ := s.GetComment(job.ID, commentId)
+	err := s.repo.Comments.DeleteComment(job.ID, commentId)
+	if err == nil && comment != nil {
+		s.record("delete", "comment", comment.ID, job.Vehicle, &job.ID, comment, nil)
+	}
+	return err
+}
+
+// ListCommentRevisions
+// Takes comment id as arg, passes to ListCommentRevisions query, returns CommentRevision list newest first
+func (s *Service) ListCommentRevisions(commentId int64) ([]*models.CommentRevision, error) {
+	revisions, err := s.repo.Comments.ListCommentRevisions(commentId)
+	return revisions, err
+}
+
+// notifyComment
+// Creates a notification alert about a new comment for the job owner and everyone else who commented on the job, except its author
+func (s *Service) notifyComment(job *models.Job, comment *models.Comment) {
+	comments, err := s.ListComments(job.ID, nil, nil, nil)
+	if err != nil {
+		log.Printf("Could not list participants of job ID %d: %v", job.ID, err)
+		return
+	}
+	recipients := []int64{job.User}
+	seen := map[int64]bool{job.User: true}
+	for _, c := range comments {
+		if !seen[c.User] {
+			seen[c.User] = true
+			recipients = append(recipients, c.User)
+		}
+	}
+	// keep within the alerts name and description limits
+	name := truncateRunes(fmt.Sprintf("New comment on %v", job.Name), 100)
+	description := truncateRunes(comment.Body, 2000)
+	for _, userId := range recipients {
+		if userId == comment.User {
+			continue
+		}
+		recipient := userId
+		_, err := s.CreateAlert(models.NewAlert{
+			Name:        &name,
+			Description: &description,
+			Type:        "notification",
+			User:        &recipient,
+			Vehicle:     job.Vehicle,
+			Job:         &job.ID,
+			Task:        comment.Task,
+		})
+		if err != nil {
+			log.Printf("Could not alert user ID %d of comment ID %d: %v", recipient, comment.ID, err)
+		}
+	}
+}
+
+// truncateRunes returns str cut to at most n characters
+func truncateRunes(str string, n int) string {
+	runes := []rune(str)
+	if len(runes) <= n {
+		return str
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/utils"
+)
+
+// ValidPerformedBy
+// Takes performed by as arg, returns whether it is self or shop
+func ValidPerformedBy(performedBy string) bool {
+	return performedBy == "self" || performedBy == "shop"
+}
+
+// GetJobCompletion
+// Takes job id as arg, passes to db query, returns its latest JobCompletion
+func (s *Service) GetJobCompletion(jobId int64) (*models.JobCompletion, error) {
+	completion, err := s.repo.Jobs.GetLatestJobCompletion(jobId)
+	return completion, err
+}
+
+// CompleteJob
+// Takes job id, completion details and acting user id as args, marks job done, records odometer and creates next occurrence of recurring jobs, returns JobCompletion
+func (s *Service) CompleteJob(jobId int64, newCompletion models.NewJobCompletion, userId int64) (*models.JobCompletion, error) {
+	job, err := s.GetJob(jobId)
+	if err != nil {
+		return nil, err
+	}
+	// validate status change against state machine
+	if !CanTransitionJobStatus(job.Status, "done") {
+		return nil, fmt.Errorf("Job status cannot change from %v to done", job.Status)
+	}
+	// set default values
+	completedAt := time.Now().UTC()
+	if newCompletion.Completed_at != nil {
+		completedAt = newCompletion.Completed_at.UTC()
+	}
+	performedBy := "self"
+	if newCompletion.Performed_by != nil {
+		performedBy = *newCompletion.Performed_by
+	}
+	if !ValidPerformedBy(performedBy) {
+		return nil, errors.New("Performed by must be self or shop")
+	}
+	completion := models.JobCompletion{
+		Job:          jobId,
+		User:         &userId,
+		Odometer:     newCompletion.Odometer,
+		Performed_by: performedBy,
+		Shop:         newCompletion.Shop,
+		Notes:        newCompletion.Notes,
+		Cost:         newCompletion.Cost,
+		Completed_at: completedAt,
+		Prior_status: job.Status,
+	}
+	// only move the vehicle odometer forward, backdated completions may be lower
+	moveOdometer := false
+	if job.Vehicle != nil && newCompletion.Odometer != nil {
+		vehicle, err := s.GetVehicle(*job.Vehicle)
+		if err != nil {
+			return nil, err
+		}
+		completion.Prior_odometer = vehicle.Odometer
+		moveOdometer = vehicle.Odometer == nil || *newCompletion.Odometer > *vehicle.Odometer
+	}
+	// if job repeats, create its next occurrence from this completion
+	var nextJob *models.NextJob
+	if job.Repeats == 1 && job.Is_template == 0 {
+		nextJob, err = s.nextJob(*job, completedAt, newCompletion.Odometer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// mark job done, record odometer, status history, next occurrence and completion together
+	_, err = s.repo.Jobs.RecordJobCompletion(completion, job.Vehicle, moveOdometer, nextJob)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := s.GetJobCompletion(jobId)
+	if err != nil {
+		return nil, err
+	}
+	if recorded.Next_job != nil {
+		s.recordNextJob(*recorded.Next_job)
+	}
+	if completedJob, err := s.GetJob(jobId); err == nil {
+		s.recordJob("complete", completedJob, job, completedJob)
+	}
+	return recorded, nil
+}
+
+// nextJob
+// Takes completed recurring job, completion time and odometer as args, returns the next occurrence (with tasks and labels) due one interval later
+func (s *Service) nextJob(job models.Job, completedAt time.Time, odometer *int64) (*models.NextJob, error) {
+	// next due date is one time interval from completion
+	var dueDate *time.Time
+	if job.Time_interval != nil && job.Time_interval_unit != nil {
+		due, err := utils.AddTimeInterval(completedAt, *job.Time_interval, *job.Time_interval_unit)
+		if err == nil {
+			dueDate = &due
+		}
+	}
+	// next due odometer is one odometer interval from odometer at service
+	var dueOdometer *int64
+	if job.Odo_interval != nil && odometer != nil {
+		due := *odometer + *job.Odo_interval
+		dueOdometer = &due
+	}
+	// keep pointing at the original job so the series can be followed back
+	originJob := job.ID
+	if job.Origin_job != nil {
+		originJob = *job.Origin_job
+	}
+	// copy tasks onto next job, unchecked
+	tasks, err := s.ListTasks(job.ID, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	labelIds := make([]int64, 0, len(job.Labels))
+	for _, label := range job.Labels {
+		labelIds = append(labelIds, label.ID)
+	}
+	isTemplate := 0
+	repeats := 1
+	status := "planned"
+	return &models.NextJob{
+		Job: models.NewJob{
+			Name:               job.Name,
+			Description:        job.Description,
+			Instructions:       job.Instructions,
+			Is_template:        &isTemplate,
+			Status:             &status,
+			Vehicle:            job.Vehicle,
+			User:               &job.User,
+			Origin_job:         &originJob,
+			Repeats:            &repeats,
+			Odo_interval:       job.Odo_interval,
+			Time_interval:      job.Time_interval,
+			Time_interval_unit: job.Time_interval_unit,
+			Due_date:           dueDate,
+			Due_odometer:       dueOdometer,
+		},
+		Tasks:  tasks,
+		Labels: labelIds,
+	}, nil
+}
+
+// recordNextJob
+// Takes id of a next occurrence created by a completion, records it being created with its tasks and labels
+func (s *Service) recordNextJob(jobId int64) {
+	job, err := s.GetJob(jobId)
+	if err != nil {
+		return
+	}
+	s.recordJob("create", job, nil, job)
+	if tasks, err := s.ListTasks(jobId, nil, nil, nil, nil); err == nil {
+		for _, task := range tasks {
+			s.recordTask("create", jobId, task.ID, nil, task)
+		}
+	}
+	for _, label := range job.Labels {
+		s.recordJobLabel("assign", jobId, label.ID)
+	}
+}
+
+// UndoJobCompletion
+// Takes job id and acting user id as args, reverts its latest completion: restores prior status and vehicle odometer, removes the untouched next occurrence, returns Job
+func (s *Service) UndoJobCompletion(jobId int64, userId int64) (*models.Job, error) {
+	job, err := s.GetJob(jobId)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != "done" {
+		return nil, errors.New("Job is not complete")
+	}
+	completion, err := s.GetJobCompletion(jobId)
+	if err != nil {
+		return nil, err
+	}
+	// restore vehicle odometer if this completion moved it
+	restoreOdometer := false
+	if job.Vehicle != nil && completion.Odometer != nil {
+		vehicle, err := s.GetVehicle(*job.Vehicle)
+		if err != nil {
+			return nil, err
+		}
+		restoreOdometer = vehicle.Odometer != nil && *vehicle.Odometer == *completion.Odometer
+	}
+	// remove next occurrence, unless work on it has already started or it was deleted
+	var nextJob *models.Job
+	if completion.Next_job != nil {
+		nextJob, err = s.GetJob(*completion.Next_job)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if nextJob != nil && nextJob.Status != "planned" {
+			nextJob = nil
+		}
+	}
+	// restore prior status, remove odometer reading, next occurrence and completion together
+	err = s.repo.Jobs.UndoJobCompletion(*completion, job.Vehicle, restoreOdometer, nextJob != nil, &userId)
+	if err != nil {
+		return nil, err
+	}
+	if nextJob != nil {
+		s.recordJob("delete", nextJob, nextJob, nil)
+	}
+	undoneJob, err := s.GetJob(jobId)
+	if err == nil {
+		s.recordJob("edit", undoneJob, job, undoneJob)
+	}
+	return undoneJob, err
+}
+
+// ListOdometerReadings
+// Takes vehicle id as arg, passes to ListOdometerReadings query, returns OdometerReading list
+func (s *Service) ListOdometerReadings(vehicleId int64) ([]*models.OdometerReading, error) {
+	readings, err := s.repo.Vehicles.ListOdometerReadings(vehicleId)
+	return readings, err
+}
+
+// CreateOdometerReading
+// Takes reading, vehicle and acting user id as args, records reading and moves vehicle odometer forward, returns OdometerReading list
+func (s *Service) CreateOdometerReading(newReading models.NewOdometerReading, vehicle models.Vehicle, userId int64) ([]*models.OdometerReading, error) {
+	readingId, err := s.repo.Vehicles.CreateOdometerReading(vehicle.ID, newReading, "manual", nil, &userId)
+	if err != nil {
+		return nil, err
+	}
+	s.record("create", "odometer_reading", *readingId, &vehicle.ID, nil, nil, newReading)
+	// only move the vehicle odometer forward, readings may be backdated
+	if vehicle.Odometer == nil || newReading.Odometer > *vehicle.Odometer {
+		err = s.repo.Vehicles.UpdateVehicleOdometer(vehicle.ID, &newReading.Odometer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.ListOdometerReadings(vehicle.ID)
+}
@@ -0,0 +1,521 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/utils"
+	"github.com/okdv/wrench-turn/validate"
+)
+
+// csv columns, named after json fields so exports can be imported again
+var (
+	VehicleCSVColumns = []string{"id", "name", "description", "type", "isMetric", "vin", "year", "make", "model", "trim", "odometer", "user", "createdAt", "updatedAt"}
+	JobCSVColumns     = []string{"id", "name", "description", "instructions", "status", "isTemplate", "isComplete", "vehicle", "user", "originJob", "labels", "repeats", "odoInterval", "timeInterval", "timeIntervalUnit", "dueDate", "dueOdometer", "completedAt", "createdAt", "updatedAt"}
+	TaskCSVColumns    = []string{"id", "job", "parent", "position", "name", "description", "isComplete", "partName", "partLink", "dueDate", "estimatedMinutes", "completedAt", "createdAt", "updatedAt"}
+	FuelLogCSVColumns = []string{"id", "vehicle", "user", "filledAt", "odometer", "volume", "cost", "isFull", "station", "notes", "createdAt"}
+)
+
+// csv fields that can be imported
+var (
+	vehicleCSVImportFields = []string{"name", "description", "type", "isMetric", "vin", "year", "make", "model", "trim", "odometer"}
+	jobCSVImportFields     = []string{"name", "description", "instructions", "isTemplate", "vehicle", "repeats", "odoInterval", "timeInterval", "timeIntervalUnit", "dueDate", "dueOdometer"}
+	taskCSVImportFields    = []string{"name", "description", "partName", "partLink", "dueDate", "estimatedMinutes"}
+	fuelLogCSVImportFields = []string{"filledAt", "odometer", "volume", "cost", "isFull", "station", "notes"}
+)
+
+// WriteVehiclesCSV
+// Takes writer and Vehicle list as args, writes them as csv with a header row
+func WriteVehiclesCSV(w io.Writer, vehicles []*models.Vehicle) error {
+	cw := csv.NewWriter(w)
+	cw.Write(VehicleCSVColumns)
+	for _, v := range vehicles {
+		cw.Write([]string{
+			csvValue(v.ID), v.Name, csvValue(v.Description), csvValue(v.Type), csvValue(v.Is_metric), csvValue(v.Vin), csvValue(v.Year),
+			csvValue(v.Make), csvValue(v.Model), csvValue(v.Trim), csvValue(v.Odometer), csvValue(v.User), csvValue(v.Created_at), csvValue(v.Updated_at),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJobsCSV
+// Takes writer and Job list as args, writes them as csv with a header row, labels are joined by semicolons
+func WriteJobsCSV(w io.Writer, jobs []*models.Job) error {
+	cw := csv.NewWriter(w)
+	cw.Write(JobCSVColumns)
+	for _, j := range jobs {
+		labels := make([]string, len(j.Labels))
+		for i, label := range j.Labels {
+			labels[i] = label.Name
+		}
+		cw.Write([]string{
+			csvValue(j.ID), j.Name, csvValue(j.Description), csvValue(j.Instructions), j.Status, csvValue(j.Is_template), csvValue(j.Is_complete),
+			csvValue(j.Vehicle), csvValue(j.User), csvValue(j.Origin_job), strings.Join(labels, ";"), csvValue(j.Repeats), csvValue(j.Odo_interval),
+			csvValue(j.Time_interval), csvValue(j.Time_interval_unit), csvValue(j.Due_date), csvValue(j.Due_odometer), csvValue(j.Completed_at),
+			csvValue(j.Created_at), csvValue(j.Updated_at),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTasksCSV
+// Takes writer and Task list as args, writes them as csv with a header row
+func WriteTasksCSV(w io.Writer, tasks []*models.Task) error {
+	cw := csv.NewWriter(w)
+	cw.Write(TaskCSVColumns)
+	for _, t := range tasks {
+		cw.Write([]string{
+			csvValue(t.ID), csvValue(t.Job), csvValue(t.Parent), csvValue(t.Position), t.Name, csvValue(t.Description), csvValue(t.Is_complete), csvValue(t.Part_name), csvValue(t.Part_link),
+			csvValue(t.Due_date), csvValue(t.Estimated_minutes), csvValue(t.Completed_at), csvValue(t.Created_at), csvValue(t.Updated_at),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteFuelLogsCSV
+// Takes writer and FuelLog list as args, writes them as csv with a header row
+func WriteFuelLogsCSV(w io.Writer, fuelLogs []*models.FuelLog) error {
+	cw := csv.NewWriter(w)
+	cw.Write(FuelLogCSVColumns)
+	for _, f := range fuelLogs {
+		cw.Write([]string{
+			csvValue(f.ID), csvValue(f.Vehicle), csvValue(f.User), csvValue(f.Filled_at), csvValue(f.Odometer), csvValue(f.Volume), csvValue(f.Cost),
+			csvValue(f.Is_full), csvValue(f.Station), csvValue(f.Notes), csvValue(f.Created_at),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportVehiclesCSV
+// Takes csv file, column mapping, owner id and preview flag as args, validates every row, creates vehicles in one transaction unless previewing or any row is invalid, returns CSVImportResult
+func (s *Service) ImportVehiclesCSV(r io.Reader, mapping map[string]string, userId int64, preview bool) (*models.CSVImportResult, error) {
+	result, rows, err := readCSV(r, mapping, vehicleCSVImportFields)
+	if err != nil {
+		return nil, err
+	}
+	result.Preview = preview
+	newVehicles := make([]models.NewVehicle, 0, len(rows))
+	for i, row := range rows {
+		p := csvRowParser{row: row, line: i + 2}
+		newVehicle := models.NewVehicle{
+			Name:        p.required("name"),
+			Description: p.str("description"),
+			Type:        p.str("type"),
+			Is_metric:   p.boolean("isMetric"),
+			Vin:         p.str("vin"),
+			Year:        p.int64("year"),
+			Make:        p.str("make"),
+			Model:       p.str("model"),
+			Trim:        p.str("trim"),
+			User:        &userId,
+		}
+		// set default values
+		defaultBool := 0
+		if newVehicle.Is_metric == nil {
+			newVehicle.Is_metric = &defaultBool
+		}
+		if odometer := p.int64("odometer"); odometer != nil {
+			o := int(*odometer)
+			newVehicle.Odometer = &o
+		}
+		p.validate(newVehicle)
+		result.Errors = append(result.Errors, p.errors...)
+		newVehicles = append(newVehicles, newVehicle)
+	}
+	result.Records = newVehicles
+	if preview || len(result.Errors) > 0 {
+		return result, nil
+	}
+	result.Created, err = s.repo.Vehicles.ImportVehicles(newVehicles)
+	if err == nil {
+		for i, vehicleId := range result.Created {
+			s.record("create", "vehicle", vehicleId, &result.Created[i], nil, nil, newVehicles[i])
+		}
+	}
+	return result, err
+}
+
+// ImportJobsCSV
+// Takes csv file, column mapping, owner id, admin flag and preview flag as args, validates every row, creates jobs in one transaction unless previewing or any row is invalid, returns CSVImportResult
+func (s *Service) ImportJobsCSV(r io.Reader, mapping map[string]string, userId int64, isAdmin bool, preview bool) (*models.CSVImportResult, error) {
+	result, rows, err := readCSV(r, mapping, jobCSVImportFields)
+	if err != nil {
+		return nil, err
+	}
+	result.Preview = preview
+	status := "planned"
+	newJobs := make([]models.NewJob, 0, len(rows))
+	// cache vehicle lookups, spreadsheets tend to repeat them
+	vehicleOwners := make(map[int64]*int64)
+	for i, row := range rows {
+		p := csvRowParser{row: row, line: i + 2}
+		newJob := models.NewJob{
+			Name:               p.required("name"),
+			Description:        p.str("description"),
+			Instructions:       p.str("instructions"),
+			Is_template:        p.boolean("isTemplate"),
+			Status:             &status,
+			Vehicle:            p.int64("vehicle"),
+			User:               &userId,
+			Repeats:            p.boolean("repeats"),
+			Odo_interval:       p.int64("odoInterval"),
+			Time_interval:      p.int64("timeInterval"),
+			Time_interval_unit: p.str("timeIntervalUnit"),
+			Due_date:           p.time("dueDate"),
+			Due_odometer:       p.int64("dueOdometer"),
+		}
+		// set default values
+		defaultBool := 0
+		if newJob.Is_template == nil {
+			newJob.Is_template = &defaultBool
+		}
+		if newJob.Repeats == nil {
+			newJob.Repeats = &defaultBool
+		}
+		// vehicle must exist and belong to user
+		if newJob.Vehicle != nil {
+			owner, ok := vehicleOwners[*newJob.Vehicle]
+			if !ok {
+				if vehicle, err := s.GetVehicle(*newJob.Vehicle); err == nil && vehicle != nil {
+					owner = &vehicle.User
+				}
+				vehicleOwners[*newJob.Vehicle] = owner
+			}
+			if owner == nil {
+				p.fail("vehicle", fmt.Sprintf("vehicle ID %d not found", *newJob.Vehicle))
+			} else if *owner != userId && !isAdmin {
+				p.fail("vehicle", fmt.Sprintf("vehicle ID %d belongs to another user", *newJob.Vehicle))
+			}
+		}
+		// time intervals need a valid unit
+		if newJob.Time_interval != nil {
+			if newJob.Time_interval_unit == nil {
+				p.fail("timeIntervalUnit", "required with timeInterval")
+			} else if _, err := utils.AddTimeInterval(time.Now(), *newJob.Time_interval, *newJob.Time_interval_unit); err != nil {
+				p.fail("timeIntervalUnit", err.Error())
+			}
+		}
+		p.validate(newJob)
+		result.Errors = append(result.Errors, p.errors...)
+		newJobs = append(newJobs, newJob)
+	}
+	result.Records = newJobs
+	if preview || len(result.Errors) > 0 {
+		return result, nil
+	}
+	result.Created, err = s.repo.Jobs.ImportJobs(newJobs)
+	if err == nil {
+		for i, jobId := range result.Created {
+			s.record("create", "job", jobId, newJobs[i].Vehicle, &result.Created[i], nil, newJobs[i])
+		}
+	}
+	return result, err
+}
+
+// ImportTasksCSV
+// Takes csv file, column mapping, job id and preview flag as args, validates every row, creates tasks on job in one transaction unless previewing or any row is invalid, returns CSVImportResult
+func (s *Service) ImportTasksCSV(r io.Reader, mapping map[string]string, jobId int64, preview bool) (*models.CSVImportResult, error) {
+	result, rows, err := readCSV(r, mapping, taskCSVImportFields)
+	if err != nil {
+		return nil, err
+	}
+	result.Preview = preview
+	newTasks := make([]models.NewTask, 0, len(rows))
+	for i, row := range rows {
+		p := csvRowParser{row: row, line: i + 2}
+		newTask := models.NewTask{
+			Name:              p.required("name"),
+			Description:       p.str("description"),
+			Part_name:         p.str("partName"),
+			Part_link:         p.str("partLink"),
+			Due_date:          p.time("dueDate"),
+			Estimated_minutes: p.int64("estimatedMinutes"),
+		}
+		p.validate(newTask)
+		result.Errors = append(result.Errors, p.errors...)
+		newTasks = append(newTasks, newTask)
+	}
+	result.Records = newTasks
+	if preview || len(result.Errors) > 0 {
+		return result, nil
+	}
+	result.Created, err = s.repo.Tasks.ImportTasks(newTasks, jobId)
+	if err == nil {
+		for i, taskId := range result.Created {
+			s.recordTask("create", jobId, taskId, nil, newTasks[i])
+		}
+	}
+	return result, err
+}
+
+// ImportFuelLogsCSV
+// Takes csv file, column mapping, vehicle, acting user id and preview flag as args, validates every row, creates fuel logs on vehicle in one transaction unless previewing or any row is invalid, returns CSVImportResult
+func (s *Service) ImportFuelLogsCSV(r io.Reader, mapping map[string]string, vehicle models.Vehicle, userId int64, preview bool) (*models.CSVImportResult, error) {
+	result, rows, err := readCSV(r, mapping, fuelLogCSVImportFields)
+	if err != nil {
+		return nil, err
+	}
+	result.Preview = preview
+	newFuelLogs := make([]models.NewFuelLog, 0, len(rows))
+	for i, row := range rows {
+		p := csvRowParser{row: row, line: i + 2}
+		newFuelLog := models.NewFuelLog{
+			Odometer: p.int64("odometer"),
+			Cost:     p.float64("cost"),
+			Is_full:  p.boolean("isFull"),
+			Station:  p.str("station"),
+			Notes:    p.str("notes"),
+		}
+		// when and how much was filled are always needed
+		if len(p.required("filledAt")) > 0 {
+			newFuelLog.Filled_at = p.time("filledAt")
+		}
+		if len(p.required("volume")) > 0 {
+			if volume := p.float64("volume"); volume != nil {
+				newFuelLog.Volume = *volume
+			}
+		}
+		p.validate(newFuelLog)
+		result.Errors = append(result.Errors, p.errors...)
+		newFuelLogs = append(newFuelLogs, newFuelLog)
+	}
+	result.Records = newFuelLogs
+	if preview || len(result.Errors) > 0 {
+		return result, nil
+	}
+	result.Created, err = s.repo.Fuel.ImportFuelLogs(vehicle.ID, newFuelLogs, &userId, forwardOdometer(vehicle, newFuelLogs))
+	if err == nil {
+		for i, fuelLogId := range result.Created {
+			s.record("create", "fuel_log", fuelLogId, &vehicle.ID, nil, nil, newFuelLogs[i])
+		}
+	}
+	return result, err
+}
+
+// readCSV
+// Takes csv file, column mapping (csv header to field) and importable fields as args, maps headers to fields, unmapped headers matching a field name are used as is, returns result with columns and rows keyed by field
+func readCSV(r io.Reader, mapping map[string]string, fields []string) (*models.CSVImportResult, []map[string]string, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// index importable fields by normalized name
+	known := make(map[string]string)
+	for _, field := range fields {
+		known[normalizeCSVHeader(field)] = field
+	}
+	result := &models.CSVImportResult{
+		Columns: make(map[string]string),
+		Created: make([]int64, 0),
+		Errors:  make([]models.CSVRowError, 0),
+	}
+	columns := make([]string, len(header))
+	for i, h := range header {
+		// excel prefixes utf-8 files with a byte order mark
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		target := h
+		if mapped, ok := mapping[h]; ok {
+			target = mapped
+		}
+		field, ok := known[normalizeCSVHeader(target)]
+		if !ok {
+			// explicitly mapped columns must map to something importable
+			if _, mapped := mapping[h]; mapped {
+				return nil, nil, fmt.Errorf("Column %q is mapped to unknown field %q, fields are %v", h, target, strings.Join(fields, ", "))
+			}
+			result.Columns[h] = ""
+			continue
+		}
+		columns[i] = field
+		result.Columns[h] = field
+	}
+	// read rows, keyed by field
+	rows := make([]map[string]string, 0)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		row := make(map[string]string)
+		for i, value := range record {
+			if i < len(columns) && len(columns[i]) > 0 {
+				row[columns[i]] = strings.TrimSpace(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	result.Rows = len(rows)
+	return result, rows, nil
+}
+
+// normalizeCSVHeader
+// Takes csv header as arg, returns it lower case without spaces, dashes and underscores, so "Due Date" matches dueDate
+func normalizeCSVHeader(header string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "_", "", "-", "").Replace(header))
+}
+
+// csvRowParser
+// Parses typed values out of a csv row, collecting an error per bad cell instead of stopping at the first
+type csvRowParser struct {
+	row    map[string]string
+	line   int
+	errors []models.CSVRowError
+}
+
+// fail records an error for a column of the row
+func (p *csvRowParser) fail(column string, message string) {
+	p.errors = append(p.errors, models.CSVRowError{Row: p.line, Column: column, Error: message})
+}
+
+// validate records validation errors of the row record, skipping columns that already failed to parse
+func (p *csvRowParser) validate(record any) {
+	var errs validate.Errors
+	if !errors.As(validate.Struct(record), &errs) {
+		return
+	}
+	failed := make(map[string]bool, len(p.errors))
+	for _, rowErr := range p.errors {
+		failed[rowErr.Column] = true
+	}
+	for _, fieldErr := range errs {
+		if !failed[fieldErr.Field] {
+			p.fail(fieldErr.Field, fieldErr.Message)
+		}
+	}
+}
+
+// required returns the value of a column that must not be empty
+func (p *csvRowParser) required(column string) string {
+	value := p.row[column]
+	if len(value) == 0 {
+		p.fail(column, "required")
+	}
+	return value
+}
+
+// str returns the value of a column, nil if empty
+func (p *csvRowParser) str(column string) *string {
+	value, ok := p.row[column]
+	if !ok || len(value) == 0 {
+		return nil
+	}
+	return &value
+}
+
+// int64 returns the value of a column as an integer, nil if empty
+func (p *csvRowParser) int64(column string) *int64 {
+	value := p.str(column)
+	if value == nil {
+		return nil
+	}
+	// spreadsheets often add thousands separators
+	i, err := strconv.ParseInt(strings.ReplaceAll(*value, ",", ""), 10, 64)
+	if err != nil {
+		p.fail(column, fmt.Sprintf("%q is not a whole number", *value))
+		return nil
+	}
+	return &i
+}
+
+// float64 returns the value of a column as a number, nil if empty
+func (p *csvRowParser) float64(column string) *float64 {
+	value := p.str(column)
+	if value == nil {
+		return nil
+	}
+	// spreadsheets often add thousands separators
+	f, err := strconv.ParseFloat(strings.ReplaceAll(*value, ",", ""), 64)
+	if err != nil {
+		p.fail(column, fmt.Sprintf("%q is not a number", *value))
+		return nil
+	}
+	return &f
+}
+
+// boolean returns the value of a column as 0 or 1, nil if empty
+func (p *csvRowParser) boolean(column string) *int {
+	value := p.str(column)
+	if value == nil {
+		return nil
+	}
+	var b int
+	switch strings.ToLower(*value) {
+	case "1", "true", "yes", "y":
+		b = 1
+	case "0", "false", "no", "n":
+		b = 0
+	default:
+		p.fail(column, fmt.Sprintf("%q is not true or false", *value))
+		return nil
+	}
+	return &b
+}
+
+// time returns the value of a column as a time, accepting dates (YYYY-MM-DD) and RFC 3339 times, nil if empty
+func (p *csvRowParser) time(column string) *time.Time {
+	value := p.str(column)
+	if value == nil {
+		return nil
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339, time.DateTime} {
+		if t, err := time.Parse(layout, *value); err == nil {
+			return &t
+		}
+	}
+	p.fail(column, fmt.Sprintf("%q is not a date (YYYY-MM-DD)", *value))
+	return nil
+}
+
+// csvValue
+// Takes a field value as arg, returns it formatted for csv, nil pointers are empty
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case *int:
+		if v != nil {
+			return strconv.Itoa(*v)
+		}
+	case *int64:
+		if v != nil {
+			return strconv.FormatInt(*v, 10)
+		}
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case *float64:
+		if v != nil {
+			return strconv.FormatFloat(*v, 'f', -1, 64)
+		}
+	case *string:
+		if v != nil {
+			return *v
+		}
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if v != nil {
+			return v.UTC().Format(time.RFC3339)
+		}
+	}
+	return ""
+}
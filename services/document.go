@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// default number of days before expiry to remind users
+var defaultRemindDays int64 = 30
+
+// ValidDocumentType
+// Takes document type as arg, returns whether it is a supported type
+func ValidDocumentType(typeStr string) bool {
+	switch typeStr {
+	case "registration", "insurance", "inspection", "other":
+		return true
+	}
+	return false
+}
+
+// GetDocument
+// Takes ids as args, passes to db query, returns Document
+func (s *Service) GetDocument(vehicleId int64, documentId int64) (*models.Document, error) {
+	document, err := s.repo.Documents.GetDocument(vehicleId, documentId)
+	return document, err
+}
+
+// ListDocuments
+// Takes URL query params as args, passes to ListDocuments query, returns Document list
+func (s *Service) ListDocuments(vehicleId int64, typeStr *string, expiresBefore *string, searchStr *string, sort *string) ([]*models.Document, error) {
+	documents, err := s.repo.Documents.ListDocuments(vehicleId, typeStr, expiresBefore, searchStr, sort)
+	return documents, err
+}
+
+// CreateDocument
+// Takes newDocument and vehicle as args, passes to db query, schedules expiry reminder, returns Document
+func (s *Service) CreateDocument(newDocument models.NewDocument, vehicle models.Vehicle) (*models.Document, error) {
+	// set default values
+	if newDocument.Remind_days == nil {
+		newDocument.Remind_days = &defaultRemindDays
+	}
+	// pass to db query, return new Documents id
+	documentId, err := s.repo.Documents.CreateDocument(newDocument, vehicle.ID, vehicle.User)
+	if err != nil || documentId == nil {
+		err = errors.Join(err, errors.New("No ID of new Document found"))
+		return nil, err
+	}
+	document, err := s.GetDocument(vehicle.ID, *documentId)
+	if err != nil {
+		return nil, err
+	}
+	// create reminder alert ahead of expiry
+	err = s.SyncDocumentReminder(document)
+	if err != nil {
+		log.Printf("Could not create reminder for document ID %d: %v", document.ID, err)
+	}
+	// pass to GetDocument, return Document
+	document, err = s.GetDocument(vehicle.ID, *documentId)
+	if err == nil {
+		s.record("create", "document", document.ID, &vehicle.ID, nil, nil, document)
+	}
+	return document, err
+}
+
+// EditDocument
+// Takes edited document, vehicle id as args, passes to EditDocument query, reschedules expiry reminder, returns updated Document
+func (s *Service) EditDocument(editedDocument models.Document, vehicleId int64) (*models.Document, error) {
+	currentDocument, _ := s.GetDocument(vehicleId, editedDocument.ID)
+	err := s.repo.Documents.EditDocument(editedDocument, vehicleId)
+	if err != nil {
+		return nil, err
+	}
+	document, err := s.GetDocument(vehicleId, editedDocument.ID)
+	if err != nil {
+		return nil, err
+	}
+	// move reminder alert to new expiry
+	err = s.SyncDocumentReminder(document)
+	if err != nil {
+		log.Printf("Could not update reminder for document ID %d: %v", document.ID, err)
+	}
+	document, err = s.GetDocument(vehicleId, editedDocument.ID)
+	if err == nil {
+		s.record("edit", "document", document.ID, &vehicleId, nil, currentDocument, document)
+	}
+	return document, err
+}
+
+// SyncDocumentReminder
+// Takes Document as arg, creates, moves or removes its reminder Alert so it fires remind_days ahead of expiry
+func (s *Service) SyncDocumentReminder(document *models.Document) error {
+	// if document no longer expires, remove any existing reminder
+	if document.Expires_at == nil {
+		if document.Alert != nil {
+			err := s.DeleteAlert(*document.Alert, nil)
+			if err != nil {
+				log.Printf("Could not delete document reminder alert ID %d: %v", *document.Alert, err)
+			}
+			return s.repo.Documents.UpdateDocumentAlert(document.ID, nil)
+		}
+		return nil
+	}
+	// build reminder from document
+	alertAt := document.Expires_at.AddDate(0, 0, -int(document.Remind_days))
+	name := strings.ToUpper(document.Type[:1]) + document.Type[1:] + " expiring"
+	if document.Number != nil && len(*document.Number) > 0 {
+		name = name + ": " + *document.Number
+	}
+	description := "Expires on " + document.Expires_at.Format(time.DateOnly)
+	// if reminder exists, move it and mark it unread again
+	if document.Alert != nil {
+		alert, err := s.GetAlert(*document.Alert)
+		if err == nil && alert != nil {
+			unread := 0
+			alert.Name = &name
+			alert.Description = &description
+			alert.Alert_at = &alertAt
+			alert.Is_read = &unread
+			_, err = s.EditAlert(*alert, nil)
+			return err
+		}
+	}
+	// otherwise create a new reminder, attach it to document
+	alert, err := s.CreateAlert(models.NewAlert{
+		Name:        &name,
+		Description: &description,
+		Type:        "reminder",
+		User:        &document.User,
+		Vehicle:     &document.Vehicle,
+		Alert_at:    &alertAt,
+	})
+	if err != nil {
+		return err
+	}
+	document.Alert = &alert.ID
+	return s.repo.Documents.UpdateDocumentAlert(document.ID, &alert.ID)
+}
+
+// GetDocumentAttachment
+// Takes ids as args, passes to db query, returns attachment name, content type and data
+func (s *Service) GetDocumentAttachment(vehicleId int64, documentId int64) (*string, *string, []byte, error) {
+	name, contentType, data, err := s.repo.Documents.GetDocumentAttachment(vehicleId, documentId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if data == nil {
+		return nil, nil, nil, errors.New("Document has no attachment")
+	}
+	return name, contentType, data, nil
+}
+
+// UpdateDocumentAttachment
+// Takes ids, attachment name, content type and data as args, passes to UpdateDocumentAttachment query
+func (s *Service) UpdateDocumentAttachment(vehicleId int64, documentId int64, name *string, contentType *string, data []byte) error {
+	currentDocument, _ := s.GetDocument(vehicleId, documentId)
+	err := s.repo.Documents.UpdateDocumentAttachment(vehicleId, documentId, name, contentType, data)
+	if err == nil {
+		document, _ := s.GetDocument(vehicleId, documentId)
+		s.record("edit", "document", documentId, &vehicleId, nil, currentDocument, document)
+	}
+	return err
+}
+
+// DeleteDocument
+// Takes vehicle id, document id as args, removes its reminder, passes to DeleteDocument query
+func (s *Service) DeleteDocument(vehicleId int64, documentId int64) error {
+	document, err := s.GetDocument(vehicleId, documentId)
+	if err != nil {
+		return err
+	}
+	// delete reminder alert
+	if document.Alert != nil {
+		err = s.DeleteAlert(*document.Alert, nil)
+		if err != nil {
+			log.Printf("Could not delete document reminder alert ID %d: %v", *document.Alert, err)
+		}
+	}
+	err = s.repo.Documents.DeleteDocument(vehicleId, documentId)
+	if err == nil {
+		s.record("delete", "document", documentId, &vehicleId, nil, document, nil)
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+)
+
+// TestDocumentReminder
+// Tests a document gets a reminder ahead of expiry that moves with it, and is trashed and restored with its vehicle
+func TestDocumentReminder(t *testing.T) {
+	s := New(repository.NewMemory())
+	userId := int64(1)
+	vehicle, err := s.CreateVehicle(models.NewVehicle{Name: "Truck", User: &userId})
+	if err != nil {
+		t.Fatalf("Error creating vehicle: %v", err)
+	}
+	expiresAt := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+	document, err := s.CreateDocument(models.NewDocument{Type: "insurance", Expires_at: &expiresAt}, *vehicle)
+	if err != nil {
+		t.Fatalf("Error creating document: %v", err)
+	}
+	if document.Alert == nil {
+		t.Fatalf("Expected reminder alert on expiring document")
+	}
+	alert, err := s.GetAlert(*document.Alert)
+	if err != nil || alert.Alert_at == nil || !alert.Alert_at.Equal(expiresAt.AddDate(0, 0, -30)) {
+		t.Fatalf("Expected reminder 30 days ahead of expiry, got %+v, %v", alert, err)
+	}
+	// moving expiry moves the reminder
+	expiresAt = expiresAt.AddDate(1, 0, 0)
+	document.Expires_at = &expiresAt
+	document, err = s.EditDocument(*document, vehicle.ID)
+	if err != nil {
+		t.Fatalf("Error editing document: %v", err)
+	}
+	alert, err = s.GetAlert(*document.Alert)
+	if err != nil || !alert.Alert_at.Equal(expiresAt.AddDate(0, 0, -30)) {
+		t.Errorf("Expected reminder moved with expiry, got %+v, %v", alert, err)
+	}
+	// document goes to the trash with its vehicle and comes back with it
+	if err = s.DeleteVehicle(vehicle.ID, nil); err != nil {
+		t.Fatalf("Error trashing vehicle: %v", err)
+	}
+	if _, err = s.GetDocument(vehicle.ID, document.ID); err == nil {
+		t.Errorf("Expected document of trashed vehicle to be hidden")
+	}
+	if _, err = s.RestoreVehicle(vehicle.ID, nil); err != nil {
+		t.Fatalf("Error restoring vehicle: %v", err)
+	}
+	if _, err = s.GetDocument(vehicle.ID, document.ID); err != nil {
+		t.Errorf("Error getting document of restored vehicle: %v", err)
+	}
+}
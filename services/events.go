@@ -0,0 +1,187 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// number of recent events kept for subscribers resuming with Last-Event-ID
+const eventBufferSize = 1000
+
+// number of events a subscriber may fall behind by before it is dropped
+const eventSubscriberBuffer = 64
+
+// entities pushed to the live update stream
+var eventEntities = map[string]bool{
+	"job":     true,
+	"task":    true,
+	"alert":   true,
+	"vehicle": true,
+}
+
+// Events
+// Event bus of this process, services publish every change to it
+var Events = NewEventBus(eventBufferSize)
+
+// EventBus
+// In process fan out of change events to subscribers, keeps recent events so reconnecting subscribers can resume
+type EventBus struct {
+	mu          sync.Mutex
+	size        int
+	lastId      int64
+	recent      []models.Event
+	subscribers map[*Subscription]bool
+}
+
+// Subscription
+// Events a subscriber is allowed to see, Events is closed if the subscriber falls behind or unsubscribes
+type Subscription struct {
+	Events <-chan models.Event
+	events chan models.Event
+	allow  func(event models.Event) bool
+	bus    *EventBus
+}
+
+// NewEventBus
+// Takes number of recent events to keep as arg, returns empty EventBus
+func NewEventBus(size int) *EventBus {
+	return &EventBus{size: size, subscribers: map[*Subscription]bool{}}
+}
+
+// Publish
+// Takes Event as arg, numbers it and sends it to every subscriber allowed to see it, subscribers that fell behind are dropped
+func (b *EventBus) Publish(event models.Event) models.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastId++
+	event.ID = b.lastId
+	event.Created_at = time.Now().UTC()
+	b.recent = append(b.recent, event)
+	if len(b.recent) > b.size {
+		b.recent = b.recent[len(b.recent)-b.size:]
+	}
+	for sub := range b.subscribers {
+		if !sub.allow(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// never block publishers, the subscriber can reconnect and resume
+			b.unsubscribe(sub)
+		}
+	}
+	return event
+}
+
+// Subscribe
+// Takes id of the last event the subscriber saw, nil for none, and filter of events it may see as args, returns Subscription, missed events and whether it could resume from the last event seen
+func (b *EventBus) Subscribe(lastEventId *int64, allow func(event models.Event) bool) (*Subscription, []models.Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	events := make(chan models.Event, eventSubscriberBuffer)
+	sub := &Subscription{Events: events, events: events, allow: allow, bus: b}
+	b.subscribers[sub] = true
+	missed := make([]models.Event, 0)
+	if lastEventId == nil {
+		return sub, missed, true
+	}
+	// ids restart with the process, and only recent events are kept
+	oldestId := b.lastId + 1
+	if len(b.recent) > 0 {
+		oldestId = b.recent[0].ID
+	}
+	if *lastEventId < oldestId-1 || *lastEventId > b.lastId {
+		return sub, missed, false
+	}
+	for _, event := range b.recent {
+		if event.ID > *lastEventId && allow(event) {
+			missed = append(missed, event)
+		}
+	}
+	return sub, missed, true
+}
+
+// Close
+// Unsubscribes from the EventBus, closing Events
+func (sub *Subscription) Close() {
+	sub.bus.mu.Lock()
+	defer sub.bus.mu.Unlock()
+	sub.bus.unsubscribe(sub)
+}
+
+// unsubscribe callers hold the lock
+func (b *EventBus) unsubscribe(sub *Subscription) {
+	if b.subscribers[sub] {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// SubscribeEvents
+// Takes requesting user id, whether they are admin and id of the last event they saw as args, subscribes them to events of entities they own, or every entity if admin
+func (s *Service) SubscribeEvents(userId int64, isAdmin bool, lastEventId *int64) (*Subscription, []models.Event, bool) {
+	return Events.Subscribe(lastEventId, func(event models.Event) bool {
+		return isAdmin || (event.User != nil && *event.User == userId)
+	})
+}
+
+// publish
+// Takes audit action, entity, its id, vehicle and job it belongs to, its state before and after and diff of the change, publishes an Event if the entity is streamed
+func (s *Service) publish(action string, entity string, entityId int64, vehicleId *int64, jobId *int64, before any, after any, diff map[string]models.AuditChange) {
+	if !eventEntities[entity] {
+		return
+	}
+	eventType := "update"
+	switch action {
+	case "create", "restore":
+		eventType = "create"
+	case "delete":
+		eventType = "delete"
+	}
+	Events.Publish(models.Event{
+		Type:      eventType,
+		Action:    action,
+		Entity:    entity,
+		Entity_id: entityId,
+		User:      s.eventOwner(vehicleId, jobId, before, after),
+		Vehicle:   vehicleId,
+		Job:       jobId,
+		Diff:      diff,
+		Actor:     s.actor,
+	})
+}
+
+// eventOwner
+// Returns id of the user owning a changed entity, from its own state if it has a user, otherwise from the job or vehicle it belongs to
+func (s *Service) eventOwner(vehicleId *int64, jobId *int64, before any, after any) *int64 {
+	for _, state := range []any{after, before} {
+		switch entity := state.(type) {
+		case *models.Job:
+			if entity != nil && entity.User != 0 {
+				return &entity.User
+			}
+		case *models.Vehicle:
+			if entity != nil {
+				return &entity.User
+			}
+		case *models.Alert:
+			if entity != nil {
+				return &entity.User
+			}
+		}
+	}
+	if jobId != nil {
+		if job, err := s.repo.Jobs.GetJob(*jobId); err == nil {
+			return &job.User
+		}
+	}
+	if vehicleId != nil {
+		if vehicle, err := s.repo.Vehicles.GetVehicle(*vehicleId); err == nil {
+			return &vehicle.User
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// TestEventBus
+// Tests events reach subscribers allowed to see them, missed events are replayed after Last-Event-ID and slow subscribers are dropped
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus(2)
+	owner, other := int64(1), int64(2)
+	allowOwner := func(event models.Event) bool { return event.User != nil && *event.User == owner }
+	sub, missed, resumed := bus.Subscribe(nil, allowOwner)
+	if len(missed) != 0 || !resumed {
+		t.Fatalf("Expected new subscription without missed events, got %d and %v", len(missed), resumed)
+	}
+	bus.Publish(models.Event{Type: "create", Entity: "job", Entity_id: 1, User: &other})
+	first := bus.Publish(models.Event{Type: "create", Entity: "job", Entity_id: 2, User: &owner})
+	if event := <-sub.Events; event.ID != first.ID || event.Entity_id != 2 {
+		t.Errorf("Expected only event of owned job, got %+v", event)
+	}
+	sub.Close()
+	if _, ok := <-sub.Events; ok {
+		t.Errorf("Expected events to be closed after unsubscribing")
+	}
+	// resume replays what the subscriber may see after the last event seen
+	second := bus.Publish(models.Event{Type: "update", Entity: "job", Entity_id: 2, User: &owner})
+	sub, missed, resumed = bus.Subscribe(&first.ID, allowOwner)
+	if !resumed || len(missed) != 1 || missed[0].ID != second.ID {
+		t.Errorf("Expected event %d to be replayed, got %v and %v", second.ID, missed, resumed)
+	}
+	sub.Close()
+	// only the last 2 events are kept, and ids from before a restart can not be resumed
+	bus.Publish(models.Event{Type: "delete", Entity: "job", Entity_id: 2, User: &owner})
+	for _, lastId := range []int64{first.ID - 1, second.ID + 10} {
+		sub, _, resumed = bus.Subscribe(&lastId, allowOwner)
+		if resumed {
+			t.Errorf("Expected resume after event %d to fail", lastId)
+		}
+		sub.Close()
+	}
+	// subscribers that fall behind are dropped instead of blocking publishers
+	sub, _, _ = bus.Subscribe(nil, allowOwner)
+	for i := 0; i <= eventSubscriberBuffer; i++ {
+		bus.Publish(models.Event{Type: "update", Entity: "job", Entity_id: 2, User: &owner})
+	}
+	count := 0
+	for range sub.Events {
+		count++
+	}
+	if count != eventSubscriberBuffer {
+		t.Errorf("Expected %d buffered events before being dropped, got %d", eventSubscriberBuffer, count)
+	}
+	sub.Close()
+}
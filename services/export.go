@@ -0,0 +1,269 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/validate"
+)
+
+// current account export format version
+const AccountExportVersion = 1
+
+// ExportAccount
+// Takes User as arg, collects their vehicles, labels, jobs, tasks and alerts, returns AccountExport
+func (s *Service) ExportAccount(user models.User) (*models.AccountExport, error) {
+	userIdStr := strconv.FormatInt(user.ID, 10)
+	export := models.AccountExport{
+		Version:     AccountExportVersion,
+		Exported_at: time.Now().UTC(),
+		Username:    user.Username,
+		Vehicles:    make([]models.Vehicle, 0),
+		Labels:      make([]models.Label, 0),
+		Jobs:        make([]models.Job, 0),
+		Tasks:       make([]models.Task, 0),
+		Alerts:      make([]models.Alert, 0),
+	}
+	labels, err := s.ListLabels(&userIdStr, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	labelIds := make(map[int64]bool)
+	for _, label := range labels {
+		labelIds[label.ID] = true
+		export.Labels = append(export.Labels, *label)
+	}
+	// include shared labels used by users jobs, tasks and vehicles so relationships survive
+	addLabels := func(labels []models.Label) {
+		for _, label := range labels {
+			if !labelIds[label.ID] {
+				labelIds[label.ID] = true
+				export.Labels = append(export.Labels, label)
+			}
+		}
+	}
+	vehicles, err := s.ListVehicles(&userIdStr, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, vehicle := range vehicles {
+		export.Vehicles = append(export.Vehicles, *vehicle)
+		addLabels(vehicle.Labels)
+	}
+	jobs, err := s.ListJobs(&userIdStr, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		export.Jobs = append(export.Jobs, *job)
+		addLabels(job.Labels)
+		tasks, err := s.ListTasks(job.ID, nil, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			export.Tasks = append(export.Tasks, *task)
+			addLabels(task.Labels)
+		}
+	}
+	alerts, err := s.ListAlerts(&userIdStr, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, alert := range alerts {
+		export.Alerts = append(export.Alerts, *alert)
+	}
+	return &export, nil
+}
+
+// ValidateAccountExport
+// Takes AccountExport as arg, checks version, validates each record and that every relationship points at a record within the export
+func ValidateAccountExport(export models.AccountExport) error {
+	if export.Version != AccountExportVersion {
+		return fmt.Errorf("Unsupported export version %d, expected %d", export.Version, AccountExportVersion)
+	}
+	err := validate.Struct(export)
+	if err != nil {
+		return err
+	}
+	vehicleIds := make(map[int64]bool)
+	for _, vehicle := range export.Vehicles {
+		vehicleIds[vehicle.ID] = true
+	}
+	labelIds := make(map[int64]bool)
+	for _, label := range export.Labels {
+		if len(strings.TrimSpace(label.Name)) == 0 {
+			return fmt.Errorf("Label ID %d is missing a name", label.ID)
+		}
+		labelIds[label.ID] = true
+	}
+	for _, vehicle := range export.Vehicles {
+		for _, label := range vehicle.Labels {
+			if !labelIds[label.ID] {
+				return fmt.Errorf("Vehicle ID %d refers to missing label ID %d", vehicle.ID, label.ID)
+			}
+		}
+	}
+	jobIds := make(map[int64]bool)
+	for _, job := range export.Jobs {
+		jobIds[job.ID] = true
+	}
+	for _, job := range export.Jobs {
+		if job.Vehicle != nil && !vehicleIds[*job.Vehicle] {
+			return fmt.Errorf("Job ID %d refers to missing vehicle ID %d", job.ID, *job.Vehicle)
+		}
+		if len(job.Status) > 0 && !ValidJobStatus(job.Status) {
+			return fmt.Errorf("Job ID %d has unknown status %v", job.ID, job.Status)
+		}
+		for _, label := range job.Labels {
+			if !labelIds[label.ID] {
+				return fmt.Errorf("Job ID %d refers to missing label ID %d", job.ID, label.ID)
+			}
+		}
+	}
+	taskIds := make(map[int64]bool)
+	for _, task := range export.Tasks {
+		if task.Job == nil || !jobIds[*task.Job] {
+			return fmt.Errorf("Task ID %d refers to a missing job", task.ID)
+		}
+		for _, label := range task.Labels {
+			if !labelIds[label.ID] {
+				return fmt.Errorf("Task ID %d refers to missing label ID %d", task.ID, label.ID)
+			}
+		}
+		taskIds[task.ID] = true
+	}
+	for _, alert := range export.Alerts {
+		if alert.Vehicle != nil && !vehicleIds[*alert.Vehicle] {
+			return fmt.Errorf("Alert ID %d refers to missing vehicle ID %d", alert.ID, *alert.Vehicle)
+		}
+		if alert.Job != nil && !jobIds[*alert.Job] {
+			return fmt.Errorf("Alert ID %d refers to missing job ID %d", alert.ID, *alert.Job)
+		}
+		if alert.Task != nil && !taskIds[*alert.Task] {
+			return fmt.Errorf("Alert ID %d refers to missing task ID %d", alert.ID, *alert.Task)
+		}
+	}
+	return nil
+}
+
+// ImportAccount
+// Takes AccountExport, target User, label conflict strategy (merge or rename) and dry run flag as args, recreates the data under the user with new ids, returns ImportResult
+func (s *Service) ImportAccount(export models.AccountExport, user models.User, labelConflicts string, dryRun bool) (*models.ImportResult, error) {
+	err := ValidateAccountExport(export)
+	if err != nil {
+		return nil, err
+	}
+	result := models.ImportResult{
+		Dry_run:   dryRun,
+		Created:   map[string]int{"vehicles": 0, "labels": 0, "jobs": 0, "tasks": 0, "alerts": 0},
+		Conflicts: make([]models.ImportConflict, 0),
+	}
+	// labels, resolving name conflicts against the users existing labels
+	userIdStr := strconv.FormatInt(user.ID, 10)
+	existingLabels, err := s.ListLabels(&userIdStr, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	labelNames := make(map[string]int64)
+	for _, label := range existingLabels {
+		labelNames[strings.ToLower(label.Name)] = label.ID
+	}
+	mergedLabels := make(map[int64]int64)
+	newLabels := make([]models.Label, 0, len(export.Labels))
+	for _, label := range export.Labels {
+		name := label.Name
+		if existingId, ok := labelNames[strings.ToLower(name)]; ok {
+			conflict := models.ImportConflict{Type: "label", Name: name, Existing: existingId}
+			if labelConflicts != "rename" {
+				conflict.Resolution = "merged"
+				result.Conflicts = append(result.Conflicts, conflict)
+				mergedLabels[label.ID] = existingId
+				continue
+			}
+			// find a free name
+			name = label.Name + " (imported)"
+			for i := 2; labelNames[strings.ToLower(name)] != 0; i++ {
+				name = fmt.Sprintf("%v (imported %d)", label.Name, i)
+			}
+			conflict.Resolution = "renamed to " + name
+			result.Conflicts = append(result.Conflicts, conflict)
+		}
+		// taken by a label of this import, its id is not known until created
+		labelNames[strings.ToLower(name)] = -1
+		label.Name = name
+		newLabels = append(newLabels, label)
+	}
+	result.Created["labels"] = len(newLabels)
+	result.Created["vehicles"] = len(export.Vehicles)
+	result.Created["jobs"] = len(export.Jobs)
+	result.Created["tasks"] = len(export.Tasks)
+	result.Created["alerts"] = len(export.Alerts)
+	if dryRun {
+		return &result, nil
+	}
+	// set default values
+	defaultBool := 0
+	vehicles := append([]models.Vehicle{}, export.Vehicles...)
+	for i := range vehicles {
+		if vehicles[i].Is_metric == nil {
+			vehicles[i].Is_metric = &defaultBool
+		}
+	}
+	// jobs in id order, so origin jobs exist before jobs created from them
+	jobs := append([]models.Job{}, export.Jobs...)
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	export.Labels, export.Vehicles, export.Jobs = newLabels, vehicles, jobs
+	// create everything together, nothing is created if any of it fails
+	ids, err := s.repo.Users.ImportAccount(models.AccountImport{User: user.ID, Export: export, Merged_labels: mergedLabels})
+	if err != nil {
+		return nil, err
+	}
+	s.recordImport(*ids)
+	return &result, nil
+}
+
+// recordImport
+// Takes ImportedIds as arg, records each imported vehicle, label, job, task and alert being created
+func (s *Service) recordImport(ids models.ImportedIds) {
+	for _, labelId := range importedIds(ids.Labels) {
+		if label, err := s.GetLabel(labelId); err == nil {
+			s.record("create", "label", label.ID, nil, nil, nil, label)
+		}
+	}
+	for _, vehicleId := range importedIds(ids.Vehicles) {
+		if vehicle, err := s.GetVehicle(vehicleId); err == nil {
+			s.record("create", "vehicle", vehicle.ID, &vehicle.ID, nil, nil, vehicle)
+		}
+	}
+	for _, jobId := range importedIds(ids.Jobs) {
+		if job, err := s.GetJob(jobId); err == nil {
+			s.recordJob("create", job, nil, job)
+		}
+	}
+	for _, taskId := range importedIds(ids.Tasks) {
+		if task, err := s.GetTaskById(taskId); err == nil && task.Job != nil {
+			s.recordTask("create", *task.Job, task.ID, nil, task)
+		}
+	}
+	for _, alertId := range importedIds(ids.Alerts) {
+		if alert, err := s.GetAlert(alertId); err == nil {
+			s.recordAlert("create", alert, nil, alert)
+		}
+	}
+}
+
+// importedIds
+// Takes map of exported ids to imported ids as arg, returns the imported ids in the order they were created
+func importedIds(ids map[int64]int64) []int64 {
+	sorted := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListFuelLogs
+// Takes vehicle id and optional Page as args, passes to ListFuelLogs query, returns FuelLog list newest first
+func (s *Service) ListFuelLogs(vehicleId int64, page *models.Page) ([]*models.FuelLog, error) {
+	fuelLogs, err := s.repo.Fuel.ListFuelLogs(vehicleId, page)
+	return fuelLogs, err
+}
+
+// forwardOdometer
+// Takes vehicle and fuel logs as args, returns highest odometer of the fuel logs if it is past the vehicles, nil otherwise, as fuel logs may be backdated
+func forwardOdometer(vehicle models.Vehicle, fuelLogs []models.NewFuelLog) *int64 {
+	var odometer *int64
+	for _, fuelLog := range fuelLogs {
+		if fuelLog.Odometer != nil && (odometer == nil || *fuelLog.Odometer > *odometer) {
+			odometer = fuelLog.Odometer
+		}
+	}
+	if odometer == nil || (vehicle.Odometer != nil && *odometer <= *vehicle.Odometer) {
+		return nil
+	}
+	return odometer
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/okdv/wrench-turn/importers"
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ImportTracker
+// Takes another trackers csv export, format (or auto), options, owner, optional target vehicle and preview flag as args, creates completed jobs for service records and fuel logs for fuel records with their odometer readings in one transaction unless previewing or any row is invalid, returns TrackerImportResult
+func (s *Service) ImportTracker(r io.Reader, format string, opts importers.Options, userId int64, vehicle *models.Vehicle, preview bool) (*models.TrackerImportResult, error) {
+	adapter, records, rowErrors, err := importers.Parse(r, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	result := models.TrackerImportResult{
+		Format:  adapter.Name(),
+		Preview: preview,
+		Rows:    len(records) + len(rowErrors),
+		Records: records,
+		Errors:  rowErrors,
+	}
+	// per vehicle exports need a target vehicle
+	if vehicle == nil {
+		for _, record := range records {
+			if len(record.Vehicle) == 0 {
+				return nil, errors.New("This export has no vehicle column, choose a vehicle with ?vehicle=")
+			}
+		}
+	}
+	// match vehicles by name, case insensitive, creating any that are missing
+	userIdStr := strconv.FormatInt(userId, 10)
+	vehicles, err := s.ListVehicles(&userIdStr, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	vehicleIds := make(map[string]int64)
+	for _, v := range vehicles {
+		vehicleIds[strings.ToLower(v.Name)] = v.ID
+	}
+	trackerImport := models.TrackerImport{User: userId, Vehicles: make([]models.NewVehicle, 0), Records: make([]models.TrackerImportRecord, 0, len(records))}
+	newVehicles := make(map[string]int)
+	defaultBool := 0
+	for _, record := range records {
+		importRecord := models.TrackerImportRecord{Record: record}
+		if vehicle != nil {
+			importRecord.Vehicle = vehicle.ID
+		} else if vehicleId, ok := vehicleIds[strings.ToLower(record.Vehicle)]; ok {
+			importRecord.Vehicle = vehicleId
+		} else {
+			index, ok := newVehicles[strings.ToLower(record.Vehicle)]
+			if !ok {
+				index = len(trackerImport.Vehicles)
+				newVehicles[strings.ToLower(record.Vehicle)] = index
+				trackerImport.Vehicles = append(trackerImport.Vehicles, models.NewVehicle{Name: record.Vehicle, Is_metric: &defaultBool, User: &userId})
+			}
+			importRecord.New_vehicle = index
+		}
+		// fuel records keep what was paid and filled
+		if record.Kind == "fuel" {
+			filledAt := record.Date
+			importRecord.Fuel_log = &models.NewFuelLog{Filled_at: &filledAt, Odometer: record.Odometer, Cost: record.Cost, Station: record.Shop, Notes: record.Notes}
+			if record.Fuel_volume != nil {
+				importRecord.Fuel_log.Volume = *record.Fuel_volume
+			}
+		}
+		trackerImport.Records = append(trackerImport.Records, importRecord)
+		// count what will be created
+		if record.Kind == "service" {
+			result.Jobs++
+			result.Tasks += len(record.Tasks)
+		} else {
+			result.Fuel_logs++
+			if record.Cost != nil {
+				result.Fuel_cost += *record.Cost
+			}
+		}
+		if record.Odometer != nil {
+			result.Odometer_readings++
+		}
+	}
+	result.Vehicles_created = len(trackerImport.Vehicles)
+	if preview || len(rowErrors) > 0 {
+		return &result, nil
+	}
+	// create everything together, nothing is created if any of it fails
+	ids, err := s.repo.Vehicles.ImportTracker(trackerImport)
+	if err != nil {
+		return nil, err
+	}
+	s.recordTrackerImport(trackerImport, *ids)
+	return &result, nil
+}
+
+// recordTrackerImport
+// Takes TrackerImport and the TrackerImportedIds it was created with as args, records each imported vehicle, job and fuel log being created
+func (s *Service) recordTrackerImport(trackerImport models.TrackerImport, ids models.TrackerImportedIds) {
+	for _, vehicleId := range ids.Vehicles {
+		if vehicle, err := s.GetVehicle(vehicleId); err == nil {
+			s.record("create", "vehicle", vehicle.ID, &vehicle.ID, nil, nil, vehicle)
+		}
+	}
+	for _, jobId := range ids.Jobs {
+		if job, err := s.GetJob(jobId); err == nil {
+			s.recordJob("create", job, nil, job)
+		}
+	}
+	// fuel logs are created in the order of their records
+	fuelLogs := ids.Fuel_logs
+	for _, importRecord := range trackerImport.Records {
+		if importRecord.Fuel_log == nil || len(fuelLogs) == 0 {
+			continue
+		}
+		vehicleId := importRecord.Vehicle
+		if vehicleId == 0 {
+			vehicleId = ids.Vehicles[importRecord.New_vehicle]
+		}
+		s.record("create", "fuel_log", fuelLogs[0], &vehicleId, nil, nil, importRecord.Fuel_log)
+		fuelLogs = fuelLogs[1:]
+	}
+}
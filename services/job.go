@@ -2,23 +2,52 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"log"
-	"strconv"
+	"time"
 
-	"github.com/okdv/wrench-turn/db"
 	"github.com/okdv/wrench-turn/models"
 )
 
+// jobStatusTransitions
+// Job status state machine, maps each status to the statuses it may move to
+var jobStatusTransitions = map[string][]string{
+	"planned":       {"parts_ordered", "in_progress", "blocked", "done", "skipped"},
+	"parts_ordered": {"planned", "in_progress", "blocked", "done", "skipped"},
+	"in_progress":   {"parts_ordered", "blocked", "done", "skipped"},
+	"blocked":       {"planned", "parts_ordered", "in_progress", "skipped"},
+	"done":          {"in_progress"},
+	"skipped":       {"planned"},
+}
+
+// ValidJobStatus
+// Takes status as arg, returns whether it is a known job status
+func ValidJobStatus(status string) bool {
+	_, ok := jobStatusTransitions[status]
+	return ok
+}
+
+// CanTransitionJobStatus
+// Takes current and new status as args, returns whether the state machine allows the change
+func CanTransitionJobStatus(from string, to string) bool {
+	for _, allowed := range jobStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
 // GetJob
 // Takes id as arg, passes to db query, returns Job
-func GetJob(jobId int64) (*models.Job, error) {
-	job, err := db.GetJob(jobId)
+func (s *Service) GetJob(jobId int64) (*models.Job, error) {
+	job, err := s.repo.Jobs.GetJob(jobId)
 	return job, err
 }
 
 // CreateJob
 // Takes newJob as arg, passes to db query, calls GetJob, returns Job
-func CreateJob(newJob models.NewJob) (*models.Job, error) {
+func (s *Service) CreateJob(newJob models.NewJob) (*models.Job, error) {
 	// set default values
 	defaultBool := 0
 	if newJob.Is_template == nil {
@@ -27,101 +56,169 @@ func CreateJob(newJob models.NewJob) (*models.Job, error) {
 	if newJob.Repeats == nil {
 		newJob.Repeats = &defaultBool
 	}
+	// new jobs always start as planned, use UpdateJobStatus to move them along
+	defaultStatus := "planned"
+	newJob.Status = &defaultStatus
 	// pass to db query, return new Jobs id
-	jobId, err := db.CreateJob(newJob)
+	jobId, err := s.repo.Jobs.CreateJob(newJob)
 	if err != nil || jobId == nil {
 		err = errors.Join(err, errors.New("No ID of new Job found"))
 		return nil, err
 	}
+	// record initial status
+	_, err = s.repo.Jobs.CreateJobStatusHistory(*jobId, nil, defaultStatus, newJob.User, nil)
+	if err != nil {
+		log.Printf("Could not record status history for job ID %d: %v", *jobId, err)
+	}
 	// pass to GetJob, return Job
-	job, err := GetJob(*jobId)
+	job, err := s.GetJob(*jobId)
+	if err == nil {
+		s.recordJob("create", job, nil, job)
+	}
 	return job, err
 }
 
 // EditJob
-// Takes User as arg, passes to EditJob query, returns updated User
-func EditJob(editedJob models.Job) (*models.Job, error) {
-	err := db.EditJob(editedJob)
+// Takes Job, acting user id and the updated_at the job was read at, nil to skip the check, keeps status and is_complete in sync, passes to EditJob query, returns updated Job
+func (s *Service) EditJob(editedJob models.Job, userId int64, revision *time.Time) (*models.Job, error) {
+	currentJob, err := s.GetJob(editedJob.ID)
+	if err != nil {
+		return nil, err
+	}
+	// work out new status, an explicit status wins over is_complete
+	if len(editedJob.Status) == 0 {
+		editedJob.Status = currentJob.Status
+	}
+	if editedJob.Status == currentJob.Status && editedJob.Is_complete != currentJob.Is_complete {
+		// only is_complete was changed, derive status from it
+		if editedJob.Is_complete == 1 {
+			editedJob.Status = "done"
+		} else {
+			editedJob.Status = "in_progress"
+		}
+	}
+	// validate status change against state machine
+	if editedJob.Status != currentJob.Status && !CanTransitionJobStatus(currentJob.Status, editedJob.Status) {
+		return nil, fmt.Errorf("Job status cannot change from %v to %v", currentJob.Status, editedJob.Status)
+	}
+	// a job is complete only when done
+	editedJob.Is_complete = 0
+	if editedJob.Status == "done" {
+		editedJob.Is_complete = 1
+	}
+	err = s.repo.Jobs.EditJob(editedJob, revision)
 	if err != nil {
 		return nil, err
 	}
-	job, err := GetJob(editedJob.ID)
+	// record status change
+	if editedJob.Status != currentJob.Status {
+		_, err = s.repo.Jobs.CreateJobStatusHistory(editedJob.ID, &currentJob.Status, editedJob.Status, &userId, nil)
+		if err != nil {
+			log.Printf("Could not record status history for job ID %d: %v", editedJob.ID, err)
+		}
+	}
+	job, err := s.GetJob(editedJob.ID)
+	if err == nil {
+		s.recordJob("edit", job, currentJob, job)
+	}
 	return job, err
 }
 
-// ListJobs
-// Takes URL query params as args, passes to ListJobs query, returns Job list
-func ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete *string, labelId *string, searchStr *string, sort *string) ([]*models.Job, error) {
-	users, err := db.ListJobs(userId, vehicleId, isTemplate, isComplete, labelId, searchStr, sort)
-	return users, err
-}
-
-// DeleteJob
-// Takes job id as arg, passes to DeleteJob query
-func DeleteJob(jobId int64, userId *int64) error {
-	// init values
-	// TODO make some more consistency on data types at different app layers, i was pretty sloppy with this...
-	jobIdStr := strconv.FormatInt(jobId, 10)
-	// get jobs tasks
-	tasks, err := ListTasks(jobId, nil, nil, nil)
+// UpdateJobStatus
+// Takes job id, new status, acting user id and optional note as args, validates the change, updates job and records it in status history
+func (s *Service) UpdateJobStatus(jobId int64, status string, userId int64, note *string) (*models.Job, error) {
+	if !ValidJobStatus(status) {
+		return nil, fmt.Errorf("Unknown job status %v", status)
+	}
+	currentJob, err := s.GetJob(jobId)
 	if err != nil {
-		log.Printf("Could not get jobs tasks: %v", err)
+		return nil, err
 	}
-	// if there are tasks, delete them
-	if len(tasks) > 0 {
-		// delete jobs tasks
-		err = DeleteTask(jobId, nil)
-		if err != nil {
-			log.Printf("Could not delete jobs tasks: %v", err)
-		}
+	// validate status change against state machine
+	if !CanTransitionJobStatus(currentJob.Status, status) {
+		return nil, fmt.Errorf("Job status cannot change from %v to %v", currentJob.Status, status)
 	}
-	// get jobs alerts
-	alerts, err := ListAlerts(nil, nil, &jobIdStr, nil, nil, nil, nil, nil, nil)
+	// a job is complete only when done, sets completed_at
+	isComplete := 0
+	if status == "done" {
+		isComplete = 1
+	}
+	err = s.repo.Jobs.UpdateJobStatus(jobId, status, isComplete)
 	if err != nil {
-		log.Printf("Could not get jobs alerts: %v", err)
-	}
-	// if there are alerts, delete them
-	if len(alerts) > 0 {
-		// loop through alerts
-		for i := 0; i < len(alerts); i++ {
-			err = DeleteAlert(alerts[i].ID, userId)
-			if err != nil {
-				log.Printf("Could not delete job alert ID %d: %v", alerts[i].ID, err)
-			}
-		}
+		return nil, err
 	}
-	// get jobs labels
-	labels, err := ListLabels(nil, &jobIdStr, nil, nil)
+	// record status change
+	_, err = s.repo.Jobs.CreateJobStatusHistory(jobId, &currentJob.Status, status, &userId, note)
 	if err != nil {
-		log.Printf("Could not get jobs labels: %v", err)
-	}
-	// if there are labels, delete them
-	if len(labels) > 0 {
-		// loop through labels
-		for i := 0; i < len(labels); i++ {
-			_, err = AssignJobLabel(jobId, labels[i].ID, 0)
-			if err != nil {
-				log.Printf("Could not remove job label ID %d: %v", labels[i].ID, err)
-			}
-		}
+		log.Printf("Could not record status history for job ID %d: %v", jobId, err)
+	}
+	job, err := s.GetJob(jobId)
+	if err == nil {
+		s.recordJob("edit", job, currentJob, job)
 	}
-	// delete job
-	err = db.DeleteJob(jobId, userId)
+	return job, err
+}
+
+// ListJobStatusHistory
+// Takes job id as arg, passes to ListJobStatusHistory query, returns status history
+func (s *Service) ListJobStatusHistory(jobId int64) ([]*models.JobStatusHistory, error) {
+	history, err := s.repo.Jobs.ListJobStatusHistory(jobId)
+	return history, err
+}
+
+// ListJobs
+// Takes URL query params and optional Page as args, passes to ListJobs query, returns Job list
+func (s *Service) ListJobs(userId *string, vehicleId *string, isTemplate *string, isComplete *string, status *string, labelId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Job, error) {
+	users, err := s.repo.Jobs.ListJobs(userId, vehicleId, isTemplate, isComplete, status, labelId, searchStr, sort, page)
+	return users, err
+}
+
+// DeleteJob
+// Takes job id as arg, passes to TrashJob query, moving the job with its tasks and alerts to the trash
+func (s *Service) DeleteJob(jobId int64, userId *int64) error {
+	// keep job for audit log
+	job, err := s.GetJob(jobId)
+	if err != nil {
+		job = &models.Job{ID: jobId}
+	}
+	err = s.repo.Trash.TrashJob(jobId, userId)
 	if err != nil {
 		return err
 	}
+	s.recordJob("delete", job, job, nil)
 	return nil
 }
 
 // AssignJobLabel
 // Takes job id, label id, creates an entry into job_label if assigning, otherwise deletes any existing entry
-func AssignJobLabel(jobId int64, taskId int64, assign int) (*int64, error) {
+func (s *Service) AssignJobLabel(jobId int64, taskId int64, assign int) (*int64, error) {
 	// if assigning, call that query and return
 	if assign == 1 {
-		relationshipId, err := db.AssignJobLabel(jobId, taskId)
+		relationshipId, err := s.repo.Jobs.AssignJobLabel(jobId, taskId)
+		if err == nil {
+			s.recordJobLabel("assign", jobId, taskId)
+		}
 		return relationshipId, err
 	}
 	// otherwise call unassign query
-	err := db.UnassignJobLabel(jobId, taskId)
+	err := s.repo.Jobs.UnassignJobLabel(jobId, taskId)
+	if err == nil {
+		s.recordJobLabel("unassign", jobId, taskId)
+	}
 	return nil, err
 }
+
+// recordJobLabel
+// Records a label being assigned to or unassigned from a job, as a change of the jobs label field
+func (s *Service) recordJobLabel(action string, jobId int64, labelId int64) {
+	job := &models.Job{ID: jobId}
+	if current, err := s.repo.Jobs.GetJob(jobId); err == nil {
+		job = current
+	}
+	change := map[string]any{"label": labelId}
+	if action == "assign" {
+		s.recordJob(action, job, nil, change)
+	} else {
+		s.recordJob(action, job, change, nil)
+	}
+}
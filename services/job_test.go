@@ -0,0 +1,386 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+)
+
+// newTestJob
+// Creates a Service over an empty in memory repository and a job owned by user 1
+func newTestJob(t *testing.T) (*Service, *models.Job) {
+	t.Helper()
+	s := New(repository.NewMemory())
+	userId := int64(1)
+	job, err := s.CreateJob(models.NewJob{Name: "Oil change", User: &userId})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	return s, job
+}
+
+// TestEditJobStatus
+// Tests is_complete and status are kept in sync by EditJob and every change is recorded in status history
+func TestEditJobStatus(t *testing.T) {
+	s, job := newTestJob(t)
+	if job.Status != "planned" || job.Is_complete != 0 {
+		t.Fatalf("Expected new job to be planned and incomplete, got %v and %d", job.Status, job.Is_complete)
+	}
+	// start the job
+	job.Status = "in_progress"
+	job, err := s.EditJob(*job, 1, nil)
+	if err != nil {
+		t.Fatalf("Error starting job: %v", err)
+	}
+	// setting only is_complete derives done
+	job.Is_complete = 1
+	job, err = s.EditJob(*job, 1, nil)
+	if err != nil {
+		t.Fatalf("Error completing job: %v", err)
+	}
+	if job.Status != "done" || job.Completed_at == nil {
+		t.Errorf("Expected job to be done with completed_at set, got %v and %v", job.Status, job.Completed_at)
+	}
+	// planned cannot follow done
+	job.Status = "planned"
+	if _, err = s.EditJob(*job, 1, nil); err == nil {
+		t.Errorf("Expected error moving job from done to planned")
+	}
+	// an edit expecting an older revision changes nothing
+	stale := job.Updated_at.Add(-time.Second)
+	job.Status, job.Name = "done", "stale"
+	if _, err = s.EditJob(*job, 1, &stale); !errors.Is(err, repository.ErrRevisionChanged) {
+		t.Errorf("Expected ErrRevisionChanged, got %v", err)
+	}
+	history, err := s.ListJobStatusHistory(job.ID)
+	if err != nil {
+		t.Fatalf("Error listing status history: %v", err)
+	}
+	expected := []string{"planned", "in_progress", "done"}
+	if len(history) != len(expected) {
+		t.Fatalf("Expected %d status history entries, got %d", len(expected), len(history))
+	}
+	for i, entry := range history {
+		if entry.To_status != expected[i] {
+			t.Errorf("Expected status history entry %d to be %v, got %v", i, expected[i], entry.To_status)
+		}
+	}
+}
+
+// TestDeleteJobCascade
+// Tests DeleteJob trashes the jobs tasks and alerts, RestoreJob brings them back and PurgeTrash removes its status history
+func TestDeleteJobCascade(t *testing.T) {
+	s, job := newTestJob(t)
+	userId := int64(1)
+	if _, err := s.CreateTask(models.NewTask{Name: "Drain oil"}, job.ID); err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	alertName := "Change oil"
+	if _, err := s.CreateAlert(models.NewAlert{Name: &alertName, Type: "notification", User: &userId, Job: &job.ID}); err != nil {
+		t.Fatalf("Error creating alert: %v", err)
+	}
+	label, err := s.CreateLabel(models.NewLabel{Name: "Engine", User: &userId})
+	if err != nil {
+		t.Fatalf("Error creating label: %v", err)
+	}
+	if _, err = s.AssignJobLabel(job.ID, label.ID, 1); err != nil {
+		t.Fatalf("Error assigning label: %v", err)
+	}
+	if err = s.DeleteJob(job.ID, &userId); err != nil {
+		t.Fatalf("Error deleting job: %v", err)
+	}
+	if _, err = s.GetJob(job.ID); err == nil {
+		t.Errorf("Expected job to be deleted")
+	}
+	tasks, _ := s.ListTasks(job.ID, nil, nil, nil, nil)
+	alerts, _ := s.ListAlerts(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if len(tasks) != 0 || len(alerts) != 0 {
+		t.Errorf("Expected no tasks or alerts left, got %d and %d", len(tasks), len(alerts))
+	}
+	// the label itself is kept
+	if _, err = s.GetLabel(label.ID); err != nil {
+		t.Errorf("Expected label to survive job deletion: %v", err)
+	}
+	// restoring brings back the job with its tasks, alerts and labels
+	trash, _ := s.ListTrash(nil)
+	if len(trash) != 1 || trash[0].Type != "job" || trash[0].ID != job.ID {
+		t.Fatalf("Expected job %d in trash, got %v", job.ID, trash)
+	}
+	if job, err = s.RestoreJob(job.ID, &userId); err != nil {
+		t.Fatalf("Error restoring job: %v", err)
+	}
+	tasks, _ = s.ListTasks(job.ID, nil, nil, nil, nil)
+	alerts, _ = s.ListAlerts(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if len(tasks) != 1 || len(alerts) != 1 || len(job.Labels) != 1 {
+		t.Errorf("Expected task, alert and label to be restored, got %d, %d and %d", len(tasks), len(alerts), len(job.Labels))
+	}
+	// purging removes the status history too
+	if err = s.DeleteJob(job.ID, &userId); err != nil {
+		t.Fatalf("Error deleting job: %v", err)
+	}
+	if purged, err := s.PurgeTrash(-1); err != nil || purged != 1 {
+		t.Fatalf("Expected 1 job purged, got %d: %v", purged, err)
+	}
+	history, _ := s.ListJobStatusHistory(job.ID)
+	trash, _ = s.ListTrash(nil)
+	if len(history) != 0 || len(trash) != 0 {
+		t.Errorf("Expected no status history or trash left, got %d and %d", len(history), len(trash))
+	}
+}
+
+// TestAssignJobLabel
+// Tests labels are added to and removed from a job
+func TestAssignJobLabel(t *testing.T) {
+	s, job := newTestJob(t)
+	userId := int64(1)
+	label, err := s.CreateLabel(models.NewLabel{Name: "Brakes", User: &userId})
+	if err != nil {
+		t.Fatalf("Error creating label: %v", err)
+	}
+	if _, err = s.AssignJobLabel(job.ID, label.ID, 1); err != nil {
+		t.Fatalf("Error assigning label: %v", err)
+	}
+	job, _ = s.GetJob(job.ID)
+	if len(job.Labels) != 1 || job.Labels[0].Name != "Brakes" {
+		t.Fatalf("Expected job to have label Brakes, got %v", job.Labels)
+	}
+	if _, err = s.AssignJobLabel(job.ID, label.ID, 0); err != nil {
+		t.Fatalf("Error unassigning label: %v", err)
+	}
+	job, _ = s.GetJob(job.ID)
+	if len(job.Labels) != 0 {
+		t.Errorf("Expected job to have no labels, got %v", job.Labels)
+	}
+}
+
+// TestCreateAlertType
+// Tests alerts created without a type default to notification
+func TestCreateAlertType(t *testing.T) {
+	s, job := newTestJob(t)
+	alert, err := s.CreateAlert(models.NewAlert{User: &job.User, Job: &job.ID})
+	if err != nil {
+		t.Fatalf("Error creating alert: %v", err)
+	}
+	if alert.Type != "notification" {
+		t.Errorf("Expected alert type notification, got %q", alert.Type)
+	}
+	alert, err = s.CreateAlert(models.NewAlert{Type: "reminder", User: &job.User, Job: &job.ID})
+	if err != nil {
+		t.Fatalf("Error creating alert: %v", err)
+	}
+	if alert.Type != "reminder" {
+		t.Errorf("Expected alert type reminder, got %q", alert.Type)
+	}
+}
+
+// TestCompleteRecurringJob
+// Tests completing a recurring job copies its tasks, their links and its labels onto the next occurrence, and undoing it removes the next occurrence
+func TestCompleteRecurringJob(t *testing.T) {
+	s := New(repository.NewMemory())
+	userId := int64(1)
+	repeats := 1
+	job, err := s.CreateJob(models.NewJob{Name: "Tire rotation", User: &userId, Repeats: &repeats})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	parent, _ := s.CreateTask(models.NewTask{Name: "Lift car"}, job.ID)
+	child, _ := s.CreateTask(models.NewTask{Name: "Remove wheels", Parent: &parent.ID, Depends_on: []int64{parent.ID}}, job.ID)
+	if child == nil {
+		t.Fatal("Error creating sub-task")
+	}
+	label, _ := s.CreateLabel(models.NewLabel{Name: "Tires", User: &userId})
+	s.AssignJobLabel(job.ID, label.ID, 1)
+	completion, err := s.CompleteJob(job.ID, models.NewJobCompletion{}, userId)
+	if err != nil {
+		t.Fatalf("Error completing job: %v", err)
+	}
+	if completion.Next_job == nil {
+		t.Fatal("Expected completion to create the next occurrence")
+	}
+	nextJob, _ := s.GetJob(*completion.Next_job)
+	if nextJob.Status != "planned" || len(nextJob.Labels) != 1 || nextJob.Labels[0].ID != label.ID {
+		t.Errorf("Expected planned next occurrence labelled Tires, got %v %v", nextJob.Status, nextJob.Labels)
+	}
+	tasks, _ := s.ListTasks(nextJob.ID, nil, nil, nil, nil)
+	if len(tasks) != 2 || tasks[1].Parent == nil || *tasks[1].Parent != tasks[0].ID || len(tasks[1].Depends_on) != 1 || tasks[1].Depends_on[0] != tasks[0].ID {
+		t.Fatalf("Expected copied sub-task linked to the copy of its parent, got %v", tasks)
+	}
+	history, _ := s.ListJobStatusHistory(job.ID)
+	if len(history) != 2 || history[1].To_status != "done" {
+		t.Errorf("Expected status history to end with done, got %v", history)
+	}
+	job, err = s.UndoJobCompletion(job.ID, userId)
+	if err != nil {
+		t.Fatalf("Error undoing completion: %v", err)
+	}
+	if job.Status != "planned" {
+		t.Errorf("Expected job to be planned again, got %v", job.Status)
+	}
+	if _, err = s.GetJob(nextJob.ID); err == nil {
+		t.Error("Expected next occurrence to be removed")
+	}
+	if _, err = s.GetJobCompletion(job.ID); err == nil {
+		t.Error("Expected completion to be removed")
+	}
+}
+
+// TestTaskDependencies
+// Tests tasks with open prerequisites are only completed when forced, loops are refused and copies keep sub-tasks and prerequisites
+func TestTaskDependencies(t *testing.T) {
+	s, job := newTestJob(t)
+	drain, err := s.CreateTask(models.NewTask{Name: "Drain oil"}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	filter, err := s.CreateTask(models.NewTask{Name: "Replace filter", Parent: &drain.ID}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	refill, err := s.CreateTask(models.NewTask{Name: "Refill", Depends_on: []int64{drain.ID, filter.ID}}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	if drain.Position != 0 || filter.Position != 1 || refill.Position != 2 {
+		t.Errorf("Expected new tasks to go last, got positions %d %d %d", drain.Position, filter.Position, refill.Position)
+	}
+	// refill is blocked until drain and filter are done, unless forced
+	if err := s.MarkComplete(job.ID, refill.ID, 1, false); !errors.Is(err, ErrTaskBlocked) {
+		t.Errorf("Expected refill to be blocked, got %v", err)
+	}
+	for _, task := range []*models.Task{drain, filter} {
+		if err := s.MarkComplete(job.ID, task.ID, 1, false); err != nil {
+			t.Fatalf("Error completing task %d: %v", task.ID, err)
+		}
+	}
+	if err := s.MarkComplete(job.ID, refill.ID, 1, false); err != nil {
+		t.Errorf("Expected refill to complete once prerequisites are done, got %v", err)
+	}
+	// loops through prerequisites or parents are refused
+	drain.Depends_on = []int64{refill.ID}
+	if _, err := s.EditTask(*drain, job.ID, nil); !errors.Is(err, ErrTaskRef) {
+		t.Errorf("Expected prerequisite loop to be refused, got %v", err)
+	}
+	drain.Depends_on, drain.Parent = nil, &filter.ID
+	if _, err := s.EditTask(*drain, job.ID, nil); !errors.Is(err, ErrTaskRef) {
+		t.Errorf("Expected parent loop to be refused, got %v", err)
+	}
+	// reorder moves listed tasks to the front, others keep their order after them
+	tasks, err := s.ReorderTasks(job.ID, []int64{refill.ID})
+	if err != nil {
+		t.Fatalf("Error reordering tasks: %v", err)
+	}
+	if len(tasks) != 3 || tasks[0].ID != refill.ID || tasks[1].ID != drain.ID || tasks[2].ID != filter.ID {
+		t.Errorf("Expected refill, drain, filter order, got %v", tasks)
+	}
+	// copies link to the copies of their parent and prerequisites
+	userId := int64(1)
+	copyJob, err := s.CreateJob(models.NewJob{Name: "Next oil change", User: &userId})
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	if err := s.copyTasks(job.ID, copyJob.ID); err != nil {
+		t.Fatalf("Error copying tasks: %v", err)
+	}
+	copies, err := s.ListTasks(copyJob.ID, nil, nil, nil, nil)
+	if err != nil || len(copies) != 3 {
+		t.Fatalf("Expected 3 copied tasks, got %d: %v", len(copies), err)
+	}
+	copyRefill, copyDrain, copyFilter := copies[0], copies[1], copies[2]
+	if copyFilter.Parent == nil || *copyFilter.Parent != copyDrain.ID || copyFilter.Is_complete != 0 {
+		t.Errorf("Expected copied filter to be an unchecked sub-task of copied drain, got parent %v", copyFilter.Parent)
+	}
+	if len(copyRefill.Depends_on) != 2 || copyRefill.Depends_on[0] != copyDrain.ID || copyRefill.Depends_on[1] != copyFilter.ID {
+		t.Errorf("Expected copied refill to depend on copied drain and filter, got %v", copyRefill.Depends_on)
+	}
+	// deleting a parent moves its sub-tasks up and drops prerequisites on it
+	if err := s.DeleteTask(copyJob.ID, &copyDrain.ID); err != nil {
+		t.Fatalf("Error deleting task: %v", err)
+	}
+	copyFilter, _ = s.GetTask(copyJob.ID, copyFilter.ID)
+	copyRefill, _ = s.GetTask(copyJob.ID, copyRefill.ID)
+	if copyFilter.Parent != nil || len(copyRefill.Depends_on) != 1 {
+		t.Errorf("Expected filter without parent and refill with 1 prerequisite, got %v and %v", copyFilter.Parent, copyRefill.Depends_on)
+	}
+}
+
+// TestTimeTracking
+// Tests each user has one running timer, time rolls up per job against estimates and accuracy is reported by label
+func TestTimeTracking(t *testing.T) {
+	s, job := newTestJob(t)
+	userId := int64(1)
+	estimate := int64(30)
+	drain, err := s.CreateTask(models.NewTask{Name: "Drain oil", Estimated_minutes: &estimate}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	refill, err := s.CreateTask(models.NewTask{Name: "Refill"}, job.ID)
+	if err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	label, err := s.CreateLabel(models.NewLabel{Name: "Engine", User: &userId})
+	if err != nil {
+		t.Fatalf("Error creating label: %v", err)
+	}
+	if _, err = s.AssignJobLabel(job.ID, label.ID, 1); err != nil {
+		t.Fatalf("Error assigning label: %v", err)
+	}
+	job, _ = s.GetJob(job.ID)
+	// starting a timer twice on the same task is refused, starting on another task stops the first
+	first, err := s.StartTimer(job, drain.ID, userId)
+	if err != nil {
+		t.Fatalf("Error starting timer: %v", err)
+	}
+	if _, err := s.StartTimer(job, drain.ID, userId); !errors.Is(err, ErrTimerRunning) {
+		t.Errorf("Expected timer to already be running, got %v", err)
+	}
+	if _, err := s.StartTimer(job, refill.ID, userId); err != nil {
+		t.Fatalf("Error starting timer: %v", err)
+	}
+	if _, err := s.StopTimer(job, drain.ID, userId); !errors.Is(err, ErrTimerStopped) {
+		t.Errorf("Expected timer on drain to be stopped, got %v", err)
+	}
+	running, err := s.GetRunningTimer(userId)
+	if err != nil || running.Task != refill.ID {
+		t.Fatalf("Expected timer running on refill, got %v, %v", running, err)
+	}
+	// time is rolled up per task and user, running timers included
+	jobTime, err := s.GetJobTime(job.ID)
+	if err != nil {
+		t.Fatalf("Error getting job time: %v", err)
+	}
+	if jobTime.Estimated_minutes != estimate || jobTime.Running != 1 || len(jobTime.Tasks) != 2 || len(jobTime.Users) != 1 {
+		t.Errorf("Expected 30 estimated minutes, 1 running timer, 2 tasks and 1 user, got %+v", jobTime)
+	}
+	stopped, err := s.StopTimer(job, refill.ID, userId)
+	if err != nil || stopped.Stopped_at == nil {
+		t.Fatalf("Expected timer on refill to stop, got %v, %v", stopped, err)
+	}
+	entries, err := s.ListTimeEntries(job.ID, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Error listing time entries: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != first.ID || entries[0].Stopped_at == nil {
+		t.Errorf("Expected 2 stopped time entries, got %v", entries)
+	}
+	// only completed tasks with an estimate count towards accuracy
+	report, err := s.BuildEstimateReport(&userId, nil, nil)
+	if err != nil {
+		t.Fatalf("Error building estimate report: %v", err)
+	}
+	if len(report.Labels) != 0 {
+		t.Errorf("Expected no accuracy before tasks are complete, got %+v", report.Labels)
+	}
+	if err := s.MarkComplete(job.ID, drain.ID, 1, false); err != nil {
+		t.Fatalf("Error completing task: %v", err)
+	}
+	report, err = s.BuildEstimateReport(&userId, nil, nil)
+	if err != nil {
+		t.Fatalf("Error building estimate report: %v", err)
+	}
+	if len(report.Labels) != 1 || report.Labels[0].Label == nil || report.Labels[0].Label.ID != label.ID || report.Labels[0].Tasks != 1 || report.Labels[0].Estimated_minutes != estimate || report.Labels[0].Ratio == nil {
+		t.Errorf("Expected accuracy of 1 task estimated at 30 minutes under Engine, got %+v", report.Labels)
+	}
+}
@@ -4,56 +4,63 @@ import (
 	"errors"
 	"log"
 	"strconv"
+	"time"
 
-	"github.com/okdv/wrench-turn/db"
 	"github.com/okdv/wrench-turn/models"
 )
 
 // GetLabel
 // Takes id as arg, passes to db query, returns Label
-func GetLabel(labelId int64) (*models.Label, error) {
-	label, err := db.GetLabel(labelId)
+func (s *Service) GetLabel(labelId int64) (*models.Label, error) {
+	label, err := s.repo.Labels.GetLabel(labelId)
 	return label, err
 }
 
 // CreateLabel
 // Takes newLabel as arg, passes to db query, calls GetLabel, returns Label
-func CreateLabel(newLabel models.NewLabel) (*models.Label, error) {
+func (s *Service) CreateLabel(newLabel models.NewLabel) (*models.Label, error) {
 	// pass to db query, return new Labels id
-	labelId, err := db.CreateLabel(newLabel)
+	labelId, err := s.repo.Labels.CreateLabel(newLabel)
 	if err != nil || labelId == nil {
 		err = errors.Join(err, errors.New("No ID of new Label found"))
 		return nil, err
 	}
 	// pass to GetLabel, return Label
-	label, err := GetLabel(*labelId)
+	label, err := s.GetLabel(*labelId)
+	if err == nil {
+		s.record("create", "label", label.ID, nil, nil, nil, label)
+	}
 	return label, err
 }
 
 // EditLabel
-// Takes User as arg, passes to EditLabel query, returns updated User
-func EditLabel(editedLabel models.Label) (*models.Label, error) {
-	err := db.EditLabel(editedLabel)
+// Takes Label and the updated_at it was read at, nil to skip the check, passes to EditLabel query, returns updated Label
+func (s *Service) EditLabel(editedLabel models.Label, revision *time.Time) (*models.Label, error) {
+	currentLabel, _ := s.GetLabel(editedLabel.ID)
+	err := s.repo.Labels.EditLabel(editedLabel, revision)
 	if err != nil {
 		return nil, err
 	}
-	label, err := GetLabel(editedLabel.ID)
+	label, err := s.GetLabel(editedLabel.ID)
+	if err == nil {
+		s.record("edit", "label", label.ID, nil, nil, currentLabel, label)
+	}
 	return label, err
 }
 
 // ListLabels
-// Takes URL query params as args, passes to ListLabels query, returns Label list
-func ListLabels(userId *string, jobId *string, searchStr *string, sort *string) ([]*models.Label, error) {
-	users, err := db.ListLabels(userId, jobId, searchStr, sort)
+// Takes URL query params and optional Page as args, passes to ListLabels query, returns Label list
+func (s *Service) ListLabels(userId *string, jobId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Label, error) {
+	users, err := s.repo.Labels.ListLabels(userId, jobId, searchStr, sort, page)
 	return users, err
 }
 
 // DeleteLabel
 // Takes label id as arg, passes to DeleteLabel query
-func DeleteLabel(labelId int64, userId *int64) error {
+func (s *Service) DeleteLabel(labelId int64, userId *int64) error {
 	labelIdStr := strconv.FormatInt(labelId, 10)
 	// get labels jobs
-	jobs, err := ListJobs(nil, nil, nil, nil, &labelIdStr, nil, nil)
+	jobs, err := s.ListJobs(nil, nil, nil, nil, nil, &labelIdStr, nil, nil, nil)
 	if err != nil {
 		log.Printf("Could not get jobs labels: %v", err)
 	}
@@ -61,12 +68,16 @@ func DeleteLabel(labelId int64, userId *int64) error {
 	if len(jobs) > 0 {
 		// loop through labels
 		for i := 0; i < len(jobs); i++ {
-			_, err = AssignJobLabel(jobs[i].ID, labelId, 0)
+			_, err = s.AssignJobLabel(jobs[i].ID, labelId, 0)
 			if err != nil {
 				log.Printf("Could not remove label from job ID %d: %v", jobs[i].ID, err)
 			}
 		}
 	}
-	err = db.DeleteLabel(labelId, userId)
+	label, _ := s.GetLabel(labelId)
+	err = s.repo.Labels.DeleteLabel(labelId, userId)
+	if err == nil {
+		s.record("delete", "label", labelId, nil, nil, label, nil)
+	}
 	return err
 }
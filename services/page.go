@@ -0,0 +1,80 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/repository"
+)
+
+// default and max number of rows per page
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// ErrCursorSort
+// Returned by list services given a cursor from a list with a different sort
+var ErrCursorSort = repository.ErrCursorSort
+
+// NewPage
+// Takes limit, cursor and count query params as args, returns Page, nil if neither limit nor cursor provided so the whole list is returned
+func NewPage(limitStr string, cursorStr string, count bool) (*models.Page, error) {
+	if len(limitStr) == 0 && len(cursorStr) == 0 {
+		return nil, nil
+	}
+	page := models.Page{Limit: DefaultPageLimit, Count: count}
+	if len(limitStr) > 0 {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return nil, errors.New("Limit must be an integer between 1 and " + strconv.Itoa(MaxPageLimit))
+		}
+		page.Limit = limit
+	}
+	if len(cursorStr) > 0 {
+		cursor, err := DecodeCursor(cursorStr)
+		if err != nil {
+			return nil, err
+		}
+		page.Cursor = cursor
+	}
+	return &page, nil
+}
+
+// EncodeCursor
+// Takes PageCursor as arg, returns opaque url safe string
+func EncodeCursor(cursor models.PageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor
+// Takes string from EncodeCursor as arg, returns PageCursor
+func DecodeCursor(cursorStr string) (*models.PageCursor, error) {
+	var cursor models.PageCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursorStr)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil || len(cursor.Sort) == 0 {
+		return nil, errors.New("Invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// NewPageResult
+// Takes list items and the Page they were listed with as args, returns PageResult envelope
+func NewPageResult(items any, page models.Page) models.PageResult {
+	result := models.PageResult{
+		Items: items,
+		Total: page.Total,
+	}
+	if page.Next != nil {
+		next := EncodeCursor(*page.Next)
+		result.Next_cursor = &next
+	}
+	return result
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/utils"
+)
+
+//go:embed templates/report.html
+var reportTemplates embed.FS
+
+// reportTemplate
+// Printable HTML service history, parsed once at startup
+var reportTemplate = template.Must(template.New("report.html").Funcs(template.FuncMap{
+	"date":  reportDate,
+	"money": reportMoney,
+}).ParseFS(reportTemplates, "templates/report.html"))
+
+// BuildVehicleReport
+// Takes vehicle and optional completion date range and label as args, collects completed jobs with tasks and completion details, returns VehicleReport
+func (s *Service) BuildVehicleReport(vehicle models.Vehicle, from *time.Time, to *time.Time, label *models.Label) (*models.VehicleReport, error) {
+	vehicleIdStr := strconv.FormatInt(vehicle.ID, 10)
+	isComplete := "1"
+	isTemplate := "0"
+	var labelIdStr *string
+	if label != nil {
+		idStr := strconv.FormatInt(label.ID, 10)
+		labelIdStr = &idStr
+	}
+	jobs, err := s.ListJobs(nil, &vehicleIdStr, &isTemplate, &isComplete, nil, labelIdStr, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	report := models.VehicleReport{
+		Vehicle:      vehicle,
+		From:         from,
+		To:           to,
+		Label:        label,
+		Jobs:         make([]models.ReportJob, 0, len(jobs)),
+		Generated_at: time.Now().UTC(),
+	}
+	for _, job := range jobs {
+		// filter by completion date, to is inclusive of the whole day
+		if job.Completed_at == nil {
+			continue
+		}
+		if from != nil && job.Completed_at.Before(*from) {
+			continue
+		}
+		if to != nil && !job.Completed_at.Before(to.AddDate(0, 0, 1)) {
+			continue
+		}
+		tasks, err := s.ListTasks(job.ID, nil, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		// jobs completed without a completion record have no odometer or cost
+		completion, err := s.GetJobCompletion(job.ID)
+		if err != nil {
+			completion = nil
+		}
+		if completion != nil && completion.Cost != nil {
+			report.Total_cost += *completion.Cost
+		}
+		report.Jobs = append(report.Jobs, models.ReportJob{
+			Job:        *job,
+			Tasks:      tasks,
+			Completion: completion,
+		})
+	}
+	// oldest work first, reads like a service book
+	sort.SliceStable(report.Jobs, func(i, j int) bool {
+		return report.Jobs[i].Job.Completed_at.Before(*report.Jobs[j].Job.Completed_at)
+	})
+	return &report, nil
+}
+
+// RenderReportHTML
+// Takes VehicleReport and writer as args, writes printable HTML report
+func RenderReportHTML(report models.VehicleReport, w io.Writer) error {
+	return reportTemplate.Execute(w, struct {
+		models.VehicleReport
+		Unit string
+	}{report, odometerUnit(report.Vehicle)})
+}
+
+// RenderReportPDF
+// Takes VehicleReport as arg, returns report as PDF file
+func RenderReportPDF(report models.VehicleReport) []byte {
+	unit := odometerUnit(report.Vehicle)
+	pdf := utils.NewPDF()
+	// vehicle details
+	pdf.Text(report.Vehicle.Name, 20, true, 0)
+	meta := "Service history generated " + reportDate(report.Generated_at)
+	if report.From != nil {
+		meta += ", from " + reportDate(report.From)
+	}
+	if report.To != nil {
+		meta += ", to " + reportDate(report.To)
+	}
+	if report.Label != nil {
+		meta += ", label " + report.Label.Name
+	}
+	pdf.Text(meta, 9, false, 0)
+	pdf.Space(8)
+	vehicle := report.Vehicle
+	var desc []string
+	if vehicle.Year != nil {
+		desc = append(desc, strconv.FormatInt(*vehicle.Year, 10))
+	}
+	for _, s := range []*string{vehicle.Make, vehicle.Model, vehicle.Trim} {
+		if s != nil && len(*s) > 0 {
+			desc = append(desc, *s)
+		}
+	}
+	if len(desc) > 0 {
+		pdf.Text("Vehicle: "+strings.Join(desc, " "), 11, false, 0)
+	}
+	if vehicle.Vin != nil && len(*vehicle.Vin) > 0 {
+		pdf.Text("VIN: "+*vehicle.Vin, 11, false, 0)
+	}
+	if vehicle.Odometer != nil {
+		pdf.Text(fmt.Sprintf("Odometer: %d %s", *vehicle.Odometer, unit), 11, false, 0)
+	}
+	if vehicle.Description != nil && len(*vehicle.Description) > 0 {
+		pdf.Text(*vehicle.Description, 11, false, 0)
+	}
+	// completed work
+	pdf.Space(8)
+	pdf.Text("Completed work", 14, true, 0)
+	pdf.Rule()
+	if len(report.Jobs) == 0 {
+		pdf.Text("No completed work recorded.", 11, false, 0)
+	}
+	for _, reportJob := range report.Jobs {
+		pdf.Text(reportDate(reportJob.Job.Completed_at)+"  "+reportJob.Job.Name, 12, true, 0)
+		if c := reportJob.Completion; c != nil {
+			var details []string
+			if c.Odometer != nil {
+				details = append(details, fmt.Sprintf("%d %s", *c.Odometer, unit))
+			}
+			if c.Shop != nil && len(*c.Shop) > 0 {
+				details = append(details, "by "+*c.Shop)
+			} else {
+				details = append(details, "by "+c.Performed_by)
+			}
+			if c.Cost != nil {
+				details = append(details, reportMoney(c.Cost))
+			}
+			pdf.Text(strings.Join(details, " | "), 10, false, 12)
+		}
+		if reportJob.Job.Description != nil && len(*reportJob.Job.Description) > 0 {
+			pdf.Text(*reportJob.Job.Description, 10, false, 12)
+		}
+		if c := reportJob.Completion; c != nil && c.Notes != nil && len(*c.Notes) > 0 {
+			pdf.Text("Notes: "+*c.Notes, 10, false, 12)
+		}
+		for _, task := range reportJob.Tasks {
+			line := "- " + task.Name
+			if task.Part_name != nil && len(*task.Part_name) > 0 {
+				line += " (" + *task.Part_name + ")"
+			}
+			pdf.Text(line, 10, false, 24)
+		}
+		pdf.Space(6)
+	}
+	pdf.Rule()
+	pdf.Text("Total: "+reportMoney(report.Total_cost), 12, true, 0)
+	return pdf.Bytes()
+}
+
+// odometerUnit
+// Takes Vehicle as arg, returns km for metric vehicles, mi otherwise
+func odometerUnit(vehicle models.Vehicle) string {
+	if vehicle.Is_metric != nil && *vehicle.Is_metric == 1 {
+		return "km"
+	}
+	return "mi"
+}
+
+// reportDate
+// Takes time or time pointer as arg, returns it formatted as a date
+func reportDate(t interface{}) string {
+	switch v := t.(type) {
+	case time.Time:
+		return v.Format(time.DateOnly)
+	case *time.Time:
+		if v != nil {
+			return v.Format(time.DateOnly)
+		}
+	}
+	return ""
+}
+
+// reportMoney
+// Takes float or float pointer as arg, returns it formatted with two decimals
+func reportMoney(f interface{}) string {
+	switch v := f.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case *float64:
+		if v != nil {
+			return strconv.FormatFloat(*v, 'f', 2, 64)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+	"github.com/okdv/wrench-turn/utils"
+	"github.com/okdv/wrench-turn/validate"
+)
+
+// ParseSchedule
+// Takes a maintenance schedule file (JSON) as reader, decodes and validates it, returns NewSchedule
+func ParseSchedule(r io.Reader) (*models.NewSchedule, error) {
+	var newSchedule models.NewSchedule
+	// decode schedule file, reject unknown fields so typos in schedule files are not silently dropped
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&newSchedule)
+	if err != nil {
+		return nil, err
+	}
+	// schedule needs a name and at least one item, each with a name
+	err = validate.Struct(newSchedule)
+	if err != nil {
+		return nil, err
+	}
+	// year range must be in order if both are provided
+	if newSchedule.Year_min != nil && newSchedule.Year_max != nil && *newSchedule.Year_min > *newSchedule.Year_max {
+		return nil, errors.New("Schedule yearMin must not be after yearMax")
+	}
+	// validate each item
+	for _, item := range newSchedule.Items {
+		// an item must repeat by odometer, time or both
+		if item.Odo_interval == nil && item.Time_interval == nil {
+			return nil, fmt.Errorf("Schedule item %q needs an odoInterval or timeInterval", item.Name)
+		}
+		if item.Time_interval != nil {
+			if item.Time_interval_unit == nil {
+				return nil, fmt.Errorf("Schedule item %q has a timeInterval but no timeIntervalUnit", item.Name)
+			}
+			if _, err := utils.AddTimeInterval(time.Now(), *item.Time_interval, *item.Time_interval_unit); err != nil {
+				return nil, fmt.Errorf("Schedule item %q: %v", item.Name, err)
+			}
+		}
+	}
+	return &newSchedule, nil
+}
+
+// GetSchedule
+// Takes id as arg, passes to db query, attaches template jobs, returns Schedule
+func (s *Service) GetSchedule(scheduleId int64) (*models.Schedule, error) {
+	schedule, err := s.repo.Schedules.GetSchedule(scheduleId)
+	if err != nil {
+		return nil, err
+	}
+	// get schedules template jobs
+	jobIds, err := s.repo.Schedules.ListScheduleJobIds(scheduleId)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Jobs = make([]models.Job, 0, len(jobIds))
+	for _, jobId := range jobIds {
+		job, err := s.GetJob(jobId)
+		if err != nil {
+			log.Printf("Could not get schedule job ID %d: %v", jobId, err)
+			continue
+		}
+		schedule.Jobs = append(schedule.Jobs, *job)
+	}
+	return schedule, nil
+}
+
+// ListSchedules
+// Takes URL query params as args, passes to ListSchedules query, returns Schedule list
+func (s *Service) ListSchedules(userId *string, makeStr *string, modelStr *string, yearStr *string, searchStr *string, sort *string) ([]*models.Schedule, error) {
+	schedules, err := s.repo.Schedules.ListSchedules(userId, makeStr, modelStr, yearStr, searchStr, sort)
+	return schedules, err
+}
+
+// ImportSchedule
+// Takes NewSchedule as arg, creates schedule and a template job (with tasks) for each item, returns Schedule
+func (s *Service) ImportSchedule(newSchedule models.NewSchedule) (*models.Schedule, error) {
+	// pass to db query, return new Schedules id
+	scheduleId, err := s.repo.Schedules.CreateSchedule(newSchedule)
+	if err != nil || scheduleId == nil {
+		err = errors.Join(err, errors.New("No ID of new Schedule found"))
+		return nil, err
+	}
+	// create a repeating template job for each item
+	isTemplate := 1
+	repeats := 1
+	for _, item := range newSchedule.Items {
+		job, err := s.CreateJob(models.NewJob{
+			Name:               item.Name,
+			Description:        item.Description,
+			Instructions:       item.Instructions,
+			Is_template:        &isTemplate,
+			User:               newSchedule.User,
+			Repeats:            &repeats,
+			Odo_interval:       item.Odo_interval,
+			Time_interval:      item.Time_interval,
+			Time_interval_unit: item.Time_interval_unit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = s.repo.Schedules.AssignScheduleJob(*scheduleId, job.ID)
+		if err != nil {
+			return nil, err
+		}
+		// create items tasks on template job
+		for _, newTask := range item.Tasks {
+			_, err = s.CreateTask(newTask, job.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	// pass to GetSchedule, return Schedule
+	schedule, err := s.GetSchedule(*scheduleId)
+	if err == nil {
+		s.record("create", "schedule", schedule.ID, nil, nil, nil, schedule)
+	}
+	return schedule, err
+}
+
+// ScheduleMatchesVehicle
+// Takes Schedule and Vehicle as args, returns whether the schedules make, model and year range fit the vehicle
+func ScheduleMatchesVehicle(schedule models.Schedule, vehicle models.Vehicle) bool {
+	// unset schedule fields match any vehicle
+	if schedule.Make != nil && (vehicle.Make == nil || !strings.EqualFold(*schedule.Make, *vehicle.Make)) {
+		return false
+	}
+	if schedule.Model != nil && (vehicle.Model == nil || !strings.EqualFold(*schedule.Model, *vehicle.Model)) {
+		return false
+	}
+	if (schedule.Year_min != nil || schedule.Year_max != nil) && vehicle.Year == nil {
+		return false
+	}
+	if schedule.Year_min != nil && *vehicle.Year < *schedule.Year_min {
+		return false
+	}
+	if schedule.Year_max != nil && *vehicle.Year > *schedule.Year_max {
+		return false
+	}
+	return true
+}
+
+// ApplySchedule
+// Takes schedule id and vehicle as args, creates a recurring job (with tasks) on the vehicle from each template job, returns created Jobs
+func (s *Service) ApplySchedule(scheduleId int64, vehicle models.Vehicle, force bool) ([]*models.Job, error) {
+	schedule, err := s.GetSchedule(scheduleId)
+	if err != nil {
+		return nil, err
+	}
+	// unless forced, only apply schedules meant for this vehicle
+	if !force && !ScheduleMatchesVehicle(*schedule, vehicle) {
+		return nil, errors.New("Schedule does not match vehicle make, model or year")
+	}
+	jobs := make([]*models.Job, 0, len(schedule.Jobs))
+	isTemplate := 0
+	repeats := 1
+	for _, template := range schedule.Jobs {
+		// first due date is one time interval from now, if the job repeats by time
+		var dueDate *time.Time
+		if template.Time_interval != nil && template.Time_interval_unit != nil {
+			due, err := utils.AddTimeInterval(time.Now().UTC(), *template.Time_interval, *template.Time_interval_unit)
+			if err == nil {
+				dueDate = &due
+			}
+		}
+		originJob := template.ID
+		job, err := s.CreateJob(models.NewJob{
+			Name:               template.Name,
+			Description:        template.Description,
+			Instructions:       template.Instructions,
+			Is_template:        &isTemplate,
+			Vehicle:            &vehicle.ID,
+			User:               &vehicle.User,
+			Origin_job:         &originJob,
+			Repeats:            &repeats,
+			Odo_interval:       template.Odo_interval,
+			Time_interval:      template.Time_interval,
+			Time_interval_unit: template.Time_interval_unit,
+			Due_date:           dueDate,
+		})
+		if err != nil {
+			return jobs, err
+		}
+		// copy template tasks onto new job
+		err = s.copyTasks(template.ID, job.ID)
+		if err != nil {
+			return jobs, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// DeleteSchedule
+// Takes schedule id as arg, deletes its template jobs, passes to DeleteSchedule query
+func (s *Service) DeleteSchedule(scheduleId int64, userId *int64) error {
+	// get schedules template jobs
+	jobIds, err := s.repo.Schedules.ListScheduleJobIds(scheduleId)
+	if err != nil {
+		log.Printf("Could not get schedules jobs: %v", err)
+	}
+	schedule, _ := s.GetSchedule(scheduleId)
+	// delete schedule first so a non-owner cannot remove its jobs
+	err = s.repo.Schedules.DeleteSchedule(scheduleId, userId)
+	if err != nil {
+		return err
+	}
+	s.record("delete", "schedule", scheduleId, nil, nil, schedule, nil)
+	err = s.repo.Schedules.UnassignScheduleJobs(scheduleId)
+	if err != nil {
+		log.Printf("Could not remove schedule jobs: %v", err)
+	}
+	// loop through template jobs, jobs created from them keep their own copy
+	for _, jobId := range jobIds {
+		err = s.DeleteJob(jobId, nil)
+		if err != nil {
+			log.Printf("Could not delete schedule job ID %d: %v", jobId, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// number of words in a search snippet when highlighting without the search index
+const searchSnippetWords = 16
+
+// SearchTypes
+// Types of records that can be searched
+var SearchTypes = []string{"job", "task", "vehicle", "label", "alert"}
+
+// ValidSearchType
+// Takes type as arg, returns whether it can be searched
+func ValidSearchType(typeStr string) bool {
+	for _, t := range SearchTypes {
+		if t == typeStr {
+			return true
+		}
+	}
+	return false
+}
+
+// Search
+// Takes query string, optional owner id (nil for all users), types and limit as args, returns ranked results with matches highlighted
+func (s *Service) Search(q string, userId *int64, types []string, limit int) ([]*models.SearchResult, error) {
+	var results []*models.SearchResult
+	var err error
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		return make([]*models.SearchResult, 0), nil
+	}
+	if s.repo.Search.SearchIndexEnabled() {
+		// quote each term so input is never parsed as FTS5 syntax, prefix match so partial words find results while typing
+		phrases := make([]string, len(terms))
+		for i, term := range terms {
+			phrases[i] = "\"" + strings.ReplaceAll(term, "\"", "\"\"") + "\"*"
+		}
+		results, err = s.repo.Search.Search(strings.Join(phrases, " "), userId, types, limit)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		results, err = s.repo.Search.SearchLike(terms, userId, types, limit)
+		if err != nil {
+			return nil, err
+		}
+		searchRankLike(results, terms)
+	}
+	// escape text and turn match markers into tags
+	for _, result := range results {
+		result.Title = searchHighlight(result.Title)
+		result.Snippet = searchHighlight(result.Snippet)
+	}
+	return results, nil
+}
+
+// searchTerms splits query string into words, ignoring any without letters or numbers
+func searchTerms(q string) []string {
+	var terms []string
+	for _, word := range strings.Fields(q) {
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) >= 0 {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// searchRankLike marks and ranks LIKE search results the way the search index would, title matches weigh most
+func searchRankLike(results []*models.SearchResult, terms []string) {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+	for _, result := range results {
+		result.Rank = float64(10*len(re.FindAllStringIndex(result.Title, -1)) + len(re.FindAllStringIndex(result.Snippet, -1)))
+		result.Title = re.ReplaceAllString(result.Title, "\x02$0\x03")
+		// trim snippet to the words around the first match
+		words := strings.Fields(re.ReplaceAllString(result.Snippet, "\x02$0\x03"))
+		start := 0
+		for i, word := range words {
+			if strings.Contains(word, "\x02") {
+				start = i - searchSnippetWords/4
+				break
+			}
+		}
+		if start < 0 {
+			start = 0
+		}
+		end := start + searchSnippetWords
+		if end > len(words) {
+			end = len(words)
+		}
+		snippet := strings.Join(words[start:end], " ")
+		if start > 0 {
+			snippet = "…" + snippet
+		}
+		if end < len(words) {
+			snippet += "…"
+		}
+		result.Snippet = snippet
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+}
+
+// searchHighlight escapes text for HTML, replacing match markers with mark tags
+func searchHighlight(text string) string {
+	return strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>").Replace(html.EscapeString(text))
+}
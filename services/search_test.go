@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// TestSearchLike
+// Tests searching without the full text index matches every term, ranks title matches first and only returns the users own records
+func TestSearchLike(t *testing.T) {
+	s, job := newTestJob(t)
+	description := "Drain the old oil"
+	if _, err := s.CreateTask(models.NewTask{Name: "Replace filter", Description: &description}, job.ID); err != nil {
+		t.Fatalf("Error creating task: %v", err)
+	}
+	otherId := int64(2)
+	if _, err := s.CreateJob(models.NewJob{Name: "Oil leak", User: &otherId}); err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	userId := int64(1)
+	results, err := s.Search("oil", &userId, nil, 10)
+	if err != nil {
+		t.Fatalf("Error searching: %v", err)
+	}
+	if len(results) != 2 || results[0].Type != "job" || results[0].Title != "<mark>Oil</mark> change" || results[1].Type != "task" || results[1].Job == nil || *results[1].Job != job.ID {
+		t.Fatalf("Expected own job then its task, got %+v", results)
+	}
+	// every term must match
+	if results, _ = s.Search("oil filter", &userId, nil, 10); len(results) != 1 || results[0].Type != "task" {
+		t.Errorf("Expected only task to match both terms, got %+v", results)
+	}
+	if results, _ = s.Search("oil", &userId, []string{"vehicle"}, 10); len(results) != 0 {
+		t.Errorf("Expected no vehicles to match, got %+v", results)
+	}
+}
@@ -0,0 +1,18 @@
+package services
+
+import (
+	"github.com/okdv/wrench-turn/repository"
+)
+
+// Service
+// Business logic over the injected repositories
+type Service struct {
+	repo  repository.Repositories
+	actor *int64 // user recorded in audit events, set by WithActor
+}
+
+// New
+// Takes Repositories as arg, e.g. db.NewRepositories() or repository.NewMemory(), returns Service
+func New(repo repository.Repositories) *Service {
+	return &Service{repo: repo}
+}
@@ -2,60 +2,301 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
-	"github.com/okdv/wrench-turn/db"
 	"github.com/okdv/wrench-turn/models"
 )
 
+// ErrTaskBlocked
+// Returned by MarkComplete when a task still has open prerequisites and completion was not forced
+var ErrTaskBlocked = errors.New("Task has open prerequisites")
+
+// ErrTaskRef
+// Returned when a parent, prerequisite or reordered task is not another task of the job, or parents or prerequisites would loop
+var ErrTaskRef = errors.New("Invalid task reference")
+
 // GetTask
 // Takes ids as args, passes to db query, returns Task
-func GetTask(jobId int64, taskId int64) (*models.Task, error) {
-	task, err := db.GetTask(jobId, taskId)
+func (s *Service) GetTask(jobId int64, taskId int64) (*models.Task, error) {
+	task, err := s.repo.Tasks.GetTask(jobId, taskId)
 	return task, err
 }
 
 // CreateTask
 // Takes newTask as arg, passes to db query, calls GetTask, returns Task
-func CreateTask(newTask models.NewTask, jobId int64) (*models.Task, error) {
+func (s *Service) CreateTask(newTask models.NewTask, jobId int64) (*models.Task, error) {
+	// parent and prerequisites must be tasks of the same job
+	err := s.checkTaskLinks(jobId, 0, newTask.Parent, newTask.Depends_on)
+	if err != nil {
+		return nil, err
+	}
 	// pass to db query, return new Tasks id
-	taskId, err := db.CreateTask(newTask, jobId)
+	taskId, err := s.repo.Tasks.CreateTask(newTask, jobId)
 	if err != nil || taskId == nil {
 		err = errors.Join(err, errors.New("No ID of new Task found"))
 		return nil, err
 	}
 	// pass to GetTask, return Task
-	task, err := GetTask(jobId, *taskId)
+	task, err := s.GetTask(jobId, *taskId)
+	if err == nil {
+		s.recordTask("create", jobId, task.ID, nil, task)
+	}
 	return task, err
 }
 
 // EditTask
-// Takes edited task, jobid as args, passes to EditTask query, returns updated Task
-func EditTask(editedTask models.Task, jobId int64) (*models.Task, error) {
-	err := db.EditTask(editedTask, jobId)
+// Takes edited task, jobid and the updated_at the task was read at, nil to skip the check, passes to EditTask query, returns updated Task
+func (s *Service) EditTask(editedTask models.Task, jobId int64, revision *time.Time) (*models.Task, error) {
+	currentTask, _ := s.GetTask(jobId, editedTask.ID)
+	// parent and prerequisites must be tasks of the same job without forming a loop
+	err := s.checkTaskLinks(jobId, editedTask.ID, editedTask.Parent, editedTask.Depends_on)
 	if err != nil {
 		return nil, err
 	}
-	task, err := GetTask(jobId, editedTask.ID)
+	err = s.repo.Tasks.EditTask(editedTask, jobId, revision)
+	if err != nil {
+		return nil, err
+	}
+	task, err := s.GetTask(jobId, editedTask.ID)
+	if err == nil {
+		s.recordTask("edit", jobId, task.ID, currentTask, task)
+	}
+	return task, err
+}
+
+// GetTaskById
+// Takes task id as arg, passes to GetTaskById query, returns Task of any job
+func (s *Service) GetTaskById(taskId int64) (*models.Task, error) {
+	task, err := s.repo.Tasks.GetTaskById(taskId)
 	return task, err
 }
 
 // MarkComplete
-// Takes job id, task id, complete status as args, passes to MarkComplete query
-func MarkComplete(jobId int64, taskId int64, status int) error {
-	err := db.UpdateTaskStatus(jobId, taskId, status)
+// Takes job id, task id, complete status and force as args, refuses to complete a task with open prerequisites unless forced, passes to MarkComplete query
+func (s *Service) MarkComplete(jobId int64, taskId int64, status int, force bool) error {
+	currentTask, err := s.GetTask(jobId, taskId)
+	if err != nil {
+		return err
+	}
+	if status == 1 && !force {
+		if open := s.OpenPrerequisites(currentTask); len(open) > 0 {
+			return fmt.Errorf("%w: %v", ErrTaskBlocked, open)
+		}
+	}
+	err = s.repo.Tasks.UpdateTaskStatus(jobId, taskId, status)
+	if err == nil {
+		task, _ := s.GetTask(jobId, taskId)
+		s.recordTask("complete", jobId, taskId, currentTask, task)
+	}
 	return err
 }
 
+// AssignTaskLabel
+// Takes job id, task id, label id, creates an entry into task_label if assigning, otherwise deletes any existing entry
+func (s *Service) AssignTaskLabel(jobId int64, taskId int64, labelId int64, assign int) (*int64, error) {
+	change := map[string]any{"label": labelId}
+	// if assigning, call that query and return
+	if assign == 1 {
+		relationshipId, err := s.repo.Tasks.AssignTaskLabel(taskId, labelId)
+		if err == nil {
+			s.recordTask("assign", jobId, taskId, nil, change)
+		}
+		return relationshipId, err
+	}
+	// otherwise call unassign query
+	err := s.repo.Tasks.UnassignTaskLabel(taskId, labelId)
+	if err == nil {
+		s.recordTask("unassign", jobId, taskId, change, nil)
+	}
+	return nil, err
+}
+
+// OpenPrerequisites
+// Takes Task as arg, returns ids of the tasks it depends on that are not complete yet
+func (s *Service) OpenPrerequisites(task *models.Task) []int64 {
+	var open []int64
+	for _, dependencyId := range task.Depends_on {
+		dependency, err := s.GetTaskById(dependencyId)
+		if err == nil && dependency.Is_complete == 0 {
+			open = append(open, dependencyId)
+		}
+	}
+	return open
+}
+
+// ReorderTasks
+// Takes job id and task ids in their new order as args, passes to ReorderTasks query, returns Task list in job order
+func (s *Service) ReorderTasks(jobId int64, taskIds []int64) ([]*models.Task, error) {
+	seen := map[int64]bool{}
+	for _, taskId := range taskIds {
+		if seen[taskId] {
+			return nil, fmt.Errorf("%w: Task ID %d is listed more than once", ErrTaskRef, taskId)
+		}
+		seen[taskId] = true
+	}
+	currentTasks, err := s.ListTasks(jobId, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	positions := map[int64]*models.Task{}
+	for _, task := range currentTasks {
+		positions[task.ID] = task
+	}
+	for _, taskId := range taskIds {
+		if positions[taskId] == nil {
+			return nil, fmt.Errorf("%w: Task ID %d not found on job ID %d", ErrTaskRef, taskId, jobId)
+		}
+	}
+	err = s.repo.Tasks.ReorderTasks(jobId, taskIds)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.ListTasks(jobId, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	// record tasks that moved
+	for _, task := range tasks {
+		if before, ok := positions[task.ID]; ok && before.Position != task.Position {
+			s.recordTask("edit", jobId, task.ID, before, task)
+		}
+	}
+	return tasks, nil
+}
+
+// checkTaskLinks
+// Takes job id, task id (0 for new tasks), parent and prerequisite ids as args, errors unless they are other tasks of the job and neither parents nor prerequisites loop back to the task
+func (s *Service) checkTaskLinks(jobId int64, taskId int64, parent *int64, dependsOn []int64) error {
+	if parent == nil && len(dependsOn) == 0 {
+		return nil
+	}
+	tasks, err := s.ListTasks(jobId, nil, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	jobTasks := map[int64]*models.Task{}
+	for _, task := range tasks {
+		jobTasks[task.ID] = task
+	}
+	if parent != nil {
+		if *parent == taskId || jobTasks[*parent] == nil {
+			return fmt.Errorf("%w: Parent task ID %d must be another task of job ID %d", ErrTaskRef, *parent, jobId)
+		}
+		// walk up from parent, reaching the task means it would be its own ancestor
+		for ancestor := jobTasks[*parent]; ancestor != nil && ancestor.Parent != nil; ancestor = jobTasks[*ancestor.Parent] {
+			if *ancestor.Parent == taskId {
+				return fmt.Errorf("%w: Task ID %d can not be a sub-task of its own sub-task ID %d", ErrTaskRef, taskId, *parent)
+			}
+		}
+	}
+	// walk prerequisites of prerequisites, reaching the task means they could never be completed
+	visited := map[int64]bool{}
+	pending := append([]int64(nil), dependsOn...)
+	for len(pending) > 0 {
+		dependencyId := pending[0]
+		pending = pending[1:]
+		if dependencyId == taskId || jobTasks[dependencyId] == nil {
+			return fmt.Errorf("%w: Prerequisite task ID %d must be another task of job ID %d", ErrTaskRef, dependencyId, jobId)
+		}
+		if visited[dependencyId] {
+			continue
+		}
+		visited[dependencyId] = true
+		for _, next := range jobTasks[dependencyId].Depends_on {
+			if next == taskId {
+				return fmt.Errorf("%w: Prerequisite task ID %d already depends on task ID %d", ErrTaskRef, dependencyId, taskId)
+			}
+			pending = append(pending, next)
+		}
+	}
+	return nil
+}
+
+// copyTasks
+// Takes ids of job to copy tasks from and job to copy them onto, creates them unchecked in the same order with the same sub-tasks, prerequisites and labels
+func (s *Service) copyTasks(fromJobId int64, toJobId int64) error {
+	tasks, err := s.ListTasks(fromJobId, nil, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	taskIds := map[int64]int64{}
+	for _, task := range tasks {
+		newTask, err := s.CreateTask(models.NewTask{
+			Name:              task.Name,
+			Description:       task.Description,
+			Part_name:         task.Part_name,
+			Part_link:         task.Part_link,
+			Estimated_minutes: task.Estimated_minutes,
+		}, toJobId)
+		if err != nil {
+			return err
+		}
+		taskIds[task.ID] = newTask.ID
+		for _, label := range task.Labels {
+			if _, err = s.repo.Tasks.AssignTaskLabel(newTask.ID, label.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return s.copyTaskLinks(tasks, taskIds)
+}
+
+// copyTaskLinks
+// Takes original tasks and map of their ids to ids of their copies, links copies to the copies of their parent and prerequisites, links to tasks that were not copied are dropped
+func (s *Service) copyTaskLinks(tasks []*models.Task, taskIds map[int64]int64) error {
+	for _, task := range tasks {
+		if task.Parent == nil && len(task.Depends_on) == 0 {
+			continue
+		}
+		copied, err := s.GetTaskById(taskIds[task.ID])
+		if err != nil {
+			return err
+		}
+		copied.Parent = nil
+		if task.Parent != nil {
+			if parentId, ok := taskIds[*task.Parent]; ok {
+				copied.Parent = &parentId
+			}
+		}
+		copied.Depends_on = []int64{}
+		for _, dependencyId := range task.Depends_on {
+			if copiedId, ok := taskIds[dependencyId]; ok {
+				copied.Depends_on = append(copied.Depends_on, copiedId)
+			}
+		}
+		err = s.repo.Tasks.EditTask(*copied, *copied.Job, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ListTasks
-// Takes URL query params as args, passes to ListTasks query, returns Task list
-func ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string) ([]*models.Task, error) {
-	users, err := db.ListTasks(jobId, isComplete, searchStr, sort)
+// Takes URL query params and optional Page as args, passes to ListTasks query, returns Task list
+func (s *Service) ListTasks(jobId int64, isComplete *string, searchStr *string, sort *string, page *models.Page) ([]*models.Task, error) {
+	users, err := s.repo.Tasks.ListTasks(jobId, isComplete, searchStr, sort, page)
 	return users, err
 }
 
 // DeleteTask
 // Takes job id, task id as args, passes to DeleteTask query
-func DeleteTask(jobId int64, taskId *int64) error {
-	err := db.DeleteTask(jobId, taskId)
+func (s *Service) DeleteTask(jobId int64, taskId *int64) error {
+	// keep tasks for audit log, all of the jobs tasks if no task id
+	var tasks []*models.Task
+	if taskId != nil {
+		if task, err := s.GetTask(jobId, *taskId); err == nil {
+			tasks = append(tasks, task)
+		}
+	} else {
+		tasks, _ = s.ListTasks(jobId, nil, nil, nil, nil)
+	}
+	err := s.repo.Tasks.DeleteTask(jobId, taskId)
+	if err == nil {
+		for _, task := range tasks {
+			s.recordTask("delete", jobId, task.ID, task, nil)
+		}
+	}
 	return err
 }
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ErrTimerRunning
+// Returned when starting a timer on a task the user is already timing
+var ErrTimerRunning = errors.New("Timer is already running")
+
+// ErrTimerStopped
+// Returned when stopping a timer on a task the user is not timing
+var ErrTimerStopped = errors.New("Timer is not running")
+
+// GetRunningTimer
+// Takes user id as arg, passes to GetRunningTimer query, returns TimeEntry with minutes so far
+func (s *Service) GetRunningTimer(userId int64) (*models.TimeEntry, error) {
+	entry, err := s.repo.Time.GetRunningTimer(userId)
+	if err != nil {
+		return nil, err
+	}
+	entry.Minutes = entryMinutes(entryDuration(entry, time.Now()))
+	return entry, nil
+}
+
+// ListTimeEntries
+// Takes job id, optional task id, user id and Page as args, passes to ListTimeEntries query, returns TimeEntry list oldest first
+func (s *Service) ListTimeEntries(jobId int64, taskId *string, userId *string, page *models.Page) ([]*models.TimeEntry, error) {
+	entries, err := s.repo.Time.ListTimeEntries(jobId, taskId, userId, page)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		entry.Minutes = entryMinutes(entryDuration(entry, now))
+	}
+	return entries, nil
+}
+
+// StartTimer
+// Takes Job, task id and user id as args, stops the users running timer on any other task and starts one on this task, returns TimeEntry
+func (s *Service) StartTimer(job *models.Job, taskId int64, userId int64) (*models.TimeEntry, error) {
+	_, err := s.GetTask(job.ID, taskId)
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("Task ID %d not found on job ID %d", taskId, job.ID))
+	}
+	running, _ := s.GetRunningTimer(userId)
+	if running != nil && running.Task == taskId {
+		return nil, fmt.Errorf("%w on task ID %d since %v", ErrTimerRunning, taskId, running.Started_at.Format(time.RFC3339))
+	}
+	entryId, err := s.repo.Time.StartTimer(job.ID, taskId, userId)
+	if err != nil || entryId == nil {
+		err = errors.Join(err, errors.New("No ID of new TimeEntry found"))
+		return nil, err
+	}
+	if running != nil {
+		stopped, err := s.repo.Time.GetTimeEntry(running.ID)
+		if err == nil {
+			stopped.Minutes = entryMinutes(entryDuration(stopped, time.Now()))
+			s.recordTimeEntry("edit", running, stopped)
+		}
+	}
+	entry, err := s.repo.Time.GetTimeEntry(*entryId)
+	if err != nil {
+		return nil, err
+	}
+	s.record("create", "time_entry", entry.ID, job.Vehicle, &job.ID, nil, entry)
+	return entry, nil
+}
+
+// StopTimer
+// Takes Job, task id and user id as args, stops the users running timer on this task, returns stopped TimeEntry
+func (s *Service) StopTimer(job *models.Job, taskId int64, userId int64) (*models.TimeEntry, error) {
+	running, _ := s.GetRunningTimer(userId)
+	if running == nil || running.Job != job.ID || running.Task != taskId {
+		return nil, fmt.Errorf("%w on task ID %d", ErrTimerStopped, taskId)
+	}
+	err := s.repo.Time.StopTimer(running.ID)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.repo.Time.GetTimeEntry(running.ID)
+	if err != nil {
+		return nil, err
+	}
+	entry.Minutes = entryMinutes(entryDuration(entry, time.Now()))
+	s.recordTimeEntry("edit", running, entry)
+	return entry, nil
+}
+
+// GetJobTime
+// Takes job id as arg, rolls time tracked on the job up per task and per user against the task estimates, returns JobTime
+func (s *Service) GetJobTime(jobId int64) (*models.JobTime, error) {
+	tasks, err := s.ListTasks(jobId, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.ListTimeEntries(jobId, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	// sum durations before rounding so short entries still add up
+	now := time.Now()
+	var total time.Duration
+	taskTime := map[int64]time.Duration{}
+	userTime := map[int64]time.Duration{}
+	var userIds []int64
+	jobTime := models.JobTime{
+		Job:   jobId,
+		Tasks: make([]models.TaskTime, 0, len(tasks)),
+		Users: make([]models.UserTime, 0),
+	}
+	for _, entry := range entries {
+		duration := entryDuration(entry, now)
+		total += duration
+		taskTime[entry.Task] += duration
+		if _, ok := userTime[entry.User]; !ok {
+			userIds = append(userIds, entry.User)
+		}
+		userTime[entry.User] += duration
+		if entry.Stopped_at == nil {
+			jobTime.Running++
+		}
+	}
+	jobTime.Actual_minutes = entryMinutes(total)
+	for _, task := range tasks {
+		if task.Estimated_minutes != nil {
+			jobTime.Estimated_minutes += *task.Estimated_minutes
+		}
+		jobTime.Tasks = append(jobTime.Tasks, models.TaskTime{
+			Task:              task.ID,
+			Name:              task.Name,
+			Estimated_minutes: task.Estimated_minutes,
+			Actual_minutes:    entryMinutes(taskTime[task.ID]),
+		})
+	}
+	for _, userId := range userIds {
+		jobTime.Users = append(jobTime.Users, models.UserTime{User: userId, Actual_minutes: entryMinutes(userTime[userId])})
+	}
+	return &jobTime, nil
+}
+
+// BuildEstimateReport
+// Takes optional job owner id and task completion date range as args, compares tracked time against estimates of completed tasks per label of their job, returns EstimateReport
+func (s *Service) BuildEstimateReport(userId *int64, from *time.Time, to *time.Time) (*models.EstimateReport, error) {
+	var userIdStr *string
+	if userId != nil {
+		idStr := strconv.FormatInt(*userId, 10)
+		userIdStr = &idStr
+	}
+	isTemplate := "0"
+	jobs, err := s.ListJobs(userIdStr, nil, &isTemplate, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	report := models.EstimateReport{
+		User:         userId,
+		From:         from,
+		To:           to,
+		Labels:       make([]models.EstimateAccuracy, 0),
+		Generated_at: time.Now().UTC(),
+	}
+	// accuracy by label id, jobs without labels are kept under 0
+	accuracy := map[int64]*models.EstimateAccuracy{}
+	now := time.Now()
+	isComplete := "1"
+	for _, job := range jobs {
+		tasks, err := s.ListTasks(job.ID, &isComplete, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		// only completed tasks with an estimate, to is inclusive of the whole day
+		var estimated []*models.Task
+		for _, task := range tasks {
+			if task.Estimated_minutes == nil || task.Completed_at == nil {
+				continue
+			}
+			if from != nil && task.Completed_at.Before(*from) {
+				continue
+			}
+			if to != nil && !task.Completed_at.Before(to.AddDate(0, 0, 1)) {
+				continue
+			}
+			estimated = append(estimated, task)
+		}
+		if len(estimated) == 0 {
+			continue
+		}
+		entries, err := s.ListTimeEntries(job.ID, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		taskTime := map[int64]time.Duration{}
+		for _, entry := range entries {
+			taskTime[entry.Task] += entryDuration(entry, now)
+		}
+		var estimatedMinutes int64
+		var actual time.Duration
+		for _, task := range estimated {
+			estimatedMinutes += *task.Estimated_minutes
+			actual += taskTime[task.ID]
+		}
+		labels := job.Labels
+		if len(labels) == 0 {
+			labels = []models.Label{{}}
+		}
+		for _, label := range labels {
+			labelAccuracy, ok := accuracy[label.ID]
+			if !ok {
+				labelAccuracy = &models.EstimateAccuracy{}
+				if label.ID != 0 {
+					jobLabel := label
+					labelAccuracy.Label = &jobLabel
+				}
+				accuracy[label.ID] = labelAccuracy
+			}
+			labelAccuracy.Jobs++
+			labelAccuracy.Tasks += len(estimated)
+			labelAccuracy.Estimated_minutes += estimatedMinutes
+			labelAccuracy.Actual_minutes += entryMinutes(actual)
+		}
+	}
+	for _, labelAccuracy := range accuracy {
+		if labelAccuracy.Estimated_minutes > 0 {
+			ratio := math.Round(float64(labelAccuracy.Actual_minutes)/float64(labelAccuracy.Estimated_minutes)*100) / 100
+			labelAccuracy.Ratio = &ratio
+		}
+		report.Labels = append(report.Labels, *labelAccuracy)
+	}
+	// by label name, jobs without labels last
+	sort.Slice(report.Labels, func(i, j int) bool {
+		a, b := report.Labels[i].Label, report.Labels[j].Label
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+	return &report, nil
+}
+
+// recordTimeEntry
+// Records a change to a time entry, scoped to its job and the jobs vehicle
+func (s *Service) recordTimeEntry(action string, before *models.TimeEntry, after *models.TimeEntry) {
+	var vehicleId *int64
+	if job, err := s.repo.Jobs.GetJob(after.Job); err == nil {
+		vehicleId = job.Vehicle
+	}
+	s.record(action, "time_entry", after.ID, vehicleId, &after.Job, before, after)
+}
+
+// entryDuration
+// Takes TimeEntry and current time as args, returns how long it ran, running timers count up to now
+func entryDuration(entry *models.TimeEntry, now time.Time) time.Duration {
+	stoppedAt := now
+	if entry.Stopped_at != nil {
+		stoppedAt = *entry.Stopped_at
+	}
+	if stoppedAt.Before(entry.Started_at) {
+		return 0
+	}
+	return stoppedAt.Sub(entry.Started_at)
+}
+
+// entryMinutes
+// Takes duration as arg, returns it in whole minutes rounded to the nearest
+func entryMinutes(duration time.Duration) int64 {
+	return int64(math.Round(duration.Minutes()))
+}
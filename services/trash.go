@@ -0,0 +1,63 @@
+package services
+
+import (
+	"time"
+
+	"github.com/okdv/wrench-turn/models"
+)
+
+// ListTrash
+// Takes optional owner id as arg, passes to ListTrash query, returns TrashItem list newest first
+func (s *Service) ListTrash(userId *string) ([]*models.TrashItem, error) {
+	items, err := s.repo.Trash.ListTrash(userId)
+	return items, err
+}
+
+// RestoreJob
+// Takes job id and optional owner id as args, passes to RestoreJob query, returns restored Job
+func (s *Service) RestoreJob(jobId int64, userId *int64) (*models.Job, error) {
+	err := s.repo.Trash.RestoreJob(jobId, userId)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.GetJob(jobId)
+	if err == nil {
+		s.recordJob("restore", job, nil, job)
+	}
+	return job, err
+}
+
+// RestoreVehicle
+// Takes vehicle id and optional owner id as args, passes to RestoreVehicle query, returns restored Vehicle
+func (s *Service) RestoreVehicle(vehicleId int64, userId *int64) (*models.Vehicle, error) {
+	err := s.repo.Trash.RestoreVehicle(vehicleId, userId)
+	if err != nil {
+		return nil, err
+	}
+	vehicle, err := s.GetVehicle(vehicleId)
+	if err == nil {
+		s.record("restore", "vehicle", vehicle.ID, &vehicle.ID, nil, nil, vehicle)
+	}
+	return vehicle, err
+}
+
+// RestoreUser
+// Takes user id as arg, passes to RestoreUser query, returns restored User
+func (s *Service) RestoreUser(userId int64) (*models.User, error) {
+	err := s.repo.Trash.RestoreUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.GetUserById(userId)
+	if err == nil {
+		s.record("restore", "user", user.ID, nil, nil, nil, user)
+	}
+	return user, err
+}
+
+// PurgeTrash
+// Takes retention in days as arg, permanently deletes everything trashed longer ago, returns number of jobs, vehicles and users deleted
+func (s *Service) PurgeTrash(days int) (int64, error) {
+	purged, err := s.repo.Trash.PurgeTrash(time.Now().AddDate(0, 0, -days))
+	return purged, err
+}
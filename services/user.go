@@ -1,77 +1,101 @@
 package services
 
 import (
-	"github.com/okdv/wrench-turn/db"
 	"github.com/okdv/wrench-turn/models"
 	"github.com/okdv/wrench-turn/utils"
+	"time"
 )
 
 // CreateUser
 // Takes NewUser as arg, validates and prepares it for db, inserts into user table
-func CreateUser(newUser models.NewUser) (*models.User, error) {
+func (s *Service) CreateUser(newUser models.NewUser) (*models.User, error) {
 	// validate and generate hashed pw
 	hashed, err := utils.ValidateAndHashPassword(newUser.Password)
 	if err != nil {
 		return nil, err
 	}
 	// insert into db, return userID
-	userId, err := db.CreateUser(newUser, hashed)
+	userId, err := s.repo.Users.CreateUser(newUser, hashed)
 	if err != nil {
 		return nil, err
 	}
 	// retrieve User from db by userID, return User
-	user, err := GetUserById(*userId)
+	user, err := s.GetUserById(*userId)
+	if err == nil {
+		s.record("create", "user", user.ID, nil, nil, nil, user)
+	}
 	return user, err
 }
 
 // ListUsers
-// Takes URL query params as args, passes to ListUsers query, returns User list
-func ListUsers(jobId *string, vehicleId *string, isAdmin *string, searchStr *string, sort *string) ([]*models.User, error) {
-	users, err := db.ListUsers(jobId, vehicleId, isAdmin, searchStr, sort)
+// Takes URL query params and optional Page as args, passes to ListUsers query, returns User list
+func (s *Service) ListUsers(jobId *string, vehicleId *string, isAdmin *string, searchStr *string, sort *string, page *models.Page) ([]*models.User, error) {
+	users, err := s.repo.Users.ListUsers(jobId, vehicleId, isAdmin, searchStr, sort, page)
 	return users, err
 }
 
 // GetUserByUsername
 // Takes username as arg, passes to GetUserByUsername query, returns User
-func GetUserByUsername(username string) (*models.User, error) {
-	user, err := db.GetUserByUsername(username)
+func (s *Service) GetUserByUsername(username string) (*models.User, error) {
+	user, err := s.repo.Users.GetUserByUsername(username)
 	return user, err
 }
 
 // GetUserById
 // Takes userID as arg, passes to GetUserById query, returns User
-func GetUserById(userID int64) (*models.User, error) {
-	user, err := db.GetUserById(userID)
+func (s *Service) GetUserById(userID int64) (*models.User, error) {
+	user, err := s.repo.Users.GetUserById(userID)
 	return user, err
 }
 
 // DeleteUser
-// Takes username as arg, passes to DeleteUser query
-func DeleteUser(username string) error {
-	err := db.DeleteUser(username)
-	return err
+// Takes username as arg, passes to TrashUser query, moving the user to the trash with their vehicles, jobs, alerts, documents and labels
+func (s *Service) DeleteUser(username string) error {
+	user, err := s.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Trash.TrashUser(username)
+	if err != nil {
+		return err
+	}
+	// revoke users calendar feed, most users never create one, it is not brought back by a restore
+	s.repo.Calendar.DeleteCalendarToken(user.ID)
+	s.record("delete", "user", user.ID, nil, nil, user, nil)
+	return nil
 }
 
 // EditUser
-// Takes User as arg, passes to EditUser query, returns updated User
-func EditUser(editedUser models.User) (*models.User, error) {
-	err := db.EditUser(editedUser)
+// Takes User and the updated_at it was read at, nil to skip the check, passes to EditUser query, returns updated User
+func (s *Service) EditUser(editedUser models.User, revision *time.Time) (*models.User, error) {
+	currentUser, _ := s.GetUserById(editedUser.ID)
+	err := s.repo.Users.EditUser(editedUser, revision)
 	if err != nil {
 		return nil, err
 	}
-	user, err := GetUserById(editedUser.ID)
+	user, err := s.GetUserById(editedUser.ID)
+	if err == nil {
+		s.record("edit", "user", user.ID, nil, nil, currentUser, user)
+	}
 	return user, err
 }
 
 // UpdatePassword
 // Take Passwords as arg, process it, pass to UpdatePassword query
-func UpdatePassword(username string, newPassword *string) error {
+func (s *Service) UpdatePassword(username string, newPassword *string) error {
 	// validate and generate hashed pw
 	hashed, err := utils.ValidateAndHashPassword(newPassword)
 	if err != nil {
 		return err
 	}
 	// call db query
-	err = db.UpdatePassword(username, hashed)
-	return err
+	err = s.repo.Users.UpdatePassword(username, hashed)
+	if err != nil {
+		return err
+	}
+	// only note that the password changed, never its hash
+	if user, err := s.GetUserByUsername(username); err == nil {
+		s.record("edit", "user", user.ID, nil, nil, map[string]any{"password": "old"}, map[string]any{"password": "new"})
+	}
+	return nil
 }
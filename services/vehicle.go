@@ -2,91 +2,91 @@ package services
 
 import (
 	"errors"
-	"log"
-	"strconv"
+	"time"
 
-	"github.com/okdv/wrench-turn/db"
 	"github.com/okdv/wrench-turn/models"
 )
 
 // GetVehicle
 // Takes id as arg, passes to db query, returns Job
-func GetVehicle(vehicleId int64) (*models.Vehicle, error) {
-	vehicle, err := db.GetVehicle(vehicleId)
+func (s *Service) GetVehicle(vehicleId int64) (*models.Vehicle, error) {
+	vehicle, err := s.repo.Vehicles.GetVehicle(vehicleId)
 	return vehicle, err
 }
 
 // ListVehicles
-// Takes URL query params as args, passes to ListVehicles query, returns Vehicle list
-func ListVehicles(userId *string, jobId *string, searchStr *string, sort *string) ([]*models.Vehicle, error) {
-	vehicles, err := db.ListVehicles(userId, jobId, searchStr, sort)
+// Takes URL query params and optional Page as args, passes to ListVehicles query, returns Vehicle list
+func (s *Service) ListVehicles(userId *string, jobId *string, searchStr *string, sort *string, page *models.Page) ([]*models.Vehicle, error) {
+	vehicles, err := s.repo.Vehicles.ListVehicles(userId, jobId, searchStr, sort, page)
 	return vehicles, err
 }
 
 // CreateVehicle
 // Takes newVehicle as arg, passes to db query, calls GetVehicle, returns Vehicle
-func CreateVehicle(newVehicle models.NewVehicle) (*models.Vehicle, error) {
+func (s *Service) CreateVehicle(newVehicle models.NewVehicle) (*models.Vehicle, error) {
 	// set default values
 	defaultBool := 0
 	if newVehicle.Is_metric == nil {
 		newVehicle.Is_metric = &defaultBool
 	}
 	// pass to db query, return new Vehicles id
-	vehicleId, err := db.CreateVehicle(newVehicle)
+	vehicleId, err := s.repo.Vehicles.CreateVehicle(newVehicle)
 	if err != nil || vehicleId == nil {
 		err = errors.Join(err, errors.New("No ID of new Vehicle found"))
 		return nil, err
 	}
 	// pass to GetVehicle, return Vehicle
-	vehicle, err := GetVehicle(*vehicleId)
+	vehicle, err := s.GetVehicle(*vehicleId)
+	if err == nil {
+		s.record("create", "vehicle", vehicle.ID, &vehicle.ID, nil, nil, vehicle)
+	}
 	return vehicle, err
 }
 
 // EditVehicle
-// Takes User as arg, passes to EditVehicle query, returns updated User
-func EditVehicle(editedVehicle models.Vehicle) (*models.Vehicle, error) {
-	err := db.EditVehicle(editedVehicle)
+// Takes Vehicle and the updated_at it was read at, nil to skip the check, passes to EditVehicle query, returns updated Vehicle
+func (s *Service) EditVehicle(editedVehicle models.Vehicle, revision *time.Time) (*models.Vehicle, error) {
+	currentVehicle, _ := s.GetVehicle(editedVehicle.ID)
+	err := s.repo.Vehicles.EditVehicle(editedVehicle, revision)
 	if err != nil {
 		return nil, err
 	}
-	vehicle, err := GetVehicle(editedVehicle.ID)
+	vehicle, err := s.GetVehicle(editedVehicle.ID)
+	if err == nil {
+		s.record("edit", "vehicle", vehicle.ID, &vehicle.ID, nil, currentVehicle, vehicle)
+	}
 	return vehicle, err
 }
 
-// DeleteVehicle
-// Takes vehicle id as arg, passes to DeleteVehicle query
-func DeleteVehicle(vehicleId int64, userId *int64) error {
-	vehicleIdStr := strconv.FormatInt(vehicleId, 10)
-	// get vehicles jobs
-	jobs, err := ListJobs(nil, &vehicleIdStr, nil, nil, nil, nil, nil)
-	if err != nil {
-		log.Printf("Could not get vehicles jobs: %v", err)
-	}
-	// if there are jobs, delete them
-	if len(jobs) > 0 {
-		// loop through jobs
-		for i := 0; i < len(jobs); i++ {
-			err = DeleteJob(jobs[i].ID, nil)
-			if err != nil {
-				log.Printf("Could not delete job ID %d: %v", jobs[i].ID, err)
-			}
+// AssignVehicleLabel
+// Takes vehicle id, label id, creates an entry into vehicle_label if assigning, otherwise deletes any existing entry
+func (s *Service) AssignVehicleLabel(vehicleId int64, labelId int64, assign int) (*int64, error) {
+	change := map[string]any{"label": labelId}
+	// if assigning, call that query and return
+	if assign == 1 {
+		relationshipId, err := s.repo.Vehicles.AssignVehicleLabel(vehicleId, labelId)
+		if err == nil {
+			s.record("assign", "vehicle", vehicleId, &vehicleId, nil, nil, change)
 		}
+		return relationshipId, err
 	}
-	// get vehicles alerts
-	alerts, err := ListAlerts(nil, &vehicleIdStr, nil, nil, nil, nil, nil, nil, nil)
-	if err != nil {
-		log.Printf("Could not get vehicles alerts: %v", err)
+	// otherwise call unassign query
+	err := s.repo.Vehicles.UnassignVehicleLabel(vehicleId, labelId)
+	if err == nil {
+		s.record("unassign", "vehicle", vehicleId, &vehicleId, nil, change, nil)
 	}
-	// if there are alerts, delete them
-	if len(alerts) > 0 {
-		// loop through alerts
-		for i := 0; i < len(alerts); i++ {
-			err = DeleteAlert(alerts[i].ID, userId)
-			if err != nil {
-				log.Printf("Could not delete vehicle alert ID %d: %v", alerts[i].ID, err)
-			}
-		}
+	return nil, err
+}
+
+// DeleteVehicle
+// Takes vehicle id as arg, passes to TrashVehicle query, moving the vehicle with its jobs, alerts and documents to the trash
+func (s *Service) DeleteVehicle(vehicleId int64, userId *int64) error {
+	// keep vehicle for audit log
+	vehicle, _ := s.GetVehicle(vehicleId)
+	err := s.repo.Trash.TrashVehicle(vehicleId, userId)
+	if err != nil {
+		return err
 	}
-	err = db.DeleteVehicle(vehicleId, userId)
-	return err
+	s.record("delete", "vehicle", vehicleId, &vehicleId, nil, vehicle, nil)
+	return nil
 }
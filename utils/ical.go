@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// iCalendar content lines may be at most 75 octets before folding
+const icalLineLength = 75
+
+// Calendar util builds an iCalendar (RFC 5545) document with CRLF line endings and folded long lines
+type Calendar struct {
+	buf bytes.Buffer
+}
+
+// NewCalendar util returns a Calendar with the VCALENDAR header written, name is shown by clients subscribing to it
+func NewCalendar(prodId string, name string) *Calendar {
+	c := &Calendar{}
+	c.Begin("VCALENDAR")
+	c.Property("VERSION", "2.0")
+	c.Property("PRODID", prodId)
+	c.Property("CALSCALE", "GREGORIAN")
+	c.Text("X-WR-CALNAME", name)
+	return c
+}
+
+// Begin util opens a component, e.g. VEVENT or VTODO
+func (c *Calendar) Begin(component string) {
+	c.Property("BEGIN", component)
+}
+
+// End util closes a component
+func (c *Calendar) End(component string) {
+	c.Property("END", component)
+}
+
+// Property util writes a property with a value that is already in iCalendar format
+func (c *Calendar) Property(name string, value string) {
+	line := name + ":" + value
+	// fold long lines, continuation lines start with a space, never split a multi byte character
+	for len(line) > icalLineLength {
+		cut := icalLineLength
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		c.buf.WriteString(line[:cut] + "\r\n")
+		line = " " + line[cut:]
+	}
+	c.buf.WriteString(line + "\r\n")
+}
+
+// Text util writes a property with a text value, escaping it
+func (c *Calendar) Text(name string, value string) {
+	c.Property(name, icalEscape(value))
+}
+
+// Time util writes a property with a UTC date-time value
+func (c *Calendar) Time(name string, t time.Time) {
+	c.Property(name, t.UTC().Format("20060102T150405Z"))
+}
+
+// Categories util writes a CATEGORIES property, escaping each value
+func (c *Calendar) Categories(values []string) {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = icalEscape(value)
+	}
+	c.Property("CATEGORIES", strings.Join(escaped, ","))
+}
+
+// Bytes util closes the VCALENDAR and returns the document
+func (c *Calendar) Bytes() []byte {
+	c.End("VCALENDAR")
+	return c.buf.Bytes()
+}
+
+// icalEscape escapes backslashes, separators and newlines in text values
+func icalEscape(text string) string {
+	return strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+		"\r", "",
+	).Replace(text)
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// page layout in PDF points, US letter
+const (
+	pdfPageWidth  = 612
+	pdfPageHeight = 792
+	pdfMargin     = 50
+)
+
+// PDF util builds a simple multi page text document using the standard Helvetica fonts, so no fonts or dependencies are needed
+type PDF struct {
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+	y       float64
+}
+
+// NewPDF util returns an empty PDF with a first page ready to write to
+func NewPDF() *PDF {
+	p := &PDF{}
+	p.addPage()
+	return p
+}
+
+// addPage starts a new page and moves the cursor to its top
+func (p *PDF) addPage() {
+	p.current = &bytes.Buffer{}
+	p.pages = append(p.pages, p.current)
+	p.y = pdfPageHeight - pdfMargin
+}
+
+// Text util writes text at the given font size and indent, wrapping long lines and breaking pages as needed
+func (p *PDF) Text(text string, size float64, bold bool, indent float64) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	lineHeight := size * 1.3
+	// approximate Helvetica glyphs as half the font size wide to decide where to wrap
+	maxChars := int((pdfPageWidth - 2*pdfMargin - indent) / (size * 0.5))
+	for _, line := range wrapText(text, maxChars) {
+		if p.y-lineHeight < pdfMargin {
+			p.addPage()
+		}
+		p.y -= lineHeight
+		fmt.Fprintf(p.current, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, size, pdfMargin+indent, p.y, pdfEscape(line))
+	}
+}
+
+// Space util moves the cursor down by the given height
+func (p *PDF) Space(height float64) {
+	p.y -= height
+	if p.y < pdfMargin {
+		p.addPage()
+	}
+}
+
+// Rule util draws a horizontal line across the page
+func (p *PDF) Rule() {
+	p.Space(4)
+	fmt.Fprintf(p.current, "0.5 w %d %.1f m %d %.1f l S\n", pdfMargin, p.y, pdfPageWidth-pdfMargin, p.y)
+	p.Space(4)
+}
+
+// Bytes util assembles pages, fonts and cross reference table into a PDF file
+func (p *PDF) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	// writes the next numbered object and records where it starts
+	writeObj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	out.WriteString("%PDF-1.4\n")
+	// objects 1-4 are catalog, page tree and fonts, each page is then a page and content stream pair starting at 5
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	writeObj("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range p.pages {
+		writeObj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, 6+i*2))
+		writeObj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+	// cross reference table, entries must be exactly 20 bytes
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// wrapText splits text into lines of at most maxChars, breaking on spaces where possible
+func wrapText(text string, maxChars int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			// hard break words longer than a line
+			for len([]rune(word)) > maxChars {
+				if len(line) > 0 {
+					lines = append(lines, line)
+					line = ""
+				}
+				lines = append(lines, string([]rune(word)[:maxChars]))
+				word = string([]rune(word)[maxChars:])
+			}
+			if len(line) == 0 {
+				line = word
+			} else if len([]rune(line))+1+len([]rune(word)) <= maxChars {
+				line = line + " " + word
+			} else {
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfEscape encodes text as a PDF string literal, characters outside Latin-1 are replaced with ?
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r < 32 || (r >= 127 && r < 160):
+			b.WriteByte(' ')
+		case r < 256:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"errors"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -53,3 +54,20 @@ func ValidateAndHashPassword(password *string) (*[]byte, error) {
 	// return nil for all if password nil, prevent rewrites elsewhere for null password support
 	return nil, nil
 }
+
+// AddTimeInterval util takes a time, interval and interval unit (hour, day, week, month, year), returns time with interval added
+func AddTimeInterval(t time.Time, interval int64, unit string) (time.Time, error) {
+	switch unit {
+	case "hour":
+		return t.Add(time.Duration(interval) * time.Hour), nil
+	case "day":
+		return t.AddDate(0, 0, int(interval)), nil
+	case "week":
+		return t.AddDate(0, 0, int(interval)*7), nil
+	case "month":
+		return t.AddDate(0, int(interval), 0), nil
+	case "year":
+		return t.AddDate(int(interval), 0, 0), nil
+	}
+	return t, errors.New("Time interval unit must be hour, day, week, month or year")
+}
@@ -0,0 +1,208 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	hexColorRe = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	alphanumRe = regexp.MustCompile(`^[0-9a-zA-Z]*$`)
+	timeType   = reflect.TypeOf(time.Time{})
+)
+
+// FieldError
+// Problem with a single field, Field is its JSON path, e.g. items[0].name
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors
+// Every problem found while validating a value
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + " " + fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Struct
+// Takes struct or pointer to one as arg, checks fields against their validate tags, returns Errors or nil
+//
+// Rules are comma separated, nil pointers and empty strings only fail required:
+//   - required: not nil, not blank, not empty
+//   - min=N, max=N: bounds of numbers, or length of strings and slices
+//   - oneof=a b c: one of the space separated values
+//   - hexcolor, email, url, alphanum: format of strings
+//
+// Nested structs and slices of structs are validated too
+func Struct(v any) error {
+	var errs Errors
+	checkStruct(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkStruct validates each field of struct value, prefixing field names with path
+func checkStruct(v reflect.Value, path string, errs *Errors) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || v.Type() == timeType {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		if len(path) > 0 {
+			name = path + "." + name
+		}
+		checkField(v.Field(i), name, field.Tag.Get("validate"), errs)
+	}
+}
+
+// checkField applies rules to a single field, then descends into it
+func checkField(v reflect.Value, name string, tag string, errs *Errors) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Field: name, Message: fmt.Sprintf(format, args...)})
+	}
+	rules := strings.Split(tag, ",")
+	// nil pointers and empty strings are only checked for required
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if hasRule(rules, "required") {
+				fail("is required")
+			}
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String && len(strings.TrimSpace(v.String())) == 0 {
+		if hasRule(rules, "required") {
+			fail("is required")
+		}
+		return
+	}
+	for _, rule := range rules {
+		key, arg, _ := strings.Cut(rule, "=")
+		switch key {
+		case "":
+		case "required":
+			if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 {
+				fail("is required")
+				return
+			}
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic("validate: invalid " + key + " rule on " + name)
+			}
+			size, unit := measure(v)
+			if key == "min" && size < limit {
+				fail("must be at least %v%s", arg, unit)
+			}
+			if key == "max" && size > limit {
+				fail("must be at most %v%s", arg, unit)
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			value := fmt.Sprint(v.Interface())
+			found := false
+			for _, option := range options {
+				if option == value {
+					found = true
+				}
+			}
+			if !found {
+				fail("must be one of %s", strings.Join(options, ", "))
+			}
+		case "hexcolor":
+			if !hexColorRe.MatchString(v.String()) {
+				fail("must be a hex color, e.g. #ff0000")
+			}
+		case "email":
+			if addr, err := mail.ParseAddress(v.String()); err != nil || addr.Address != v.String() {
+				fail("must be an email address")
+			}
+		case "url":
+			if u, err := url.Parse(v.String()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+				fail("must be an http or https url")
+			}
+		case "alphanum":
+			if !alphanumRe.MatchString(v.String()) {
+				fail("must only contain letters and numbers")
+			}
+		default:
+			panic("validate: unknown rule " + key + " on " + name)
+		}
+	}
+	// descend into nested structs and slices of them
+	switch v.Kind() {
+	case reflect.Struct:
+		checkStruct(v, name, errs)
+	case reflect.Slice:
+		elem := v.Type().Elem()
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			checkStruct(v.Index(i), name+"["+strconv.Itoa(i)+"]", errs)
+		}
+	}
+}
+
+// measure returns value of numbers, or length of strings and slices along with its unit
+func measure(v reflect.Value) (float64, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Map:
+		return float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), ""
+	}
+	panic("validate: min and max do not apply to " + v.Kind().String())
+}
+
+// hasRule returns whether rules contain rule
+func hasRule(rules []string, rule string) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}